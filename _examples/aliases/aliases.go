@@ -0,0 +1,50 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package aliases tests the wrapping of type aliases.
+package aliases
+
+import "bytes"
+
+// Point is a point of the plane.
+type Point struct {
+	X, Y int
+}
+
+// Pt is an alias of Point.
+type Pt = Point
+
+// Buffer re-exports bytes.Buffer.
+type Buffer = bytes.Buffer
+
+// Norm1 returns the manhattan norm of p.
+func Norm1(p Pt) int {
+	return abs(p.X) + abs(p.Y)
+}
+
+// Scale returns p scaled by k.
+func Scale(p *Pt, k int) Pt {
+	return Pt{p.X * k, p.Y * k}
+}
+
+// NewBuffer returns a buffer holding s.
+func NewBuffer(s string) *Buffer {
+	return bytes.NewBufferString(s)
+}
+
+// Points returns n points on the diagonal.
+func Points(n int) []Pt {
+	ps := make([]Pt, n)
+	for i := range ps {
+		ps[i] = Pt{i, i}
+	}
+	return ps
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,26 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import aliases
+
+print("aliases.Pt is aliases.Point:", aliases.Pt is aliases.Point)
+
+p = aliases.Pt(X=3, Y=-4)
+print("type(p):", type(p).__name__)
+print("aliases.Norm1(p):", aliases.Norm1(p))
+print("aliases.Norm1(aliases.Point(X=1, Y=2)):", aliases.Norm1(aliases.Point(X=1, Y=2)))
+
+q = aliases.Scale(aliases.Pt(X=3, Y=4), 2)
+print("q:", q.X, q.Y)
+print("isinstance(q, aliases.Pt):", isinstance(q, aliases.Pt))
+
+ps = aliases.Points(3)
+print("points:", [(v.X, v.Y) for v in ps])
+
+b = aliases.NewBuffer("hello")
+print("isinstance(b, aliases.Buffer):", isinstance(b, aliases.Buffer))
+print("b.Len():", b.Len())
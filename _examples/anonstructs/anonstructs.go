@@ -0,0 +1,60 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package anonstructs tests the wrapping of anonymous structs.
+package anonstructs
+
+// Point is a named struct, used as a field of anonymous structs.
+type Point struct {
+	X, Y int
+}
+
+// Stat returns the size and name of a file.
+func Stat() struct {
+	Size int64
+	Name string
+} {
+	return struct {
+		Size int64
+		Name string
+	}{Size: 42, Name: "file.txt"}
+}
+
+// Lstat returns the size and name of a link.
+func Lstat() struct {
+	Size int64
+	Name string
+} {
+	return struct {
+		Size int64
+		Name string
+	}{Size: 7, Name: "link"}
+}
+
+// Describe describes st.
+func Describe(st struct {
+	Size int64
+	Name string
+}) string {
+	return st.Name
+}
+
+// Segment returns a segment, with nested anonymous structs.
+func Segment() struct {
+	From, To Point
+	Meta     struct{ Label string }
+} {
+	s := struct {
+		From, To Point
+		Meta     struct{ Label string }
+	}{From: Point{1, 2}, To: Point{3, 4}}
+	s.Meta.Label = "seg"
+	return s
+}
+
+// Opaque returns an anonymous struct with an unexported field, which can
+// not be wrapped.
+func Opaque() struct{ n int } {
+	return struct{ n int }{}
+}
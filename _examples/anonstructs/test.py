@@ -0,0 +1,29 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import anonstructs
+
+st = anonstructs.Stat()
+print("type(st) = %s" % (type(st).__name__,))
+print("doc(st) = %r" % (type(st).__doc__,))
+print("st.Size, st.Name = %s, %s" % (st.Size, st.Name))
+
+lst = anonstructs.Lstat()
+print("type(lst) is type(st) = %s" % (type(lst) is type(st),))
+print("anonstructs.Describe(lst) = %s" % (anonstructs.Describe(lst),))
+
+try:
+    st.Size = 0
+except Exception as err:
+    print("caught: %s" % (type(err).__name__,))
+
+seg = anonstructs.Segment()
+print("type(seg) = %s" % (type(seg).__name__,))
+print("seg.From.X, seg.To.Y = %s, %s" % (seg.From.X, seg.To.Y))
+print("seg.Meta.Label = %s" % (seg.Meta.Label,))
+
+print("hasattr(anonstructs, 'Opaque') = %s" % (hasattr(anonstructs, 'Opaque'),))
@@ -0,0 +1,69 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package anys tests the dynamic conversion of interface{} values.
+package anys
+
+import (
+	"fmt"
+	"math"
+)
+
+// Point is a wrapped type, passed by handle through interface{} values.
+type Point struct {
+	X, Y int
+}
+
+// Describe returns the value and the go type of v.
+func Describe(v interface{}) string {
+	return fmt.Sprintf("%v (%T)", v, v)
+}
+
+// Identity returns v.
+func Identity(v interface{}) interface{} {
+	return v
+}
+
+// Origin returns a Point value as an interface{}.
+func Origin() interface{} {
+	return Point{}
+}
+
+// Same returns whether a and b hold the same go value.
+func Same(a, b interface{}) bool {
+	return a == b
+}
+
+// Move shifts the Point held by v, and returns it.
+func Move(v interface{}, dx, dy int) interface{} {
+	p := v.(*Point)
+	p.X += dx
+	p.Y += dy
+	return p
+}
+
+// Unsupported returns a value of a type python has no conversion for, by
+// kind: complex, map (with int keys), chan, func or list (holding a
+// complex).
+func Unsupported(kind string) interface{} {
+	switch kind {
+	case "complex":
+		return complex(1, 2)
+	case "map":
+		return map[int]string{1: "one"}
+	case "chan":
+		return make(chan int)
+	case "func":
+		return func() {}
+	case "list":
+		return []interface{}{1, complex(1, 2), "x"}
+	}
+	return nil
+}
+
+// Uints returns unsigned integers as interface{} values, up to the largest
+// uint64.
+func Uints() interface{} {
+	return []interface{}{uint8(255), uint64(math.MaxInt64), uint64(1) << 63, uint64(math.MaxUint64)}
+}
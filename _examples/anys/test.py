@@ -0,0 +1,57 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import anys
+
+for v in [None, True, 42, 2**40, 1.5, "str", u'\xe9', [1, "a"], (2.5,), {'k': [None]}]:
+    print("anys.Describe(%r) = %s" % (v, anys.Describe(v)))
+
+for v in [None, False, 42, 1.5, "str", [1, [2]], {'k': {'v': 1}}]:
+    print("anys.Identity(%r) = %r" % (v, anys.Identity(v)))
+
+print("p = anys.Origin()")
+p = anys.Origin()
+print("type(p) = %s" % (type(p).__name__,))
+print("anys.Describe(p) = %s" % (anys.Describe(p),))
+
+print("q = anys.Identity(p)")
+q = anys.Identity(p)
+print("type(q) = %s" % (type(q).__name__,))
+print("anys.Same(p, q) = %s" % (anys.Same(p, q),))
+print("anys.Same(p, anys.Origin()) = %s" % (anys.Same(p, anys.Origin()),))
+
+print("anys.Move(p, 1, 2)")
+r = anys.Move(p, 1, 2)
+print("p.X, p.Y = %d, %d" % (p.X, p.Y))
+print("q.X, q.Y = %d, %d" % (q.X, q.Y))
+print("r.X, r.Y = %d, %d" % (r.X, r.Y))
+
+print("l = anys.Identity([p, {'p': p}])")
+l = anys.Identity([p, {'p': p}])
+print("anys.Same(l[0], p) = %s" % (anys.Same(l[0], p),))
+print("anys.Same(l[1]['p'], p) = %s" % (anys.Same(l[1]['p'], p),))
+
+try:
+    print("anys.Describe(object())")
+    anys.Describe(object())
+except TypeError as err:
+    print("caught: %s" % (err,))
+
+try:
+    print("anys.Describe(2**70)")
+    anys.Describe(2**70)
+except OverflowError as err:
+    print("caught: %s" % (err,))
+
+for kind in ["complex", "map", "chan", "func", "list"]:
+    try:
+        print("anys.Unsupported(%r)" % (kind,))
+        anys.Unsupported(kind)
+    except TypeError as err:
+        print("caught: %s" % (err,))
+
+print("anys.Uints() = %s" % (anys.Uints(),))
@@ -0,0 +1,57 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package arrays tests arrays of structs and multi-dimensional arrays.
+package arrays
+
+// Point is a point of the plane.
+type Point struct {
+	X, Y float64
+}
+
+// Triangle is an array of structs.
+type Triangle [3]Point
+
+// Centroid returns the centroid of t.
+func (t *Triangle) Centroid() Point {
+	var c Point
+	for _, p := range t {
+		c.X += p.X / 3
+		c.Y += p.Y / 3
+	}
+	return c
+}
+
+// Matrix is a two-dimensional array.
+type Matrix [3][3]float64
+
+// Identity returns the identity matrix.
+func Identity() Matrix {
+	var m Matrix
+	for i := range m {
+		m[i][i] = 1
+	}
+	return m
+}
+
+// Trace returns the sum of the diagonal of m.
+func (m *Matrix) Trace() float64 {
+	return m[0][0] + m[1][1] + m[2][2]
+}
+
+// Cube is a three-dimensional array.
+type Cube [2][2][2]int
+
+// Sum returns the sum of the items of c.
+func (c *Cube) Sum() int {
+	sum := 0
+	for _, plane := range c {
+		for _, row := range plane {
+			for _, v := range row {
+				sum += v
+			}
+		}
+	}
+	return sum
+}
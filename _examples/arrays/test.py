@@ -0,0 +1,45 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import arrays
+
+m = arrays.Identity()
+print("m = %s" % (m,))
+print("len(m) = %d, len(m[1]) = %d" % (len(m), len(m[1])))
+print("type(m[1]) = %s" % (type(m[1]).__name__,))
+
+m[1][2] = 5.0
+print("m[1][2] = %s" % (m[1][2],))
+print("m = %s" % (m,))
+
+row = m[2]
+row[2] = 3.0
+print("m.Trace() = %s" % (m.Trace(),))
+
+m[0] = m[1]
+m[1][1] = 7.0
+print("m[0][1] = %s, m[1][1] = %s" % (m[0][1], m[1][1]))
+
+try:
+    m[3]
+except IndexError as err:
+    print("caught: %s" % (err,))
+
+t = arrays.Triangle()
+t[1].X = 3.0
+t[2].Y = 6.0
+print("t.Centroid() = %s" % (t.Centroid(),))
+print("[p.X for p in t] = %s" % ([p.X for p in t],))
+
+t[0] = arrays.Point(X=3.0, Y=3.0)
+print("t[0] = %s" % (t[0],))
+
+c = arrays.Cube()
+c[1][0][1] = 4
+c[0][1][0] = 2
+print("c.Sum() = %d" % (c.Sum(),))
+print("c = %s" % (c,))
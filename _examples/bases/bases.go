@@ -0,0 +1,36 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package bases tests the python base classes of wrapped types.
+package bases
+
+import "fmt"
+
+// Ints is a python collections.Sequence, with its index and count mixin
+// methods.
+//
+//gopy:base collections.Sequence
+type Ints []int
+
+// Point is a point on a grid, described by the mixin of the python module
+// mixins.
+//
+//gopy:base mixins.Describer
+type Point struct {
+	X, Y int
+}
+
+// String returns the coordinates of p.
+func (p Point) String() string {
+	return fmt.Sprintf("(%d, %d)", p.X, p.Y)
+}
+
+// Squares returns the n first squares.
+func Squares(n int) Ints {
+	s := make(Ints, n)
+	for i := range s {
+		s[i] = i * i
+	}
+	return s
+}
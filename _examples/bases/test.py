@@ -0,0 +1,59 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import collections
+import gc
+import sys
+import types
+import weakref
+
+# the mixins module is imported by bases, when imported.
+mixins = types.ModuleType("mixins")
+
+class Describer(object):
+    def describe(self):
+        return "%s at %s" % (type(self).__name__, self)
+
+mixins.Describer = Describer
+sys.modules["mixins"] = mixins
+
+import bases
+
+print("bases.Ints.__bases__ = %s" % (bases.Ints.__bases__,))
+s = bases.Squares(4)
+print("s = %s" % (s,))
+print("isinstance(s, collections.Sequence) = %s" % (isinstance(s, collections.Sequence),))
+print("s.index(9) = %d" % (s.index(9),))
+print("s.count(4) = %d" % (s.count(4),))
+print("4 in s = %s, 5 in s = %s" % (4 in s, 5 in s))
+print("list(reversed(s)) = %s" % (list(reversed(s)),))
+
+p = bases.Point(X=1, Y=2)
+print("isinstance(p, mixins.Describer) = %s" % (isinstance(p, mixins.Describer),))
+print("p.describe() = %s" % (p.describe(),))
+p.label = "origin"
+print("p.label = %s" % (p.label,))
+r = weakref.ref(p)
+print("r() is p: %s" % (r() is p,))
+del p
+gc.collect()
+print("r() = %s" % (r(),))
+
+class Point3(bases.Point):
+    def __init__(self, z, **kw):
+        super(Point3, self).__init__(**kw)
+        self.Z = z
+
+p = Point3(3, X=1, Y=2)
+print("p.describe() = %s, p.Z = %d" % (p.describe(), p.Z))
+print("isinstance(p, mixins.Describer) = %s" % (isinstance(p, mixins.Describer),))
+
+n = bases._gopy_handle_count()
+objs = [bases.Point(X=i) for i in range(5)]
+del objs
+gc.collect()
+print("handles after releasing points: +%d" % (bases._gopy_handle_count() - n,))
@@ -0,0 +1,57 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package bigs tests the conversion of *big.Int and *big.Float values to
+// and from python numbers.
+package bigs
+
+import "math/big"
+
+// Factorial returns n!.
+func Factorial(n int) *big.Int {
+	return new(big.Int).MulRange(1, int64(n))
+}
+
+// Mul returns x*y.
+func Mul(x, y *big.Int) *big.Int {
+	return new(big.Int).Mul(x, y)
+}
+
+// Neg returns -x, or nil for a nil x.
+func Neg(x *big.Int) *big.Int {
+	if x == nil {
+		return nil
+	}
+	return new(big.Int).Neg(x)
+}
+
+// Half returns x/2, at the precision of x.
+func Half(x *big.Float) *big.Float {
+	return new(big.Float).SetPrec(x.Prec()).Quo(x, big.NewFloat(2))
+}
+
+// Third returns 1/3 at prec bits of precision.
+func Third(prec uint) *big.Float {
+	one := new(big.Float).SetPrec(prec).SetInt64(1)
+	return one.Quo(one, big.NewFloat(3))
+}
+
+// Prec returns the precision of x.
+func Prec(x *big.Float) uint {
+	return x.Prec()
+}
+
+// Account holds a balance, in cents.
+type Account struct {
+	Owner   string
+	Balance *big.Int
+}
+
+// Deposit adds cents to the balance of a.
+func (a *Account) Deposit(cents *big.Int) {
+	if a.Balance == nil {
+		a.Balance = new(big.Int)
+	}
+	a.Balance.Add(a.Balance, cents)
+}
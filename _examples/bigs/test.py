@@ -0,0 +1,52 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import decimal
+
+import bigs
+
+n = int("1234567890" * 20)
+print("len(str(n)) = %d" % len(str(n)))
+print("bigs.Mul(n, 1) == n: %s" % (bigs.Mul(n, 1) == n,))
+print("bigs.Neg(n) == -n: %s" % (bigs.Neg(n) == -n,))
+print("bigs.Mul(n, n) == n*n: %s" % (bigs.Mul(n, n) == n*n,))
+print("bigs.Mul(6, 7) = %r" % (bigs.Mul(6, 7),))
+print("bigs.Neg(None) = %r" % (bigs.Neg(None),))
+print("bigs.Neg() = %r" % (bigs.Neg(),))
+print("bigs.Factorial(30) = %d" % bigs.Factorial(30))
+
+print("bigs.Half(3.0) = %r" % (bigs.Half(3.0),))
+print("bigs.Half(1e300) = %r" % (bigs.Half(1e300),))
+print("int(bigs.Half(n)) == n//2: %s" % (int(bigs.Half(n)) == n//2,))
+print("bigs.Half(decimal.Decimal('0.1')) = %r" % (bigs.Half(decimal.Decimal('0.1')),))
+print("bigs.Prec(0.1) = %d" % bigs.Prec(0.1))
+print("bigs.Third(53) = %r" % (bigs.Third(53),))
+print("bigs.Third(100) = %r" % (bigs.Third(100),))
+print("bigs.Half(float('inf')) = %r" % (bigs.Half(float('inf')),))
+
+print("bigs.Mul(1.5, 2)")
+try:
+    bigs.Mul(1.5, 2)
+except TypeError as err:
+    print("caught: %s" % (err,))
+print("bigs.Half(float('nan'))")
+try:
+    bigs.Half(float('nan'))
+except ValueError as err:
+    print("caught: %s" % (err,))
+
+print("a = bigs.Account(Owner='bob')")
+a = bigs.Account(Owner='bob')
+print("a.Balance = %r" % (a.Balance,))
+print("a.Deposit(n)")
+a.Deposit(n)
+print("a.Deposit(1)")
+a.Deposit(1)
+print("a.Balance == n+1: %s" % (a.Balance == n+1,))
+print("a.Balance = 5")
+a.Balance = 5
+print("a.Balance = %r" % (a.Balance,))
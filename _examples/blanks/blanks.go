@@ -0,0 +1,41 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package blanks tests the wrapping of funcs with blank and unnamed
+// parameters.
+package blanks
+
+// Second returns its second argument, ignoring the first one.
+func Second(_ int, x int) int {
+	return x
+}
+
+// Ignore ignores both its arguments.
+func Ignore(_, _ string) bool {
+	return true
+}
+
+// Join joins its unnamed arguments.
+func Join(string, int) string {
+	return "unnamed"
+}
+
+// Counter counts.
+type Counter struct {
+	N int
+}
+
+// Add adds n to c, ignoring the reason.
+func (c *Counter) Add(_ string, n int, _ *Counter) int {
+	c.N += n
+	return c.N
+}
+
+// Pred is a func type with unnamed parameters.
+type Pred func(int, string) bool
+
+// Apply calls p with 1 and "one".
+func Apply(p Pred) bool {
+	return p(1, "one")
+}
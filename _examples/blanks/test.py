@@ -0,0 +1,22 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import blanks
+
+print("blanks.Second(1, 2) = %s" % (blanks.Second(1, 2),))
+print("blanks.Ignore('a', 'b') = %s" % (blanks.Ignore('a', 'b'),))
+print("blanks.Join('a', 1) = %r" % (blanks.Join('a', 1),))
+c = blanks.Counter()
+print("c = blanks.Counter()")
+print("c.Add('why', 2) = %s" % (c.Add('why', 2),))
+print("c.Add('why', 3, c) = %s" % (c.Add('why', 3, c),))
+print("c.Add('why', n=4)")
+try:
+    c.Add('why', n=4)
+except TypeError as err:
+    print("caught: %s" % (err,))
+print("blanks.Apply(blanks.Pred(lambda n, s: len(s) == 3*n)) = %s" % (blanks.Apply(blanks.Pred(lambda n, s: len(s) == 3*n)),))
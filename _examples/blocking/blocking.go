@@ -0,0 +1,46 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package blocking tests the release of the GIL around blocking calls.
+package blocking
+
+import (
+	"time"
+)
+
+// Event is a one-shot event, signalled from one python thread and waited
+// for from another.
+type Event struct {
+	c chan struct{}
+}
+
+// NewEvent returns a new, unsignalled, Event.
+func NewEvent() *Event {
+	return &Event{c: make(chan struct{})}
+}
+
+// Wait waits for the event to be signalled, for at most ms milliseconds.
+// It returns whether the event was signalled.
+//
+//gopy:blocking
+func (e *Event) Wait(ms int) bool {
+	select {
+	case <-e.c:
+		return true
+	case <-time.After(time.Duration(ms) * time.Millisecond):
+		return false
+	}
+}
+
+// Signal signals the event.
+func (e *Event) Signal() {
+	close(e.c)
+}
+
+// Sleep sleeps for ms milliseconds.
+//
+//gopy:blocking
+func Sleep(ms int) {
+	time.Sleep(time.Duration(ms) * time.Millisecond)
+}
@@ -0,0 +1,25 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import threading
+
+import blocking
+
+print("doc(blocking.Sleep): %r" % (blocking.Sleep.__doc__,))
+
+# the GIL is released while waiting, so the main thread can signal the event.
+e = blocking.NewEvent()
+res = []
+t = threading.Thread(target=lambda: res.append(e.Wait(10000)))
+t.start()
+blocking.Sleep(50)
+e.Signal()
+t.join()
+print("e.Wait(10000) = %s" % (res[0],))
+
+e = blocking.NewEvent()
+print("e.Wait(10) = %s" % (e.Wait(10),))
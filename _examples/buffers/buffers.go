@@ -0,0 +1,52 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package buffers tests the access to the storage of byte arrays and slices
+// through the python buffer protocol.
+package buffers
+
+// Image is a grayscale image.
+type Image struct {
+	W, H int
+	pix  []byte
+}
+
+// NewImage returns a black image of w x h pixels.
+func NewImage(w, h int) *Image {
+	return &Image{W: w, H: h, pix: make([]byte, w*h)}
+}
+
+// Pixels returns the pixels of the image, row by row, for python to edit
+// them in place.
+//
+//gopy:buffer
+func (img *Image) Pixels() []byte {
+	return img.pix
+}
+
+// Row returns the pixels of the i-th row of the image.
+func (img *Image) Row(i int) []byte {
+	return img.pix[i*img.W : (i+1)*img.W]
+}
+
+// Sum returns the sum of the pixels of the image.
+func (img *Image) Sum() int {
+	n := 0
+	for _, p := range img.pix {
+		n += int(p)
+	}
+	return n
+}
+
+// Digest is the digest of some bytes.
+type Digest [4]byte
+
+// Checksum returns the digest of b.
+func Checksum(b []byte) Digest {
+	var d Digest
+	for i, c := range b {
+		d[i%len(d)] ^= c
+	}
+	return d
+}
@@ -0,0 +1,35 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import buffers
+
+img = buffers.NewImage(3, 2)
+
+v = img.Pixels()
+print("type(img.Pixels()) = %s, len = %d" % (type(v).__name__, len(v)))
+v[0] = b'\x05'
+v[3:6] = b'\x01\x02\x03'
+print("img.Sum() = %d" % img.Sum())
+print("img.Pixels().tolist() = %s" % img.Pixels().tolist())
+del v
+
+row = img.Row(1)
+print("type(img.Row(1)) = %s" % type(row).__name__)
+print("memoryview(img.Row(1)).tobytes() = %r" % memoryview(row).tobytes())
+print("str(buffer(img.Row(1))) = %r" % str(buffer(row)))
+memoryview(row)[0] = b'\x07'
+print("img.Sum() = %d" % img.Sum())
+
+# the storage is pinned while viewed.
+n = buffers._gopy_handle_count()
+m = memoryview(row)
+print("pinned while viewed: %s" % (buffers._gopy_handle_count() == n+1))
+del m
+print("released with the view: %s" % (buffers._gopy_handle_count() == n))
+
+d = buffers.Checksum([ord(c) for c in 'gopy'])
+print("bytearray(d) = %r" % bytearray(d))
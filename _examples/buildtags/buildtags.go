@@ -0,0 +1,11 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package buildtags tests the binding of files selected by build tags.
+package buildtags
+
+// Common is bound whatever the build tags.
+func Common() string {
+	return "common"
+}
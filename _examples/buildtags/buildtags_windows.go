@@ -0,0 +1,10 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package buildtags
+
+// Windows is only bound for windows.
+func Windows() string {
+	return "windows"
+}
@@ -0,0 +1,12 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !gopy_extra
+
+package buildtags
+
+// Plain is only bound without the gopy_extra build tag.
+func Plain() string {
+	return "plain"
+}
@@ -0,0 +1,13 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import buildtags
+
+print("buildtags.Common() = %s" % (buildtags.Common(),))
+print("buildtags.Extra() = %s" % (buildtags.Extra(),))
+print("hasattr(buildtags, 'Plain') = %s" % (hasattr(buildtags, "Plain"),))
+print("hasattr(buildtags, 'Windows') = %s" % (hasattr(buildtags, "Windows"),))
@@ -5,14 +5,21 @@
 // Package cgo tests bindings of CGo-based packages.
 package cgo
 
+//#cgo CFLAGS: -DCPKG_SCALE=2
+//#cgo LDFLAGS: -lm
+//#include <math.h>
 //#include <stdio.h>
 //#include <string.h>
 //#include <stdlib.h>
 //const char* cpkg_sprintf(const char *str) {
-//  char *o = (char*)malloc(strlen(str));
+//	char *o = (char*)malloc(strlen(str)+1);
 //	sprintf(o, "%s", str);
 //	return o;
 //}
+//double cpkg_scaled_hypot(double x, double y) {
+//	return CPKG_SCALE * hypot(x, y);
+//}
+//typedef struct { double x; double y; } cpkg_point;
 import "C"
 
 import (
@@ -40,3 +47,36 @@ func Hello(s string) string {
 	defer C.free(unsafe.Pointer(cout))
 	return C.GoString(cout)
 }
+
+// ScaledHypot returns twice the hypotenuse of x and y, through C's libm,
+// with the scale defined by the cgo flags of the package.
+func ScaledHypot(x, y float64) float64 {
+	return float64(C.cpkg_scaled_hypot(C.double(x), C.double(y)))
+}
+
+// Point is a point stored in a C struct: it is wrapped by pointer only.
+type Point struct {
+	c C.cpkg_point
+}
+
+// NewPoint returns a new point at x, y.
+func NewPoint(x, y float64) *Point {
+	return &Point{c: C.cpkg_point{x: C.double(x), y: C.double(y)}}
+}
+
+// Hypot returns the distance of p to the origin, through C's libm.
+func (p *Point) Hypot() float64 {
+	return float64(C.hypot(p.c.x, p.c.y))
+}
+
+// Scale scales p by f.
+func (p *Point) Scale(f float64) {
+	p.c.x *= C.double(f)
+	p.c.y *= C.double(f)
+}
+
+// Norm returns the distance of p to the origin. It is not bound: p would
+// be a copy of the C struct.
+func Norm(p Point) float64 {
+	return p.Hypot()
+}
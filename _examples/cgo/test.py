@@ -10,3 +10,11 @@ import cgo
 print("cgo.doc: %r" % (cgo.__doc__,))
 print("cgo.Hi()= %r" % (cgo.Hi(),))
 print("cgo.Hello(you)= %r" % (cgo.Hello("you"),))
+print("cgo.ScaledHypot(3, 4)= %r" % (cgo.ScaledHypot(3, 4),))
+
+p = cgo.NewPoint(3, 4)
+print("p.Hypot()= %r" % (p.Hypot(),))
+p.Scale(2)
+print("p.Scale(2); p.Hypot()= %r" % (p.Hypot(),))
+print("cgo.Point().Hypot()= %r" % (cgo.Point().Hypot(),))
+print("hasattr(cgo, 'Norm')= %r" % (hasattr(cgo, 'Norm'),))
@@ -0,0 +1,82 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package chans tests channels, and waiting on several of them.
+package chans
+
+import (
+	"strings"
+	"time"
+)
+
+// Event is an event sent by Watch.
+type Event struct {
+	Name string
+	N    int
+}
+
+// Events is a channel of events.
+type Events chan Event
+
+// Ints is a channel of ints.
+type Ints chan int
+
+// Results is a channel of results, which can only be received.
+type Results <-chan string
+
+// Done is a channel of signals, carrying no values.
+type Done chan struct{}
+
+// Watch returns a channel sending n events, closed after the last one.
+func Watch(name string, n int) Events {
+	ch := make(Events)
+	go func() {
+		for i := 0; i < n; i++ {
+			ch <- Event{Name: name, N: i}
+		}
+		close(ch)
+	}()
+	return ch
+}
+
+// NewInts returns a channel of ints, buffering up to n values.
+func NewInts(n int) Ints {
+	return make(Ints, n)
+}
+
+// Sum receives the ints of ch until it is closed, and returns their sum.
+func Sum(ch Ints) int {
+	sum := 0
+	for v := range ch {
+		sum += v
+	}
+	return sum
+}
+
+// Later sends s, upper-cased, after ms milliseconds.
+func Later(s string, ms int) Results {
+	ch := make(chan string, 1)
+	go func() {
+		time.Sleep(time.Duration(ms) * time.Millisecond)
+		ch <- strings.ToUpper(s)
+	}()
+	return ch
+}
+
+// NewDone returns a channel of signals, buffering up to n signals.
+func NewDone(n int) Done {
+	return make(Done, n)
+}
+
+// Relay waits for n signals on in, signaling out after each of them, and
+// closes out after the last one.
+func Relay(in, out Done, n int) {
+	go func() {
+		for i := 0; i < n; i++ {
+			<-in
+			out <- struct{}{}
+		}
+		close(out)
+	}()
+}
@@ -0,0 +1,122 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import threading
+
+import chans
+
+def drain(ch):
+    values = []
+    while True:
+        try:
+            values.append(ch.Recv())
+        except RuntimeError as err:
+            return values, str(err)
+
+events, err = drain(chans.Watch("w", 3))
+print("events = %s" % ([(e.Name, e.N) for e in events],))
+print("err = %s" % (err,))
+
+ints = chans.NewInts(3)
+ints.Send(1)
+ints.Send(2)
+print("len = %d, cap = %d" % (ints.Len(), ints.Cap()))
+ints.Close()
+print("sum = %d" % (chans.Sum(ints),))
+try:
+    ints.Send(3)
+except RuntimeError as err:
+    print("send: %s" % (err,))
+try:
+    ints.Close()
+except RuntimeError as err:
+    print("close: %s" % (err,))
+
+# the GIL is released while Recv blocks.
+ch = chans.Ints()
+print("unbuffered cap = %d" % (ch.Cap(),))
+t = threading.Thread(target=lambda: ch.Send(42))
+t.start()
+print("recv = %d" % (ch.Recv(),))
+t.join()
+
+r = chans.Later("late", 10)
+print("results have Send: %s" % (hasattr(r, "Send"),))
+
+a = chans.NewInts(1)
+b = chans.NewInts(1)
+b.Send(7)
+print("select = %s" % (chans.select([a, b]),))
+print("select(poll) = %s" % (chans.select([a, b], timeout=0),))
+print("select(timeout) = %s" % (chans.select([a, b], 0.01),))
+print("select(later) = %s" % (chans.select([a, r], timeout=5),))
+i, e, ok = chans.select([a, chans.Watch("s", 1)])
+print("select(event) = %s" % ((i, e.Name, e.N, ok),))
+a.Close()
+print("select(closed) = %s" % (chans.select([a, b]),))
+
+for args in [([1],), ([],), ([a], -1)]:
+    try:
+        chans.select(*args)
+    except (TypeError, ValueError) as err:
+        print("%s: %s" % (type(err).__name__, err))
+
+# Recv waits at most timeout seconds: zero polls, None waits forever.
+c = chans.NewInts(1)
+for timeout in [0, 0.01]:
+    try:
+        c.Recv(timeout)
+    except Exception as err:
+        # TimeoutError is a RuntimeError subclass on python-2.
+        print("recv(%s): %s: %s" % (timeout, type(err).__name__, err))
+c.Send(5)
+print("recv(poll) = %d" % (c.Recv(timeout=0),))
+t = threading.Thread(target=lambda: c.Send(6))
+t.start()
+print("recv(None) = %d" % (c.Recv(timeout=None),))
+t.join()
+try:
+    c.Recv(-1)
+except ValueError as err:
+    print("ValueError: %s" % (err,))
+c.Close()
+try:
+    c.Recv(1)
+except RuntimeError as err:
+    print("recv(closed): %s" % (err,))
+
+# channels of struct{} carry signals: Signal sends one, and Wait receives
+# one, returning False once the timeout expires, and True once closed.
+d = chans.NewDone(1)
+print("done has Send: %s, Recv: %s" % (hasattr(d, "Send"), hasattr(d, "Recv")))
+print("wait(poll) = %s" % (d.Wait(0),))
+print("wait(0.01) = %s" % (d.Wait(timeout=0.01),))
+d.Signal()
+print("len = %d" % (d.Len(),))
+print("wait(signaled) = %s" % (d.Wait(0),))
+d.Close()
+print("wait(closed) = %s" % (d.Wait(),))
+try:
+    d.Signal()
+except RuntimeError as err:
+    print("signal: %s" % (err,))
+try:
+    d.Wait(-1)
+except ValueError as err:
+    print("ValueError: %s" % (err,))
+
+# a python thread waits for the signals relayed by a goroutine.
+ping, pong = chans.Done(), chans.Done()
+chans.Relay(ping, pong, 3)
+waits = []
+t = threading.Thread(target=lambda: waits.extend(pong.Wait(5) for _ in range(3)))
+t.start()
+for _ in range(3):
+    ping.Signal()
+t.join()
+print("relayed = %s" % (waits,))
+print("pong closed = %s" % (pong.Wait(5),))
@@ -0,0 +1,69 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package closures tests the wrapping of the func values returned to python.
+package closures
+
+import "fmt"
+
+// Counter returns a func incrementing a counter at each call.
+func Counter() func() int {
+	n := 0
+	return func() int {
+		n++
+		return n
+	}
+}
+
+// Account is a bank account.
+type Account struct {
+	Balance int
+}
+
+// Deposit adds amount to the balance of the account.
+func (a *Account) Deposit(amount int) error {
+	if amount < 0 {
+		return fmt.Errorf("invalid amount %d", amount)
+	}
+	a.Balance += amount
+	return nil
+}
+
+// Depositor returns the Deposit method of the account, bound to it.
+func (a *Account) Depositor() func(int) error {
+	return a.Deposit
+}
+
+// Scaler returns a func multiplying its argument by factor, which must
+// not be zero.
+func Scaler(factor int) (func(int) int, error) {
+	if factor == 0 {
+		return nil, fmt.Errorf("invalid factor %d", factor)
+	}
+	return func(v int) int {
+		return v * factor
+	}, nil
+}
+
+// Apply returns f(v).
+func Apply(f func() int, v int) int {
+	return f() + v
+}
+
+// Transform is a func transforming an int.
+type Transform func(int) int
+
+// Named returns the transform named name, or nil when there is none.
+func Named(name string) Transform {
+	switch name {
+	case "double":
+		return func(v int) int { return 2 * v }
+	}
+	return nil
+}
+
+// Lookup returns the unnamed func named name, or nil when there is none.
+func Lookup(name string) func(int) int {
+	return Named(name)
+}
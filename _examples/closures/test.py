@@ -0,0 +1,60 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import gc
+
+import closures
+
+c = closures.Counter()
+print("type(c) = %s" % (type(c).__name__,))
+print("doc(c) = %r" % (type(c).__doc__,))
+print("c() = %s" % (c(),))
+print("c() = %s" % (c(),))
+print("c() = %s" % (c(),))
+
+c2 = closures.Counter()
+print("c2() = %s" % (c2(),))
+print("c() = %s" % (c(),))
+
+## the captured counter lives as long as its python wrapper.
+del c2
+gc.collect()
+print("c() = %s" % (c(),))
+print("closures.Apply(c, 10) = %s" % (closures.Apply(c, 10),))
+
+a = closures.Account()
+deposit = a.Depositor()
+print("type(deposit) = %s" % (type(deposit).__name__,))
+deposit(10)
+deposit(32)
+print("a.Balance = %s" % (a.Balance,))
+
+## the bound method keeps its account alive.
+del a
+gc.collect()
+print("deposit(8) = %s" % (deposit(8),))
+
+try:
+    deposit(-1)
+except Exception as err:
+    print("caught: %s" % (err,))
+
+scale = closures.Scaler(3)
+print("type(scale) = %s" % (type(scale).__name__,))
+print("scale(14) = %s" % (scale(14),))
+
+try:
+    closures.Scaler(0)
+    print("*ERROR* no exception raised!")
+except Exception as err:
+    print("caught: %s" % (err,))
+
+## nil funcs are None.
+print("closures.Named('double')(21) = %s" % (closures.Named('double')(21),))
+print("closures.Named('') = %s" % (closures.Named(''),))
+print("closures.Lookup('double')(21) = %s" % (closures.Lookup('double')(21),))
+print("closures.Lookup('') = %s" % (closures.Lookup(''),))
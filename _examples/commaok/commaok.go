@@ -0,0 +1,72 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package commaok tests the wrapping of funcs with comma-ok results.
+package commaok
+
+var colors = map[string]string{
+	"red":   "#ff0000",
+	"green": "#00ff00",
+}
+
+// Lookup returns the hex code of the color name, and whether it is known.
+func Lookup(name string) (string, bool) {
+	hex, ok := colors[name]
+	return hex, ok
+}
+
+// Find returns the hex code of the color name, or None when it is unknown.
+//
+//gopy:ok
+func Find(name string) (string, bool) {
+	hex, ok := colors[name]
+	return hex, ok
+}
+
+// Point is a point of the plane.
+type Point struct {
+	X, Y int
+}
+
+var points = map[string]Point{
+	"origin": {0, 0},
+	"unit":   {1, 1},
+}
+
+// FindPoint returns the point name, or None when it is unknown.
+//
+//gopy:ok
+func FindPoint(name string) (Point, bool) {
+	p, ok := points[name]
+	return p, ok
+}
+
+// Table maps keys to counts.
+type Table struct {
+	counts map[string]int
+}
+
+// NewTable returns an empty table.
+func NewTable() *Table {
+	return &Table{counts: make(map[string]int)}
+}
+
+// Add increments the count of key.
+func (t *Table) Add(key string) {
+	t.counts[key]++
+}
+
+// Get returns the count of key, and whether key was added.
+func (t *Table) Get(key string) (int, bool) {
+	n, ok := t.counts[key]
+	return n, ok
+}
+
+// Count returns the count of key, or None when key was not added.
+//
+//gopy:ok
+func (t *Table) Count(key string) (int, bool) {
+	n, ok := t.counts[key]
+	return n, ok
+}
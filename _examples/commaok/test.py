@@ -0,0 +1,26 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import commaok
+
+print("commaok.Lookup('red') = %r" % (commaok.Lookup("red"),))
+print("commaok.Lookup('blue') = %r" % (commaok.Lookup("blue"),))
+print("commaok.Find('green') = %r" % (commaok.Find("green"),))
+print("commaok.Find('blue') = %r" % (commaok.Find("blue"),))
+
+p = commaok.FindPoint("unit")
+print("commaok.FindPoint('unit') = (%d, %d)" % (p.X, p.Y))
+
+print("commaok.FindPoint('nowhere') = %r" % (commaok.FindPoint("nowhere"),))
+
+t = commaok.NewTable()
+t.Add("a")
+t.Add("a")
+print("t.Get('a') = %r" % (t.Get("a"),))
+print("t.Get('b') = %r" % (t.Get("b"),))
+print("t.Count('a') = %r" % (t.Count("a"),))
+print("t.Count('b') = %r" % (t.Count("b"),))
@@ -24,6 +24,15 @@ const (
 	Kind2      = 2
 )
 
+// Color is an enum-like string type.
+type Color string
+
+const (
+	Red   Color = "red"
+	Green Color = "green"
+	Rouge Color = "red" // Rouge is an alias of Red.
+)
+
 // FIXME: also use an unexported type
 // type kind int
 // const (
@@ -21,3 +21,27 @@ print("k2 = %s" % consts.GetKind2())
 #print("k3 = %s" % consts.GetKind3())
 #print("k4 = %s" % consts.GetKind4())
 
+
+## named integer types can be used as indices.
+print("['a', 'b', 'c'][consts.GetKind1()] = %s" % (['a', 'b', 'c'][consts.GetKind1()],))
+print("'abc'[:consts.Kind(2)] = %s" % ('abc'[:consts.Kind(2)],))
+print("range(consts.Kind(3)) = %s" % (range(consts.Kind(3)),))
+print("int(consts.Kind(-4)) = %s" % (int(consts.Kind(-4)),))
+
+## consts are module attributes too.
+print("consts.C1 = %s" % (consts.C1,))
+print("consts.C2 = %s" % (consts.C2,))
+print("consts.C3 = %s" % (consts.C3,))
+print("consts.C6 = %s" % (consts.C6,))
+print("consts.Kind1 = %s" % (consts.Kind1,))
+print("type(consts.Kind1) = %s" % (type(consts.Kind1).__name__,))
+print("consts.Kind2 = %s" % (consts.Kind2,))
+
+## named-typed consts know their name.
+print("consts.Kind1.name = %s" % (consts.Kind1.name,))
+print("consts.Kind(1).name = %s" % (consts.Kind(1).name,))
+print("consts.Kind(3).name = %s" % (consts.Kind(3).name,))
+print("consts.Red = %s" % (consts.Red,))
+print("consts.Red.name = %s" % (consts.Red.name,))
+print("consts.Rouge.name = %s" % (consts.Rouge.name,))
+print("consts.Color('green').name = %s" % (consts.Color('green').name,))
@@ -0,0 +1,104 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package converts tests the types converted to and from python values by
+// user-defined funcs.
+package converts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Color is an RGB color, exchanged with python as a "#rrggbb" string.
+//
+//gopy:convert ColorToPy ColorFromPy
+type Color struct {
+	R, G, B uint8
+}
+
+// ColorToPy returns the "#rrggbb" form of c.
+func ColorToPy(c Color) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// ColorFromPy parses the "#rrggbb" form of a color.
+func ColorFromPy(s string) (Color, error) {
+	var c Color
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x", &c.R, &c.G, &c.B); err != nil || len(s) != 7 {
+		return c, fmt.Errorf("converts: invalid color %q", s)
+	}
+	return c, nil
+}
+
+// Celsius is a temperature, exchanged with python as kelvins.
+//
+//gopy:convert CelsiusToPy CelsiusFromPy
+type Celsius float64
+
+// CelsiusToPy returns t in kelvins.
+func CelsiusToPy(t Celsius) float64 {
+	return float64(t) + 273.15
+}
+
+// CelsiusFromPy returns the temperature of k kelvins.
+func CelsiusFromPy(k float64) (Celsius, error) {
+	if k < 0 {
+		return 0, fmt.Errorf("converts: negative temperature %v", k)
+	}
+	return Celsius(k - 273.15), nil
+}
+
+// Mix returns the average of two colors.
+func Mix(a, b Color) Color {
+	return Color{(a.R + b.R) / 2, (a.G + b.G) / 2, (a.B + b.B) / 2}
+}
+
+// Darker returns c, with each component halved.
+func Darker(c Color) (Color, error) {
+	if c == (Color{}) {
+		return c, fmt.Errorf("converts: black has no darker color")
+	}
+	return Color{c.R / 2, c.G / 2, c.B / 2}, nil
+}
+
+// Freezing is the freezing temperature of water.
+const Freezing Celsius = 0
+
+// Boiling is the boiling temperature of water.
+var Boiling = Celsius(100)
+
+// Warm returns whether t is above 20 degrees.
+func Warm(t Celsius) bool {
+	return t > 20
+}
+
+// Shape is a colored shape.
+type Shape struct {
+	Name string
+	Fill Color
+}
+
+// Describe describes s.
+func (s *Shape) Describe() string {
+	return strings.Join([]string{s.Name, ColorToPy(s.Fill)}, " filled with ")
+}
+
+// Palette returns the colors of the rainbow ends.
+func Palette() []Color {
+	return []Color{{255, 0, 0}, {0, 0, 255}}
+}
+
+// Named returns colors by name.
+func Named() map[string]Color {
+	return map[string]Color{"red": {255, 0, 0}}
+}
+
+// Painter paints a color.
+type Painter func(c Color) Color
+
+// Paint calls p with white.
+func Paint(p Painter) Color {
+	return p(Color{255, 255, 255})
+}
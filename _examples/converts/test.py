@@ -0,0 +1,61 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import converts
+
+print("converts.Mix('#ff0000', '#0000ff') = %r" % (converts.Mix('#ff0000', '#0000ff'),))
+print("converts.Darker('#804020') = %r" % (converts.Darker('#804020'),))
+try:
+    converts.Darker('#000000')
+except RuntimeError as err:
+    print("caught: %s" % (err,))
+try:
+    converts.Mix('red', '#0000ff')
+except ValueError as err:
+    print("caught: %s" % (err,))
+try:
+    converts.Mix(42, '#0000ff')
+except TypeError as err:
+    print("caught: TypeError")
+
+print("converts.Warm(300.0) = %s" % (converts.Warm(300.0),))
+print("converts.Warm(280.0) = %s" % (converts.Warm(280.0),))
+try:
+    converts.Warm(-1.0)
+except ValueError as err:
+    print("caught: %s" % (err,))
+print("converts.Freezing = %s" % (converts.Freezing,))
+print("converts.Boiling = %s" % (converts.Boiling,))
+
+s = converts.Shape(Name="square", Fill="#00ff00")
+print("s.Fill = %r" % (s.Fill,))
+s.Fill = '#123456'
+print("s.Describe() = %r" % (s.Describe(),))
+try:
+    s.Fill = 'blue'
+except ValueError as err:
+    print("caught: %s" % (err,))
+print("s.Fill = %r" % (s.Fill,))
+
+print("hasattr(converts, 'Color') = %s" % (hasattr(converts, 'Color'),))
+print("hasattr(converts, 'ColorToPy') = %s" % (hasattr(converts, 'ColorToPy'),))
+
+p = converts.Palette()
+print("list(converts.Palette()) = %s" % (list(p),))
+p[1] = '#00ffff'
+print("p[1] = %r" % (p[1],))
+try:
+    p[0] = 'cyan'
+except ValueError as err:
+    print("caught: %s" % (err,))
+
+m = converts.Named()
+print("m['red'] = %r" % (m['red'],))
+m['blue'] = '#0000ff'
+print("m['blue'] = %r" % (m['blue'],))
+
+print("converts.Paint(converts.Painter(lambda c: c[:3] + '0000')) = %r" % (converts.Paint(converts.Painter(lambda c: c[:3] + '0000')),))
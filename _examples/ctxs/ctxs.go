@@ -0,0 +1,40 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package ctxs tests passing contexts created and canceled from python.
+package ctxs
+
+import (
+	"context"
+	"time"
+)
+
+type nameKey struct{}
+
+// Wait blocks until ctx is done, and returns its error.
+func Wait(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// Sleep sleeps for ms milliseconds, unless ctx is done first.
+func Sleep(ctx context.Context, ms int) error {
+	select {
+	case <-time.After(time.Duration(ms) * time.Millisecond):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WithName returns a child of ctx, holding a name.
+func WithName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, nameKey{}, name)
+}
+
+// Name returns the name held by ctx, if any.
+func Name(ctx context.Context) string {
+	name, _ := ctx.Value(nameKey{}).(string)
+	return name
+}
@@ -0,0 +1,53 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import threading
+import time
+
+import ctxs
+
+bg = ctxs.Context()
+print("bg.Deadline() = %s" % (bg.Deadline(),))
+print("bg.Err() = %s" % (bg.Err(),))
+try:
+    bg.Cancel()
+except RuntimeError as err:
+    print("bg.Cancel(): %s" % (err,))
+print("ctxs.Sleep(bg, 1) = %s" % (ctxs.Sleep(bg, 1),))
+
+# the GIL is released while Wait blocks, so another thread can cancel.
+ctx = bg.WithCancel()
+threading.Timer(0.05, ctx.Cancel).start()
+try:
+    ctxs.Wait(ctx)
+except RuntimeError as err:
+    print("ctxs.Wait(ctx): %s" % (err,))
+try:
+    ctx.Err()
+except RuntimeError as err:
+    print("ctx.Err(): %s" % (err,))
+ctx.Cancel()
+print("ctx canceled twice")
+
+start = time.time()
+ctx = bg.WithTimeout(0.05)
+deadline = ctx.Deadline()
+print("deadline in range: %s" % (start < deadline < start + 1,))
+try:
+    ctxs.Sleep(ctx, 5000)
+except RuntimeError as err:
+    print("ctxs.Sleep(ctx, 5000): %s" % (err,))
+
+# cancellation propagates through the contexts derived in go.
+parent = bg.WithCancel()
+child = ctxs.WithName(parent, "gopher").WithCancel()
+print("ctxs.Name(child) = %s" % (ctxs.Name(child),))
+parent.Cancel()
+try:
+    ctxs.Wait(child)
+except RuntimeError as err:
+    print("ctxs.Wait(child): %s" % (err,))
@@ -0,0 +1,40 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package dicts tests the conversion of map[string]interface{} values
+// to and from python dicts.
+package dicts
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Config returns a decoded configuration.
+func Config() map[string]interface{} {
+	return map[string]interface{}{
+		"name":  "gopy",
+		"port":  8080,
+		"ratio": 0.5,
+		"debug": true,
+		"tags":  []string{"a", "b"},
+		"db":    map[string]interface{}{"host": "localhost"},
+		"none":  nil,
+	}
+}
+
+// Describe returns the sorted keys of m, with their values and go types.
+func Describe(m map[string]interface{}) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	str := make([]string, 0, len(keys))
+	for _, k := range keys {
+		str = append(str, fmt.Sprintf("%s=%v (%T)", k, m[k], m[k]))
+	}
+	return strings.Join(str, ", ")
+}
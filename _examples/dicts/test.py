@@ -0,0 +1,38 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import dicts
+
+print("cfg = dicts.Config()")
+cfg = dicts.Config()
+print("type(cfg) = %s" % (type(cfg).__name__,))
+for k in sorted(cfg.keys()):
+    print("cfg[%r] = %r" % (k, cfg[k]))
+
+print("dicts.Describe(cfg)")
+print(dicts.Describe(cfg))
+
+print("dicts.Describe({'x': 1, 'y': [1.5, u'\\xe9'], 'z': {'ok': False}})")
+print(dicts.Describe({'x': 1, 'y': [1.5, u'\xe9'], 'z': {'ok': False}}))
+
+try:
+    print("dicts.Describe({1: 'one'})")
+    dicts.Describe({1: 'one'})
+except TypeError as err:
+    print("caught: %s" % (err,))
+
+try:
+    print("dicts.Describe({'f': object()})")
+    dicts.Describe({'f': object()})
+except TypeError as err:
+    print("caught: %s" % (err,))
+
+try:
+    print("dicts.Describe([1])")
+    dicts.Describe([1])
+except TypeError as err:
+    print("caught: %s" % (err,))
@@ -0,0 +1,56 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package durations tests the conversion of time.Duration values.
+package durations
+
+import "time"
+
+// Timeout returns the default timeout.
+func Timeout() time.Duration {
+	return 1500 * time.Millisecond
+}
+
+// Double returns twice d.
+func Double(d time.Duration) time.Duration {
+	return 2 * d
+}
+
+// Parse parses a duration string, such as "1h30m".
+func Parse(s string) (time.Duration, error) {
+	return time.ParseDuration(s)
+}
+
+// String returns the go formatting of d.
+func String(d time.Duration) string {
+	return d.String()
+}
+
+// Backoff is a retry policy.
+type Backoff struct {
+	Base time.Duration
+	Max  time.Duration
+}
+
+// Delay returns the delay before the n-th retry, doubling from Base up to
+// Max.
+func (b *Backoff) Delay(n int) time.Duration {
+	d := b.Base
+	for i := 0; i < n && d < b.Max; i++ {
+		d *= 2
+	}
+	if d > b.Max {
+		d = b.Max
+	}
+	return d
+}
+
+// Steps returns the delays of the first n retries.
+func (b *Backoff) Steps(n int) []time.Duration {
+	var ds []time.Duration
+	for i := 0; i < n; i++ {
+		ds = append(ds, b.Delay(i))
+	}
+	return ds
+}
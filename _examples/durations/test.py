@@ -0,0 +1,49 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import datetime
+
+import durations
+
+print("durations.Timeout() = %r" % (durations.Timeout(),))
+print("durations.Double(1.25) = %r" % (durations.Double(1.25),))
+print("durations.Double(2) = %r" % (durations.Double(2),))
+print("durations.Double(-0.5) = %r" % (durations.Double(-0.5),))
+print("durations.Double(timedelta(minutes=1, microseconds=3)) = %r" % (
+    durations.Double(datetime.timedelta(minutes=1, microseconds=3)),))
+print("durations.Parse('1h30m') = %r" % (durations.Parse("1h30m"),))
+print("durations.Parse('1ns') = %r" % (durations.Parse("1ns"),))
+
+## float seconds are rounded to the nearest nanosecond.
+print("durations.String(4.35) = %r" % (durations.String(4.35),))
+print("durations.String(1e-9) = %r" % (durations.String(1e-9),))
+print("durations.String(timedelta(days=-1)) = %r" % (
+    durations.String(datetime.timedelta(days=-1)),))
+
+try:
+    durations.Parse("soon")
+except Exception as err:
+    print("caught: %s" % (err,))
+
+for v in ["1s", 1e10, 10**10, float("nan"), datetime.timedelta(days=300*365)]:
+    try:
+        durations.String(v)
+        print("*ERROR* no exception raised!")
+    except (TypeError, ValueError, OverflowError) as err:
+        print("caught %s: %s" % (type(err).__name__, err))
+
+b = durations.Backoff(Base=0.1, Max=datetime.timedelta(seconds=1))
+print("b.Base = %r" % (b.Base,))
+print("b.Max = %r" % (b.Max,))
+print("b.Delay(3) = %r" % (b.Delay(3),))
+print("b.Steps(5) = %r" % (list(b.Steps(5)),))
+b.Max = 2
+print("b.Delay(10) = %r" % (b.Delay(10),))
+try:
+    b.Max = "2s"
+except TypeError as err:
+    print("caught: %s" % (err,))
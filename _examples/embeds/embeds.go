@@ -0,0 +1,154 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package embeds tests the methods promoted through embedded fields.
+package embeds
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Reader reads a source.
+type Reader struct {
+	Src string
+}
+
+// Read returns the next line of the source.
+func (r *Reader) Read() string {
+	return "line of " + r.Src
+}
+
+// Close closes the source.
+func (r *Reader) Close() string {
+	return "closed " + r.Src
+}
+
+// Writer writes to a destination.
+type Writer struct {
+	Dst string
+}
+
+// Write writes s to the destination.
+func (w *Writer) Write(s string) string {
+	return fmt.Sprintf("wrote %q to %s", s, w.Dst)
+}
+
+// Close closes the destination.
+func (w *Writer) Close() string {
+	return "closed " + w.Dst
+}
+
+// String returns the destination of the writer.
+func (w *Writer) String() string {
+	return "writer to " + w.Dst
+}
+
+// ReadWriter reads from a Reader and writes to a Writer. Close is
+// ambiguous, promoted from both at the same depth: it is not bound.
+type ReadWriter struct {
+	*Reader
+	*Writer
+}
+
+// NewReadWriter returns a ReadWriter from src to dst.
+func NewReadWriter(src, dst string) *ReadWriter {
+	return &ReadWriter{&Reader{Src: src}, &Writer{Dst: dst}}
+}
+
+// File is a ReadWriter closing itself, its Close shadowing the ambiguous
+// ones of ReadWriter.
+type File struct {
+	ReadWriter
+	Path string
+}
+
+// NewFile returns the file at path.
+func NewFile(path string) *File {
+	return &File{ReadWriter: *NewReadWriter(path, path), Path: path}
+}
+
+// Close closes the file.
+func (f *File) Close() string {
+	return "closed file " + f.Path
+}
+
+// Tee writes to Writer, and to the Writer of its ReadWriter. Write and
+// Close are promoted from its Writer, shallower than the ReadWriter's.
+type Tee struct {
+	*Writer
+	ReadWriter
+}
+
+// NewTee returns a Tee writing to dst, and reading from src.
+func NewTee(dst, src string) *Tee {
+	return &Tee{&Writer{Dst: dst}, *NewReadWriter(src, src+".copy")}
+}
+
+// Namer names things.
+type Namer interface {
+	Name() string
+}
+
+type namer string
+
+func (n namer) Name() string { return string(n) }
+
+// Find returns the Namer named name, or nil for an empty name.
+func Find(name string) Namer {
+	if name == "" {
+		return nil
+	}
+	return namer(name)
+}
+
+// Logger logs lines. Name is promoted from its embedded Namer, and called
+// through it.
+type Logger struct {
+	Namer
+}
+
+// NewLogger returns a Logger named name.
+func NewLogger(name string) *Logger {
+	return &Logger{namer(name)}
+}
+
+// Tag is a Namer held by value, with a key.
+type Tag struct {
+	Namer
+	Key string
+}
+
+// NewTag returns the tag of key, named name.
+func NewTag(name, key string) Tag {
+	return Tag{namer(name), key}
+}
+
+// Source reads a string through its embedded io.Reader.
+type Source struct {
+	io.Reader
+	r *strings.Reader
+}
+
+// NewSource returns a source reading s.
+func NewSource(s string) *Source {
+	r := strings.NewReader(s)
+	return &Source{r, r}
+}
+
+// Len returns the number of bytes left to read.
+func (s *Source) Len() int {
+	return s.r.Len()
+}
+
+// Label is printed by its embedded fmt.Stringer.
+type Label struct {
+	fmt.Stringer
+}
+
+// NewLabel returns the label of the Writer writing to dst.
+func NewLabel(dst string) *Label {
+	return &Label{&Writer{Dst: dst}}
+}
@@ -0,0 +1,49 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import embeds
+
+rw = embeds.NewReadWriter("in", "out")
+print("rw.Read() = %r" % (rw.Read(),))
+print("rw.Write('x') = %r" % (rw.Write("x"),))
+## Close is promoted from both the Reader and the Writer.
+print("hasattr(rw, 'Close') = %s" % (hasattr(rw, "Close"),))
+
+f = embeds.NewFile("f.txt")
+print("f.Read() = %r" % (f.Read(),))
+print("f.Close() = %r" % (f.Close(),))
+
+t = embeds.NewTee("a", "b")
+print("t.Read() = %r" % (t.Read(),))
+print("t.Write('x') = %r" % (t.Write("x"),))
+print("t.Close() = %r" % (t.Close(),))
+
+## methods promoted from embedded interfaces are called through them.
+l = embeds.NewLogger("log")
+print("l.Name() = %r" % (l.Name(),))
+tag = embeds.NewTag("tag", "k")
+print("tag.Name() = %r" % (tag.Name(),))
+try:
+    embeds.Logger().Name()
+except RuntimeError as e:
+    print("embeds.Logger().Name(): %s" % (e,))
+
+## nil interfaces are None.
+print("embeds.Find('n').Name() = %r" % (embeds.Find("n").Name(),))
+print("embeds.Find('') = %s" % (embeds.Find(""),))
+print("embeds.Logger().Namer = %s" % (embeds.Logger().Namer,))
+try:
+    embeds.Namer().Name()
+except RuntimeError as e:
+    print("embeds.Namer().Name(): %s" % (e,))
+
+s = embeds.NewSource("abc")
+print("s.Read(bytearray(2)) = %r" % (s.Read(bytearray(2)),))
+print("s.Len() = %r" % (s.Len(),))
+
+print("str(embeds.NewLabel('w')) = %r" % (str(embeds.NewLabel("w")),))
+print("str(embeds.Label()) = %r" % (str(embeds.Label()),))
@@ -0,0 +1,37 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package enums tests string types with consts, exposed like enums.
+package enums
+
+import "strings"
+
+// Status is the status of an account.
+type Status string
+
+const (
+	StatusActive    Status = "active"
+	StatusSuspended Status = "suspended"
+	StatusClosed    Status = "closed"
+	StatusDefault          = StatusActive // StatusDefault is an alias of StatusActive.
+)
+
+// Version is a plain string const, not an enum member.
+const Version = "v1"
+
+// Parse returns the status named s, which may not be a valid one.
+func Parse(s string) Status {
+	return Status(strings.ToLower(s))
+}
+
+// IsOpen returns whether an account of status s can be used.
+func IsOpen(s Status) bool {
+	return s == StatusActive
+}
+
+// Account is an account of a service.
+type Account struct {
+	Name   string
+	Status Status
+}
@@ -0,0 +1,53 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import enums
+
+## consts are module attributes, and members of their type.
+print("enums.Version:", enums.Version)
+print("Status.StatusActive.name:", enums.Status.StatusActive.name)
+print("Status.StatusActive.value:", enums.Status.StatusActive.value)
+print("enums.StatusClosed is Status.StatusClosed:", enums.StatusClosed is enums.Status.StatusClosed)
+print("Status.__members__:", sorted(enums.Status.__members__))
+print("aliases:", enums.Status.StatusDefault == enums.Status.StatusActive)
+
+## Status(value) looks up the member of that value.
+s = enums.Status("suspended")
+print("Status('suspended').name:", s.name)
+print("Status('suspended') == StatusSuspended:", s == enums.StatusSuspended)
+print("Status('suspended') != StatusClosed:", s != enums.StatusClosed)
+print("dict key:", {enums.StatusSuspended: "x"}[s])
+
+try:
+    enums.Status("deleted")
+    print("*ERROR* no exception raised!")
+except ValueError as err:
+    print("caught:", err)
+
+## values returned by go are not checked.
+p = enums.Parse("Deleted")
+print("Parse('Deleted'):", p.value, p.name)
+print("Parse('Active') == StatusActive:", enums.Parse("Active") == enums.StatusActive)
+print("IsOpen(Status('active')):", enums.IsOpen(enums.Status("active")))
+
+a = enums.Account()
+a.Status = enums.StatusClosed
+print("a.Status.name:", a.Status.name)
+
+## with python-3, or the enum34 backport, Status is an enum.Enum.
+try:
+    import enum
+except ImportError:
+    enum = None
+if enum is not None:
+    print("issubclass(Status, enum.Enum):", issubclass(enums.Status, enum.Enum))
+    print("isinstance(StatusActive, enum.Enum):", isinstance(enums.StatusActive, enum.Enum))
+    print("isinstance(Status('closed'), enum.Enum):", isinstance(enums.Status("closed"), enum.Enum))
+    print("list(Status):", [s.name for s in enums.Status])
+    print("Parse('Active') is StatusActive:", enums.Parse("Active") is enums.StatusActive)
+    print("isinstance(a.Status, enum.Enum):", isinstance(a.Status, enum.Enum))
+    print("isinstance(Parse('Deleted'), enum.Enum):", isinstance(enums.Parse("Deleted"), enum.Enum))
@@ -0,0 +1,103 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package errs tests the wrapping of error values.
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is returned when a name could not be found.
+var ErrNotFound = errors.New("not found")
+
+// Find returns ErrNotFound for any name but "gopy".
+func Find(name string) error {
+	if name != "gopy" {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Result holds the outcome of a lookup.
+type Result struct {
+	Name string
+	Err  error
+}
+
+// Lookup looks up name and records the outcome.
+func Lookup(name string) Result {
+	return Result{Name: name, Err: Find(name)}
+}
+
+// PathError records an error and the path of the file it was raised for.
+type PathError struct {
+	Op   string
+	Path string
+	Err  error
+}
+
+func (e *PathError) Error() string { return e.Op + " " + e.Path + ": " + e.Err.Error() }
+
+func (e *PathError) Unwrap() error { return e.Err }
+
+// Stat looks up the file at path. The error of the result wraps a
+// *PathError, wrapping ErrNotFound, for any path but "/gopy".
+func Stat(path string) Result {
+	if path == "/gopy" {
+		return Result{Name: path}
+	}
+	err := &PathError{Op: "stat", Path: path, Err: ErrNotFound}
+	return Result{Name: path, Err: fmt.Errorf("errs: %w", err)}
+}
+
+// Parse returns the number of digits of s, and an advisory error for the
+// other characters of s, which are skipped.
+//
+//gopy:warn-on-error
+func Parse(s string) (int, error) {
+	n := 0
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			n++
+		}
+	}
+	if n != len(s) {
+		return n, errors.New("skipped non-digits in " + s)
+	}
+	return n, nil
+}
+
+// Check reports an advisory error for an empty name.
+//
+//gopy:warn-on-error
+func (r *Result) Check() error {
+	if r.Name == "" {
+		return errors.New("empty name")
+	}
+	return nil
+}
+
+// Failure is an alias of error: functions returning it raise it, as they
+// would an error.
+type Failure = error
+
+// Open returns the path of the file opened at path, failing for any path
+// but "/gopy".
+func Open(path string) (string, Failure) {
+	if path != "/gopy" {
+		return "", &PathError{Op: "open", Path: path, Err: ErrNotFound}
+	}
+	return path, nil
+}
+
+// Blame returns the error of a failed open of path, as a value: PathError
+// implements error, but is not raised.
+func Blame(path string) (PathError, error) {
+	if path == "" {
+		return PathError{}, errors.New("no path to blame")
+	}
+	return PathError{Op: "open", Path: path, Err: ErrNotFound}, nil
+}
@@ -0,0 +1,82 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import errs
+
+print("doc(errs): %r" % (errs.__doc__,))
+
+err = errs.GetErrNotFound()
+print("err = %s" % (err,))
+print("err.Error() = %r" % (err.Error(),))
+
+print("errs.Find('gopy')...")
+errs.Find("gopy")
+print("errs.Find('foo')...")
+try:
+    errs.Find("foo")
+except RuntimeError, e:
+    print("caught: %s" % (e,))
+
+r = errs.Lookup("foo")
+print("r.Err = %s" % (r.Err,))
+print("r.Err == err: %s" % (r.Err == err,))
+
+r = errs.Lookup("gopy")
+print("r.Err = %s" % (r.Err,))
+print("r.Err == err: %s" % (r.Err == err,))
+
+## nil errors are None, and None is a nil error.
+print("r.Err is None: %s" % (r.Err is None,))
+print("errs.errors_is(None, err): %s" % (errs.errors_is(None, err),))
+try:
+    errs.error().Error()
+except RuntimeError as e:
+    print("caught: %s" % (e,))
+
+r = errs.Stat("/foo")
+print("r.Err = %s" % (r.Err,))
+print("r.Err == err: %s" % (r.Err == err,))
+print("errs.errors_is(r.Err, err): %s" % (errs.errors_is(r.Err, err),))
+print("errs.errors_is(r.Err, errs.Lookup('bar').Err): %s" % (errs.errors_is(r.Err, errs.Lookup('bar').Err),))
+print("errs.errors_is(errs.Stat('/gopy').Err, err): %s" % (errs.errors_is(errs.Stat('/gopy').Err, err),))
+pe = errs.errors_as(r.Err, errs.PathError)
+print("errs.errors_as(r.Err, errs.PathError): %s %s %s" % (type(pe).__name__, pe.Op, pe.Path))
+print("errs.errors_as(r.Err, errs.PathError).Err == err: %s" % (pe.Err == err,))
+print("errs.errors_as(err, errs.PathError): %s" % (errs.errors_as(err, errs.PathError),))
+try:
+    errs.errors_as(r.Err, int)
+except TypeError as e:
+    print("caught: %s" % (e,))
+
+import warnings
+
+with warnings.catch_warnings(record=True) as ws:
+    warnings.simplefilter("always")
+    print("errs.Parse('123') = %s" % (errs.Parse("123"),))
+    print("errs.Parse('1a2') = %s" % (errs.Parse("1a2"),))
+    print("errs.Result().Check() = %s" % (errs.Result().Check(),))
+    for w in ws:
+        print("warning: %s: %s" % (w.category.__name__, w.message))
+
+with warnings.catch_warnings():
+    warnings.simplefilter("error")
+    try:
+        errs.Parse("x")
+    except UserWarning as e:
+        print("caught: %s" % (e,))
+
+print("errs.Open('/gopy') = %s" % (errs.Open("/gopy"),))
+try:
+    errs.Open("/tmp")
+except RuntimeError as e:
+    print("caught: %s" % (e,))
+p = errs.Blame("/tmp")
+print("errs.Blame('/tmp') = %s, p.Path = %s" % (type(p).__name__, p.Path))
+try:
+    errs.Blame("")
+except RuntimeError as e:
+    print("caught: %s" % (e,))
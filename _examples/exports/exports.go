@@ -0,0 +1,43 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package exports tests the __all__ attribute of the module, listing the
+// names bound to exported go objects.
+package exports
+
+// Max is the largest size.
+const Max = 10
+
+// Size is a size.
+type Size int
+
+// Small is a small size.
+const Small Size = 1
+
+// Box holds sizes.
+type Box struct {
+	Sizes []Size
+}
+
+// NewBox returns a box holding sizes.
+func NewBox(sizes ...Size) *Box {
+	return &Box{Sizes: sizes}
+}
+
+// Crate is another name of Box.
+type Crate = Box
+
+// Total returns the sum of sizes.
+func Total(sizes []int) int {
+	n := 0
+	for _, s := range sizes {
+		n += s
+	}
+	return n
+}
+
+// Default is the default size.
+var Default = Small
+
+func helper() {}
@@ -0,0 +1,16 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import exports
+
+print("exports.__all__ = %s" % (exports.__all__,))
+
+ns = {}
+exec("from exports import *", ns)
+print("star import: %s" % (sorted(k for k in ns if k != "__builtins__"),))
+print("SliceInt in dir(exports): %s" % ("SliceInt" in dir(exports),))
+print("Default: %s" % (ns["Default"],))
@@ -0,0 +1,51 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package exttypes tests the wrapping of types defined in other packages.
+package exttypes
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// NewReader returns a reader over s.
+func NewReader(s string) io.Reader {
+	return strings.NewReader(s)
+}
+
+// NewBuffer returns a buffer initialized with s.
+func NewBuffer(s string) *bytes.Buffer {
+	return bytes.NewBufferString(s)
+}
+
+// ReadAll returns the content of r.
+func ReadAll(r io.Reader) string {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+// NewClient returns an HTTP client, timing out after ms milliseconds.
+func NewClient(ms int) *http.Client {
+	return &http.Client{Timeout: time.Duration(ms) * time.Millisecond}
+}
+
+// TextTemplate returns the text template named name, parsed from s.
+func TextTemplate(name, s string) (*template.Template, error) {
+	return template.New(name).Parse(s)
+}
+
+// HTMLTemplate returns the HTML template named name, parsed from s.
+func HTMLTemplate(name, s string) (*htmltemplate.Template, error) {
+	return htmltemplate.New(name).Parse(s)
+}
@@ -0,0 +1,60 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import exttypes
+
+buf = exttypes.NewBuffer("hello")
+print("buf = %s" % (buf,))
+print("buf.Len() = %s" % (buf.Len(),))
+n = buf.WriteString(" world")
+print("buf.WriteString(' world') = %s" % (n,))
+print("buf.String() = %r" % (buf.String(),))
+print("buf.ReadString(32) = %r" % (buf.ReadString(32),))
+print("buf.String() = %r" % (buf.String(),))
+buf.Reset()
+print("buf.Len() = %s" % (buf.Len(),))
+
+r = exttypes.NewReader("some data")
+print("ReadAll(r) = %r" % (exttypes.ReadAll(r),))
+print("ReadAll(r) = %r" % (exttypes.ReadAll(r),))
+
+buf = exttypes.Buffer()
+print("exttypes.Buffer() = %r" % (buf.String(),))
+print("buf.Write(b'hello') = %s" % (buf.Write(b"hello"),))
+print("buf.Write(bytearray(b' world')) = %s" % (buf.Write(bytearray(b" world")),))
+print("buf.String() = %r, buf.Len() = %s" % (buf.String(), buf.Len()))
+print("buf.Next(3) = %r" % (bytes(bytearray(buf.Next(3))),))
+p = exttypes.SliceByte(bytearray(4))
+print("buf.Read(p) = %s, p = %r" % (buf.Read(p), bytes(bytearray(p))))
+print("buf.Bytes() = %r" % (bytes(bytearray(buf.Bytes())),))
+
+# buffers are also read and written as python files are.
+buf = exttypes.Buffer()
+print("buf.write(b'hello world') = %s" % (buf.write(b"hello world"),))
+print("len(buf) = %s" % (len(buf),))
+print("buf.read(5) = %r" % (buf.read(5),))
+print("buf.string() = %r, len(buf) = %s" % (buf.string(), len(buf)))
+print("buf.read(-1) = %r, len(buf) = %s" % (buf.read(-1), len(buf)))
+
+r = exttypes.NewReader("more data")
+p = exttypes.SliceByte(bytearray(4))
+print("r.Read(p) = %s, p = %r" % (r.Read(p), bytes(bytearray(p))))
+print("ReadAll(r) = %r" % (exttypes.ReadAll(r),))
+
+# the fields of types from other packages which can not be exchanged with
+# python, such as the CheckRedirect func of http.Client, are not exposed.
+c = exttypes.NewClient(1500)
+print("c.Timeout = %s" % (c.Timeout,))
+print("c has CheckRedirect: %s" % (hasattr(c, "CheckRedirect"),))
+c.CloseIdleConnections()
+
+# text/template and html/template both declare a Template type.
+t = exttypes.TextTemplate("text", "{{.}}")
+h = exttypes.HTMLTemplate("html", "{{.}}")
+print("t.Name() = %r, h.Name() = %r" % (t.Name(), h.Name()))
+print("same type: %s" % (type(t) is type(h),))
+print("t.Lookup('text').Name() = %r" % (t.Lookup("text").Name(),))
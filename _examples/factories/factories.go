@@ -0,0 +1,36 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package factories tests the _new_<Type> functions rebuilding values of
+// the wrapped types from their state.
+package factories
+
+import "fmt"
+
+// Point is a point on a plane.
+type Point struct {
+	X, Y int
+}
+
+// String returns a string representation of the point.
+func (p Point) String() string {
+	return fmt.Sprintf("Point{X: %d, Y: %d}", p.X, p.Y)
+}
+
+// Level is a level.
+type Level int
+
+// Triple holds three ints.
+type Triple [3]int
+
+// Path is a list of points.
+type Path []Point
+
+// Ages maps names to ages.
+type Ages map[string]int
+
+// Len returns the number of points of the path.
+func (p Path) Len() int {
+	return len(p)
+}
@@ -0,0 +1,32 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import copy_reg
+import pickle
+
+import factories
+
+p = factories._new_Point({'X': 1, 'Y': 2})
+print("factories._new_Point({'X': 1, 'Y': 2}) = %s" % p)
+print("factories._new_Level(3) = %s" % factories._new_Level(3))
+print("factories._new_Triple([1, 2, 3]) = %s" % list(factories._new_Triple([1, 2, 3])))
+print("len(factories._new_Path([p, p])) = %s" % len(factories._new_Path([p, p])))
+print("factories._new_Ages({'alice': 42})['alice'] = %s" % factories._new_Ages({'alice': 42})['alice'])
+
+try:
+    factories._new_Point([1, 2])
+except TypeError as err:
+    print("caught: %s" % err)
+
+f = factories._new_Point
+print("%s.%s" % (f.__module__, f.__name__))
+print(f.__doc__)
+
+# the factories are found by their qualified name, as pickle needs.
+copy_reg.pickle(factories.Point, lambda p: (factories._new_Point, ({'X': p.X, 'Y': p.Y},)))
+q = pickle.loads(pickle.dumps(factories.Point(X=4, Y=5)))
+print("pickle.loads(pickle.dumps(factories.Point(X=4, Y=5))) = %s" % q)
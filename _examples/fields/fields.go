@@ -0,0 +1,65 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package fields tests the getters of struct fields, returning copies or,
+// with //gopy:ref, references aliasing the struct.
+package fields
+
+// Point is a point of the plane.
+type Point struct {
+	X, Y int
+}
+
+// Pair is a pair of ints.
+type Pair [2]int
+
+// Corners are the corners of a rectangle.
+type Corners [2]Point
+
+// Segment joins two points.
+type Segment struct {
+	// A is read as a copy.
+	A Point
+
+	// B is read by reference.
+	//
+	//gopy:ref
+	B Point
+
+	// Steps is read as a copy of the array.
+	Steps Pair
+
+	// Marks is read by reference.
+	//
+	//gopy:ref
+	Marks Pair
+
+	Name string
+}
+
+// Shape holds its points by reference.
+//
+//gopy:ref
+type Shape struct {
+	Center Point
+	Corner Corners
+	Size   int // Size is the side of the shape.
+}
+
+// Label is a text at a point.
+type Label struct {
+	// Point is the position of the text.
+	Point
+	Text string
+}
+
+// NewSegment returns the segment from a to b.
+func NewSegment(a, b Point) *Segment {
+	return &Segment{A: a, B: b}
+}
+
+// Sum returns the sum of the coordinates of the points of s.
+func (s *Segment) Sum() int {
+	return s.A.X + s.A.Y + s.B.X + s.B.Y
+}
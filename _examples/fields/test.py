@@ -0,0 +1,53 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import gc
+
+import fields
+
+s = fields.NewSegment(fields.Point(X=1, Y=2), fields.Point(X=3, Y=4))
+print("s.Sum() = %d" % (s.Sum(),))
+
+a = s.A
+a.X = 10
+print("a.X = 10: s.A.X = %d, a.X = %d" % (s.A.X, a.X))
+s.A.Y = 20
+print("s.A.Y = 20: s.A.Y = %d" % (s.A.Y,))
+
+b = s.B
+b.X = 30
+print("b.X = 30: s.B.X = %d" % (s.B.X,))
+s.B.Y = 40
+print("s.B.Y = 40: s.B.Y = %d" % (s.B.Y,))
+print("s.Sum() = %d" % (s.Sum(),))
+
+steps = s.Steps
+steps[0] = 5
+print("steps[0] = 5: s.Steps[0] = %d" % (s.Steps[0],))
+marks = s.Marks
+marks[0] = 6
+print("marks[0] = 6: s.Marks[0] = %d" % (s.Marks[0],))
+
+s.B = fields.Point(X=7, Y=8)
+print("s.B = Point(7, 8): b.X = %d, b.Y = %d" % (b.X, b.Y))
+
+# the references keep the struct alive.
+b = fields.NewSegment(fields.Point(), fields.Point(X=9)).B
+gc.collect()
+print("b.X = %d" % (b.X,))
+
+sh = fields.Shape()
+sh.Center.X = 1
+sh.Corner[1].Y = 2
+print("sh.Center.X = %d, sh.Corner[1].Y = %d" % (sh.Center.X, sh.Corner[1].Y))
+
+# the getters are documented by the doc comments of the fields.
+print("Segment.A.__doc__ = %r" % (fields.Segment.A.__doc__,))
+print("Segment.B.__doc__ = %r" % (fields.Segment.B.__doc__,))
+print("Segment.Name.__doc__ = %r" % (fields.Segment.Name.__doc__,))
+print("Shape.Size.__doc__ = %r" % (fields.Shape.Size.__doc__,))
+print("Label.Point.__doc__ = %r" % (fields.Label.Point.__doc__,))
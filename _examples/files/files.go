@@ -0,0 +1,60 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package files tests the exchange of *os.File values with python, as
+// file descriptors.
+package files
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// WriteString writes s to f.
+func WriteString(f *os.File, s string) error {
+	_, err := f.WriteString(s)
+	return err
+}
+
+// ReadAll reads f until EOF.
+func ReadAll(f *os.File) (string, error) {
+	data, err := ioutil.ReadAll(f)
+	return string(data), err
+}
+
+// Close closes f.
+func Close(f *os.File) error {
+	return f.Close()
+}
+
+// IsNil returns whether f is nil.
+func IsNil(f *os.File) bool {
+	return f == nil
+}
+
+// Open opens the named file for reading.
+func Open(name string) (*os.File, error) {
+	return os.Open(name)
+}
+
+// Stdout returns the standard output of the process.
+func Stdout() *os.File {
+	return os.Stdout
+}
+
+// Null returns a nil file.
+func Null() *os.File {
+	return nil
+}
+
+// Logger writes messages to a file.
+type Logger struct {
+	Out    *os.File
+	Prefix string
+}
+
+// Log writes msg to the output of the logger.
+func (l *Logger) Log(msg string) error {
+	return WriteString(l.Out, l.Prefix+msg+"\n")
+}
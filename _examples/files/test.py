@@ -0,0 +1,88 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import os
+import shutil
+import tempfile
+
+import files
+
+print("r, w = os.pipe()")
+r, w = os.pipe()
+print("files.WriteString(w, 'from go\\n')")
+files.WriteString(w, 'from go\n')
+print("files.Close(w)")
+files.Close(w)
+print("os.write(w, 'still open\\n')")
+os.write(w, 'still open\n')
+os.close(w)
+print("os.read(r, 64) = %r" % (os.read(r, 64),))
+os.close(r)
+
+tmp = tempfile.mkdtemp()
+try:
+    name = os.path.join(tmp, "data.txt")
+    print("f = open(name, 'w')")
+    with open(name, 'w') as f:
+        f.write('from python\n')
+        print("files.WriteString(f, 'from go\\n')")
+        files.WriteString(f, 'from go\n')
+        f.write('from python again\n')
+    print("open(name).read() = %r" % (open(name).read(),))
+
+    print("fd = files.Open(name)")
+    fd = files.Open(name)
+    print("type(fd) = %s" % (type(fd).__name__,))
+    with os.fdopen(fd) as f:
+        print("f.read() = %r" % (f.read(),))
+
+    print("files.ReadAll(open(name)) = %r" % (files.ReadAll(open(name)),))
+
+    try:
+        print("files.Open(missing)")
+        files.Open(os.path.join(tmp, "missing.txt"))
+    except Exception as err:
+        print("caught: %s" % (type(err).__name__,))
+finally:
+    shutil.rmtree(tmp)
+
+print("files.IsNil(None) = %s" % (files.IsNil(None),))
+print("files.Null() = %s" % (files.Null(),))
+
+fd = files.Stdout()
+print("fd != 1: %s" % (fd != 1,))
+os.close(fd)
+
+try:
+    print("files.WriteString('x', 'y')")
+    files.WriteString('x', 'y')
+except TypeError as err:
+    print("caught: %s" % (err,))
+
+try:
+    print("files.WriteString(bad, 'y')")
+    bad = os.open(os.devnull, os.O_RDONLY)
+    os.close(bad)
+    files.WriteString(bad, 'y')
+except OSError as err:
+    print("caught: %s" % (err.strerror,))
+
+print("l = files.Logger()")
+r, w = os.pipe()
+l = files.Logger()
+l.Out = w
+l.Prefix = '> '
+os.close(w)
+print("l.Log('hello')")
+l.Log('hello')
+out = l.Out
+print("type(l.Out) = %s" % (type(out).__name__,))
+os.close(out)
+l.Out = None
+print("l.Out = %s" % (l.Out,))
+print("os.read(r, 64) = %r" % (os.read(r, 64),))
+os.close(r)
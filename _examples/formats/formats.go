@@ -0,0 +1,40 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package formats tests the formatting of go values with go fmt verbs.
+package formats
+
+import "fmt"
+
+// Point is a point of the plane.
+type Point struct {
+	X, Y int
+}
+
+// Celsius is a temperature.
+type Celsius float64
+
+// Level is a log level.
+type Level int
+
+// String returns the name of the level.
+func (l Level) String() string {
+	switch l {
+	case 0:
+		return "debug"
+	case 1:
+		return "info"
+	}
+	return fmt.Sprintf("level-%d", int(l))
+}
+
+// Temp returns a temperature.
+func Temp() Celsius {
+	return 21.5
+}
+
+// Info returns the info level.
+func Info() Level {
+	return 1
+}
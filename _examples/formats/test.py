@@ -0,0 +1,32 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import formats
+
+p = formats.Point(X=1, Y=2)
+print("format(p) = %s" % (format(p),))
+print("format(p, '+v') = %s" % (format(p, "+v"),))
+print("format(p, '#v') = %s" % (format(p, "#v"),))
+print("'{:v}'.format(p) = %s" % ("{:v}".format(p),))
+
+t = formats.Temp()
+print("format(t, '6.2f') = [%s]" % (format(t, "6.2f"),))
+print("format(t, 'e') = %s" % (format(t, "e"),))
+
+l = formats.Info()
+print("format(l, 'v') = %s" % (format(l, "v"),))
+print("format(l, 'd') = %s" % (format(l, "d"),))
+print("format(l, '03d') = %s" % (format(l, "03d"),))
+
+for spec in ["s", "5", "vv", "%v"]:
+    try:
+        format(p, spec)
+        print("format(p, %r): no error" % (spec,))
+    except ValueError as e:
+        print("format(p, %r): ValueError: %s" % (spec, e))
+
+print("doc(p.__format__) = %r" % (p.__format__.__doc__,))
@@ -5,6 +5,8 @@
 package funcs
 
 import (
+	"fmt"
+
 	"github.com/go-python/gopy/_examples/cpkg"
 )
 
@@ -33,6 +35,68 @@ type S2 struct {
 	F3 [5]func()
 }
 
+// Op combines two ints.
+type Op func(a, b int) int
+
+// Plugin is configured with funcs, which may be python callables.
+type Plugin struct {
+	Name  string
+	Op    Op
+	Check func(v int) error
+}
+
+// Run applies the op of the plugin to a and b, and checks the result.
+func (p *Plugin) Run(a, b int) (int, error) {
+	if p.Op == nil {
+		return 0, fmt.Errorf("funcs: plugin %q has no op", p.Name)
+	}
+	v := p.Op(a, b)
+	if p.Check != nil {
+		if err := p.Check(v); err != nil {
+			return 0, fmt.Errorf("funcs: plugin %q: %v", p.Name, err)
+		}
+	}
+	return v, nil
+}
+
+// Spawn returns op(a, b), called on another goroutine.
+func Spawn(op Op, a, b int) int {
+	ch := make(chan int)
+	go func() {
+		ch <- op(a, b)
+	}()
+	return <-ch
+}
+
+// Go returns the op of the plugin applied to a and b, called on another
+// goroutine.
+func (p *Plugin) Go(a, b int) int {
+	return Spawn(p.Op, a, b)
+}
+
+var hooks []Op
+
+// Register stores op, to be called by Fire.
+func Register(op Op) {
+	hooks = append(hooks, op)
+}
+
+// Fire returns the sum of the results of the ops stored by Register,
+// applied to a and b, each called on its own goroutine.
+func Fire(a, b int) int {
+	ch := make(chan int)
+	for _, op := range hooks {
+		go func(op Op) {
+			ch <- op(a, b)
+		}(op)
+	}
+	sum := 0
+	for range hooks {
+		sum += <-ch
+	}
+	return sum
+}
+
 func init() {
 	F1 = func() {
 		cpkg.Printf("calling F1\n")
@@ -27,3 +27,66 @@ print("s2.F1 = funcs.GetF1()...")
 s2.F1 = funcs.GetF1()
 print("s2.F1() = %s" % s2.F1())
 
+print("s1.F1 = python func...")
+def hello():
+    print("calling python func")
+s1.F1 = hello
+print("s1.F1() = %s" % s1.F1())
+
+print("s2.F1 = python lambda...")
+s2.F1 = lambda: print("calling python lambda")
+print("s2.F1() = %s" % s2.F1())
+
+print("funcs.Op(python lambda)...")
+op = funcs.Op(lambda a, b: a * b)
+print("op(6, 7) = %s" % op(6, 7))
+
+def check(v):
+    if v > 10:
+        raise ValueError("%d is too big" % v)
+
+print("p = funcs.Plugin(Name='add', Op=python lambda, Check=python func)...")
+p = funcs.Plugin(Name="add", Op=lambda a, b: a + b, Check=check)
+print("p.Run(1, 2) = %s" % p.Run(1, 2))
+try:
+    p.Run(10, 20)
+except Exception as err:
+    print("caught: %s" % err)
+
+print("p.Op = 42...")
+try:
+    p.Op = 42
+except TypeError as err:
+    print("caught: %s" % err)
+
+print("funcs.Spawn(funcs.Op(python lambda), 6, 7)...")
+print("funcs.Spawn(...) = %s" % funcs.Spawn(funcs.Op(lambda a, b: a * b), 6, 7))
+
+## python -> go -> python -> go -> python, on other goroutines.
+def nested(a, b):
+    return funcs.Spawn(funcs.Op(lambda x, y: x - y), a, b) * 10
+
+print("funcs.Spawn(funcs.Op(nested), 5, 3) = %s" % funcs.Spawn(funcs.Op(nested), 5, 3))
+
+## python -> go -> python -> go -> python, on the calling thread.
+inner = funcs.Plugin(Name="inner", Op=lambda a, b: a * b)
+outer = funcs.Plugin(Name="outer", Op=lambda a, b: inner.Run(a, b) + 1)
+print("outer.Run(6, 7) = %s" % (outer.Run(6, 7),))
+
+## callables stored by go, in a field or by an earlier call, called on
+## other goroutines.
+print("p.Go(4, 5) = %s" % (p.Go(4, 5),))
+funcs.Register(funcs.Op(lambda a, b: a + b))
+funcs.Register(funcs.Op(lambda a, b: a * b))
+print("funcs.Fire(4, 5) = %s" % (funcs.Fire(4, 5),))
+
+import threading
+results = []
+def spawn(i):
+    results.append(funcs.Spawn(funcs.Op(nested), i, 1))
+threads = [threading.Thread(target=spawn, args=(i,)) for i in range(4)]
+for t in threads:
+    t.start()
+for t in threads:
+    t.join()
+print("threads: %s" % (sorted(results),))
@@ -0,0 +1,50 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package futures tests the _async variants of the funcs and methods
+// annotated with //gopy:async, returning futures.
+package futures
+
+import (
+	"errors"
+	"time"
+)
+
+// Sleep sleeps for ms milliseconds and returns ms.
+//
+//gopy:async
+func Sleep(ms int) int {
+	time.Sleep(time.Duration(ms) * time.Millisecond)
+	return ms
+}
+
+// Add returns a+b, without an _async variant.
+func Add(a, b int) int {
+	return a + b
+}
+
+// Client is a client of a slow service.
+type Client struct {
+	Name  string
+	Delay int // in milliseconds
+}
+
+// NewClient returns a client waiting for delay milliseconds on each call.
+func NewClient(name string, delay int) *Client {
+	return &Client{Name: name, Delay: delay}
+}
+
+// Fetch returns the value of key, after the delay of c.
+//
+//gopy:async
+func (c *Client) Fetch(key string) (string, error) {
+	time.Sleep(time.Duration(c.Delay) * time.Millisecond)
+	if key == "" {
+		return "", errors.New("fetch: empty key")
+	}
+	return c.Name + ":" + key, nil
+}
+
+// Close closes c.
+func (c *Client) Close() {}
@@ -0,0 +1,48 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import sys
+import threading
+import time
+
+import futures
+
+print("futures.Sleep(1):", futures.Sleep(1))
+print("hasattr(futures, 'Add_async'):", hasattr(futures, "Add_async"))
+
+# the calls run concurrently, with the GIL released.
+start = time.time()
+fs = [futures.Sleep_async(200) for _ in range(5)]
+print("results:", [f.result(timeout=5) for f in fs])
+print("concurrent:", time.time() - start < 0.8)
+print("done:", all(f.done() for f in fs))
+
+c = futures.NewClient("svc", 50)
+f = c.Fetch_async("k")
+print("c.Fetch_async('k'):", f.result(timeout=5))
+print("hasattr(c, 'Close_async'):", hasattr(c, "Close_async"))
+
+try:
+    c.Fetch_async("").result(timeout=5)
+    print("*ERROR* no exception raised!")
+except Exception as err:
+    print("caught:", err)
+
+# callbacks run once the result is set.
+got = threading.Event()
+results = []
+def done(f):
+    results.append(f.result())
+    got.set()
+c.Fetch_async("cb").add_done_callback(done)
+got.wait(5)
+print("callback:", results)
+
+print("doc:", futures.Sleep_async.__doc__.splitlines()[0])
+
+if sys.version_info >= (3, 7):
+    import test_asyncio
@@ -0,0 +1,43 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## the _async variants are awaited from a running asyncio loop, which keeps
+## running the other coroutines meanwhile. python-3.7 or later only.
+import asyncio
+
+import futures
+
+
+async def tick(ticks, stop):
+    while not stop.is_set():
+        ticks.append(None)
+        await asyncio.sleep(0.01)
+
+
+async def main():
+    ticks = []
+    stop = asyncio.Event()
+    ticker = asyncio.ensure_future(tick(ticks, stop))
+    await asyncio.sleep(0)
+
+    f = futures.Sleep_async(300)
+    print("asyncio future:", asyncio.isfuture(f))
+    n = len(ticks)
+    print("await Sleep_async(300):", await f)
+    # the ticker runs every 10ms: blocked, it would not have run at all.
+    print("loop kept running:", len(ticks) - n >= 10)
+
+    c = futures.NewClient("svc", 100)
+    print("gather:", await asyncio.gather(c.Fetch_async("a"), c.Fetch_async("b")))
+    try:
+        await c.Fetch_async("")
+        print("*ERROR* no exception raised!")
+    except Exception as err:
+        print("caught:", err)
+
+    stop.set()
+    await ticker
+
+
+asyncio.run(main())
@@ -0,0 +1,84 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package generics tests the wrapping of instantiated generic types.
+package generics
+
+import "fmt"
+
+// List is a list of values.
+type List[T any] struct {
+	items []T
+}
+
+// Append appends v to the list.
+func (l *List[T]) Append(v T) {
+	l.items = append(l.items, v)
+}
+
+// At returns the i-th value of the list.
+func (l *List[T]) At(i int) T {
+	return l.items[i]
+}
+
+// Len returns the number of values in the list.
+func (l *List[T]) Len() int {
+	return len(l.items)
+}
+
+// String implements fmt.Stringer.
+func (l *List[T]) String() string {
+	return fmt.Sprintf("List%v", l.items)
+}
+
+// Pair holds two values of the same type.
+type Pair[T any] struct {
+	First, Second T
+}
+
+// Swap swaps the values of the pair.
+func (p *Pair[T]) Swap() {
+	p.First, p.Second = p.Second, p.First
+}
+
+// Unused is never instantiated: it is not wrapped.
+type Unused[T any] struct {
+	V T
+}
+
+// Ints returns a list of ints.
+func Ints(vs ...int) *List[int] {
+	l := &List[int]{}
+	for _, v := range vs {
+		l.Append(v)
+	}
+	return l
+}
+
+// Strings returns an empty list of strings.
+func Strings() *List[string] {
+	return &List[string]{}
+}
+
+// Sum returns the sum of the values of l.
+func Sum(l *List[int]) int {
+	sum := 0
+	for _, v := range l.items {
+		sum += v
+	}
+	return sum
+}
+
+// MakePair returns a pair of strings.
+func MakePair(first, second string) Pair[string] {
+	return Pair[string]{First: first, Second: second}
+}
+
+// Pairs returns a list of pairs of ints.
+func Pairs() *List[Pair[int]] {
+	l := &List[Pair[int]]{}
+	l.Append(Pair[int]{1, 2})
+	l.Append(Pair[int]{3, 4})
+	return l
+}
@@ -0,0 +1,37 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import generics
+
+ints = generics.Ints(1, 2, 3)
+print("type(ints) = %s" % (type(ints).__name__,))
+print("doc(ints) = %r" % (type(ints).__doc__,))
+ints.Append(4)
+print("ints.Len() = %s" % (ints.Len(),))
+print("ints.At(3) = %s" % (ints.At(3),))
+print("ints = %s" % (ints,))
+print("generics.Sum(ints) = %s" % (generics.Sum(ints),))
+
+strs = generics.Strings()
+print("type(strs) = %s" % (type(strs).__name__,))
+strs.Append("hello")
+print("strs.At(0) = %s" % (strs.At(0),))
+
+p = generics.MakePair("a", "b")
+print("type(p) = %s" % (type(p).__name__,))
+p.Swap()
+print("p.First, p.Second = %s, %s" % (p.First, p.Second))
+
+pairs = generics.Pairs()
+print("type(pairs) = %s" % (type(pairs).__name__,))
+print("pairs.At(1).Second = %s" % (pairs.At(1).Second,))
+
+l = generics.ListInt()
+l.Append(7)
+print("generics.Sum(l) = %s" % (generics.Sum(l),))
+
+print("hasattr(generics, 'Unused') = %s" % (hasattr(generics, 'Unused'),))
@@ -0,0 +1,40 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package implements tests the checking of //gopy:implements directives.
+package implements
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Upper reads the upper-cased contents of a string.
+//
+//gopy:implements io.Reader fmt.Stringer
+type Upper struct {
+	r *strings.Reader
+}
+
+// NewUpper returns an Upper reading s.
+func NewUpper(s string) *Upper {
+	return &Upper{strings.NewReader(strings.ToUpper(s))}
+}
+
+// Read implements io.Reader.
+func (u *Upper) Read(p []byte) (int, error) {
+	return u.r.Read(p)
+}
+
+// String implements fmt.Stringer.
+func (u *Upper) String() string {
+	return fmt.Sprintf("Upper(%d bytes left)", u.r.Len())
+}
+
+// ReadAll returns everything left in r.
+func ReadAll(r io.Reader) (string, error) {
+	b, err := io.ReadAll(r)
+	return string(b), err
+}
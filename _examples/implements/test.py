@@ -0,0 +1,13 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import implements
+
+u = implements.NewUpper("hello")
+print("u:", u)
+print("implements.ReadAll(u):", implements.ReadAll(u))
+print("u:", u)
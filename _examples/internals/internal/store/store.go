@@ -0,0 +1,72 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package store is an internal package, whose types are returned by the
+// funcs of package internals.
+package store
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Store is a key-value store.
+type Store struct {
+	Name  string
+	items map[string]string
+}
+
+// New returns a new empty store.
+func New(name string) *Store {
+	return &Store{Name: name, items: make(map[string]string)}
+}
+
+// Put sets the value of key.
+func (s *Store) Put(key, value string) { s.items[key] = value }
+
+// Get returns the value of key, if any.
+func (s *Store) Get(key string) (string, bool) {
+	v, ok := s.items[key]
+	return v, ok
+}
+
+// Keys returns the keys of the store, sorted.
+func (s *Store) Keys() []string {
+	keys := make([]string, 0, len(s.items))
+	for k := range s.items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Snapshot returns a copy of the store.
+func (s *Store) Snapshot() Snapshot {
+	return Snapshot{n: len(s.items)}
+}
+
+// Merge puts the items of other into the store.
+func (s *Store) Merge(other *Store) error {
+	if other == nil {
+		return fmt.Errorf("store: merge of a nil store")
+	}
+	for k, v := range other.items {
+		s.items[k] = v
+	}
+	return nil
+}
+
+// Snapshot is a value copy of a store.
+type Snapshot struct{ n int }
+
+// Len returns the number of items of the snapshot.
+func (s Snapshot) Len() int { return s.n }
+
+// String returns the string form of the snapshot.
+func (s Snapshot) String() string { return fmt.Sprintf("snapshot of %d item(s)", s.n) }
+
+// Counter counts.
+type Counter interface {
+	Count() int
+}
@@ -0,0 +1,48 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package internals tests the wrapping of the types of internal packages,
+// returned by its funcs.
+package internals
+
+import "github.com/go-python/gopy/_examples/internals/internal/store"
+
+// Open returns a new store.
+func Open(name string) *store.Store {
+	return store.New(name)
+}
+
+// Len returns the number of items of s.
+func Len(s *store.Store) int {
+	if s == nil {
+		return -1
+	}
+	return len(s.Keys())
+}
+
+// Freeze returns a snapshot of s.
+func Freeze(s *store.Store) store.Snapshot {
+	return s.Snapshot()
+}
+
+// Sized returns the counter of the items of s.
+func Sized(s *store.Store) store.Counter {
+	return counter{s}
+}
+
+type counter struct{ s *store.Store }
+
+func (c counter) Count() int { return len(c.s.Keys()) }
+
+// Cache caches a store.
+type Cache struct {
+	Hits  int
+	store *store.Store
+}
+
+// NewCache returns a cache of s.
+func NewCache(s *store.Store) *Cache { return &Cache{store: s} }
+
+// Store returns the store of the cache.
+func (c *Cache) Store() *store.Store { return c.store }
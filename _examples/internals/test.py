@@ -0,0 +1,54 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import internals
+
+s = internals.Open("main")
+print("type(s) = %s" % (type(s).__name__,))
+s.Put("a", "1")
+s.Put("b", "2")
+print("s.Get('a') = %r" % (s.Get("a"),))
+print("s.Get('z') = %r" % (s.Get("z"),))
+print("internals.Len(s) = %d" % (internals.Len(s),))
+print("internals.Len(None) = %d" % (internals.Len(None),))
+
+other = internals.Open("other")
+other.Put("c", "3")
+s.Merge(other)
+print("after merge: internals.Len(s) = %d" % (internals.Len(s),))
+try:
+    s.Merge(None)
+except RuntimeError as e:
+    print("caught RuntimeError: %s" % (e,))
+
+snap = internals.Freeze(s)
+print("snap = %s" % (snap,))
+print("snap.Len() = %d" % (snap.Len(),))
+
+c = internals.Sized(s)
+print("c.Count() = %d" % (c.Count(),))
+
+cache = internals.NewCache(s)
+print("cache.Store().Get('c') = %r" % (cache.Store().Get("c"),))
+
+print("str(s) = %s" % (s,))
+print("format(snap, 'T') = %s" % (format(snap, "T"),))
+print("hasattr(s, 'Name') = %s" % (hasattr(s, "Name"),))
+try:
+    internals.Len(42)
+except TypeError as e:
+    print("caught TypeError: %s" % (e,))
+
+n = internals._gopy_handle_count()
+objs = [internals.Open("tmp") for _ in range(10)]
+del objs
+print("handles released: %s" % (internals._gopy_handle_count() == n,))
+
+try:
+    type(s)()
+except TypeError as e:
+    print("caught TypeError: %s" % (e,))
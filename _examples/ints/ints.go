@@ -0,0 +1,42 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package ints tests the python values of the 64-bit ints, which are only
+// longs in python 2 when they do not fit an int.
+package ints
+
+import "math"
+
+// ID is an identifier.
+type ID uint64
+
+// Next returns the identifier following id.
+func (id ID) Next() ID { return id + 1 }
+
+// Counts holds 64-bit counters.
+type Counts struct {
+	Signed   int64
+	Unsigned uint64
+}
+
+// Int64 returns 5.
+func Int64() int64 { return 5 }
+
+// Uint64 returns 5.
+func Uint64() uint64 { return 5 }
+
+// Uint returns 5.
+func Uint() uint { return 5 }
+
+// MaxUint64 returns the largest uint64.
+func MaxUint64() uint64 { return math.MaxUint64 }
+
+// Pair returns 1 and 2.
+func Pair() (int64, uint64) { return 1, 2 }
+
+// NewCounts returns counters set to 7 and 8.
+func NewCounts() *Counts { return &Counts{Signed: 7, Unsigned: 8} }
+
+// Totals returns totals by name.
+func Totals() map[string]uint64 { return map[string]uint64{"a": 1} }
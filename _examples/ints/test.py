@@ -0,0 +1,19 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import ints
+
+print("ints.Int64() = %r" % (ints.Int64(),))
+print("ints.Uint64() = %r" % (ints.Uint64(),))
+print("ints.Uint() = %r" % (ints.Uint(),))
+print("ints.MaxUint64() == 2**64-1: %s" % (ints.MaxUint64() == 2**64-1,))
+print("ints.Pair() = %r" % (ints.Pair(),))
+c = ints.NewCounts()
+print("c.Signed, c.Unsigned = %r, %r" % (c.Signed, c.Unsigned))
+print("ints.Totals()['a'] = %r" % (ints.Totals()["a"],))
+print("ints.ID(41).Next() = %r" % (ints.ID(41).Next(),))
+print("ints.Uint64() == 5: %s" % (ints.Uint64() == 5,))
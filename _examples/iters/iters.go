@@ -0,0 +1,52 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package iters tests the wrapping of iter.Seq and iter.Seq2 iterators.
+package iters
+
+import "iter"
+
+// Count yields the ints from 0 to n-1.
+func Count(n int) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for i := 0; i < n; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	}
+}
+
+// Pairs yields the words with their lengths.
+func Pairs(words []string) iter.Seq2[string, int] {
+	return func(yield func(string, int) bool) {
+		for _, w := range words {
+			if !yield(w, len(w)) {
+				return
+			}
+		}
+	}
+}
+
+// stopped counts the iterations over Letters which were stopped early.
+var stopped int
+
+// Letters yields the letters of s, counting the early stops of the
+// iterations.
+func Letters(s string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for _, r := range s {
+			if !yield(string(r)) {
+				stopped++
+				return
+			}
+		}
+	}
+}
+
+// Stopped returns the number of iterations over Letters which were stopped
+// before their end.
+func Stopped() int {
+	return stopped
+}
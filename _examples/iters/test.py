@@ -0,0 +1,44 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import iters
+
+print("for i in iters.Count(3):")
+for i in iters.Count(3):
+    print("  %d" % i)
+
+seq = iters.Count(4)
+print("list(seq) = %s" % list(seq))
+print("list(seq) = %s" % list(seq))
+print("sum(iters.Count(5)) = %d" % sum(iters.Count(5)))
+print("list(iters.Count(0)) = %s" % list(iters.Count(0)))
+
+print("for w, n in iters.Pairs(['go', 'python']):")
+for w, n in iters.Pairs(["go", "python"]):
+    print("  %s %d" % (w, n))
+
+it = iter(iters.Letters("gopy"))
+print("next(it) = %s" % next(it))
+print("next(it) = %s" % next(it))
+print("iters.Stopped() = %d" % iters.Stopped())
+del it
+print("iters.Stopped() = %d" % iters.Stopped())
+
+for c in iters.Letters("abc"):
+    if c == "b":
+        break
+print("iters.Stopped() = %d" % iters.Stopped())
+
+print("list(iters.Letters('ok')) = %s" % list(iters.Letters("ok")))
+print("iters.Stopped() = %d" % iters.Stopped())
+
+it = iter(iters.Count(1))
+print("next(it) = %d" % next(it))
+try:
+    next(it)
+except StopIteration:
+    print("caught: StopIteration")
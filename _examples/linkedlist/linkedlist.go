@@ -0,0 +1,66 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package linkedlist tests the wrapping of self-referential struct types.
+package linkedlist
+
+// Node is a node of a singly linked list of ints.
+type Node struct {
+	Val  int
+	Next *Node
+}
+
+// Push returns a new node holding val, in front of the list head.
+func Push(head *Node, val int) *Node {
+	return &Node{Val: val, Next: head}
+}
+
+// Len returns the number of nodes of the list head.
+func Len(head *Node) int {
+	n := 0
+	for ; head != nil; head = head.Next {
+		n++
+	}
+	return n
+}
+
+// Sum returns the sum of the values of the list head.
+func (n *Node) Sum() int {
+	sum := 0
+	for ; n != nil; n = n.Next {
+		sum += n.Val
+	}
+	return sum
+}
+
+// Reverse returns the list head, reversed in place.
+func Reverse(head *Node) *Node {
+	var prev *Node
+	for head != nil {
+		head, head.Next, prev = head.Next, prev, head
+	}
+	return prev
+}
+
+// Dir is a directory, holding its first file.
+type Dir struct {
+	Name  string
+	First *File
+}
+
+// File is a file, linked to its directory and to the next file.
+type File struct {
+	Name string
+	Dir  *Dir
+	Next *File
+}
+
+// NewDir returns a directory holding the files names, in order.
+func NewDir(name string, names ...string) *Dir {
+	d := &Dir{Name: name}
+	for i := len(names) - 1; i >= 0; i-- {
+		d.First = &File{Name: names[i], Dir: d, Next: d.First}
+	}
+	return d
+}
@@ -0,0 +1,41 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import linkedlist
+
+def values(head):
+    vals = []
+    while head is not None:
+        vals.append(head.Val)
+        head = head.Next
+    return vals
+
+head = None
+for v in [1, 2, 3]:
+    head = linkedlist.Push(head, v)
+print("values(head) = %s" % (values(head),))
+print("linkedlist.Len(head) = %d" % (linkedlist.Len(head),))
+print("head.Sum() = %d" % (head.Sum(),))
+print("linkedlist.Len(None) = %d" % (linkedlist.Len(None),))
+
+head = linkedlist.Reverse(head)
+print("values(reversed) = %s" % (values(head),))
+
+# build a list from python, through the Next field.
+n1 = linkedlist.Node(Val=10)
+n2 = linkedlist.Node(Val=20)
+n1.Next = n2
+print("values(n1) = %s" % (values(n1),))
+print("n2.Next is None: %s" % (n2.Next is None,))
+
+d = linkedlist.NewDir("etc", "hosts", "passwd")
+f = d.First
+names = []
+while f is not None:
+    names.append("%s/%s" % (f.Dir.Name, f.Name))
+    f = f.Next
+print("files = %s" % (names,))
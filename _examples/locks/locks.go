@@ -0,0 +1,94 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package locks tests the wrapping of types holding sync primitives,
+// which must never be copied.
+package locks
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a counter safe for concurrent use.
+type Counter struct {
+	mu sync.Mutex
+	n  int
+}
+
+// Inc increments c and returns its new value.
+func (c *Counter) Inc() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.n++
+	return c.n
+}
+
+// Value returns the value of c.
+func (c *Counter) Value() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.n
+}
+
+// Registry maps names to values.
+type Registry struct {
+	sync.RWMutex
+	m sync.Map
+
+	Name  string
+	Hits  Counter
+	Count atomic.Int64
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry(name string) *Registry {
+	return &Registry{Name: name}
+}
+
+// Store sets the value of key.
+func (r *Registry) Store(key, value string) {
+	r.m.Store(key, value)
+	r.Count.Add(1)
+}
+
+// Load returns the value of key, or "" if key is not set.
+func (r *Registry) Load(key string) string {
+	r.Hits.Inc()
+	v, _ := r.m.Load(key)
+	s, _ := v.(string)
+	return s
+}
+
+// Lookups returns the number of calls to the Load method of r.
+func (r *Registry) Lookups() int {
+	return r.Hits.Value()
+}
+
+// Stores returns the number of calls to the Store method of r.
+func (r *Registry) Stores() int {
+	return int(r.Count.Load())
+}
+
+// Counters returns n new counters.
+func Counters(n int) []Counter {
+	return make([]Counter, n)
+}
+
+// Total returns the sum of the values of the counters cs.
+func Total(cs []Counter) int {
+	n := 0
+	for i := range cs {
+		n += cs[i].Value()
+	}
+	return n
+}
+
+// Value returns the value of c, which is copied.
+func Value(c Counter) int {
+	return c.n
+}
+
+// Default is the default registry.
+var Default Registry
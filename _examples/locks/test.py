@@ -0,0 +1,42 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import locks
+
+c = locks.Counter()
+c.Inc()
+print("c.Inc():", c.Inc())
+print("str(c):", str(c).startswith("locks.Counter{"))
+print("format(c, 'T'):", format(c, "T"))
+
+r = locks.NewRegistry("reg")
+r.Store("a", "1")
+r.Store("b", "2")
+print("r.Name:", r.Name)
+print("r.Load('a'):", r.Load("a"))
+print("r.Load('c'): %r" % (r.Load("c"),))
+print("r.Lookups():", r.Lookups())
+print("r.Stores():", r.Stores())
+print("hasattr(r, 'Hits'):", hasattr(r, "Hits"))
+print("hasattr(r, 'Count'):", hasattr(r, "Count"))
+
+cs = locks.Counters(3)
+cs[0].Inc()
+cs[2].Inc()
+cs[2].Inc()
+print("locks.Total(cs):", locks.Total(cs))
+try:
+    cs[1] = locks.Counter()
+except TypeError as err:
+    print("caught:", err)
+try:
+    locks.Total([locks.Counter()])
+except TypeError as err:
+    print("caught:", err)
+
+print("hasattr(locks, 'Value'):", hasattr(locks, "Value"))
+print("hasattr(locks, 'GetDefault'):", hasattr(locks, "GetDefault"))
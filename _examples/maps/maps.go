@@ -2,8 +2,15 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
+// package maps tests the wrapping of maps, with basic and struct keys.
 package maps
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
 func MapsFunc(t map[string]int) {
 
 }
@@ -14,3 +21,74 @@ func MapsFunc2() map[int]string {
 		2: "world",
 	}
 }
+
+// Key is a comparable struct, used as a map key.
+type Key struct {
+	Name string
+	ID   int
+}
+
+// Value is a struct, used as a map element.
+type Value struct {
+	Score float64
+}
+
+// Scores returns the scores of a few keys.
+func Scores() map[Key]Value {
+	return map[Key]Value{
+		{Name: "a", ID: 1}: {Score: 0.5},
+		{Name: "b", ID: 2}: {Score: 1.5},
+	}
+}
+
+// Sum returns the sum of the scores in m.
+func Sum(m map[Key]Value) float64 {
+	sum := 0.0
+	for _, v := range m {
+		sum += v.Score
+	}
+	return sum
+}
+
+// Flags is a named map, with methods.
+type Flags map[string]bool
+
+// Enabled returns the sorted names of the enabled flags.
+func (f Flags) Enabled() string {
+	var names []string
+	for k, v := range f {
+		if v {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// Describe returns the items of m, sorted by key.
+func Describe(m map[int]string) string {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	str := make([]string, 0, len(keys))
+	for _, k := range keys {
+		str = append(str, fmt.Sprintf("%d:%s", k, m[k]))
+	}
+	return strings.Join(str, " ")
+}
+
+// Config returns the settings of m, sorted by name.
+func Config(m map[string]int) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	str := make([]string, 0, len(keys))
+	for _, k := range keys {
+		str = append(str, fmt.Sprintf("%s=%d", k, m[k]))
+	}
+	return strings.Join(str, " ")
+}
@@ -0,0 +1,106 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import maps
+
+print("m = maps.MapsFunc2()")
+m = maps.MapsFunc2()
+print("len(m) = %d" % len(m))
+print("m[1] = %s" % m[1])
+print("m[2] = %s" % m[2])
+print("1 in m = %s, 3 in m = %s" % (1 in m, 3 in m))
+
+print("m[3] = 'again'")
+m[3] = "again"
+print("maps.Describe(m) = %s" % maps.Describe(m))
+
+print("del m[1]")
+del m[1]
+print("maps.Describe(m) = %s" % maps.Describe(m))
+
+try:
+    print("m[1]")
+    m[1]
+except KeyError as err:
+    print("caught: KeyError %s" % err)
+
+try:
+    print("del m[1]")
+    del m[1]
+except KeyError as err:
+    print("caught: KeyError %s" % err)
+
+try:
+    print("m['x']")
+    m['x']
+except TypeError as err:
+    print("caught: TypeError: %s" % err)
+
+try:
+    print("m[4] = 4")
+    m[4] = 4
+except TypeError as err:
+    print("caught: TypeError: %s" % err)
+
+print("m.keys() = %s" % m.keys())
+print("m.values() = %s" % m.values())
+print("m.items() = %s" % m.items())
+for k in m:
+    print("for k in m: k = %s, m[k] = %s" % (k, m[k]))
+for k, v in m.items():
+    print("for k, v in m.items(): k = %s, v = %s" % (k, v))
+print("dict(m) = %s" % sorted(dict(m).items()))
+
+print("s = maps.Scores()")
+s = maps.Scores()
+k = maps.Key(Name="a", ID=1)
+print("s[Key(a, 1)].Score = %s" % s[k].Score)
+print("s[Key(c, 3)] = Value(Score=2.0)")
+s[maps.Key(Name="c", ID=3)] = maps.Value(Score=2.0)
+print("len(s) = %d" % len(s))
+print("maps.Sum(s) = %s" % maps.Sum(s))
+print("Key(a, 2) in s = %s" % (maps.Key(Name="a", ID=2) in s))
+
+for k, v in s.items():
+    print("s.items(): %s/%d = %s" % (k.Name, k.ID, v.Score))
+
+try:
+    print("s['a']")
+    s['a']
+except TypeError as err:
+    print("caught: TypeError: %s" % err)
+
+print("f = maps.Flags({'x': True, 'y': False, 'z': True})")
+f = maps.Flags({'x': True, 'y': False, 'z': True})
+print("f.Enabled() = %s" % f.Enabled())
+f['y'] = True
+print("f.Enabled() = %s" % f.Enabled())
+
+print("g = maps.Flags(f)")
+g = maps.Flags(f)
+print("g.keys() = %s" % g.keys())
+
+try:
+    print("maps.Flags([1])")
+    maps.Flags([1])
+except TypeError as err:
+    print("caught: %s" % err)
+
+print("maps.Config({'width': 80, 'height': 24}) = %s" % maps.Config({'width': 80, 'height': 24}))
+print("maps.Describe({1: 'a', 2: 'b'}) = %s" % maps.Describe({1: 'a', 2: 'b'}))
+
+try:
+    print("maps.Config({'width': 80, 'height': 'tall'})")
+    maps.Config({'width': 80, 'height': 'tall'})
+except TypeError as err:
+    print("caught: %s" % err)
+
+try:
+    print("maps.Config({1: 80})")
+    maps.Config({1: 80})
+except TypeError as err:
+    print("caught: %s" % err)
@@ -0,0 +1,45 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package mutual tests types referring to each other.
+package mutual
+
+// Node is a node of a tree, pointing to its Tree.
+type Node struct {
+	Name string
+	Tree *Tree
+}
+
+// Parent returns the root of the tree of n.
+func (n *Node) Parent() *Node {
+	return n.Tree.Root
+}
+
+// Tree is a tree of nodes.
+type Tree struct {
+	Root *Node
+	Walk Walker
+}
+
+// Walker walks from a node to the next one.
+type Walker func(n *Node) *Node
+
+// Visitor visits the nodes of a tree.
+type Visitor interface {
+	Visit(n *Node) *Tree
+}
+
+// Visit implements Visitor.
+func (t *Tree) Visit(n *Node) *Tree {
+	n.Tree = t
+	return t
+}
+
+// New returns a tree with a root node named name.
+func New(name string) *Tree {
+	t := &Tree{}
+	t.Root = &Node{Name: name, Tree: t}
+	t.Walk = func(n *Node) *Node { return n.Tree.Root }
+	return t
+}
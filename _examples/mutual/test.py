@@ -0,0 +1,17 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import mutual
+
+t = mutual.New("root")
+print("t.Root.Name = %s" % (t.Root.Name,))
+print("t.Root.Parent().Name = %s" % (t.Root.Parent().Name,))
+
+n = mutual.Node(Name="leaf")
+t.Visit(n)
+print("n.Parent().Name = %s" % (n.Parent().Name,))
+print("t.Walk(n).Name = %s" % (t.Walk(n).Name,))
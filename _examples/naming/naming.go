@@ -0,0 +1,56 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package naming tests the -naming option of gopy.
+package naming
+
+import (
+	"fmt"
+)
+
+// MaxConns is the default maximum number of connections.
+var MaxConns = 8
+
+// HTTPGetURL returns the URL of a host.
+func HTTPGetURL(host string) string {
+	return "http://" + host
+}
+
+type Server struct {
+	HTTPAddr string
+	MaxConns int
+}
+
+// ConnID returns the identifier of the i-th connection.
+func (s *Server) ConnID(i int) string {
+	return fmt.Sprintf("%s#%d", s.HTTPAddr, i)
+}
+
+// Type returns the type of the server, renamed type_ in python.
+func (s *Server) Type() string {
+	return "server"
+}
+
+// Node has fields renamed class_ and id_ in python.
+type Node struct {
+	Class string
+	ID    int
+}
+
+// Import is renamed import_ in python, and its from parameter from_.
+func Import(from *Server) string {
+	if from == nil {
+		return "nothing"
+	}
+	return from.HTTPAddr
+}
+
+// BaseURL is the base URL of the server.
+var BaseURL = "http://localhost"
+
+// GetBaseUrl returns BaseURL, with a trailing slash. It is bound in place
+// of the GetBaseURL func of the var, both named get_base_url in python.
+func GetBaseUrl() string {
+	return BaseURL + "/"
+}
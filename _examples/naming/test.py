@@ -0,0 +1,30 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import naming
+
+print("naming.http_get_url('example.com') = %r" % (naming.http_get_url('example.com'),))
+print("naming.get_max_conns() = %s" % (naming.get_max_conns(),))
+
+s = naming.Server(http_addr='localhost', max_conns=2)
+print("s = naming.Server(http_addr='localhost', max_conns=2)")
+print("s.http_addr = %r" % (s.http_addr,))
+print("s.max_conns = %s" % (s.max_conns,))
+print("s.conn_id(3) = %r" % (s.conn_id(3),))
+print("s.type_() = %r" % (s.type_(),))
+print("'type' in dir(s): %s" % ('type' in dir(s),))
+print("s.type_.__doc__ = %r" % (s.type_.__doc__,))
+
+n = naming.Node(class_='leaf', id_=1)
+print("n = naming.Node(class_='leaf', id_=1)")
+print("n.class_ = %r" % (n.class_,))
+print("n.id_ = %s" % (n.id_,))
+print("naming.import_() = %r" % (naming.import_(),))
+print("naming.import_(from_=s) = %r" % (naming.import_(from_=s),))
+
+print("naming.get_base_url() = %r" % (naming.get_base_url(),))
+print("naming.base_url = %r" % (naming.base_url,))
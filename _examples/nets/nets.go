@@ -0,0 +1,45 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package nets tests the net.IP and *url.URL values, exchanged with python
+// as their text.
+package nets
+
+import (
+	"net"
+	"net/url"
+)
+
+// Loopback returns the IPv6 loopback address.
+func Loopback() net.IP { return net.IPv6loopback }
+
+// Same returns ip.
+func Same(ip net.IP) net.IP { return ip }
+
+// Is4 returns whether ip is an IPv4 address.
+func Is4(ip net.IP) bool { return ip.To4() != nil }
+
+// Endpoint returns the URL of the endpoint, with a query.
+func Endpoint() *url.URL {
+	u, _ := url.Parse("https://example.com/api?q=go+python&page=2")
+	return u
+}
+
+// Echo returns u.
+func Echo(u *url.URL) *url.URL { return u }
+
+// Query returns the value of the key of the query of u.
+func Query(u *url.URL, key string) string { return u.Query().Get(key) }
+
+// Server is a server, listening at an address.
+type Server struct {
+	Addr  net.IP
+	Base  *url.URL
+	Peers []net.IP
+}
+
+// NewServer returns a server listening at 10.0.0.1.
+func NewServer() *Server {
+	return &Server{Addr: net.ParseIP("10.0.0.1"), Peers: []net.IP{net.ParseIP("::1")}}
+}
@@ -0,0 +1,48 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import nets
+
+if not isinstance(nets.Loopback(), str):
+    # -nettypes=opaque: the values are wrapped, as other go values.
+    ip = nets.Loopback()
+    print("type(nets.Loopback()) = %s" % (type(ip).__name__,))
+    print("nets.Is4(ip) = %s" % (nets.Is4(ip),))
+    print("type(nets.Endpoint()) = %s" % (type(nets.Endpoint()).__name__,))
+    print("type(s.Addr) = %s" % (type(nets.NewServer().Addr).__name__,))
+else:
+    print("nets.Loopback() = %r" % (nets.Loopback(),))
+    print("nets.Same('2001:db8::1') = %r" % (nets.Same("2001:db8::1"),))
+    print("nets.Same('2001:0db8:0000::0001') = %r" % (nets.Same("2001:0db8:0000::0001"),))
+    print("nets.Is4('192.168.0.1') = %s" % (nets.Is4("192.168.0.1"),))
+    print("nets.Same(None) = %r" % (nets.Same(None),))
+    try:
+        nets.Same("10.0.0")
+    except ValueError as e:
+        print("caught ValueError: %s" % (e,))
+    try:
+        nets.Same(42)
+    except TypeError as e:
+        print("caught TypeError: %s" % (e,))
+
+    u = nets.Endpoint()
+    print("nets.Endpoint() = %r" % (u,))
+    print("nets.Echo(u) == u: %s" % (nets.Echo(u) == u,))
+    print("nets.Query(u, 'q') = %r" % (nets.Query(u, "q"),))
+    print("nets.Query('http://h/p?a=1&b=x%%20y', 'b') = %r" % (nets.Query("http://h/p?a=1&b=x%20y", "b"),))
+    try:
+        nets.Echo("http://h/%zz")
+    except ValueError as e:
+        print("caught ValueError: %s" % (e,))
+
+    s = nets.NewServer()
+    print("s.Addr = %r" % (s.Addr,))
+    print("s.Base = %r" % (s.Base,))
+    s.Base = "http://localhost:8080/"
+    s.Addr = "fe80::1"
+    print("s.Base, s.Addr = %r, %r" % (s.Base, s.Addr))
+    print("list(s.Peers) = %r" % (list(s.Peers),))
@@ -0,0 +1,41 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package optargs tests the optional pointer parameters.
+package optargs
+
+import "fmt"
+
+// Opts holds optional settings.
+type Opts struct {
+	Verbose bool
+	Level   int
+}
+
+func (o *Opts) describe() string {
+	if o == nil {
+		return "defaults"
+	}
+	return fmt.Sprintf("verbose=%v level=%d", o.Verbose, o.Level)
+}
+
+// F describes opts, which may be nil.
+func F(opts *Opts) string {
+	return opts.describe()
+}
+
+// Greet greets name, with the settings opts.
+func Greet(name string, opts *Opts) string {
+	return "hello " + name + " (" + opts.describe() + ")"
+}
+
+// Server is a server.
+type Server struct {
+	Name string
+}
+
+// Start starts s, with the settings opts.
+func (s *Server) Start(opts *Opts) string {
+	return s.Name + " started (" + opts.describe() + ")"
+}
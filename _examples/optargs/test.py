@@ -0,0 +1,37 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import optargs
+
+o = optargs.Opts(True, 2)
+
+print("F() = %s" % (optargs.F(),))
+print("F(None) = %s" % (optargs.F(None),))
+print("F(o) = %s" % (optargs.F(o),))
+print("F(opts=None) = %s" % (optargs.F(opts=None),))
+print("F(opts=o) = %s" % (optargs.F(opts=o),))
+
+print("Greet('bob') = %s" % (optargs.Greet("bob"),))
+print("Greet('bob', o) = %s" % (optargs.Greet("bob", o),))
+print("Greet(name='bob', opts=o) = %s" % (optargs.Greet(name="bob", opts=o),))
+
+s = optargs.Server(Name="srv")
+print("s.Start() = %s" % (s.Start(),))
+print("s.Start(None) = %s" % (s.Start(None),))
+print("s.Start(opts=o) = %s" % (s.Start(opts=o),))
+
+try:
+    optargs.F(42)
+    print("*ERROR* no exception raised!")
+except TypeError:
+    print("caught: TypeError")
+
+try:
+    optargs.Greet()
+    print("*ERROR* no exception raised!")
+except TypeError:
+    print("caught: TypeError")
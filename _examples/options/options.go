@@ -0,0 +1,49 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package options tests the wrapping of the functional options pattern.
+package options
+
+type config struct {
+	name string
+	size int
+}
+
+// Option configures a Server.
+type Option func(*config)
+
+// WithName sets the name of a Server.
+func WithName(name string) Option {
+	return func(c *config) { c.name = name }
+}
+
+// WithSize sets the size of a Server.
+func WithSize(size int) Option {
+	return func(c *config) { c.size = size }
+}
+
+// Server is a configured server.
+type Server struct {
+	Name string
+	Size int
+}
+
+// New returns a Server configured with opts.
+func New(opts ...Option) *Server {
+	cfg := config{name: "default", size: 1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &Server{Name: cfg.name, Size: cfg.size}
+}
+
+// Greeter greets a name.
+type Greeter func(name string) string
+
+// Greeter returns a Greeter signed by the server.
+func (s *Server) Greeter() Greeter {
+	return func(name string) string {
+		return "hello " + name + " from " + s.Name
+	}
+}
@@ -0,0 +1,30 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import options
+
+print("s = options.New()")
+s = options.New()
+print("s.Name = %r, s.Size = %d" % (s.Name, s.Size))
+
+print("s = options.New([options.WithName('srv'), options.WithSize(42)])")
+s = options.New([options.WithName("srv"), options.WithSize(42)])
+print("s.Name = %r, s.Size = %d" % (s.Name, s.Size))
+
+print("s = options.New(options.WithSize(3), options.WithName('gopy'))")
+s = options.New(options.WithSize(3), options.WithName("gopy"))
+print("s.Name = %r, s.Size = %d" % (s.Name, s.Size))
+
+print("g = s.Greeter()")
+g = s.Greeter()
+print("g('world') = %r" % (g("world"),))
+
+try:
+    print("s = options.New([42])")
+    s = options.New([42])
+except TypeError as err:
+    print("caught: %s" % (err,))
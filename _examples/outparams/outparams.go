@@ -0,0 +1,48 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package outparams tests the funcs annotated with //gopy:out, which copy
+// the struct they return into the wrapper passed as their out argument.
+package outparams
+
+import (
+	"errors"
+	"math"
+)
+
+// Vec is a 2D vector.
+type Vec struct {
+	X, Y float64
+}
+
+// Add returns a+b.
+//
+//gopy:out
+func Add(a, b Vec) Vec {
+	return Vec{a.X + b.X, a.Y + b.Y}
+}
+
+// Normalize returns v scaled to a unit length, failing for the zero
+// vector.
+//
+//gopy:out
+func Normalize(v Vec) (Vec, error) {
+	n := math.Hypot(v.X, v.Y)
+	if n == 0 {
+		return Vec{}, errors.New("zero vector")
+	}
+	return Vec{v.X / n, v.Y / n}, nil
+}
+
+// Scale returns v scaled by k.
+//
+//gopy:out
+func (v Vec) Scale(k float64) Vec {
+	return Vec{v.X * k, v.Y * k}
+}
+
+// Sub returns a-b, always into a new wrapper.
+func Sub(a, b Vec) Vec {
+	return Vec{a.X - b.X, a.Y - b.Y}
+}
@@ -0,0 +1,48 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import outparams
+
+def show(v):
+    return "(%s, %s)" % (v.X, v.Y)
+
+a = outparams.Vec(X=1.0, Y=2.0)
+b = outparams.Vec(X=3.0, Y=4.0)
+out = outparams.Vec()
+
+print("Add(a, b) = %s" % (show(outparams.Add(a, b)),))
+r = outparams.Add(a, b, out=out)
+print("Add(a, b, out=out) = %s, is out: %s" % (show(out), r is out))
+r = outparams.Add(a=a, b=b, out=None)
+print("Add(a, b, out=None) = %s, is out: %s" % (show(r), r is out))
+r = a.Scale(10, out=out)
+print("a.Scale(10, out=out) = %s, is out: %s" % (show(out), r is out))
+r = outparams.Normalize(b, out=out)
+print("Normalize(b, out=out) = %s, is out: %s" % (show(out), r is out))
+
+# out is left untouched on error.
+try:
+    outparams.Normalize(outparams.Vec(), out=out)
+except RuntimeError as err:
+    print("Normalize(zero): %s, out = %s" % (err, show(out)))
+try:
+    outparams.Add(a, b, out=1)
+except TypeError as err:
+    print("TypeError: %s" % (err,))
+try:
+    outparams.Sub(a, b, out=out)
+except TypeError as err:
+    print("Sub takes no out argument")
+
+# filling out allocates no go value, nor wrapper, in the loop.
+n = outparams._gopy_handle_count()
+vs = [outparams.Add(a, b) for _ in range(100)]
+print("go values without out: %d" % (outparams._gopy_handle_count() - n,))
+del vs
+n = outparams._gopy_handle_count()
+vs = [outparams.Add(a, b, out=out) for _ in range(100)]
+print("go values with out: %d, wrappers: %d" % (outparams._gopy_handle_count() - n, len(set(id(v) for v in vs))))
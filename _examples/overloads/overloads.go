@@ -0,0 +1,120 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package overloads groups funcs called from python through a single name,
+// dispatching on the number and types of the arguments.
+package overloads
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Point is a point of the plane.
+type Point struct {
+	X, Y float64
+}
+
+// NewPoint returns the point at x, y.
+//
+//gopy:overload New
+func NewPoint(x, y float64) Point {
+	return Point{X: x, Y: y}
+}
+
+// ParsePoint returns the point written as "x,y".
+//
+//gopy:overload New
+func ParsePoint(s string) (Point, error) {
+	xy := strings.Split(s, ",")
+	if len(xy) != 2 {
+		return Point{}, fmt.Errorf("overloads: invalid point %q", s)
+	}
+	x, err := strconv.ParseFloat(xy[0], 64)
+	if err != nil {
+		return Point{}, err
+	}
+	y, err := strconv.ParseFloat(xy[1], 64)
+	if err != nil {
+		return Point{}, err
+	}
+	return Point{X: x, Y: y}, nil
+}
+
+// Area returns the area of the w by h rectangle.
+//
+//gopy:overload Area
+func Area(w, h float64) float64 {
+	return w * h
+}
+
+// CircleArea returns the area of the circle of radius r.
+//
+//gopy:overload Area
+func CircleArea(r float64) float64 {
+	return math.Pi * r * r
+}
+
+// DescribeBool describes b. bools are ints in python: it is tried before
+// DescribeInt.
+//
+//gopy:overload Describe
+func DescribeBool(b bool) string {
+	return fmt.Sprintf("bool %v", b)
+}
+
+// DescribeInt describes n.
+//
+//gopy:overload Describe
+func DescribeInt(n int) string {
+	return fmt.Sprintf("int %d", n)
+}
+
+// DescribeString describes s.
+//
+//gopy:overload Describe
+func DescribeString(s string) string {
+	return fmt.Sprintf("string %q", s)
+}
+
+// DescribePoint describes p, nil if given None.
+//
+//gopy:overload Describe
+func DescribePoint(p *Point) string {
+	if p == nil {
+		return "no point"
+	}
+	return fmt.Sprintf("point %v", *p)
+}
+
+// DescribeInts describes xs.
+//
+//gopy:overload Describe
+func DescribeInts(xs []int) string {
+	return fmt.Sprintf("ints %v", xs)
+}
+
+// SumInts returns the sum of xs.
+//
+//gopy:overload Sum
+func SumInts(xs ...int) int {
+	n := 0
+	for _, x := range xs {
+		n += x
+	}
+	return n
+}
+
+// SumFloats returns the sum of xs.
+//
+//gopy:overload Sum
+func SumFloats(xs ...float64) float64 {
+	f := 0.0
+	for _, x := range xs {
+		f += x
+	}
+	return f
+}
@@ -0,0 +1,43 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import overloads as pkg
+
+p = pkg.New(1, 2)
+print("pkg.New(1, 2) = (%s, %s)" % (p.X, p.Y))
+p = pkg.New("3,4")
+print("pkg.New('3,4') = (%s, %s)" % (p.X, p.Y))
+try:
+    pkg.New("3")
+except RuntimeError as e:
+    print("pkg.New('3'): caught: %s" % e)
+
+print("pkg.Area(2, 3) = %s" % pkg.Area(2, 3))
+print("pkg.Area(1) = %.5f" % pkg.Area(1))
+print("pkg.CircleArea(1) = %.5f" % pkg.CircleArea(1))
+
+print("pkg.Describe(True) = %s" % pkg.Describe(True))
+print("pkg.Describe(42) = %s" % pkg.Describe(42))
+print("pkg.Describe('hi') = %s" % pkg.Describe('hi'))
+print("pkg.Describe(u'hi') = %s" % pkg.Describe(u'hi'))
+print("pkg.Describe(p) = %s" % pkg.Describe(p))
+print("pkg.Describe(None) = %s" % pkg.Describe(None))
+print("pkg.Describe([1, 2]) = %s" % pkg.Describe([1, 2]))
+
+print("pkg.Sum() = %s" % pkg.Sum())
+print("pkg.Sum(1, 2, 3) = %s" % pkg.Sum(1, 2, 3))
+print("pkg.Sum(1, 2.5) = %s" % pkg.Sum(1, 2.5))
+
+for args in [(1.5,), (1, 2, 3)]:
+    try:
+        pkg.Describe(*args)
+    except TypeError as e:
+        print("pkg.Describe%r: caught: %s" % (args, e))
+
+print("'Describe' in pkg.__all__: %s" % ('Describe' in pkg.__all__))
+print("'DescribeInt' in pkg.__all__: %s" % ('DescribeInt' in pkg.__all__))
+print("pkg.Area.__doc__:\n%s" % pkg.Area.__doc__)
@@ -5,6 +5,8 @@
 // package seqs tests various aspects of sequence types.
 package seqs
 
+import "runtime"
+
 type Slice []float64
 
 func (s Slice) At(i int) float64 { return s[i] }
@@ -12,3 +14,97 @@ func (s Slice) At(i int) float64 { return s[i] }
 type Array [10]float64
 
 func (a Array) At(i int) float64 { return a[i] }
+
+// Sum returns the sum of the elements of s.
+func Sum(s []int) int {
+	sum := 0
+	for _, v := range s {
+		sum += v
+	}
+	return sum
+}
+
+// Range returns the integers from 0 to n-1.
+func Range(n int) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = i
+	}
+	return s
+}
+
+// Fib returns the n first fibonacci numbers, as a python list.
+//
+//gopy:list
+func Fib(n int) []int {
+	s := make([]int, n)
+	for i := range s {
+		if i < 2 {
+			s[i] = i
+			continue
+		}
+		s[i] = s[i-1] + s[i-2]
+	}
+	return s
+}
+
+// Head returns the n first elements of s, sharing its storage.
+func (s Slice) Head(n int) Slice { return s[:n] }
+
+// Values returns the elements of s, as a python list.
+//
+//gopy:list
+func (s Slice) Values() Slice { return s }
+
+// Dot returns the dot product of s and o.
+func (s Slice) Dot(o Slice) float64 {
+	dot := 0.0
+	for i := range s {
+		dot += s[i] * o[i]
+	}
+	return dot
+}
+
+// Person is an item of the slices returned by People.
+type Person struct {
+	Name string
+	Age  int
+}
+
+// People returns the people named by names, aged from 20 on.
+func People(names ...string) []Person {
+	people := make([]Person, len(names))
+	for i, name := range names {
+		people[i] = Person{Name: name, Age: 20 + i}
+	}
+	return people
+}
+
+// Bag holds ints, in a slice and in an array.
+type Bag struct {
+	items []int
+	pair  [2]int
+}
+
+// NewBag returns a bag holding items, and the pair 1,2.
+func NewBag(items ...int) *Bag {
+	return &Bag{items: items, pair: [2]int{1, 2}}
+}
+
+// Items returns the items of b, sharing their storage with b.
+func (b *Bag) Items() []int { return b.items }
+
+// Pair returns the pair of b, stored in b itself.
+func (b *Bag) Pair() []int { return b.pair[:] }
+
+// Sum returns the sum of the items and of the pair of b.
+func (b *Bag) Sum() int {
+	sum := b.pair[0] + b.pair[1]
+	for _, v := range b.items {
+		sum += v
+	}
+	return sum
+}
+
+// Collect runs the go garbage collector.
+func Collect() { runtime.GC() }
@@ -41,3 +41,76 @@ s += [10,20]
 print("s = %s" % (s,))
 
 
+print("seqs.Sum([1,2,3]) = %s" % (seqs.Sum([1,2,3]),))
+print("seqs.Sum((1,2,3,4)) = %s" % (seqs.Sum((1,2,3,4)),))
+print("seqs.Sum([]) = %s" % (seqs.Sum([]),))
+print("r = seqs.Range(4)")
+r = seqs.Range(4)
+print("r = %s" % (r,))
+print("seqs.Sum(r) = %s" % (seqs.Sum(r),))
+print("seqs.Fib(10) = %s" % (seqs.Fib(10),))
+print("seqs.Fib(0) = %s" % (seqs.Fib(0),))
+
+print("h = s.Head(2)")
+h = s.Head(2)
+print("h[0] = 100")
+h[0] = 100
+print("s = %s" % (s,))
+print("v = s.Values()")
+v = s.Values()
+print("v = %s (%s)" % (v, type(v).__name__))
+print("v[0] = -1")
+v[0] = -1
+print("s = %s" % (s,))
+print("h[0] = 1")
+h[0] = 1
+
+print("s.Dot([1,1,1,1]) = %s" % (s.Dot([1,1,1,1]),))
+print("s.Dot(s) = %s" % (s.Dot(s),))
+
+try:
+    seqs.Sum(["a"])
+    print("*ERROR* no exception raised!")
+except Exception as err:
+    print("caught: %s" % (type(err).__name__,))
+
+try:
+    seqs.Sum(42)
+    print("*ERROR* no exception raised!")
+except TypeError:
+    print("caught: TypeError")
+
+print("people = seqs.People('alice', 'bob')")
+people = seqs.People("alice", "bob")
+print("type(people) = %s, type(people[0]) = %s" % (type(people).__name__, type(people[0]).__name__))
+for p in people:
+    print("  %s is %d" % (p.Name, p.Age))
+print("people[1].Age = 30")
+people[1].Age = 30
+print("people[1].Age = %d" % (people[1].Age,))
+print("[p.Name for p in people] = %s" % ([p.Name for p in people],))
+
+print("alice = people[0]; del people")
+n = seqs._gopy_handle_count()
+alice = people[0]
+del people
+seqs.People("carol", "dave")
+print("alice.Name = %s, alice.Age = %d" % (alice.Name, alice.Age))
+del alice
+print("handles released: %s" % (seqs._gopy_handle_count() == n-1,))
+
+print("b = seqs.NewBag(3, 4)")
+b = seqs.NewBag(3, 4)
+items, pair = b.Items(), b.Pair()
+print("items[0] = 10; pair[1] = 20")
+items[0] = 10
+pair[1] = 20
+print("b.Sum() = %d" % (b.Sum(),))
+print("del b")
+n = seqs._gopy_handle_count()
+del b
+seqs.Collect()
+print("handles released: %s" % (seqs._gopy_handle_count() == n-1,))
+print("pair[0] = 5")
+pair[0] = 5
+print("items = %s, pair = %s" % (list(items), list(pair)))
@@ -0,0 +1,41 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package sets tests the wrapping of types with a Contains method.
+package sets
+
+// Set is a set of strings.
+type Set struct {
+	items map[string]bool
+}
+
+// NewSet returns a set holding items.
+func NewSet(items ...string) *Set {
+	s := &Set{items: make(map[string]bool)}
+	for _, item := range items {
+		s.Add(item)
+	}
+	return s
+}
+
+// Add adds item to the set.
+func (s *Set) Add(item string) {
+	s.items[item] = true
+}
+
+// Contains returns whether item is in the set.
+func (s *Set) Contains(item string) bool {
+	return s.items[item]
+}
+
+// Range is a closed range of ints.
+type Range struct {
+	Min int
+	Max int
+}
+
+// Contains returns whether i is in the range.
+func (r Range) Contains(i int) bool {
+	return r.Min <= i && i <= r.Max
+}
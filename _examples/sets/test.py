@@ -0,0 +1,31 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import sets
+
+print("s = sets.NewSet('a', 'b')")
+s = sets.NewSet("a", "b")
+print("s.Contains('a') = %s" % (s.Contains("a"),))
+print("'a' in s = %s" % ("a" in s,))
+print("'z' in s = %s" % ("z" in s,))
+print("'z' not in s = %s" % ("z" not in s,))
+print("s.Add('z')")
+s.Add("z")
+print("'z' in s = %s" % ("z" in s,))
+
+try:
+    print("1 in s")
+    1 in s
+except TypeError as err:
+    print("caught: %s" % (err,))
+
+print("r = sets.Range()")
+r = sets.Range()
+r.Min = 1
+r.Max = 10
+print("5 in r = %s" % (5 in r,))
+print("42 in r = %s" % (42 in r,))
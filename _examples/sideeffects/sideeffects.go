@@ -0,0 +1,38 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sideeffects registers handlers in its init funcs, and exports
+// nothing: it is wrapped and imported for its side-effects only.
+package sideeffects
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/go-python/gopy/_examples/cpkg"
+)
+
+// handler is not exported, and not wrapped.
+type handler func(s string) string
+
+var handlers = make(map[string]handler)
+
+const prefix = "sideeffects"
+
+func register(name string, h handler) {
+	handlers[name] = h
+}
+
+func init() {
+	register("upper", strings.ToUpper)
+	register("lower", strings.ToLower)
+	register("trim", strings.TrimSpace)
+
+	var names []string
+	for name := range handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	cpkg.Printf("%s.init()... [CALLED] handlers=%s\n", prefix, strings.Join(names, ","))
+}
@@ -0,0 +1,17 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import sideeffects as pkg
+
+print("doc(pkg):\n%s" % repr(pkg.__doc__))
+print("pkg.__gopy_package__ = %s" % pkg.__gopy_package__)
+print("hasattr(pkg, '__all__') = %s" % hasattr(pkg, '__all__'))
+print("pkg._gopy_handle_count() = %s" % pkg._gopy_handle_count())
+
+## importing the module again does not run the init funcs again.
+import sideeffects
+print("sideeffects is pkg: %s" % (sideeffects is pkg))
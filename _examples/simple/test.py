@@ -17,3 +17,16 @@ fct()
 
 print("pkg.Add(1,2)= %s" % (pkg.Add(1,2),))
 
+## bytes.Buffer is exposed by every module.
+buf = pkg.Buffer()
+print("buf.write(b'gopy') = %s, len(buf) = %s" % (buf.write(b"gopy"), len(buf)))
+
+
+import time
+
+print("pkg.__gopy_package__ = %s" % (pkg.__gopy_package__,))
+cmd = pkg.__gopy_cmd__.split()
+print("pkg.__gopy_cmd__ = %s ... %s" % (" ".join(cmd[:2]), cmd[-1]))
+print("pkg.__gopy_version__ is set: %s" % (pkg.__gopy_version__ != "",))
+build = time.strptime(pkg.__gopy_build_time__, "%b %d %Y %H:%M:%S")
+print("pkg.__gopy_build_time__ is a date: %s" % (build.tm_year > 2000,))
@@ -0,0 +1,52 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package statics tests the funcs attached to types as static methods, or
+// kept at module level rather than made constructors.
+package statics
+
+import "fmt"
+
+// Point is a point of the plane.
+type Point struct {
+	X, Y int
+}
+
+// Parse parses a point written as "x,y".
+//
+//gopy:static Point
+func Parse(s string) (*Point, error) {
+	p := new(Point)
+	if _, err := fmt.Sscanf(s, "%d,%d", &p.X, &p.Y); err != nil {
+		return nil, fmt.Errorf("invalid point %q", s)
+	}
+	return p, nil
+}
+
+// Origin returns the origin.
+//
+//gopy:static Point
+func Origin() Point {
+	return Point{}
+}
+
+// Unit returns the point at 1,1. It is a func of the module, not a
+// constructor of Point.
+//
+//gopy:not-ctor
+func Unit() Point {
+	return Point{X: 1, Y: 1}
+}
+
+// Dist returns the manhattan distance between p and q.
+func Dist(p, q *Point) int {
+	return abs(p.X-q.X) + abs(p.Y-q.Y)
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,28 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import statics
+
+p = statics.Point.Parse("1,2")
+print("statics.Point.Parse('1,2') = %s" % (p,))
+o = statics.Point.Origin()
+print("statics.Point.Origin() = %s" % (o,))
+print("p.Origin() = %s" % (p.Origin(),))
+print("statics.Dist(p, o) = %s" % (statics.Dist(p, o),))
+
+print("hasattr(statics, 'Parse') = %s" % (hasattr(statics, "Parse"),))
+print("hasattr(statics, 'Origin') = %s" % (hasattr(statics, "Origin"),))
+print("statics.Unit() = %s" % (statics.Unit(),))
+print("doc(statics.Unit) = %r" % (statics.Unit.__doc__,))
+print("hasattr(statics.Point, 'zero') = %s" % (hasattr(statics.Point, "zero"),))
+print("doc(statics.Point.Parse) = %r" % (statics.Point.Parse.__doc__,))
+
+try:
+    statics.Point.Parse("nope")
+    print("*ERROR* no exception raised!")
+except Exception as err:
+    print("caught: %s" % (err,))
@@ -0,0 +1,54 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package streams tests the wrapping of python file objects into io.Reader
+// and io.Writer values.
+package streams
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+)
+
+// Copy copies r to w, returning the number of bytes copied.
+func Copy(w io.Writer, r io.Reader) (int64, error) {
+	return io.Copy(w, r)
+}
+
+// ReadAll returns the content of r.
+func ReadAll(r io.Reader) (string, error) {
+	b, err := io.ReadAll(r)
+	return string(b), err
+}
+
+// Upper writes the upper-cased content of r to w.
+func Upper(w io.Writer, r io.Reader) error {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(bytes.ToUpper(b))
+	return err
+}
+
+// WriteString writes s to w.
+func WriteString(w io.Writer, s string) error {
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+// NewReader returns a reader over s.
+func NewReader(s string) io.Reader {
+	return strings.NewReader(s)
+}
+
+// Broken is a writer failing to write.
+type Broken struct{}
+
+// Write implements io.Writer.
+func (Broken) Write(p []byte) (int, error) {
+	return 0, errors.New("broken writer")
+}
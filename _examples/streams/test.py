@@ -0,0 +1,108 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import io
+
+import streams
+
+src = io.BytesIO(b"hello from python\n" * 1000)
+dst = io.BytesIO()
+print("streams.Copy(dst, src) = %s" % (streams.Copy(dst, src),))
+print("dst.getvalue() == src.getvalue(): %s" % (dst.getvalue() == src.getvalue(),))
+
+print("streams.ReadAll(io.BytesIO(b'abc')) = %r" % (streams.ReadAll(io.BytesIO(b"abc")),))
+print("streams.ReadAll(io.BytesIO()) = %r" % (streams.ReadAll(io.BytesIO()),))
+
+out = io.BytesIO()
+streams.Upper(out, io.BytesIO(b"shout"))
+print("streams.Upper(out, io.BytesIO(b'shout')): %r" % (out.getvalue(),))
+
+out = io.BytesIO()
+streams.Copy(out, streams.NewReader("from go"))
+print("streams.Copy(out, streams.NewReader('from go')): %r" % (out.getvalue(),))
+
+Reader = type(streams.NewReader(""))
+r = Reader(io.BytesIO(b"wrapped"))
+print("type(r) = %s" % (type(r).__name__,))
+print("streams.ReadAll(r) = %r" % (streams.ReadAll(r),))
+
+class Chunks(object):
+    """Chunks returns its data by chunks of at most 3 bytes."""
+
+    def __init__(self, data):
+        self.data = data
+
+    def read(self, n):
+        chunk, self.data = self.data[:min(n, 3)], self.data[min(n, 3):]
+        return chunk
+
+class Lines(object):
+    """Lines collects what is written to it."""
+
+    def __init__(self):
+        self.chunks = []
+
+    def write(self, data):
+        self.chunks.append(data)
+
+lines = Lines()
+streams.WriteString(lines, "one\n")
+streams.Copy(lines, Chunks(b"two\n"))
+print("lines.chunks = %r" % (lines.chunks,))
+
+class Failing(object):
+    def read(self, n):
+        raise IOError("disk on fire")
+
+    def write(self, data):
+        raise IOError("disk full")
+
+try:
+    print("streams.ReadAll(Failing())")
+    streams.ReadAll(Failing())
+except Exception as err:
+    print("caught: %s" % (err,))
+
+try:
+    print("streams.WriteString(Failing(), 'x')")
+    streams.WriteString(Failing(), "x")
+except Exception as err:
+    print("caught: %s" % (err,))
+
+class Greedy(object):
+    def read(self, n):
+        return b"x" * (n + 1)
+
+try:
+    print("streams.ReadAll(Greedy())")
+    streams.ReadAll(Greedy())
+except Exception as err:
+    print("caught: %s" % (err,))
+
+try:
+    print("streams.ReadAll(io.StringIO(u'text'))")
+    streams.ReadAll(io.StringIO(u"text"))
+except Exception as err:
+    print("caught: %s" % (err,))
+
+try:
+    print("streams.Copy(streams.Broken(), io.BytesIO(b'x'))")
+    streams.Copy(streams.Broken(), io.BytesIO(b"x"))
+except Exception as err:
+    print("caught: %s" % (err,))
+
+try:
+    print("streams.ReadAll('not a file')")
+    streams.ReadAll("not a file")
+except TypeError as err:
+    print("caught: %s" % (err,))
+
+try:
+    print("Reader(1)")
+    Reader(1)
+except TypeError as err:
+    print("caught: %s" % (err,))
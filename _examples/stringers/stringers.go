@@ -0,0 +1,64 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package stringers tests the string forms of wrapped values, from their
+// String, Error and GoString methods.
+package stringers
+
+import "fmt"
+
+// Point is a point on a grid.
+type Point struct {
+	X, Y int
+}
+
+func (p Point) String() string {
+	return fmt.Sprintf("(%d, %d)", p.X, p.Y)
+}
+
+func (p Point) GoString() string {
+	return fmt.Sprintf("stringers.Point{X:%d, Y:%d}", p.X, p.Y)
+}
+
+// Failure is an error, without a String method.
+type Failure struct {
+	Op   string
+	Code int
+}
+
+func (f *Failure) Error() string {
+	return fmt.Sprintf("%s failed with code %d", f.Op, f.Code)
+}
+
+// NewFailure returns a failure of op.
+func NewFailure(op string, code int) *Failure {
+	return &Failure{Op: op, Code: code}
+}
+
+// Status is both an error and a fmt.Stringer.
+type Status int
+
+func (s Status) String() string {
+	return fmt.Sprintf("status %d", int(s))
+}
+
+func (s Status) Error() string {
+	return fmt.Sprintf("error with status %d", int(s))
+}
+
+// Code is an error code.
+type Code int
+
+func (c Code) Error() string {
+	return fmt.Sprintf("error code %d", int(c))
+}
+
+func (c Code) GoString() string {
+	return fmt.Sprintf("stringers.Code(%d)", int(c))
+}
+
+// Plain has no string method.
+type Plain struct {
+	Name string
+}
@@ -0,0 +1,31 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import stringers
+
+p = stringers.Point()
+p.X = 1
+p.Y = 2
+print("str(p) = %s" % (str(p),))
+print("repr(p) = %s" % (repr(p),))
+
+f = stringers.NewFailure("open", 2)
+print("str(f) = %s" % (str(f),))
+print("repr(f) is default: %s" % (repr(f).startswith("<stringers.Failure object"),))
+
+s = stringers.Status(3)
+print("str(s) = %s" % (str(s),))
+
+c = stringers.Code(4)
+print("str(c) = %s" % (str(c),))
+print("repr(c) = %s" % (repr(c),))
+print("repr([c]) = %s" % (repr([c]),))
+
+pl = stringers.Plain()
+pl.Name = "plain"
+print("str(pl) = %s" % (str(pl),))
+print("repr(pl) is default: %s" % (repr(pl).startswith("<stringers.Plain object"),))
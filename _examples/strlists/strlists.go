@@ -0,0 +1,57 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package strlists tests the exchange of []string values as lists of str.
+package strlists
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Split slices s into all substrings separated by sep.
+func Split(s, sep string) []string {
+	return strings.Split(s, sep)
+}
+
+// Join concatenates the elements of elems, separated by sep.
+func Join(elems []string, sep string) string {
+	return strings.Join(elems, sep)
+}
+
+// Upper returns the elements of elems mapped to upper case.
+func Upper(elems []string) []string {
+	out := make([]string, len(elems))
+	for i, s := range elems {
+		out[i] = strings.ToUpper(s)
+	}
+	return out
+}
+
+// Fields returns the fields of s, or an error if s holds none.
+func Fields(s string) ([]string, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, errors.New("strlists: no fields")
+	}
+	return fields, nil
+}
+
+// Doc is a tagged document.
+type Doc struct {
+	Tags []string
+}
+
+// Tag adds tags to the tags of d.
+func (d *Doc) Tag(tags []string) {
+	d.Tags = append(d.Tags, tags...)
+}
+
+// Sorted returns the sorted tags of d.
+func (d *Doc) Sorted() []string {
+	tags := append([]string(nil), d.Tags...)
+	sort.Strings(tags)
+	return tags
+}
@@ -0,0 +1,39 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import strlists
+
+v = strlists.Split("a,b,c", ",")
+print("strlists.Split('a,b,c', ','):", v, type(v).__name__, type(v[0]).__name__)
+print("strlists.Split('', ','):", strlists.Split("", ","))
+print("strlists.Join(['x', 'y'], '-'):", strlists.Join(["x", "y"], "-"))
+print("strlists.Join(('x', u'y'), '+'):", strlists.Join(("x", u"y"), "+"))
+print("strlists.Join([], '-'): %r" % (strlists.Join([], "-"),))
+print("strlists.Upper(['go', 'py']):", strlists.Upper(["go", "py"]))
+print("strlists.Fields(' a  b '):", strlists.Fields(" a  b "))
+
+try:
+    strlists.Join(["x", 1, "y"], "-")
+except TypeError as err:
+    print("caught:", err)
+
+try:
+    strlists.Join("xy", "-")
+except TypeError as err:
+    print("caught:", err)
+
+try:
+    strlists.Fields("  ")
+except RuntimeError as err:
+    print("caught:", err)
+
+d = strlists.Doc()
+d.Tag(["red", "blue"])
+d.Tag(("green",))
+print("d.Sorted():", d.Sorted())
+print("len(d.Tags):", len(d.Tags))
+print("strlists.Join(d.Tags, ','):", strlists.Join(d.Tags, ","))
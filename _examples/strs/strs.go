@@ -0,0 +1,20 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package strs tests the wrapping of named string types.
+package strs
+
+import (
+	"strings"
+)
+
+type Str string
+
+// Upper returns s with all its letters mapped to upper case.
+func (s Str) Upper() string { return strings.ToUpper(string(s)) }
+
+// Hello returns a greeting for name.
+func Hello(name string) Str {
+	return Str("hello " + name)
+}
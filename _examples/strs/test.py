@@ -0,0 +1,33 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import strs
+
+print("s = strs.Str('string')")
+s = strs.Str("string")
+print("s = %s" % (s,))
+print("len(s) = %d" % (len(s),))
+print("s[0] = %r" % (s[0],))
+print("s[-1] = %r" % (s[-1],))
+print("s[1:4] = %r" % (s[1:4],))
+print("str(s) = %r" % (str(s),))
+print("s.Upper() = %r" % (s.Upper(),))
+
+print("s = strs.Hello('world')")
+s = strs.Hello("world")
+print("s = %s" % (s,))
+print("len(s) = %d" % (len(s),))
+
+print("s = strs.Str('h\\xc3\\xa9llo')")
+s = strs.Str("h\xc3\xa9llo")
+print("len(s) = %d" % (len(s),))
+print("s[1] = %r" % (s[1],))
+print("s[1:] = %r" % (s[1:],))
+try:
+    print("s[10] = %r" % (s[10],))
+except IndexError as err:
+    print("caught: %s" % (err,))
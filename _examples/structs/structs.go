@@ -28,3 +28,13 @@ type S2 struct {
 	Public  int
 	private int
 }
+
+// Path is a value object: paths with the same name and points are equal,
+// though their slice fields make it non-comparable in go.
+//
+//gopy:deepeq
+type Path struct {
+	Name   string
+	Points []int
+	Tags   map[string]bool
+}
@@ -53,3 +53,29 @@ try:
 except Exception, err:
     print("caught error: %s" % (err,))
     pass
+
+## handles of the go values held by python
+import gc
+n = structs._gopy_handle_count()
+objs = [structs.S2(i) for i in range(10)]
+print("handles after creating 10 values: +%d" % (structs._gopy_handle_count() - n,))
+del objs
+gc.collect()
+print("handles after releasing them: +%d" % (structs._gopy_handle_count() - n,))
+
+## values compared field by field
+a = structs.Path(Name="a", Points=structs.SliceInt([1, 2]))
+b = structs.Path(Name="a", Points=structs.SliceInt([1, 2]))
+c = structs.Path(Name="a", Points=structs.SliceInt([1, 3]))
+print("a == b: %s, a != b: %s" % (a == b, a != b))
+print("a == c: %s, a != c: %s" % (a == c, a != c))
+print("a == a: %s" % (a == a,))
+c.Points[1] = 2
+print("after c.Points[1] = 2, a == c: %s" % (a == c,))
+print("a == 'a': %s, a == None: %s" % (a == "a", a == None))
+try:
+    hash(a)
+    print("*ERROR* no exception raised!")
+except TypeError as err:
+    print("caught: %s" % (err,))
+print("structs.S2(1) == structs.S2(1): %s" % (structs.S2(1) == structs.S2(1),))
@@ -0,0 +1,42 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package subclass tests python classes deriving from wrapped go types.
+package subclass
+
+import "fmt"
+
+// Person is a simple struct.
+type Person struct {
+	Name string
+	Age  int
+}
+
+// NewPerson returns a newborn person named name.
+func NewPerson(name string) Person {
+	return Person{Name: name}
+}
+
+// Greet returns a greeting from p.
+func (p *Person) Greet() string {
+	return fmt.Sprintf("hello, I am %s", p.Name)
+}
+
+// Birthday makes p one year older.
+func (p *Person) Birthday() {
+	p.Age++
+}
+
+// Describe describes p.
+func Describe(p *Person) string {
+	return fmt.Sprintf("%s (%d)", p.Name, p.Age)
+}
+
+// Celsius is a temperature.
+type Celsius float64
+
+// Fahrenheit returns c in degrees Fahrenheit.
+func (c Celsius) Fahrenheit() float64 {
+	return float64(c)*9/5 + 32
+}
@@ -0,0 +1,64 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import subclass
+
+class Student(subclass.Person):
+    def __init__(self, name, school):
+        super(Student, self).__init__(Name=name, Age=20)
+        self.school = school
+
+    def Introduce(self):
+        return "%s, from %s" % (self.Greet(), self.school)
+
+s = Student("alice", "gopher school")
+print("isinstance(s, subclass.Person) = %s" % (isinstance(s, subclass.Person),))
+print("s.Introduce() = %s" % (s.Introduce(),))
+s.Birthday()
+print("s.Age = %s" % (s.Age,))
+print("subclass.Describe(s) = %s" % (subclass.Describe(s),))
+
+class Anonymous(subclass.Person):
+    def __init__(self):
+        pass
+
+a = Anonymous()
+a.Name = "bob"
+print("a.Greet() = %s" % (a.Greet(),))
+
+class Temperature(subclass.Celsius):
+    def Boiling(self):
+        return self.Fahrenheit() >= 212
+
+t = Temperature(100)
+print("t.Fahrenheit() = %s" % (t.Fahrenheit(),))
+print("t.Boiling() = %s" % (t.Boiling(),))
+
+## zero returns the zero value, without running __init__.
+z = subclass.Person.zero()
+print("subclass.Person.zero() = %s" % (subclass.Describe(z),))
+print("repr(z.Name) = %r" % (z.Name,))
+z = Student.zero()
+print("type(Student.zero()) = %s" % (type(z).__name__,))
+print("hasattr(Student.zero(), 'school') = %s" % (hasattr(z, "school"),))
+print("subclass.NewPerson('bob') = %s" % (subclass.NewPerson("bob").Name,))
+print("hasattr(subclass.Celsius, 'zero') = %s" % (hasattr(subclass.Celsius, "zero"),))
+
+print("doc(subclass.Person.Greet) = %r" % (subclass.Person.Greet.__doc__,))
+
+## the go values of collected subclass instances are released
+import gc
+class Tracked(subclass.Person):
+    def __del__(self):
+        self.Name = "gone"
+
+n = subclass._gopy_handle_count()
+objs = [Tracked(Name="p%d" % i, Age=i) for i in range(5)]
+print("handles after creating 5 subclass instances: +%d" % (subclass._gopy_handle_count() - n,))
+del objs
+gc.collect()
+print("handles after releasing them: +%d" % (subclass._gopy_handle_count() - n,))
@@ -0,0 +1,29 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package subinterps tests the modules imported more than once, by
+// sub-interpreters or once removed from sys.modules.
+package subinterps
+
+// Color is a color.
+type Color string
+
+const (
+	ColorRed  Color = "red"
+	ColorBlue Color = "blue"
+)
+
+// Box holds a color.
+type Box struct {
+	Color Color
+}
+
+// Counter counts the calls to Incr.
+var Counter int
+
+// Incr increments Counter, and returns it.
+func Incr() int {
+	Counter++
+	return Counter
+}
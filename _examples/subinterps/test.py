@@ -0,0 +1,32 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## python-3 only: python-2 runs the init function of a module only once.
+import sys
+
+import subinterps
+
+## the module is executed anew when imported again, sharing its types and
+## the go state with the first one.
+first = subinterps
+subinterps.Counter = 1
+del sys.modules["subinterps"]
+import subinterps
+print("reimported: %s" % (subinterps is not first,))
+print("same types: %s" % (subinterps.Box is first.Box,))
+print("same enum: %s" % (subinterps.ColorRed is first.ColorRed,))
+print("subinterps.Counter = %s" % (subinterps.Counter,))
+print("subinterps.Incr() = %s" % (subinterps.Incr(),))
+print("first.Counter = %s" % (first.Counter,))
+
+## so are sub-interpreters.
+import _testcapi
+sys.stdout.flush()
+code = "import sys\nsys.path = %r\n" % (sys.path,) + """
+import subinterps
+print("sub-interpreter: Counter = %s, Incr() = %s" % (subinterps.Counter, subinterps.Incr()))
+sys.stdout.flush()
+"""
+print("run_in_subinterp(code) = %s" % (_testcapi.run_in_subinterp(code),))
+print("subinterps.Counter = %s" % (subinterps.Counter,))
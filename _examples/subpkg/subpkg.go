@@ -0,0 +1,11 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package subpkg tests bindings generated into a python package.
+package subpkg
+
+// Hello returns a greeting for name.
+func Hello(name string) string {
+	return "hello " + name
+}
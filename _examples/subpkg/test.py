@@ -0,0 +1,14 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+from myproject.gobindings import subpkg
+import myproject.gobindings
+
+print("subpkg.__name__ = %s" % (subpkg.__name__,))
+print("myproject.gobindings.__all__ = %s" % (myproject.gobindings.__all__,))
+print("myproject.gobindings.subpkg is subpkg = %s" % (myproject.gobindings.subpkg is subpkg,))
+print("subpkg.Hello('gopy') = %s" % (subpkg.Hello('gopy'),))
@@ -0,0 +1,28 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import tuples
+
+p, i = tuples.Find("bob")
+print("p, i = tuples.Find('bob')")
+print("p.Name = %r, i = %s" % (p.Name, i))
+print("tuples.Find('carol') = %s" % (tuples.Find("carol"),))
+
+p, name = tuples.Get(0)
+print("p, name = tuples.Get(0)")
+print("p.Age = %s, name = %r" % (p.Age, name))
+try:
+    tuples.Get(5)
+except RuntimeError as err:
+    print("caught: %s" % (err,))
+
+print("tuples.Split('carol:7') = %s" % (tuples.Split("carol:7"),))
+print("tuples.Names() = %s" % (tuples.Names(),))
+
+q, adult, n = tuples.Person(Name="dan", Age=10).Older(8)
+print("q, adult, n = tuples.Person(Name='dan', Age=10).Older(8)")
+print("q.Age = %s, adult = %s, n = %s" % (q.Age, adult, n))
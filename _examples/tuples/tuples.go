@@ -0,0 +1,68 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package tuples tests the wrapping of funcs returning several results.
+package tuples
+
+import (
+	"errors"
+	"strings"
+)
+
+// Person is a person.
+type Person struct {
+	Name string
+	Age  int
+}
+
+var people = []*Person{
+	{Name: "alice", Age: 42},
+	{Name: "bob", Age: 24},
+}
+
+// Find returns the person named name and its index, or nil and -1.
+func Find(name string) (*Person, int) {
+	for i, p := range people {
+		if p.Name == name {
+			return p, i
+		}
+	}
+	return nil, -1
+}
+
+// Get returns the person at index i and its name, or an error.
+func Get(i int) (*Person, string, error) {
+	if i < 0 || i >= len(people) {
+		return nil, "", errors.New("tuples: index out of range")
+	}
+	return people[i], people[i].Name, nil
+}
+
+// Split splits a name and its age.
+func Split(s string) (string, int) {
+	i := strings.IndexByte(s, ':')
+	if i < 0 {
+		return s, 0
+	}
+	n := 0
+	for _, c := range s[i+1:] {
+		n = 10*n + int(c-'0')
+	}
+	return s[:i], n
+}
+
+// Names returns the names of the people, and their count.
+func Names() ([]string, int) {
+	names := []string{}
+	for _, p := range people {
+		names = append(names, p.Name)
+	}
+	return names, len(names)
+}
+
+// Older returns a copy of p, n years older, and whether it is an adult.
+func (p Person) Older(n int) (Person, bool, int) {
+	p.Age += n
+	return p, p.Age >= 18, n
+}
@@ -0,0 +1,21 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import unexported
+
+c = unexported.NewCounter(2)
+c.Incr()
+print("peek = %d" % (c.peek(),))
+print("add = %d" % (c.add(1, 2),))
+try:
+    c.add(-1)
+except RuntimeError as err:
+    print("add: %s" % (err,))
+c.reset()
+print("reset: peek = %d" % (c.peek(),))
+print("hidden: %s" % (hasattr(c, "hidden"),))
+print("double = %d" % (unexported.Level(3).double(),))
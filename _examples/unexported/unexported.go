@@ -0,0 +1,60 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package unexported tests the unexported methods listed by a
+// //gopy:export directive, bound as exported methods are.
+package unexported
+
+import "fmt"
+
+// Counter counts.
+//
+//gopy:export reset peek add
+type Counter struct {
+	n int
+}
+
+// NewCounter returns a counter starting at n.
+func NewCounter(n int) *Counter {
+	return &Counter{n: n}
+}
+
+// Incr increments the counter.
+func (c *Counter) Incr() {
+	c.n++
+}
+
+// reset resets the counter.
+func (c *Counter) reset() {
+	c.n = 0
+}
+
+// peek returns the count.
+func (c Counter) peek() int {
+	return c.n
+}
+
+// add adds ks to the counter, returning the new count.
+func (c *Counter) add(ks ...int) (int, error) {
+	for _, k := range ks {
+		if k < 0 {
+			return c.n, fmt.Errorf("negative increment %d", k)
+		}
+		c.n += k
+	}
+	return c.n, nil
+}
+
+// hidden is not listed by the //gopy:export directive.
+func (c *Counter) hidden() {}
+
+// Level is a level.
+//
+//gopy:export double
+type Level int
+
+// double returns twice the level.
+func (l Level) double() Level {
+	return 2 * l
+}
@@ -0,0 +1,24 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import units
+
+a, b = units.Signal(), units.Signal()
+print("units.Signal() is units.Signal(): %s" % (a is b,))
+print("units.Signal() == units.Signal(): %s" % (a == b,))
+print("units.Unit() is units.Signal(): %s" % (units.Unit() is a,))
+print("hash(units.Unit()) == hash(units.Signal()): %s" % (hash(units.Unit()) == hash(a),))
+
+n = units._gopy_handle_count()
+s = [units.Signal() for i in range(10)]
+print("handles made by 10 Signals: %d" % (units._gopy_handle_count() - n,))
+
+m = units.Set("a", "b")
+print("sorted(m) = %s" % (sorted(m),))
+print("m['a'] is m['b'] is a: %s" % (m["a"] is m["b"] is a,))
+print("units.Seen()[units.Unit()] = %s" % (units.Seen()[units.Unit()],))
+print("units.Take(units.Unit()) = %s" % (units.Take(units.Unit()),))
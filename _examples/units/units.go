@@ -0,0 +1,30 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package units tests the wrapping of empty structs, whose values all share
+// one python object.
+package units
+
+// Unit is an empty struct, as the values of sets.
+type Unit struct{}
+
+// Signal returns a Unit, as sent to wake a waiter.
+func Signal() Unit { return Unit{} }
+
+// Set returns a set of names, held as a map to Units.
+func Set(names ...string) map[string]Unit {
+	m := make(map[string]Unit, len(names))
+	for _, n := range names {
+		m[n] = Unit{}
+	}
+	return m
+}
+
+// Seen returns a map keyed by Units, seen once.
+func Seen() map[Unit]bool {
+	return map[Unit]bool{{}: true}
+}
+
+// Take returns whether u is the zero Unit, as all Units are.
+func Take(u Unit) bool { return u == Unit{} }
@@ -0,0 +1,47 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import variadics
+
+print("Max(1, 5, 3):", variadics.Max(1, 5, 3))
+print("Max(*[4, 2]):", variadics.Max(*[4, 2]))
+print("Max([7, 8]):", variadics.Max([7, 8]))
+print("Max((-1, -2)):", variadics.Max((-1, -2)))
+print("Max(-3, -4):", variadics.Max(-3, -4))
+print("Max():", variadics.Max())
+print("Count():", variadics.Count())
+print("Count(*range(10)):", variadics.Count(*range(10)))
+print("Mean(1, 2.5):", variadics.Mean(1, 2.5))
+print("Mean():", variadics.Mean())
+print("Any(False, True):", variadics.Any(False, True))
+print("Any():", variadics.Any())
+print("Join('-', 'a', 'b'):", variadics.Join("-", "a", "b"))
+print("Join('-'):", repr(variadics.Join("-")))
+print("Bytes(1, 2, 255):", variadics.Bytes(1, 2, 255))
+
+a = variadics.Acc()
+print("a.Add(1, 2):", a.Add(1, 2))
+print("a.Add():", a.Add())
+print("a.Add(*[3, 4]):", a.Add(*[3, 4]))
+
+for args in [("a",), (1, "b"), (1.5,)]:
+    try:
+        variadics.Max(*args)
+        print("*ERROR* no exception raised!")
+    except TypeError as err:
+        print("caught:", err)
+
+l = variadics.Log()
+print("l.Addf('no args'):", l.Addf("no args"))
+print("l.Addf('%s=%d %v', 'x', 1, True):", l.Addf("%s=%d %v", "x", 1, True))
+print("l.Addf('%v %v', 1.5, None):", l.Addf("%v %v", 1.5, None))
+print("l.Lines:", list(l.Lines))
+print("Sprintf('%d-%d', 1, 2):", variadics.Sprintf("%d-%d", 1, 2))
+print("l.Points():", l.Points())
+print("l.Points(Point(X=1, Y=2), Point(X=3)):", l.Points(variadics.Point(X=1, Y=2), variadics.Point(X=3)))
+print("Sprintf('%v', [1, 2]):", variadics.Sprintf("%v", [1, 2]))
+print("Sprintf('%v %v', (1,), 'a'):", variadics.Sprintf("%v %v", (1,), "a"))
@@ -0,0 +1,108 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package variadics tests funcs and methods taking a ...T parameter, called
+// with the items as python arguments.
+package variadics
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Max returns the largest of xs, or 0 without any.
+func Max(xs ...int) int {
+	m := 0
+	for i, x := range xs {
+		if i == 0 || x > m {
+			m = x
+		}
+	}
+	return m
+}
+
+// Count returns the number of xs.
+func Count(xs ...int) int {
+	return len(xs)
+}
+
+// Mean returns the mean of xs, or 0 without any.
+func Mean(xs ...float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+// Any returns whether one of bs is true.
+func Any(bs ...bool) bool {
+	for _, b := range bs {
+		if b {
+			return true
+		}
+	}
+	return false
+}
+
+// Join joins parts with sep.
+func Join(sep string, parts ...string) string {
+	return strings.Join(parts, sep)
+}
+
+// Bytes returns the sum of bs.
+func Bytes(bs ...uint8) int {
+	n := 0
+	for _, b := range bs {
+		n += int(b)
+	}
+	return n
+}
+
+// Acc accumulates ints.
+type Acc struct {
+	Total int
+}
+
+// Add adds xs to a, and returns its new total.
+func (a *Acc) Add(xs ...int) int {
+	for _, x := range xs {
+		a.Total += x
+	}
+	return a.Total
+}
+
+// Log collects formatted lines.
+type Log struct {
+	Lines []string
+}
+
+// Addf appends the line formatted from format and a to l, and returns the
+// number of lines of l.
+func (l *Log) Addf(format string, a ...interface{}) int {
+	l.Lines = append(l.Lines, fmt.Sprintf(format, a...))
+	return len(l.Lines)
+}
+
+// Sprintf formats a according to format.
+func Sprintf(format string, a ...interface{}) string {
+	return fmt.Sprintf(format, a...)
+}
+
+// Points returns the number of points and their sum of coordinates.
+func (l *Log) Points(ps ...Point) string {
+	sum := 0
+	for _, p := range ps {
+		sum += p.X + p.Y
+	}
+	return fmt.Sprintf("%d points, sum %d", len(ps), sum)
+}
+
+// Point is a point of the plane.
+type Point struct {
+	X, Y int
+}
@@ -47,3 +47,37 @@ print("k2 = %s" % vars.GetKind2())
 #print("k4 = %s" % vars.GetKind4())
 
 
+
+# vars are module attributes too.
+print("vars.Debug = %s" % (vars.Debug,))
+vars.Debug = True
+print("vars.Debug = True")
+print("vars.Debug = %s" % (vars.Debug,))
+print("vars.GetDebug() = %s" % (vars.GetDebug(),))
+vars.V1 = "-v1 again-"
+print("vars.V1 = %s" % (vars.V1,))
+print("vars.Kind1 = %s" % (vars.Kind1,))
+print("vars.Settings.Name = %s" % (vars.Settings.Name,))
+s = vars.Settings
+s.Name = "custom"
+vars.Settings = s
+print("vars.Settings = s")
+print("vars.Settings.Name = %s" % (vars.Settings.Name,))
+print("vars.V2 = 'x'")
+try:
+    vars.V2 = 'x'
+except TypeError as err:
+    print("caught: %s" % (err,))
+print("del vars.Debug")
+try:
+    del vars.Debug
+except TypeError as err:
+    print("caught: %s" % (err,))
+print("vars.Other = 1")
+vars.Other = 1
+print("vars.Other = %s" % (vars.Other,))
+
+# go funcs win over the generated funcs of the vars they clash with.
+print("vars.SetLevel(5) = %s" % (vars.SetLevel(5),))
+print("vars.Level = %s" % (vars.Level,))
+print("vars.GetLevel() = %s" % (vars.GetLevel(),))
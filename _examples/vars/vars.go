@@ -30,3 +30,30 @@ var (
 //  Kind3 kind = 3
 //  Kind4      = 4
 // )
+
+// Debug enables the debug mode.
+var Debug bool
+
+// Config holds settings.
+type Config struct {
+	Name string
+}
+
+// Settings is a var of a struct type.
+var Settings = Config{Name: "default"}
+
+// Level is the verbosity, from 0 to 3.
+var Level = 1
+
+// SetLevel sets Level, clamped to [0, 3], and returns it.
+// It is bound in place of the SetLevel func of the var.
+func SetLevel(l int) int {
+	switch {
+	case l < 0:
+		l = 0
+	case l > 3:
+		l = 3
+	}
+	Level = l
+	return l
+}
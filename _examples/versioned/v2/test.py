@@ -0,0 +1,14 @@
+# Copyright 2015 The go-python Authors.  All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+
+## py2/py3 compat
+from __future__ import print_function
+
+import versioned
+
+print("versioned.__name__ = %s" % (versioned.__name__,))
+print("versioned.Version = %d" % (versioned.Version,))
+p = versioned.NewPoint(1, 2)
+print("p.Sum() = %d" % (p.Sum(),))
+print("type(p) = %s" % (type(p).__name__,))
@@ -0,0 +1,25 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// package versioned tests the wrapping of a package whose import path ends
+// with a major version, /v2, rather than with its name.
+package versioned
+
+// Version is the major version of the package.
+const Version = 2
+
+// Point is a point.
+type Point struct {
+	X, Y int
+}
+
+// NewPoint returns the point (x, y).
+func NewPoint(x, y int) *Point {
+	return &Point{X: x, Y: y}
+}
+
+// Sum returns the sum of the coordinates of p.
+func (p *Point) Sum() int {
+	return p.X + p.Y
+}
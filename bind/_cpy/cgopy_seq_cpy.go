@@ -12,10 +12,14 @@ package main
 //#include <stddef.h>
 //#include <stdlib.h>
 //#include <string.h>
+//
+//void cgopy_seq_callback(void *fn, uint32_t code, uint8_t *req, uint32_t reqlen, uint8_t **res, uint32_t *reslen, void **ret);
+//void cgopy_seq_callback_release(void *o);
 import "C"
 
 import (
 	"fmt"
+	"runtime"
 	"sync"
 	"unsafe"
 
@@ -54,6 +58,46 @@ func cgopy_seq_destroy_ref(refnum C.int32_t) {
 	seq.Delete(int32(refnum))
 }
 
+// pyFunc is a python callable, called back by the go funcs made from it.
+type pyFunc struct {
+	obj unsafe.Pointer // PyObject*, referenced by the pyFunc
+}
+
+// newPyFunc returns the python callable fn, whose reference is released
+// once the returned value is garbage collected.
+func newPyFunc(fn uint64) *pyFunc {
+	f := &pyFunc{obj: unsafe.Pointer(uintptr(fn))}
+	runtime.SetFinalizer(f, func(f *pyFunc) {
+		C.cgopy_seq_callback_release(f.obj)
+	})
+	return f
+}
+
+// call calls the python callable with the arguments in in, through the
+// trampoline of the func type identified by code, and passes its results
+// to read. The python object holding them is released once read.
+func (f *pyFunc) call(code uint32, in *seq.Buffer, read func(out *seq.Buffer)) {
+	var (
+		req    *C.uint8_t
+		res    *C.uint8_t
+		reslen C.uint32_t
+		ret    unsafe.Pointer
+	)
+	if in.Offset > 0 {
+		req = (*C.uint8_t)(unsafe.Pointer(&in.Data[0]))
+	}
+	C.cgopy_seq_callback(f.obj, C.uint32_t(code), req, C.uint32_t(in.Offset), &res, &reslen, &ret)
+	runtime.KeepAlive(f)
+
+	out := new(seq.Buffer)
+	if reslen > 0 {
+		out.Data = C.GoBytes(unsafe.Pointer(res), C.int(reslen))
+	}
+	C.free(unsafe.Pointer(res))
+	read(out)
+	C.cgopy_seq_callback_release(ret)
+}
+
 type request struct {
 	ref    *seq.Ref
 	handle int32
@@ -26,14 +26,76 @@ func (list ErrorList) Error() string {
 	return buf.String()
 }
 
-// GenCPython generates a (C)Python package from a Go package
-func GenCPython(w io.Writer, fset *token.FileSet, pkg *Package, lang int) error {
+// Diagnostic reports an exported entity of a package which could not be
+// bound, and was skipped.
+type Diagnostic struct {
+	Pos  token.Position // position of the entity, if known
+	Kind string         // kind of the entity: "function", "method", "field", ...
+	Name string         // qualified name of the entity
+	Err  error          // reason why the entity was skipped
+}
+
+func (d *Diagnostic) Error() string {
+	msg := fmt.Sprintf("gopy: skipped %s %s: %v", d.Kind, d.Name, d.Err)
+	if d.Pos.IsValid() {
+		return d.Pos.String() + ": " + msg
+	}
+	return msg
+}
+
+// Note reports an exported entity of a package which is bound in a
+// restricted way, such as a type wrapped by pointer only.
+type Note struct {
+	Pos  token.Position // position of the entity, if known
+	Name string         // qualified name of the entity
+	Msg  string         // how the entity is bound
+}
+
+func (n *Note) String() string {
+	msg := fmt.Sprintf("gopy: %s: %s", n.Name, n.Msg)
+	if n.Pos.IsValid() {
+		return n.Pos.String() + ": " + msg
+	}
+	return msg
+}
+
+// Symbol reports how an exported entity of a package is bound, as listed
+// by Package.Report.
+type Symbol struct {
+	Pos    token.Position // position of the entity, if known
+	Kind   string         // kind of the entity: "function", "type", "method", ...
+	Name   string         // qualified name of the entity
+	Bound  bool           // whether the entity is exposed to python
+	Reason string         // why the entity is skipped, or how it is bound if not as is
+}
+
+// BuildInfo describes the gopy invocation generating the bindings of a
+// package, for users to paste into bug reports.
+type BuildInfo struct {
+	Version string // version of gopy
+	Cmd     string // command line generating the bindings
+}
+
+// GenCPython generates a (C)Python package from a Go package.
+// lang is the version of the c-python api (2), or 0 for sources compiling
+// against the python-2 and the python-3 headers. naming selects how go
+// names are exposed to python, async whether the funcs and methods
+// annotated with //gopy:async get an _async variant, durations how
+// time.Duration values are returned, and info is exposed as module
+// attributes.
+func GenCPython(w io.Writer, fset *token.FileSet, pkg *Package, lang int, naming Naming, async bool, durations Durations, info BuildInfo) error {
 	gen := &cpyGen{
 		decl: &printer{buf: new(bytes.Buffer), indentEach: []byte("\t")},
 		impl: &printer{buf: new(bytes.Buffer), indentEach: []byte("\t")},
 		fset: fset,
 		pkg:  pkg,
 		lang: lang,
+
+		naming: naming,
+		async:  async,
+		info:   info,
+
+		durations: durations,
 	}
 	err := gen.gen()
 	if err != nil {
@@ -0,0 +1,189 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bind
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// converter holds the funcs converting the values of a type of the package
+// to and from a basic type, as named by the //gopy:convert To From
+// directive of the type:
+//
+//	func To(v T) B
+//	func From(v B) (T, error)
+//
+// The values of the type are exchanged with python as values of B, rather
+// than wrapped into a python type. From checks the values given by python:
+// its error is raised as a ValueError.
+type converter struct {
+	obj  *types.TypeName // converted type T
+	base *types.Basic    // type B exchanged with python
+	to   *types.Func
+	from *types.Func
+}
+
+// converters returns the converters of the exported types of the package
+// annotated with a //gopy:convert directive, sorted by type name.
+func (p *Package) converters() ([]*converter, error) {
+	var convs []*converter
+	scope := p.pkg.Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || !tn.Exported() || tn.IsAlias() {
+			continue
+		}
+		args := directiveArgs(p.getTypeDoc(name), "gopy:convert")
+		if len(args) == 0 {
+			continue
+		}
+		conv, err := newConverter(scope, tn, args)
+		if err != nil {
+			return nil, fmt.Errorf("bind: %s: //gopy:convert %s: %v", name, strings.Join(args, " "), err)
+		}
+		convs = append(convs, conv)
+	}
+	return convs, nil
+}
+
+// newConverter returns the converter of the type obj, made of the funcs of
+// scope named by args.
+func newConverter(scope *types.Scope, obj *types.TypeName, args []string) (*converter, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("want the names of a To and a From func")
+	}
+	if isGeneric(obj.Type()) {
+		return nil, fmt.Errorf("generic type %s", obj.Name())
+	}
+	var fcts [2]*types.Func
+	for i, name := range args {
+		fct, ok := scope.Lookup(name).(*types.Func)
+		if !ok || !fct.Exported() {
+			return nil, fmt.Errorf("%s is not an exported func of the package", name)
+		}
+		fcts[i] = fct
+	}
+	to, from := fcts[0], fcts[1]
+	T := obj.Type()
+
+	sig := to.Type().(*types.Signature)
+	if sig.TypeParams().Len() > 0 || sig.Params().Len() != 1 || sig.Results().Len() != 1 ||
+		!types.Identical(sig.Params().At(0).Type(), T) {
+		return nil, fmt.Errorf("%s must be a func(%s) B", to.Name(), obj.Name())
+	}
+	base, ok := sig.Results().At(0).Type().(*types.Basic)
+	if !ok || !isConvertBase(base) {
+		return nil, fmt.Errorf("%s returns %s: want a bool, an integer, a float or a string",
+			to.Name(), typeString(sig.Results().At(0).Type()),
+		)
+	}
+
+	sig = from.Type().(*types.Signature)
+	if sig.TypeParams().Len() > 0 || sig.Params().Len() != 1 || sig.Results().Len() != 2 ||
+		!types.Identical(sig.Params().At(0).Type(), base) ||
+		!types.Identical(sig.Results().At(0).Type(), T) ||
+		!isErrorType(sig.Results().At(1).Type()) {
+		return nil, fmt.Errorf("%s must be a func(%s) (%s, error)", from.Name(), base.Name(), obj.Name())
+	}
+
+	return &converter{obj: obj, base: base, to: to, from: from}, nil
+}
+
+// isConvertBase returns whether values of a converted type may be exchanged
+// with python as values of typ.
+func isConvertBase(typ *types.Basic) bool {
+	switch typ.Kind() {
+	case types.Bool, types.String,
+		types.Int, types.Int8, types.Int16, types.Int32, types.Int64,
+		types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64,
+		types.Float32, types.Float64:
+		return true
+	}
+	return false
+}
+
+// isHook returns whether obj is the To or the From func of a converter.
+func (conv *converter) isHook(obj types.Object) bool {
+	return obj == conv.to || obj == conv.from
+}
+
+// id returns the id of the converted type.
+func (conv *converter) id() string {
+	return conv.obj.Pkg().Name() + "_" + conv.obj.Name()
+}
+
+// held returns whether the values of the converted type are held by
+// pointer by the go side of the bindings, as structs, arrays and slices
+// are.
+func (conv *converter) held() bool {
+	switch conv.obj.Type().Underlying().(type) {
+	case *types.Struct, *types.Array, *types.Slice:
+		return true
+	}
+	return false
+}
+
+// checkConverted returns an error if typ refers to a pointer to a converted
+// type: converted values are copied to and from python values, and cannot
+// be shared.
+// The named types typ refers to are checked on their own.
+func (sym *symtab) checkConverted(typ types.Type) error {
+	switch typ := unalias(typ).(type) {
+	case *types.Pointer:
+		if conv := sym.conv(typ.Elem()); conv != nil {
+			return fmt.Errorf("pointer to converted type %s", typeString(conv.obj.Type()))
+		}
+		return sym.checkConverted(typ.Elem())
+	case *types.Slice:
+		return sym.checkConverted(typ.Elem())
+	case *types.Array:
+		return sym.checkConverted(typ.Elem())
+	case *types.Chan:
+		return sym.checkConverted(typ.Elem())
+	case *types.Map:
+		if err := sym.checkConverted(typ.Key()); err != nil {
+			return err
+		}
+		return sym.checkConverted(typ.Elem())
+	case *types.Struct:
+		for i := 0; i < typ.NumFields(); i++ {
+			if err := sym.checkConverted(typ.Field(i).Type()); err != nil {
+				return err
+			}
+		}
+	case *types.Signature:
+		for _, tuple := range []*types.Tuple{typ.Params(), typ.Results()} {
+			for i := 0; i < tuple.Len(); i++ {
+				if err := sym.checkConverted(tuple.At(i).Type()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkConvertedObject returns an error if the type of obj refers to a
+// pointer to a converted type. The fields of structs are checked one by
+// one.
+func (p *Package) checkConvertedObject(obj types.Object) error {
+	typ := obj.Type()
+	if _, ok := obj.(*types.TypeName); ok {
+		if _, ok := typ.Underlying().(*types.Struct); ok {
+			return nil
+		}
+		typ = typ.Underlying()
+	}
+	return p.syms.checkConverted(typ)
+}
+
+// isWrappedField returns whether the struct field f is exposed to python:
+// fields holding pointers to converted types are not, nor the ones whose
+// type has no symbol.
+func (sym *symtab) isWrappedField(f *types.Var) bool {
+	return isWrappedField(f) && sym.checkConverted(f.Type()) == nil && sym.unbound[sym.typename(f.Type(), nil)] == nil
+}
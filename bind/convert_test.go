@@ -0,0 +1,119 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bind
+
+import (
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+)
+
+// newTestPackage type-checks the package p made of src, and binds it.
+func newTestPackage(t *testing.T, src string) (*Package, error) {
+	t.Helper()
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "gc", nil)}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dpkg, err := doc.NewFromFiles(fset, []*ast.File{f}, "p", doc.PreserveAST)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewPackage(fset, pkg, dpkg, NetTypesText)
+}
+
+func TestConverters(t *testing.T) {
+	const src = `package p
+
+// C is a color.
+//
+//gopy:convert %s
+type C struct{ R, G, B uint8 }
+
+func ToPy(c C) string              { return "" }
+func FromPy(s string) (C, error)   { return C{}, nil }
+func ToInt(c C) int                { return 0 }
+func FromInt(n int) C              { return C{} }
+func ToRune(c C) rune              { return 0 }
+func ToPtr(c C) *C                 { return nil }
+func toPy(c C) string              { return "" }
+`
+	for _, tc := range []struct {
+		args string
+		err  string
+	}{
+		{args: "ToPy FromPy", err: ""},
+		{args: "ToPy", err: "bind: C: //gopy:convert ToPy: want the names of a To and a From func"},
+		{args: "toPy FromPy", err: "bind: C: //gopy:convert toPy FromPy: toPy is not an exported func of the package"},
+		{args: "ToPy C", err: "bind: C: //gopy:convert ToPy C: C is not an exported func of the package"},
+		{args: "FromPy ToPy", err: "bind: C: //gopy:convert FromPy ToPy: FromPy must be a func(C) B"},
+		{args: "ToRune FromPy", err: "bind: C: //gopy:convert ToRune FromPy: FromPy must be a func(rune) (C, error)"},
+		{args: "ToPtr FromPy", err: "bind: C: //gopy:convert ToPtr FromPy: ToPtr returns *p.C: want a bool, an integer, a float or a string"},
+		{args: "ToInt FromPy", err: "bind: C: //gopy:convert ToInt FromPy: FromPy must be a func(int) (C, error)"},
+		{args: "ToInt FromInt", err: "bind: C: //gopy:convert ToInt FromInt: FromInt must be a func(int) (C, error)"},
+	} {
+		_, err := newTestPackage(t, fmt.Sprintf(src, tc.args))
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%q: unexpected error: %v", tc.args, err)
+		case tc.err != "" && (err == nil || err.Error() != tc.err):
+			t.Errorf("%q: got error %v, want %q", tc.args, err, tc.err)
+		}
+	}
+}
+
+func TestConvertedPointers(t *testing.T) {
+	const src = `package p
+
+// C is a color.
+//
+//gopy:convert ToPy FromPy
+type C struct{ R, G, B uint8 }
+
+func ToPy(c C) string            { return "" }
+func FromPy(s string) (C, error) { return C{}, nil }
+
+func F1(c C) []C           { return nil }
+func F2(c *C)              {}
+func F3(f func(c *C))     {}
+
+type S struct {
+	A C
+	B *C
+}
+
+func (s *S) M1() C   { return s.A }
+func (s *S) M2() *C  { return s.B }
+`
+	p, err := newTestPackage(t, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, err := range p.Diagnostics() {
+		d := err.(*Diagnostic)
+		got = append(got, d.Name+": "+d.Err.Error())
+	}
+	want := []string{
+		"p.F2: pointer to converted type p.C",
+		"p.F3: pointer to converted type p.C",
+		"p.S.B: pointer to converted type p.C",
+		"p.S.M2: pointer to converted type p.C",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("diagnostics:\ngot= %q\nwant=%q", got, want)
+	}
+}
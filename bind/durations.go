@@ -0,0 +1,40 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bind
+
+import (
+	"fmt"
+)
+
+// Durations describes how time.Duration values are returned to python.
+// Durations are received from python as float seconds or as
+// datetime.timedelta values, whatever the convention.
+type Durations int
+
+const (
+	DurationsSeconds   Durations = iota // durations are returned as float seconds
+	DurationsTimedelta                  // durations are returned as datetime.timedelta values
+)
+
+// ParseDurations returns the Durations convention named s.
+func ParseDurations(s string) (Durations, error) {
+	switch s {
+	case "", "seconds":
+		return DurationsSeconds, nil
+	case "timedelta":
+		return DurationsTimedelta, nil
+	}
+	return DurationsSeconds, fmt.Errorf("bind: unknown durations convention %q", s)
+}
+
+// c2py returns the name of the C function converting the nanoseconds of a
+// time.Duration to a python object.
+func (d Durations) c2py() string {
+	switch d {
+	case DurationsTimedelta:
+		return "cgopy_duration_timedelta"
+	}
+	return "cgopy_duration_seconds"
+}
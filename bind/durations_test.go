@@ -0,0 +1,65 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bind
+
+import (
+	"testing"
+)
+
+func TestParseDurations(t *testing.T) {
+	for _, table := range []struct {
+		name string
+		want Durations
+		err  bool
+	}{
+		{"", DurationsSeconds, false},
+		{"seconds", DurationsSeconds, false},
+		{"timedelta", DurationsTimedelta, false},
+		{"ns", DurationsSeconds, true},
+	} {
+		got, err := ParseDurations(table.name)
+		if (err != nil) != table.err {
+			t.Errorf("ParseDurations(%q): unexpected error value: %v\n", table.name, err)
+			continue
+		}
+		if got != table.want {
+			t.Errorf("ParseDurations(%q): got=%v want=%v\n", table.name, got, table.want)
+		}
+	}
+}
+
+func TestDurationTypes(t *testing.T) {
+	const src = `package p
+
+import "time"
+
+// Timeout is wrapped as any named int64.
+type Timeout time.Duration
+
+func F(d time.Duration) time.Duration { return d }
+func G(d Timeout) Timeout             { return d }
+`
+	p, err := newTestPackage(t, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	funcs := append([]Func(nil), p.funcs...)
+	for _, typ := range p.types {
+		if isDurationType(typ.GoType()) {
+			t.Errorf("time.Duration wrapped as %s", typ.ID())
+		}
+		funcs = append(funcs, typ.ctors...)
+	}
+	if len(funcs) != 2 {
+		t.Fatalf("got %d funcs, want 2", len(funcs))
+	}
+	for _, f := range funcs {
+		ret := p.syms.symtype(f.ret)
+		want := f.GoName() == "F"
+		if got := ret.id == "duration"; got != want {
+			t.Errorf("%s: got duration=%v, want %v (sym=%s)", f.GoName(), got, want, ret.id)
+		}
+	}
+}
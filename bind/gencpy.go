@@ -5,14 +5,91 @@
 package bind
 
 import (
+	"fmt"
 	"go/token"
+	"go/types"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
 const (
+	// cPy2Only rejects the python-3 headers, for the sources generated
+	// against the python-2 API only.
+	cPy2Only = `#if PY_VERSION_HEX > 0x03000000
+#error "Python-3 is not yet supported by gopy: generate the sources with -py23"
+#endif`
+
+	// cPy23 maps the python-2 API used by the generated sources onto
+	// python-3, for the sources compiling against both.
+	cPy23 = `#if PY_MAJOR_VERSION >= 3
+#define PyInt_Check PyLong_Check
+#define PyInt_FromLong PyLong_FromLong
+#define PyInt_FromSsize_t PyLong_FromSsize_t
+#define PyInt_FromString PyLong_FromString
+#define PyInt_AsLong PyLong_AsLong
+#define PyInt_AS_LONG PyLong_AsLong
+#define PyInt_AsSsize_t PyLong_AsSsize_t
+
+// strings are unicode objects, exchanged with go as their UTF-8 bytes.
+#define PyString_Check PyUnicode_Check
+#define PyString_FromString PyUnicode_FromString
+#define PyString_FromStringAndSize PyUnicode_FromStringAndSize
+#define PyString_FromFormat PyUnicode_FromFormat
+#define PyString_AsString(o) ((char*)PyUnicode_AsUTF8(o))
+#define PyString_AS_STRING(o) ((char*)PyUnicode_AsUTF8(o))
+#define PyString_Size cgopy_py3_string_size
+#define PyString_GET_SIZE cgopy_py3_string_size
+#define PyString_ConcatAndDel PyUnicode_AppendAndDel
+
+#define Py_TPFLAGS_HAVE_NEWBUFFER 0
+#define PyFloat_FromString(o, pend) PyFloat_FromString(o)
+
+// the UTF-8 bytes of strings are read from the unicode objects.
+#define PyUnicode_AsUTF8String cgopy_py3_utf8_new
+
+#undef PyUnicode_GET_SIZE
+#define PyUnicode_GET_SIZE PyUnicode_GetLength
+
+// cgopy_py3_string_size returns the number of UTF-8 bytes of the unicode
+// object o, or -1 with a python exception set.
+static Py_ssize_t
+cgopy_py3_string_size(PyObject *o) {
+	Py_ssize_t n = -1;
+	if (PyUnicode_AsUTF8AndSize(o, &n) == NULL) {
+		return -1;
+	}
+	return n;
+}
+
+// cgopy_py3_utf8_new returns a new reference to the unicode object o, whose
+// UTF-8 bytes are read by PyString_AsString.
+static PyObject*
+cgopy_py3_utf8_new(PyObject *o) {
+	Py_INCREF(o);
+	return o;
+}
+
+// cgopy_richcompare returns the result of the comparison op of two values
+// from their three-way comparison c, for the types compared by tp_compare.
+static PyObject*
+cgopy_richcompare(int c, int op) {
+	int res = 0;
+	switch (op) {
+	case Py_LT: res = c < 0; break;
+	case Py_LE: res = c <= 0; break;
+	case Py_EQ: res = c == 0; break;
+	case Py_NE: res = c != 0; break;
+	case Py_GT: res = c > 0; break;
+	case Py_GE: res = c >= 0; break;
+	}
+	return PyBool_FromLong(res);
+}
+#endif`
+
 	cPreamble = `/*
   C stubs for package %[1]q.
-  gopy gen -lang=python %[1]s
+  %[4]s
 
   File is generated by gopy gen. Do not edit.
 */
@@ -24,7 +101,13 @@ const (
 #include "Python.h"
 #include "structmember.h"
 #include "memoryobject.h"
+#if PY_MAJOR_VERSION < 3
 #include "bufferobject.h"
+#endif
+
+#ifndef _WIN32
+#include <fcntl.h>
+#endif
 
 // cpy-seq support
 #include "cgopy_seq_cpy.h"
@@ -33,8 +116,10 @@ const (
 // header exported from 'go tool cgo'
 #include "%[3]s.h"
 
-#if PY_VERSION_HEX > 0x03000000
-#error "Python-3 is not yet supported by gopy"
+%[5]s
+
+#ifndef Py_SET_TYPE
+#define Py_SET_TYPE(o, type) (Py_TYPE(o) = (type))
 #endif
 
 // descriptor for calls placed to the wrapped go package
@@ -62,10 +147,37 @@ typedef struct _gopy_object gopy_object;
 
 // helpers for cgopy
 
+// cgopy_int_from_int64 returns v as an int, and as a long in python 2 only
+// when it does not fit an int there: the repr of longs has an L suffix.
+static PyObject*
+cgopy_int_from_int64(long long v) {
+#if PY_MAJOR_VERSION < 3
+	if (v >= LONG_MIN && v <= LONG_MAX) {
+		return PyInt_FromLong((long)v);
+	}
+#endif
+	return PyLong_FromLongLong(v);
+}
+
+// cgopy_int_from_uint64 returns v as an int, and as a long in python 2 only
+// when it does not fit an int there.
+static PyObject*
+cgopy_int_from_uint64(unsigned long long v) {
+#if PY_MAJOR_VERSION < 3
+	if (v <= (unsigned long long)LONG_MAX) {
+		return PyInt_FromLong((long)v);
+	}
+#endif
+	return PyLong_FromUnsignedLongLong(v);
+}
+
 #define def_cnv(name, c2py, py2c, gotype) \
 	static int \
 	cgopy_cnv_py2c_ ## name(PyObject *o, gotype *addr) { \
 		*addr = py2c(o); \
+		if (*addr == (gotype)-1 && PyErr_Occurred()) { \
+			return 0; \
+		} \
 		return 1;	\
 	} \
 	\
@@ -79,22 +191,34 @@ typedef struct _gopy_object gopy_object;
 	def_cnv(uint,  PyLong_FromUnsignedLong, PyLong_AsUnsignedLong, GoUint)
 #else
 	def_cnv( int,  PyInt_FromLong, PyInt_AsLong, GoInt)
-	def_cnv(uint,  PyInt_FromLong, PyInt_AsLong, GoUint)
+	def_cnv(uint,  cgopy_int_from_uint64, PyInt_AsLong, GoUint)
 #endif
 
 def_cnv(  int8, PyInt_FromLong, PyInt_AsLong, GoInt8)
 def_cnv( int16, PyInt_FromLong, PyInt_AsLong, GoInt16)
 def_cnv( int32, PyInt_FromLong, PyInt_AsLong, GoInt32)
-def_cnv( int64, PyLong_FromLong, PyLong_AsLong, GoInt64)
+def_cnv( int64, cgopy_int_from_int64, PyLong_AsLong, GoInt64)
 def_cnv(uint8,  PyInt_FromLong, PyInt_AsLong, GoUint8)
 def_cnv(uint16, PyInt_FromLong, PyInt_AsLong, GoUint16)
 def_cnv(uint32, PyInt_FromLong, PyInt_AsLong, GoUint32)
-def_cnv(uint64, PyLong_FromUnsignedLong, PyLong_AsUnsignedLong, GoUint64)
+def_cnv(uint64, cgopy_int_from_uint64, PyLong_AsUnsignedLong, GoUint64)
 
 def_cnv(float64, PyFloat_FromDouble, PyFloat_AsDouble, GoFloat64)
 
 #undef def_cnv
 
+// cgopy_check_int returns whether o may be converted to a go integer.
+// like the int parameters parsed by PyArg_ParseTuple, floats are not
+// truncated: they raise a TypeError.
+static int
+cgopy_check_int(PyObject *o) {
+	if (PyFloat_Check(o)) {
+		PyErr_SetString(PyExc_TypeError, "integer argument expected, got float");
+		return 0;
+	}
+	return 1;
+}
+
 static int
 cgopy_cnv_py2c_bool(PyObject *o, GoUint8 *addr) {
 	*addr = (o == Py_True) ? 1 : 0;
@@ -165,184 +289,2549 @@ static PyObject*
 cgopy_cnv_c2py_complex128(GoComplex128 *addr) {
 	return PyComplex_FromDoubles(creal(*addr), cimag(*addr));
 }
-`
-)
 
-type cpyGen struct {
-	decl *printer
-	impl *printer
+// dynamically typed values, exchanged with seq.Buffer.WriteValue and
+// seq.Buffer.ReadValue on the go side.
 
-	fset *token.FileSet
-	pkg  *Package
-	err  ErrorList
+enum {
+	cgopy_seq_value_nil = 0,
+	cgopy_seq_value_bool,
+	cgopy_seq_value_int,
+	cgopy_seq_value_float,
+	cgopy_seq_value_string,
+	cgopy_seq_value_list,
+	cgopy_seq_value_dict,
+	cgopy_seq_value_ref,
+	cgopy_seq_value_error,
+	cgopy_seq_value_uint
+};
 
-	lang int // c-python api version (2,3)
-}
+// cgopy_seq_value_type returns the python type wrapping the go values of
+// the named type, or NULL if that type is not wrapped.
+static PyTypeObject*
+cgopy_seq_value_type(const char *name);
 
-func (g *cpyGen) gen() error {
+// cgopy_seq_value_check_ref returns whether o wraps a go value, sent by
+// handle.
+static int
+cgopy_seq_value_check_ref(PyObject *o);
 
-	g.genPreamble()
+// cgopy_seq_check_value returns whether o can be sent as a value.
+// it sets a python exception otherwise.
+static int
+cgopy_seq_check_value(PyObject *o) {
+	if (cgopy_seq_value_check_ref(o)) {
+		return 1;
+	}
+	if (PyLong_Check(o) && !PyBool_Check(o)) {
+		// ints are longs too with python 3.
+		PyLong_AsLongLong(o);
+		return PyErr_Occurred() == NULL;
+	}
+	if (o == Py_None || PyBool_Check(o) || PyInt_Check(o) ||
+	    PyFloat_Check(o) || PyString_Check(o) || PyUnicode_Check(o)) {
+		return 1;
+	}
+	if (PyList_Check(o) || PyTuple_Check(o)) {
+		Py_ssize_t i = 0;
+		for (i = 0; i < PySequence_Fast_GET_SIZE(o); i++) {
+			if (!cgopy_seq_check_value(PySequence_Fast_GET_ITEM(o, i))) {
+				return 0;
+			}
+		}
+		return 1;
+	}
+	if (PyDict_Check(o)) {
+		PyObject *k = NULL, *v = NULL;
+		Py_ssize_t pos = 0;
+		while (PyDict_Next(o, &pos, &k, &v)) {
+			if (!PyString_Check(k) && !PyUnicode_Check(k)) {
+				PyErr_Format(PyExc_TypeError, "invalid dict key type (got=%%s, expected a str)", Py_TYPE(k)->tp_name);
+				return 0;
+			}
+			if (!cgopy_seq_check_value(v)) {
+				return 0;
+			}
+		}
+		return 1;
+	}
+	PyErr_Format(PyExc_TypeError, "invalid value type (got=%%s)", Py_TYPE(o)->tp_name);
+	return 0;
+}
 
-	// first, process types
-	for _, t := range g.pkg.types {
-		sym := t.sym
-		if !sym.isType() {
-			continue
+static void
+cgopy_seq_buffer_write_value_string(cgopy_seq_buffer buf, PyObject *o) {
+	cgopy_seq_bytearray arr;
+	PyObject *str = NULL;
+	if (PyUnicode_Check(o)) {
+		str = PyUnicode_AsUTF8String(o);
+	} else {
+		str = o;
+		Py_INCREF(str);
+	}
+	arr.Data = NULL;
+	arr.Len = 0;
+	if (str != NULL) {
+		arr.Data = (uint8_t*)PyString_AS_STRING(str);
+		arr.Len = PyString_GET_SIZE(str);
+	}
+	cgopy_seq_buffer_write_bytearray(buf, arr);
+	Py_XDECREF(str);
+}
+
+// cgopy_seq_strings_new returns a new reference to the list or tuple of str
+// o, or a list of the items of o if it is of type, the wrapper of []string.
+// It sets a TypeError, naming the index of the first item which is not a
+// str, and returns NULL otherwise.
+static PyObject*
+cgopy_seq_strings_new(PyObject *o, PyTypeObject *type) {
+	Py_ssize_t i = 0;
+	if (PyObject_TypeCheck(o, type)) {
+		return PySequence_List(o);
+	}
+	if (!PyList_Check(o) && !PyTuple_Check(o)) {
+		PyErr_Format(PyExc_TypeError, "invalid type (got=%%s, expected a list or tuple of str)",
+			Py_TYPE(o)->tp_name);
+		return NULL;
+	}
+	for (i = 0; i < PySequence_Fast_GET_SIZE(o); i++) {
+		PyObject *item = PySequence_Fast_GET_ITEM(o, i);
+		if (!PyString_Check(item) && !PyUnicode_Check(item)) {
+			PyErr_Format(PyExc_TypeError, "invalid item %%zd (got=%%s, expected a str)",
+				i, Py_TYPE(item)->tp_name);
+			return NULL;
 		}
-		g.genType(t)
 	}
+	Py_INCREF(o);
+	return o;
+}
 
-	// expose ctors at module level
-	for _, t := range g.pkg.types {
-		for _, ctor := range t.ctors {
-			g.genFunc(ctor)
+// cgopy_overload_err sets a TypeError for the arguments args of the
+// overloaded func name, matching none of the signatures sigs.
+static void
+cgopy_overload_err(const char *name, PyObject *args, const char *sigs) {
+	Py_ssize_t i = 0;
+	PyObject *got = PyString_FromString("");
+	for (i = 0; got != NULL && i < PyTuple_GET_SIZE(args); i++) {
+		if (i > 0) {
+			PyString_ConcatAndDel(&got, PyString_FromString(", "));
 		}
+		PyString_ConcatAndDel(&got, PyString_FromString(Py_TYPE(PyTuple_GET_ITEM(args, i))->tp_name));
 	}
+	if (got == NULL) {
+		return;
+	}
+	PyErr_Format(PyExc_TypeError, "%%s(%%s) matches none of:\n%%s",
+		name, PyString_AS_STRING(got), sigs);
+	Py_DECREF(got);
+}
 
-	for _, f := range g.pkg.funcs {
-		g.genFunc(f)
+// cgopy_err_item prefixes the message of the pending TypeError, if any,
+// with the repr of the key of the item it was raised for.
+static void
+cgopy_err_item(PyObject *key) {
+	PyObject *type = NULL, *value = NULL, *tb = NULL;
+	PyObject *repr = NULL, *msg = NULL;
+	if (!PyErr_ExceptionMatches(PyExc_TypeError)) {
+		return;
 	}
+	PyErr_Fetch(&type, &value, &tb);
+	PyErr_NormalizeException(&type, &value, &tb);
+	repr = PyObject_Repr(key);
+	if (repr != NULL && value != NULL) {
+		msg = PyObject_Str(value);
+	}
+	if (msg == NULL) {
+		// the original error is kept.
+		PyErr_Clear();
+		PyErr_Restore(type, value, tb);
+		Py_XDECREF(repr);
+		return;
+	}
+	PyErr_Format(PyExc_TypeError, "invalid item %%s: %%s",
+		PyString_AsString(repr), PyString_AsString(msg));
+	Py_XDECREF(type);
+	Py_XDECREF(value);
+	Py_XDECREF(tb);
+	Py_DECREF(repr);
+	Py_DECREF(msg);
+}
 
-	for _, c := range g.pkg.consts {
-		g.genConst(c)
+// cgopy_timeout_error returns the exception raised when a timeout expires:
+// TimeoutError, or a RuntimeError subclass of the same name on python-2,
+// which has none.
+static PyObject*
+cgopy_timeout_error(void) {
+#if PY_MAJOR_VERSION >= 3
+	return PyExc_TimeoutError;
+#else
+	static PyObject *exc = NULL;
+	if (exc == NULL) {
+		exc = PyErr_NewException("gopy.TimeoutError", PyExc_RuntimeError, NULL);
+	}
+	if (exc == NULL) {
+		PyErr_Clear();
+		return PyExc_RuntimeError;
 	}
+	return exc;
+#endif
+}
 
-	for _, v := range g.pkg.vars {
-		g.genVar(v)
+// cgopy_seq_buffer_write_strings writes the number of items of the list or
+// tuple o, returned by cgopy_seq_strings_new, followed by the items.
+static void
+cgopy_seq_buffer_write_strings(cgopy_seq_buffer buf, PyObject *o) {
+	Py_ssize_t i = 0;
+	cgopy_seq_buffer_write_int64(buf, PySequence_Fast_GET_SIZE(o));
+	for (i = 0; i < PySequence_Fast_GET_SIZE(o); i++) {
+		cgopy_seq_buffer_write_value_string(buf, PySequence_Fast_GET_ITEM(o, i));
 	}
+}
 
-	g.impl.Printf("\n/* functions for package %s */\n", g.pkg.pkg.Name())
-	g.impl.Printf("static PyMethodDef cpy_%s_methods[] = {\n", g.pkg.pkg.Name())
-	g.impl.Indent()
-	for _, f := range g.pkg.funcs {
-		name := f.GoName()
-		//obj := scope.Lookup(name)
-		g.impl.Printf("{%[1]q, %[2]s, METH_VARARGS, %[3]q},\n",
-			name, "cpy_func_"+f.ID(), f.Doc(),
-		)
+// cgopy_seq_buffer_read_strings reads strings written by the WriteStrings
+// method of seq.Buffer into a new list of str.
+// All the strings are read, even when the list cannot be made.
+static PyObject*
+cgopy_seq_buffer_read_strings(cgopy_seq_buffer buf) {
+	int64_t i = 0;
+	int64_t n = cgopy_seq_buffer_read_int64(buf);
+	PyObject *list = PyList_New((Py_ssize_t)n);
+	for (i = 0; i < n; i++) {
+		cgopy_seq_bytearray arr = cgopy_seq_buffer_read_string(buf);
+		if (list != NULL) {
+#if PY_MAJOR_VERSION >= 3
+			PyObject *item = PyUnicode_FromStringAndSize((const char*)(arr.Data), (Py_ssize_t)(arr.Len));
+#else
+			PyObject *item = PyString_FromStringAndSize((const char*)(arr.Data), (Py_ssize_t)(arr.Len));
+#endif
+			if (item == NULL) {
+				Py_CLEAR(list);
+			} else {
+				PyList_SET_ITEM(list, (Py_ssize_t)i, item);
+			}
+		}
+		cgopy_seq_bytearray_free(arr);
 	}
-	// expose ctors at module level
-	// FIXME(sbinet): attach them to types/structs?
-	// -> problem is if one has 2 or more ctors with exactly the same signature.
-	for _, t := range g.pkg.types {
-		for _, f := range t.ctors {
-			name := f.GoName()
-			//obj := scope.Lookup(name)
-			g.impl.Printf("{%[1]q, %[2]s, METH_VARARGS, %[3]q},\n",
-				name, "cpy_func_"+f.ID(), f.Doc(),
-			)
+	return list;
+}
+
+// cgopy_seq_buffer_write_value writes o, which must have been checked with
+// cgopy_seq_check_value.
+static void
+cgopy_seq_buffer_write_value(cgopy_seq_buffer buf, PyObject *o) {
+	if (cgopy_seq_value_check_ref(o)) {
+		cgopy_seq_bytearray name;
+		name.Data = (uint8_t*)(Py_TYPE(o)->tp_name);
+		name.Len = strlen(Py_TYPE(o)->tp_name);
+		cgopy_seq_buffer_write_int8(buf, cgopy_seq_value_ref);
+		cgopy_seq_buffer_write_bytearray(buf, name);
+		cgopy_seq_buffer_write_int32(buf, ((gopy_object*)o)->cgopy);
+	} else if (o == Py_None) {
+		cgopy_seq_buffer_write_int8(buf, cgopy_seq_value_nil);
+	} else if (PyBool_Check(o)) {
+		cgopy_seq_buffer_write_int8(buf, cgopy_seq_value_bool);
+		cgopy_seq_buffer_write_bool(buf, o == Py_True);
+	} else if (PyInt_Check(o)) {
+		cgopy_seq_buffer_write_int8(buf, cgopy_seq_value_int);
+		cgopy_seq_buffer_write_int64(buf, PyInt_AS_LONG(o));
+	} else if (PyLong_Check(o)) {
+		cgopy_seq_buffer_write_int8(buf, cgopy_seq_value_int);
+		cgopy_seq_buffer_write_int64(buf, PyLong_AsLongLong(o));
+	} else if (PyFloat_Check(o)) {
+		cgopy_seq_buffer_write_int8(buf, cgopy_seq_value_float);
+		cgopy_seq_buffer_write_float64(buf, PyFloat_AS_DOUBLE(o));
+	} else if (PyString_Check(o) || PyUnicode_Check(o)) {
+		cgopy_seq_buffer_write_int8(buf, cgopy_seq_value_string);
+		cgopy_seq_buffer_write_value_string(buf, o);
+	} else if (PyList_Check(o) || PyTuple_Check(o)) {
+		Py_ssize_t i = 0;
+		cgopy_seq_buffer_write_int8(buf, cgopy_seq_value_list);
+		cgopy_seq_buffer_write_int64(buf, PySequence_Fast_GET_SIZE(o));
+		for (i = 0; i < PySequence_Fast_GET_SIZE(o); i++) {
+			cgopy_seq_buffer_write_value(buf, PySequence_Fast_GET_ITEM(o, i));
+		}
+	} else if (PyDict_Check(o)) {
+		PyObject *k = NULL, *v = NULL;
+		Py_ssize_t pos = 0;
+		cgopy_seq_buffer_write_int8(buf, cgopy_seq_value_dict);
+		cgopy_seq_buffer_write_int64(buf, PyDict_Size(o));
+		while (PyDict_Next(o, &pos, &k, &v)) {
+			cgopy_seq_buffer_write_value_string(buf, k);
+			cgopy_seq_buffer_write_value(buf, v);
 		}
 	}
+}
 
-	for _, c := range g.pkg.consts {
-		name := c.GoName()
-		g.impl.Printf("{%[1]q, %[2]s, METH_VARARGS, %[3]q},\n",
-			"Get"+name, "cpy_func_"+c.id+"_get", c.Doc(),
-		)
+// cgopy_seq_buffer_write_exception writes the message of the python
+// exception being raised, which it clears, to be read as a go error: the str
+// of the exception, or the name of its type if empty.
+static void
+cgopy_seq_buffer_write_exception(cgopy_seq_buffer buf) {
+	PyObject *type = NULL, *value = NULL, *tb = NULL, *str = NULL;
+	PyErr_Fetch(&type, &value, &tb);
+	PyErr_NormalizeException(&type, &value, &tb);
+	if (value != NULL) {
+		str = PyObject_Str(value);
 	}
+	if ((str == NULL || PyObject_Size(str) == 0) && type != NULL) {
+		Py_XDECREF(str);
+		str = PyString_FromString(((PyTypeObject*)type)->tp_name);
+	}
+	PyErr_Clear();
+	cgopy_seq_buffer_write_value_string(buf, str);
+	Py_XDECREF(str);
+	Py_XDECREF(type);
+	Py_XDECREF(value);
+	Py_XDECREF(tb);
+}
 
-	for _, v := range g.pkg.vars {
-		name := v.Name()
-		g.impl.Printf("{%[1]q, %[2]s, METH_VARARGS, %[3]q},\n",
-			"Get"+name, "cpy_func_"+v.id+"_get", v.doc,
-		)
-		g.impl.Printf("{%[1]q, %[2]s, METH_VARARGS, %[3]q},\n",
-			"Set"+name, "cpy_func_"+v.id+"_set", v.doc,
-		)
+static PyObject*
+cgopy_seq_buffer_read_value_string(cgopy_seq_buffer buf) {
+	cgopy_seq_bytearray arr = cgopy_seq_buffer_read_bytearray(buf);
+	PyObject *str = PyString_FromStringAndSize((const char*)(arr.Data), (Py_ssize_t)(arr.Len));
+	cgopy_seq_bytearray_free(arr);
+	return str;
+}
+
+// cgopy_seq_buffer_read_value returns a new reference to the value read
+// from buf, or NULL with a python exception set.
+static PyObject*
+cgopy_seq_buffer_read_value(cgopy_seq_buffer buf) {
+	int8_t kind = cgopy_seq_buffer_read_int8(buf);
+	switch (kind) {
+	case cgopy_seq_value_nil:
+		Py_INCREF(Py_None);
+		return Py_None;
+
+	case cgopy_seq_value_bool:
+		return PyBool_FromLong(cgopy_seq_buffer_read_bool(buf));
+
+	case cgopy_seq_value_int: {
+		int64_t v = cgopy_seq_buffer_read_int64(buf);
+		if (v < LONG_MIN || v > LONG_MAX) {
+			return PyLong_FromLongLong(v);
+		}
+		return PyInt_FromLong((long)v);
 	}
 
-	g.impl.Printf("{NULL, NULL, 0, NULL}        /* Sentinel */\n")
-	g.impl.Outdent()
-	g.impl.Printf("};\n\n")
+	case cgopy_seq_value_uint:
+		return PyLong_FromUnsignedLongLong(cgopy_seq_buffer_read_uint64(buf));
 
-	g.impl.Printf("PyMODINIT_FUNC\ninit%[1]s(void)\n{\n", g.pkg.pkg.Name())
-	g.impl.Indent()
-	g.impl.Printf("PyObject *module = NULL;\n\n")
+	case cgopy_seq_value_float:
+		return PyFloat_FromDouble(cgopy_seq_buffer_read_float64(buf));
 
-	g.impl.Printf("/* make sure Cgo is loaded and initialized */\n")
-	g.impl.Printf("cgo_pkg_%[1]s_init();\n\n", g.pkg.pkg.Name())
+	case cgopy_seq_value_string:
+		return cgopy_seq_buffer_read_value_string(buf);
 
-	for _, n := range g.pkg.syms.names() {
-		sym := g.pkg.syms.sym(n)
-		if !sym.isType() {
-			continue
+	case cgopy_seq_value_list: {
+		int64_t i = 0;
+		int64_t n = cgopy_seq_buffer_read_int64(buf);
+		PyObject *list = PyList_New((Py_ssize_t)n);
+		if (list == NULL) {
+			return NULL;
 		}
-		g.impl.Printf(
-			"if (PyType_Ready(&%sType) < 0) { return; }\n",
-			sym.cpyname,
-		)
+		for (i = 0; i < n; i++) {
+			PyObject *item = cgopy_seq_buffer_read_value(buf);
+			if (item == NULL) {
+				Py_DECREF(list);
+				return NULL;
+			}
+			PyList_SET_ITEM(list, (Py_ssize_t)i, item);
+		}
+		return list;
 	}
 
-	g.impl.Printf("module = Py_InitModule3(%[1]q, cpy_%[1]s_methods, %[2]q);\n\n",
-		g.pkg.pkg.Name(),
-		g.pkg.doc.Doc,
-	)
+	case cgopy_seq_value_dict: {
+		int64_t i = 0;
+		int64_t n = cgopy_seq_buffer_read_int64(buf);
+		PyObject *dict = PyDict_New();
+		if (dict == NULL) {
+			return NULL;
+		}
+		for (i = 0; i < n; i++) {
+			int err = -1;
+			PyObject *k = cgopy_seq_buffer_read_value_string(buf);
+			PyObject *v = cgopy_seq_buffer_read_value(buf);
+			if (k != NULL && v != NULL) {
+				err = PyDict_SetItem(dict, k, v);
+			}
+			Py_XDECREF(k);
+			Py_XDECREF(v);
+			if (err < 0) {
+				Py_DECREF(dict);
+				return NULL;
+			}
+		}
+		return dict;
+	}
 
-	for _, n := range g.pkg.syms.names() {
-		sym := g.pkg.syms.sym(n)
-		if !sym.isType() {
-			continue
+	case cgopy_seq_value_ref: {
+		PyObject *o = NULL;
+		PyObject *name = cgopy_seq_buffer_read_value_string(buf);
+		int32_t handle = cgopy_seq_buffer_read_int32(buf);
+		PyTypeObject *type = NULL;
+		if (name != NULL) {
+			type = cgopy_seq_value_type(PyString_AS_STRING(name));
+			if (type == NULL) {
+				PyErr_Format(PyExc_TypeError, "gopy: no python type for go values of type %%s", PyString_AS_STRING(name));
+			}
 		}
-		g.impl.Printf("Py_INCREF(&%sType);\n", sym.cpyname)
-		g.impl.Printf("PyModule_AddObject(module, %q, (PyObject*)&%sType);\n\n",
-			sym.goname,
-			sym.cpyname,
-		)
+		Py_XDECREF(name);
+		if (type != NULL) {
+			o = type->tp_alloc(type, 0);
+		}
+		if (o == NULL) {
+			cgopy_seq_destroy_ref(handle);
+			return NULL;
+		}
+		((gopy_object*)o)->cgopy = handle;
+		return o;
 	}
-	g.impl.Outdent()
-	g.impl.Printf("}\n\n")
 
-	if len(g.err) > 0 {
-		return g.err
+	case cgopy_seq_value_error: {
+		// go values of unsupported types are sent as their error.
+		PyObject *msg = cgopy_seq_buffer_read_value_string(buf);
+		if (msg != NULL) {
+			PyErr_SetObject(PyExc_TypeError, msg);
+			Py_DECREF(msg);
+		}
+		return NULL;
+	}
 	}
 
-	return nil
+	PyErr_Format(PyExc_RuntimeError, "gopy: invalid value kind (%%d)", (int)kind);
+	return NULL;
 }
 
-func (g *cpyGen) genConst(o Const) {
-	g.genFunc(o.f)
+static int
+cgopy_cnv_py2c_dict(PyObject *o, PyObject **addr) {
+	if (!PyDict_Check(o)) {
+		PyErr_Format(PyExc_TypeError, "invalid type (got=%%s, expected a dict)", Py_TYPE(o)->tp_name);
+		return 0;
+	}
+	if (!cgopy_seq_check_value(o)) {
+		return 0;
+	}
+	*addr = o;
+	return 1;
 }
 
-func (g *cpyGen) genVar(v Var) {
+static PyObject*
+cgopy_cnv_c2py_dict(PyObject **addr) {
+	return *addr;
+}
 
-	desc := g.pkg.ImportPath() + "." + v.Name()
-	id := g.pkg.Name() + "_" + v.Name()
-	doc := v.doc
-	{
-		res := []*Var{newVar(g.pkg, v.GoType(), "ret", v.Name(), doc)}
-		sig := newSignature(g.pkg, nil, nil, res)
-		fget := Func{
-			pkg:  g.pkg,
-			sig:  sig,
-			typ:  nil,
-			name: v.Name(),
-			desc: desc + ".get",
-			id:   id + "_get",
-			doc:  "returns " + g.pkg.Name() + "." + v.Name(),
-			ret:  v.GoType(),
-			err:  false,
-		}
-		g.genFunc(fget)
+static int
+cgopy_cnv_py2c_any(PyObject *o, PyObject **addr) {
+	if (!cgopy_seq_check_value(o)) {
+		return 0;
 	}
-	{
-		params := []*Var{newVar(g.pkg, v.GoType(), "arg", v.Name(), doc)}
-		sig := newSignature(g.pkg, nil, params, nil)
-		fset := Func{
-			pkg:  g.pkg,
-			sig:  sig,
-			typ:  nil,
-			name: v.Name(),
-			desc: desc + ".set",
-			id:   id + "_set",
-			doc:  "sets " + g.pkg.Name() + "." + v.Name(),
-			ret:  nil,
-			err:  false,
+	*addr = o;
+	return 1;
+}
+
+static PyObject*
+cgopy_cnv_c2py_any(PyObject **addr) {
+	return *addr;
+}
+
+// *os.File values are exchanged as file descriptors, -1 standing for nil.
+// the go side works on a duplicate of the descriptors it is given, and
+// gives away a duplicate of the descriptors of its files.
+
+// cgopy_check_file returns whether o may be converted to a descriptor:
+// None, an int or an object with a fileno method.
+static int
+cgopy_check_file(PyObject *o) {
+	return o == Py_None || PyInt_Check(o) || PyLong_Check(o) ||
+	       PyObject_HasAttrString(o, "fileno");
+}
+
+static int
+cgopy_cnv_py2c_file(PyObject *o, int64_t *addr) {
+	int fd = -1;
+	if (o == Py_None) {
+		*addr = -1;
+		return 1;
+	}
+	if (!PyInt_Check(o) && !PyLong_Check(o)) {
+		// flush python buffers before go writes to the same file.
+		PyObject *r = NULL;
+		if (PyObject_HasAttrString(o, "flush")) {
+			r = PyObject_CallMethod(o, "flush", NULL);
+			if (r == NULL) {
+				return 0;
+			}
+			Py_DECREF(r);
 		}
-		g.genFunc(fset)
 	}
+	fd = PyObject_AsFileDescriptor(o);
+	if (fd < 0) {
+		return 0;
+	}
+#ifdef _WIN32
+	PyErr_SetString(PyExc_NotImplementedError, "gopy: files are not supported on windows");
+	return 0;
+#else
+	if (fcntl(fd, F_GETFD) < 0) {
+		PyErr_SetFromErrno(PyExc_OSError);
+		return 0;
+	}
+#endif
+	*addr = fd;
+	return 1;
 }
 
-func (g *cpyGen) genPreamble() {
-	n := g.pkg.pkg.Name()
-	g.decl.Printf(cPreamble, g.pkg.ImportPath(), g.pkg.pkg.Path(), filepath.Base(n))
+static PyObject*
+cgopy_cnv_c2py_file(int64_t *addr) {
+	if (*addr < 0) {
+		Py_INCREF(Py_None);
+		return Py_None;
+	}
+	return PyInt_FromLong((long)*addr);
+}
+
+// *big.Int and *big.Float values are exchanged as their decimal text, an
+// empty text standing for nil. floats are converted to python floats when
+// they are exactly float64 values, and to decimal.Decimal otherwise.
+
+// cgopy_decimal_type returns a borrowed reference to decimal.Decimal, or
+// NULL with a python exception set.
+static PyObject*
+cgopy_decimal_type(void) {
+	static PyObject *type = NULL;
+	PyObject *mod = NULL;
+	if (type != NULL) {
+		return type;
+	}
+	mod = PyImport_ImportModule("decimal");
+	if (mod == NULL) {
+		return NULL;
+	}
+	type = PyObject_GetAttrString(mod, "Decimal");
+	Py_DECREF(mod);
+	return type;
+}
+
+// cgopy_is_decimal returns whether o is a decimal.Decimal.
+static int
+cgopy_is_decimal(PyObject *o) {
+	PyObject *type = cgopy_decimal_type();
+	int ok = 0;
+	if (type == NULL) {
+		PyErr_Clear();
+		return 0;
+	}
+	ok = PyObject_IsInstance(o, type);
+	if (ok < 0) {
+		PyErr_Clear();
+		return 0;
+	}
+	return ok;
+}
+
+// cgopy_check_bigint returns whether o may be converted to a *big.Int:
+// None or an int.
+static int
+cgopy_check_bigint(PyObject *o) {
+	return o == Py_None || PyInt_Check(o) || PyLong_Check(o);
+}
+
+// cgopy_check_bigfloat returns whether o may be converted to a *big.Float:
+// None, an int, a float or a decimal.Decimal.
+static int
+cgopy_check_bigfloat(PyObject *o) {
+	return cgopy_check_bigint(o) || PyFloat_Check(o) || cgopy_is_decimal(o);
+}
+
+static int
+cgopy_cnv_py2c_bigint(PyObject *o, PyObject **addr) {
+	if (!cgopy_check_bigint(o)) {
+		PyErr_Format(PyExc_TypeError, "invalid type (got=%%s, expected an int)",
+			Py_TYPE(o)->tp_name);
+		return 0;
+	}
+	*addr = o;
+	return 1;
+}
+
+static int
+cgopy_cnv_py2c_bigfloat(PyObject *o, PyObject **addr) {
+	int nan = 0;
+	if (!cgopy_check_bigfloat(o)) {
+		PyErr_Format(PyExc_TypeError, "invalid type (got=%%s, expected a float or a decimal.Decimal)",
+			Py_TYPE(o)->tp_name);
+		return 0;
+	}
+	if (PyFloat_Check(o)) {
+		nan = Py_IS_NAN(PyFloat_AS_DOUBLE(o));
+	} else if (cgopy_is_decimal(o)) {
+		PyObject *r = PyObject_CallMethod(o, "is_nan", NULL);
+		if (r == NULL) {
+			return 0;
+		}
+		nan = PyObject_IsTrue(r);
+		Py_DECREF(r);
+	}
+	if (nan) {
+		// big.Float has no NaN.
+		PyErr_SetString(PyExc_ValueError, "invalid value nan (expected a number)");
+		return 0;
+	}
+	*addr = o;
+	return 1;
+}
+
+static PyObject*
+cgopy_cnv_c2py_big(PyObject **addr) {
+	return *addr;
+}
+
+// cgopy_seq_buffer_write_big_text writes the text of o, formatted by
+// str, or an empty text for NULL or None.
+static void
+cgopy_seq_buffer_write_big_text(cgopy_seq_buffer buf, PyObject *o, reprfunc str) {
+	PyObject *txt = NULL;
+	if (o != NULL && o != Py_None) {
+		txt = str(o);
+	}
+	if (txt == NULL) {
+		cgopy_seq_bytearray arr;
+		arr.Data = NULL;
+		arr.Len = 0;
+		cgopy_seq_buffer_write_bytearray(buf, arr);
+		return;
+	}
+	cgopy_seq_buffer_write_value_string(buf, txt);
+	Py_DECREF(txt);
+}
+
+static void
+cgopy_seq_buffer_write_bigint(cgopy_seq_buffer buf, PyObject *o) {
+	cgopy_seq_buffer_write_big_text(buf, o, PyObject_Str);
+}
+
+// cgopy_seq_buffer_write_bigfloat writes the precision of o, followed by its
+// text: the repr of a float is its shortest text at 53 bits, and the other
+// numbers are read back with a precision holding all their digits.
+static void
+cgopy_seq_buffer_write_bigfloat(cgopy_seq_buffer buf, PyObject *o) {
+	if (o != NULL && PyFloat_Check(o)) {
+		cgopy_seq_buffer_write_int64(buf, 53);
+		cgopy_seq_buffer_write_big_text(buf, o, PyObject_Repr);
+		return;
+	}
+	cgopy_seq_buffer_write_int64(buf, 0);
+	cgopy_seq_buffer_write_big_text(buf, o, PyObject_Str);
+}
+
+// cgopy_seq_buffer_read_bigint returns a new reference to the int read
+// from buf, None for nil, or NULL with a python exception set.
+static PyObject*
+cgopy_seq_buffer_read_bigint(cgopy_seq_buffer buf) {
+	PyObject *txt = cgopy_seq_buffer_read_value_string(buf);
+	PyObject *o = NULL;
+	if (txt == NULL) {
+		return NULL;
+	}
+	if (PyString_GET_SIZE(txt) == 0) {
+		Py_INCREF(Py_None);
+		o = Py_None;
+	} else {
+		// ints overflowing a long are returned as longs.
+		o = PyInt_FromString(PyString_AS_STRING(txt), NULL, 10);
+	}
+	Py_DECREF(txt);
+	return o;
+}
+
+// cgopy_seq_buffer_read_bigfloat returns a new reference to the float or
+// decimal.Decimal read from buf, None for nil, or NULL with a python
+// exception set.
+static PyObject*
+cgopy_seq_buffer_read_bigfloat(cgopy_seq_buffer buf) {
+	int exact = cgopy_seq_buffer_read_bool(buf);
+	PyObject *txt = cgopy_seq_buffer_read_value_string(buf);
+	PyObject *o = NULL;
+	if (txt == NULL) {
+		return NULL;
+	}
+	if (PyString_GET_SIZE(txt) == 0) {
+		Py_INCREF(Py_None);
+		o = Py_None;
+	} else if (exact) {
+		o = PyFloat_FromString(txt, NULL);
+	} else {
+		PyObject *type = cgopy_decimal_type();
+		if (type != NULL) {
+			o = PyObject_CallFunctionObjArgs(type, txt, NULL);
+		}
+	}
+	Py_DECREF(txt);
+	return o;
+}
+
+// net.IP and *url.URL values are exchanged as whether they are not nil,
+// followed by their text. python holds them as str, None standing for nil.
+
+// cgopy_check_net returns whether o may be converted to a net.IP or a
+// *url.URL: None or a str.
+static int
+cgopy_check_net(PyObject *o) {
+	return o == Py_None || PyString_Check(o) || PyUnicode_Check(o);
+}
+
+static PyObject*
+cgopy_cnv_c2py_net(PyObject **addr) {
+	return *addr;
+}
+
+static void
+cgopy_seq_buffer_write_net(cgopy_seq_buffer buf, PyObject *o) {
+	cgopy_seq_bytearray arr;
+	if (o != NULL && o != Py_None) {
+		cgopy_seq_buffer_write_bool(buf, 1);
+		cgopy_seq_buffer_write_value_string(buf, o);
+		return;
+	}
+	cgopy_seq_buffer_write_bool(buf, 0);
+	arr.Data = NULL;
+	arr.Len = 0;
+	cgopy_seq_buffer_write_bytearray(buf, arr);
+}
+
+// cgopy_seq_buffer_read_net returns a new reference to the str read from
+// buf, None for nil, or NULL with a python exception set.
+static PyObject*
+cgopy_seq_buffer_read_net(cgopy_seq_buffer buf) {
+	int ok = cgopy_seq_buffer_read_bool(buf);
+	PyObject *txt = cgopy_seq_buffer_read_value_string(buf);
+	if (txt == NULL || ok) {
+		return txt;
+	}
+	Py_DECREF(txt);
+	Py_INCREF(Py_None);
+	return Py_None;
+}
+
+// time.Duration values are exchanged as nanoseconds. they are received from
+// python as int or float seconds, or as datetime.timedelta values, and
+// returned as float seconds, or as datetime.timedelta values when bound with
+// -durations=timedelta.
+
+// cgopy_timedelta_type returns a borrowed reference to datetime.timedelta,
+// or NULL with a python exception set.
+static PyObject*
+cgopy_timedelta_type(void) {
+	static PyObject *type = NULL;
+	PyObject *mod = NULL;
+	if (type != NULL) {
+		return type;
+	}
+	mod = PyImport_ImportModule("datetime");
+	if (mod == NULL) {
+		return NULL;
+	}
+	type = PyObject_GetAttrString(mod, "timedelta");
+	Py_DECREF(mod);
+	return type;
+}
+
+// cgopy_is_timedelta returns whether o is a datetime.timedelta.
+static int
+cgopy_is_timedelta(PyObject *o) {
+	PyObject *type = cgopy_timedelta_type();
+	int ok = 0;
+	if (type == NULL) {
+		PyErr_Clear();
+		return 0;
+	}
+	ok = PyObject_IsInstance(o, type);
+	if (ok < 0) {
+		PyErr_Clear();
+		return 0;
+	}
+	return ok;
+}
+
+// cgopy_check_duration returns whether o may be converted to a
+// time.Duration: an int, a float or a datetime.timedelta.
+static int
+cgopy_check_duration(PyObject *o) {
+	return PyInt_Check(o) || PyLong_Check(o) || PyFloat_Check(o) || cgopy_is_timedelta(o);
+}
+
+// cgopy_timedelta_part returns the int attribute name of the timedelta o,
+// or -1 with a python exception set.
+static long
+cgopy_timedelta_part(PyObject *o, const char *name) {
+	PyObject *v = PyObject_GetAttrString(o, name);
+	long n = -1;
+	if (v == NULL) {
+		return -1;
+	}
+	n = PyInt_AsLong(v);
+	Py_DECREF(v);
+	return n;
+}
+
+static int
+cgopy_cnv_py2c_duration(PyObject *o, int64_t *addr) {
+	// the largest durations, in seconds.
+	const double max = 9223372036.854775807;
+	if (PyFloat_Check(o)) {
+		double ns = floor(PyFloat_AS_DOUBLE(o) * 1e9 + 0.5);
+		if (Py_IS_NAN(ns)) {
+			PyErr_SetString(PyExc_ValueError, "invalid value nan (expected a duration)");
+			return 0;
+		}
+		if (ns >= max * 1e9 || ns < -max * 1e9) {
+			PyErr_SetString(PyExc_OverflowError, "duration out of range");
+			return 0;
+		}
+		*addr = (int64_t)ns;
+		return 1;
+	}
+	if (PyInt_Check(o) || PyLong_Check(o)) {
+		PY_LONG_LONG s = PyLong_AsLongLong(o);
+		if (s == -1 && PyErr_Occurred()) {
+			return 0;
+		}
+		if (s > (PY_LONG_LONG)max || s < -(PY_LONG_LONG)max) {
+			PyErr_SetString(PyExc_OverflowError, "duration out of range");
+			return 0;
+		}
+		*addr = (int64_t)s * 1000000000;
+		return 1;
+	}
+	if (cgopy_is_timedelta(o)) {
+		long days = 0, secs = 0, us = 0;
+		days = cgopy_timedelta_part(o, "days");
+		secs = cgopy_timedelta_part(o, "seconds");
+		us = cgopy_timedelta_part(o, "microseconds");
+		if (PyErr_Occurred()) {
+			return 0;
+		}
+		if (days * 86400.0 + secs + us / 1e6 >= max || days * 86400.0 + secs + us / 1e6 < -max) {
+			PyErr_SetString(PyExc_OverflowError, "duration out of range");
+			return 0;
+		}
+		// timedeltas hold whole microseconds: their nanoseconds are exact.
+		*addr = (((int64_t)days * 86400 + secs) * 1000000 + us) * 1000;
+		return 1;
+	}
+	PyErr_Format(PyExc_TypeError, "invalid type (got=%%s, expected a float or a datetime.timedelta)",
+		Py_TYPE(o)->tp_name);
+	return 0;
+}
+
+// cgopy_duration_seconds returns a new reference to the float seconds of
+// ns nanoseconds.
+static PyObject*
+cgopy_duration_seconds(int64_t ns) {
+	return PyFloat_FromDouble(ns / 1e9);
+}
+
+// cgopy_duration_timedelta returns a new reference to the datetime.timedelta
+// of ns nanoseconds, truncated to microseconds, or NULL with a python
+// exception set.
+static PyObject*
+cgopy_duration_timedelta(int64_t ns) {
+	PyObject *type = cgopy_timedelta_type();
+	int64_t us = ns / 1000;
+	if (type == NULL) {
+		return NULL;
+	}
+	// timedelta normalizes negative seconds and microseconds.
+	return PyObject_CallFunction(type, "iLL", 0, (PY_LONG_LONG)(us / 1000000), (PY_LONG_LONG)(us %% 1000000));
+}
+
+// cgopy_base returns a new reference to the python class named by name,
+// as module.Class, a base class of go types annotated with //gopy:base, or
+// NULL with a python exception set.
+static PyObject*
+cgopy_base(const char *name) {
+	const char *dot = strrchr(name, '.');
+	PyObject *path = NULL;
+	PyObject *mod = NULL;
+	PyObject *base = NULL;
+	path = PyString_FromStringAndSize(name, dot - name);
+	if (path == NULL) {
+		return NULL;
+	}
+	mod = PyImport_Import(path);
+	Py_DECREF(path);
+	if (mod == NULL) {
+		return NULL;
+	}
+	base = PyObject_GetAttrString(mod, dot + 1);
+	Py_DECREF(mod);
+	if (base != NULL && !PyType_Check(base)) {
+		PyErr_Format(PyExc_TypeError, "%%s is not a new-style class", name);
+		Py_DECREF(base);
+		return NULL;
+	}
+	return base;
+}
+
+// cgopy_const_new returns the value v of a const of a named type, as a
+// value of the python type typ wrapping that named type.
+// it steals the reference to v.
+static PyObject*
+cgopy_const_new(PyTypeObject *typ, PyObject *v) {
+	PyObject *o = NULL;
+	if (v == NULL || PyObject_TypeCheck(v, typ)) {
+		return v;
+	}
+	o = PyObject_CallFunctionObjArgs((PyObject*)typ, v, NULL);
+	Py_DECREF(v);
+	return o;
+}
+
+// cgopy_enum_member_new is the __new__ of the enum.Enum subclasses of the
+// wrapper types typ of string enums, making the member of value: it holds
+// the go value of value, and its _value_ is the python str.
+static PyObject*
+cgopy_enum_member_new(PyObject *typ, PyObject *args) {
+	PyObject *cls = NULL, *value = NULL, *vargs = NULL, *o = NULL;
+	if (!PyArg_ParseTuple(args, "OO", &cls, &value)) {
+		return NULL;
+	}
+	vargs = PyTuple_Pack(1, value);
+	if (vargs == NULL) {
+		return NULL;
+	}
+	o = ((PyTypeObject*)typ)->tp_new((PyTypeObject*)cls, vargs, NULL);
+	if (o != NULL && ((PyTypeObject*)typ)->tp_init(o, vargs, NULL) < 0) {
+		Py_CLEAR(o);
+	}
+	if (o != NULL && PyObject_SetAttrString(o, "_value_", value) < 0) {
+		Py_CLEAR(o);
+	}
+	Py_DECREF(vargs);
+	return o;
+}
+
+static PyMethodDef cgopy_enum_member_new_def = {
+	"__new__", (PyCFunction)cgopy_enum_member_new, METH_VARARGS, NULL
+};
+
+// cgopy_enum_add adds the enum.Enum subclass cls, named name, and its members
+// named by names, a NULL-terminated array, to the module, replacing the type
+// and the consts. It returns -1 with an exception set on failure.
+static int
+cgopy_enum_add(PyObject *module, PyObject *cls, const char *name, const char **names) {
+	int i = 0;
+	for (i = 0; names[i] != NULL; i++) {
+		PyObject *attr = PyObject_GetAttrString(cls, names[i]);
+		if (attr == NULL || PyModule_AddObject(module, names[i], attr) < 0) {
+			Py_XDECREF(attr);
+			return -1;
+		}
+	}
+	Py_INCREF(cls);
+	if (PyModule_AddObject(module, name, cls) < 0) {
+		Py_DECREF(cls);
+		return -1;
+	}
+	return 0;
+}
+
+// cgopy_enum_new returns a new reference to the enum.Enum subclass of the
+// wrapper type typ of a string enum, named name, whose members are the
+// consts of the module named by names, a NULL-terminated array. The class
+// and its members replace the type and the consts in the module.
+// It returns NULL, with no python exception set, if the enum module can not
+// be imported, as with python-2 without the enum34 backport.
+static PyObject*
+cgopy_enum_new(PyObject *module, PyTypeObject *typ, const char *name, const char **names) {
+	PyObject *mod = NULL, *base = NULL, *meta = NULL, *bases = NULL;
+	PyObject *dict = NULL, *attr = NULL, *cls = NULL;
+	int i = 0;
+	mod = PyImport_ImportModule("enum");
+	if (mod == NULL) {
+		PyErr_Clear();
+		return NULL;
+	}
+	base = PyObject_GetAttrString(mod, "Enum");
+	Py_DECREF(mod);
+	if (base == NULL) {
+		return NULL;
+	}
+	meta = (PyObject*)Py_TYPE(base);
+	bases = PyTuple_Pack(2, (PyObject*)typ, base);
+	if (bases == NULL) {
+		goto done;
+	}
+	dict = PyObject_CallMethod(meta, "__prepare__", "sO", name, bases);
+	if (dict == NULL) {
+		goto done;
+	}
+	attr = PyCFunction_New(&cgopy_enum_member_new_def, (PyObject*)typ);
+	if (attr == NULL || PyMapping_SetItemString(dict, "__new__", attr) < 0) {
+		goto done;
+	}
+	Py_CLEAR(attr);
+	attr = PyString_FromString(PyModule_GetName(module));
+	if (attr == NULL || PyMapping_SetItemString(dict, "__module__", attr) < 0) {
+		goto done;
+	}
+	Py_CLEAR(attr);
+	if (typ->tp_doc != NULL) {
+		attr = PyString_FromString(typ->tp_doc);
+		if (attr == NULL || PyMapping_SetItemString(dict, "__doc__", attr) < 0) {
+			goto done;
+		}
+		Py_CLEAR(attr);
+	}
+	for (i = 0; names[i] != NULL; i++) {
+		PyObject *member = PyObject_GetAttrString(module, names[i]);
+		if (member == NULL) {
+			goto done;
+		}
+		attr = PyObject_GetAttrString(member, "value");
+		Py_DECREF(member);
+		if (attr == NULL || PyMapping_SetItemString(dict, (char*)names[i], attr) < 0) {
+			goto done;
+		}
+		Py_CLEAR(attr);
+	}
+	cls = PyObject_CallFunction(meta, "sOO", name, bases, dict);
+	if (cls == NULL) {
+		goto done;
+	}
+	if (cgopy_enum_add(module, cls, name, names) < 0) {
+		Py_CLEAR(cls);
+	}
+
+done:
+	Py_XDECREF(attr);
+	Py_XDECREF(dict);
+	Py_XDECREF(bases);
+	Py_DECREF(base);
+	return cls;
+}
+
+// cgopy_enum_member returns the member of the enum.Enum subclass cls holding
+// the value of o, a value of its wrapper type, or o itself if cls is NULL or
+// no member holds that value: values returned by go are not checked.
+// it steals the reference to o.
+static PyObject*
+cgopy_enum_member(PyObject *cls, PyObject *o) {
+	PyObject *value = NULL, *member = NULL;
+	if (cls == NULL || o == NULL) {
+		return o;
+	}
+	value = PyObject_GetAttrString(o, "value");
+	if (value != NULL) {
+		member = PyObject_CallFunctionObjArgs(cls, value, NULL);
+		Py_DECREF(value);
+	}
+	if (member == NULL) {
+		if (!PyErr_ExceptionMatches(PyExc_ValueError)) {
+			Py_DECREF(o);
+			return NULL;
+		}
+		PyErr_Clear();
+		return o;
+	}
+	Py_DECREF(o);
+	return member;
+}
+`
+)
+
+type cpyGen struct {
+	decl *printer
+	impl *printer
+
+	fset *token.FileSet
+	pkg  *Package
+	err  ErrorList
+
+	// lang is the c-python api version (2,3), or 0 for the sources
+	// compiling against both: they are written against the python-2 api,
+	// mapped onto python-3 by the preamble, with the code of each version
+	// where they differ (see genVersions).
+	lang int
+
+	naming Naming    // naming convention for python-visible names
+	async  bool      // whether //gopy:async funcs and methods get an _async variant
+	info   BuildInfo // gopy invocation generating the package
+
+	durations Durations // convention for the time.Duration values returned to python
+}
+
+// genVersions generates the code specific to the c-python api versions
+// targeted by the bindings, calling gen with each version. The sources
+// compiling against python-2 and python-3 hold the code of both versions,
+// selected by the preprocessor in each of the printers.
+func (g *cpyGen) genVersions(gen func(lang int), printers ...*printer) {
+	if g.lang != 0 {
+		gen(g.lang)
+		return
+	}
+	for _, p := range printers {
+		p.Printf("#if PY_MAJOR_VERSION >= 3\n")
+	}
+	gen(3)
+	for _, p := range printers {
+		p.Printf("#else\n")
+	}
+	gen(2)
+	for _, p := range printers {
+		p.Printf("#endif\n")
+	}
+}
+
+// pyname returns the python name of the go entity named name.
+func (g *cpyGen) pyname(name string) string {
+	return g.naming.pyname(name)
+}
+
+// pydoc returns the docstring doc of the go entity named name, noting its
+// renaming if its name clashes with python's.
+func (g *cpyGen) pydoc(name, doc string) string {
+	return g.naming.pydoc(name, doc)
+}
+
+func (g *cpyGen) gen() error {
+
+	g.genPreamble()
+
+	// declare all the types and their converters up front, so the code
+	// of a type can use any other type, whatever the generation order.
+	g.genTypeRegistry()
+
+	for _, conv := range g.pkg.convs {
+		g.genConverter(conv)
+	}
+	for _, kind := range g.pkg.syms.netTypes() {
+		g.genNetType(kind)
+	}
+
+	hasBuffers := g.genBufferPin()
+
+	// first, process types
+	for _, t := range g.pkg.types {
+		sym := t.sym
+		if !sym.isType() {
+			continue
+		}
+		g.genType(t)
+	}
+
+	// the factories rebuilding values from their state.
+	for _, t := range g.pkg.types {
+		if t.hasState() {
+			g.genTypeFactory(t)
+		}
+	}
+
+	// expose ctors at module level
+	for _, t := range g.pkg.types {
+		for _, ctor := range t.ctors {
+			g.genFunc(ctor)
+		}
+	}
+
+	for _, f := range g.pkg.funcs {
+		g.genFunc(f)
+	}
+
+	for _, o := range g.pkg.overloads {
+		g.genOverload(o)
+	}
+
+	for _, c := range g.pkg.consts {
+		g.genConst(c)
+	}
+
+	for _, v := range g.pkg.vars {
+		g.genVar(v)
+	}
+
+	hasSelect := g.genSelect()
+	g.genErrors()
+	hasAsync := g.genAsync()
+	hasCallbacks := g.genCallbacks()
+	hasVarAttrs := g.genModuleType()
+	g.genHandleCount()
+
+	g.impl.Printf("\n/* functions for package %s */\n", g.pkg.pkg.Name())
+	g.impl.Printf("static PyMethodDef cpy_%s_methods[] = {\n", g.pkg.pkg.Name())
+	g.impl.Indent()
+	for _, f := range g.pkg.funcs {
+		if g.pkg.isOverloaded(f.GoName()) {
+			continue
+		}
+		name := g.pyname(f.GoName())
+		//obj := scope.Lookup(name)
+		g.impl.Printf("{%[1]q, (PyCFunction)%[2]s, %[3]s, %[4]q},\n",
+			name, "cpy_func_"+f.ID(), methFlags(f), g.pydoc(f.GoName(), f.Doc()),
+		)
+	}
+	// expose ctors at module level
+	// FIXME(sbinet): attach them to types/structs?
+	// -> problem is if one has 2 or more ctors with exactly the same signature.
+	for _, t := range g.pkg.types {
+		for _, f := range t.ctors {
+			if g.pkg.isOverloaded(f.GoName()) {
+				continue
+			}
+			name := g.pyname(f.GoName())
+			//obj := scope.Lookup(name)
+			g.impl.Printf("{%[1]q, (PyCFunction)%[2]s, %[3]s, %[4]q},\n",
+				name, "cpy_func_"+f.ID(), methFlags(f), g.pydoc(f.GoName(), f.Doc()),
+			)
+		}
+	}
+
+	for _, o := range g.pkg.overloads {
+		g.impl.Printf("{%[1]q, %[2]s, METH_VARARGS, %[3]q},\n",
+			g.pyname(o.name), "cpy_overload_"+g.pkg.Name()+"_"+o.name, g.pydoc(o.name, overloadDoc(o)),
+		)
+	}
+
+	// the names of the factories start with an underscore, as the ones of
+	// go objects can not: they are stable and never collide with them.
+	for _, t := range g.pkg.types {
+		if !t.hasState() {
+			continue
+		}
+		g.impl.Printf("{%[1]q, %[2]s, METH_O, %[3]q},\n",
+			"_new_"+t.sym.goname, "cpy_func_"+t.sym.id+"__new", factoryDoc(t),
+		)
+	}
+
+	// the GetX and SetX funcs clashing with other funcs or types of the
+	// package are left out.
+	for _, c := range g.pkg.consts {
+		name := c.GoName()
+		if g.pkg.shadowed["Get"+name] {
+			continue
+		}
+		g.impl.Printf("{%[1]q, %[2]s, METH_VARARGS, %[3]q},\n",
+			g.pyname("Get"+name), "cpy_func_"+c.id+"_get", c.Doc(),
+		)
+	}
+
+	for _, v := range g.pkg.vars {
+		name := v.Name()
+		if !g.pkg.shadowed["Get"+name] {
+			g.impl.Printf("{%[1]q, %[2]s, METH_VARARGS, %[3]q},\n",
+				g.pyname("Get"+name), "cpy_func_"+v.id+"_get", v.doc,
+			)
+		}
+		if !g.pkg.shadowed["Set"+name] {
+			g.impl.Printf("{%[1]q, %[2]s, METH_VARARGS, %[3]q},\n",
+				g.pyname("Set"+name), "cpy_func_"+v.id+"_set", v.doc,
+			)
+		}
+	}
+
+	if hasSelect {
+		g.impl.Printf("{%[1]q, (PyCFunction)%[2]s, METH_VARARGS | METH_KEYWORDS, %[3]q},\n",
+			"select", "cpy_func_"+g.pkg.Name()+"_select", selectDoc,
+		)
+	}
+
+	g.impl.Printf("{%[1]q, %[2]s, METH_VARARGS, %[3]q},\n",
+		"errors_is", "cpy_func_"+g.pkg.errorsIs.ID(), errorsIsDoc,
+	)
+	g.impl.Printf("{%[1]q, %[2]s, METH_VARARGS, %[3]q},\n",
+		"errors_as", "cpy_func_"+g.pkg.Name()+"_errors_as", errorsAsDoc,
+	)
+
+	g.impl.Printf("{%[1]q, %[2]s, METH_NOARGS, %[3]q},\n",
+		"_gopy_handle_count", "cpy_func_"+g.pkg.Name()+"__gopy_handle_count", handleCountDoc,
+	)
+
+	g.impl.Printf("{NULL, NULL, 0, NULL}        /* Sentinel */\n")
+	g.impl.Outdent()
+	g.impl.Printf("};\n\n")
+
+	// the module is set up by cpy_<pkg>_module_exec, returning -1 with an
+	// exception set on failure, called by the init function of the python
+	// version once it made the module.
+	// python-2 runs the init function of an extension module only once per
+	// process: sub-interpreters get a copy of the module dict, sharing the
+	// static type objects and the handles to go values.
+	// python-3 uses the multi-phase init (PEP 489): the module is executed
+	// anew each time it is imported, by a sub-interpreter or once removed
+	// from sys.modules, while the types and the go values are shared by the
+	// process. The types are prepared once, by the first execution. The
+	// modules declare that they do not support being imported by the
+	// isolated interpreters of python-3.12, which must not share objects.
+	g.genVersions(func(lang int) {
+		switch lang {
+		case 2:
+			g.impl.Printf("static int\ncpy_%[1]s_module_exec(PyObject *module);\n\n", g.pkg.pkg.Name())
+			g.impl.Printf("PyMODINIT_FUNC\ninit%[1]s(void)\n{\n", g.pkg.pkg.Name())
+			g.impl.Printf("\tPyObject *module = Py_InitModule3(%[1]q, cpy_%[1]s_methods, %[2]q);\n",
+				g.pkg.pkg.Name(),
+				g.pkg.doc.Doc,
+			)
+			g.impl.Printf("\tif (module == NULL) { return; }\n")
+			g.impl.Printf("\tcpy_%[1]s_module_exec(module);\n", g.pkg.pkg.Name())
+			g.impl.Printf("}\n\n")
+		case 3:
+			g.impl.Printf("static int\ncpy_%[1]s_module_exec(PyObject *module);\n\n", g.pkg.pkg.Name())
+			g.impl.Printf("static PyModuleDef_Slot cpy_%[1]s_slots[] = {\n", g.pkg.pkg.Name())
+			g.impl.Indent()
+			g.impl.Printf("{Py_mod_exec, (void*)cpy_%[1]s_module_exec},\n", g.pkg.pkg.Name())
+			g.impl.Printf("#if PY_VERSION_HEX >= 0x030C0000\n")
+			g.impl.Printf("{Py_mod_multiple_interpreters, Py_MOD_MULTIPLE_INTERPRETERS_NOT_SUPPORTED},\n")
+			g.impl.Printf("#endif\n")
+			g.impl.Printf("{0, NULL}\n")
+			g.impl.Outdent()
+			g.impl.Printf("};\n\n")
+			g.impl.Printf("static struct PyModuleDef cpy_%[1]s_module = {\n", g.pkg.pkg.Name())
+			g.impl.Indent()
+			g.impl.Printf("PyModuleDef_HEAD_INIT,\n")
+			g.impl.Printf("%q,\t/* m_name */\n", g.pkg.pkg.Name())
+			g.impl.Printf("%q,\t/* m_doc */\n", g.pkg.doc.Doc)
+			g.impl.Printf("0,\t/* m_size */\n")
+			g.impl.Printf("cpy_%[1]s_methods,\t/* m_methods */\n", g.pkg.pkg.Name())
+			g.impl.Printf("cpy_%[1]s_slots,\t/* m_slots */\n", g.pkg.pkg.Name())
+			g.impl.Outdent()
+			g.impl.Printf("};\n\n")
+			g.impl.Printf("PyMODINIT_FUNC\nPyInit_%[1]s(void)\n{\n", g.pkg.pkg.Name())
+			g.impl.Printf("\treturn PyModuleDef_Init(&cpy_%[1]s_module);\n", g.pkg.pkg.Name())
+			g.impl.Printf("}\n\n")
+		}
+	}, g.impl)
+
+	g.impl.Printf("static int\ncpy_%[1]s_module_exec(PyObject *module)\n{\n", g.pkg.pkg.Name())
+	g.impl.Indent()
+	g.impl.Printf("static int ready = 0;\n\n")
+
+	g.impl.Printf("/* make sure Cgo is loaded and initialized */\n")
+	g.impl.Printf("cgo_pkg_%[1]s_init();\n\n", g.pkg.pkg.Name())
+
+	g.impl.Printf("if (!ready) {\n")
+	g.impl.Indent()
+	if hasCallbacks {
+		// go funcs made from python callables may call them back from
+		// other threads.
+		g.impl.Printf("PyEval_InitThreads();\n\n")
+	}
+
+	for _, t := range g.pkg.types {
+		sym := t.sym
+		if !sym.isType() {
+			continue
+		}
+		if len(t.bases) > 0 {
+			// the base classes are imported by the module.
+			bases := make([]string, len(t.bases))
+			for i, base := range t.bases {
+				bases[i] = fmt.Sprintf("cgopy_base(%q)", base)
+			}
+			g.impl.Printf("%sType.tp_bases = Py_BuildValue(\"(%s)\", %s);\n",
+				sym.cpyname,
+				strings.Repeat("N", len(bases)),
+				strings.Join(bases, ", "),
+			)
+			g.impl.Printf("if (%sType.tp_bases == NULL) { return -1; }\n", sym.cpyname)
+		}
+		g.impl.Printf(
+			"if (PyType_Ready(&%sType) < 0) { return -1; }\n",
+			sym.cpyname,
+		)
+		if t.isIterator() {
+			g.impl.Printf(
+				"if (PyType_Ready(&%s_iterType) < 0) { return -1; }\n",
+				sym.cpyname,
+			)
+		}
+	}
+	if hasBuffers {
+		g.impl.Printf("if (PyType_Ready(&cpy_%s_PinType) < 0) { return -1; }\n", g.pkg.pkg.Name())
+	}
+	if hasVarAttrs {
+		g.impl.Printf("cpy_%s_ModuleType.tp_base = &PyModule_Type;\n", g.pkg.pkg.Name())
+		g.impl.Printf("if (PyType_Ready(&cpy_%s_ModuleType) < 0) { return -1; }\n", g.pkg.pkg.Name())
+	}
+	g.impl.Printf("ready = 1;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+
+	if hasVarAttrs {
+		// the module type has the same layout as its base: only the
+		// lookup of the attributes changes.
+		g.impl.Printf("Py_SET_TYPE(module, &cpy_%s_ModuleType);\n\n", g.pkg.pkg.Name())
+	}
+
+	for _, t := range g.pkg.types {
+		sym := t.sym
+		if !sym.isType() || (t.isExternal() && !g.isExposedExternal(t)) {
+			// external types are only reachable through values.
+			continue
+		}
+		name := sym.goname
+		if t.isExternal() {
+			name = t.obj.Name()
+		}
+		g.impl.Printf("Py_INCREF(&%sType);\n", sym.cpyname)
+		g.impl.Printf("PyModule_AddObject(module, %q, (PyObject*)&%sType);\n\n",
+			name,
+			sym.cpyname,
+		)
+	}
+
+	// type aliases are other names of the python type of their target.
+	for _, a := range g.pkg.aliases {
+		g.impl.Printf("Py_INCREF(&%sType);\n", a.typ.sym.cpyname)
+		g.impl.Printf("PyModule_AddObject(module, %q, (PyObject*)&%sType);\n\n",
+			a.obj.Name(),
+			a.typ.sym.cpyname,
+		)
+	}
+
+	if hasAsync {
+		g.impl.Printf("if (cgopy_async_init(module) < 0) { return -1; }\n\n")
+	}
+
+	// consts are exposed as module attributes too, holding their value.
+	// consts of wrapped named types hold a value of the python type, which
+	// knows the name of the const.
+	for _, c := range g.pkg.consts {
+		get := fmt.Sprintf("cpy_func_%s(module, NULL)", c.f.ID())
+		if named, ok := c.GoType().(*types.Named); ok && g.pkg.wrapped[named.Obj()] {
+			get = fmt.Sprintf("cgopy_const_new(&%sType, %s)", c.sym.cpyname, get)
+		}
+		g.impl.Printf("PyModule_AddObject(module, %q, %s);\n", g.pyname(c.GoName()), get)
+	}
+	g.genEnumMembers()
+	g.genAll()
+
+	// describe the bindings, for bug reports.
+	// the build time is the one of the compilation of the extension.
+	g.impl.Printf("\n")
+	g.impl.Printf("PyModule_AddStringConstant(module, \"__gopy_version__\", %q);\n", g.info.Version)
+	g.impl.Printf("PyModule_AddStringConstant(module, \"__gopy_cmd__\", %q);\n", g.cmd())
+	g.impl.Printf("PyModule_AddStringConstant(module, \"__gopy_package__\", %q);\n", g.pkg.ImportPath())
+	g.impl.Printf("PyModule_AddStringConstant(module, \"__gopy_build_time__\", __DATE__ \" \" __TIME__);\n")
+	g.impl.Printf("return 0;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+
+	if len(g.err) > 0 {
+		return g.err
+	}
+
+	return nil
+}
+
+// genAll generates the code setting the __all__ attribute of the module,
+// listing the names bound to exported go objects of the package, for
+// `from pkg import *`: its types, type aliases, funcs, consts and vars.
+// The names generated by gopy, such as SliceInt or the Get and Set funcs of
+// consts and vars, are left out, as is Buffer when the package does not
+// use bytes.Buffer.
+// Packages bound for the side-effects of their init funcs only have no
+// names to export, and no __all__.
+func (g *cpyGen) genAll() {
+	var names []string
+	scope := g.pkg.pkg.Scope()
+	for _, t := range g.pkg.types {
+		switch {
+		case !t.sym.isType():
+		case g.isExposedExternal(t):
+			if !t.isBytesBuffer() || g.pkg.usesBuffer {
+				names = append(names, t.obj.Name())
+			}
+		case !t.isExternal() && scope.Lookup(t.obj.Name()) == t.obj:
+			names = append(names, t.sym.goname)
+		}
+	}
+	for _, a := range g.pkg.aliases {
+		names = append(names, a.obj.Name())
+	}
+	for _, f := range g.pkg.funcs {
+		if !g.pkg.isOverloaded(f.GoName()) {
+			names = append(names, g.pyname(f.GoName()))
+		}
+	}
+	for _, t := range g.pkg.types {
+		for _, f := range t.ctors {
+			if !g.pkg.isOverloaded(f.GoName()) {
+				names = append(names, g.pyname(f.GoName()))
+			}
+		}
+	}
+	for _, o := range g.pkg.overloads {
+		names = append(names, g.pyname(o.name))
+	}
+	for _, c := range g.pkg.consts {
+		names = append(names, g.pyname(c.GoName()))
+	}
+	for _, v := range g.pkg.vars {
+		names = append(names, g.pyname(v.Name()))
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+
+	format := strings.Repeat("s", len(names))
+	g.impl.Printf("\n/* names exported by from %s import * */\n", g.pkg.pkg.Name())
+	g.impl.Printf("PyModule_AddObject(module, \"__all__\", Py_BuildValue(%q", "["+format+"]")
+	for _, name := range names {
+		g.impl.Printf(", %q", name)
+	}
+	g.impl.Printf("));\n")
+}
+
+// genEnumMembers generates the code making the string enums enum.Enum
+// subclasses of their type, whose members are their consts, once they are
+// module attributes, when the enum module can be imported.
+// Otherwise, the consts are added to the dict of their type, and listed in
+// its __members__ dict, mapping the names of the consts to their values.
+// consts named like a method or an attribute of the type are only
+// members.
+func (g *cpyGen) genEnumMembers() {
+	for _, t := range g.pkg.types {
+		if !t.sym.isType() || t.isExternal() || !t.isStrEnum() {
+			continue
+		}
+		names := make([]string, 0, len(t.consts)+1)
+		for _, c := range t.consts {
+			names = append(names, fmt.Sprintf("%q", g.pyname(c.GoName())))
+		}
+		names = append(names, "NULL")
+		g.impl.Printf("\n/* members of the %s enum */\n", t.sym.goname)
+		g.impl.Printf("{\n")
+		g.impl.Indent()
+		g.impl.Printf("static const char *names[] = {%s};\n", strings.Join(names, ", "))
+		// the enum made by an earlier execution of the module is shared
+		// by the later ones.
+		g.impl.Printf("if (cpy_func_%[1]s_enum != NULL) {\n", t.sym.id)
+		g.impl.Printf("\tif (cgopy_enum_add(module, cpy_func_%[1]s_enum, %[2]q, names) < 0) { return -1; }\n",
+			t.sym.id,
+			t.sym.goname,
+		)
+		g.impl.Printf("} else {\n")
+		g.impl.Indent()
+		g.impl.Printf("cpy_func_%[1]s_enum = cgopy_enum_new(module, &%[2]sType, %[3]q, names);\n",
+			t.sym.id,
+			t.sym.cpyname,
+			t.sym.goname,
+		)
+		g.impl.Printf("if (cpy_func_%s_enum == NULL && PyErr_Occurred()) { return -1; }\n", t.sym.id)
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+		g.impl.Printf("if (cpy_func_%s_enum == NULL) {\n", t.sym.id)
+		g.impl.Indent()
+		g.impl.Printf("PyObject *dict = %sType.tp_dict;\n", t.sym.cpyname)
+		g.impl.Printf("PyObject *members = PyDict_New();\n")
+		g.impl.Printf("PyObject *member = NULL;\n")
+		g.impl.Printf("if (members == NULL) { return -1; }\n")
+		for _, c := range t.consts {
+			name := g.pyname(c.GoName())
+			g.impl.Printf("member = PyDict_GetItemString(PyModule_GetDict(module), %q);\n", name)
+			g.impl.Printf("if (member == NULL || PyDict_SetItemString(members, %q, member) < 0) {\n", name)
+			g.impl.Printf("\tPy_DECREF(members);\n")
+			g.impl.Printf("\treturn -1;\n")
+			g.impl.Printf("}\n")
+			g.impl.Printf("if (PyDict_GetItemString(dict, %[1]q) == NULL && PyDict_SetItemString(dict, %[1]q, member) < 0) {\n", name)
+			g.impl.Printf("\tPy_DECREF(members);\n")
+			g.impl.Printf("\treturn -1;\n")
+			g.impl.Printf("}\n")
+		}
+		g.impl.Printf("if (PyDict_SetItemString(dict, \"__members__\", members) < 0) {\n")
+		g.impl.Printf("\tPy_DECREF(members);\n")
+		g.impl.Printf("\treturn -1;\n")
+		g.impl.Printf("}\n")
+		g.impl.Printf("Py_DECREF(members);\n")
+		g.impl.Printf("PyType_Modified(&%sType);\n", t.sym.cpyname)
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+	}
+}
+
+// isExposedExternal returns whether t wraps context.Context or
+// bytes.Buffer, exposed as a module attribute so python can create contexts
+// and buffers, unless the package declares its own Context or Buffer.
+func (g *cpyGen) isExposedExternal(t Type) bool {
+	return (t.isContext() || t.isBytesBuffer()) && g.pkg.pkg.Scope().Lookup(t.obj.Name()) == nil
+}
+
+// isSingleton returns whether sym is a type whose values share one wrapper.
+func (g *cpyGen) isSingleton(sym *symbol) bool {
+	for _, t := range g.pkg.types {
+		if t.sym == sym {
+			return t.isSingleton()
+		}
+	}
+	return false
+}
+
+// isStrEnum returns whether sym is a string enum type.
+func (g *cpyGen) isStrEnum(sym *symbol) bool {
+	for _, t := range g.pkg.types {
+		if t.sym == sym {
+			return t.isStrEnum()
+		}
+	}
+	return false
+}
+
+// genConverter generates the py->c converter of a type converted by conv:
+// the python value is converted to the basic type of the converter, then
+// checked by its From func, whose error is raised as a ValueError.
+// The values sent to python are converted as values of the basic type.
+func (g *cpyGen) genConverter(conv *converter) {
+	sym := g.pkg.syms.symtype(conv.obj.Type())
+	bsym := g.pkg.syms.symtype(conv.base)
+	id := conv.id()
+	g.decl.Printf("\n/* %s, converted with %s and %s */\n", sym.gofmt(), conv.to.Name(), conv.from.Name())
+	g.decl.Printf("static int cgopy_cnv_py2c_%s(PyObject *o, %s *addr);\n", id, sym.cgoname)
+
+	g.impl.Printf("\n/* cgopy_cnv_py2c_%s converts o to a %s, checked by %s */\n", id, sym.gofmt(), conv.from.Name())
+	g.impl.Printf("static int\ncgopy_cnv_py2c_%s(PyObject *o, %s *addr) {\n", id, sym.cgoname)
+	g.impl.Indent()
+	g.impl.Printf("if (!%s(o, addr)) {\n", bsym.py2c)
+	g.impl.Indent()
+	g.impl.Printf("return 0;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Printf("cgopy_seq_buffer ibuf = cgopy_seq_buffer_new();\n")
+	g.impl.Printf("cgopy_seq_buffer obuf = cgopy_seq_buffer_new();\n")
+	g.genWrite("*addr", "ibuf", conv.base)
+	g.genSend(g.pkg.ImportPath()+"."+conv.obj.Name()+".check", id+"_check", false)
+	g.impl.Printf("cgopy_seq_bytearray err = cgopy_seq_buffer_read_string(obuf);\n")
+	g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
+	g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
+	g.impl.Printf("if (err.Len > 0) {\n")
+	g.impl.Indent()
+	g.impl.Printf("PyObject *msg = cgopy_cnv_c2py_string(&err);\n")
+	g.impl.Printf("PyErr_SetObject(PyExc_ValueError, msg);\n")
+	g.impl.Printf("Py_XDECREF(msg);\n")
+	g.impl.Printf("cgopy_seq_bytearray_free(err);\n")
+	if conv.base.Kind() == types.String {
+		g.impl.Printf("cgopy_seq_bytearray_free(*addr);\n")
+	}
+	g.impl.Printf("return 0;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Printf("cgopy_seq_bytearray_free(err);\n")
+	g.impl.Printf("return 1;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+}
+
+// genNetType generates the py->c converter of net.IP, or *url.URL, named
+// kind: the text of the python str is checked by the go side, and its error
+// raised as a ValueError.
+func (g *cpyGen) genNetType(kind string) {
+	id := "net" + strings.ToLower(kind)
+	g.decl.Printf("\n/* %s, exchanged as its text */\n", netGoType(kind))
+	g.decl.Printf("static int cgopy_cnv_py2c_%s(PyObject *o, PyObject **addr);\n", id)
+
+	g.impl.Printf("\n/* cgopy_cnv_py2c_%s converts o to a %s, checked by the go side */\n", id, netGoType(kind))
+	g.impl.Printf("static int\ncgopy_cnv_py2c_%s(PyObject *o, PyObject **addr) {\n", id)
+	g.impl.Indent()
+	g.impl.Printf("if (!cgopy_check_net(o)) {\n")
+	g.impl.Indent()
+	g.impl.Printf("PyErr_Format(PyExc_TypeError, \"invalid type (got=%%s, expected a str)\",\n")
+	g.impl.Printf("\tPy_TYPE(o)->tp_name);\n")
+	g.impl.Printf("return 0;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Printf("if (o != Py_None) {\n")
+	g.impl.Indent()
+	g.impl.Printf("cgopy_seq_buffer ibuf = cgopy_seq_buffer_new();\n")
+	g.impl.Printf("cgopy_seq_buffer obuf = cgopy_seq_buffer_new();\n")
+	g.impl.Printf("cgopy_seq_bytearray err;\n")
+	g.impl.Printf("cgopy_seq_buffer_write_value_string(ibuf, o);\n")
+	g.genSend(g.pkg.ImportPath()+"._gopy_check_"+strings.ToLower(kind), g.pkg.Name()+"__gopy_check_"+strings.ToLower(kind), false)
+	g.impl.Printf("err = cgopy_seq_buffer_read_string(obuf);\n")
+	g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
+	g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
+	g.impl.Printf("if (err.Len > 0) {\n")
+	g.impl.Indent()
+	g.impl.Printf("PyObject *msg = cgopy_cnv_c2py_string(&err);\n")
+	g.impl.Printf("PyErr_SetObject(PyExc_ValueError, msg);\n")
+	g.impl.Printf("Py_XDECREF(msg);\n")
+	g.impl.Printf("cgopy_seq_bytearray_free(err);\n")
+	g.impl.Printf("return 0;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Printf("cgopy_seq_bytearray_free(err);\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Printf("*addr = o;\n")
+	g.impl.Printf("return 1;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+}
+
+// registeredTypes returns the types for which genType generates a python
+// type, sorted by symbol id.
+func (g *cpyGen) registeredTypes() []Type {
+	var typs []Type
+	for _, t := range g.pkg.types {
+		sym := t.sym
+		if !sym.isType() || (sym.isBasic() && !sym.isNamed()) {
+			continue
+		}
+		typs = append(typs, t)
+	}
+	sort.Slice(typs, func(i, j int) bool { return typs[i].sym.id < typs[j].sym.id })
+	return typs
+}
+
+// genTypeRegistry forward-declares the python type, the converters and the
+// check-type function of each wrapped type, keyed by symbol id, and
+// generates the registry of the python types wrapping the go values sent
+// by handle in dynamically typed values: structs, held by pointer, keyed
+// by qualified go name.
+func (g *cpyGen) genTypeRegistry() {
+	typs := g.registeredTypes()
+
+	g.decl.Printf("\n/* --- registry of the wrapped types --- */\n")
+	for _, t := range typs {
+		sym := t.sym
+		g.decl.Printf("\n/* %s */\n", sym.gofmt())
+		g.decl.Printf("static PyTypeObject %sType;\n", sym.cpyname)
+		g.decl.Printf("static int\ncgopy_cnv_py2c_%[1]s(PyObject *o, %[2]s *addr);\n", sym.id, sym.cgoname)
+		g.decl.Printf("static PyObject*\ncgopy_cnv_c2py_%[1]s(%[2]s *addr);\n", sym.id, sym.cgoname)
+		g.decl.Printf("static int\ncpy_func_%[1]s_check(PyObject *self);\n", sym.id)
+	}
+	g.decl.Printf("\n")
+
+	var structs []*symbol
+	for _, t := range typs {
+		if t.sym.isStruct() {
+			structs = append(structs, t.sym)
+		}
+	}
+	sort.Slice(structs, func(i, j int) bool { return structs[i].gofmt() < structs[j].gofmt() })
+
+	g.impl.Printf("/* cgopy_seq_value_types maps the qualified go names of the values sent\n")
+	g.impl.Printf(" * by handle to the python types wrapping them. */\n")
+	g.impl.Printf("static struct {\n")
+	g.impl.Printf("\tconst char *name;\n")
+	g.impl.Printf("\tPyTypeObject *type;\n")
+	g.impl.Printf("} cgopy_seq_value_types[] = {\n")
+	g.impl.Indent()
+	for _, sym := range structs {
+		g.impl.Printf("{%q, &%sType},\n", sym.gofmt(), sym.cpyname)
+	}
+	g.impl.Printf("{NULL, NULL}\n")
+	g.impl.Outdent()
+	g.impl.Printf("};\n\n")
+
+	g.impl.Printf("static PyTypeObject*\ncgopy_seq_value_type(const char *name) {\n")
+	g.impl.Indent()
+	g.impl.Printf("int i = 0;\n")
+	g.impl.Printf("for (i = 0; cgopy_seq_value_types[i].name != NULL; i++) {\n")
+	g.impl.Indent()
+	g.impl.Printf("if (strcmp(name, cgopy_seq_value_types[i].name) == 0) {\n")
+	g.impl.Printf("\treturn cgopy_seq_value_types[i].type;\n")
+	g.impl.Printf("}\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Printf("return NULL;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+
+	g.impl.Printf("static int\ncgopy_seq_value_check_ref(PyObject *o) {\n")
+	g.impl.Indent()
+	g.impl.Printf("int i = 0;\n")
+	g.impl.Printf("for (i = 0; cgopy_seq_value_types[i].name != NULL; i++) {\n")
+	g.impl.Indent()
+	g.impl.Printf("if (PyObject_TypeCheck(o, cgopy_seq_value_types[i].type)) {\n")
+	g.impl.Printf("\treturn 1;\n")
+	g.impl.Printf("}\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Printf("return 0;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
+
+const selectDoc = `select(chans, timeout=None) -> (index, value, ok)
+
+Waits for a value from one of the channels of chans, and returns the index
+of the chosen channel, the received value and whether it was sent, False
+meaning the channel is closed.
+A timeout, in seconds, bounds the wait: select then returns (-1, None, False).
+A zero timeout polls the channels.`
+
+// genSelect generates the select function of the module, waiting for a
+// value from one of several channels, with the GIL released.
+// It returns whether the function was generated: it is not for packages
+// without channels, or with another object named select in python.
+func (g *cpyGen) genSelect() bool {
+	typs := g.pkg.selectTypes()
+	if len(typs) == 0 {
+		return false
+	}
+	for _, f := range g.pkg.funcs {
+		if g.pyname(f.GoName()) == "select" {
+			return false
+		}
+	}
+	for _, t := range g.pkg.types {
+		for _, f := range t.ctors {
+			if g.pyname(f.GoName()) == "select" {
+				return false
+			}
+		}
+	}
+	for _, c := range g.pkg.consts {
+		if g.pyname(c.GoName()) == "select" {
+			return false
+		}
+	}
+
+	id := g.pkg.Name() + "_select"
+
+	g.impl.Printf("\n/* check-type function for the channels passed to select */\n")
+	g.impl.Printf("static int\ncpy_func_%s_check_chan(PyObject *o) {\n", id)
+	g.impl.Indent()
+	for _, t := range typs {
+		g.impl.Printf("if (cpy_func_%s_check(o)) {\n", t.sym.id)
+		g.impl.Printf("\treturn 1;\n")
+		g.impl.Printf("}\n")
+	}
+	g.impl.Printf("return 0;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+
+	g.impl.Printf("/* select waits for a value from one of several channels */\n")
+	g.impl.Printf("static PyObject*\ncpy_func_%s(PyObject *self, PyObject *args, PyObject *kwds) {\n", id)
+	g.impl.Indent()
+	g.impl.Printf("static char *kwlist[] = {\"chans\", \"timeout\", NULL};\n")
+	g.impl.Printf("PyObject *chans = NULL;\n")
+	g.impl.Printf("PyObject *timeout = Py_None;\n")
+	g.impl.Printf("PyObject *items = NULL;\n")
+	g.impl.Printf("PyObject *value = NULL;\n")
+	g.impl.Printf("double secs = -1;\n")
+	g.impl.Printf("Py_ssize_t i = 0;\n")
+	g.impl.Printf("Py_ssize_t n = 0;\n")
+	g.impl.Printf("int64_t chosen = 0;\n")
+	g.impl.Printf("int8_t ok = 0;\n")
+	g.impl.Printf("cgopy_seq_buffer ibuf = NULL;\n")
+	g.impl.Printf("cgopy_seq_buffer obuf = NULL;\n\n")
+
+	g.impl.Printf("if (!PyArg_ParseTupleAndKeywords(args, kwds, \"O|O\", kwlist, &chans, &timeout)) {\n")
+	g.impl.Printf("\treturn NULL;\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("if (timeout != Py_None) {\n")
+	g.impl.Indent()
+	g.impl.Printf("secs = PyFloat_AsDouble(timeout);\n")
+	g.impl.Printf("if (secs == -1 && PyErr_Occurred()) {\n")
+	g.impl.Printf("\treturn NULL;\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("if (secs < 0) {\n")
+	g.impl.Printf("\tPyErr_SetString(PyExc_ValueError, \"select: negative timeout\");\n")
+	g.impl.Printf("\treturn NULL;\n")
+	g.impl.Printf("}\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+
+	g.impl.Printf("items = PySequence_Fast(chans, \"select: chans must be a sequence of channels\");\n")
+	g.impl.Printf("if (items == NULL) {\n")
+	g.impl.Printf("\treturn NULL;\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("n = PySequence_Fast_GET_SIZE(items);\n")
+	g.impl.Printf("if (n == 0 && secs < 0) {\n")
+	g.impl.Printf("\tPy_DECREF(items);\n")
+	g.impl.Printf("\tPyErr_SetString(PyExc_ValueError, \"select: no channels and no timeout\");\n")
+	g.impl.Printf("\treturn NULL;\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("for (i = 0; i < n; i++) {\n")
+	g.impl.Indent()
+	g.impl.Printf("PyObject *o = PySequence_Fast_GET_ITEM(items, i);\n")
+	g.impl.Printf("if (!cpy_func_%s_check_chan(o)) {\n", id)
+	g.impl.Indent()
+	g.impl.Printf("Py_DECREF(items);\n")
+	g.impl.Printf("PyErr_Format(PyExc_TypeError, \"select: invalid channel (got=%%s)\", Py_TYPE(o)->tp_name);\n")
+	g.impl.Printf("return NULL;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+
+	g.impl.Printf("ibuf = cgopy_seq_buffer_new();\n")
+	g.impl.Printf("obuf = cgopy_seq_buffer_new();\n")
+	g.impl.Printf("cgopy_seq_buffer_write_int64(ibuf, n);\n")
+	g.impl.Printf("for (i = 0; i < n; i++) {\n")
+	g.impl.Printf("\tcgopy_seq_buffer_write_int32(ibuf, ((gopy_object*)PySequence_Fast_GET_ITEM(items, i))->cgopy);\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("cgopy_seq_buffer_write_float64(ibuf, secs);\n\n")
+
+	// the channels are kept alive, holding their go values, until the
+	// go side returns.
+	g.genSend(g.pkg.ImportPath()+".select", id, true)
+	g.impl.Printf("Py_DECREF(items);\n\n")
+
+	g.impl.Printf("chosen = cgopy_seq_buffer_read_int64(obuf);\n")
+	g.impl.Printf("value = cgopy_seq_buffer_read_value(obuf);\n")
+	g.impl.Printf("ok = cgopy_seq_buffer_read_bool(obuf);\n")
+	g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
+	g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
+	g.impl.Printf("if (value == NULL) {\n")
+	g.impl.Printf("\treturn NULL;\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("return Py_BuildValue(\"(nNO)\", (Py_ssize_t)chosen, value, ok ? Py_True : Py_False);\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+	return true
+}
+
+const errorsIsDoc = `errors_is(err, target) -> bool
+
+Reports whether err, or an error it wraps at any depth, is target, as
+errors.Is does in go.`
+
+const errorsAsDoc = `errors_as(err, type) -> value or None
+
+Returns the first error of the chain of err, itself or an error it wraps at
+any depth, which is a value of the go type type, or None, as errors.As does
+in go. The types whose pointers implement error are found by pointer.`
+
+// genErrors generates the errors_is and errors_as functions of the module,
+// matching go errors against sentinel errors, such as the error vars of the
+// package, and types.
+// errors_as dispatches on its type argument to the as funcs of the types.
+func (g *cpyGen) genErrors() {
+	g.genFunc(g.pkg.errorsIs)
+
+	var typs []Type
+	for _, t := range g.pkg.types {
+		if t.isErrorsAsTarget() {
+			g.genFunc(t.funcs.as)
+			typs = append(typs, t)
+		}
+	}
+
+	g.impl.Printf("\n/* errors_as finds the first error of a chain of a go type */\n")
+	g.impl.Printf("static PyObject*\ncpy_func_%s_errors_as(PyObject *self, PyObject *args) {\n", g.pkg.Name())
+	g.impl.Indent()
+	g.impl.Printf("PyObject *err = NULL;\n")
+	g.impl.Printf("PyObject *type = NULL;\n")
+	g.impl.Printf("PyObject *fargs = NULL;\n")
+	g.impl.Printf("PyObject *ret = NULL;\n")
+	g.impl.Printf("if (!PyArg_ParseTuple(args, \"OO\", &err, &type)) {\n")
+	g.impl.Printf("\treturn NULL;\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("fargs = PyTuple_Pack(1, err);\n")
+	g.impl.Printf("if (fargs == NULL) {\n")
+	g.impl.Printf("\treturn NULL;\n")
+	g.impl.Printf("}\n")
+	for i, t := range typs {
+		if i > 0 {
+			g.impl.Printf("} else ")
+		}
+		g.impl.Printf("if (type == (PyObject*)&%sType) {\n", t.sym.cpyname)
+		g.impl.Printf("\tret = cpy_func_%s(self, fargs);\n", t.funcs.as.ID())
+	}
+	if len(typs) > 0 {
+		g.impl.Printf("} else {\n")
+		g.impl.Indent()
+	}
+	g.impl.Printf("PyErr_Format(PyExc_TypeError, \"errors_as() argument 2 must be a go interface or error type, not %%s\", ")
+	g.impl.Printf("PyType_Check(type) ? ((PyTypeObject*)type)->tp_name : Py_TYPE(type)->tp_name);\n")
+	if len(typs) > 0 {
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+	}
+	g.impl.Printf("Py_DECREF(fargs);\n")
+	g.impl.Printf("return ret;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
+
+const handleCountDoc = `_gopy_handle_count() -> int
+
+Returns the number of go values currently held by python, through the
+wrappers of all the gopy modules of the process.
+The count goes back down as the wrappers are collected.`
+
+// genModuleType generates the module type of the package, a subtype of the
+// python module type whose getset descriptors expose the vars of the package
+// as module attributes, read and written through their get and set funcs.
+// It returns whether the package has vars, and thus a module type.
+// With python-2, sub-interpreters get a copy of the module, of the python
+// module type: the vars are reachable there through the get and set funcs
+// only.
+func (g *cpyGen) genModuleType() bool {
+	if len(g.pkg.vars) == 0 {
+		return false
+	}
+	n := g.pkg.pkg.Name()
+	for _, v := range g.pkg.vars {
+		g.impl.Printf("\n/* getter for the %s.%s module attribute */\n", n, v.Name())
+		g.impl.Printf("static PyObject*\ncpy_var_%s_get(PyObject *self, void *closure) {\n", v.id)
+		g.impl.Indent()
+		g.impl.Printf("return cpy_func_%s_get(self, NULL);\n", v.id)
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n")
+
+		g.impl.Printf("/* setter for the %s.%s module attribute */\n", n, v.Name())
+		g.impl.Printf("static int\ncpy_var_%s_set(PyObject *self, PyObject *value, void *closure) {\n", v.id)
+		g.impl.Indent()
+		g.impl.Printf("PyObject *args = NULL;\n")
+		g.impl.Printf("PyObject *res = NULL;\n")
+		g.impl.Printf("if (value == NULL) {\n")
+		g.impl.Indent()
+		g.impl.Printf("PyErr_SetString(PyExc_TypeError, \"cannot delete '%s' attribute\");\n", g.pyname(v.Name()))
+		g.impl.Printf("return -1;\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+		g.impl.Printf("args = PyTuple_Pack(1, value);\n")
+		g.impl.Printf("if (args == NULL) {\n")
+		g.impl.Printf("\treturn -1;\n")
+		g.impl.Printf("}\n")
+		g.impl.Printf("res = cpy_func_%s_set(self, args);\n", v.id)
+		g.impl.Printf("Py_DECREF(args);\n")
+		g.impl.Printf("if (res == NULL) {\n")
+		g.impl.Printf("\treturn -1;\n")
+		g.impl.Printf("}\n")
+		g.impl.Printf("Py_DECREF(res);\n")
+		g.impl.Printf("return 0;\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+	}
+
+	g.impl.Printf("\n/* module attributes for the vars of package %s */\n", n)
+	g.impl.Printf("static PyGetSetDef cpy_%s_module_getsets[] = {\n", n)
+	g.impl.Indent()
+	for _, v := range g.pkg.vars {
+		g.impl.Printf("{%[1]q, (getter)cpy_var_%[2]s_get, (setter)cpy_var_%[2]s_set, %[3]q, NULL},\n",
+			g.pyname(v.Name()), v.id, g.pydoc(v.Name(), v.doc),
+		)
+	}
+	g.impl.Printf("{NULL} /* Sentinel */\n")
+	g.impl.Outdent()
+	g.impl.Printf("};\n\n")
+
+	g.impl.Printf("/* cpy_%[1]s_ModuleType is the type of the %[1]s module */\n", n)
+	g.impl.Printf("static PyTypeObject cpy_%s_ModuleType = {\n", n)
+	g.impl.Indent()
+	g.impl.Printf("PyVarObject_HEAD_INIT(NULL, 0)\n")
+	// named as its base, so that the module looks like any other.
+	g.impl.Printf("\"module\",\t/*tp_name*/\n")
+	g.impl.Printf("0,\t/*tp_basicsize, inherited*/\n")
+	for _, slot := range []string{
+		"tp_itemsize", "tp_dealloc", "tp_print", "tp_getattr", "tp_setattr",
+		"tp_compare", "tp_repr", "tp_as_number", "tp_as_sequence",
+		"tp_as_mapping", "tp_hash", "tp_call", "tp_str", "tp_getattro",
+		"tp_setattro", "tp_as_buffer",
+	} {
+		g.impl.Printf("0,\t/*%s*/\n", slot)
+	}
+	// the gc support of the module type is inherited, with its flag.
+	g.impl.Printf("Py_TPFLAGS_DEFAULT,\t/*tp_flags*/\n")
+	g.impl.Printf("0,\t/* tp_doc */\n")
+	for _, slot := range []string{
+		"tp_traverse", "tp_clear", "tp_richcompare", "tp_weaklistoffset",
+		"tp_iter", "tp_iternext", "tp_methods", "tp_members",
+	} {
+		g.impl.Printf("0,\t/* %s */\n", slot)
+	}
+	g.impl.Printf("cpy_%s_module_getsets,\t/* tp_getset */\n", n)
+	g.impl.Outdent()
+	g.impl.Printf("};\n")
+	return true
+}
+
+// genBufferPin generates the pin type of the package, whose values hold the
+// handles pinning the storage of byte arrays and slices for the new-style
+// buffers viewing it. The buffers hold their pin, rather than the wrapper
+// of the array or slice: python-2 copies them into the buffers made from a
+// memoryview, releasing them once per copy.
+// It returns whether the package has byte arrays or slices, and thus a pin
+// type.
+func (g *cpyGen) genBufferPin() bool {
+	has := false
+	for _, t := range g.pkg.types {
+		if t.sym.isType() && isByteSeqType(t.GoType()) {
+			has = true
+			break
+		}
+	}
+	if !has {
+		return false
+	}
+	n := g.pkg.pkg.Name()
+	g.impl.Printf("\n/* cpy_%[1]s_Pin pins the storage of a go byte array or slice */\n", n)
+	g.impl.Printf("typedef struct {\n")
+	g.impl.Indent()
+	g.impl.Printf("PyObject_HEAD\n")
+	g.impl.Printf("int32_t ref; /* handle pinning the storage */\n")
+	g.impl.Printf("void *buf;\n")
+	g.impl.Printf("Py_ssize_t len;\n")
+	g.impl.Outdent()
+	g.impl.Printf("} cpy_%s_Pin;\n\n", n)
+
+	g.impl.Printf("static void\ncpy_%[1]s_Pin_dealloc(cpy_%[1]s_Pin *self) {\n", n)
+	g.impl.Indent()
+	g.impl.Printf("cgopy_seq_destroy_ref(self->ref);\n")
+	g.impl.Printf("PyObject_Del(self);\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+
+	g.impl.Printf("static int\ncpy_%[1]s_Pin_getbuffer(cpy_%[1]s_Pin *self, Py_buffer *view, int flags) {\n", n)
+	g.impl.Indent()
+	g.impl.Printf("return PyBuffer_FillInfo(view, (PyObject*)self, self->buf, self->len, 0, flags);\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+
+	g.impl.Printf("static PyBufferProcs cpy_%s_Pin_tp_as_buffer = {\n", n)
+	g.impl.Indent()
+	g.genVersions(func(lang int) {
+		if lang == 2 {
+			g.impl.Printf("0, 0, 0, 0,\n")
+		}
+	}, g.impl)
+	g.impl.Printf("(getbufferproc)cpy_%s_Pin_getbuffer,\n", n)
+	g.impl.Printf("(releasebufferproc)0,\n")
+	g.impl.Outdent()
+	g.impl.Printf("};\n\n")
+
+	g.impl.Printf("static PyTypeObject cpy_%s_PinType = {\n", n)
+	g.impl.Indent()
+	g.impl.Printf("PyVarObject_HEAD_INIT(NULL, 0)\n")
+	g.impl.Printf("\"%s._pin\",\t/*tp_name*/\n", n)
+	g.impl.Printf("sizeof(cpy_%s_Pin),\t/*tp_basicsize*/\n", n)
+	g.impl.Printf("0,\t/*tp_itemsize*/\n")
+	g.impl.Printf("(destructor)cpy_%s_Pin_dealloc,\t/*tp_dealloc*/\n", n)
+	for _, slot := range []string{
+		"tp_print", "tp_getattr", "tp_setattr", "tp_compare", "tp_repr",
+		"tp_as_number", "tp_as_sequence", "tp_as_mapping", "tp_hash",
+		"tp_call", "tp_str", "tp_getattro", "tp_setattro",
+	} {
+		g.impl.Printf("0,\t/*%s*/\n", slot)
+	}
+	g.impl.Printf("&cpy_%s_Pin_tp_as_buffer,\t/*tp_as_buffer*/\n", n)
+	g.impl.Printf("Py_TPFLAGS_DEFAULT | Py_TPFLAGS_HAVE_NEWBUFFER,\t/*tp_flags*/\n")
+	g.impl.Outdent()
+	g.impl.Printf("};\n\n")
+
+	g.impl.Printf("/* cpy_%[1]s_pin_buffer fills view with the storage buf of len bytes,\n", n)
+	g.impl.Printf("   pinned by the handle ref until the view is released. */\n")
+	g.impl.Printf("static int\ncpy_%s_pin_buffer(int32_t ref, void *buf, Py_ssize_t len, Py_buffer *view, int flags) {\n", n)
+	g.impl.Indent()
+	g.impl.Printf("int res = -1;\n")
+	g.impl.Printf("cpy_%[1]s_Pin *pin = PyObject_New(cpy_%[1]s_Pin, &cpy_%[1]s_PinType);\n", n)
+	g.impl.Printf("if (pin == NULL) {\n")
+	g.impl.Indent()
+	g.impl.Printf("cgopy_seq_destroy_ref(ref);\n")
+	g.impl.Printf("return -1;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Printf("pin->ref = ref;\n")
+	g.impl.Printf("pin->buf = buf;\n")
+	g.impl.Printf("pin->len = len;\n")
+	g.impl.Printf("res = PyBuffer_FillInfo(view, (PyObject*)pin, buf, len, 0, flags);\n")
+	g.impl.Printf("Py_DECREF(pin);\n")
+	g.impl.Printf("return res;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	return true
+}
+
+// genHandleCount generates the _gopy_handle_count function of the module,
+// returning the number of go values held by python.
+// The names of go objects can not start with an underscore in python, so
+// that the function may not collide with them.
+func (g *cpyGen) genHandleCount() {
+	id := g.pkg.Name() + "__gopy_handle_count"
+	g.impl.Printf("\n/* _gopy_handle_count returns the number of go values held by python */\n")
+	g.impl.Printf("static PyObject*\ncpy_func_%s(PyObject *self, PyObject *args) {\n", id)
+	g.impl.Indent()
+	g.impl.Printf("int64_t n = 0;\n")
+	g.impl.Printf("cgopy_seq_buffer ibuf = cgopy_seq_buffer_new();\n")
+	g.impl.Printf("cgopy_seq_buffer obuf = cgopy_seq_buffer_new();\n")
+	g.genSend(g.pkg.ImportPath()+"._gopy_handle_count", id, false)
+	g.impl.Printf("n = cgopy_seq_buffer_read_int64(obuf);\n")
+	g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
+	g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
+	g.impl.Printf("return Py_BuildValue(\"n\", (Py_ssize_t)n);\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
+
+// genCallbacks generates cgopy_seq_callback, called by go to call back the
+// python callables made into go funcs, or the python file objects made into
+// io.Reader and io.Writer values, and cgopy_seq_callback_release, releasing
+// them. The callbacks hold the GIL, taken from whatever thread go calls them
+// on.
+// It returns whether python objects can be called back from go.
+func (g *cpyGen) genCallbacks() bool {
+	var typs []Type
+	for _, t := range g.pkg.types {
+		if t.isWrappingCallables() || t.isWrappingFiles() {
+			typs = append(typs, t)
+		}
+	}
+
+	g.impl.Printf("\n/* cgopy_seq_callback calls the python callable fn, made into a go func of\n")
+	g.impl.Printf("   the type identified by code, or the method of the python file object fn,\n")
+	g.impl.Printf("   made into an io.Reader or io.Writer, with the arguments in req. The results are\n")
+	g.impl.Printf("   returned in res, and the object holding them in ret, to be released with\n")
+	g.impl.Printf("   cgopy_seq_callback_release once read. */\n")
+	g.impl.Printf("void\ncgopy_seq_callback(void *fn, uint32_t code, uint8_t *req, uint32_t reqlen, uint8_t **res, uint32_t *reslen, void **ret) {\n")
+	g.impl.Indent()
+	g.impl.Printf("PyGILState_STATE gstate = PyGILState_Ensure();\n")
+	g.impl.Printf("cgopy_seq_buffer ibuf = cgopy_seq_buffer_new();\n")
+	g.impl.Printf("cgopy_seq_buffer obuf = cgopy_seq_buffer_new();\n")
+	g.impl.Printf("*ret = NULL;\n")
+	g.impl.Printf("if (reqlen > 0) {\n")
+	g.impl.Indent()
+	g.impl.Printf("ibuf->buf = (uint8_t*)malloc(reqlen);\n")
+	g.impl.Printf("memcpy(ibuf->buf, req, reqlen);\n")
+	g.impl.Printf("ibuf->len = reqlen;\n")
+	g.impl.Printf("ibuf->cap = reqlen;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+	g.impl.Printf("switch (code) {\n")
+	for _, t := range typs {
+		code := uhash(t.funcs.wrap.ID())
+		if t.isWrappingFiles() {
+			code = uhash(t.funcs.file.ID())
+		}
+		g.impl.Printf("case %d:\n", code)
+		g.impl.Indent()
+		g.impl.Printf("*ret = cgopy_callback_%s((PyObject*)fn, ibuf, obuf);\n", t.sym.id)
+		g.impl.Printf("break;\n")
+		g.impl.Outdent()
+	}
+	g.impl.Printf("}\n\n")
+	g.impl.Printf("*res = obuf->buf;\n")
+	g.impl.Printf("*reslen = obuf->len;\n")
+	g.impl.Printf("obuf->buf = NULL;\n")
+	g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
+	g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
+	g.impl.Printf("PyGILState_Release(gstate);\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+
+	g.impl.Printf("/* cgopy_seq_callback_release releases the python object o, referenced by go. */\n")
+	g.impl.Printf("void\ncgopy_seq_callback_release(void *o) {\n")
+	g.impl.Indent()
+	g.impl.Printf("PyGILState_STATE gstate;\n")
+	g.impl.Printf("if (o == NULL || !Py_IsInitialized()) {\n")
+	g.impl.Indent()
+	g.impl.Printf("return;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Printf("gstate = PyGILState_Ensure();\n")
+	g.impl.Printf("Py_DECREF((PyObject*)o);\n")
+	g.impl.Printf("PyGILState_Release(gstate);\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+
+	return len(typs) > 0
+}
+
+// asyncSrc is the python code wrapping the //gopy:async funcs and methods
+// into their _async variants.
+// The variants run the call on a thread, the GIL being released around the
+// go call, and return a concurrent.futures.Future of its result, or an
+// asyncio future when called from a running event loop, so they can be
+// awaited without blocking it.
+// Future implements the part of concurrent.futures.Future used here, for
+// pythons without it.
+const asyncSrc = `import sys
+import threading
+
+try:
+    from concurrent.futures import Future
+except ImportError:
+    class Future(object):
+        """Future holds the result of a call running on another thread."""
+
+        def __init__(self):
+            self._lock = threading.Lock()
+            self._event = threading.Event()
+            self._result = None
+            self._exception = None
+            self._callbacks = []
+
+        def cancel(self):
+            return False
+
+        def cancelled(self):
+            return False
+
+        def running(self):
+            return not self._event.is_set()
+
+        def done(self):
+            return self._event.is_set()
+
+        def result(self, timeout=None):
+            exc = self.exception(timeout)
+            if exc is not None:
+                raise exc
+            return self._result
+
+        def exception(self, timeout=None):
+            if not self._event.wait(timeout):
+                raise RuntimeError("future: timeout")
+            return self._exception
+
+        def add_done_callback(self, fn):
+            with self._lock:
+                if not self._event.is_set():
+                    self._callbacks.append(fn)
+                    return
+            fn(self)
+
+        def set_running_or_notify_cancel(self):
+            return True
+
+        def set_result(self, result):
+            self._set(result, None)
+
+        def set_exception(self, exception):
+            self._set(None, exception)
+
+        def _set(self, result, exception):
+            with self._lock:
+                self._result = result
+                self._exception = exception
+                self._event.set()
+                callbacks, self._callbacks = self._callbacks, []
+            for fn in callbacks:
+                try:
+                    fn(self)
+                except Exception:
+                    pass
+
+
+def running_loop():
+    asyncio = sys.modules.get("asyncio")
+    if asyncio is None:
+        return None
+    try:
+        return asyncio.get_running_loop()
+    except (AttributeError, RuntimeError):
+        return None
+
+
+def wrap(fn, name):
+    def call(*args, **kwargs):
+        future = Future()
+
+        def run():
+            if not future.set_running_or_notify_cancel():
+                return
+            try:
+                result = fn(*args, **kwargs)
+            except BaseException as e:
+                future.set_exception(e)
+            else:
+                future.set_result(result)
+
+        loop = running_loop()
+        thread = threading.Thread(target=run, name=name)
+        thread.daemon = True
+        thread.start()
+        if loop is None:
+            return future
+        return sys.modules["asyncio"].wrap_future(future, loop=loop)
+
+    call.__name__ = name
+    call.__doc__ = "%s runs %s on a thread, returning a future of its result.\n\n%s" % (
+        name, fn.__name__, fn.__doc__ or "")
+    return call
+`
+
+// asyncName returns the python name of the _async variant of f.
+func (g *cpyGen) asyncName(f Func) string {
+	return g.naming.convert(f.GoName()) + "_async"
+}
+
+// genAsync generates the cgopy_async_init function, adding the _async
+// variants of the //gopy:async funcs and methods to the module and to
+// their types, when generated with -async.
+// It returns whether the function was generated: it is not without -async,
+// or for packages without async funcs and methods.
+func (g *cpyGen) genAsync() bool {
+	if !g.async {
+		return false
+	}
+	var funcs []Func
+	for _, f := range g.pkg.funcs {
+		if f.async {
+			funcs = append(funcs, f)
+		}
+	}
+	var typs []Type
+	for _, t := range g.pkg.types {
+		if !t.sym.isType() {
+			continue
+		}
+		for _, m := range t.meths {
+			if m.async {
+				typs = append(typs, t)
+				break
+			}
+		}
+	}
+	if len(funcs) == 0 && len(typs) == 0 {
+		return false
+	}
+
+	g.impl.Printf("\n/* cgopy_async_src wraps the //gopy:async funcs and methods */\n")
+	g.impl.Printf("static const char cgopy_async_src[] =\n")
+	g.impl.Indent()
+	for _, line := range strings.SplitAfter(asyncSrc, "\n") {
+		if line != "" {
+			g.impl.Printf("%q\n", line)
+		}
+	}
+	g.impl.Printf(";\n")
+	g.impl.Outdent()
+
+	g.impl.Printf("\n/* cgopy_async_wrap sets name to the _async variant of fn in dict */\n")
+	g.impl.Printf("static int\ncgopy_async_wrap(PyObject *wrap, PyObject *dict, PyObject *fn, const char *name) {\n")
+	g.impl.Indent()
+	g.impl.Printf("PyObject *variant = NULL;\n")
+	g.impl.Printf("int ret = 0;\n")
+	g.impl.Printf("if (fn == NULL) {\n")
+	g.impl.Printf("\treturn -1;\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("variant = PyObject_CallFunction(wrap, \"Os\", fn, name);\n")
+	g.impl.Printf("if (variant == NULL) {\n")
+	g.impl.Printf("\treturn -1;\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("ret = PyDict_SetItemString(dict, name, variant);\n")
+	g.impl.Printf("Py_DECREF(variant);\n")
+	g.impl.Printf("return ret;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+
+	g.impl.Printf("/* cgopy_async_init adds the _async variants to the module and its types */\n")
+	g.impl.Printf("static int\ncgopy_async_init(PyObject *module) {\n")
+	g.impl.Indent()
+	g.impl.Printf("PyObject *globals = NULL;\n")
+	g.impl.Printf("PyObject *res = NULL;\n")
+	g.impl.Printf("PyObject *wrap = NULL;\n")
+	g.impl.Printf("PyObject *dict = PyModule_GetDict(module);\n")
+	g.impl.Printf("int ret = -1;\n\n")
+	g.impl.Printf("globals = PyDict_New();\n")
+	g.impl.Printf("if (globals == NULL) {\n")
+	g.impl.Printf("\treturn -1;\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("if (PyDict_SetItemString(globals, \"__builtins__\", PyEval_GetBuiltins()) < 0) {\n")
+	g.impl.Printf("\tgoto done;\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("res = PyRun_String(cgopy_async_src, Py_file_input, globals, globals);\n")
+	g.impl.Printf("if (res == NULL) {\n")
+	g.impl.Printf("\tgoto done;\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("Py_DECREF(res);\n")
+	g.impl.Printf("wrap = PyDict_GetItemString(globals, \"wrap\");\n\n")
+
+	for _, f := range funcs {
+		g.impl.Printf("if (cgopy_async_wrap(wrap, dict, PyDict_GetItemString(dict, %q), %q) < 0) {\n",
+			g.pyname(f.GoName()), g.asyncName(f),
+		)
+		g.impl.Printf("\tgoto done;\n")
+		g.impl.Printf("}\n")
+	}
+
+	// the variants of the methods are plain python functions in the dict
+	// of the type, bound to the instance like the methods they wrap.
+	for _, t := range typs {
+		cpyname := t.sym.cpyname
+		for _, m := range t.meths {
+			if !m.async {
+				continue
+			}
+			g.impl.Printf("if (cgopy_async_wrap(wrap, %[1]sType.tp_dict, PyDict_GetItemString(%[1]sType.tp_dict, %[2]q), %[3]q) < 0) {\n",
+				cpyname, g.pyname(m.GoName()), g.asyncName(m),
+			)
+			g.impl.Printf("\tgoto done;\n")
+			g.impl.Printf("}\n")
+		}
+		g.impl.Printf("PyType_Modified(&%sType);\n", cpyname)
+	}
+	g.impl.Printf("ret = 0;\n\n")
+
+	g.impl.Printf("done:\n")
+	g.impl.Printf("Py_DECREF(globals);\n")
+	g.impl.Printf("return ret;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+	return true
+}
+
+func (g *cpyGen) genConst(o Const) {
+	g.genFunc(o.f)
+}
+
+func (g *cpyGen) genVar(v Var) {
+
+	desc := g.pkg.ImportPath() + "." + v.Name()
+	id := g.pkg.Name() + "_" + v.Name()
+	doc := v.doc
+	{
+		res := []*Var{newVar(g.pkg, v.GoType(), "ret", v.Name(), doc)}
+		sig := newSignature(g.pkg, nil, nil, res)
+		fget := Func{
+			pkg:  g.pkg,
+			sig:  sig,
+			typ:  nil,
+			name: v.Name(),
+			desc: desc + ".get",
+			id:   id + "_get",
+			doc:  "returns " + g.pkg.Name() + "." + v.Name(),
+			ret:  v.GoType(),
+			err:  false,
+		}
+		g.genFunc(fget)
+	}
+	{
+		params := []*Var{newVar(g.pkg, v.GoType(), "arg", v.Name(), doc)}
+		sig := newSignature(g.pkg, nil, params, nil)
+		fset := Func{
+			pkg:  g.pkg,
+			sig:  sig,
+			typ:  nil,
+			name: v.Name(),
+			desc: desc + ".set",
+			id:   id + "_set",
+			doc:  "sets " + g.pkg.Name() + "." + v.Name(),
+			ret:  nil,
+			err:  false,
+		}
+		g.genFunc(fset)
+	}
+}
+
+func (g *cpyGen) genPreamble() {
+	n := g.pkg.pkg.Name()
+	// the command is quoted in the comment heading the generated file.
+	cmd := strings.Replace(g.cmd(), "*/", "* /", -1)
+	versions := cPy2Only
+	if g.lang == 0 {
+		versions = cPy23
+	}
+	g.decl.Printf(cPreamble, g.pkg.ImportPath(), g.pkg.pkg.Path(), filepath.Base(n), cmd, versions)
+
+	g.decl.Printf("\nstatic PyObject*\ncgopy_cnv_c2py_duration(int64_t *addr) {\n")
+	g.decl.Indent()
+	g.decl.Printf("return %s(*addr);\n", g.durations.c2py())
+	g.decl.Outdent()
+	g.decl.Printf("}\n")
+}
+
+// cmd returns the command line generating the package.
+func (g *cpyGen) cmd() string {
+	if g.info.Cmd == "" {
+		return "gopy gen -lang=python " + g.pkg.ImportPath()
+	}
+	return g.info.Cmd
 }
@@ -7,220 +7,34 @@ package bind
 import (
 	"fmt"
 	"go/types"
-	"log"
 	"strings"
 )
 
-func (g *cpyGen) _genFunc(sym *symbol, fsym *symbol) {
-	isMethod := (sym != nil)
-
-	switch {
-	case isMethod:
-		g.decl.Printf("\n/* wrapping %s.%s */\n", sym.gofmt(), fsym.goname)
-		g.decl.Printf("static PyObject*\n")
-		g.decl.Printf(
-			"cpy_func_%[1]s(%[2]s *self, PyObject *args, PyObject *kwds);\n",
-			fsym.id,
-			sym.cpyname,
-		)
-
-		g.impl.Printf(
-			"\n/* wrapping %s.%s */\n",
-			sym.gofmt(),
-			fsym.goname,
-		)
-		g.impl.Printf("static PyObject*\n")
-		g.impl.Printf(
-			"cpy_func_%[1]s(%[2]s *self, PyObject *args, PyObject *kwds) {\n",
-			fsym.id,
-			sym.cpyname,
-		)
-
-	default:
-		g.decl.Printf("\n/* wrapping %s */\n", fsym.goname)
-		g.decl.Printf("static PyObject*\n")
-		g.decl.Printf(
-			"cpy_func_%[1]s(PyObject *self, PyObject *args, PyObject *kwds);\n",
-			fsym.id,
-		)
-
-		g.impl.Printf(
-			"\n/* wrapping %s */\n",
-			fsym.goname,
-		)
-		g.impl.Printf("static PyObject*\n")
-		g.impl.Printf(
-			"cpy_func_%[1]s(PyObject *self, PyObject *args, PyObject *kwds) {\n",
-			fsym.id,
-		)
-	}
-	g.impl.Indent()
-
-	sig := fsym.GoType().Underlying().(*types.Signature)
-	args := sig.Params()
-	res := sig.Results()
-
-	nargs := 0
-	nres := 0
-
-	if args != nil {
-		nargs = args.Len()
-		for i := 0; i < nargs; i++ {
-			arg := args.At(i)
-			sarg := g.pkg.syms.symtype(arg.Type())
-			if sarg == nil {
-				panic(fmt.Errorf(
-					"gopy: could not find symbol for %q",
-					arg.String(),
-				))
-			}
-			g.impl.Printf("%[1]s _arg%03d;\n",
-				sarg.cgoname,
-				i,
-			)
-		}
-	}
-
-	if res != nil {
-		nres = res.Len()
-		switch nres {
-		case 0:
-			// no-op
-
-		case 1:
-			ret := res.At(0)
-			sret := g.pkg.syms.symtype(ret.Type())
-			if sret == nil {
-				panic(fmt.Errorf(
-					"gopy: could not find symbol for %q",
-					ret.String(),
-				))
-			}
-			g.impl.Printf("%[1]s ret;\n", sret.cgoname)
-
-		default:
-			g.impl.Printf(
-				"struct cgo_func_%[1]s_return ret;\n",
-				fsym.id,
-			)
-		}
-	}
-
-	g.impl.Printf("\n")
-
-	if nargs > 0 {
-		g.impl.Printf("if (!PyArg_ParseTuple(args, ")
-		format := []string{}
-		pyaddrs := []string{}
-		for i := 0; i < nargs; i++ {
-			sarg := g.pkg.syms.symtype(args.At(i).Type())
-			vname := fmt.Sprintf("_arg%03d", i)
-			pyfmt, addr := sarg.getArgParse(vname)
-			format = append(format, pyfmt)
-			pyaddrs = append(pyaddrs, addr...)
-		}
-		g.impl.Printf("%q, %s)) {\n", strings.Join(format, ""), strings.Join(pyaddrs, ", "))
-		g.impl.Indent()
-		g.impl.Printf("return NULL;\n")
-		g.impl.Outdent()
-		g.impl.Printf("}\n\n")
-	}
-
-	/*
-		if nargs > 0 {
-			for i := 0; i < nargs; i++ {
-				arg := args.At(i)
-				sarg := g.pkg.syms.symtype(arg.Type())
-				sarg.genFuncPreamble(g.impl)
-			}
-			g.impl.Printf("\n")
-		}
-	*/
-
-	// create in/out seq-buffers
-	g.impl.Printf("cgopy_seq_buffer ibuf = cgopy_seq_buffer_new();\n")
-	g.impl.Printf("cgopy_seq_buffer obuf = cgopy_seq_buffer_new();\n")
-	g.impl.Printf("\n")
-
-	// fill input seq-buffer
-	if isMethod {
-		g.genWrite("self->cgopy", "ibuf", sym.GoType())
-	}
-
-	for i := 0; i < nargs; i++ {
-		sarg := g.pkg.syms.symtype(args.At(i).Type())
-		g.genWrite(fmt.Sprintf("_arg%03d", i), "ibuf", sarg.GoType())
-	}
-
-	g.impl.Printf("cgopy_seq_send(%q, %d, ibuf->buf, ibuf->len, &obuf->buf,	&obuf->len);\n\n",
-		fsym.gopkg.Path()+"."+sym.goname+"."+fsym.goname,
-		uhash(fsym.id),
+func (g *cpyGen) genMethod(typ Type, m Func) {
+	sym := typ.sym
+	g.decl.Printf("\n/* wrapping %s.%s */\n", sym.gofmt(), m.GoName())
+	g.decl.Printf("static PyObject*\n")
+	g.decl.Printf(
+		"cpy_func_%[1]s(%[2]s *self, %[3]s);\n",
+		m.ID(),
+		sym.cpyname,
+		funcParams(m),
 	)
 
-	if nres <= 0 {
-		g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
-		g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
-		g.impl.Printf("Py_INCREF(Py_None);\nreturn Py_None;\n")
-		g.impl.Outdent()
-		g.impl.Printf("}\n\n")
-		return
-	}
-
-	if hasError(sig) {
-		switch nres {
-		case 1:
-			g.impl.Printf("if (!_cgopy_ErrorIsNil(ret)) {\n")
-			g.impl.Indent()
-			g.impl.Printf("const char* c_err_str = _cgopy_ErrorString(ret);\n")
-			g.impl.Printf("PyErr_SetString(PyExc_RuntimeError, c_err_str);\n")
-			g.impl.Printf("free((void*)c_err_str);\n")
-			g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
-			g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
-			g.impl.Printf("return NULL;\n")
-			g.impl.Outdent()
-			g.impl.Printf("}\n\n")
-			g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
-			g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
-			g.impl.Printf("Py_INCREF(Py_None);\nreturn Py_None;\n")
-			g.impl.Outdent()
-			g.impl.Printf("}\n\n")
-			return
-
-		case 2:
-			g.impl.Printf("if (!_cgopy_ErrorIsNil(ret.r1)) {\n")
-			g.impl.Indent()
-			g.impl.Printf("const char* c_err_str = _cgopy_ErrorString(ret.r1);\n")
-			g.impl.Printf("PyErr_SetString(PyExc_RuntimeError, c_err_str);\n")
-			g.impl.Printf("free((void*)c_err_str);\n")
-			g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
-			g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
-			g.impl.Printf("return NULL;\n")
-			g.impl.Outdent()
-			g.impl.Printf("}\n\n")
-			ret := res.At(0)
-			sret := g.pkg.syms.symtype(ret.Type())
-			g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
-			g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
-			g.impl.Printf("return %s(&ret.r0);\n", sret.c2py)
-			g.impl.Outdent()
-			g.impl.Printf("}\n\n")
-			return
-
-		default:
-			panic(fmt.Errorf(
-				"bind: function/method with more than 2 results not supported! (%s)",
-				fsym.id,
-			))
-		}
-	}
-
-	ret := res.At(0)
-	sret := g.pkg.syms.symtype(ret.Type())
-	g.genRead("ret", "obuf", sret.GoType())
-
-	g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
-	g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
-	g.impl.Printf("return %s(&ret);\n", sret.c2py)
+	g.impl.Printf("\n/* wrapping %s.%s */\n", sym.gofmt(), m.GoName())
+	g.impl.Printf("static PyObject*\n")
+	g.impl.Printf(
+		"cpy_func_%[1]s(%[2]s *self, %[3]s) {\n",
+		m.ID(),
+		sym.cpyname,
+		funcParams(m),
+	)
+	g.impl.Indent()
+	recv := newVar(g.pkg, typ.GoType(), "self", typ.obj.Name(), "")
+	sig := newSignature(g.pkg, recv, m.sig.Params(), m.sig.Results())
+	sig.variadic = m.sig.Variadic()
+	m.sig = sig
+	g.genFuncBody(m)
 	g.impl.Outdent()
 	g.impl.Printf("}\n\n")
 }
@@ -230,11 +44,12 @@ func (g *cpyGen) genFunc(o Func) {
 	g.impl.Printf(`
 /* pythonization of: %[1]s.%[2]s */
 static PyObject*
-cpy_func_%[3]s(PyObject *self, PyObject *args) {
+cpy_func_%[3]s(PyObject *self, %[4]s) {
 `,
 		g.pkg.pkg.Name(),
 		o.GoName(),
 		o.ID(),
+		funcParams(o),
 	)
 
 	g.impl.Indent()
@@ -244,7 +59,6 @@ cpy_func_%[3]s(PyObject *self, PyObject *args) {
 }
 
 func (g *cpyGen) genFuncBody(f Func) {
-	id := f.ID()
 	sig := f.Signature()
 
 	res := sig.Results()
@@ -255,18 +69,65 @@ func (g *cpyGen) genFuncBody(f Func) {
 		recv.genRecvDecl(g.impl)
 	}
 
+	// the items of a trailing ...T parameter are passed either as the
+	// remaining positional arguments or as a single list or tuple.
+	var vararg *Var
+	if sig.Variadic() {
+		vararg = args[len(args)-1]
+		args = args[:len(args)-1]
+	}
+
 	for _, arg := range args {
+		if f.strList(arg.GoType()) {
+			// []string parameters are written from a list or tuple of str.
+			g.impl.Printf("PyObject *py_%s = NULL;\n", arg.Name())
+			continue
+		}
 		arg.genDecl(g.impl)
+		if isCopiedArg(arg) || isNilableArg(arg) || isTimeoutArg(f, arg) {
+			g.impl.Printf("PyObject *py_%s = NULL;\n", arg.Name())
+		}
+	}
+	if vararg != nil {
+		g.impl.Printf("PyObject *c_%s = NULL;\n", vararg.Name())
+	}
+	if f.out {
+		g.impl.Printf("PyObject *py_gopy_out = NULL;\n")
+	}
+
+	// number of results, not counting the trailing comma-error or comma-ok
+	nres := len(res)
+	if f.err || f.ok {
+		nres--
 	}
 
+	// a []string result is read into a list of str.
+	strs := nres > 0 && f.strList(res[0].GoType())
+
 	if len(res) > 0 {
 		g.impl.Printf("PyObject *pyout = NULL;\n")
-		switch len(res) {
-		case 1:
-			ret := res[0]
-			ret.genRetDecl(g.impl)
-		default:
-			g.impl.Printf("struct cgo_func_%[1]s_return c_gopy_ret;\n", id)
+		switch {
+		case f.tuple:
+			for i, ret := range res[:nres] {
+				if i == 0 && strs {
+					g.impl.Printf("PyObject *c_gopy_ret_%d = NULL;\n", i)
+					continue
+				}
+				g.impl.Printf("%s c_gopy_ret_%d;\n", ret.sym.cgoname, i)
+			}
+		case strs:
+			g.impl.Printf("PyObject *c_gopy_ret = NULL;\n")
+		case nres > 0:
+			res[0].genRetDecl(g.impl)
+		}
+		if f.err {
+			g.impl.Printf("cgopy_seq_bytearray c_gopy_err;\n")
+		}
+		if f.ok {
+			g.impl.Printf("%s c_gopy_ok;\n", res[1].sym.cgoname)
+		}
+		if f.err && f.timeout {
+			g.impl.Printf("int8_t c_gopy_timeout = 0;\n")
 		}
 	}
 
@@ -276,20 +137,73 @@ func (g *cpyGen) genFuncBody(f Func) {
 		recv.genRecvImpl(g.impl)
 	}
 
-	if len(args) > 0 {
-		g.impl.Printf("if (!PyArg_ParseTuple(args, ")
+	if len(args) > 0 || f.out {
 		format := []string{}
 		pyaddrs := []string{}
 		for _, arg := range args {
 			pyfmt, addr := arg.getArgParse()
+			if isCopiedArg(arg) || isNilableArg(arg) || isTimeoutArg(f, arg) {
+				// slices, maps, pointers and timeouts are converted once
+				// all the arguments are parsed.
+				pyfmt, addr = "O", []string{"&py_" + arg.Name()}
+			}
 			format = append(format, pyfmt)
 			pyaddrs = append(pyaddrs, addr...)
 		}
-		g.impl.Printf("%q, %s)) {\n", strings.Join(format, ""), strings.Join(pyaddrs, ", "))
-		g.impl.Indent()
-		g.impl.Printf("return NULL;\n")
-		g.impl.Outdent()
-		g.impl.Printf("}\n\n")
+		if n := optionalArgs(f); n > 0 {
+			format = append(format[:len(args)-n], append([]string{"|"}, format[len(args)-n:]...)...)
+		}
+		if f.out {
+			if optionalArgs(f) == 0 {
+				format = append(format, "|")
+			}
+			format = append(format, "O")
+			pyaddrs = append(pyaddrs, "&py_gopy_out")
+		}
+		if hasKwargs(f) {
+			g.impl.Printf("static char *kwlist[] = {")
+			for _, arg := range args {
+				g.impl.Printf("%q, ", pyArgName(arg.Name()))
+			}
+			if f.out {
+				g.impl.Printf("%q, ", "out")
+			}
+			g.impl.Printf("NULL};\n")
+			g.impl.Printf("if (!PyArg_ParseTupleAndKeywords(args, kwds, ")
+			g.impl.Printf("%q, kwlist, %s)) {\n", strings.Join(format, ""), strings.Join(pyaddrs, ", "))
+			g.impl.Indent()
+			g.impl.Printf("return NULL;\n")
+			g.impl.Outdent()
+			g.impl.Printf("}\n\n")
+		} else if vararg == nil {
+			g.impl.Printf("if (!PyArg_ParseTuple(args, ")
+			g.impl.Printf("%q, %s)) {\n", strings.Join(format, ""), strings.Join(pyaddrs, ", "))
+			g.impl.Indent()
+			g.impl.Printf("return NULL;\n")
+			g.impl.Outdent()
+			g.impl.Printf("}\n\n")
+		} else {
+			g.impl.Printf("{\n")
+			g.impl.Indent()
+			g.impl.Printf("PyObject *c_gopy_args = PyTuple_GetSlice(args, 0, %d);\n", len(args))
+			g.impl.Printf("int ok = PyArg_ParseTuple(c_gopy_args, ")
+			g.impl.Printf("%q, %s);\n", strings.Join(format, ""), strings.Join(pyaddrs, ", "))
+			g.impl.Printf("Py_DECREF(c_gopy_args);\n")
+			g.impl.Printf("if (!ok) {\n")
+			g.impl.Indent()
+			g.impl.Printf("return NULL;\n")
+			g.impl.Outdent()
+			g.impl.Printf("}\n")
+			g.impl.Outdent()
+			g.impl.Printf("}\n\n")
+		}
+	}
+
+	if vararg != nil {
+		g.genVarargParse(vararg, len(args))
+	}
+	if f.out {
+		g.genOutArg(f)
 	}
 
 	if len(args) > 0 {
@@ -297,6 +211,9 @@ func (g *cpyGen) genFuncBody(f Func) {
 			arg.genFuncPreamble(g.impl)
 		}
 		g.impl.Printf("\n")
+		g.genNilableArgs(args, vararg)
+		g.genTimeoutArg(f, args)
+		g.genSliceArgs(f, args, vararg)
 	}
 
 	// create in/out seq-buffers
@@ -311,93 +228,135 @@ func (g *cpyGen) genFuncBody(f Func) {
 	// fill input seq-buffer
 	if len(args) > 0 {
 		for _, arg := range args {
+			if f.strList(arg.GoType()) {
+				g.impl.Printf("cgopy_seq_buffer_write_strings(ibuf, py_%s);\n", arg.Name())
+				continue
+			}
 			g.genWrite(fmt.Sprintf("c_%s", arg.Name()), "ibuf", arg.sym.GoType())
 		}
 	}
+	if vararg != nil {
+		g.genVarargWrite(vararg, args, "ibuf")
+	}
+	if f.out {
+		// the handle 0, of a nil pointer, when there is no out argument.
+		g.impl.Printf("cgopy_seq_buffer_write_int32(ibuf, py_gopy_out == NULL ? 0 : ((gopy_object*)py_gopy_out)->cgopy);\n")
+	}
 
-	g.impl.Printf("cgopy_seq_send(%q, %d, ibuf->buf, ibuf->len, &obuf->buf, &obuf->len);\n\n",
-		f.Descriptor(),
-		uhash(f.ID()),
-	)
+	g.genSend(f.Descriptor(), f.ID(), f.blocking)
+	g.genSliceArgsRelease(args)
+	g.impl.Printf("\n")
+	if f.embed != "" || f.nilRecv {
+		g.genNilCheck(f)
+	}
+
+	if nres > 1 && !f.tuple {
+		panic(fmt.Errorf(
+			"bind: function/method with more than 2 results not supported! (%s)",
+			f.ID(),
+		))
+	}
+
+	switch {
+	case f.tuple:
+		for i, ret := range res[:nres] {
+			if i == 0 && strs {
+				g.impl.Printf("c_gopy_ret_0 = cgopy_seq_buffer_read_strings(obuf);\n")
+				continue
+			}
+			g.genRead(fmt.Sprintf("c_gopy_ret_%d", i), "obuf", ret.sym.GoType())
+		}
+	case strs:
+		g.impl.Printf("c_gopy_ret = cgopy_seq_buffer_read_strings(obuf);\n")
+	case f.out:
+		// the result was copied into the out argument, if any.
+		g.impl.Printf("if (py_gopy_out == NULL) {\n")
+		g.impl.Indent()
+		g.genRead("c_gopy_ret", "obuf", res[0].sym.GoType())
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+	case nres > 0:
+		g.genRead("c_gopy_ret", "obuf", res[0].sym.GoType())
+	}
+	if f.ok {
+		g.genRead("c_gopy_ok", "obuf", res[1].sym.GoType())
+	}
 
-	if len(res) <= 0 {
+	if f.err {
+		if f.timeout {
+			g.impl.Printf("c_gopy_timeout = cgopy_seq_buffer_read_bool(obuf);\n")
+		}
+		g.impl.Printf("c_gopy_err = cgopy_seq_buffer_read_string(obuf);\n")
+		g.impl.Printf("if (c_gopy_err.Len > 0) {\n")
+		g.impl.Indent()
+		g.impl.Printf("PyObject *c_err_str = cgopy_cnv_c2py_string(&c_gopy_err);\n")
+		if f.warn {
+			// the error is issued as a warning, and the results returned,
+			// unless the warning filters turn it into an exception.
+			g.impl.Printf("int c_warn = (c_err_str == NULL) ? -1 : ")
+			g.impl.Printf("PyErr_WarnEx(PyExc_UserWarning, PyString_AS_STRING(c_err_str), 1);\n")
+			g.impl.Printf("Py_XDECREF(c_err_str);\n")
+			g.impl.Printf("cgopy_seq_bytearray_free(c_gopy_err);\n")
+			g.impl.Printf("if (c_warn < 0) {\n")
+			g.impl.Indent()
+		} else if f.timeout {
+			g.impl.Printf("PyErr_SetObject(c_gopy_timeout ? cgopy_timeout_error() : PyExc_RuntimeError, c_err_str);\n")
+			g.impl.Printf("Py_XDECREF(c_err_str);\n")
+			g.impl.Printf("cgopy_seq_bytearray_free(c_gopy_err);\n")
+		} else {
+			g.impl.Printf("PyErr_SetObject(PyExc_RuntimeError, c_err_str);\n")
+			g.impl.Printf("Py_XDECREF(c_err_str);\n")
+			g.impl.Printf("cgopy_seq_bytearray_free(c_gopy_err);\n")
+		}
+		switch {
+		case f.tuple && strs:
+			g.impl.Printf("Py_XDECREF(c_gopy_ret_0);\n")
+		case strs:
+			g.impl.Printf("Py_XDECREF(c_gopy_ret);\n")
+		}
+		g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
+		g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
+		g.impl.Printf("return NULL;\n")
+		if f.warn {
+			g.impl.Outdent()
+			g.impl.Printf("}\n")
+		}
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n")
+	}
+
+	if nres <= 0 {
 		g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
 		g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
 		g.impl.Printf("Py_INCREF(Py_None);\nreturn Py_None;\n")
 		return
 	}
 
-	if f.err {
-		switch len(res) {
-		case 1:
-			g.impl.Printf("if (!_cgopy_ErrorIsNil(c_gopy_ret)) {\n")
-			g.impl.Indent()
-			g.impl.Printf("const char* c_err_str = _cgopy_ErrorString(c_gopy_ret);\n")
-			g.impl.Printf("PyErr_SetString(PyExc_RuntimeError, c_err_str);\n")
-			g.impl.Printf("free((void*)c_err_str);\n")
-			g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
-			g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
-			g.impl.Printf("return NULL;\n")
-			g.impl.Outdent()
-			g.impl.Printf("}\n\n")
-			g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
-			g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
-			g.impl.Printf("Py_INCREF(Py_None);\nreturn Py_None;\n")
-			return
+	if f.out {
+		g.impl.Printf("if (py_gopy_out != NULL) {\n")
+		g.impl.Indent()
+		g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
+		g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
+		g.impl.Printf("Py_INCREF(py_gopy_out);\n")
+		g.impl.Printf("return py_gopy_out;\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n")
+	}
 
-		case 2:
-			g.impl.Printf("if (!_cgopy_ErrorIsNil(c_gopy_ret.r1)) {\n")
+	if f.ctor && !g.isSingleton(res[0].sym) {
+		ret := res[0]
+		// as in cgopy_cnv_c2py, tp_new is skipped: the wrapper holds the
+		// handle made by the constructor, and nil values are None.
+		if !ret.sym.isBasic() {
+			g.impl.Printf("if (c_gopy_ret == 0) {\n")
 			g.impl.Indent()
-			g.impl.Printf("const char* c_err_str = _cgopy_ErrorString(c_gopy_ret.r1);\n")
-			g.impl.Printf("PyErr_SetString(PyExc_RuntimeError, c_err_str);\n")
-			g.impl.Printf("free((void*)c_err_str);\n")
 			g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
 			g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
-			g.impl.Printf("return NULL;\n")
+			g.impl.Printf("Py_RETURN_NONE;\n")
 			g.impl.Outdent()
-			g.impl.Printf("}\n\n")
-			if f.ctor {
-				ret := res[0]
-				g.impl.Printf("PyObject *o = cpy_func_%[1]s_new(&%[2]sType, 0, 0);\n",
-					ret.sym.id,
-					ret.sym.cpyname,
-				)
-				g.impl.Printf("if (o == NULL) {\n")
-				g.impl.Indent()
-				g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
-				g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
-				g.impl.Printf("return NULL;\n")
-				g.impl.Outdent()
-				g.impl.Printf("}\n")
-				g.impl.Printf("((%[1]s*)o)->cgopy = c_gopy_ret.r0;\n",
-					ret.sym.cpyname,
-				)
-				g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
-				g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
-				g.impl.Printf("return o;\n")
-				return
-			}
-			pyfmt, _ := res[0].getArgBuildValue()
-			g.impl.Printf("pyout = Py_BuildValue(%q, c_gopy_ret.r0);\n", pyfmt)
-			g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
-			g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
-			g.impl.Printf("return pyout;\n")
-			return
-
-		default:
-			panic(fmt.Errorf(
-				"bind: function/method with more than 2 results not supported! (%s)",
-				f.ID(),
-			))
+			g.impl.Printf("}\n")
 		}
-	}
-
-	if f.ctor {
-		ret := res[0]
-		g.impl.Printf("PyObject *o = cpy_func_%[1]s_new(&%[2]sType, 0, 0);\n",
-			ret.sym.id,
-			ret.sym.cpyname,
-		)
+		g.impl.Printf("PyObject *o = %[1]sType.tp_alloc(&%[1]sType, 0);\n", ret.sym.cpyname)
 		g.impl.Printf("if (o == NULL) {\n")
 		g.impl.Indent()
 		g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
@@ -410,48 +369,463 @@ func (g *cpyGen) genFuncBody(f Func) {
 		)
 		g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
 		g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
+		if f.buffer {
+			g.genBufferView("o")
+		}
+		if g.isStrEnum(ret.sym) {
+			g.impl.Printf("return cgopy_enum_member(cpy_func_%s_enum, o);\n", ret.sym.id)
+			return
+		}
 		g.impl.Printf("return o;\n")
 		return
 	}
 
-	format := []string{}
-	funcArgs := []string{}
-	switch len(res) {
-	case 1:
-		ret := res[0]
-		ret.name = "gopy_ret"
-		pyfmt, pyaddrs := ret.getArgBuildValue()
-		format = append(format, pyfmt)
-		funcArgs = append(funcArgs, pyaddrs...)
-		g.genRead("c_gopy_ret", "obuf", ret.sym.GoType())
-
-	default:
-		for _, ret := range res {
-			pyfmt, pyaddrs := ret.getArgBuildValue()
+	switch {
+	case f.tuple:
+		// the results are returned as a tuple, wrapping each of them as
+		// when returned alone.
+		format := []string{}
+		pyaddrs := []string{}
+		for i, ret := range res[:nres] {
+			if i == 0 && strs {
+				// the tuple steals the list.
+				format = append(format, "N")
+				pyaddrs = append(pyaddrs, "c_gopy_ret_0")
+				continue
+			}
+			v := *ret
+			v.name = fmt.Sprintf("gopy_ret_%d", i)
+			pyfmt, addrs := v.getArgBuildValue(g.lang)
 			format = append(format, pyfmt)
-			funcArgs = append(funcArgs, pyaddrs...)
+			pyaddrs = append(pyaddrs, addrs...)
 		}
+		g.impl.Printf("pyout = Py_BuildValue(%q, %s);\n",
+			"("+strings.Join(format, "")+")",
+			strings.Join(pyaddrs, ", "),
+		)
+	case strs:
+		// the list was filled from the strings of the slice.
+		g.impl.Printf("pyout = c_gopy_ret;\n")
+	case f.list:
+		// the slice is copied item by item into a python list.
+		g.impl.Printf("{\n")
+		g.impl.Indent()
+		g.impl.Printf("PyObject *o = %s(&c_gopy_ret);\n", res[0].sym.c2py)
+		g.impl.Printf("if (o != NULL) {\n")
+		g.impl.Indent()
+		g.impl.Printf("pyout = PySequence_List(o);\n")
+		g.impl.Printf("Py_DECREF(o);\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+	case f.bytes:
+		// the bytes are copied from a view of the storage of the slice.
+		g.impl.Printf("{\n")
+		g.impl.Indent()
+		g.impl.Printf("PyObject *o = %s(&c_gopy_ret);\n", res[0].sym.c2py)
+		g.genBufferView("o")
+		g.impl.Printf("if (o != NULL) {\n")
+		g.impl.Indent()
+		g.impl.Printf("pyout = PyObject_CallMethod(o, \"tobytes\", NULL);\n")
+		g.impl.Printf("Py_DECREF(o);\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+	default:
+		ret := *res[0]
+		ret.name = "gopy_ret"
+		pyfmt, pyaddrs := ret.getArgBuildValue(g.lang)
+
+		g.impl.Printf("pyout = Py_BuildValue(%q, %s);\n",
+			pyfmt,
+			strings.Join(pyaddrs, ", "),
+		)
+	}
+
+	if f.buffer {
+		g.genBufferView("pyout")
+	}
+
+	switch {
+	case f.okNone:
+		// the value is released with its wrapper, when not ok.
+		g.impl.Printf("if (pyout != NULL && !c_gopy_ok) {\n")
+		g.impl.Indent()
+		g.impl.Printf("Py_DECREF(pyout);\n")
+		g.impl.Printf("Py_INCREF(Py_None);\n")
+		g.impl.Printf("pyout = Py_None;\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+	case f.ok:
+		g.impl.Printf("if (pyout != NULL) {\n")
+		g.impl.Indent()
+		g.impl.Printf("pyout = Py_BuildValue(\"(NO)\", pyout, c_gopy_ok ? Py_True : Py_False);\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
 	}
 
-	g.impl.Printf("pyout = Py_BuildValue(%q, %s);\n",
-		strings.Join(format, ""),
-		strings.Join(funcArgs, ", "),
-	)
 	g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
 	g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
 	g.impl.Printf("return pyout;\n")
 }
 
-func (g *cpyGen) genWrite(valName, seqName string, T types.Type) {
-	if isErrorType(T) {
-		g.impl.Printf("cgopy_seq_write_error(%s, %s);\n", seqName, valName)
+// genBufferView replaces the wrapper of a byte array or slice held by the
+// python variable name, if not NULL, by a memoryview of its storage.
+// The view holds the wrapper, and pins the storage until it is released.
+func (g *cpyGen) genBufferView(name string) {
+	g.impl.Printf("if (%s != NULL) {\n", name)
+	g.impl.Indent()
+	g.impl.Printf("PyObject *view = PyMemoryView_FromObject(%s);\n", name)
+	g.impl.Printf("Py_DECREF(%s);\n", name)
+	g.impl.Printf("%s = view;\n", name)
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+}
+
+// genVarargParse collects the items of the ...T parameter v into a tuple,
+// from the positional arguments following the n first ones.
+// A single list or tuple holds the items, unless it can be an item itself,
+// for ...interface{} and ...[]T parameters.
+func (g *cpyGen) genVarargParse(v *Var, n int) {
+	g.impl.Printf("c_%[1]s = PyTuple_GetSlice(args, %[2]d, PyTuple_GET_SIZE(args));\n", v.Name(), n)
+	g.impl.Printf("if (c_%[1]s == NULL) {\n", v.Name())
+	g.impl.Indent()
+	g.impl.Printf("return NULL;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	switch v.GoType().(*types.Slice).Elem().Underlying().(type) {
+	case *types.Interface, *types.Slice:
+		return
+	}
+	g.impl.Printf("if (PyTuple_GET_SIZE(c_%[1]s) == 1 &&\n", v.Name())
+	g.impl.Printf("    (PyList_Check(PyTuple_GET_ITEM(c_%[1]s, 0)) ||\n", v.Name())
+	g.impl.Printf("     PyTuple_Check(PyTuple_GET_ITEM(c_%[1]s, 0)))) {\n", v.Name())
+	g.impl.Indent()
+	g.impl.Printf("PyObject *items = PySequence_Tuple(PyTuple_GET_ITEM(c_%[1]s, 0));\n", v.Name())
+	g.impl.Printf("Py_DECREF(c_%[1]s);\n", v.Name())
+	g.impl.Printf("c_%[1]s = items;\n", v.Name())
+	g.impl.Printf("if (c_%[1]s == NULL) {\n", v.Name())
+	g.impl.Indent()
+	g.impl.Printf("return NULL;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
+
+// isCopiedArg returns whether the parameter v may be given as a python
+// list or tuple, for slices, bytes or bytearray too, for byte slices, or
+// dict, for maps, copied into a new value.
+// map[string]interface{} parameters are dicts already.
+// io.Reader and io.Writer parameters may be given as python file objects,
+// wrapped into a new value.
+func isCopiedArg(v *Var) bool {
+	return v.sym.isSlice() || (v.sym.isMap() && !isDictType(v.GoType())) ||
+		(v.sym.isInterface() && streamMethod(v.GoType()) != "")
+}
+
+// isNilableArg returns whether python callers may pass None as the
+// parameter v, a nil pointer being passed in its place.
+func isNilableArg(v *Var) bool {
+	return v.sym.isPointer() && !isFileType(v.GoType())
+}
+
+// genSend generates the call of the go function desc, identified by id,
+// sending it ibuf and receiving its results in obuf.
+// The GIL is released around the call when blocking, so other python
+// threads run while it blocks, or when go may call back into python: go
+// may then call the python callables it holds, stored by earlier calls,
+// from other goroutines, taking the GIL while the call waits for them.
+func (g *cpyGen) genSend(desc, id string, blocking bool) {
+	release := blocking || g.pkg.callsBack()
+	if release {
+		g.impl.Printf("Py_BEGIN_ALLOW_THREADS\n")
+	}
+	g.impl.Printf("cgopy_seq_send(%q, %d, ibuf->buf, ibuf->len, &obuf->buf, &obuf->len);\n",
+		desc,
+		uhash(id),
+	)
+	if release {
+		g.impl.Printf("Py_END_ALLOW_THREADS\n")
+	}
+}
+
+// genNilCheck raises a RuntimeError when the interface receiver of the
+// method f, or the embedded interface field it is promoted through, is
+// nil: the go side then returns without calling it.
+func (g *cpyGen) genNilCheck(f Func) {
+	g.impl.Printf("{\n")
+	g.impl.Indent()
+	g.impl.Printf("cgopy_seq_bytearray c_gopy_nil = cgopy_seq_buffer_read_string(obuf);\n")
+	g.impl.Printf("if (c_gopy_nil.Len > 0) {\n")
+	g.impl.Indent()
+	g.impl.Printf("PyObject *c_err_str = cgopy_cnv_c2py_string(&c_gopy_nil);\n")
+	g.impl.Printf("PyErr_SetObject(PyExc_RuntimeError, c_err_str);\n")
+	g.impl.Printf("Py_XDECREF(c_err_str);\n")
+	g.impl.Printf("cgopy_seq_bytearray_free(c_gopy_nil);\n")
+	g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
+	g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
+	g.impl.Printf("return NULL;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Printf("cgopy_seq_bytearray_free(c_gopy_nil);\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
+
+// isTimeoutArg returns whether v is the timeout parameter of f, in
+// seconds, which python callers may omit or pass as None to wait forever.
+func isTimeoutArg(f Func, v *Var) bool {
+	params := f.Signature().Params()
+	return f.timeout && v == params[len(params)-1]
+}
+
+// optionalArgs returns the number of the trailing pointer parameters of f,
+// which python callers may omit, or 1 for the timeout parameter.
+func optionalArgs(f Func) int {
+	sig := f.Signature()
+	if f.timeout {
+		return 1
+	}
+	if sig.Variadic() {
+		return 0
+	}
+	args := sig.Params()
+	n := 0
+	for n < len(args) && isNilableArg(args[len(args)-1-n]) {
+		n++
+	}
+	return n
+}
+
+// hasKwargs returns whether f takes its arguments by keyword too, so that
+// python callers may omit any of its optional arguments, or pass the out
+// argument of the funcs annotated with //gopy:out.
+// The funcs generated for the protocols of the wrapped types are called
+// by the slots of these types, with positional arguments only, but for the
+// timeout of the generated methods.
+func hasKwargs(f Func) bool {
+	if f.out {
+		return true
+	}
+	if (f.typ == nil && !f.timeout) || optionalArgs(f) == 0 {
+		return false
+	}
+	for _, arg := range f.Signature().Params() {
+		if arg.blank {
+			// blank parameters are positional only.
+			return false
+		}
+	}
+	return true
+}
+
+// funcParams returns the parameters of the C function wrapping f, after
+// its self parameter.
+func funcParams(f Func) string {
+	if hasKwargs(f) {
+		return "PyObject *args, PyObject *kwds"
+	}
+	return "PyObject *args"
+}
+
+// methFlags returns the flags of the PyMethodDef entry of f.
+func methFlags(f Func) string {
+	if hasKwargs(f) {
+		return "METH_VARARGS | METH_KEYWORDS"
+	}
+	return "METH_VARARGS"
+}
+
+// genNilableArgs converts the pointer parameters among args, which are nil
+// when omitted or given as None.
+func (g *cpyGen) genNilableArgs(args []*Var, vararg *Var) {
+	for _, arg := range args {
+		if !isNilableArg(arg) {
+			continue
+		}
+		g.impl.Printf("if (py_%[1]s == NULL || py_%[1]s == Py_None) {\n", arg.Name())
+		g.impl.Indent()
+		g.impl.Printf("c_%s = 0;\n", arg.Name())
+		g.impl.Outdent()
+		g.impl.Printf("} else if (!%[2]s(py_%[1]s, &c_%[1]s)) {\n", arg.Name(), arg.sym.py2c)
+		g.impl.Indent()
+		if vararg != nil {
+			g.impl.Printf("Py_DECREF(c_%s);\n", vararg.Name())
+		}
+		g.impl.Printf("return NULL;\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n")
+	}
+}
+
+// genOutArg checks the out argument of f, omitted when given as None, is a
+// wrapper of the struct returned by f, whose storage the result is copied
+// into.
+func (g *cpyGen) genOutArg(f Func) {
+	sym := f.Signature().Results()[0].sym
+	g.impl.Printf("if (py_gopy_out == Py_None) {\n")
+	g.impl.Printf("\tpy_gopy_out = NULL;\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("if (py_gopy_out != NULL && !cpy_func_%s_check(py_gopy_out)) {\n", sym.id)
+	g.impl.Indent()
+	g.impl.Printf("PyErr_Format(PyExc_TypeError, \"out: expected %s (got=%%s)\", Py_TYPE(py_gopy_out)->tp_name);\n", sym.gofmt())
+	g.impl.Printf("return NULL;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
+
+// genTimeoutArg converts the timeout parameter of f, if any, into seconds:
+// -1 when omitted or given as None, waiting forever.
+func (g *cpyGen) genTimeoutArg(f Func, args []*Var) {
+	if !f.timeout {
+		return
 	}
+	arg := args[len(args)-1]
+	g.impl.Printf("c_%s = -1;\n", arg.Name())
+	g.impl.Printf("if (py_%[1]s != NULL && py_%[1]s != Py_None) {\n", arg.Name())
+	g.impl.Indent()
+	g.impl.Printf("c_%[1]s = PyFloat_AsDouble(py_%[1]s);\n", arg.Name())
+	g.impl.Printf("if (c_%s == -1 && PyErr_Occurred()) {\n", arg.Name())
+	g.impl.Printf("\treturn NULL;\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("if (c_%s < 0) {\n", arg.Name())
+	g.impl.Printf("\tPyErr_SetString(PyExc_ValueError, \"%s: negative timeout\");\n", g.pyname(f.GoName()))
+	g.impl.Printf("\treturn NULL;\n")
+	g.impl.Printf("}\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
 
-	switch T := T.(type) {
+// genSliceArgs converts the slice and map parameters among args, which may
+// be given either as values of their wrapped type or as python lists and
+// tuples, for slices, bytes and bytearrays too, for byte slices, and dicts,
+// for maps.
+// lists, tuples and dicts are copied into a new slice or map, which lives
+// until the call returns. []string parameters are checked to be lists or
+// tuples of str.
+func (g *cpyGen) genSliceArgs(f Func, args []*Var, vararg *Var) {
+	for i, arg := range args {
+		if !isCopiedArg(arg) {
+			continue
+		}
+		if f.strList(arg.GoType()) {
+			g.impl.Printf("py_%[1]s = cgopy_seq_strings_new(py_%[1]s, &%[2]sType);\n",
+				arg.Name(),
+				arg.sym.cpyname,
+			)
+			g.impl.Printf("if (py_%s == NULL) {\n", arg.Name())
+			g.impl.Indent()
+			g.genSliceArgsRelease(args[:i])
+			if vararg != nil {
+				g.impl.Printf("Py_DECREF(c_%s);\n", vararg.Name())
+			}
+			g.impl.Printf("return NULL;\n")
+			g.impl.Outdent()
+			g.impl.Printf("}\n\n")
+			continue
+		}
+		switch {
+		case arg.sym.isMap():
+			g.impl.Printf("if (PyDict_Check(py_%[1]s)) {\n", arg.Name())
+		case arg.sym.isInterface():
+			g.impl.Printf("if (!%[1]s && PyObject_HasAttrString(py_%[2]s, %[3]q)) {\n",
+				fmt.Sprintf(arg.sym.pychk, "py_"+arg.Name()),
+				arg.Name(),
+				streamMethod(arg.GoType()),
+			)
+		case isByteSeqType(arg.GoType()):
+			g.impl.Printf("if (PyList_Check(py_%[1]s) || PyTuple_Check(py_%[1]s) || PyBytes_Check(py_%[1]s) || PyByteArray_Check(py_%[1]s)) {\n", arg.Name())
+		default:
+			g.impl.Printf("if (PyList_Check(py_%[1]s) || PyTuple_Check(py_%[1]s)) {\n", arg.Name())
+		}
+		g.impl.Indent()
+		g.impl.Printf("py_%[1]s = PyObject_CallFunctionObjArgs((PyObject*)&%[2]sType, py_%[1]s, NULL);\n",
+			arg.Name(),
+			arg.sym.cpyname,
+		)
+		g.impl.Outdent()
+		g.impl.Printf("} else {\n")
+		g.impl.Indent()
+		g.impl.Printf("Py_INCREF(py_%s);\n", arg.Name())
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+		g.impl.Printf("if (py_%[1]s == NULL || !%[2]s(py_%[1]s, &c_%[1]s)) {\n",
+			arg.Name(),
+			arg.sym.py2c,
+		)
+		g.impl.Indent()
+		// the following ones are still borrowed from args.
+		g.genSliceArgsRelease(args[:i+1])
+		if vararg != nil {
+			g.impl.Printf("Py_DECREF(c_%s);\n", vararg.Name())
+		}
+		g.impl.Printf("return NULL;\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n")
+	}
+}
+
+// genSliceArgsRelease releases the slice and map parameters among args,
+// converted by genSliceArgs.
+func (g *cpyGen) genSliceArgsRelease(args []*Var) {
+	for _, arg := range args {
+		if isCopiedArg(arg) {
+			g.impl.Printf("Py_XDECREF(py_%s);\n", arg.Name())
+		}
+	}
+}
+
+// genVarargWrite writes the items of the ...T parameter v to the seq-buffer,
+// preceded by their number.
+func (g *cpyGen) genVarargWrite(v *Var, args []*Var, seqName string) {
+	elem := v.GoType().(*types.Slice).Elem()
+	esym := g.pkg.syms.symtype(elem)
+	g.impl.Printf("cgopy_seq_buffer_write_int64(%[1]s, PyTuple_GET_SIZE(c_%[2]s));\n", seqName, v.Name())
+	g.impl.Printf("{\n")
+	g.impl.Indent()
+	g.impl.Printf("Py_ssize_t i = 0;\n")
+	g.impl.Printf("for (i = 0; i < PyTuple_GET_SIZE(c_%[1]s); i++) {\n", v.Name())
+	g.impl.Indent()
+	g.impl.Printf("%s c_item;\n", esym.cgoname)
+	cond := fmt.Sprintf("!%s(PyTuple_GET_ITEM(c_%s, i), &c_item)", esym.py2c, v.Name())
+	if b, ok := elem.Underlying().(*types.Basic); ok && b.Info()&types.IsInteger != 0 {
+		cond = fmt.Sprintf("!cgopy_check_int(PyTuple_GET_ITEM(c_%s, i)) || %s", v.Name(), cond)
+	}
+	g.impl.Printf("if (%s) {\n", cond)
+	g.impl.Indent()
+	g.impl.Printf("Py_DECREF(c_%s);\n", v.Name())
+	g.genSliceArgsRelease(args)
+	g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
+	g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
+	g.impl.Printf("return NULL;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.genWrite("c_item", seqName, elem)
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Printf("Py_DECREF(c_%s);\n\n", v.Name())
+}
+
+func (g *cpyGen) genWrite(valName, seqName string, T types.Type) {
+	if conv := g.pkg.syms.conv(T); conv != nil {
+		// converted types are exchanged as values of their basic type.
+		g.genWrite(valName, seqName, conv.base)
+		return
+	}
+	if g.pkg.syms.netType(T) != "" {
+		g.impl.Printf("cgopy_seq_buffer_write_net(%s, %s);\n", seqName, valName)
+		return
+	}
+	switch T := unalias(T).(type) {
 	case *types.Basic:
 		switch T.Kind() {
 		case types.Bool:
-			log.Fatalf("unhandled type [bool]")
+			g.impl.Printf("cgopy_seq_buffer_write_bool(%s, %s);\n", seqName, valName)
 		case types.Int8:
 			g.impl.Printf("cgopy_seq_buffer_write_int8(%s, %s);\n", seqName, valName)
 		case types.Int16:
@@ -478,28 +852,62 @@ func (g *cpyGen) genWrite(valName, seqName string, T types.Type) {
 	case *types.Named:
 		switch u := T.Underlying().(type) {
 		case *types.Interface, *types.Pointer, *types.Struct,
-			*types.Array, *types.Slice:
+			*types.Array, *types.Slice, *types.Signature, *types.Chan,
+			*types.Map:
 			g.impl.Printf("cgopy_seq_buffer_write_int32(%[1]s, %[2]s);\n", seqName, valName)
 		case *types.Basic:
 			g.genWrite(valName, seqName, u)
 		default:
-			panic(fmt.Errorf("unsupported, direct named type %s: %s", T, u))
+			g.err = append(g.err, fmt.Errorf("gopy: unsupported, direct named type %s: %s", T, u))
+		}
+	case *types.Struct, *types.Signature, *types.Array, *types.Slice:
+		// anonymous structs, unnamed funcs, arrays and slices are wrapped
+		// like named ones.
+		g.impl.Printf("cgopy_seq_buffer_write_int32(%[1]s, %[2]s);\n", seqName, valName)
+	case *types.Map:
+		if !isDictType(T) {
+			// unnamed maps are wrapped like named ones.
+			g.impl.Printf("cgopy_seq_buffer_write_int32(%[1]s, %[2]s);\n", seqName, valName)
+			break
 		}
+		g.impl.Printf("cgopy_seq_buffer_write_value(%s, %s);\n", seqName, valName)
+	case *types.Interface:
+		if !T.Empty() {
+			g.err = append(g.err, fmt.Errorf("gopy: unsupported interface type %s", T))
+			break
+		}
+		g.impl.Printf("cgopy_seq_buffer_write_value(%s, %s);\n", seqName, valName)
+	case *types.Pointer:
+		if isFileType(T) {
+			g.impl.Printf("cgopy_seq_buffer_write_int64(%s, %s);\n", seqName, valName)
+			break
+		}
+		if kind := bigType(T); kind != "" {
+			g.impl.Printf("cgopy_seq_buffer_write_big%s(%s, %s);\n", strings.ToLower(kind), seqName, valName)
+			break
+		}
+		// pointers share the handle of the value they point to.
+		g.genWrite(valName, seqName, T.Elem())
 	default:
 		g.impl.Printf("/* not implemented %#T */\n", T)
 	}
 }
 
 func (g *cpyGen) genRead(valName, seqName string, T types.Type) {
-	if isErrorType(T) {
-		g.impl.Printf("cgopy_seq_read_error(%s, %s);\n", seqName, valName)
+	if conv := g.pkg.syms.conv(T); conv != nil {
+		// converted types are exchanged as values of their basic type.
+		g.genRead(valName, seqName, conv.base)
+		return
 	}
-
-	switch T := T.(type) {
+	if g.pkg.syms.netType(T) != "" {
+		g.impl.Printf("%[2]s = cgopy_seq_buffer_read_net(%[1]s);\n", seqName, valName)
+		return
+	}
+	switch T := unalias(T).(type) {
 	case *types.Basic:
 		switch T.Kind() {
 		case types.Bool:
-			log.Fatalf("unhandled type [bool]")
+			g.impl.Printf("%[2]s = cgopy_seq_buffer_read_bool(%[1]s);\n", seqName, valName)
 		case types.Int8:
 			g.impl.Printf("%[2]s = cgopy_seq_buffer_read_int8(%[1]s);\n", seqName, valName)
 		case types.Int16:
@@ -526,14 +934,190 @@ func (g *cpyGen) genRead(valName, seqName string, T types.Type) {
 	case *types.Named:
 		switch u := T.Underlying().(type) {
 		case *types.Interface, *types.Pointer, *types.Struct,
-			*types.Array, *types.Slice:
+			*types.Array, *types.Slice, *types.Signature, *types.Chan,
+			*types.Map:
 			g.impl.Printf("%[2]s = cgopy_seq_buffer_read_int32(%[1]s);\n", seqName, valName)
 		case *types.Basic:
 			g.genRead(valName, seqName, u)
 		default:
-			panic(fmt.Errorf("unsupported, direct named type %s: %s", T, u))
+			g.err = append(g.err, fmt.Errorf("gopy: unsupported, direct named type %s: %s", T, u))
+		}
+	case *types.Struct, *types.Signature, *types.Array, *types.Slice:
+		// anonymous structs, unnamed funcs, arrays and slices are wrapped
+		// like named ones.
+		g.impl.Printf("%[2]s = cgopy_seq_buffer_read_int32(%[1]s);\n", seqName, valName)
+	case *types.Map:
+		if !isDictType(T) {
+			// unnamed maps are wrapped like named ones.
+			g.impl.Printf("%[2]s = cgopy_seq_buffer_read_int32(%[1]s);\n", seqName, valName)
+			break
+		}
+		g.impl.Printf("%[2]s = cgopy_seq_buffer_read_value(%[1]s);\n", seqName, valName)
+	case *types.Interface:
+		if !T.Empty() {
+			g.err = append(g.err, fmt.Errorf("gopy: unsupported interface type %s", T))
+			break
+		}
+		g.impl.Printf("%[2]s = cgopy_seq_buffer_read_value(%[1]s);\n", seqName, valName)
+	case *types.Pointer:
+		if isFileType(T) {
+			g.impl.Printf("%[2]s = cgopy_seq_buffer_read_int64(%[1]s);\n", seqName, valName)
+			break
+		}
+		if kind := bigType(T); kind != "" {
+			g.impl.Printf("%[2]s = cgopy_seq_buffer_read_big%[3]s(%[1]s);\n", seqName, valName, strings.ToLower(kind))
+			break
 		}
+		// pointers share the handle of the value they point to.
+		g.genRead(valName, seqName, T.Elem())
 	default:
 		g.impl.Printf("/* not implemented %#T */\n", T)
 	}
 }
+
+// genOverload generates the python func named after the group of
+// overloaded funcs o, calling the first of them its positional arguments
+// match in number and types. A TypeError listing their signatures is
+// raised when none does.
+func (g *cpyGen) genOverload(o overload) {
+	id := g.pkg.Name() + "_" + o.name
+	g.impl.Printf("\n/* dispatch of the //gopy:overload %s funcs */\n", o.name)
+	g.impl.Printf("static PyObject*\n")
+	g.impl.Printf("cpy_overload_%s(PyObject *self, PyObject *args) {\n", id)
+	g.impl.Indent()
+	g.impl.Printf("Py_ssize_t n = PyTuple_GET_SIZE(args);\n")
+	for _, f := range o.funcs {
+		if f.Signature().Variadic() {
+			g.impl.Printf("Py_ssize_t i = 0;\n")
+			break
+		}
+	}
+	g.impl.Printf("int ok = 0;\n")
+	for _, f := range o.funcs {
+		sig := f.Signature()
+		args := sig.Params()
+		var elem types.Type
+		if sig.Variadic() {
+			elem = args[len(args)-1].GoType().(*types.Slice).Elem()
+			args = args[:len(args)-1]
+		}
+		g.impl.Printf("\n/* %s */\n", overloadSig(f))
+		if elem != nil {
+			g.impl.Printf("ok = n >= %d;\n", len(args))
+		} else {
+			g.impl.Printf("ok = n >= %d && n <= %d;\n", len(args)-optionalArgs(f), len(args))
+		}
+		for i, arg := range args {
+			item := fmt.Sprintf("PyTuple_GET_ITEM(args, %d)", i)
+			g.impl.Printf("ok = ok && (n <= %d || %s);\n", i, g.overloadCheck(f, arg.GoType(), item))
+		}
+		if elem != nil {
+			// the items of a trailing ...T parameter are checked one by one.
+			g.impl.Printf("for (i = %d; ok && i < n; i++) {\n", len(args))
+			g.impl.Indent()
+			g.impl.Printf("ok = %s;\n", g.overloadCheck(f, elem, "PyTuple_GET_ITEM(args, i)"))
+			g.impl.Outdent()
+			g.impl.Printf("}\n")
+		}
+		g.impl.Printf("if (ok) {\n")
+		g.impl.Indent()
+		if hasKwargs(f) {
+			g.impl.Printf("return cpy_func_%s(self, args, NULL);\n", f.ID())
+		} else {
+			g.impl.Printf("return cpy_func_%s(self, args);\n", f.ID())
+		}
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+	}
+	sigs := make([]string, len(o.funcs))
+	for i, f := range o.funcs {
+		sigs[i] = "\t" + overloadSig(f)
+	}
+	g.impl.Printf("\ncgopy_overload_err(%q, args, %q);\n", g.pyname(o.name), strings.Join(sigs, "\n"))
+	g.impl.Printf("return NULL;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
+
+// overloadSig returns the go signature of the overloaded func f.
+func overloadSig(f Func) string {
+	return funcSignature(f.Package().pkg.Scope().Lookup(f.GoName()).(*types.Func))
+}
+
+// overloadDoc returns the docstring of the python func dispatching to the
+// overloaded funcs o.
+func overloadDoc(o overload) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "%s(*args)\n\nCalls the first of the following funcs its arguments match:\n", o.name)
+	for _, f := range o.funcs {
+		fmt.Fprintf(&buf, "\n%s", overloadSig(f))
+	}
+	return buf.String()
+}
+
+// overloadCheck returns the C expression testing whether the python object
+// o may be passed to the parameter of type typ of the overloaded func f:
+// numbers, strings, values of the wrapped types, and the python values the
+// parameter converts, such as lists for slices or None for pointers.
+// The items of lists and dicts are not checked.
+func (g *cpyGen) overloadCheck(f Func, typ types.Type, o string) string {
+	if conv := g.pkg.syms.conv(typ); conv != nil {
+		return basicCheck(conv.base, o)
+	}
+	sym := g.pkg.syms.symtype(typ)
+	chk := fmt.Sprintf(sym.pychk, o)
+	switch u := typ.Underlying().(type) {
+	case *types.Basic:
+		return basicCheck(u, o)
+	case *types.Pointer:
+		if !isFileType(typ) {
+			return fmt.Sprintf("(%s == Py_None || %s)", o, chk)
+		}
+	case *types.Slice:
+		if f.strList(typ) {
+			return fmt.Sprintf("(PyList_Check(%[1]s) || PyTuple_Check(%[1]s))", o)
+		}
+		return fmt.Sprintf("(%[2]s || PyList_Check(%[1]s) || PyTuple_Check(%[1]s))", o, chk)
+	case *types.Map:
+		if !isDictType(typ) {
+			return fmt.Sprintf("(%s || PyDict_Check(%s))", chk, o)
+		}
+	case *types.Signature:
+		return fmt.Sprintf("(%s || PyCallable_Check(%s))", chk, o)
+	case *types.Interface:
+		if meth := streamMethod(typ); meth != "" {
+			return fmt.Sprintf("(%s || PyObject_HasAttrString(%s, %q))", chk, o, meth)
+		}
+		if u.NumMethods() == 0 {
+			return chk
+		}
+		chks := []string{chk}
+		for _, t := range g.implementers(sym) {
+			chks = append(chks, fmt.Sprintf("cpy_func_%s_check(%s)", t.sym.id, o))
+		}
+		return "(" + strings.Join(chks, " || ") + ")"
+	}
+	return chk
+}
+
+// basicCheck returns the C expression testing whether the python object o
+// may be passed as a value of the basic type typ.
+// ints are taken as floats and complex numbers too.
+func basicCheck(typ *types.Basic, o string) string {
+	info := typ.Info()
+	switch {
+	case typ.Name() == "rune":
+		return fmt.Sprintf("(PyUnicode_Check(%[1]s) || PyString_Check(%[1]s))", o)
+	case info&types.IsBoolean != 0:
+		return fmt.Sprintf("PyBool_Check(%s)", o)
+	case info&types.IsInteger != 0:
+		return fmt.Sprintf("(PyInt_Check(%[1]s) || PyLong_Check(%[1]s))", o)
+	case info&types.IsFloat != 0:
+		return fmt.Sprintf("(PyFloat_Check(%[1]s) || PyInt_Check(%[1]s) || PyLong_Check(%[1]s))", o)
+	case info&types.IsComplex != 0:
+		return fmt.Sprintf("(PyComplex_Check(%[1]s) || PyFloat_Check(%[1]s) || PyInt_Check(%[1]s) || PyLong_Check(%[1]s))", o)
+	case info&types.IsString != 0:
+		return fmt.Sprintf("(PyString_Check(%[1]s) || PyUnicode_Check(%[1]s))", o)
+	}
+	return "1"
+}
@@ -32,23 +32,26 @@ func (g *cpyGen) genStructInit(cpy Type) {
 	numPublic := numFields
 	for i := 0; i < cpy.Struct().NumFields(); i++ {
 		f := cpy.Struct().Field(i)
-		if !f.Exported() {
+		if !cpy.isExposedField(f) {
 			numPublic--
 			continue
 		}
 	}
+	if cpy.isAnonymous() {
+		// the fields of anonymous structs are read-only.
+		numPublic = 0
+	}
 
 	if numPublic > 0 {
-		kwds := make(map[string]int)
+		// the keywords are the exposed fields, in declaration order.
 		g.impl.Printf("static char *kwlist[] = {\n")
 		g.impl.Indent()
 		for i := 0; i < numFields; i++ {
 			field := cpy.Struct().Field(i)
-			if !field.Exported() {
+			if !cpy.isExposedField(field) {
 				continue
 			}
-			kwds[field.Name()] = i
-			g.impl.Printf("%q, /* py_kwd_%03d */\n", field.Name(), i)
+			g.impl.Printf("%q, /* py_kwd_%03d */\n", g.pyname(field.Name()), i)
 		}
 		g.impl.Printf("NULL\n")
 		g.impl.Outdent()
@@ -56,7 +59,7 @@ func (g *cpyGen) genStructInit(cpy Type) {
 
 		for i := 0; i < numFields; i++ {
 			field := cpy.Struct().Field(i)
-			if !field.Exported() {
+			if !cpy.isExposedField(field) {
 				continue
 			}
 			g.impl.Printf("PyObject *py_kwd_%03d = NULL;\n", i)
@@ -80,7 +83,7 @@ func (g *cpyGen) genStructInit(cpy Type) {
 		addrs := []string{}
 		for i := 0; i < numFields; i++ {
 			field := cpy.Struct().Field(i)
-			if !field.Exported() {
+			if !cpy.isExposedField(field) {
 				continue
 			}
 			format = append(format, "O")
@@ -97,7 +100,7 @@ func (g *cpyGen) genStructInit(cpy Type) {
 
 		for i := 0; i < numFields; i++ {
 			field := cpy.Struct().Field(i)
-			if !field.Exported() {
+			if !cpy.isExposedField(field) {
 				continue
 			}
 			g.impl.Printf("if (py_kwd_%03d != NULL) {\n", i)
@@ -121,9 +124,9 @@ func (g *cpyGen) genStructInit(cpy Type) {
 	g.impl.Outdent()
 	g.impl.Printf("\ncpy_label_%s_init_fail:\n", cpy.sym.cpyname)
 	g.impl.Indent()
-	for i := 0; i < numFields; i++ {
+	for i := 0; i < numFields && numPublic > 0; i++ {
 		field := cpy.Struct().Field(i)
-		if !field.Exported() {
+		if !cpy.isExposedField(field) {
 			continue
 		}
 		g.impl.Printf("Py_XDECREF(py_kwd_%03d);\n", i)
@@ -140,11 +143,13 @@ func (g *cpyGen) genStructMembers(cpy Type) {
 	g.decl.Printf("\n/* tp_getset for %s.%v */\n", pkgname, cpy.GoName())
 	for i := 0; i < typ.NumFields(); i++ {
 		f := typ.Field(i)
-		if !f.Exported() {
+		if !cpy.isExposedField(f) {
 			continue
 		}
 		g.genStructMemberGetter(cpy, i, f)
-		g.genStructMemberSetter(cpy, i, f)
+		if !cpy.isAnonymous() {
+			g.genStructMemberSetter(cpy, i, f)
+		}
 	}
 
 	g.impl.Printf("\n/* tp_getset for %s.%v */\n", pkgname, cpy.GoName())
@@ -152,13 +157,17 @@ func (g *cpyGen) genStructMembers(cpy Type) {
 	g.impl.Indent()
 	for i := 0; i < typ.NumFields(); i++ {
 		f := typ.Field(i)
-		if !f.Exported() {
+		if !cpy.isExposedField(f) {
 			continue
 		}
-		doc := "doc for " + f.Name() // FIXME(sbinet) retrieve doc for fields
-		g.impl.Printf("{%q, ", f.Name())
+		doc := g.pydoc(f.Name(), cpy.pkg.getDoc(cpy.obj.Name(), f))
+		g.impl.Printf("{%q, ", g.pyname(f.Name()))
 		g.impl.Printf("(getter)cpy_func_%[1]s_getter_%[2]d, ", cpy.sym.id, i+1)
-		g.impl.Printf("(setter)cpy_func_%[1]s_setter_%[2]d, ", cpy.sym.id, i+1)
+		if cpy.isAnonymous() {
+			g.impl.Printf("(setter)NULL, ")
+		} else {
+			g.impl.Printf("(setter)cpy_func_%[1]s_setter_%[2]d, ", cpy.sym.id, i+1)
+		}
 		g.impl.Printf("%q, NULL},\n", doc)
 	}
 	g.impl.Printf("{NULL} /* Sentinel */\n")
@@ -168,25 +177,27 @@ func (g *cpyGen) genStructMembers(cpy Type) {
 
 func (g *cpyGen) genStructMemberGetter(cpy Type, i int, f types.Object) {
 	pkg := cpy.Package()
-	ft := f.Type()
+	// fields are read as a copy, or by reference with //gopy:ref.
+	ft := cpy.fieldType(f.(*types.Var))
 	var (
 		cpy_fgetname = fmt.Sprintf("cpy_func_%[1]s_getter_%[2]d", cpy.sym.id, i+1)
 		ifield       = newVar(pkg, ft, f.Name(), "ret", "")
 		results      = []*Var{ifield}
 	)
 
-	recv := newVar(cpy.pkg, cpy.GoType(), "self", cpy.GoName(), "")
+	recv := newVar(cpy.pkg, cpy.GoType(), "self", cpy.obj.Name(), "")
 
 	fget := Func{
-		pkg:  cpy.pkg,
-		sig:  newSignature(cpy.pkg, recv, nil, results),
-		typ:  nil,
-		name: f.Name(),
-		desc: pkg.ImportPath() + "." + cpy.GoName() + "." + f.Name() + ".get",
-		id:   cpy.ID() + "_" + f.Name() + "_get",
-		doc:  "",
-		ret:  ft,
-		err:  false,
+		pkg:   cpy.pkg,
+		sig:   newSignature(cpy.pkg, recv, nil, results),
+		typ:   nil,
+		name:  f.Name(),
+		desc:  pkg.ImportPath() + "." + cpy.GoName() + "." + f.Name() + ".get",
+		id:    cpy.ID() + "_" + f.Name() + "_get",
+		doc:   "",
+		ret:   ft,
+		err:   false,
+		field: true,
 	}
 
 	g.decl.Printf("\n/* getter for %[1]s.%[2]s.%[3]s */\n",
@@ -223,7 +234,7 @@ func (g *cpyGen) genStructMemberSetter(cpy Type, i int, f types.Object) {
 		ifield       = newVar(pkg, ft, f.Name(), "ret", "")
 		cpy_fsetname = fmt.Sprintf("cpy_func_%[1]s_setter_%[2]d", cpy.sym.id, i+1)
 		params       = []*Var{ifield}
-		recv         = newVar(cpy.pkg, cpy.GoType(), "self", cpy.GoName(), "")
+		recv         = newVar(cpy.pkg, cpy.GoType(), "self", cpy.obj.Name(), "")
 	)
 
 	fset := Func{
@@ -265,24 +276,50 @@ func (g *cpyGen) genStructMemberSetter(cpy Type, i int, f types.Object) {
 	g.impl.Indent()
 	g.impl.Printf(
 		"PyErr_SetString(PyExc_TypeError, \"cannot delete '%[1]s' attribute\");\n",
-		f.Name(),
+		g.pyname(f.Name()),
 	)
 	g.impl.Printf("return -1;\n")
 	g.impl.Outdent()
 	g.impl.Printf("}\n\n")
 
+	// python callables are made into a go func calling them back, held
+	// by a new value of the python type of the func field.
+	ftyp, callable := pkg.callableType(ft)
+	if callable {
+		g.impl.Printf("if (!%s && PyCallable_Check(value)) {\n", fmt.Sprintf(ifield.sym.pychk, "value"))
+		g.impl.Indent()
+		g.impl.Printf("value = PyObject_CallFunctionObjArgs((PyObject*)&%sType, value, NULL);\n", ftyp.sym.cpyname)
+		g.impl.Printf("if (value == NULL) {\n")
+		g.impl.Indent()
+		g.impl.Printf("return -1;\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+		g.impl.Outdent()
+		g.impl.Printf("} else {\n")
+		g.impl.Indent()
+		g.impl.Printf("Py_INCREF(value);\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n")
+	}
+
 	g.impl.Printf("if (!%s) {\n", fmt.Sprintf(ifield.sym.pychk, "value"))
 	g.impl.Indent()
 	g.impl.Printf(
 		"PyErr_SetString(PyExc_TypeError, \"invalid type for '%[1]s' attribute\");\n",
-		f.Name(),
+		g.pyname(f.Name()),
 	)
+	if callable {
+		g.impl.Printf("Py_DECREF(value);\n")
+	}
 	g.impl.Printf("return -1;\n")
 	g.impl.Outdent()
 	g.impl.Printf("}\n\n")
 
 	g.impl.Printf("if (!%[1]s(value, &c_ret)) {\n", ifield.sym.py2c)
 	g.impl.Indent()
+	if callable {
+		g.impl.Printf("Py_DECREF(value);\n")
+	}
 	g.impl.Printf("return -1;\n")
 	g.impl.Outdent()
 	g.impl.Printf("}\n\n")
@@ -297,13 +334,14 @@ func (g *cpyGen) genStructMemberSetter(cpy Type, i int, f types.Object) {
 	g.genWrite("c_"+ifield.Name(), "ibuf", ifield.GoType())
 	g.impl.Printf("\n")
 
-	g.impl.Printf("cgopy_seq_send(%q, %d, ibuf->buf, ibuf->len, &obuf->buf, &obuf->len);\n\n",
-		fset.Descriptor(),
-		uhash(fset.id),
-	)
+	g.genSend(fset.Descriptor(), fset.id, false)
+	g.impl.Printf("\n")
 
 	g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
 	g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
+	if callable {
+		g.impl.Printf("Py_DECREF(value);\n")
+	}
 
 	g.impl.Printf("return 0;\n")
 	g.impl.Outdent()
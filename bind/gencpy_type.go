@@ -37,6 +37,12 @@ func (g *cpyGen) genType(typ Type) {
 		)
 	}
 	g.decl.Printf("gopy_efacefunc eface;\n")
+	if len(typ.bases) > 0 {
+		// python base classes may expect an instance dict, and weak
+		// references.
+		g.decl.Printf("PyObject *dict;\n")
+		g.decl.Printf("PyObject *weakrefs;\n")
+	}
 	g.decl.Outdent()
 	g.decl.Printf("} %s;\n", sym.cpyname)
 	g.decl.Printf("\n\n")
@@ -53,50 +59,102 @@ func (g *cpyGen) genType(typ Type) {
 
 	tpAsBuffer := "0"
 	tpAsSequence := "0"
-	tpFlags := "Py_TPFLAGS_DEFAULT"
+	// Py_TPFLAGS_BASETYPE lets python classes derive from the wrapped types.
+	tpFlags := "Py_TPFLAGS_DEFAULT | Py_TPFLAGS_BASETYPE"
 	if sym.isArray() || sym.isSlice() {
+		tpAsSequence = fmt.Sprintf("&%[1]s_tp_as_sequence", sym.cpyname)
+	}
+	if isByteSeqType(sym.GoType()) {
+		// the buffer protocol of other items would need their format
+		// and size on the python side.
 		tpAsBuffer = fmt.Sprintf("&%[1]s_tp_as_buffer", sym.cpyname)
+		tpFlags += " | Py_TPFLAGS_HAVE_NEWBUFFER"
+	}
+
+	tpAsNumber := "0"
+	if isIntegerType(sym.GoType()) {
+		tpAsNumber = fmt.Sprintf("&%[1]s_tp_as_number", sym.cpyname)
+	}
+
+	tpAsMapping := "0"
+	if isStringType(sym.GoType()) {
 		tpAsSequence = fmt.Sprintf("&%[1]s_tp_as_sequence", sym.cpyname)
-		switch g.lang {
-		case 2:
-			tpFlags = fmt.Sprintf(
-				"(%s)",
-				strings.Join([]string{
-					"Py_TPFLAGS_DEFAULT",
-					"Py_TPFLAGS_HAVE_NEWBUFFER",
-				},
-					" |\n ",
-				))
-		case 3:
-		}
+		tpAsMapping = fmt.Sprintf("&%[1]s_tp_as_mapping", sym.cpyname)
+	}
+	if typ.prots&(ProtoContainer|ProtoSized) != 0 {
+		tpAsSequence = fmt.Sprintf("&%[1]s_tp_as_sequence", sym.cpyname)
+	}
+	if sym.isMap() {
+		tpAsSequence = fmt.Sprintf("&%[1]s_tp_as_sequence", sym.cpyname)
+		tpAsMapping = fmt.Sprintf("&%[1]s_tp_as_mapping", sym.cpyname)
+	}
+
+	tpRepr := "0"
+	if typ.prots&ProtoGoStringer != 0 {
+		tpRepr = fmt.Sprintf("cpy_func_%[1]s_tp_repr", sym.id)
 	}
 
 	tpCall := "0"
-	if sym.isSignature() {
-		sig := sym.GoType().Underlying().(*types.Signature)
-		if sig.Recv() == nil {
-			// only generate tp_call for functions (not methods)
-			tpCall = fmt.Sprintf("(ternaryfunc)cpy_func_%[1]s_tp_call", sym.id)
-		}
+	if typ.isCallable() {
+		tpCall = fmt.Sprintf("(ternaryfunc)cpy_func_%[1]s_tp_call", sym.id)
+	}
+
+	tpName := sym.gofmt()
+	if typ.isAliased() {
+		tpName = typ.pkg.Name() + "." + sym.goname
+	}
+
+	tpIter := "0"
+	if sym.isMap() || typ.isIterator() {
+		tpIter = fmt.Sprintf("(getiterfunc)cpy_func_%[1]s_tp_iter", sym.id)
+	}
+
+	tpCompare := "0"
+	tpHash := "0"
+	if sym.isInterface() {
+		tpCompare = fmt.Sprintf("(cmpfunc)cpy_func_%[1]s_compare", sym.id)
+	}
+	if typ.isStrEnum() {
+		tpCompare = fmt.Sprintf("(cmpfunc)cpy_func_%[1]s_compare", sym.id)
+		tpHash = fmt.Sprintf("(hashfunc)cpy_func_%[1]s_hash", sym.id)
+	}
+
+	tpRichCompare := "0"
+	if typ.isDeepEq() {
+		tpRichCompare = fmt.Sprintf("(richcmpfunc)cpy_func_%[1]s_richcompare", sym.id)
+		g.genTypeDeepEq(typ)
+	}
+
+	tpDictOffset := "0"
+	tpWeakListOffset := "0"
+	if len(typ.bases) > 0 {
+		tpDictOffset = fmt.Sprintf("offsetof(%s, dict)", sym.cpyname)
+		tpWeakListOffset = fmt.Sprintf("offsetof(%s, weakrefs)", sym.cpyname)
 	}
 
 	g.impl.Printf("static PyTypeObject %sType = {\n", sym.cpyname)
 	g.impl.Indent()
-	g.impl.Printf("PyObject_HEAD_INIT(NULL)\n")
-	g.impl.Printf("0,\t/*ob_size*/\n")
-	g.impl.Printf("\"%s\",\t/*tp_name*/\n", sym.gofmt())
+	g.impl.Printf("PyVarObject_HEAD_INIT(NULL, 0)\n")
+	g.impl.Printf("\"%s\",\t/*tp_name*/\n", tpName)
 	g.impl.Printf("sizeof(%s),\t/*tp_basicsize*/\n", sym.cpyname)
 	g.impl.Printf("0,\t/*tp_itemsize*/\n")
 	g.impl.Printf("(destructor)cpy_func_%s_dealloc,\t/*tp_dealloc*/\n", sym.id)
 	g.impl.Printf("0,\t/*tp_print*/\n")
 	g.impl.Printf("0,\t/*tp_getattr*/\n")
 	g.impl.Printf("0,\t/*tp_setattr*/\n")
-	g.impl.Printf("0,\t/*tp_compare*/\n")
-	g.impl.Printf("0,\t/*tp_repr*/\n")
-	g.impl.Printf("0,\t/*tp_as_number*/\n")
+	g.genVersions(func(lang int) {
+		switch lang {
+		case 2:
+			g.impl.Printf("%s,\t/*tp_compare*/\n", tpCompare)
+		case 3:
+			g.impl.Printf("0,\t/*tp_as_async*/\n")
+		}
+	}, g.impl)
+	g.impl.Printf("%s,\t/*tp_repr*/\n", tpRepr)
+	g.impl.Printf("%s,\t/*tp_as_number*/\n", tpAsNumber)
 	g.impl.Printf("%s,\t/*tp_as_sequence*/\n", tpAsSequence)
-	g.impl.Printf("0,\t/*tp_as_mapping*/\n")
-	g.impl.Printf("0,\t/*tp_hash */\n")
+	g.impl.Printf("%s,\t/*tp_as_mapping*/\n", tpAsMapping)
+	g.impl.Printf("%s,\t/*tp_hash */\n", tpHash)
 	g.impl.Printf("%s,\t/*tp_call*/\n", tpCall)
 	g.impl.Printf("cpy_func_%s_tp_str,\t/*tp_str*/\n", sym.id)
 	g.impl.Printf("0,\t/*tp_getattro*/\n")
@@ -106,9 +164,15 @@ func (g *cpyGen) genType(typ Type) {
 	g.impl.Printf("%q,\t/* tp_doc */\n", sym.doc)
 	g.impl.Printf("0,\t/* tp_traverse */\n")
 	g.impl.Printf("0,\t/* tp_clear */\n")
-	g.impl.Printf("0,\t/* tp_richcompare */\n")
-	g.impl.Printf("0,\t/* tp_weaklistoffset */\n")
-	g.impl.Printf("0,\t/* tp_iter */\n")
+	g.genVersions(func(lang int) {
+		richCompare := tpRichCompare
+		if lang == 3 && tpCompare != "0" {
+			richCompare = fmt.Sprintf("(richcmpfunc)cpy_func_%[1]s_richcompare", sym.id)
+		}
+		g.impl.Printf("%s,\t/* tp_richcompare */\n", richCompare)
+	}, g.impl)
+	g.impl.Printf("%s,\t/* tp_weaklistoffset */\n", tpWeakListOffset)
+	g.impl.Printf("%s,\t/* tp_iter */\n", tpIter)
 	g.impl.Printf("0,\t/* tp_iternext */\n")
 	g.impl.Printf("%s_methods,             /* tp_methods */\n", sym.cpyname)
 	g.impl.Printf("0,\t/* tp_members */\n")
@@ -117,7 +181,7 @@ func (g *cpyGen) genType(typ Type) {
 	g.impl.Printf("0,\t/* tp_dict */\n")
 	g.impl.Printf("0,\t/* tp_descr_get */\n")
 	g.impl.Printf("0,\t/* tp_descr_set */\n")
-	g.impl.Printf("0,\t/* tp_dictoffset */\n")
+	g.impl.Printf("%s,\t/* tp_dictoffset */\n", tpDictOffset)
 	g.impl.Printf("(initproc)cpy_func_%s_init,      /* tp_init */\n", sym.id)
 	g.impl.Printf("0,                         /* tp_alloc */\n")
 	g.impl.Printf("cpy_func_%s_new,\t/* tp_new */\n", sym.id)
@@ -138,24 +202,70 @@ func (g *cpyGen) genTypeNew(typ Type) {
 		sym.id,
 	)
 
+	if typ.isSingleton() {
+		g.decl.Printf("\n/* the wrapper shared by the values of %s */\n", sym.gofmt())
+		g.decl.Printf("static PyObject *cpy_func_%s_singleton = NULL;\n", sym.id)
+	}
+	if typ.isStrEnum() {
+		g.decl.Printf("\n/* the enum.Enum subclass of %s, if the enum module can be imported */\n", sym.gofmt())
+		g.decl.Printf("static PyObject *cpy_func_%s_enum = NULL;\n", sym.id)
+	}
+
 	g.impl.Printf("\n/* tp_new */\n")
 	g.impl.Printf(
 		"static PyObject*\ncpy_func_%s_new(PyTypeObject *type, PyObject *args, PyObject *kwds) {\n",
 		sym.id,
 	)
 	g.impl.Indent()
+	if typ.isOpaque() {
+		// the go side can not name the type: its values are only
+		// returned by go.
+		g.impl.Printf("PyErr_SetString(PyExc_TypeError, ")
+		g.impl.Printf("\"%s values are only made by go: the type is declared in an internal package\");\n", sym.gofmt())
+		g.impl.Printf("return NULL;\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n")
+		return
+	}
 	g.impl.Printf("%s *self;\n", sym.cpyname)
-	g.impl.Printf("cgopy_seq_buffer ibuf = cgopy_seq_buffer_new();\n")
-	g.impl.Printf("cgopy_seq_buffer obuf = cgopy_seq_buffer_new();\n")
+	g.impl.Printf("cgopy_seq_buffer ibuf = NULL;\n")
+	g.impl.Printf("cgopy_seq_buffer obuf = NULL;\n")
 	g.impl.Printf("\n")
+	if typ.isSingleton() {
+		// python subclasses get instances of their own.
+		g.impl.Printf("if (type == &%[1]sType && cpy_func_%[2]s_singleton != NULL) {\n", sym.cpyname, sym.id)
+		g.impl.Indent()
+		g.impl.Printf("Py_INCREF(cpy_func_%s_singleton);\n", sym.id)
+		g.impl.Printf("return cpy_func_%s_singleton;\n", sym.id)
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n")
+	}
+	// type is the wrapped type or a python subclass of it: the go value is
+	// created here, so that subclasses not calling the base __init__ still
+	// hold a valid handle.
 	g.impl.Printf("self = (%s *)type->tp_alloc(type, 0);\n", sym.cpyname)
+	g.impl.Printf("if (self == NULL) {\n")
+	g.impl.Indent()
+	g.impl.Printf("return NULL;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+	g.impl.Printf("ibuf = cgopy_seq_buffer_new();\n")
+	g.impl.Printf("obuf = cgopy_seq_buffer_new();\n")
 
-	g.impl.Printf("cgopy_seq_send(%q, %d, ibuf->buf, ibuf->len, &obuf->buf, &obuf->len);\n\n",
-		f.Descriptor(),
-		uhash(f.ID()),
-	)
-	g.impl.Printf("self->cgopy = cgopy_seq_buffer_read_int32(obuf);\n")
+	g.genSend(f.Descriptor(), f.ID(), false)
+	g.impl.Printf("\n")
+	g.genRead("self->cgopy", "obuf", sym.GoType())
 	//g.impl.Printf("self->eface = (gopy_efacefunc)cgo_func_%s_eface;\n", sym.id)
+	g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
+	g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
+	if typ.isSingleton() {
+		g.impl.Printf("if (type == &%[1]sType) {\n", sym.cpyname)
+		g.impl.Indent()
+		g.impl.Printf("Py_INCREF(self);\n")
+		g.impl.Printf("cpy_func_%s_singleton = (PyObject*)self;\n", sym.id)
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+	}
 	g.impl.Printf("return (PyObject*)self;\n")
 	g.impl.Outdent()
 	g.impl.Printf("}\n\n")
@@ -176,9 +286,22 @@ func (g *cpyGen) genTypeDealloc(typ Type) {
 	)
 	g.impl.Indent()
 	if !sym.isBasic() {
+		// the go value is released once, by the wrapper holding its
+		// handle, python subclasses inheriting this slot.
+		g.impl.Printf("if (self->cgopy != 0) {\n")
+		g.impl.Indent()
 		g.impl.Printf("cgopy_seq_destroy_ref(self->cgopy);\n")
+		g.impl.Printf("self->cgopy = 0;\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+	}
+	if len(typ.bases) > 0 {
+		g.impl.Printf("if (self->weakrefs != NULL) {\n")
+		g.impl.Printf("\tPyObject_ClearWeakRefs((PyObject*)self);\n")
+		g.impl.Printf("}\n")
+		g.impl.Printf("Py_CLEAR(self->dict);\n")
 	}
-	g.impl.Printf("self->ob_type->tp_free((PyObject*)self);\n")
+	g.impl.Printf("Py_TYPE(self)->tp_free((PyObject*)self);\n")
 	g.impl.Outdent()
 	g.impl.Printf("}\n\n")
 }
@@ -227,6 +350,17 @@ func (g *cpyGen) genTypeInit(typ Type) {
 	g.impl.Outdent()
 	g.impl.Printf("}\n\n")
 
+	if lock := typ.itemLock(); lock != "" {
+		// items holding a lock cannot be copied in.
+		g.impl.Printf("if ((nkwds + nargs) > 0) {\n")
+		g.impl.Indent()
+		g.impl.Printf("PyErr_SetString(PyExc_TypeError, ")
+		g.impl.Printf("\"%s.__init__ takes no argument: its items hold a %s\");\n", sym.goname, lock)
+		g.impl.Printf("goto cpy_label_%s_init_fail;\n", sym.id)
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n")
+	}
+
 	g.impl.Printf("if (!PyArg_ParseTupleAndKeywords(args, kwds, ")
 	format := []string{"|O"}
 	addrs := []string{"&arg"}
@@ -254,6 +388,10 @@ func (g *cpyGen) genTypeInit(typ Type) {
 		g.impl.Outdent()
 		g.impl.Printf("}\n\n")
 
+		if typ.isStrEnum() {
+			g.genTypeInitEnum(typ)
+		}
+
 		g.impl.Outdent()
 		g.impl.Printf("}\n\n")
 
@@ -318,6 +456,10 @@ func (g *cpyGen) genTypeInit(typ Type) {
 		g.impl.Printf("}\n\n") // if-arg
 
 	case sym.isSlice():
+		if typ.itemLock() != "" {
+			// no items are appended: arguments were refused.
+			break
+		}
 		g.impl.Printf("if (arg != NULL) {\n")
 		g.impl.Indent()
 
@@ -329,26 +471,136 @@ func (g *cpyGen) genTypeInit(typ Type) {
 		g.impl.Outdent()
 		g.impl.Printf("}\n\n")
 
-		g.impl.Printf("if (!cpy_func_%[1]s_inplace_concat(self, arg)) {\n", sym.id)
+		if isByteSeqType(sym.GoType()) {
+			// bytes are appended as the ints they hold, as in python 3,
+			// also in python 2, where their items are str.
+			g.impl.Printf("if (PyBytes_Check(arg)) {\n")
+			g.impl.Indent()
+			g.impl.Printf("arg = PyByteArray_FromObject(arg);\n")
+			g.impl.Printf("if (arg == NULL) {\n")
+			g.impl.Indent()
+			g.impl.Printf("goto cpy_label_%s_init_fail;\n", sym.id)
+			g.impl.Outdent()
+			g.impl.Printf("}\n")
+			g.impl.Outdent()
+			g.impl.Printf("} else {\n")
+			g.impl.Indent()
+			g.impl.Printf("Py_INCREF(arg);\n")
+			g.impl.Outdent()
+			g.impl.Printf("}\n")
+			g.impl.Printf("PyObject *res = cpy_func_%[1]s_inplace_concat(self, arg);\n", sym.id)
+			g.impl.Printf("Py_DECREF(arg);\n")
+		} else {
+			g.impl.Printf("PyObject *res = cpy_func_%[1]s_inplace_concat(self, arg);\n", sym.id)
+		}
+		g.impl.Printf("if (res == NULL) {\n")
 		g.impl.Indent()
 		g.impl.Printf("goto cpy_label_%s_init_fail;\n", sym.id)
 		g.impl.Outdent()
-		g.impl.Printf("}\n\n")
+		g.impl.Printf("}\n")
+		g.impl.Printf("Py_DECREF(res);\n\n")
 		g.impl.Outdent()
 		g.impl.Printf("}\n\n") // if-arg
 
 	case sym.isMap():
 		g.impl.Printf("if (arg != NULL) {\n")
 		g.impl.Indent()
-		//g.impl.Printf("//put map __init__ functions here")
+		// strings are mappings too, indexed by slices.
+		g.impl.Printf("if (!PyMapping_Check(arg) || PySequence_Check(arg)) {\n")
+		g.impl.Indent()
+		g.impl.Printf("PyErr_SetString(PyExc_TypeError, ")
+		g.impl.Printf("\"%s.__init__ takes a mapping as argument\");\n", sym.goname)
+		g.impl.Printf("goto cpy_label_%s_init_fail;\n", sym.id)
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n")
+
+		g.impl.Printf("PyObject *items = PyMapping_Items(arg);\n")
+		g.impl.Printf("if (items == NULL) {\n")
+		g.impl.Indent()
+		g.impl.Printf("goto cpy_label_%s_init_fail;\n", sym.id)
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n")
+
+		g.impl.Printf("Py_ssize_t i = 0;\n")
+		g.impl.Printf("for (i = 0; i < PyList_GET_SIZE(items); i++) {\n")
+		g.impl.Indent()
+		g.impl.Printf("PyObject *item = PyList_GET_ITEM(items, i);\n")
+		g.impl.Printf("if (cpy_func_%[1]s_mp_ass_subscript(self, PyTuple_GET_ITEM(item, 0), PyTuple_GET_ITEM(item, 1))) {\n", sym.id)
+		g.impl.Indent()
+		g.impl.Printf("cgopy_err_item(PyTuple_GET_ITEM(item, 0));\n")
+		g.impl.Printf("Py_DECREF(items);\n")
+		g.impl.Printf("goto cpy_label_%s_init_fail;\n", sym.id)
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+		g.impl.Printf("Py_DECREF(items);\n")
 		g.impl.Outdent()
 		g.impl.Printf("}\n\n") // if-arg
 
 	case sym.isSignature():
-		//TODO(sbinet)
+		if !typ.isWrappingCallables() {
+			//TODO(sbinet)
+			break
+		}
+		g.impl.Printf("if (arg != NULL) {\n")
+		g.impl.Indent()
+		g.impl.Printf("if (!PyCallable_Check(arg)) {\n")
+		g.impl.Indent()
+		g.impl.Printf("PyErr_SetString(PyExc_TypeError, ")
+		g.impl.Printf("\"%s.__init__ takes a callable as argument\");\n", sym.goname)
+		g.impl.Printf("goto cpy_label_%s_init_fail;\n", sym.id)
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n")
+
+		// the go func made from the callable replaces the nil one
+		// created by tp_new. it holds a reference to the callable.
+		wrap := typ.funcs.wrap
+		g.impl.Printf("cgopy_seq_buffer ibuf = cgopy_seq_buffer_new();\n")
+		g.impl.Printf("cgopy_seq_buffer obuf = cgopy_seq_buffer_new();\n")
+		g.impl.Printf("Py_INCREF(arg);\n")
+		g.impl.Printf("cgopy_seq_buffer_write_uint64(ibuf, (uint64_t)(uintptr_t)arg);\n")
+		g.genSend(wrap.Descriptor(), wrap.ID(), false)
+		g.impl.Printf("cgopy_seq_destroy_ref(self->cgopy);\n")
+		g.genRead("self->cgopy", "obuf", sym.GoType())
+		g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
+		g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n") // if-arg
+
+	case sym.isChan():
+		// channels are created unbuffered, by tp_new.
 
 	case sym.isInterface():
 		//TODO(sbinet): check the argument implements the interface.
+		if !typ.isWrappingFiles() {
+			break
+		}
+		meth := streamMethod(sym.GoType())
+		g.impl.Printf("if (arg != NULL) {\n")
+		g.impl.Indent()
+		g.impl.Printf("if (!PyObject_HasAttrString(arg, %q)) {\n", meth)
+		g.impl.Indent()
+		g.impl.Printf("PyErr_SetString(PyExc_TypeError, ")
+		g.impl.Printf("\"%s.__init__ takes a file object with a %s method\");\n", sym.goname, meth)
+		g.impl.Printf("goto cpy_label_%s_init_fail;\n", sym.id)
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n")
+
+		// the go value made from the file object replaces the nil one
+		// created by tp_new. it holds a reference to the file object.
+		file := typ.funcs.file
+		g.impl.Printf("cgopy_seq_buffer ibuf = cgopy_seq_buffer_new();\n")
+		g.impl.Printf("cgopy_seq_buffer obuf = cgopy_seq_buffer_new();\n")
+		g.impl.Printf("Py_INCREF(arg);\n")
+		g.impl.Printf("cgopy_seq_buffer_write_uint64(ibuf, (uint64_t)(uintptr_t)arg);\n")
+		g.genSend(file.Descriptor(), file.ID(), false)
+		g.impl.Printf("cgopy_seq_destroy_ref(self->cgopy);\n")
+		g.genRead("self->cgopy", "obuf", sym.GoType())
+		g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
+		g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n") // if-arg
 
 	default:
 		panic(fmt.Errorf(
@@ -362,7 +614,7 @@ func (g *cpyGen) genTypeInit(typ Type) {
 
 	g.impl.Printf("\ncpy_label_%s_init_fail:\n", sym.id)
 	g.impl.Indent()
-	g.impl.Printf("Py_XDECREF(arg);\n")
+	// arg is borrowed from args or kwds.
 	g.impl.Printf("return -1;\n")
 	g.impl.Outdent()
 	g.impl.Printf("}\n\n")
@@ -376,147 +628,981 @@ func (g *cpyGen) genTypeMembers(typ Type) {
 	}
 
 	g.decl.Printf("\n/* tp_getset for %s */\n", sym.gofmt())
+	if len(typ.consts) > 0 {
+		g.genTypeConstName(typ)
+	}
+	if typ.isStrEnum() {
+		g.genTypeEnumValue(typ)
+	}
 	g.impl.Printf("\n/* tp_getset for %s */\n", sym.gofmt())
 	g.impl.Printf("static PyGetSetDef %s_getsets[] = {\n", sym.cpyname)
 	g.impl.Indent()
+	if len(typ.consts) > 0 {
+		g.impl.Printf("{\"name\", (getter)cpy_func_%[1]s_getter_name, (setter)NULL, %[2]q, NULL},\n",
+			sym.id,
+			"name of the const holding the value, or None",
+		)
+	}
+	if typ.isStrEnum() {
+		g.impl.Printf("{\"value\", (getter)cpy_func_%[1]s_getter_value, (setter)NULL, %[2]q, NULL},\n",
+			sym.id,
+			"value held, as a str",
+		)
+	}
 	g.impl.Printf("{NULL} /* Sentinel */\n")
 	g.impl.Outdent()
 	g.impl.Printf("};\n\n")
 }
 
-func (g *cpyGen) genTypeMethods(typ Type) {
+// genTypeConstName generates the getter of the name of the const holding
+// the value of a named basic type.
+func (g *cpyGen) genTypeConstName(typ Type) {
 	sym := typ.sym
-	g.decl.Printf("\n/* methods for %s */\n", sym.gofmt())
-	if sym.isNamed() {
-		typ := sym.GoType().(*types.Named)
-		for imeth := 0; imeth < typ.NumMethods(); imeth++ {
-			m := typ.Method(imeth)
-			if !m.Exported() {
-				continue
-			}
-			mname := types.ObjectString(m, nil)
-			msym := g.pkg.syms.sym(mname)
-			if msym == nil {
-				panic(fmt.Errorf(
-					"gopy: could not find symbol for [%[1]T] (%#[1]v) (%[2]s)",
-					m.Type(),
-					m.Name()+" || "+m.FullName(),
-				))
-			}
-			g._genFunc(sym, msym)
-		}
-	}
-	g.impl.Printf("\n/* methods for %s */\n", sym.gofmt())
-	g.impl.Printf("static PyMethodDef %s_methods[] = {\n", sym.cpyname)
+	g.genMethod(typ, typ.funcs.name)
+
+	g.decl.Printf("\n/* getter for %[1]s.name */\n", sym.gofmt())
+	g.decl.Printf("static PyObject*\n")
+	g.decl.Printf("cpy_func_%[1]s_getter_name(%[2]s *self, void *closure);\n",
+		sym.id,
+		sym.cpyname,
+	)
+
+	g.impl.Printf("\n/* getter for %[1]s.name */\n", sym.gofmt())
+	g.impl.Printf("static PyObject*\n")
+	g.impl.Printf("cpy_func_%[1]s_getter_name(%[2]s *self, void *closure) {\n",
+		sym.id,
+		sym.cpyname,
+	)
 	g.impl.Indent()
-	if sym.isNamed() {
-		typ := sym.GoType().(*types.Named)
-		for imeth := 0; imeth < typ.NumMethods(); imeth++ {
-			m := typ.Method(imeth)
-			if !m.Exported() {
-				continue
-			}
-			mname := types.ObjectString(m, nil)
-			msym := g.pkg.syms.sym(mname)
-			margs := "METH_VARARGS"
-			sig := m.Type().Underlying().(*types.Signature)
-			if sig.Params() == nil || sig.Params().Len() <= 0 {
-				margs = "METH_NOARGS"
-			}
-			g.impl.Printf(
-				"{%[1]q, (PyCFunction)cpy_func_%[2]s, %[3]s, %[4]q},\n",
-				msym.goname,
-				msym.id,
-				margs,
-				msym.doc,
-			)
-		}
-	}
-	g.impl.Printf("{NULL} /* sentinel */\n")
+	g.impl.Printf("PyObject *name = cpy_func_%[1]s(self, NULL);\n", typ.funcs.name.ID())
+	g.impl.Printf("if (name != NULL && PyString_Size(name) == 0) {\n")
+	g.impl.Indent()
+	g.impl.Printf("/* not the value of a const */\n")
+	g.impl.Printf("Py_DECREF(name);\n")
+	g.impl.Printf("Py_RETURN_NONE;\n")
 	g.impl.Outdent()
-	g.impl.Printf("};\n\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("return name;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
 }
 
-func (g *cpyGen) genTypeProtocols(typ Type) {
+// genTypeInitEnum generates the check that the value of a string enum,
+// created from python, is the value of one of its consts.
+func (g *cpyGen) genTypeInitEnum(typ Type) {
 	sym := typ.sym
-	g.genTypeTPStr(typ)
-	if sym.isSlice() || sym.isArray() {
-		g.genTypeTPAsSequence(typ)
-		g.genTypeTPAsBuffer(typ)
+	g.impl.Printf("/* %s is an enum: its values are the ones of its consts */\n", sym.goname)
+	g.impl.Printf("{\n")
+	g.impl.Indent()
+	g.impl.Printf("PyObject *name = cpy_func_%s(self, NULL);\n", typ.funcs.name.ID())
+	g.impl.Printf("if (name == NULL) {\n")
+	g.impl.Printf("\tgoto cpy_label_%s_init_fail;\n", sym.id)
+	g.impl.Printf("}\n")
+	g.impl.Printf("if (PyString_Size(name) == 0) {\n")
+	g.impl.Indent()
+	g.impl.Printf("PyObject *repr = PyObject_Repr(arg);\n")
+	g.impl.Printf("if (repr != NULL) {\n")
+	g.impl.Printf("\tPyErr_Format(PyExc_ValueError, \"%%s is not a valid %s\", PyString_AsString(repr));\n", sym.goname)
+	g.impl.Printf("\tPy_DECREF(repr);\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("Py_DECREF(name);\n")
+	g.impl.Printf("goto cpy_label_%s_init_fail;\n", sym.id)
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Printf("Py_DECREF(name);\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+}
+
+// genTypeFactory generates the _new_<Type> module function, rebuilding a
+// value of the type from its state, as given to the python type itself.
+// As a module-level function, it can be referred to by its qualified name,
+// as __reduce__ and the pickle protocol need.
+func (g *cpyGen) genTypeFactory(typ Type) {
+	sym := typ.sym
+	g.impl.Printf("\n/* _new_%s rebuilds a %s from its state */\n", sym.goname, sym.gofmt())
+	g.impl.Printf("static PyObject*\ncpy_func_%s__new(PyObject *self, PyObject *state) {\n", sym.id)
+	g.impl.Indent()
+	if !sym.isStruct() {
+		g.impl.Printf("return PyObject_CallFunctionObjArgs((PyObject*)&%sType, state, NULL);\n", sym.cpyname)
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n")
+		return
 	}
-	if sym.isSignature() {
-		g.genTypeTPCall(typ)
+	g.impl.Printf("PyObject *args = NULL;\n")
+	g.impl.Printf("PyObject *res = NULL;\n")
+	g.impl.Printf("if (!PyDict_Check(state)) {\n")
+	g.impl.Indent()
+	g.impl.Printf("PyErr_SetString(PyExc_TypeError, \"_new_%s takes a dict of field values\");\n", sym.goname)
+	g.impl.Printf("return NULL;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Printf("args = PyTuple_New(0);\n")
+	g.impl.Printf("if (args == NULL) {\n")
+	g.impl.Printf("\treturn NULL;\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("res = PyObject_Call((PyObject*)&%sType, args, state);\n", sym.cpyname)
+	g.impl.Printf("Py_DECREF(args);\n")
+	g.impl.Printf("return res;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
+
+// factoryDoc returns the docstring of the _new_<Type> function of typ.
+func factoryDoc(typ Type) string {
+	name := typ.sym.goname
+	var state string
+	switch typ.GoType().Underlying().(type) {
+	case *types.Struct:
+		state = "a dict of its field values"
+	case *types.Basic:
+		state = "its value"
+	case *types.Map:
+		state = "a mapping of its items"
+	default:
+		state = "a sequence of its items"
 	}
+	return fmt.Sprintf("_new_%[1]s(state) -> %[1]s\n\nRebuilds a %[1]s from its state, %[2]s.", name, state)
 }
 
-func (g *cpyGen) genTypeTPStr(typ Type) {
+// genTypeEnumValue generates the getter of the value of a string enum, as
+// a plain python str.
+func (g *cpyGen) genTypeEnumValue(typ Type) {
 	sym := typ.sym
-	f := typ.funcs.str
-	g.decl.Printf("\n/* __str__ support for %[1]s.%[2]s */\n",
-		f.Package().Name(),
-		sym.goname,
-	)
-	g.decl.Printf(
-		"static PyObject*\ncpy_func_%s_tp_str(PyObject *self);\n",
+	bsym := g.pkg.syms.symtype(sym.GoType().Underlying())
+
+	g.decl.Printf("\n/* getter for %[1]s.value */\n", sym.gofmt())
+	g.decl.Printf("static PyObject*\n")
+	g.decl.Printf("cpy_func_%[1]s_getter_value(%[2]s *self, void *closure);\n",
 		sym.id,
+		sym.cpyname,
 	)
 
-	g.impl.Printf(
-		"static PyObject*\ncpy_func_%s_tp_str(PyObject *self) {\n",
+	g.impl.Printf("\n/* getter for %[1]s.value */\n", sym.gofmt())
+	g.impl.Printf("static PyObject*\n")
+	g.impl.Printf("cpy_func_%[1]s_getter_value(%[2]s *self, void *closure) {\n",
 		sym.id,
+		sym.cpyname,
 	)
-
 	g.impl.Indent()
-	if f != (Func{}) {
-		g.genFuncBody(f)
-	} else {
-		g.impl.Printf("PyObject *pystr = NULL;\n")
-		g.impl.Printf("cgopy_seq_bytearray str;\n")
-		g.impl.Printf("\n")
-		g.impl.Printf("cgopy_seq_buffer ibuf = cgopy_seq_buffer_new();\n")
-		g.impl.Printf("cgopy_seq_buffer obuf = cgopy_seq_buffer_new();\n")
-		g.impl.Printf("\n")
-
-		g.impl.Printf("int32_t c_self = ((%[1]s*)self)->cgopy;\n", sym.cpyname)
-		g.impl.Printf("pystr = cgopy_cnv_c2py_string(&str);\n")
-		g.impl.Printf("\n")
-		g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
-		g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
-		g.impl.Printf("\n")
-		g.impl.Printf("return pystr;\n")
-	}
+	g.impl.Printf("return %s(&self->cgopy);\n", bsym.c2py)
 	g.impl.Outdent()
 	g.impl.Printf("}\n\n")
 }
 
-func (g *cpyGen) genTypeTPAsSequence(typ Type) {
+func (g *cpyGen) genTypeMethods(typ Type) {
 	sym := typ.sym
-	g.decl.Printf("\n/* sequence support for %s */\n", sym.gofmt())
-
-	var arrlen int64
-	var etyp types.Type
-	switch typ := sym.GoType().(type) {
-	case *types.Array:
-		etyp = typ.Elem()
-		arrlen = typ.Len()
-	case *types.Slice:
-		etyp = typ.Elem()
-	case *types.Named:
-		switch typ := typ.Underlying().(type) {
-		case *types.Array:
-			etyp = typ.Elem()
-			arrlen = typ.Len()
-		case *types.Slice:
-			etyp = typ.Elem()
-		default:
-			panic(fmt.Errorf(
-				"gopy: unhandled type [%#v] (go=%s kind=%v)",
-				typ,
-				sym.gofmt(),
-				sym.kind,
-			))
+	g.decl.Printf("\n/* methods for %s */\n", sym.gofmt())
+	for _, m := range typ.meths {
+		g.genMethod(typ, m)
+	}
+	for _, f := range typ.statics {
+		g.decl.Printf("\n/* pythonization of: %s.%s */\n", g.pkg.pkg.Name(), f.GoName())
+		g.decl.Printf("static PyObject*\ncpy_func_%s(PyObject *self, %s);\n", f.ID(), funcParams(f))
+		g.genFunc(f)
+	}
+	g.genTypeTPFormat(typ)
+	zero := g.hasZero(typ)
+	if zero {
+		g.genTypeZero(typ)
+	}
+	g.impl.Printf("\n/* methods for %s */\n", sym.gofmt())
+	g.impl.Printf("static PyMethodDef %s_methods[] = {\n", sym.cpyname)
+	g.impl.Indent()
+	// the methods generated for bytes.Buffer take precedence over the
+	// methods declared in go sharing their python name, e.g. Write once
+	// snake_cased.
+	generated := make(map[string]bool)
+	for _, m := range typ.meths {
+		if m.typ == nil && typ.isBytesBuffer() {
+			generated[g.pyname(m.GoName())] = true
 		}
-	default:
+	}
+	for _, m := range typ.meths {
+		if m.typ != nil && generated[g.pyname(m.GoName())] {
+			continue
+		}
+		margs := methFlags(m)
+		if len(m.Signature().Params()) <= 0 {
+			margs = "METH_NOARGS"
+		}
+		g.impl.Printf(
+			"{%[1]q, (PyCFunction)cpy_func_%[2]s, %[3]s, %[4]q},\n",
+			g.pyname(m.GoName()),
+			m.ID(),
+			margs,
+			g.pydoc(m.GoName(), m.Doc()),
+		)
+	}
+	for _, f := range typ.statics {
+		g.impl.Printf(
+			"{%[1]q, (PyCFunction)cpy_func_%[2]s, %[3]s | METH_STATIC, %[4]q},\n",
+			g.pyname(f.GoName()),
+			f.ID(),
+			methFlags(f),
+			g.pydoc(f.GoName(), f.Doc()),
+		)
+	}
+	if zero {
+		g.impl.Printf(
+			"{\"zero\", (PyCFunction)cpy_func_%[1]s_zero, METH_NOARGS | METH_CLASS, %[2]q},\n",
+			sym.id,
+			fmt.Sprintf(zeroDoc, sym.goname),
+		)
+	}
+	for _, name := range g.mapMethods(typ) {
+		g.impl.Printf(
+			"{%[2]q, (PyCFunction)cpy_func_%[1]s_mp_%[2]s, METH_NOARGS, %[3]q},\n",
+			sym.id,
+			name,
+			mapMethodDocs[name],
+		)
+	}
+	g.impl.Printf(
+		"{\"__format__\", (PyCFunction)cpy_func_%[1]s_tp_format, METH_VARARGS, %[2]q},\n",
+		sym.id,
+		typ.funcs.fmt.Doc(),
+	)
+	g.impl.Printf("{NULL} /* sentinel */\n")
+	g.impl.Outdent()
+	g.impl.Printf("};\n\n")
+}
+
+// mapMethodDocs holds the doc of the methods of maps, returning lists
+// like the ones of python-2 dicts.
+var mapMethodDocs = map[string]string{
+	"keys":   "keys() -> list of the keys, sorted",
+	"items":  "items() -> list of the (key, value) pairs, sorted by key",
+	"values": "values() -> list of the values, sorted by key",
+}
+
+// mapMethods returns the names of the keys, items and values methods of
+// the map type typ, but the ones of its go methods.
+func (g *cpyGen) mapMethods(typ Type) []string {
+	if !typ.sym.isMap() {
+		return nil
+	}
+	var names []string
+	for _, name := range []string{"keys", "items", "values"} {
+		shadowed := false
+		for _, m := range typ.meths {
+			if g.pyname(m.GoName()) == name {
+				shadowed = true
+			}
+		}
+		if !shadowed {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+const zeroDoc = `zero() -> %[1]s
+
+Returns the zero value of %[1]s, without running __init__.
+Called on a subclass, it returns an instance of the subclass.`
+
+// hasZero returns whether the zero classmethod is generated for typ: it is
+// for structs with constructors, whose python users may otherwise not know
+// how to get the plain zero value, unless the type has a method or a field
+// named zero in python.
+func (g *cpyGen) hasZero(typ Type) bool {
+	if !typ.sym.isStruct() || len(typ.ctors) == 0 {
+		return false
+	}
+	for _, m := range typ.meths {
+		if g.pyname(m.GoName()) == "zero" {
+			return false
+		}
+	}
+	for _, f := range typ.statics {
+		if g.pyname(f.GoName()) == "zero" {
+			return false
+		}
+	}
+	s := typ.Struct()
+	for i := 0; i < s.NumFields(); i++ {
+		if f := s.Field(i); typ.isExposedField(f) && g.pyname(f.Name()) == "zero" {
+			return false
+		}
+	}
+	return true
+}
+
+// genTypeZero generates the zero classmethod of typ, creating a value with
+// tp_new alone, which holds the zero value of the go type.
+func (g *cpyGen) genTypeZero(typ Type) {
+	sym := typ.sym
+	g.decl.Printf("\n/* zero value of %s */\n", sym.gofmt())
+	g.decl.Printf("static PyObject*\ncpy_func_%s_zero(PyObject *cls, PyObject *noargs);\n", sym.id)
+
+	g.impl.Printf("\n/* zero value of %s */\n", sym.gofmt())
+	g.impl.Printf("static PyObject*\ncpy_func_%s_zero(PyObject *cls, PyObject *noargs) {\n", sym.id)
+	g.impl.Indent()
+	g.impl.Printf("PyTypeObject *typ = (PyTypeObject*)cls;\n")
+	g.impl.Printf("PyObject *args = PyTuple_New(0);\n")
+	g.impl.Printf("PyObject *o = NULL;\n")
+	g.impl.Printf("if (args == NULL) {\n")
+	g.impl.Printf("\treturn NULL;\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("o = typ->tp_new(typ, args, NULL);\n")
+	g.impl.Printf("Py_DECREF(args);\n")
+	g.impl.Printf("return o;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
+
+func (g *cpyGen) genTypeProtocols(typ Type) {
+	sym := typ.sym
+	g.genTypeTPStr(typ)
+	if typ.prots&ProtoGoStringer != 0 {
+		g.genTypeTPRepr(typ)
+	}
+	if sym.isSlice() || sym.isArray() {
+		g.genTypeTPAsSequence(typ)
+	}
+	if sym.isMap() {
+		g.genTypeTPAsMapping(typ)
+	}
+	if isByteSeqType(sym.GoType()) {
+		g.genTypeTPAsBuffer(typ)
+	}
+	if typ.isIterator() {
+		g.genTypeTPIter(typ)
+	}
+	if typ.isCallable() {
+		g.genTypeTPCall(typ)
+	}
+	if typ.isWrappingCallables() {
+		g.genTypeCallback(typ)
+	}
+	if typ.isWrappingFiles() {
+		g.genTypeFileCallback(typ)
+	}
+	if sym.isInterface() {
+		g.genTypeTPCompare(typ)
+	}
+	if typ.isStrEnum() {
+		g.genTypeEnumCompare(typ)
+	}
+	if sym.isInterface() || typ.isStrEnum() {
+		g.genVersions(func(lang int) {
+			if lang == 3 {
+				g.genTypeCompareRich(typ)
+			}
+		}, g.decl, g.impl)
+	}
+	if isStringType(sym.GoType()) {
+		g.genTypeTPAsString(typ)
+	}
+	if typ.prots&ProtoContainer != 0 {
+		g.genTypeSQContains(typ)
+	}
+	if typ.prots&ProtoSized != 0 {
+		g.genTypeSQLength(typ)
+	}
+	if typ.prots&(ProtoContainer|ProtoSized) != 0 && !sym.isArray() && !sym.isSlice() && !sym.isMap() && !isStringType(sym.GoType()) {
+		// arrays, slices, maps and strings have their own sequence
+		// protocol.
+		g.genTypeTPAsObjSequence(typ)
+	}
+	if isIntegerType(sym.GoType()) {
+		g.genTypeTPAsNumber(typ)
+	}
+}
+
+// nbSlots2 and nbSlots3 list the slots of PyNumberMethods, in order, for
+// python-2 and python-3.
+var (
+	nbSlots2 = []string{
+		"nb_add", "nb_subtract", "nb_multiply", "nb_divide", "nb_remainder",
+		"nb_divmod", "nb_power", "nb_negative", "nb_positive", "nb_absolute",
+		"nb_nonzero", "nb_invert", "nb_lshift", "nb_rshift", "nb_and",
+		"nb_xor", "nb_or", "nb_coerce", "nb_int", "nb_long", "nb_float",
+		"nb_oct", "nb_hex", "nb_inplace_add", "nb_inplace_subtract",
+		"nb_inplace_multiply", "nb_inplace_divide", "nb_inplace_remainder",
+		"nb_inplace_power", "nb_inplace_lshift", "nb_inplace_rshift",
+		"nb_inplace_and", "nb_inplace_xor", "nb_inplace_or",
+		"nb_floor_divide", "nb_true_divide", "nb_inplace_floor_divide",
+		"nb_inplace_true_divide", "nb_index",
+	}
+	nbSlots3 = []string{
+		"nb_add", "nb_subtract", "nb_multiply", "nb_remainder", "nb_divmod",
+		"nb_power", "nb_negative", "nb_positive", "nb_absolute", "nb_bool",
+		"nb_invert", "nb_lshift", "nb_rshift", "nb_and", "nb_xor", "nb_or",
+		"nb_int", "nb_reserved", "nb_float", "nb_inplace_add",
+		"nb_inplace_subtract", "nb_inplace_multiply", "nb_inplace_remainder",
+		"nb_inplace_power", "nb_inplace_lshift", "nb_inplace_rshift",
+		"nb_inplace_and", "nb_inplace_xor", "nb_inplace_or",
+		"nb_floor_divide", "nb_true_divide", "nb_inplace_floor_divide",
+		"nb_inplace_true_divide", "nb_index", "nb_matrix_multiply",
+		"nb_inplace_matrix_multiply",
+	}
+)
+
+// genTypeTPAsNumber generates the number protocol of named integer types.
+// nb_index returns the held integer, so values can be used as list indices,
+// slice bounds, or anywhere python expects an index.
+// python-2 builtins like range() and int() go through nb_int and nb_long
+// instead, which return the same value.
+func (g *cpyGen) genTypeTPAsNumber(typ Type) {
+	sym := typ.sym
+	bsym := g.pkg.syms.symtype(sym.GoType().Underlying())
+
+	g.decl.Printf("\n/* nb_index */\n")
+	g.decl.Printf("static PyObject*\ncpy_func_%[1]s_nb_index(%[2]s *self);\n",
+		sym.id,
+		sym.cpyname,
+	)
+
+	g.impl.Printf("\n/* nb_index */\n")
+	g.impl.Printf("static PyObject*\ncpy_func_%[1]s_nb_index(%[2]s *self) {\n",
+		sym.id,
+		sym.cpyname,
+	)
+	g.impl.Indent()
+	g.impl.Printf("return %s(&self->cgopy);\n", bsym.c2py)
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+
+	index := fmt.Sprintf("(unaryfunc)cpy_func_%[1]s_nb_index", sym.id)
+	impls := map[string]string{
+		"nb_int":   index,
+		"nb_index": index,
+		"nb_long":  index,
+	}
+
+	g.impl.Printf("\n/* tp_as_number */\n")
+	g.impl.Printf("static PyNumberMethods %[1]s_tp_as_number = {\n", sym.cpyname)
+	g.impl.Indent()
+	g.genVersions(func(lang int) {
+		slots := nbSlots3
+		if lang == 2 {
+			slots = nbSlots2
+		}
+		for _, slot := range slots {
+			impl, ok := impls[slot]
+			if !ok {
+				impl = "0"
+			}
+			g.impl.Printf("%s,\t/* %s */\n", impl, slot)
+		}
+	}, g.impl)
+	g.impl.Outdent()
+	g.impl.Printf("};\n\n")
+}
+
+// sqContains returns the sq_contains slot of the sequence protocol of typ.
+// Without it, python falls back to iterating over the items of typ.
+func (g *cpyGen) sqContains(typ Type) string {
+	if typ.prots&ProtoContainer == 0 {
+		return "(objobjproc)0"
+	}
+	return fmt.Sprintf("(objobjproc)cpy_func_%[1]s_sq_contains", typ.sym.id)
+}
+
+// genTypeSQContains generates the sq_contains slot of types with a
+// Contains(x T) bool method, so "x in o" calls o.Contains(x).
+func (g *cpyGen) genTypeSQContains(typ Type) {
+	sym := typ.sym
+	var m Func
+	for _, meth := range typ.meths {
+		if meth.GoName() == "Contains" {
+			m = meth
+		}
+	}
+
+	g.decl.Printf("\n/* sq_contains */\n")
+	g.decl.Printf("static int\n")
+	g.decl.Printf("cpy_func_%[1]s_sq_contains(%[2]s *self, PyObject *value);\n",
+		sym.id,
+		sym.cpyname,
+	)
+
+	g.impl.Printf("\n/* sq_contains */\n")
+	g.impl.Printf("static int\n")
+	g.impl.Printf("cpy_func_%[1]s_sq_contains(%[2]s *self, PyObject *value) {\n",
+		sym.id,
+		sym.cpyname,
+	)
+	g.impl.Indent()
+	g.impl.Printf("int ok = -1;\n")
+	g.impl.Printf("PyObject *res = NULL;\n")
+	g.impl.Printf("PyObject *args = PyTuple_Pack(1, value);\n")
+	g.impl.Printf("if (args == NULL) {\n")
+	g.impl.Indent()
+	g.impl.Printf("return -1;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	if hasKwargs(m) {
+		g.impl.Printf("res = cpy_func_%[1]s(self, args, NULL);\n", m.ID())
+	} else {
+		g.impl.Printf("res = cpy_func_%[1]s(self, args);\n", m.ID())
+	}
+	g.impl.Printf("Py_DECREF(args);\n")
+	g.impl.Printf("if (res == NULL) {\n")
+	g.impl.Indent()
+	g.impl.Printf("return -1;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Printf("ok = PyObject_IsTrue(res);\n")
+	g.impl.Printf("Py_DECREF(res);\n")
+	g.impl.Printf("return ok;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
+
+// sqLength returns the sq_length slot of the sequence protocol of typ.
+func (g *cpyGen) sqLength(typ Type) string {
+	if typ.prots&ProtoSized == 0 {
+		return "(lenfunc)0"
+	}
+	return fmt.Sprintf("(lenfunc)cpy_func_%[1]s_sq_length", typ.sym.id)
+}
+
+// genTypeSQLength generates the sq_length slot of bytes.Buffer, so len(o)
+// calls o.Len().
+func (g *cpyGen) genTypeSQLength(typ Type) {
+	sym := typ.sym
+	var m Func
+	for _, meth := range typ.meths {
+		if meth.GoName() == "Len" {
+			m = meth
+		}
+	}
+
+	g.decl.Printf("\n/* sq_length */\n")
+	g.decl.Printf("static Py_ssize_t\n")
+	g.decl.Printf("cpy_func_%[1]s_sq_length(%[2]s *self);\n",
+		sym.id,
+		sym.cpyname,
+	)
+
+	g.impl.Printf("\n/* sq_length */\n")
+	g.impl.Printf("static Py_ssize_t\n")
+	g.impl.Printf("cpy_func_%[1]s_sq_length(%[2]s *self) {\n",
+		sym.id,
+		sym.cpyname,
+	)
+	g.impl.Indent()
+	g.impl.Printf("Py_ssize_t n = -1;\n")
+	if hasKwargs(m) {
+		g.impl.Printf("PyObject *res = cpy_func_%[1]s(self, NULL, NULL);\n", m.ID())
+	} else {
+		g.impl.Printf("PyObject *res = cpy_func_%[1]s(self, NULL);\n", m.ID())
+	}
+	g.impl.Printf("if (res == NULL) {\n")
+	g.impl.Indent()
+	g.impl.Printf("return -1;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Printf("n = PyNumber_AsSsize_t(res, PyExc_OverflowError);\n")
+	g.impl.Printf("Py_DECREF(res);\n")
+	g.impl.Printf("return n;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
+
+// genTypeTPAsObjSequence generates the sequence protocol of the types which
+// are not sequences, but have a Contains method or a length.
+func (g *cpyGen) genTypeTPAsObjSequence(typ Type) {
+	sym := typ.sym
+	g.impl.Printf("\n/* tp_as_sequence */\n")
+	g.impl.Printf("static PySequenceMethods %[1]s_tp_as_sequence = {\n", sym.cpyname)
+	g.impl.Indent()
+	g.impl.Printf("%s,\n", g.sqLength(typ))     // sq_length
+	g.impl.Printf("(binaryfunc)0,\n")           // sq_concat
+	g.impl.Printf("(ssizeargfunc)0,\n")         // sq_repeat
+	g.impl.Printf("(ssizeargfunc)0,\n")         // sq_item
+	g.impl.Printf("(ssizessizeargfunc)0,\n")    // sq_slice
+	g.impl.Printf("(ssizeobjargproc)0,\n")      // sq_ass_item
+	g.impl.Printf("(ssizessizeobjargproc)0,\n") // sq_ass_slice
+	g.impl.Printf("%s,\n", g.sqContains(typ))   // sq_contains
+	g.impl.Printf("(binaryfunc)0,\n")           // sq_inplace_concat
+	g.impl.Printf("(ssizeargfunc)0\n")          // sq_inplace_repeat
+	g.impl.Outdent()
+	g.impl.Printf("};\n\n")
+}
+
+// genTypeTPAsString generates the sequence and mapping protocols of named
+// string types.
+// Strings are indexed by runes: the held UTF-8 bytes are decoded into a
+// unicode object and the items and slices are encoded back into str.
+func (g *cpyGen) genTypeTPAsString(typ Type) {
+	sym := typ.sym
+	g.decl.Printf("\n/* string support for %s */\n", sym.gofmt())
+
+	switch g.lang {
+	case 0, 2:
+		g.decl.Printf("static PyObject*\ncpy_func_%[1]s_unicode(%[2]s *self);\n",
+			sym.id,
+			sym.cpyname,
+		)
+		g.impl.Printf("\n/* decodes the runes of %s */\n", sym.gofmt())
+		g.impl.Printf("static PyObject*\ncpy_func_%[1]s_unicode(%[2]s *self) {\n",
+			sym.id,
+			sym.cpyname,
+		)
+		g.impl.Indent()
+		g.impl.Printf("return PyUnicode_DecodeUTF8(")
+		g.impl.Printf("(const char*)(self->cgopy.Data), (Py_ssize_t)(self->cgopy.Len), \"replace\");\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n")
+
+		g.decl.Printf("\n/* len */\n")
+		g.decl.Printf("static Py_ssize_t\ncpy_func_%[1]s_len(%[2]s *self);\n",
+			sym.id,
+			sym.cpyname,
+		)
+		g.impl.Printf("\n/* len */\n")
+		g.impl.Printf("static Py_ssize_t\ncpy_func_%[1]s_len(%[2]s *self) {\n",
+			sym.id,
+			sym.cpyname,
+		)
+		g.impl.Indent()
+		g.impl.Printf("PyObject *u = cpy_func_%[1]s_unicode(self);\n", sym.id)
+		g.impl.Printf("if (u == NULL) { return -1; }\n")
+		g.impl.Printf("Py_ssize_t n = PyUnicode_GET_SIZE(u);\n")
+		g.impl.Printf("Py_DECREF(u);\n")
+		g.impl.Printf("return n;\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n")
+
+		g.decl.Printf("\n/* item */\n")
+		g.decl.Printf("static PyObject*\ncpy_func_%[1]s_item(%[2]s *self, Py_ssize_t i);\n",
+			sym.id,
+			sym.cpyname,
+		)
+		g.impl.Printf("\n/* item */\n")
+		g.impl.Printf("static PyObject*\ncpy_func_%[1]s_item(%[2]s *self, Py_ssize_t i) {\n",
+			sym.id,
+			sym.cpyname,
+		)
+		g.impl.Indent()
+		g.impl.Printf("PyObject *u = cpy_func_%[1]s_unicode(self);\n", sym.id)
+		g.impl.Printf("if (u == NULL) { return NULL; }\n")
+		g.impl.Printf("PyObject *item = PySequence_GetItem(u, i);\n")
+		g.impl.Printf("Py_DECREF(u);\n")
+		g.impl.Printf("if (item == NULL) { return NULL; }\n")
+		g.impl.Printf("PyObject *str = PyUnicode_AsUTF8String(item);\n")
+		g.impl.Printf("Py_DECREF(item);\n")
+		g.impl.Printf("return str;\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n")
+
+		g.decl.Printf("\n/* subscript */\n")
+		g.decl.Printf("static PyObject*\ncpy_func_%[1]s_subscript(%[2]s *self, PyObject *key);\n",
+			sym.id,
+			sym.cpyname,
+		)
+		g.impl.Printf("\n/* subscript */\n")
+		g.impl.Printf("static PyObject*\ncpy_func_%[1]s_subscript(%[2]s *self, PyObject *key) {\n",
+			sym.id,
+			sym.cpyname,
+		)
+		g.impl.Indent()
+		g.impl.Printf("PyObject *u = cpy_func_%[1]s_unicode(self);\n", sym.id)
+		g.impl.Printf("if (u == NULL) { return NULL; }\n")
+		g.impl.Printf("PyObject *item = PyObject_GetItem(u, key);\n")
+		g.impl.Printf("Py_DECREF(u);\n")
+		g.impl.Printf("if (item == NULL) { return NULL; }\n")
+		g.impl.Printf("PyObject *str = PyUnicode_AsUTF8String(item);\n")
+		g.impl.Printf("Py_DECREF(item);\n")
+		g.impl.Printf("return str;\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n")
+
+		g.impl.Printf("\n/* tp_as_sequence */\n")
+		g.impl.Printf("static PySequenceMethods %[1]s_tp_as_sequence = {\n", sym.cpyname)
+		g.impl.Indent()
+		g.impl.Printf("(lenfunc)cpy_func_%[1]s_len,\n", sym.id)
+		g.impl.Printf("(binaryfunc)0,\n")   // sq_concat
+		g.impl.Printf("(ssizeargfunc)0,\n") // sq_repeat
+		g.impl.Printf("(ssizeargfunc)cpy_func_%[1]s_item,\n", sym.id)
+		g.impl.Printf("(ssizessizeargfunc)0,\n")    // sq_slice
+		g.impl.Printf("(ssizeobjargproc)0,\n")      // sq_ass_item
+		g.impl.Printf("(ssizessizeobjargproc)0,\n") // sq_ass_slice
+		g.impl.Printf("%s,\n", g.sqContains(typ))   // sq_contains
+		g.impl.Printf("(binaryfunc)0,\n")           // sq_inplace_concat
+		g.impl.Printf("(ssizeargfunc)0\n")          // sq_inplace_repeat
+		g.impl.Outdent()
+		g.impl.Printf("};\n\n")
+
+		g.impl.Printf("\n/* tp_as_mapping */\n")
+		g.impl.Printf("static PyMappingMethods %[1]s_tp_as_mapping = {\n", sym.cpyname)
+		g.impl.Indent()
+		g.impl.Printf("(lenfunc)cpy_func_%[1]s_len,\n", sym.id)
+		g.impl.Printf("(binaryfunc)cpy_func_%[1]s_subscript,\n", sym.id)
+		g.impl.Printf("(objobjargproc)0\n") // mp_ass_subscript
+		g.impl.Outdent()
+		g.impl.Printf("};\n\n")
+
+	case 3:
+	}
+}
+
+// genTypeTPCompare compares the go values held by two interface values.
+// Equal go values share the same handle, so that sentinel values (e.g. errors)
+// can be compared from python.
+func (g *cpyGen) genTypeTPCompare(typ Type) {
+	sym := typ.sym
+	g.decl.Printf("\n/* tp_compare for %s */\n", sym.gofmt())
+	g.decl.Printf("static int\n")
+	g.decl.Printf(
+		"cpy_func_%[1]s_compare(%[2]s *self, %[2]s *other);\n",
+		sym.id,
+		sym.cpyname,
+	)
+
+	g.impl.Printf("\n/* tp_compare for %s */\n", sym.gofmt())
+	g.impl.Printf("static int\n")
+	g.impl.Printf(
+		"cpy_func_%[1]s_compare(%[2]s *self, %[2]s *other) {\n",
+		sym.id,
+		sym.cpyname,
+	)
+	g.impl.Indent()
+	g.impl.Printf("if (self->cgopy == other->cgopy) {\n")
+	g.impl.Indent()
+	g.impl.Printf("return 0;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Printf("return (self->cgopy < other->cgopy) ? -1 : 1;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
+
+// genTypeCompareRich generates the tp_richcompare of the types compared
+// by their tp_compare, which python-3 does not have. Values of other types
+// are left to python.
+func (g *cpyGen) genTypeCompareRich(typ Type) {
+	sym := typ.sym
+	g.decl.Printf("\n/* tp_richcompare for %s */\n", sym.gofmt())
+	g.decl.Printf("static PyObject*\n")
+	g.decl.Printf("cpy_func_%[1]s_richcompare(PyObject *self, PyObject *other, int op);\n", sym.id)
+
+	g.impl.Printf("\n/* tp_richcompare for %s */\n", sym.gofmt())
+	g.impl.Printf("static PyObject*\n")
+	g.impl.Printf("cpy_func_%[1]s_richcompare(PyObject *self, PyObject *other, int op) {\n", sym.id)
+	g.impl.Indent()
+	g.impl.Printf("if (!cpy_func_%s_check(other)) {\n", sym.id)
+	g.impl.Indent()
+	g.impl.Printf("Py_INCREF(Py_NotImplemented);\n")
+	g.impl.Printf("return Py_NotImplemented;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Printf("return cgopy_richcompare(cpy_func_%[1]s_compare((%[2]s*)self, (%[2]s*)other), op);\n",
+		sym.id,
+		sym.cpyname,
+	)
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
+
+// genTypeDeepEq generates the == and != operators of the structs annotated
+// with //gopy:deepeq, comparing the go values field by field. Other
+// comparisons, and comparisons with values of other types, are left to
+// python. The values are not hashable: they are mutable.
+func (g *cpyGen) genTypeDeepEq(typ Type) {
+	sym := typ.sym
+	g.genMethod(typ, typ.funcs.eq)
+
+	g.decl.Printf("\n/* tp_richcompare for %s */\n", sym.gofmt())
+	g.decl.Printf("static PyObject*\n")
+	g.decl.Printf("cpy_func_%[1]s_richcompare(PyObject *self, PyObject *other, int op);\n", sym.id)
+
+	g.impl.Printf("\n/* tp_richcompare for %s */\n", sym.gofmt())
+	g.impl.Printf("static PyObject*\n")
+	g.impl.Printf("cpy_func_%[1]s_richcompare(PyObject *self, PyObject *other, int op) {\n", sym.id)
+	g.impl.Indent()
+	g.impl.Printf("PyObject *args = NULL;\n")
+	g.impl.Printf("PyObject *eq = NULL;\n")
+	g.impl.Printf("int ne = 0;\n")
+	g.impl.Printf("if ((op != Py_EQ && op != Py_NE) || !cpy_func_%s_check(other)) {\n", sym.id)
+	g.impl.Indent()
+	g.impl.Printf("Py_INCREF(Py_NotImplemented);\n")
+	g.impl.Printf("return Py_NotImplemented;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Printf("args = PyTuple_Pack(1, other);\n")
+	g.impl.Printf("if (args == NULL) {\n")
+	g.impl.Printf("\treturn NULL;\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("eq = cpy_func_%[1]s((%[2]s*)self, args);\n", typ.funcs.eq.ID(), sym.cpyname)
+	g.impl.Printf("Py_DECREF(args);\n")
+	g.impl.Printf("if (eq == NULL || op == Py_EQ) {\n")
+	g.impl.Printf("\treturn eq;\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("ne = PyObject_Not(eq);\n")
+	g.impl.Printf("Py_DECREF(eq);\n")
+	g.impl.Printf("if (ne < 0) {\n")
+	g.impl.Printf("\treturn NULL;\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("return PyBool_FromLong(ne);\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
+
+// genTypeEnumCompare compares the values of string enums, so that the
+// values created from python equal the consts, and hashes them like their
+// value, so they can be used as dict keys.
+func (g *cpyGen) genTypeEnumCompare(typ Type) {
+	sym := typ.sym
+	g.decl.Printf("\n/* tp_compare for %s */\n", sym.gofmt())
+	g.decl.Printf("static int\n")
+	g.decl.Printf(
+		"cpy_func_%[1]s_compare(%[2]s *self, %[2]s *other);\n",
+		sym.id,
+		sym.cpyname,
+	)
+	g.decl.Printf("\n/* tp_hash for %s */\n", sym.gofmt())
+	g.decl.Printf("static long\n")
+	g.decl.Printf("cpy_func_%[1]s_hash(%[2]s *self);\n", sym.id, sym.cpyname)
+
+	g.impl.Printf("\n/* tp_compare for %s */\n", sym.gofmt())
+	g.impl.Printf("static int\n")
+	g.impl.Printf(
+		"cpy_func_%[1]s_compare(%[2]s *self, %[2]s *other) {\n",
+		sym.id,
+		sym.cpyname,
+	)
+	g.impl.Indent()
+	g.impl.Printf("GoInt n = self->cgopy.Len < other->cgopy.Len ? self->cgopy.Len : other->cgopy.Len;\n")
+	g.impl.Printf("int c = memcmp(self->cgopy.Data, other->cgopy.Data, n);\n")
+	g.impl.Printf("if (c == 0 && self->cgopy.Len != other->cgopy.Len) {\n")
+	g.impl.Printf("\tc = (self->cgopy.Len < other->cgopy.Len) ? -1 : 1;\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("return (c < 0) ? -1 : (c > 0);\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+
+	g.impl.Printf("\n/* tp_hash for %s */\n", sym.gofmt())
+	g.impl.Printf("static long\n")
+	g.impl.Printf("cpy_func_%[1]s_hash(%[2]s *self) {\n", sym.id, sym.cpyname)
+	g.impl.Indent()
+	g.impl.Printf("long h = -1;\n")
+	g.impl.Printf("PyObject *value = cpy_func_%s_getter_value(self, NULL);\n", sym.id)
+	g.impl.Printf("if (value != NULL) {\n")
+	g.impl.Printf("\th = PyObject_Hash(value);\n")
+	g.impl.Printf("\tPy_DECREF(value);\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("return h;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
+
+// genTypeTPStr generates the __str__ method of typ, the string form made
+// by the go side: from the String or Error method of typ if any, or else
+// the go syntax of the value, e.g. pkg.Person{Name:"x", Age:5}.
+func (g *cpyGen) genTypeTPStr(typ Type) {
+	sym := typ.sym
+	f := typ.funcs.str
+	g.decl.Printf("\n/* __str__ support for %[1]s.%[2]s */\n",
+		f.Package().Name(),
+		sym.goname,
+	)
+	g.decl.Printf(
+		"static PyObject*\ncpy_func_%s_tp_str(PyObject *self);\n",
+		sym.id,
+	)
+
+	g.impl.Printf(
+		"static PyObject*\ncpy_func_%s_tp_str(PyObject *self) {\n",
+		sym.id,
+	)
+
+	g.impl.Indent()
+	g.genFuncBody(f)
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
+
+// genTypeTPFormat generates the __format__ method of typ, which formats
+// values with a go fmt verb. Invalid verbs raise a ValueError.
+func (g *cpyGen) genTypeTPFormat(typ Type) {
+	sym := typ.sym
+	f := typ.funcs.fmt
+	g.genMethod(typ, f)
+
+	g.decl.Printf("\n/* __format__ support for %[1]s */\n", sym.gofmt())
+	g.decl.Printf(
+		"static PyObject*\ncpy_func_%s_tp_format(PyObject *self, PyObject *args);\n",
+		sym.id,
+	)
+
+	g.impl.Printf("\n/* __format__ support for %[1]s */\n", sym.gofmt())
+	g.impl.Printf(
+		"static PyObject*\ncpy_func_%s_tp_format(PyObject *self, PyObject *args) {\n",
+		sym.id,
+	)
+	g.impl.Indent()
+	g.impl.Printf("PyObject *str = cpy_func_%[1]s((%[2]s*)self, args);\n", f.ID(), sym.cpyname)
+	g.impl.Printf("if (str == NULL && PyErr_ExceptionMatches(PyExc_RuntimeError)) {\n")
+	g.impl.Indent()
+	g.impl.Printf("/* the go error reports an invalid spec */\n")
+	g.impl.Printf("PyObject *type, *value, *tb;\n")
+	g.impl.Printf("PyErr_Fetch(&type, &value, &tb);\n")
+	g.impl.Printf("PyErr_SetObject(PyExc_ValueError, value);\n")
+	g.impl.Printf("Py_XDECREF(type);\n")
+	g.impl.Printf("Py_XDECREF(value);\n")
+	g.impl.Printf("Py_XDECREF(tb);\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Printf("return str;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
+
+func (g *cpyGen) genTypeTPRepr(typ Type) {
+	sym := typ.sym
+	f := typ.funcs.repr
+	g.decl.Printf("\n/* __repr__ support for %[1]s.%[2]s */\n",
+		f.Package().Name(),
+		sym.goname,
+	)
+	g.decl.Printf(
+		"static PyObject*\ncpy_func_%s_tp_repr(PyObject *self);\n",
+		sym.id,
+	)
+
+	g.impl.Printf(
+		"static PyObject*\ncpy_func_%s_tp_repr(PyObject *self) {\n",
+		sym.id,
+	)
+	g.impl.Indent()
+	g.genFuncBody(f)
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
+
+func (g *cpyGen) genTypeTPAsSequence(typ Type) {
+	sym := typ.sym
+	g.decl.Printf("\n/* sequence support for %s */\n", sym.gofmt())
+
+	var arrlen int64
+	var etyp types.Type
+	switch typ := sym.GoType().(type) {
+	case *types.Array:
+		etyp = typ.Elem()
+		arrlen = typ.Len()
+	case *types.Slice:
+		etyp = typ.Elem()
+	case *types.Named:
+		switch typ := typ.Underlying().(type) {
+		case *types.Array:
+			etyp = typ.Elem()
+			arrlen = typ.Len()
+		case *types.Slice:
+			etyp = typ.Elem()
+		default:
+			panic(fmt.Errorf(
+				"gopy: unhandled type [%#v] (go=%s kind=%v)",
+				typ,
+				sym.gofmt(),
+				sym.kind,
+			))
+		}
+	default:
 		panic(fmt.Errorf(
 			"gopy: unhandled type [%#v] (go=%s kind=%v)",
 			typ,
@@ -532,7 +1618,16 @@ func (g *cpyGen) genTypeTPAsSequence(typ Type) {
 	}
 
 	switch g.lang {
-	case 2:
+	case 0, 2:
+
+		// the items are read and written by the funcs of the sequence
+		// protocol, called as methods.
+		g.genMethod(typ, typ.funcs.len)
+		g.genMethod(typ, typ.funcs.item)
+		lock := typ.itemLock()
+		if lock == "" {
+			g.genMethod(typ, typ.funcs.setitem)
+		}
 
 		g.decl.Printf("\n/* len */\n")
 		g.decl.Printf("static Py_ssize_t\ncpy_func_%[1]s_len(%[2]s *self);\n",
@@ -549,8 +1644,14 @@ func (g *cpyGen) genTypeTPAsSequence(typ Type) {
 		if sym.isArray() {
 			g.impl.Printf("return %d;\n", arrlen)
 		} else {
-			g.impl.Printf("GoSlice *slice = (GoSlice*)(self->cgopy);\n")
-			g.impl.Printf("return slice->len;\n")
+			g.impl.Printf("Py_ssize_t len = -1;\n")
+			g.impl.Printf("PyObject *pylen = cpy_func_%[1]s(self, NULL);\n", typ.funcs.len.ID())
+			g.impl.Printf("if (pylen == NULL) {\n")
+			g.impl.Printf("\treturn -1;\n")
+			g.impl.Printf("}\n")
+			g.impl.Printf("len = PyInt_AsSsize_t(pylen);\n")
+			g.impl.Printf("Py_DECREF(pylen);\n")
+			g.impl.Printf("return len;\n")
 		}
 		g.impl.Outdent()
 		g.impl.Printf("}\n\n")
@@ -569,24 +1670,22 @@ func (g *cpyGen) genTypeTPAsSequence(typ Type) {
 			sym.cpyname,
 		)
 		g.impl.Indent()
+		g.impl.Printf("PyObject *args = NULL;\n")
 		g.impl.Printf("PyObject *pyitem = NULL;\n")
-		if sym.isArray() {
-			g.impl.Printf("if (i < 0 || i >= %d) {\n", arrlen)
-		} else {
-			g.impl.Printf("GoSlice *slice = (GoSlice*)(self->cgopy);\n")
-			g.impl.Printf("if (i < 0 || i >= slice->len) {\n")
-		}
+		g.impl.Printf("if (i < 0 || i >= cpy_func_%[1]s_len(self)) {\n", sym.id)
 		g.impl.Indent()
 		g.impl.Printf("PyErr_SetString(PyExc_IndexError, ")
 		g.impl.Printf("\"array index out of range\");\n")
 		g.impl.Printf("return NULL;\n")
 		g.impl.Outdent()
 		g.impl.Printf("}\n\n")
-		g.impl.Printf("%[1]s item = cgo_func_%[2]s_item(self->cgopy, i);\n",
-			esym.cgoname,
-			sym.id,
-		)
-		g.impl.Printf("pyitem = %[1]s(&item);\n", esym.c2py)
+		g.impl.Printf("args = Py_BuildValue(\"(n)\", i);\n")
+		g.impl.Printf("if (args == NULL) {\n")
+		g.impl.Printf("\treturn NULL;\n")
+		g.impl.Printf("}\n")
+		// struct, array and slice items alias the storage of self.
+		g.impl.Printf("pyitem = cpy_func_%[1]s(self, args);\n", typ.funcs.item.ID())
+		g.impl.Printf("Py_DECREF(args);\n")
 		g.impl.Printf("return pyitem;\n")
 		g.impl.Outdent()
 		g.impl.Printf("}\n\n")
@@ -605,33 +1704,45 @@ func (g *cpyGen) genTypeTPAsSequence(typ Type) {
 			sym.cpyname,
 		)
 		g.impl.Indent()
-		g.impl.Printf("%[1]s c_v;\n", esym.cgoname)
-		if sym.isArray() {
-			g.impl.Printf("if (i < 0 || i >= %d) {\n", arrlen)
+		if lock != "" {
+			// items holding a lock cannot be copied in.
+			g.impl.Printf("PyErr_SetString(PyExc_TypeError, ")
+			g.impl.Printf("\"items of %s hold a %s: they cannot be assigned\");\n", sym.goname, lock)
+			g.impl.Printf("return -1;\n")
 		} else {
-			g.impl.Printf("GoSlice *slice = (GoSlice*)(self->cgopy);\n")
-			g.impl.Printf("if (i < 0 || i >= slice->len) {\n")
+			g.impl.Printf("PyObject *args = NULL;\n")
+			g.impl.Printf("PyObject *res = NULL;\n")
+			g.impl.Printf("if (i < 0 || i >= cpy_func_%[1]s_len(self)) {\n", sym.id)
+			g.impl.Indent()
+			g.impl.Printf("PyErr_SetString(PyExc_IndexError, ")
+			g.impl.Printf("\"array assignment index out of range\");\n")
+			g.impl.Printf("return -1;\n")
+			g.impl.Outdent()
+			g.impl.Printf("}\n\n")
+			g.impl.Printf("if (v == NULL) { return 0; }\n") // FIXME(sbinet): semantics?
+			g.impl.Printf("args = Py_BuildValue(\"(nO)\", i, v);\n")
+			g.impl.Printf("if (args == NULL) {\n")
+			g.impl.Printf("\treturn -1;\n")
+			g.impl.Printf("}\n")
+			g.impl.Printf("res = cpy_func_%[1]s(self, args);\n", typ.funcs.setitem.ID())
+			g.impl.Printf("Py_DECREF(args);\n")
+			g.impl.Printf("if (res == NULL) {\n")
+			g.impl.Printf("\treturn -1;\n")
+			g.impl.Printf("}\n")
+			g.impl.Printf("Py_DECREF(res);\n")
+			g.impl.Printf("return 0;\n")
 		}
-		g.impl.Indent()
-		g.impl.Printf("PyErr_SetString(PyExc_IndexError, ")
-		g.impl.Printf("\"array assignment index out of range\");\n")
-		g.impl.Printf("return -1;\n")
-		g.impl.Outdent()
-		g.impl.Printf("}\n\n")
-		g.impl.Printf("if (v == NULL) { return 0; }\n") // FIXME(sbinet): semantics?
-		g.impl.Printf("if (!%[1]s(v, &c_v)) { return -1; }\n", esym.py2c)
-		g.impl.Printf("cgo_func_%[1]s_ass_item(self->cgopy, i, c_v);\n", sym.id)
-		g.impl.Printf("return 0;\n")
 		g.impl.Outdent()
 		g.impl.Printf("}\n\n")
 
 		sq_inplace_concat := "0"
 		// append
-		if sym.isSlice() {
+		if sym.isSlice() && lock == "" {
 			sq_inplace_concat = fmt.Sprintf(
 				"cpy_func_%[1]s_inplace_concat",
 				sym.id,
 			)
+			g.genMethod(typ, typ.funcs.append)
 
 			g.decl.Printf("\n/* append-item */\n")
 			g.decl.Printf("static int\n")
@@ -647,11 +1758,19 @@ func (g *cpyGen) genTypeTPAsSequence(typ Type) {
 				sym.cpyname,
 			)
 			g.impl.Indent()
-			g.impl.Printf("%[1]s c_v;\n", esym.cgoname)
-			g.impl.Printf("GoSlice *slice = (GoSlice*)(self->cgopy);\n")
+			g.impl.Printf("PyObject *args = NULL;\n")
+			g.impl.Printf("PyObject *res = NULL;\n")
 			g.impl.Printf("if (v == NULL) { return 0; }\n") // FIXME(sbinet): semantics?
-			g.impl.Printf("if (!%[1]s(v, &c_v)) { return -1; }\n", esym.py2c)
-			g.impl.Printf("cgo_func_%[1]s_append(self->cgopy, c_v);\n", sym.id)
+			g.impl.Printf("args = Py_BuildValue(\"(O)\", v);\n")
+			g.impl.Printf("if (args == NULL) {\n")
+			g.impl.Printf("\treturn -1;\n")
+			g.impl.Printf("}\n")
+			g.impl.Printf("res = cpy_func_%[1]s(self, args);\n", typ.funcs.append.ID())
+			g.impl.Printf("Py_DECREF(args);\n")
+			g.impl.Printf("if (res == NULL) {\n")
+			g.impl.Printf("\treturn -1;\n")
+			g.impl.Printf("}\n")
+			g.impl.Printf("Py_DECREF(res);\n")
 			g.impl.Printf("return 0;\n")
 			g.impl.Outdent()
 			g.impl.Printf("}\n\n")
@@ -704,6 +1823,8 @@ func (g *cpyGen) genTypeTPAsSequence(typ Type) {
 			g.impl.Outdent()
 			g.impl.Printf("}\n\n") // for-loop
 
+			// sq_inplace_concat returns a new reference.
+			g.impl.Printf("Py_INCREF(self);\n")
 			g.impl.Printf("return (PyObject*)self;\n")
 			g.impl.Outdent()
 
@@ -725,7 +1846,7 @@ func (g *cpyGen) genTypeTPAsSequence(typ Type) {
 		g.impl.Printf("(ssizessizeargfunc)0,\n") // array_slice,             /*sq_slice
 		g.impl.Printf("(ssizeobjargproc)cpy_func_%[1]s_ass_item,\n", sym.id)
 		g.impl.Printf("(ssizessizeobjargproc)0,\n") //array_ass_slice,      /*sq_ass_slice
-		g.impl.Printf("(objobjproc)0,\n")           //array_contains,                 /*sq_contains
+		g.impl.Printf("%s,\n", g.sqContains(typ))   //array_contains,                 /*sq_contains
 		g.impl.Printf("(binaryfunc)%s,\n", sq_inplace_concat)
 		g.impl.Printf("(ssizeargfunc)0\n") //array_inplace_repeat          /*sq_inplace_repeat
 		g.impl.Outdent()
@@ -735,219 +1856,650 @@ func (g *cpyGen) genTypeTPAsSequence(typ Type) {
 	}
 }
 
-func (g *cpyGen) genTypeTPAsBuffer(typ Type) {
+// genTypeTPAsMapping generates the mapping protocol of maps: items are
+// read, set and deleted by key, missing keys raising a KeyError. The
+// sequence protocol only holds sq_contains, so "k in m" looks k up.
+func (g *cpyGen) genTypeTPAsMapping(typ Type) {
 	sym := typ.sym
-	g.decl.Printf("\n/* buffer support for %s */\n", sym.gofmt())
-
-	g.decl.Printf("\n/* __get_buffer__ impl for %s */\n", sym.gofmt())
-	g.decl.Printf("static int\n")
-	g.decl.Printf(
-		"cpy_func_%[1]s_getbuffer(PyObject *self, Py_buffer *view, int flags);\n",
-		sym.id,
-	)
+	g.decl.Printf("\n/* mapping support for %s */\n", sym.gofmt())
 
-	var esize int64
-	var arrlen int64
-	esym := g.pkg.syms.symtype(sym.GoType())
-	switch o := sym.GoType().(type) {
-	case *types.Array:
-		esize = g.pkg.sz.Sizeof(o.Elem())
-		arrlen = o.Len()
-	case *types.Slice:
-		esize = g.pkg.sz.Sizeof(o.Elem())
-	}
+	switch g.lang {
+	case 0, 2:
+		// the items are read, set and deleted by the funcs of the
+		// mapping protocol, called as methods.
+		g.genMethod(typ, typ.funcs.len)
+		g.genMethod(typ, typ.funcs.item)
+		g.genMethod(typ, typ.funcs.setitem)
+		g.genMethod(typ, typ.funcs.delitem)
+
+		g.decl.Printf("\n/* mp_length */\n")
+		g.decl.Printf("static Py_ssize_t\ncpy_func_%[1]s_mp_length(%[2]s *self);\n",
+			sym.id,
+			sym.cpyname,
+		)
 
-	g.impl.Printf("\n/* __get_buffer__ impl for %s */\n", sym.gofmt())
-	g.impl.Printf("static int\n")
-	g.impl.Printf(
-		"cpy_func_%[1]s_getbuffer(PyObject *self, Py_buffer *view, int flags) {\n",
-		sym.id,
-	)
-	g.impl.Indent()
-	g.impl.Printf("if (view == NULL) {\n")
-	g.impl.Indent()
-	g.impl.Printf("PyErr_SetString(PyExc_ValueError, ")
-	g.impl.Printf("\"NULL view in getbuffer\");\n")
-	g.impl.Printf("return -1;\n")
-	g.impl.Outdent()
-	g.impl.Printf("}\n\n")
-	g.impl.Printf("%[1]s *py = (%[1]s*)self;\n", sym.cpyname)
-	if sym.isArray() {
-		g.impl.Printf("void *array = (void*)(py->cgopy);\n")
-		g.impl.Printf("view->obj = (PyObject*)py;\n")
-		g.impl.Printf("view->buf = (void*)array;\n")
-		g.impl.Printf("view->len = %d;\n", arrlen)
-		g.impl.Printf("view->readonly = 0;\n")
-		g.impl.Printf("view->itemsize = %d;\n", esize)
-		g.impl.Printf("view->format = %q;\n", esym.pybuf)
-		g.impl.Printf("view->ndim = 1;\n")
-		g.impl.Printf("view->shape = (Py_ssize_t*)&view->len;\n")
-	} else {
-		g.impl.Printf("GoSlice *slice = (GoSlice*)(py->cgopy);\n")
-		g.impl.Printf("view->obj = (PyObject*)py;\n")
-		g.impl.Printf("view->buf = (void*)slice->data;\n")
-		g.impl.Printf("view->len = slice->len;\n")
-		g.impl.Printf("view->readonly = 0;\n")
-		g.impl.Printf("view->itemsize = %d;\n", esize)
-		g.impl.Printf("view->format = %q;\n", esym.pybuf)
-		g.impl.Printf("view->ndim = 1;\n")
-		g.impl.Printf("view->shape = (Py_ssize_t*)&slice->len;\n")
-	}
-	g.impl.Printf("view->strides = &view->itemsize;\n")
-	g.impl.Printf("view->suboffsets = NULL;\n")
-	g.impl.Printf("view->internal = NULL;\n")
-
-	g.impl.Printf("\nPy_INCREF(py);\n")
-	g.impl.Printf("return 0;\n")
-	g.impl.Outdent()
-	g.impl.Printf("}\n\n")
+		g.impl.Printf("\n/* mp_length */\n")
+		g.impl.Printf("static Py_ssize_t\ncpy_func_%[1]s_mp_length(%[2]s *self) {\n",
+			sym.id,
+			sym.cpyname,
+		)
+		g.impl.Indent()
+		g.impl.Printf("Py_ssize_t len = -1;\n")
+		g.impl.Printf("PyObject *pylen = cpy_func_%[1]s(self, NULL);\n", typ.funcs.len.ID())
+		g.impl.Printf("if (pylen == NULL) {\n")
+		g.impl.Printf("\treturn -1;\n")
+		g.impl.Printf("}\n")
+		g.impl.Printf("len = PyInt_AsSsize_t(pylen);\n")
+		g.impl.Printf("Py_DECREF(pylen);\n")
+		g.impl.Printf("return len;\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n")
 
-	switch g.lang {
-	case 2:
-		g.decl.Printf("\n/* readbuffer */\n")
-		g.decl.Printf("static Py_ssize_t\n")
-		g.decl.Printf(
-			"cpy_func_%[1]s_readbuffer(%[2]s *self, Py_ssize_t index, const void **ptr);\n",
+		g.decl.Printf("\n/* mp_lookup */\n")
+		g.decl.Printf("static PyObject*\n")
+		g.decl.Printf("cpy_func_%[1]s_mp_lookup(%[2]s *self, PyObject *key);\n",
 			sym.id,
 			sym.cpyname,
 		)
 
-		g.impl.Printf("\n/* readbuffer */\n")
-		g.impl.Printf("static Py_ssize_t\n")
-		g.impl.Printf(
-			"cpy_func_%[1]s_readbuffer(%[2]s *self, Py_ssize_t index, const void **ptr) {\n",
+		// mp_lookup returns the (value, ok) tuple of the item at key.
+		g.impl.Printf("\n/* mp_lookup */\n")
+		g.impl.Printf("static PyObject*\n")
+		g.impl.Printf("cpy_func_%[1]s_mp_lookup(%[2]s *self, PyObject *key) {\n",
 			sym.id,
 			sym.cpyname,
 		)
 		g.impl.Indent()
-		g.impl.Printf("if (index != 0) {\n")
+		g.impl.Printf("PyObject *res = NULL;\n")
+		g.impl.Printf("PyObject *args = Py_BuildValue(\"(O)\", key);\n")
+		g.impl.Printf("if (args == NULL) {\n")
+		g.impl.Printf("\treturn NULL;\n")
+		g.impl.Printf("}\n")
+		g.impl.Printf("res = cpy_func_%[1]s(self, args);\n", typ.funcs.item.ID())
+		g.impl.Printf("Py_DECREF(args);\n")
+		g.impl.Printf("return res;\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n")
+
+		g.decl.Printf("\n/* mp_key_error */\n")
+		g.decl.Printf("static void\ncpy_func_%[1]s_mp_key_error(PyObject *key);\n", sym.id)
+
+		// the key is wrapped into a tuple, as it may be a tuple itself.
+		g.impl.Printf("\n/* mp_key_error */\n")
+		g.impl.Printf("static void\ncpy_func_%[1]s_mp_key_error(PyObject *key) {\n", sym.id)
+		g.impl.Indent()
+		g.impl.Printf("PyObject *exc = Py_BuildValue(\"(O)\", key);\n")
+		g.impl.Printf("if (exc != NULL) {\n")
 		g.impl.Indent()
-		g.impl.Printf("PyErr_SetString(PyExc_SystemError, ")
-		g.impl.Printf("\"Accessing non-existent array segment\");\n")
-		g.impl.Printf("return -1;\n")
+		g.impl.Printf("PyErr_SetObject(PyExc_KeyError, exc);\n")
+		g.impl.Printf("Py_DECREF(exc);\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
 		g.impl.Outdent()
 		g.impl.Printf("}\n\n")
-		if sym.isArray() {
-			g.impl.Printf("*ptr = (void*)self->cgopy;\n")
-			g.impl.Printf("return %d;\n", arrlen)
-		} else {
-			g.impl.Printf("GoSlice *slice = (GoSlice*)self->cgopy;\n")
-			g.impl.Printf("*ptr = (void*)slice->data;\n")
-			g.impl.Printf("return slice->len;\n")
-		}
+
+		g.decl.Printf("\n/* mp_subscript */\n")
+		g.decl.Printf("static PyObject*\n")
+		g.decl.Printf("cpy_func_%[1]s_mp_subscript(%[2]s *self, PyObject *key);\n",
+			sym.id,
+			sym.cpyname,
+		)
+
+		g.impl.Printf("\n/* mp_subscript */\n")
+		g.impl.Printf("static PyObject*\n")
+		g.impl.Printf("cpy_func_%[1]s_mp_subscript(%[2]s *self, PyObject *key) {\n",
+			sym.id,
+			sym.cpyname,
+		)
+		g.impl.Indent()
+		g.impl.Printf("PyObject *pyitem = NULL;\n")
+		g.impl.Printf("PyObject *res = cpy_func_%[1]s_mp_lookup(self, key);\n", sym.id)
+		g.impl.Printf("if (res == NULL) {\n")
+		g.impl.Printf("\treturn NULL;\n")
+		g.impl.Printf("}\n")
+		g.impl.Printf("if (PyTuple_GET_ITEM(res, 1) == Py_True) {\n")
+		g.impl.Indent()
+		g.impl.Printf("pyitem = PyTuple_GET_ITEM(res, 0);\n")
+		g.impl.Printf("Py_INCREF(pyitem);\n")
+		g.impl.Outdent()
+		g.impl.Printf("} else {\n")
+		g.impl.Indent()
+		g.impl.Printf("cpy_func_%[1]s_mp_key_error(key);\n", sym.id)
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+		g.impl.Printf("Py_DECREF(res);\n")
+		g.impl.Printf("return pyitem;\n")
 		g.impl.Outdent()
 		g.impl.Printf("}\n\n")
 
-		g.decl.Printf("\n/* writebuffer */\n")
-		g.decl.Printf("static Py_ssize_t\n")
-		g.decl.Printf(
-			"cpy_func_%[1]s_writebuffer(%[2]s *self, Py_ssize_t segment, void **ptr);\n",
+		g.decl.Printf("\n/* mp_ass_subscript */\n")
+		g.decl.Printf("static int\n")
+		g.decl.Printf("cpy_func_%[1]s_mp_ass_subscript(%[2]s *self, PyObject *key, PyObject *v);\n",
 			sym.id,
 			sym.cpyname,
 		)
 
-		g.impl.Printf("\n/* writebuffer */\n")
-		g.impl.Printf("static Py_ssize_t\n")
-		g.impl.Printf(
-			"cpy_func_%[1]s_writebuffer(%[2]s *self, Py_ssize_t segment, void **ptr) {\n",
+		g.impl.Printf("\n/* mp_ass_subscript */\n")
+		g.impl.Printf("static int\n")
+		g.impl.Printf("cpy_func_%[1]s_mp_ass_subscript(%[2]s *self, PyObject *key, PyObject *v) {\n",
 			sym.id,
 			sym.cpyname,
 		)
 		g.impl.Indent()
-		g.impl.Printf("return cpy_func_%[1]s_readbuffer(self, segment, (const void**)ptr);\n",
+		g.impl.Printf("PyObject *args = NULL;\n")
+		g.impl.Printf("PyObject *res = NULL;\n")
+		g.impl.Printf("int ok = 1;\n")
+		g.impl.Printf("if (v == NULL) {\n")
+		g.impl.Indent()
+		g.impl.Printf("args = Py_BuildValue(\"(O)\", key);\n")
+		g.impl.Printf("if (args == NULL) {\n")
+		g.impl.Printf("\treturn -1;\n")
+		g.impl.Printf("}\n")
+		g.impl.Printf("res = cpy_func_%[1]s(self, args);\n", typ.funcs.delitem.ID())
+		g.impl.Printf("ok = (res != Py_False);\n")
+		g.impl.Outdent()
+		g.impl.Printf("} else {\n")
+		g.impl.Indent()
+		g.impl.Printf("args = Py_BuildValue(\"(OO)\", key, v);\n")
+		g.impl.Printf("if (args == NULL) {\n")
+		g.impl.Printf("\treturn -1;\n")
+		g.impl.Printf("}\n")
+		g.impl.Printf("res = cpy_func_%[1]s(self, args);\n", typ.funcs.setitem.ID())
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+		g.impl.Printf("Py_DECREF(args);\n")
+		g.impl.Printf("if (res == NULL) {\n")
+		g.impl.Printf("\treturn -1;\n")
+		g.impl.Printf("}\n")
+		g.impl.Printf("Py_DECREF(res);\n")
+		g.impl.Printf("if (!ok) {\n")
+		g.impl.Indent()
+		g.impl.Printf("cpy_func_%[1]s_mp_key_error(key);\n", sym.id)
+		g.impl.Printf("return -1;\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+		g.impl.Printf("return 0;\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n\n")
+
+		g.decl.Printf("\n/* sq_contains */\n")
+		g.decl.Printf("static int\n")
+		g.decl.Printf("cpy_func_%[1]s_mp_contains(%[2]s *self, PyObject *key);\n",
+			sym.id,
+			sym.cpyname,
+		)
+
+		g.impl.Printf("\n/* sq_contains */\n")
+		g.impl.Printf("static int\n")
+		g.impl.Printf("cpy_func_%[1]s_mp_contains(%[2]s *self, PyObject *key) {\n",
 			sym.id,
+			sym.cpyname,
 		)
+		g.impl.Indent()
+		g.impl.Printf("int ok = 0;\n")
+		g.impl.Printf("PyObject *res = cpy_func_%[1]s_mp_lookup(self, key);\n", sym.id)
+		g.impl.Printf("if (res == NULL) {\n")
+		g.impl.Printf("\treturn -1;\n")
+		g.impl.Printf("}\n")
+		g.impl.Printf("ok = (PyTuple_GET_ITEM(res, 1) == Py_True);\n")
+		g.impl.Printf("Py_DECREF(res);\n")
+		g.impl.Printf("return ok;\n")
 		g.impl.Outdent()
 		g.impl.Printf("}\n\n")
 
-		g.decl.Printf("\n/* segcount */\n")
-		g.decl.Printf("static Py_ssize_t\n")
-		g.decl.Printf("cpy_func_%[1]s_segcount(%[2]s *self, Py_ssize_t *lenp);\n",
+		key := typ.funcs.item.Signature().Params()[0]
+		g.decl.Printf("\n/* keys */\n")
+		g.decl.Printf("static PyObject*\n")
+		g.decl.Printf("cpy_func_%[1]s_mp_keys(%[2]s *self, PyObject *unused);\n",
 			sym.id,
 			sym.cpyname,
 		)
 
-		g.impl.Printf("\n/* segcount */\n")
-		g.impl.Printf("static Py_ssize_t\n")
-		g.impl.Printf("cpy_func_%[1]s_segcount(%[2]s *self, Py_ssize_t *lenp) {\n",
+		// the keys are read into a list, after their number.
+		g.impl.Printf("\n/* keys */\n")
+		g.impl.Printf("static PyObject*\n")
+		g.impl.Printf("cpy_func_%[1]s_mp_keys(%[2]s *self, PyObject *unused) {\n",
 			sym.id,
 			sym.cpyname,
 		)
 		g.impl.Indent()
-		if sym.isArray() {
-			g.impl.Printf("if (lenp) { *lenp = %d; }\n", arrlen)
-		} else {
-			g.impl.Printf("GoSlice *slice = (GoSlice*)(self->cgopy);\n")
-			g.impl.Printf("if (lenp) { *lenp = slice->len; }\n")
+		key.genDecl(g.impl)
+		g.impl.Printf("PyObject *keys = NULL;\n")
+		g.impl.Printf("int64_t i = 0;\n")
+		g.impl.Printf("int64_t n = 0;\n")
+		g.impl.Printf("cgopy_seq_buffer ibuf = cgopy_seq_buffer_new();\n")
+		g.impl.Printf("cgopy_seq_buffer obuf = cgopy_seq_buffer_new();\n")
+		g.impl.Printf("cgopy_seq_buffer_write_int32(ibuf, self->cgopy);\n")
+		g.genSend(typ.funcs.keys.Descriptor(), typ.funcs.keys.ID(), false)
+		g.impl.Printf("n = cgopy_seq_buffer_read_int64(obuf);\n")
+		g.impl.Printf("keys = PyList_New(n);\n")
+		g.impl.Printf("for (i = 0; keys != NULL && i < n; i++) {\n")
+		g.impl.Indent()
+		g.genRead("c_"+key.Name(), "obuf", key.GoType())
+		pyfmt, pyaddrs := key.getArgBuildValue(g.lang)
+		g.impl.Printf("PyObject *k = Py_BuildValue(%q, %s);\n", pyfmt, strings.Join(pyaddrs, ", "))
+		if key.sym.cgoname == "cgopy_seq_bytearray" {
+			g.impl.Printf("cgopy_seq_bytearray_free(c_%s);\n", key.Name())
 		}
-		g.impl.Printf("return 1;\n")
+		g.impl.Printf("if (k == NULL) {\n")
+		g.impl.Indent()
+		g.impl.Printf("Py_CLEAR(keys);\n")
+		g.impl.Printf("break;\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+		g.impl.Printf("PyList_SET_ITEM(keys, i, k);\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+		g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
+		g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
+		g.impl.Printf("return keys;\n")
 		g.impl.Outdent()
 		g.impl.Printf("}\n\n")
 
-		g.decl.Printf("\n/* charbuffer */\n")
-		g.decl.Printf("static Py_ssize_t\n")
-		g.decl.Printf("cpy_func_%[1]s_charbuffer(%[2]s *self, Py_ssize_t segment, const char **ptr);\n",
+		for _, v := range []struct {
+			name string
+			item string
+		}{
+			{"items", "Py_BuildValue(\"(OO)\", k, v)"},
+			{"values", "v"},
+		} {
+			g.decl.Printf("\n/* %s */\n", v.name)
+			g.decl.Printf("static PyObject*\n")
+			g.decl.Printf("cpy_func_%[1]s_mp_%[3]s(%[2]s *self, PyObject *unused);\n",
+				sym.id,
+				sym.cpyname,
+				v.name,
+			)
+
+			// the items are looked up key by key.
+			g.impl.Printf("\n/* %s */\n", v.name)
+			g.impl.Printf("static PyObject*\n")
+			g.impl.Printf("cpy_func_%[1]s_mp_%[3]s(%[2]s *self, PyObject *unused) {\n",
+				sym.id,
+				sym.cpyname,
+				v.name,
+			)
+			g.impl.Indent()
+			g.impl.Printf("Py_ssize_t i = 0;\n")
+			g.impl.Printf("PyObject *keys = cpy_func_%[1]s_mp_keys(self, NULL);\n", sym.id)
+			g.impl.Printf("if (keys == NULL) {\n")
+			g.impl.Printf("\treturn NULL;\n")
+			g.impl.Printf("}\n")
+			g.impl.Printf("for (i = 0; i < PyList_GET_SIZE(keys); i++) {\n")
+			g.impl.Indent()
+			g.impl.Printf("PyObject *k = PyList_GET_ITEM(keys, i);\n")
+			g.impl.Printf("PyObject *v = cpy_func_%[1]s_mp_subscript(self, k);\n", sym.id)
+			g.impl.Printf("if (v == NULL) {\n")
+			g.impl.Indent()
+			g.impl.Printf("Py_DECREF(keys);\n")
+			g.impl.Printf("return NULL;\n")
+			g.impl.Outdent()
+			g.impl.Printf("}\n")
+			if v.item != "v" {
+				g.impl.Printf("PyObject *item = %s;\n", v.item)
+				g.impl.Printf("Py_DECREF(v);\n")
+				g.impl.Printf("if (item == NULL) {\n")
+				g.impl.Indent()
+				g.impl.Printf("Py_DECREF(keys);\n")
+				g.impl.Printf("return NULL;\n")
+				g.impl.Outdent()
+				g.impl.Printf("}\n")
+				g.impl.Printf("v = item;\n")
+			}
+			// the list of keys is reused: its items are replaced.
+			g.impl.Printf("PyList_SetItem(keys, i, v);\n")
+			g.impl.Outdent()
+			g.impl.Printf("}\n")
+			g.impl.Printf("return keys;\n")
+			g.impl.Outdent()
+			g.impl.Printf("}\n\n")
+		}
+
+		g.decl.Printf("\n/* tp_iter */\n")
+		g.decl.Printf("static PyObject*\ncpy_func_%[1]s_tp_iter(%[2]s *self);\n",
 			sym.id,
 			sym.cpyname,
 		)
 
-		g.impl.Printf("\n/* charbuffer */\n")
-		g.impl.Printf("static Py_ssize_t\n")
-		g.impl.Printf("cpy_func_%[1]s_charbuffer(%[2]s *self, Py_ssize_t segment, const char **ptr) {\n",
+		// iterating over a map iterates over a list of its keys.
+		g.impl.Printf("\n/* tp_iter */\n")
+		g.impl.Printf("static PyObject*\ncpy_func_%[1]s_tp_iter(%[2]s *self) {\n",
 			sym.id,
 			sym.cpyname,
 		)
 		g.impl.Indent()
-		g.impl.Printf("return cpy_func_%[1]s_readbuffer(self, segment, (const void**)ptr);\n", sym.id)
+		g.impl.Printf("PyObject *it = NULL;\n")
+		g.impl.Printf("PyObject *keys = cpy_func_%[1]s_mp_keys(self, NULL);\n", sym.id)
+		g.impl.Printf("if (keys == NULL) {\n")
+		g.impl.Printf("\treturn NULL;\n")
+		g.impl.Printf("}\n")
+		g.impl.Printf("it = PyObject_GetIter(keys);\n")
+		g.impl.Printf("Py_DECREF(keys);\n")
+		g.impl.Printf("return it;\n")
 		g.impl.Outdent()
 		g.impl.Printf("}\n\n")
 
-	case 3:
-		// no-op
-	}
-
-	switch g.lang {
-	case 2:
-		g.impl.Printf("\n/* tp_as_buffer */\n")
-		g.impl.Printf("static PyBufferProcs %[1]s_tp_as_buffer = {\n", sym.cpyname)
+		contains := fmt.Sprintf("(objobjproc)cpy_func_%[1]s_mp_contains", sym.id)
+		if typ.prots&ProtoContainer != 0 {
+			// a Contains method takes precedence.
+			contains = g.sqContains(typ)
+		}
+		g.impl.Printf("\n/* tp_as_sequence */\n")
+		g.impl.Printf("static PySequenceMethods %[1]s_tp_as_sequence = {\n", sym.cpyname)
 		g.impl.Indent()
-		g.impl.Printf("(readbufferproc)cpy_func_%[1]s_readbuffer,\n", sym.id)
-		g.impl.Printf("(writebufferproc)cpy_func_%[1]s_writebuffer,\n", sym.id)
-		g.impl.Printf("(segcountproc)cpy_func_%[1]s_segcount,\n", sym.id)
-		g.impl.Printf("(charbufferproc)cpy_func_%[1]s_charbuffer,\n", sym.id)
-		g.impl.Printf("(getbufferproc)cpy_func_%[1]s_getbuffer,\n", sym.id)
-		g.impl.Printf("(releasebufferproc)0,\n")
+		g.impl.Printf("(lenfunc)0,\n")              // sq_length
+		g.impl.Printf("(binaryfunc)0,\n")           // sq_concat
+		g.impl.Printf("(ssizeargfunc)0,\n")         // sq_repeat
+		g.impl.Printf("(ssizeargfunc)0,\n")         // sq_item
+		g.impl.Printf("(ssizessizeargfunc)0,\n")    // sq_slice
+		g.impl.Printf("(ssizeobjargproc)0,\n")      // sq_ass_item
+		g.impl.Printf("(ssizessizeobjargproc)0,\n") // sq_ass_slice
+		g.impl.Printf("%s,\n", contains)            // sq_contains
+		g.impl.Printf("(binaryfunc)0,\n")           // sq_inplace_concat
+		g.impl.Printf("(ssizeargfunc)0\n")          // sq_inplace_repeat
 		g.impl.Outdent()
 		g.impl.Printf("};\n\n")
-	case 3:
 
-		g.impl.Printf("\n/* tp_as_buffer */\n")
-		g.impl.Printf("static PyBufferProcs %[1]s_tp_as_buffer = {\n", sym.cpyname)
+		g.impl.Printf("\n/* tp_as_mapping */\n")
+		g.impl.Printf("static PyMappingMethods %[1]s_tp_as_mapping = {\n", sym.cpyname)
 		g.impl.Indent()
-		g.impl.Printf("(getbufferproc)cpy_func_%[1]s_getbuffer,\n", sym.id)
-		g.impl.Printf("(releasebufferproc)0,\n")
+		g.impl.Printf("(lenfunc)cpy_func_%[1]s_mp_length,\n", sym.id)
+		g.impl.Printf("(binaryfunc)cpy_func_%[1]s_mp_subscript,\n", sym.id)
+		g.impl.Printf("(objobjargproc)cpy_func_%[1]s_mp_ass_subscript\n", sym.id)
 		g.impl.Outdent()
 		g.impl.Printf("};\n\n")
+
+	case 3:
 	}
 }
 
-func (g *cpyGen) genTypeTPCall(typ Type) {
+// genTypeTPIter generates the iteration over iter.Seq and iter.Seq2 values.
+// Each python iterator holds the handle of a pull of the values, stopped
+// when the iterator is released. The pulls run with the GIL released: the
+// go funcs yielding the values may block, or call back into python.
+// iter.Seq2 values are iterated over as (key, value) tuples.
+func (g *cpyGen) genTypeTPIter(typ Type) {
 	sym := typ.sym
+	vals := iterSeqTypes(sym.GoType())
+	it := sym.cpyname + "_iter"
 
-	if !sym.isSignature() {
-		return
+	g.decl.Printf("\n/* python iterator over the values of %s */\n", sym.gofmt())
+	g.decl.Printf("typedef struct {\n")
+	g.decl.Indent()
+	g.decl.Printf("PyObject_HEAD\n")
+	g.decl.Printf("int32_t ref; /* handle to the pull of the values */\n")
+	g.decl.Outdent()
+	g.decl.Printf("} %s;\n", it)
+	g.decl.Printf("\nstatic PyTypeObject %sType;\n", it)
+
+	desc, id := typ.iterFunc("stop")
+	g.impl.Printf("\n/* dealloc stops the pull of the values */\n")
+	g.impl.Printf("static void\n%[1]s_dealloc(%[1]s *self) {\n", it)
+	g.impl.Indent()
+	g.impl.Printf("cgopy_seq_buffer ibuf = cgopy_seq_buffer_new();\n")
+	g.impl.Printf("cgopy_seq_buffer obuf = cgopy_seq_buffer_new();\n")
+	g.impl.Printf("cgopy_seq_buffer_write_int32(ibuf, self->ref);\n")
+	g.genSend(desc, id, true)
+	g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
+	g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
+	g.impl.Printf("cgopy_seq_destroy_ref(self->ref);\n")
+	g.impl.Printf("PyObject_Del(self);\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+
+	desc, id = typ.iterFunc("next")
+	g.impl.Printf("/* tp_iternext pulls the next value, returning NULL without an\n")
+	g.impl.Printf("   exception set once all the values were pulled. */\n")
+	g.impl.Printf("static PyObject*\n%[1]s_iternext(%[1]s *self) {\n", it)
+	g.impl.Indent()
+	g.impl.Printf("PyObject *pyout = NULL;\n")
+	g.impl.Printf("int8_t c_gopy_ok = 0;\n")
+	g.impl.Printf("cgopy_seq_buffer ibuf = cgopy_seq_buffer_new();\n")
+	g.impl.Printf("cgopy_seq_buffer obuf = cgopy_seq_buffer_new();\n")
+	g.impl.Printf("cgopy_seq_buffer_write_int32(ibuf, self->ref);\n")
+	g.genSend(desc, id, true)
+	g.impl.Printf("c_gopy_ok = cgopy_seq_buffer_read_bool(obuf);\n")
+	g.impl.Printf("if (c_gopy_ok) {\n")
+	g.impl.Indent()
+	format := []string{}
+	pyaddrs := []string{}
+	for i, v := range vals {
+		ret := newVar(g.pkg, v, "", fmt.Sprintf("gopy_ret_%d", i), "")
+		ret.genDecl(g.impl)
+		g.genRead("c_"+ret.Name(), "obuf", v)
+		pyfmt, addrs := ret.getArgBuildValue(g.lang)
+		format = append(format, pyfmt)
+		pyaddrs = append(pyaddrs, addrs...)
 	}
+	pyfmt := strings.Join(format, "")
+	if len(vals) > 1 {
+		pyfmt = "(" + pyfmt + ")"
+	}
+	g.impl.Printf("pyout = Py_BuildValue(%q, %s);\n", pyfmt, strings.Join(pyaddrs, ", "))
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
+	g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
+	g.impl.Printf("return pyout;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
 
-	sig := sym.GoType().Underlying().(*types.Signature)
-	if sig.Recv() != nil {
-		// don't generate tp_call for methods.
-		return
+	g.impl.Printf("static PyTypeObject %sType = {\n", it)
+	g.impl.Indent()
+	g.impl.Printf("PyVarObject_HEAD_INIT(NULL, 0)\n")
+	g.impl.Printf("\"%s._%s_iter\",\t/*tp_name*/\n", g.pkg.Name(), sym.goname)
+	g.impl.Printf("sizeof(%s),\t/*tp_basicsize*/\n", it)
+	g.impl.Printf("0,\t/*tp_itemsize*/\n")
+	g.impl.Printf("(destructor)%s_dealloc,\t/*tp_dealloc*/\n", it)
+	for _, slot := range []string{
+		"tp_print", "tp_getattr", "tp_setattr", "tp_compare", "tp_repr",
+		"tp_as_number", "tp_as_sequence", "tp_as_mapping", "tp_hash",
+		"tp_call", "tp_str", "tp_getattro", "tp_setattro", "tp_as_buffer",
+	} {
+		g.impl.Printf("0,\t/*%s*/\n", slot)
 	}
+	g.impl.Printf("Py_TPFLAGS_DEFAULT,\t/*tp_flags*/\n")
+	for _, slot := range []string{
+		"tp_doc", "tp_traverse", "tp_clear", "tp_richcompare",
+		"tp_weaklistoffset",
+	} {
+		g.impl.Printf("0,\t/*%s*/\n", slot)
+	}
+	g.impl.Printf("PyObject_SelfIter,\t/*tp_iter*/\n")
+	g.impl.Printf("(iternextfunc)%s_iternext,\t/*tp_iternext*/\n", it)
+	g.impl.Outdent()
+	g.impl.Printf("};\n\n")
+
+	g.decl.Printf("\n/* tp_iter */\n")
+	g.decl.Printf("static PyObject*\ncpy_func_%[1]s_tp_iter(%[2]s *self);\n",
+		sym.id,
+		sym.cpyname,
+	)
+
+	// iterating over a value starts a new pull of its values.
+	desc, id = typ.iterFunc("pull")
+	g.impl.Printf("\n/* tp_iter */\n")
+	g.impl.Printf("static PyObject*\ncpy_func_%[1]s_tp_iter(%[2]s *self) {\n",
+		sym.id,
+		sym.cpyname,
+	)
+	g.impl.Indent()
+	g.impl.Printf("int32_t ref = 0;\n")
+	g.impl.Printf("%[1]s *it = NULL;\n", it)
+	g.impl.Printf("cgopy_seq_buffer ibuf = cgopy_seq_buffer_new();\n")
+	g.impl.Printf("cgopy_seq_buffer obuf = cgopy_seq_buffer_new();\n")
+	g.genWrite("self->cgopy", "ibuf", sym.GoType())
+	g.genSend(desc, id, false)
+	g.impl.Printf("ref = cgopy_seq_buffer_read_int32(obuf);\n")
+	g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
+	g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
+	g.impl.Printf("it = PyObject_New(%[1]s, &%[1]sType);\n", it)
+	g.impl.Printf("if (it == NULL) {\n")
+	g.impl.Indent()
+	g.impl.Printf("cgopy_seq_destroy_ref(ref);\n")
+	g.impl.Printf("return NULL;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
+	g.impl.Printf("it->ref = ref;\n")
+	g.impl.Printf("return (PyObject*)it;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
+
+// genTypeTPAsBuffer generates the buffer protocol of byte arrays and slices,
+// giving access to their storage without copying it.
+// The address of the storage is asked to go each time: it changes as slices
+// grow. New-style buffers, such as memoryviews, hold a pin of the storage
+// until they are released.
+func (g *cpyGen) genTypeTPAsBuffer(typ Type) {
+	sym := typ.sym
+	desc, id := typ.bufferFunc()
+
+	g.decl.Printf("\n/* storage of %s */\n", sym.gofmt())
+	g.decl.Printf("static void\n")
+	g.decl.Printf("cpy_func_%[1]s_buffer(%[2]s *self, int32_t *ref, void **buf, Py_ssize_t *len);\n",
+		sym.id,
+		sym.cpyname,
+	)
+
+	g.impl.Printf("\n/* storage of %s, pinned by the handle ref */\n", sym.gofmt())
+	g.impl.Printf("static void\n")
+	g.impl.Printf("cpy_func_%[1]s_buffer(%[2]s *self, int32_t *ref, void **buf, Py_ssize_t *len) {\n",
+		sym.id,
+		sym.cpyname,
+	)
+	g.impl.Indent()
+	g.impl.Printf("cgopy_seq_buffer ibuf = cgopy_seq_buffer_new();\n")
+	g.impl.Printf("cgopy_seq_buffer obuf = cgopy_seq_buffer_new();\n")
+	g.genWrite("self->cgopy", "ibuf", sym.GoType())
+	g.genSend(desc, id, false)
+	g.impl.Printf("*ref = cgopy_seq_buffer_read_int32(obuf);\n")
+	g.impl.Printf("*buf = (void*)(intptr_t)cgopy_seq_buffer_read_int64(obuf);\n")
+	g.impl.Printf("*len = (Py_ssize_t)cgopy_seq_buffer_read_int64(obuf);\n")
+	g.impl.Printf("cgopy_seq_buffer_free(ibuf);\n")
+	g.impl.Printf("cgopy_seq_buffer_free(obuf);\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+
+	g.decl.Printf("\n/* getbuffer */\n")
+	g.decl.Printf("static int\n")
+	g.decl.Printf("cpy_func_%[1]s_getbuffer(%[2]s *self, Py_buffer *view, int flags);\n",
+		sym.id,
+		sym.cpyname,
+	)
+
+	g.impl.Printf("\n/* getbuffer */\n")
+	g.impl.Printf("static int\n")
+	g.impl.Printf("cpy_func_%[1]s_getbuffer(%[2]s *self, Py_buffer *view, int flags) {\n",
+		sym.id,
+		sym.cpyname,
+	)
+	g.impl.Indent()
+	g.impl.Printf("int32_t ref = 0;\n")
+	g.impl.Printf("void *buf = NULL;\n")
+	g.impl.Printf("Py_ssize_t len = 0;\n")
+	g.impl.Printf("if (view == NULL) {\n")
+	g.impl.Printf("\treturn 0;\n")
+	g.impl.Printf("}\n")
+	g.impl.Printf("cpy_func_%[1]s_buffer(self, &ref, &buf, &len);\n", sym.id)
+	g.impl.Printf("/* the view holds the pin of the storage, released with it. */\n")
+	g.impl.Printf("return cpy_%[1]s_pin_buffer(ref, buf, len, view, flags);\n", g.pkg.Name())
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+
+	g.genVersions(func(lang int) {
+		switch lang {
+		case 2:
+			// old-style buffers use the storage right away: it stays
+			// reachable from self, and is not pinned beyond the call.
+			g.decl.Printf("\n/* readbuffer */\n")
+			g.decl.Printf("static Py_ssize_t\n")
+			g.decl.Printf(
+				"cpy_func_%[1]s_readbuffer(%[2]s *self, Py_ssize_t segment, void **ptr);\n",
+				sym.id,
+				sym.cpyname,
+			)
+
+			g.impl.Printf("\n/* readbuffer */\n")
+			g.impl.Printf("static Py_ssize_t\n")
+			g.impl.Printf(
+				"cpy_func_%[1]s_readbuffer(%[2]s *self, Py_ssize_t segment, void **ptr) {\n",
+				sym.id,
+				sym.cpyname,
+			)
+			g.impl.Indent()
+			g.impl.Printf("int32_t ref = 0;\n")
+			g.impl.Printf("Py_ssize_t len = 0;\n")
+			g.impl.Printf("if (segment != 0) {\n")
+			g.impl.Indent()
+			g.impl.Printf("PyErr_SetString(PyExc_SystemError, ")
+			g.impl.Printf("\"accessing non-existent buffer segment\");\n")
+			g.impl.Printf("return -1;\n")
+			g.impl.Outdent()
+			g.impl.Printf("}\n")
+			g.impl.Printf("cpy_func_%[1]s_buffer(self, &ref, ptr, &len);\n", sym.id)
+			g.impl.Printf("cgopy_seq_destroy_ref(ref);\n")
+			g.impl.Printf("return len;\n")
+			g.impl.Outdent()
+			g.impl.Printf("}\n\n")
+
+			g.decl.Printf("\n/* segcount */\n")
+			g.decl.Printf("static Py_ssize_t\n")
+			g.decl.Printf("cpy_func_%[1]s_segcount(%[2]s *self, Py_ssize_t *lenp);\n",
+				sym.id,
+				sym.cpyname,
+			)
+
+			g.impl.Printf("\n/* segcount */\n")
+			g.impl.Printf("static Py_ssize_t\n")
+			g.impl.Printf("cpy_func_%[1]s_segcount(%[2]s *self, Py_ssize_t *lenp) {\n",
+				sym.id,
+				sym.cpyname,
+			)
+			g.impl.Indent()
+			g.impl.Printf("void *ptr = NULL;\n")
+			g.impl.Printf("Py_ssize_t len = cpy_func_%[1]s_readbuffer(self, 0, &ptr);\n", sym.id)
+			g.impl.Printf("if (lenp) { *lenp = len; }\n")
+			g.impl.Printf("return 1;\n")
+			g.impl.Outdent()
+			g.impl.Printf("}\n\n")
+
+			g.impl.Printf("\n/* tp_as_buffer */\n")
+			g.impl.Printf("static PyBufferProcs %[1]s_tp_as_buffer = {\n", sym.cpyname)
+			g.impl.Indent()
+			g.impl.Printf("(readbufferproc)cpy_func_%[1]s_readbuffer,\n", sym.id)
+			g.impl.Printf("(writebufferproc)cpy_func_%[1]s_readbuffer,\n", sym.id)
+			g.impl.Printf("(segcountproc)cpy_func_%[1]s_segcount,\n", sym.id)
+			g.impl.Printf("(charbufferproc)cpy_func_%[1]s_readbuffer,\n", sym.id)
+			g.impl.Printf("(getbufferproc)cpy_func_%[1]s_getbuffer,\n", sym.id)
+			g.impl.Printf("(releasebufferproc)0,\n")
+			g.impl.Outdent()
+			g.impl.Printf("};\n\n")
+		case 3:
+
+			g.impl.Printf("\n/* tp_as_buffer */\n")
+			g.impl.Printf("static PyBufferProcs %[1]s_tp_as_buffer = {\n", sym.cpyname)
+			g.impl.Indent()
+			g.impl.Printf("(getbufferproc)cpy_func_%[1]s_getbuffer,\n", sym.id)
+			g.impl.Printf("(releasebufferproc)0,\n")
+			g.impl.Outdent()
+			g.impl.Printf("};\n\n")
+		}
+	}, g.decl, g.impl)
+}
+
+// genTypeTPCall generates the tp_call slot of callable func types.
+// The call is placed through the func generated for typ.funcs.call.
+func (g *cpyGen) genTypeTPCall(typ Type) {
+	sym := typ.sym
+	call := typ.funcs.call
+
+	g.genMethod(typ, call)
 
 	g.decl.Printf("\n/* tp_call */\n")
 	g.decl.Printf("static PyObject *\n")
 	g.decl.Printf(
-		"cpy_func_%[1]s_tp_call(%[2]s *self, PyObject *args, PyObject *other);\n",
+		"cpy_func_%[1]s_tp_call(%[2]s *self, PyObject *args, PyObject *kwds);\n",
 		sym.id,
 		sym.cpyname,
 	)
@@ -955,144 +2507,244 @@ func (g *cpyGen) genTypeTPCall(typ Type) {
 	g.impl.Printf("\n/* tp_call */\n")
 	g.impl.Printf("static PyObject *\n")
 	g.impl.Printf(
-		"cpy_func_%[1]s_tp_call(%[2]s *self, PyObject *args, PyObject *other) {\n",
+		"cpy_func_%[1]s_tp_call(%[2]s *self, PyObject *args, PyObject *kwds) {\n",
 		sym.id,
 		sym.cpyname,
 	)
 	g.impl.Indent()
+	g.impl.Printf("return cpy_func_%[1]s(self, args);\n", call.ID())
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
 
-	//TODO(sbinet): refactor/consolidate with genFuncBody
+// genTypeCallback generates the trampoline calling back the python
+// callables made into values of the func type typ, called by
+// cgopy_seq_callback. The arguments of the call are read from ibuf.
+// Whether the call succeeded is written to obuf, followed by the result, or
+// else by the message of the exception for funcs returning an error.
+// Other exceptions are reported as unraisable.
+// The result of the call is returned, to be released once go has read it.
+func (g *cpyGen) genTypeCallback(typ Type) {
+	sym := typ.sym
+	call := typ.funcs.call
+	args := call.Signature().Params()
+	res := call.Signature().Results()
+	nres := len(res)
+	if call.err {
+		nres--
+	}
 
-	funcArgs := []string{"self->cgopy"}
-	args := newVarsFrom(g.pkg, sig.Params())
-	res := newVarsFrom(g.pkg, sig.Results())
+	g.decl.Printf("\n/* callback trampoline for %s */\n", sym.gofmt())
+	g.decl.Printf("static PyObject*\n")
+	g.decl.Printf("cgopy_callback_%s(PyObject *fn, cgopy_seq_buffer ibuf, cgopy_seq_buffer obuf);\n", sym.id)
 
+	g.impl.Printf("\n/* callback trampoline for %s */\n", sym.gofmt())
+	g.impl.Printf("static PyObject*\n")
+	g.impl.Printf("cgopy_callback_%s(PyObject *fn, cgopy_seq_buffer ibuf, cgopy_seq_buffer obuf) {\n", sym.id)
+	g.impl.Indent()
 	for _, arg := range args {
 		arg.genDecl(g.impl)
-		funcArgs = append(funcArgs, arg.getFuncArg())
 	}
+	if nres > 0 {
+		res[0].genRetDecl(g.impl)
+	}
+	g.impl.Printf("PyObject *pyargs = NULL;\n")
+	g.impl.Printf("PyObject *pyout = NULL;\n\n")
 
-	if len(res) > 0 {
-		switch len(res) {
-		case 1:
-			ret := res[0]
-			ret.genRetDecl(g.impl)
-		default:
-			g.impl.Printf(
-				"struct cgo_func_%[1]s_tp_call_return c_gopy_ret;\n",
-				sym.id,
-			)
+	format := []string{}
+	addrs := []string{}
+	for _, arg := range args {
+		g.genRead("c_"+arg.Name(), "ibuf", arg.GoType())
+		pyfmt, pyaddrs := arg.getArgBuildValue(g.lang)
+		format = append(format, pyfmt)
+		addrs = append(addrs, pyaddrs...)
+	}
+	g.impl.Printf("pyargs = Py_BuildValue(%q", "("+strings.Join(format, "")+")")
+	for _, addr := range addrs {
+		g.impl.Printf(", %s", addr)
+	}
+	g.impl.Printf(");\n")
+	for _, arg := range args {
+		if arg.sym.cgoname == "cgopy_seq_bytearray" {
+			g.impl.Printf("cgopy_seq_bytearray_free(c_%s);\n", arg.Name())
 		}
 	}
+	g.impl.Printf("if (pyargs != NULL) {\n")
+	g.impl.Indent()
+	g.impl.Printf("pyout = PyObject_CallObject(fn, pyargs);\n")
+	g.impl.Printf("Py_DECREF(pyargs);\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n")
 
-	g.impl.Printf("\n")
-
-	if len(args) > 0 {
-		g.impl.Printf("if (!PyArg_ParseTuple(args, ")
-		format := []string{}
-		pyaddrs := []string{}
-		for _, arg := range args {
-			pyfmt, addr := arg.getArgParse()
-			format = append(format, pyfmt)
-			pyaddrs = append(pyaddrs, addr...)
+	if nres > 0 {
+		ret := res[0]
+		rsym := ret.sym
+		if rsym.isBasic() && rsym.isNamed() {
+			// values of named basic types are returned as values of
+			// their underlying type.
+			rsym = g.pkg.syms.symtype(rsym.GoType().Underlying())
+		}
+		cond := fmt.Sprintf("!%s(pyout, &c_gopy_ret)", rsym.py2c)
+		if b, ok := ret.GoType().Underlying().(*types.Basic); ok && b.Info()&types.IsInteger != 0 {
+			cond = fmt.Sprintf("!cgopy_check_int(pyout) || %s", cond)
 		}
-		g.impl.Printf("%q, %s)) {\n", strings.Join(format, ""), strings.Join(pyaddrs, ", "))
+		g.impl.Printf("if (pyout != NULL && (%s)) {\n", cond)
 		g.impl.Indent()
-		g.impl.Printf("return NULL;\n")
+		g.impl.Printf("Py_CLEAR(pyout);\n")
 		g.impl.Outdent()
-		g.impl.Printf("}\n\n")
+		g.impl.Printf("}\n")
 	}
 
-	if len(args) > 0 {
-		for _, arg := range args {
-			arg.genFuncPreamble(g.impl)
-		}
-		g.impl.Printf("\n")
+	g.impl.Printf("if (pyout == NULL) {\n")
+	g.impl.Indent()
+	g.impl.Printf("cgopy_seq_buffer_write_bool(obuf, 0);\n")
+	if call.err {
+		g.impl.Printf("cgopy_seq_buffer_write_exception(obuf);\n")
+	} else {
+		g.impl.Printf("PyErr_WriteUnraisable(fn);\n")
 	}
+	g.impl.Printf("return NULL;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
 
-	if len(res) > 0 {
-		g.impl.Printf("c_gopy_ret = ")
+	g.impl.Printf("cgopy_seq_buffer_write_bool(obuf, 1);\n")
+	if nres > 0 {
+		g.genWrite("c_gopy_ret", "obuf", res[0].GoType())
+		if res[0].sym.cgoname == "cgopy_seq_bytearray" {
+			g.impl.Printf("cgopy_seq_bytearray_free(c_gopy_ret);\n")
+		}
 	}
-	g.impl.Printf("cgo_func_%[1]s_call(%[2]s);\n", sym.id, strings.Join(funcArgs, ", "))
+	g.impl.Printf("return pyout;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
+}
 
-	g.impl.Printf("\n")
+// genTypeFileCallback generates the trampoline calling the read or write
+// method of the python file objects made into io.Reader or io.Writer
+// values, called by cgopy_seq_callback.
+// read is called with the number of bytes wanted, read from ibuf, and
+// write with the bytes read from ibuf. Whether the call succeeded is
+// written to obuf, followed by the bytes read or the number of bytes
+// written, or else by the message of the exception.
+// The result of the call is returned, to be released once go has read it.
+func (g *cpyGen) genTypeFileCallback(typ Type) {
+	sym := typ.sym
+	meth := streamMethod(sym.GoType())
 
-	if len(res) <= 0 {
-		g.impl.Printf("Py_INCREF(Py_None);\nreturn Py_None;\n")
+	g.decl.Printf("\n/* callback trampoline for %s */\n", sym.gofmt())
+	g.decl.Printf("static PyObject*\n")
+	g.decl.Printf("cgopy_callback_%s(PyObject *fn, cgopy_seq_buffer ibuf, cgopy_seq_buffer obuf);\n", sym.id)
+
+	g.impl.Printf("\n/* callback trampoline for %s */\n", sym.gofmt())
+	g.impl.Printf("static PyObject*\n")
+	g.impl.Printf("cgopy_callback_%s(PyObject *fn, cgopy_seq_buffer ibuf, cgopy_seq_buffer obuf) {\n", sym.id)
+	g.impl.Indent()
+	g.impl.Printf("PyObject *pyout = NULL;\n")
+	switch meth {
+	case "read":
+		g.impl.Printf("Py_buffer view;\n")
+		g.impl.Printf("cgopy_seq_bytearray c_gopy_ret;\n")
+		g.impl.Printf("Py_ssize_t n = (Py_ssize_t)cgopy_seq_buffer_read_int64(ibuf);\n\n")
+		g.impl.Printf("pyout = PyObject_CallMethod(fn, \"read\", \"n\", n);\n")
+		g.impl.Printf("if (pyout != NULL && PyObject_GetBuffer(pyout, &view, PyBUF_SIMPLE) < 0) {\n")
+		g.impl.Indent()
+		g.impl.Printf("Py_CLEAR(pyout);\n")
 		g.impl.Outdent()
-		g.impl.Printf("}\n\n")
-		return
+		g.impl.Printf("}\n")
+		g.impl.Printf("if (pyout != NULL && view.len > n) {\n")
+		g.impl.Indent()
+		g.impl.Printf("PyErr_Format(PyExc_ValueError, \"read(%%zd) returned %%zd bytes\", n, view.len);\n")
+		g.impl.Printf("PyBuffer_Release(&view);\n")
+		g.impl.Printf("Py_CLEAR(pyout);\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+	case "write":
+		g.impl.Printf("cgopy_seq_bytearray c_p = cgopy_seq_buffer_read_bytearray(ibuf);\n")
+		g.impl.Printf("int64_t n = c_p.Len;\n")
+		g.impl.Printf("PyObject *p = PyString_FromStringAndSize((const char*)(c_p.Data), (Py_ssize_t)(c_p.Len));\n")
+		g.impl.Printf("cgopy_seq_bytearray_free(c_p);\n\n")
+		g.impl.Printf("if (p != NULL) {\n")
+		g.impl.Indent()
+		g.impl.Printf("pyout = PyObject_CallMethod(fn, \"write\", \"(O)\", p);\n")
+		g.impl.Printf("Py_DECREF(p);\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+		// files of python 2 return None, io objects the number of bytes
+		// written.
+		g.impl.Printf("if (pyout != NULL && pyout != Py_None) {\n")
+		g.impl.Indent()
+		g.impl.Printf("n = (int64_t)PyNumber_AsSsize_t(pyout, PyExc_OverflowError);\n")
+		g.impl.Printf("if (n == -1 && PyErr_Occurred()) {\n")
+		g.impl.Indent()
+		g.impl.Printf("Py_CLEAR(pyout);\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
 	}
 
-	if hasError(sig) {
-		switch len(res) {
-		case 1:
-			g.impl.Printf("if (!_cgopy_ErrorIsNil(c_gopy_ret)) {\n")
-			g.impl.Indent()
-			g.impl.Printf("const char* c_err_str = _cgopy_ErrorString(c_gopy_ret);\n")
-			g.impl.Printf("PyErr_SetString(PyExc_RuntimeError, c_err_str);\n")
-			g.impl.Printf("free((void*)c_err_str);\n")
-			g.impl.Printf("return NULL;\n")
-			g.impl.Outdent()
-			g.impl.Printf("}\n\n")
-			g.impl.Printf("Py_INCREF(Py_None);\nreturn Py_None;\n")
-			g.impl.Outdent()
-			g.impl.Printf("}\n\n")
-			return
-
-		case 2:
-			g.impl.Printf("if (!_cgopy_ErrorIsNil(c_gopy_ret.r1)) {\n")
-			g.impl.Indent()
-			g.impl.Printf("const char* c_err_str = _cgopy_ErrorString(c_gopy_ret.r1);\n")
-			g.impl.Printf("PyErr_SetString(PyExc_RuntimeError, c_err_str);\n")
-			g.impl.Printf("free((void*)c_err_str);\n")
-			g.impl.Printf("return NULL;\n")
-			g.impl.Outdent()
-			g.impl.Printf("}\n\n")
-			ret := res[0]
-			sret := g.pkg.syms.symtype(ret.GoType())
-			g.impl.Printf("return %s(&c_gopy_ret.r0);\n", sret.c2py)
-			g.impl.Outdent()
-			g.impl.Printf("}\n\n")
-			return
+	g.impl.Printf("if (pyout == NULL) {\n")
+	g.impl.Indent()
+	g.impl.Printf("cgopy_seq_buffer_write_bool(obuf, 0);\n")
+	g.impl.Printf("cgopy_seq_buffer_write_exception(obuf);\n")
+	g.impl.Printf("return NULL;\n")
+	g.impl.Outdent()
+	g.impl.Printf("}\n\n")
 
-		default:
-			panic(fmt.Errorf(
-				"bind: function/method with more than 2 results not supported! (%s)",
-				sym.id,
-			))
-		}
+	g.impl.Printf("cgopy_seq_buffer_write_bool(obuf, 1);\n")
+	switch meth {
+	case "read":
+		g.impl.Printf("c_gopy_ret.Data = (uint8_t*)view.buf;\n")
+		g.impl.Printf("c_gopy_ret.Len = (int64_t)view.len;\n")
+		g.impl.Printf("cgopy_seq_buffer_write_bytearray(obuf, c_gopy_ret);\n")
+		g.impl.Printf("PyBuffer_Release(&view);\n")
+	case "write":
+		g.impl.Printf("cgopy_seq_buffer_write_int64(obuf, n);\n")
 	}
-
-	ret := res[0]
-	sret := g.pkg.syms.symtype(ret.GoType())
-	g.impl.Printf("return %s(&c_gopy_ret);\n", sret.c2py)
+	g.impl.Printf("return pyout;\n")
 	g.impl.Outdent()
 	g.impl.Printf("}\n\n")
 }
 
+// implementers returns the wrapped types, but interfaces and basic types,
+// whose pointers implement the interface sym. Their values are passed to
+// the parameters of type sym by handle.
+func (g *cpyGen) implementers(sym *symbol) []Type {
+	iface := sym.GoType().Underlying().(*types.Interface)
+	var typs []Type
+	for _, t := range g.pkg.types {
+		if t.sym.isInterface() || t.sym.isBasic() {
+			continue
+		}
+		if types.Implements(types.NewPointer(t.GoType()), iface) {
+			typs = append(typs, t)
+		}
+	}
+	return typs
+}
+
 func (g *cpyGen) genTypeConverter(typ Type) {
 	sym := typ.sym
-	g.decl.Printf("\n/* converters for %s - %s */\n",
+	// the converters are declared by genTypeRegistry.
+	g.impl.Printf("\n/* converters for %s - %s */\n",
 		sym.id,
 		sym.goname,
 	)
-	g.decl.Printf("static int\n")
-	g.decl.Printf("cgopy_cnv_py2c_%[1]s(PyObject *o, int32_t *addr);\n",
-		sym.id,
-	)
-	g.decl.Printf("static PyObject*\n")
-	g.decl.Printf("cgopy_cnv_c2py_%[1]s(int32_t *addr);\n\n",
-		sym.id,
-	)
-
 	g.impl.Printf("static int\n")
-	g.impl.Printf("cgopy_cnv_py2c_%[1]s(PyObject *o, int32_t *addr) {\n",
+	g.impl.Printf("cgopy_cnv_py2c_%[1]s(PyObject *o, %[2]s *addr) {\n",
 		sym.id,
+		sym.cgoname,
 	)
 	g.impl.Indent()
 	g.impl.Printf("%s *self = NULL;\n", sym.cpyname)
 	if sym.isInterface() {
+		// None stands for a nil interface, as returned to python.
+		g.impl.Printf("if (o == Py_None) {\n")
+		g.impl.Indent()
+		g.impl.Printf("*addr = 0;\n")
+		g.impl.Printf("return 1;\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
 		g.impl.Printf("if (%s) {\n", fmt.Sprintf(sym.pychk, "o"))
 		g.impl.Indent()
 		g.impl.Printf("self = (%s *)o;\n", sym.cpyname)
@@ -1100,10 +2752,31 @@ func (g *cpyGen) genTypeConverter(typ Type) {
 		g.impl.Printf("return 1;\n")
 		g.impl.Outdent()
 		g.impl.Printf("}\n\n")
-		g.impl.Printf("GoInterface *iface = (GoInterface*)(addr);\n")
-		g.impl.Printf("*iface = ((gopy_object*)o)->eface((gopy_object*)o);\n")
-		g.impl.Printf("return 1;\n")
+		// values of wrapped types implementing the interface are passed
+		// by handle.
+		for _, t := range g.implementers(sym) {
+			g.impl.Printf("if (cpy_func_%s_check(o)) {\n", t.sym.id)
+			g.impl.Indent()
+			g.impl.Printf("*addr = ((%s*)o)->cgopy;\n", t.sym.cpyname)
+			g.impl.Printf("return 1;\n")
+			g.impl.Outdent()
+			g.impl.Printf("}\n\n")
+		}
+		g.impl.Printf(
+			"PyErr_Format(PyExc_TypeError, \"invalid type (got=%%s, expected a %s)\", Py_TYPE(o)->tp_name);\n",
+			sym.gofmt(),
+		)
+		g.impl.Printf("return 0;\n")
 	} else {
+		g.impl.Printf("if (!%s) {\n", fmt.Sprintf(sym.pychk, "o"))
+		g.impl.Indent()
+		g.impl.Printf(
+			"PyErr_Format(PyExc_TypeError, \"invalid type (got=%%s, expected a %s)\", Py_TYPE(o)->tp_name);\n",
+			sym.gofmt(),
+		)
+		g.impl.Printf("return 0;\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
 		g.impl.Printf("self = (%s *)o;\n", sym.cpyname)
 		g.impl.Printf("*addr = self->cgopy;\n")
 		g.impl.Printf("return 1;\n")
@@ -1112,19 +2785,44 @@ func (g *cpyGen) genTypeConverter(typ Type) {
 	g.impl.Printf("}\n\n")
 
 	g.impl.Printf("static PyObject*\n")
-	g.impl.Printf("cgopy_cnv_c2py_%[1]s(int32_t *addr) {\n", sym.id)
+	g.impl.Printf("cgopy_cnv_c2py_%[1]s(%[2]s *addr) {\n", sym.id, sym.cgoname)
 	g.impl.Indent()
-	g.impl.Printf("PyObject *o = cpy_func_%[1]s_new(&%[2]sType, 0, 0);\n",
-		sym.id,
-		sym.cpyname,
-	)
+	if !sym.isBasic() {
+		// the reference number 0 stands for a nil value.
+		g.impl.Printf("if (*addr == 0) {\n")
+		g.impl.Indent()
+		g.impl.Printf("Py_RETURN_NONE;\n")
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+	}
+	if typ.isSingleton() {
+		// the shared wrapper holds a value of its own.
+		g.impl.Printf("if (cpy_func_%s_singleton != NULL) {\n", sym.id)
+		g.impl.Indent()
+		g.impl.Printf("cgopy_seq_destroy_ref(*addr);\n")
+		g.impl.Printf("Py_INCREF(cpy_func_%s_singleton);\n", sym.id)
+		g.impl.Printf("return cpy_func_%s_singleton;\n", sym.id)
+		g.impl.Outdent()
+		g.impl.Printf("}\n")
+	}
+	// the wrapper is allocated without tp_new, which would make a go value
+	// of its own: its handle would be lost once replaced by *addr.
+	g.impl.Printf("PyObject *o = %[1]sType.tp_alloc(&%[1]sType, 0);\n", sym.cpyname)
 	g.impl.Printf("if (o == NULL) {\n")
 	g.impl.Indent()
 	g.impl.Printf("return NULL;\n")
 	g.impl.Outdent()
 	g.impl.Printf("}\n")
 	g.impl.Printf("((%[1]s*)o)->cgopy = *addr;\n", sym.cpyname)
-	g.impl.Printf("return o;\n")
+	if typ.isSingleton() {
+		g.impl.Printf("Py_INCREF(o);\n")
+		g.impl.Printf("cpy_func_%s_singleton = o;\n", sym.id)
+	}
+	if typ.isStrEnum() {
+		g.impl.Printf("return cgopy_enum_member(cpy_func_%s_enum, o);\n", sym.id)
+	} else {
+		g.impl.Printf("return o;\n")
+	}
 	g.impl.Outdent()
 	g.impl.Printf("}\n\n")
 
@@ -1132,16 +2830,7 @@ func (g *cpyGen) genTypeConverter(typ Type) {
 
 func (g *cpyGen) genTypeTypeCheck(typ Type) {
 	sym := typ.sym
-	g.decl.Printf(
-		"\n/* check-type function for %[1]s */\n",
-		sym.gofmt(),
-	)
-	g.decl.Printf("static int\n")
-	g.decl.Printf(
-		"cpy_func_%[1]s_check(PyObject *self);\n",
-		sym.id,
-	)
-
+	// the check-type function is declared by genTypeRegistry.
 	g.impl.Printf(
 		"\n/* check-type function for %[1]s */\n",
 		sym.gofmt(),
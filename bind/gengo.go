@@ -8,12 +8,14 @@ import (
 	"fmt"
 	"go/token"
 	"go/types"
+	"path"
+	"sort"
 	"strings"
 )
 
 const (
 	goPreamble = `// Package main is an autogenerated binder stub for package %[1]s.
-// gopy gen -lang=go %[1]s
+// gopy gen -lang=go %[5]s
 //
 // File is generated by gopy gen. Do not edit.
 package main
@@ -26,17 +28,31 @@ package main
 import "C"
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
 	"unsafe"
 
 	"github.com/go-python/gopy/bind/seq"
 	
-	%[3]s
+	%[3]s%[4]s
 )
 
 var (
 	_ = unsafe.Pointer(nil)
+	_ = errors.Is
 	_ = fmt.Sprintf
+	_ = big.NewInt
+	_ = os.NewFile
+	_ = reflect.ValueOf
+	_ = sort.Slice
+	_ = sync.NewCond
+	_ = time.Second
 	_ = seq.Delete
 )
 
@@ -52,6 +68,81 @@ func _cgopy_ErrorString(err error) *C.char {
 	return C.CString(err.Error())
 }
 
+// _cgopy_Format formats v with the fmt verb of the python format spec,
+// made of flags, width and precision followed by a single verb: %%v if
+// spec is empty.
+func _cgopy_Format(spec string, v interface{}) (string, error) {
+	if spec == "" {
+		spec = "v"
+	}
+	for i, c := range spec {
+		last := i == len(spec)-1
+		switch {
+		case last && ('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'):
+		case !last && (c == '+' || c == '-' || c == '#' || c == ' ' || c == '.' || '0' <= c && c <= '9'):
+		default:
+			return "", fmt.Errorf("invalid format spec %%q", spec)
+		}
+	}
+	typ := fmt.Sprintf("%%T", v)
+	if rv, ok := v.(reflect.Value); ok {
+		// values holding a lock, and the ones of internal packages, are
+		// formatted through reflection.
+		typ = rv.Type().String()
+	}
+	if spec[len(spec)-1] == 'T' {
+		return fmt.Sprintf("%%"+spec[:len(spec)-1]+"s", typ), nil
+	}
+	str := fmt.Sprintf("%%"+spec, v)
+	for i := 0; i+1 < len(str); i++ {
+		// fmt reports bad verbs in the formatted string: %%!d(string=a)
+		if str[i] == '%%' && str[i+1] == '!' {
+			return "", fmt.Errorf("invalid format spec %%q for %%s", spec, typ)
+		}
+	}
+	return str, nil
+}
+
+// _cgopy_Call calls fn through reflection, for the funcs and methods whose
+// signatures hold types of internal packages, which can not be named here:
+// nil args stand for the zero values of their parameters, and the structs
+// held by pointer are passed by value to the parameters taking them so.
+func _cgopy_Call(fn reflect.Value, args ...interface{}) []interface{} {
+	typ := fn.Type()
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		t := typ.In(i)
+		v := reflect.ValueOf(arg)
+		switch {
+		case arg == nil:
+			in[i] = reflect.Zero(t)
+		case v.Kind() == reflect.Ptr && !v.Type().AssignableTo(t) && v.Type().Elem().AssignableTo(t):
+			in[i] = v.Elem()
+		default:
+			in[i] = v
+		}
+	}
+	var out []reflect.Value
+	if typ.IsVariadic() {
+		out = fn.CallSlice(in)
+	} else {
+		out = fn.Call(in)
+	}
+	res := make([]interface{}, len(out))
+	for i, v := range out {
+		res[i] = v.Interface()
+	}
+	return res
+}
+
+// _cgopy_Ptr returns a pointer to a copy of the struct v, returned through
+// reflection: structs are held by pointer.
+func _cgopy_Ptr(v interface{}) interface{} {
+	p := reflect.New(reflect.TypeOf(v))
+	p.Elem().Set(reflect.ValueOf(v))
+	return p.Interface()
+}
+
 // --- end cgo helpers ---
 
 func init() {
@@ -86,6 +177,13 @@ func (g *goGen) gen() error {
 	// create a Cgo hook for empty packages
 	g.genPackage()
 
+	for _, conv := range g.pkg.convs {
+		g.genConverter(conv)
+	}
+	for _, kind := range g.pkg.syms.netTypes() {
+		g.genNetType(kind)
+	}
+
 	// process slices, arrays, ...
 	for _, t := range g.pkg.types {
 		g.genType(t)
@@ -96,6 +194,9 @@ func (g *goGen) gen() error {
 		for _, ctor := range t.ctors {
 			g.genFunc(ctor)
 		}
+		for _, f := range t.statics {
+			g.genFunc(f)
+		}
 	}
 
 	for _, f := range g.pkg.funcs {
@@ -110,6 +211,10 @@ func (g *goGen) gen() error {
 		g.genVar(v)
 	}
 
+	g.genSelect()
+	g.genErrors()
+	g.genHandleCount()
+
 	g.Printf("func init() {\n")
 	g.Indent()
 
@@ -133,7 +238,13 @@ func (g *goGen) gen() error {
 }
 
 func (g *goGen) genPackage() {
-	g.Printf("\n//export cgo_pkg_%[1]s_init\n", g.pkg.Name())
+	// the python module init function calls this hook each time the module
+	// is (re-)imported, possibly from several sub-interpreters.
+	// it must stay idempotent: the wrapped package is initialized only once,
+	// by the go runtime, when the shared library is loaded.
+	g.Printf("\n// cgo_pkg_%[1]s_init makes sure cgo is loaded.\n", g.pkg.Name())
+	g.Printf("// It may be called several times and has no side effects.\n")
+	g.Printf("//export cgo_pkg_%[1]s_init\n", g.pkg.Name())
 	g.Printf("func cgo_pkg_%[1]s_init() {}\n\n", g.pkg.Name())
 	g.Printf("const cgopy_seq_pkg_DESCRIPTOR string = %q\n\n", g.pkg.ImportPath())
 
@@ -175,9 +286,142 @@ func (g *goGen) genVar(o Var) {
 	g.genFunc(fset)
 }
 
+// genConverter generates the go side of the converter of a type: the
+// conversion of the values read from python, and the check of these values
+// python runs before sending them.
+func (g *goGen) genConverter(conv *converter) {
+	id := conv.id()
+	typ := g.pkg.Name() + "." + conv.obj.Name()
+	ret := typ
+	if conv.held() {
+		// held by pointer, as the wrapped structs, arrays and slices.
+		ret = "*" + typ
+	}
+	g.Printf("// cgopy_from_%[1]s converts v to a %[2]s, with %[3]s.\n", id, typ, conv.from.Name())
+	g.Printf("// python checked v with cgo_func_%[1]s_check.\n", id)
+	g.Printf("func cgopy_from_%[1]s(v %[2]s) %[3]s {\n", id, conv.base.Name(), ret)
+	g.Indent()
+	g.Printf("o, _ := %s.%s(v)\n", g.pkg.Name(), conv.from.Name())
+	if conv.held() {
+		g.Printf("return &o\n")
+	} else {
+		g.Printf("return o\n")
+	}
+	g.Outdent()
+	g.Printf("}\n\n")
+
+	g.Printf("// cgo_func_%[1]s_check checks %[2]s converts v to a %[3]s.\n", id, conv.from.Name(), typ)
+	g.Printf("func cgo_func_%[1]s_check(out, in *seq.Buffer) {\n", id)
+	g.Indent()
+	g.Printf("_, err := %s.%s(in.Read%s())\n", g.pkg.Name(), conv.from.Name(), g.seqType(conv.base))
+	g.genWriteError("err", "out")
+	g.Outdent()
+	g.Printf("}\n\n")
+
+	g.regs = append(g.regs, goReg{
+		Descriptor: g.pkg.ImportPath() + "." + conv.obj.Name() + ".check",
+		ID:         uhash(id + "_check"),
+		Func:       id + "_check",
+	})
+}
+
+// genNetType generates the go side of the check of the text of a net.IP,
+// or a *url.URL, named kind, which python runs before sending it.
+func (g *goGen) genNetType(kind string) {
+	id := g.pkg.Name() + "__gopy_check_" + strings.ToLower(kind)
+	parse := "net.ParseIP"
+	if kind == "URL" {
+		parse = "url.Parse"
+	}
+	g.Printf("// cgo_func_%[1]s checks %[2]s parses the text of a %[3]s.\n", id, parse, netGoType(kind))
+	g.Printf("func cgo_func_%[1]s(out, in *seq.Buffer) {\n", id)
+	g.Indent()
+	g.Printf("s := string(in.ReadByteArray())\n")
+	if kind == "URL" {
+		g.Printf("_, err := url.Parse(s)\n")
+	} else {
+		g.Printf("var err error\n")
+		g.Printf("if net.ParseIP(s) == nil {\n")
+		g.Printf("\terr = fmt.Errorf(\"invalid IP address %%q\", s)\n")
+		g.Printf("}\n")
+	}
+	g.genWriteError("err", "out")
+	g.Outdent()
+	g.Printf("}\n\n")
+
+	g.regs = append(g.regs, goReg{
+		Descriptor: g.pkg.ImportPath() + "._gopy_check_" + strings.ToLower(kind),
+		ID:         uhash(id),
+		Func:       id,
+	})
+}
+
+// genSelect generates the go side of the select function of the module,
+// waiting for a value from one of several channels.
+func (g *goGen) genSelect() {
+	if len(g.pkg.selectTypes()) == 0 {
+		return
+	}
+	id := g.pkg.Name() + "_select"
+	g.Printf("// cgo_func_%[1]s waits for a value from one of several channels.\n", id)
+	g.Printf("func cgo_func_%[1]s(out, in *seq.Buffer) {\n", id)
+	g.Indent()
+	g.Printf("chans := make([]interface{}, in.ReadInt64())\n")
+	g.Printf("for i := range chans {\n")
+	g.Printf("\tchans[i] = in.ReadRef().Get()\n")
+	g.Printf("}\n")
+	g.Printf("i, v, ok := seq.Select(chans, in.ReadFloat64())\n")
+	g.Printf("out.WriteInt64(int64(i))\n")
+	g.Printf("out.WriteValue(v)\n")
+	g.Printf("out.WriteBool(ok)\n")
+	g.Outdent()
+	g.Printf("}\n\n")
+
+	g.regs = append(g.regs, goReg{
+		Descriptor: g.pkg.ImportPath() + ".select",
+		ID:         uhash(id),
+		Func:       id,
+	})
+}
+
+// genErrors generates the go side of the errors_is and errors_as functions
+// of the module.
+func (g *goGen) genErrors() {
+	f := g.pkg.errorsIs
+	g.Printf("// cgo_func_%[1]s_ reports whether an error of the chain of err is target.\n", f.ID())
+	g.Printf("func cgo_func_%[1]s_(err, target error) bool {\n", f.ID())
+	g.Printf("\treturn errors.Is(err, target)\n")
+	g.Printf("}\n\n")
+	g.genFunc(f)
+
+	for _, t := range g.pkg.types {
+		if t.isErrorsAsTarget() {
+			g.genTypeErrorsAs(t)
+		}
+	}
+}
+
+// genHandleCount generates the go side of the _gopy_handle_count function
+// of the module, returning the number of go values held by python.
+func (g *goGen) genHandleCount() {
+	id := g.pkg.Name() + "__gopy_handle_count"
+	g.Printf("// cgo_func_%[1]s returns the number of go values held by python.\n", id)
+	g.Printf("func cgo_func_%[1]s(out, in *seq.Buffer) {\n", id)
+	g.Indent()
+	g.Printf("out.WriteInt64(int64(seq.NumRefs()))\n")
+	g.Outdent()
+	g.Printf("}\n\n")
+
+	g.regs = append(g.regs, goReg{
+		Descriptor: g.pkg.ImportPath() + "._gopy_handle_count",
+		ID:         uhash(id),
+		Func:       id,
+	})
+}
+
 func (g *goGen) genPreamble() {
 	n := g.pkg.pkg.Name()
-	pkgimport := fmt.Sprintf("%q", g.pkg.pkg.Path())
+	pkgimport := importSpec(g.pkg.pkg)
 	if g.pkg.n == 0 {
 		pkgimport = fmt.Sprintf("_ %q", g.pkg.pkg.Path())
 	}
@@ -187,7 +431,69 @@ func (g *goGen) genPreamble() {
 		panic(err)
 	}
 
-	g.Printf(goPreamble, n, pkgcfg, pkgimport)
+	g.Printf(goPreamble, n, pkgcfg, pkgimport, g.extImports(), g.pkg.ImportPath())
+}
+
+// importSpec returns the import spec of pkg, which names it when its name
+// is not the last element of its path, e.g. foo "example.com/foo/v2" for the
+// major versions of modules.
+func importSpec(pkg *types.Package) string {
+	if path.Base(pkg.Path()) == pkg.Name() {
+		return fmt.Sprintf("%q", pkg.Path())
+	}
+	return fmt.Sprintf("%s %q", pkg.Name(), pkg.Path())
+}
+
+// extImports returns the import lines for the packages declaring the
+// external types wrapped alongside the package.
+func (g *goGen) extImports() string {
+	var imports []string
+	seen := map[string]bool{
+		"errors":   true, // imported by the preamble, for errors_is and errors_as.
+		"fmt":      true, // imported by the preamble, for the errors of the helpers.
+		"math/big": true, // imported by the preamble, for *big.Int values.
+		"os":       true, // imported by the preamble, for *os.File values.
+		"reflect":  true, // imported by the preamble, for the deep equality of structs.
+		"sort":     true, // imported by the preamble, for the keys of maps.
+		"sync":     true, // imported by the preamble, for the pulls of iterators.
+		"time":     true, // imported by the preamble, for time.Duration values.
+		"unsafe":   true, // imported by the preamble, for the C pointers.
+	}
+	for _, t := range g.pkg.types {
+		var pkg *types.Package
+		switch named, ok := t.GoType().(*types.Named); {
+		case t.isOpaque():
+			// internal packages can not be imported.
+			continue
+		case t.isExternal():
+			pkg = t.obj.Pkg()
+		case ok && isInstance(named) && named.Obj().Pkg() != g.pkg.pkg:
+			// instances of generic types declared in other packages, such
+			// as iter.Seq[int], are wrapped under a generated name.
+			pkg = named.Obj().Pkg()
+		default:
+			continue
+		}
+		if seen[pkg.Path()] {
+			continue
+		}
+		seen[pkg.Path()] = true
+		imports = append(imports, "\n\t"+importSpec(pkg))
+	}
+	for _, kind := range g.pkg.syms.netTypes() {
+		// IPs and URLs are exchanged as their text.
+		path := "net"
+		if kind == "URL" {
+			path = "net/url"
+		}
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		imports = append(imports, fmt.Sprintf("\n\t%q", path))
+	}
+	sort.Strings(imports)
+	return strings.Join(imports, "")
 }
 
 func (g *goGen) tupleString(tuple []*Var) string {
@@ -210,64 +516,182 @@ func (g *goGen) tupleString(tuple []*Var) string {
 }
 
 func (g *goGen) genRead(valName, seqName string, T types.Type) {
-	if isErrorType(T) {
-		g.Printf("%s := %s.ReadError()\n", valName, seqName)
+	if conv := g.pkg.syms.conv(T); conv != nil {
+		g.Printf("%[2]s := cgopy_from_%[3]s(%[1]s.Read%[4]s())\n", seqName, valName, conv.id(), g.seqType(conv.base))
 		return
 	}
-
-	switch T := T.(type) {
+	if isOpaqueType(T) {
+		g.Printf("%[2]s := %[1]s.ReadRef().Get()\n", seqName, valName)
+		return
+	}
+	switch g.pkg.syms.netType(T) {
+	case "IP":
+		// held by pointer, as the wrapped slices.
+		g.Printf("%[2]s_ip := %[1]s.ReadIP()\n", seqName, valName)
+		g.Printf("%[1]s := &%[1]s_ip\n", valName)
+		return
+	case "URL":
+		g.Printf("%[2]s := %[1]s.ReadURL()\n", seqName, valName)
+		return
+	}
+	switch T := unalias(T).(type) {
 	case *types.Basic:
 		g.Printf("%s := %s.Read%s()\n", valName, seqName, g.seqType(T))
 
 	case *types.Named:
 		switch u := T.Underlying().(type) {
-		case *types.Interface, *types.Pointer, *types.Struct,
+		case *types.Interface:
+			g.Printf(
+				"%[2]s, _ := %[1]s.ReadRef().Get().(%[3]s)\n",
+				seqName, valName,
+				g.pkg.syms.symtype(T).gofmt(),
+			)
+		case *types.Pointer, *types.Struct,
 			*types.Array, *types.Slice:
 			g.Printf(
 				"%[2]s := %[1]s.ReadRef().Get().(*%[3]s)\n",
 				seqName, valName,
 				g.pkg.syms.symtype(T).gofmt(),
 			)
+		case *types.Signature, *types.Chan, *types.Map:
+			// funcs, chans and maps are held by pointer.
+			g.Printf(
+				"%[2]s := *%[1]s.ReadRef().Get().(*%[3]s)\n",
+				seqName, valName,
+				g.pkg.syms.symtype(T).gofmt(),
+			)
 		case *types.Basic:
 			fctName := seqType(u)
-			typName := gofmt(g.pkg.Name(), T)
+			typName := g.pkg.syms.symtype(T).gofmt()
 			g.Printf("%[4]s := %[3]s(%[1]s.Read%[2]s());\n", seqName, fctName, typName, valName)
 		default:
 			panic(fmt.Errorf("unsupported, direct named type %s: %s", T, u))
 		}
+	case *types.Pointer:
+		if isFileType(T) {
+			g.Printf("%[2]s := %[1]s.ReadFile()\n", seqName, valName)
+			break
+		}
+		if kind := bigType(T); kind != "" {
+			g.Printf("%[2]s := %[1]s.ReadBig%[3]s()\n", seqName, valName, kind)
+			break
+		}
+		// structs are held by pointer, nil pointers being passed as
+		// the reference number 0.
+		g.Printf(
+			"%[2]s, _ := %[1]s.ReadRef().Get().(*%[3]s)\n",
+			seqName, valName,
+			g.pkg.syms.symtype(T.Elem()).gofmt(),
+		)
+	case *types.Struct, *types.Array, *types.Slice:
+		// anonymous structs, unnamed arrays and unnamed slices are held
+		// by pointer, as named ones.
+		g.Printf(
+			"%[2]s := %[1]s.ReadRef().Get().(*%[3]s)\n",
+			seqName, valName,
+			g.pkg.syms.symtype(T).gofmt(),
+		)
+	case *types.Signature:
+		// unnamed funcs are held by pointer, as named ones.
+		g.Printf(
+			"%[2]s := *%[1]s.ReadRef().Get().(*%[3]s)\n",
+			seqName, valName,
+			g.pkg.syms.symtype(T).gofmt(),
+		)
+	case *types.Map:
+		if !isDictType(T) {
+			// unnamed maps are held by pointer, as named ones.
+			g.Printf(
+				"%[2]s := *%[1]s.ReadRef().Get().(*%[3]s)\n",
+				seqName, valName,
+				g.pkg.syms.symtype(T).gofmt(),
+			)
+			break
+		}
+		g.Printf("%[2]s, _ := %[1]s.ReadValue().(map[string]interface{})\n", seqName, valName)
+	case *types.Interface:
+		if !T.Empty() {
+			panic(fmt.Errorf("gopy: unhandled type %s", T))
+		}
+		g.Printf("%[2]s := %[1]s.ReadValue()\n", seqName, valName)
 	default:
 		panic(fmt.Errorf("gopy: unhandled type %#T", T))
 	}
 }
 
+// genReadVariadic reads the items of a ...T parameter, sent as their
+// number followed by each item.
+func (g *goGen) genReadVariadic(valName, seqName string, T types.Type) {
+	elem := T.(*types.Slice).Elem()
+	g.Printf("%[1]s := make([]%[2]s, %[3]s.ReadInt())\n",
+		valName,
+		g.pkg.syms.symtype(elem).gofmt(),
+		seqName,
+	)
+	g.Printf("for i := range %s {\n", valName)
+	g.Indent()
+	g.genRead("v", seqName, elem)
+	switch elem.Underlying().(type) {
+	case *types.Struct, *types.Array, *types.Slice:
+		// structs, arrays and slices are held by pointer.
+		g.Printf("%s[i] = *v\n", valName)
+	default:
+		g.Printf("%s[i] = v\n", valName)
+	}
+	g.Outdent()
+	g.Printf("}\n")
+}
+
+// genWriteError writes the trailing error of a comma-error function.
+// A nil error is sent as an empty string.
+func (g *goGen) genWriteError(valName, seqName string) {
+	g.Printf("if %s == nil {\n", valName)
+	g.Printf("\t%s.WriteString(\"\");\n", seqName)
+	g.Printf("} else {\n")
+	g.Printf("\t%s.WriteString(%s.Error());\n", seqName, valName)
+	g.Printf("}\n")
+}
+
 func (g *goGen) genWrite(valName, seqName string, T types.Type) {
-	if isErrorType(T) {
-		g.Printf("if %s == nil {\n", valName)
-		g.Printf("\t%s.WriteString(\"\");\n", seqName)
-		g.Printf("} else {\n")
-		g.Printf("\t%s.WriteString(%s.Error());\n", seqName, valName)
-		g.Printf("}\n")
+	if conv := g.pkg.syms.conv(T); conv != nil {
+		g.genWrite(fmt.Sprintf("%s.%s(%s)", g.pkg.Name(), conv.to.Name(), valName), seqName, conv.base)
+		return
+	}
+	if kind := g.pkg.syms.netType(T); kind != "" {
+		g.Printf("%s.Write%s(%s)\n", seqName, kind, valName)
+		return
+	}
+	if isOpaqueType(T) {
+		// returned through reflection, structs by pointer.
+		g.Printf("%s.WriteGoRef(%s)\n", seqName, valName)
 		return
 	}
-	switch T := T.(type) {
+	switch T := unalias(T).(type) {
 	case *types.Pointer:
+		if isFileType(T) {
+			g.Printf("%s.WriteFile(%s)\n", seqName, valName)
+			break
+		}
+		if kind := bigType(T); kind != "" {
+			g.Printf("%s.WriteBig%s(%s)\n", seqName, kind, valName)
+			break
+		}
 		// TODO(crawshaw): test *int
 		// TODO(crawshaw): test **Generator
 		switch T := T.Elem().(type) {
-		case *types.Named:
-			obj := T.Obj()
-			if obj.Pkg() != g.pkg.pkg {
-				panic(fmt.Errorf("type %s not defined in package %s", T, g.pkg))
-				return
-			}
+		case *types.Named, *types.Array:
 			g.Printf("%s.WriteGoRef(%s)\n", seqName, valName)
 		default:
 			panic(fmt.Errorf("unsupported type %s", T))
 		}
 	case *types.Named:
 		switch u := T.Underlying().(type) {
-		case *types.Interface, *types.Pointer, *types.Struct,
-			*types.Array, *types.Slice:
+		case *types.Struct, *types.Signature, *types.Chan,
+			*types.Array, *types.Slice, *types.Map:
+			// structs, funcs, chans, arrays, slices and maps are held
+			// by pointer.
+			g.Printf("%s.WriteGoRef(&%s)\n", seqName, valName)
+		case *types.Interface, *types.Pointer:
 			g.Printf("%s.WriteGoRef(%s)\n", seqName, valName)
 		case *types.Basic:
 			fctName := seqType(u)
@@ -276,6 +700,22 @@ func (g *goGen) genWrite(valName, seqName string, T types.Type) {
 		default:
 			panic(fmt.Errorf("unsupported, direct named type %s: %s", T, u))
 		}
+	case *types.Struct, *types.Signature, *types.Array, *types.Slice:
+		// anonymous structs, unnamed funcs, arrays and slices are held
+		// by pointer, as named ones.
+		g.Printf("%s.WriteGoRef(&%s)\n", seqName, valName)
+	case *types.Map:
+		if !isDictType(T) {
+			// unnamed maps are held by pointer, as named ones.
+			g.Printf("%s.WriteGoRef(&%s)\n", seqName, valName)
+			break
+		}
+		g.Printf("%s.WriteValue(%s)\n", seqName, valName)
+	case *types.Interface:
+		if !T.Empty() {
+			panic(fmt.Errorf("unsupported type %s", T))
+		}
+		g.Printf("%s.WriteValue(%s)\n", seqName, valName)
 	default:
 		g.Printf("%s.Write%s(%s);\n", seqName, seqType(T), valName)
 	}
@@ -308,8 +748,7 @@ func seqType(t types.Type) string {
 		case types.Int64:
 			return "Int64"
 		case types.Uint8: // Byte.
-			// TODO(crawshaw): questionable, but vital?
-			return "Byte"
+			return "Uint8"
 		case types.Uint:
 			return "Uint"
 		case types.Uint16:
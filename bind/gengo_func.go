@@ -7,6 +7,7 @@ package bind
 import (
 	"fmt"
 	"go/types"
+	"strings"
 )
 
 func (g *goGen) genFunc(f Func) {
@@ -36,9 +37,90 @@ func (g *goGen) genFuncBody(f Func) {
 
 	args := sig.Params()
 	for i, arg := range args {
+		if sig.Variadic() && i == len(args)-1 {
+			g.genReadVariadic(fmt.Sprintf("_arg_%03d", i), "in", arg.GoType())
+			continue
+		}
+		if f.strList(arg.GoType()) {
+			g.Printf("_arg_%03d := in.ReadStrings()\n", i)
+			continue
+		}
 		g.genRead(fmt.Sprintf("_arg_%03d", i), "in", arg.GoType())
 	}
+	if f.out {
+		g.genReadOut(f)
+	}
+
+	results := sig.Results()
+	switch {
+	case f.typ != nil && f.hasOpaque():
+		g.genCallOpaque(f, fmt.Sprintf("reflect.ValueOf(%s.%s)", g.pkg.Name(), f.GoName()))
+	case f.typ == nil:
+		g.genCall(f, fmt.Sprintf("cgo_func_%s_(", f.ID()))
+	default:
+		g.genCall(f, fmt.Sprintf("%s.%s(", g.pkg.Name(), f.GoName()))
+	}
+
+	if len(results) <= 0 {
+		return
+	}
+
+	if f.list && !f.strList(results[0].GoType()) {
+		// the list is made from a copy, python never sees the storage
+		// of the returned slice.
+		g.Printf("_res_000 = append(_res_000[:0:0], _res_000...)\n")
+	}
+
+	for i, res := range results {
+		if f.err && i == len(results)-1 {
+			g.genWriteError(fmt.Sprintf("_res_%03d", i), "out")
+			continue
+		}
+		if i == 0 && f.strList(res.GoType()) {
+			g.Printf("out.WriteStrings(_res_000)\n")
+			continue
+		}
+		if i == 0 && f.out {
+			g.genWriteOut(f, results)
+			continue
+		}
+		g.genWrite(fmt.Sprintf("_res_%03d", i), "out", res.GoType())
+	}
+}
+
+// callArgs returns the arguments of the go call of f, read by genRead:
+// the structs, arrays and slices held by pointer are passed by value.
+func (g *goGen) callArgs(f Func) []string {
+	sig := f.Signature()
+	args := sig.Params()
+	exprs := make([]string, len(args))
+	for i, arg := range args {
+		exprs[i] = fmt.Sprintf("_arg_%03d", i)
+		if (sig.Variadic() && i == len(args)-1) || isOpaqueType(arg.GoType()) {
+			// the items of ...T parameters are read into a slice, and
+			// the opaque values passed as they are held.
+			continue
+		}
+		switch typ := arg.GoType().Underlying().(type) {
+		case *types.Struct, *types.Array, *types.Slice:
+			if f.strList(typ) {
+				// the items of []string parameters are read into a
+				// slice.
+				break
+			}
+			// structs, arrays and slices are held by pointer.
+			ptr := types.NewPointer(typ)
+			exprs[i] = g.cnv(typ, ptr, exprs[i])
+		}
+	}
+	return exprs
+}
 
+// genCall calls f, with call, the go func or method of f followed by an
+// opening parenthesis and, for methods, the leading arguments, assigning
+// its results to _res_000, _res_001, ...
+func (g *goGen) genCall(f Func, call string) {
+	sig := f.Signature()
 	results := sig.Results()
 	if len(results) > 0 {
 		for i := range results {
@@ -49,35 +131,69 @@ func (g *goGen) genFuncBody(f Func) {
 		}
 		g.Printf(" := ")
 	}
-
-	if f.typ == nil {
-		g.Printf("cgo_func_%s_(", f.ID())
-	} else {
-		g.Printf("%s.%s(", g.pkg.Name(), f.GoName())
+	args := g.callArgs(f)
+	if sig.Variadic() {
+		args[len(args)-1] += "..."
 	}
+	g.Printf("%s%s)\n", call, strings.Join(args, ", "))
+}
 
-	for i, arg := range args {
-		tail := ""
-		if i+1 < len(args) {
-			tail = ", "
-		}
-		switch typ := arg.GoType().Underlying().(type) {
-		case *types.Struct:
-			ptr := types.NewPointer(typ)
-			g.Printf("%s%s", g.cnv(typ, ptr, fmt.Sprintf("_arg_%03d", i)), tail)
+// genCallOpaque calls f through reflection, with fn, the reflect.Value of
+// its go func or method, as the types of internal packages in its
+// signature can not be named: the results are asserted back to their
+// types, but for the opaque ones, held as interface{} values.
+func (g *goGen) genCallOpaque(f Func, fn string) {
+	results := f.Signature().Results()
+	if len(results) > 0 {
+		g.Printf("_res := ")
+	}
+	g.Printf("_cgopy_Call(%s", fn)
+	for _, arg := range g.callArgs(f) {
+		g.Printf(", %s", arg)
+	}
+	g.Printf(")\n")
+	for i, res := range results {
+		typ := res.GoType()
+		_, ptr := unalias(typ).(*types.Pointer)
+		switch {
+		case !isOpaqueType(typ):
+			g.Printf("_res_%03[1]d, _ := _res[%[1]d].(%[2]s)\n", i, res.sym.gofmt())
+		case ptr, types.IsInterface(typ):
+			g.Printf("_res_%03[1]d := _res[%[1]d]\n", i)
 		default:
-			g.Printf("_arg_%03d%s", i, tail)
+			// structs are held by pointer.
+			g.Printf("_res_%03[1]d := _cgopy_Ptr(_res[%[1]d])\n", i)
 		}
 	}
-	g.Printf(")\n")
+}
 
-	if len(results) <= 0 {
-		return
-	}
+// genReadOut reads the wrapper passed as the out argument of f, nil when
+// there is none.
+func (g *goGen) genReadOut(f Func) {
+	g.Printf("_out, _ := in.ReadRef().Get().(*%s)\n", g.pkg.syms.symtype(f.Return()).gofmt())
+}
 
-	for i, res := range results {
-		g.genWrite(fmt.Sprintf("_res_%03d", i), "out", res.GoType())
+// genWriteOut writes the struct returned by f, or copies it into the
+// wrapper passed as the out argument, unless f failed.
+func (g *goGen) genWriteOut(f Func, results []*Var) {
+	if !f.err {
+		g.Printf("if _out == nil {\n")
+		g.Indent()
+		g.genWrite("_res_000", "out", results[0].GoType())
+		g.Outdent()
+		g.Printf("} else {\n")
+		g.Printf("\t*_out = _res_000\n")
+		g.Printf("}\n")
+		return
 	}
+	g.Printf("switch {\n")
+	g.Printf("case _out == nil:\n")
+	g.Indent()
+	g.genWrite("_res_000", "out", results[0].GoType())
+	g.Outdent()
+	g.Printf("case _res_%03d == nil:\n", len(results)-1)
+	g.Printf("\t*_out = _res_000\n")
+	g.Printf("}\n")
 }
 
 func (g *goGen) genFuncGetter(f Func, o Object, sym *symbol) {
@@ -106,7 +222,12 @@ func (g *goGen) genFuncGetter(f Func, o Object, sym *symbol) {
 		ret.sym.gofmt(),
 	)
 	g.Indent()
-	g.Printf("return %s(%s)\n", ret.sym.gofmt(), get)
+	if f.ref {
+		// the pointer keeps the whole struct alive.
+		g.Printf("return &%s\n", get)
+	} else {
+		g.Printf("return %s(%s)\n", convType(ret.sym.gofmt()), get)
+	}
 	g.Outdent()
 	g.Printf("}\n\n")
 }
@@ -137,7 +258,7 @@ func (g *goGen) genFuncSetter(f Func, o Object, sym *symbol) {
 		arg,
 	)
 	g.Indent()
-	g.Printf("%s = %s(v)\n", set, typ)
+	g.Printf("%s = %s(v)\n", set, convType(typ))
 	g.Outdent()
 	g.Printf("}\n\n")
 }
@@ -149,43 +270,67 @@ func (g *goGen) genFuncNew(f Func, typ Type) {
 		typ.Package().Name(),
 		typ.GoName(),
 	)
-	if typ := typ.Struct(); typ != nil {
-		g.Printf("func cgo_func_%[1]s_() *%[2]s {\n",
-			f.ID(),
-			sym.gofmt(),
-		)
-		g.Indent()
-		g.Printf("var o %[1]s\n", sym.gofmt())
-		g.Printf("return &o;\n")
-	} else {
-		g.Printf("func cgo_func_%[1]s_() %[2]s {\n",
-			f.ID(),
-			sym.gofmt(),
-		)
+	if typ.hasLock() {
+		// the value is not copied out of the func.
+		g.Printf("func cgo_func_%[1]s_() *%[2]s {\n", f.ID(), sym.gofmt())
 		g.Indent()
+		g.Printf("return new(%s)\n", sym.gofmt())
+		g.Outdent()
+		g.Printf("}\n\n")
+		return
+	}
+	g.Printf("func cgo_func_%[1]s_() %[2]s {\n",
+		f.ID(),
+		sym.gofmt(),
+	)
+	g.Indent()
+	switch {
+	case sym.isChan():
+		// nil channels block forever: python creates unbuffered ones.
+		g.Printf("o := make(%[1]s)\n", sym.gofmt())
+	case sym.isMap():
+		// items can not be set into nil maps.
+		g.Printf("o := make(%[1]s)\n", sym.gofmt())
+	case typ.isContext():
+		g.Printf("o := context.Background()\n")
+	default:
 		g.Printf("var o %[1]s\n", sym.gofmt())
-		g.Printf("return o;\n")
 	}
+	g.Printf("return o;\n")
 	g.Outdent()
 	g.Printf("}\n\n")
 }
 
+// genFuncTPStr generates the string form of the values of typ, from its
+// String method or, lacking one, from its Error method.
 func (g *goGen) genFuncTPStr(typ Type) {
-	stringer := typ.prots&ProtoStringer == 1
+	stringer := typ.prots&ProtoStringer != 0
+	errorer := typ.prots&ProtoError != 0
 	sym := typ.sym
 	id := typ.ID()
 	g.Printf("// cgo_func_%[1]s_str_ wraps Stringer\n", id)
-	if typ := typ.Struct(); typ != nil {
+	if typ.isHeldByPointer() {
 		g.Printf(
 			"func cgo_func_%[1]s_str_(o *%[2]s) string {\n",
 			id,
 			sym.gofmt(),
 		)
 		g.Indent()
-		if !stringer {
-			g.Printf("str := fmt.Sprintf(\"%%#v\", *o)\n")
+		if typ.hasLock() {
+			g.genNilEmbedStr(typ, "fmt.Sprintf(\"%#v\", reflect.ValueOf(o).Elem())")
 		} else {
+			g.genNilEmbedStr(typ, "fmt.Sprintf(\"%#v\", *o)")
+		}
+		switch {
+		case stringer:
 			g.Printf("str := o.String()\n")
+		case errorer:
+			g.Printf("str := o.Error()\n")
+		case typ.hasLock():
+			// the value is formatted through reflection, not copied.
+			g.Printf("str := fmt.Sprintf(\"%%#v\", reflect.ValueOf(o).Elem())\n")
+		default:
+			g.Printf("str := fmt.Sprintf(\"%%#v\", *o)\n")
 		}
 	} else {
 		g.Printf(
@@ -194,10 +339,23 @@ func (g *goGen) genFuncTPStr(typ Type) {
 			sym.gofmt(),
 		)
 		g.Indent()
-		if !stringer {
-			g.Printf("str := fmt.Sprintf(\"%%#v\", o)\n")
-		} else {
+		g.genNilEmbedStr(typ, "fmt.Sprintf(\"%#v\", o)")
+		if sym.isInterface() {
+			g.Printf("if o == nil {\n")
+			g.Printf("\treturn \"<nil>\"\n")
+			g.Printf("}\n")
+		}
+		switch {
+		case isErrorType(sym.GoType()):
+			g.Printf("str := fmt.Sprintf(\"%%v\", o)\n")
+		case stringer:
 			g.Printf("str := o.String()\n")
+		case errorer:
+			g.Printf("str := o.Error()\n")
+		case isStringType(sym.GoType()):
+			g.Printf("str := string(o)\n")
+		default:
+			g.Printf("str := fmt.Sprintf(\"%%#v\", o)\n")
 		}
 	}
 	g.Printf("return str\n")
@@ -205,3 +363,116 @@ func (g *goGen) genFuncTPStr(typ Type) {
 	g.Printf("}\n\n")
 
 }
+
+// genNilEmbedStr returns the go syntax of the value o, given by dflt, when
+// the String or Error method making the string form of the values of typ is
+// promoted through a nil embedded interface.
+func (g *goGen) genNilEmbedStr(typ Type, dflt string) {
+	switch {
+	case typ.prots&ProtoStringer != 0:
+		g.genNilEmbed(typ, "String", dflt)
+	case typ.prots&ProtoError != 0:
+		g.genNilEmbed(typ, "Error", dflt)
+	}
+}
+
+// genNilEmbed returns ret when the method of typ named name is promoted
+// through a nil embedded interface.
+func (g *goGen) genNilEmbed(typ Type, name, ret string) {
+	for _, m := range typ.meths {
+		if m.GoName() == name && m.embed != "" {
+			g.Printf("if o.%s == nil {\n", m.embed)
+			g.Printf("\treturn %s\n", ret)
+			g.Printf("}\n")
+			return
+		}
+	}
+}
+
+// genFuncTPRepr generates the representation of the values of typ, from
+// its GoString method.
+func (g *goGen) genFuncTPRepr(typ Type) {
+	sym := typ.sym
+	id := typ.ID()
+	g.Printf("// cgo_func_%[1]s_repr_ wraps GoStringer\n", id)
+	if typ.isHeldByPointer() {
+		g.Printf("func cgo_func_%[1]s_repr_(o *%[2]s) string {\n", id, sym.gofmt())
+	} else {
+		g.Printf("func cgo_func_%[1]s_repr_(o %[2]s) string {\n", id, sym.gofmt())
+	}
+	g.Indent()
+	if typ.isHeldByPointer() {
+		g.genNilEmbed(typ, "GoString", "fmt.Sprintf(\"%#v\", *o)")
+	} else {
+		g.genNilEmbed(typ, "GoString", "fmt.Sprintf(\"%#v\", o)")
+	}
+	g.Printf("return o.GoString()\n")
+	g.Outdent()
+	g.Printf("}\n\n")
+}
+
+// genFuncTPFormat generates the formatting of the values of typ with a go
+// fmt verb.
+// Values held by pointer are formatted through their pointer when they
+// have a String or Error method, which may have a pointer receiver.
+func (g *goGen) genFuncTPFormat(typ Type) {
+	sym := typ.sym
+	id := typ.ID()
+	g.Printf("// cgo_func_%[1]s_format_ wraps fmt.Sprintf\n", id)
+	if !typ.isHeldByPointer() {
+		g.Printf("func cgo_func_%[1]s_format_(o %[2]s, spec string) (string, error) {\n", id, sym.gofmt())
+		g.Indent()
+		g.Printf("return _cgopy_Format(spec, o)\n")
+		g.Outdent()
+		g.Printf("}\n\n")
+		return
+	}
+	g.Printf("func cgo_func_%[1]s_format_(o *%[2]s, spec string) (string, error) {\n", id, sym.gofmt())
+	g.Indent()
+	switch {
+	case typ.prots&(ProtoStringer|ProtoError) != 0:
+		g.Printf("return _cgopy_Format(spec, o)\n")
+	case typ.hasLock():
+		// the value is formatted through reflection, not copied.
+		g.Printf("return _cgopy_Format(spec, reflect.ValueOf(o).Elem())\n")
+	default:
+		g.Printf("return _cgopy_Format(spec, *o)\n")
+	}
+	g.Outdent()
+	g.Printf("}\n\n")
+}
+
+// genFuncName generates the look-up of the name of the const holding the
+// value of a named basic type. Consts sharing a value are named after the
+// first one declared.
+func (g *goGen) genFuncName(typ Type) {
+	id := typ.ID()
+	g.Printf("// cgo_func_%[1]s_name_ returns the name of the const of value o\n", id)
+	g.Printf("func cgo_func_%[1]s_name_(o %[2]s) string {\n", id, typ.sym.gofmt())
+	g.Indent()
+	g.Printf("switch o {\n")
+	seen := make(map[string]bool)
+	for _, c := range typ.consts {
+		val := c.obj.Val().ExactString()
+		if seen[val] {
+			continue
+		}
+		seen[val] = true
+		g.Printf("case %s.%s:\n", g.pkg.Name(), c.GoName())
+		g.Indent()
+		g.Printf("return %q\n", c.GoName())
+		g.Outdent()
+	}
+	g.Printf("}\n")
+	g.Printf("return \"\"\n")
+	g.Outdent()
+	g.Printf("}\n\n")
+}
+
+// convType returns the type name typ, usable in a conversion.
+func convType(typ string) string {
+	if strings.HasPrefix(typ, "*") || strings.HasPrefix(typ, "func(") {
+		return "(" + typ + ")"
+	}
+	return typ
+}
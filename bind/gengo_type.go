@@ -7,6 +7,7 @@ package bind
 import (
 	"fmt"
 	"go/types"
+	"strings"
 )
 
 func (g *goGen) genStruct(s Type) {
@@ -14,47 +15,59 @@ func (g *goGen) genStruct(s Type) {
 	typ := s.Struct()
 	g.Printf("\n// --- wrapping %s ---\n\n", s.sym.gofmt())
 
-	recv := newVar(s.pkg, s.GoType(), "self", s.GoName(), "")
+	recv := newVar(s.pkg, s.GoType(), "self", s.obj.Name(), "")
 
 	for i := 0; i < typ.NumFields(); i++ {
 		f := typ.Field(i)
-		if !f.Exported() {
+		if !s.isExposedField(f) {
 			continue
 		}
 
-		ft := f.Type()
-
 		// -- getter --
+		// fields are read as a copy, or by reference with //gopy:ref.
+		ft := s.fieldType(f)
 		fget := Func{
-			pkg:  s.pkg,
-			sig:  newSignature(s.pkg, recv, nil, []*Var{newVarFrom(s.pkg, f)}),
-			typ:  nil,
-			name: f.Name(),
-			desc: s.pkg.ImportPath() + "." + s.GoName() + "." + f.Name() + ".get",
-			id:   s.ID() + "_" + f.Name() + "_get",
-			doc:  "",
-			ret:  ft,
-			err:  false,
+			pkg:   s.pkg,
+			sig:   newSignature(s.pkg, recv, nil, []*Var{newVar(s.pkg, ft, f.Name(), f.Name(), s.pkg.getDoc("", f))}),
+			typ:   nil,
+			name:  f.Name(),
+			desc:  s.pkg.ImportPath() + "." + s.GoName() + "." + f.Name() + ".get",
+			id:    s.ID() + "_" + f.Name() + "_get",
+			doc:   "",
+			ret:   ft,
+			err:   false,
+			field: true,
+			ref:   s.isRefField(f),
 		}
 		g.genFuncGetter(fget, s, s.sym)
 		g.genMethod(s, fget)
 
+		if s.isAnonymous() {
+			// the fields of anonymous structs are read-only.
+			continue
+		}
+
 		// -- setter --
 		fset := Func{
-			pkg:  s.pkg,
-			sig:  newSignature(s.pkg, recv, []*Var{newVarFrom(s.pkg, f)}, nil),
-			typ:  nil,
-			name: f.Name(),
-			desc: s.pkg.ImportPath() + "." + s.GoName() + "." + f.Name() + ".set",
-			id:   s.ID() + "_" + f.Name() + "_set",
-			doc:  "",
-			ret:  nil,
-			err:  false,
+			pkg:   s.pkg,
+			sig:   newSignature(s.pkg, recv, []*Var{newVarFrom(s.pkg, f)}, nil),
+			typ:   nil,
+			name:  f.Name(),
+			desc:  s.pkg.ImportPath() + "." + s.GoName() + "." + f.Name() + ".set",
+			id:    s.ID() + "_" + f.Name() + "_set",
+			doc:   "",
+			ret:   nil,
+			err:   false,
+			field: true,
 		}
 		g.genFuncSetter(fset, s, s.sym)
 		g.genMethod(s, fset)
 	}
 
+	if s.isBytesBuffer() {
+		g.genTypeBytesBuffer(s)
+	}
+
 	for _, m := range s.meths {
 		g.genMethod(s, m)
 	}
@@ -84,9 +97,32 @@ func (g *goGen) genStruct(s Type) {
 	// support for __str__
 	g.genFuncTPStr(s)
 	g.genMethod(s, s.funcs.str)
+
+	// support for __repr__
+	if s.prots&ProtoGoStringer != 0 {
+		g.genFuncTPRepr(s)
+		g.genMethod(s, s.funcs.repr)
+	}
+
+	// support for __format__
+	g.genFuncTPFormat(s)
+	g.genMethod(s, s.funcs.fmt)
+
+	// support for __eq__ and __ne__
+	if s.isDeepEq() {
+		f := s.funcs.eq
+		g.Printf("// cgo_func_%[1]s_ compares two %[2]s field by field\n", f.ID(), s.sym.gofmt())
+		g.Printf("func cgo_func_%[1]s_(o, other *%[2]s) bool {\n", f.ID(), s.sym.gofmt())
+		g.Printf("\treturn reflect.DeepEqual(o, other)\n")
+		g.Printf("}\n\n")
+		g.genMethod(s, f)
+	}
 }
 
 func (g *goGen) genMethod(s Type, m Func) {
+	if m.linkname != "" {
+		g.genMethodLinkname(s, m)
+	}
 	g.Printf("\n// cgo_func_%[1]s wraps %[2]s.%[3]s\n",
 		m.ID(),
 		s.sym.gofmt(), m.GoName(),
@@ -104,6 +140,67 @@ func (g *goGen) genMethod(s Type, m Func) {
 	})
 }
 
+// genMethodLinkname declares the unexported method m, listed by a
+// //gopy:export directive, as a func taking its receiver as first
+// parameter, bound to the method by a //go:linkname directive.
+func (g *goGen) genMethodLinkname(s Type, m Func) {
+	sig := m.Signature()
+	recv := s.sym.gofmt()
+	if linknamePtr(m) {
+		recv = "*" + recv
+	}
+	params := []string{recv}
+	for i, arg := range sig.Params() {
+		typ := arg.sym.gofmt()
+		if sig.Variadic() && i == len(sig.Params())-1 {
+			elem := arg.GoType().Underlying().(*types.Slice).Elem()
+			typ = "..." + g.pkg.syms.symtype(elem).gofmt()
+		}
+		params = append(params, typ)
+	}
+	var results []string
+	for _, res := range sig.Results() {
+		results = append(results, res.sym.gofmt())
+	}
+
+	g.Printf("\n// cgo_linkname_%[1]s is the unexported method %[2]s\n", m.ID(), m.linkname)
+	g.Printf("//go:linkname cgo_linkname_%[1]s %[2]s\n", m.ID(), m.linkname)
+	g.Printf("func cgo_linkname_%[1]s(%[2]s)", m.ID(), strings.Join(params, ", "))
+	switch len(results) {
+	case 0:
+	case 1:
+		g.Printf(" %s", results[0])
+	default:
+		g.Printf(" (%s)", strings.Join(results, ", "))
+	}
+	g.Printf("\n")
+}
+
+// linknamePtr returns whether the unexported method m has a pointer
+// receiver. The receiver of the signature of m is the one of the method
+// set of *T, a pointer in any case, unlike the one of its linker symbol.
+func linknamePtr(m Func) bool {
+	return strings.Contains(m.linkname, ".(*")
+}
+
+// linknameRecv returns the receiver of the unexported method m of s, from
+// o, as read by genRead: a pointer for the types held by pointer.
+func linknameRecv(s Type, m Func) string {
+	ptr := linknamePtr(m)
+	held := false
+	switch s.sym.GoType().Underlying().(type) {
+	case *types.Pointer, *types.Struct, *types.Array, *types.Slice:
+		held = true
+	}
+	switch {
+	case ptr && !held:
+		return "&o"
+	case !ptr && held:
+		return "*o"
+	}
+	return "o"
+}
+
 func (g *goGen) genMethodBody(s Type, m Func) {
 	g.genRead("o", "in", s.sym.GoType())
 
@@ -111,51 +208,86 @@ func (g *goGen) genMethodBody(s Type, m Func) {
 	args := sig.Params()
 	for i, arg := range args {
 		g.Printf("// arg-%03d: %v\n", i, gofmt(g.pkg.Name(), arg.GoType()))
+		if sig.Variadic() && i == len(args)-1 {
+			g.genReadVariadic(fmt.Sprintf("_arg_%03d", i), "in", arg.GoType())
+			continue
+		}
+		if m.strList(arg.GoType()) {
+			g.Printf("_arg_%03d := in.ReadStrings()\n", i)
+			continue
+		}
 		g.genRead(fmt.Sprintf("_arg_%03d", i), "in", arg.GoType())
 	}
+	if m.out {
+		g.genReadOut(m)
+	}
+	if m.embed != "" {
+		// a nil embedded interface is reported to python, rather than
+		// panicking in the call.
+		g.Printf("if o.%s == nil {\n", m.embed)
+		g.Printf("\tout.WriteString(%q)\n", "nil embedded "+m.embed)
+		g.Printf("\treturn\n")
+		g.Printf("}\n")
+		g.Printf("out.WriteString(\"\")\n")
+	}
+	if m.nilRecv {
+		// so is a nil interface, e.g. made by the python constructor of
+		// the interface type.
+		g.Printf("if o == nil {\n")
+		g.Printf("\tout.WriteString(%q)\n", "nil "+s.GoName())
+		g.Printf("\treturn\n")
+		g.Printf("}\n")
+		g.Printf("out.WriteString(\"\")\n")
+	}
 
 	results := sig.Results()
-	if len(results) > 0 {
-		for i := range results {
-			if i > 0 {
-				g.Printf(", ")
-			}
-			g.Printf("_res_%03d", i)
-		}
-		g.Printf(" := ")
+	comma := ""
+	if len(args) > 0 {
+		comma = ", "
 	}
-
-	if m.typ == nil {
+	switch {
+	case m.typ == nil:
 		src := s.sym.GoType() // FIXME(sbinet)
 		cnv := g.cnv(src, src, "o")
-		g.Printf("cgo_func_%s_(%s", m.ID(), cnv)
-		if len(args) > 0 {
-			g.Printf(", ")
-		}
-	} else {
-		g.Printf("o.%s(", m.GoName())
-	}
-
-	for i, arg := range args {
-		tail := ""
-		if i+1 < len(args) {
-			tail = ", "
-		}
-		switch typ := arg.GoType().Underlying().(type) {
-		case *types.Struct:
-			ptr := types.NewPointer(typ)
-			g.Printf("%s%s", g.cnv(typ, ptr, fmt.Sprintf("_arg_%03d", i)), tail)
-		default:
-			g.Printf("_arg_%03d%s", i, tail)
-		}
+		g.genCall(m, fmt.Sprintf("cgo_func_%s_(%s%s", m.ID(), cnv, comma))
+	case s.isOpaque():
+		// the methods of the types of internal packages are looked up
+		// by name.
+		g.genCallOpaque(m, fmt.Sprintf("reflect.ValueOf(o).MethodByName(%q)", m.GoName()))
+	case m.hasOpaque() && m.embed != "":
+		g.genCallOpaque(m, fmt.Sprintf("reflect.ValueOf(o.%s.%s)", m.embed, m.GoName()))
+	case m.hasOpaque():
+		g.genCallOpaque(m, fmt.Sprintf("reflect.ValueOf(o.%s)", m.GoName()))
+	case m.linkname != "":
+		g.genCall(m, fmt.Sprintf("cgo_linkname_%s(%s%s", m.ID(), linknameRecv(s, m), comma))
+	case m.embed != "":
+		// dispatched through the embedded interface value.
+		g.genCall(m, fmt.Sprintf("o.%s.%s(", m.embed, m.GoName()))
+	default:
+		g.genCall(m, fmt.Sprintf("o.%s(", m.GoName()))
 	}
-	g.Printf(")\n")
 
 	if len(results) <= 0 {
 		return
 	}
 
 	for i, res := range results {
+		if m.err && i == len(results)-1 {
+			if m.timeout {
+				// the C side raises a TimeoutError for seq.ErrTimeout.
+				g.Printf("out.WriteBool(_res_%03d == seq.ErrTimeout)\n", i)
+			}
+			g.genWriteError(fmt.Sprintf("_res_%03d", i), "out")
+			continue
+		}
+		if i == 0 && m.strList(res.GoType()) {
+			g.Printf("out.WriteStrings(_res_000)\n")
+			continue
+		}
+		if i == 0 && m.out {
+			g.genWriteOut(m, results)
+			continue
+		}
 		g.genWrite(fmt.Sprintf("_res_%03d", i), "out", res.GoType())
 	}
 }
@@ -165,6 +297,10 @@ func (g *goGen) genType(typ Type) {
 	if !sym.isType() {
 		return
 	}
+	if typ.isOpaque() {
+		g.genOpaque(typ)
+		return
+	}
 	if sym.isStruct() {
 		g.genStruct(typ)
 		return
@@ -201,362 +337,733 @@ func (g *goGen) genType(typ Type) {
 	g.genFuncTPStr(typ)
 	g.genMethod(typ, typ.funcs.str)
 
+	// support for __repr__
+	if typ.prots&ProtoGoStringer != 0 {
+		g.genFuncTPRepr(typ)
+		g.genMethod(typ, typ.funcs.repr)
+	}
+
+	// support for __format__
+	g.genFuncTPFormat(typ)
+	g.genMethod(typ, typ.funcs.fmt)
+
+	// support for the name of consts
+	if len(typ.consts) > 0 {
+		g.genFuncName(typ)
+		g.genMethod(typ, typ.funcs.name)
+	}
+
 	if sym.isArray() || sym.isSlice() {
-		var etyp types.Type
-		switch typ := sym.GoType().(type) {
-		case *types.Array:
-			etyp = typ.Elem()
-		case *types.Slice:
-			etyp = typ.Elem()
-		case *types.Named:
-			switch typ := typ.Underlying().(type) {
-			case *types.Array:
-				etyp = typ.Elem()
-			case *types.Slice:
-				etyp = typ.Elem()
-			default:
-				panic(fmt.Errorf("gopy: unhandled type [%#v]", typ))
-			}
-		default:
-			panic(fmt.Errorf("gopy: unhandled type [%#v]", typ))
+		g.genTypeSeq(typ)
+	}
+
+	if isByteSeqType(sym.GoType()) {
+		g.genTypeBuffer(typ)
+	}
+
+	if sym.isMap() {
+		g.genTypeMap(typ)
+	}
+
+	if typ.isIterator() {
+		g.genTypeIter(typ)
+	}
+
+	if typ.isCallable() {
+		g.genTypeTPCall(typ)
+	}
+
+	if typ.isWrappingCallables() {
+		g.genTypeWrap(typ)
+	}
+
+	if sym.isChan() {
+		g.genTypeChan(typ)
+	}
+
+	if typ.isContext() {
+		g.genTypeContext(typ)
+	}
+
+	if typ.isWrappingFiles() {
+		g.genTypeFile(typ)
+	}
+
+	for _, m := range typ.meths {
+		g.genMethod(typ, m)
+	}
+}
+
+// genOpaque generates the go side of the types of internal packages, which
+// the generated package can not import: their values are held as
+// interface{} values, formatted by fmt and having their methods called
+// through reflection. They are only made by go.
+func (g *goGen) genOpaque(typ Type) {
+	id := typ.ID()
+	g.Printf("\n// --- wrapping %s ---\n\n", typ.sym.gofmt())
+
+	// values are formatted through their pointer, or their dynamic value
+	// for interfaces, unless they have a String or Error method.
+	value := "reflect.Indirect(reflect.ValueOf(o))"
+	g.Printf("// cgo_func_%[1]s_str_ wraps Stringer\n", id)
+	g.Printf("func cgo_func_%[1]s_str_(o interface{}) string {\n", id)
+	switch {
+	case typ.prots&ProtoStringer != 0:
+		g.Printf("\treturn o.(fmt.Stringer).String()\n")
+	case typ.prots&ProtoError != 0:
+		g.Printf("\treturn o.(error).Error()\n")
+	default:
+		g.Printf("\treturn fmt.Sprintf(\"%%#v\", %s)\n", value)
+	}
+	g.Printf("}\n\n")
+	g.genMethod(typ, typ.funcs.str)
+
+	if typ.prots&ProtoGoStringer != 0 {
+		g.Printf("// cgo_func_%[1]s_repr_ wraps GoStringer\n", id)
+		g.Printf("func cgo_func_%[1]s_repr_(o interface{}) string {\n", id)
+		g.Printf("\treturn o.(fmt.GoStringer).GoString()\n")
+		g.Printf("}\n\n")
+		g.genMethod(typ, typ.funcs.repr)
+	}
+
+	if typ.prots&(ProtoStringer|ProtoError) != 0 {
+		value = "o"
+	}
+	g.Printf("// cgo_func_%[1]s_format_ wraps fmt.Sprintf\n", id)
+	g.Printf("func cgo_func_%[1]s_format_(o interface{}, spec string) (string, error) {\n", id)
+	g.Printf("\treturn _cgopy_Format(spec, %s)\n", value)
+	g.Printf("}\n\n")
+	g.genMethod(typ, typ.funcs.fmt)
+
+	for _, m := range typ.meths {
+		g.genMethod(typ, m)
+	}
+}
+
+// genTypeTPCall generates the go side of the tp_call slot of callable
+// func types.
+func (g *goGen) genTypeTPCall(typ Type) {
+	sym := typ.sym
+	sig := sym.GoType().Underlying().(*types.Signature)
+
+	params := []string{"o " + sym.gofmt()}
+	args := []string{}
+	for i := 0; i < sig.Params().Len(); i++ {
+		arg := g.pkg.syms.symtype(sig.Params().At(i).Type())
+		params = append(params, fmt.Sprintf("_arg_%03d %s", i, arg.gofmt()))
+		args = append(args, fmt.Sprintf("_arg_%03d", i))
+	}
+	results := []string{}
+	for i := 0; i < sig.Results().Len(); i++ {
+		ret := g.pkg.syms.symtype(sig.Results().At(i).Type())
+		results = append(results, ret.gofmt())
+	}
+
+	g.Printf("// cgo_func_%[1]s_ calls a %[2]s\n", typ.funcs.call.ID(), sym.gofmt())
+	g.Printf("func cgo_func_%[1]s_(%[2]s) ", typ.funcs.call.ID(), strings.Join(params, ", "))
+	switch len(results) {
+	case 0:
+	case 1:
+		g.Printf("%s ", results[0])
+	default:
+		g.Printf("(%s) ", strings.Join(results, ", "))
+	}
+	g.Printf("{\n")
+	g.Indent()
+	if len(results) > 0 {
+		g.Printf("return ")
+	}
+	g.Printf("o(%s)\n", strings.Join(args, ", "))
+	g.Outdent()
+	g.Printf("}\n")
+
+	g.genMethod(typ, typ.funcs.call)
+}
+
+// genTypeWrap generates the go side of the conversion of python callables
+// to values of func types. The funcs made from a callable call it back
+// through the trampoline of the type, which sends whether the call
+// succeeded, followed by its results, or else by the message of the python
+// exception for funcs returning an error. Funcs returning no error return
+// zero values when the call fails.
+func (g *goGen) genTypeWrap(typ Type) {
+	sym := typ.sym
+	wrap := typ.funcs.wrap
+	sig := sym.GoType().Underlying().(*types.Signature)
+
+	params := []string{}
+	for i := 0; i < sig.Params().Len(); i++ {
+		arg := g.pkg.syms.symtype(sig.Params().At(i).Type())
+		params = append(params, fmt.Sprintf("_arg_%03d %s", i, arg.gofmt()))
+	}
+	results := []string{}
+	for i := 0; i < sig.Results().Len(); i++ {
+		ret := g.pkg.syms.symtype(sig.Results().At(i).Type())
+		results = append(results, fmt.Sprintf("_res_%03d %s", i, ret.gofmt()))
+	}
+
+	g.Printf("// cgo_func_%[1]s_ makes the python callable fn into a %[2]s\n", wrap.ID(), sym.gofmt())
+	g.Printf("func cgo_func_%[1]s_(fn uint64) %[2]s {\n", wrap.ID(), sym.gofmt())
+	g.Indent()
+	g.Printf("py := newPyFunc(fn)\n")
+	g.Printf("return func(%s) ", strings.Join(params, ", "))
+	if len(results) > 0 {
+		g.Printf("(%s) ", strings.Join(results, ", "))
+	}
+	g.Printf("{\n")
+	g.Indent()
+	g.Printf("in := new(seq.Buffer)\n")
+	for i := 0; i < sig.Params().Len(); i++ {
+		g.genWrite(fmt.Sprintf("_arg_%03d", i), "in", sig.Params().At(i).Type())
+	}
+	g.Printf("py.call(%d, in, func(out *seq.Buffer) {\n", uhash(wrap.ID()))
+	g.Indent()
+	g.Printf("if !out.ReadBool() {\n")
+	if typ.funcs.call.err {
+		g.Printf("\t_res_%03d = out.ReadError()\n", sig.Results().Len()-1)
+	}
+	g.Printf("\treturn\n")
+	g.Printf("}\n")
+	for i := 0; i < sig.Results().Len(); i++ {
+		rtyp := sig.Results().At(i).Type()
+		if typ.funcs.call.err && i == sig.Results().Len()-1 {
+			break
 		}
-		esym := g.pkg.syms.symtype(etyp)
-		if esym == nil {
-			panic(fmt.Errorf("gopy: could not retrieve element type of %#v",
-				sym,
-			))
+		g.genRead(fmt.Sprintf("_ret_%03d", i), "out", rtyp)
+		switch rtyp.Underlying().(type) {
+		case *types.Struct, *types.Array, *types.Slice:
+			// structs, arrays and slices are held by pointer.
+			g.Printf("_res_%03[1]d = *_ret_%03[1]d\n", i)
+		default:
+			g.Printf("_res_%03[1]d = _ret_%03[1]d\n", i)
 		}
+	}
+	g.Outdent()
+	g.Printf("})\n")
+	g.Printf("return\n")
+	g.Outdent()
+	g.Printf("}\n")
+	g.Outdent()
+	g.Printf("}\n\n")
 
-		// support for __getitem__
-		g.Printf("//export cgo_func_%[1]s_item\n", sym.id)
-		g.Printf(
-			"func cgo_func_%[1]s_item(self %[2]s, i int) %[3]s {\n",
-			sym.id,
-			sym.cgoname,
-			esym.cgotypename(),
-		)
-		g.Indent()
-		g.Printf("arr := (*%[1]s)(unsafe.Pointer(self))\n", sym.gofmt())
-		g.Printf("elt := (*arr)[i]\n")
-		if !esym.isBasic() {
-			g.Printf("cgopy_incref(unsafe.Pointer(&elt))\n")
-			g.Printf("return (%[1]s)(unsafe.Pointer(&elt))\n", esym.cgotypename())
-		} else {
-			if esym.isNamed() {
-				g.Printf("return %[1]s(elt)\n", esym.cgotypename())
-			} else {
-				g.Printf("return elt\n")
-			}
-		}
-		g.Outdent()
-		g.Printf("}\n\n")
+	g.genFunc(wrap)
+}
 
-		// support for __setitem__
-		g.Printf("//export cgo_func_%[1]s_ass_item\n", sym.id)
-		g.Printf("func cgo_func_%[1]s_ass_item(self %[2]s, i int, v %[3]s) {\n",
-			sym.id,
-			sym.cgoname,
-			esym.cgotypename(),
-		)
+// genTypeFile generates the go side of the conversion of python file
+// objects into io.Reader or io.Writer values, calling back their read or
+// write method through cgopy_seq_callback, which holds the GIL.
+// Reads of no bytes are reported as io.EOF.
+func (g *goGen) genTypeFile(typ Type) {
+	sym := typ.sym
+	file := typ.funcs.file
+	code := uhash(file.ID())
+
+	switch streamMethod(sym.GoType()) {
+	case "read":
+		g.Printf("// cgopy_reader reads from a python file object.\n")
+		g.Printf("type cgopy_reader struct {\n")
+		g.Printf("\tpy *pyFunc\n")
+		g.Printf("}\n\n")
+		g.Printf("func (r *cgopy_reader) Read(p []byte) (n int, err error) {\n")
 		g.Indent()
-		g.Printf("arr := (*%[1]s)(unsafe.Pointer(self))\n", sym.gofmt())
-		g.Printf("(*arr)[i] = ")
-		if !esym.isBasic() {
-			g.Printf("*(*%[1]s)(unsafe.Pointer(v))\n", esym.gofmt())
-		} else {
-			if esym.isNamed() {
-				g.Printf("%[1]s(v)\n", esym.gofmt())
-			} else {
-				g.Printf("v\n")
-			}
-		}
+		g.Printf("if len(p) == 0 {\n")
+		g.Printf("\treturn 0, nil\n")
+		g.Printf("}\n")
+		g.Printf("in := new(seq.Buffer)\n")
+		g.Printf("in.WriteInt64(int64(len(p)))\n")
+		g.Printf("r.py.call(%d, in, func(out *seq.Buffer) {\n", code)
+		g.Indent()
+		g.Printf("if !out.ReadBool() {\n")
+		g.Printf("\terr = out.ReadError()\n")
+		g.Printf("\treturn\n")
+		g.Printf("}\n")
+		g.Printf("n = copy(p, out.ReadByteArray())\n")
+		g.Outdent()
+		g.Printf("})\n")
+		g.Printf("if n == 0 && err == nil {\n")
+		g.Printf("\terr = io.EOF\n")
+		g.Printf("}\n")
+		g.Printf("return n, err\n")
 		g.Outdent()
 		g.Printf("}\n\n")
-	}
 
-	if sym.isSlice() {
-		etyp := sym.GoType().Underlying().(*types.Slice).Elem()
-		esym := g.pkg.syms.symtype(etyp)
-		if esym == nil {
-			panic(fmt.Errorf("gopy: could not retrieve element type of %#v",
-				sym,
-			))
-		}
+		g.Printf("// cgo_func_%[1]s_ makes the python file object fn into a %[2]s\n", file.ID(), sym.gofmt())
+		g.Printf("func cgo_func_%[1]s_(fn uint64) %[2]s {\n", file.ID(), sym.gofmt())
+		g.Printf("\treturn &cgopy_reader{newPyFunc(fn)}\n")
+		g.Printf("}\n\n")
 
-		// support for __append__
-		g.Printf("//export cgo_func_%[1]s_append\n", sym.id)
-		g.Printf("func cgo_func_%[1]s_append(self %[2]s, v %[3]s) {\n",
-			sym.id,
-			sym.cgoname,
-			esym.cgotypename(),
-		)
+	case "write":
+		g.Printf("// cgopy_writer writes to a python file object.\n")
+		g.Printf("type cgopy_writer struct {\n")
+		g.Printf("\tpy *pyFunc\n")
+		g.Printf("}\n\n")
+		g.Printf("func (w *cgopy_writer) Write(p []byte) (n int, err error) {\n")
 		g.Indent()
-		g.Printf("slice := (*%[1]s)(unsafe.Pointer(self))\n", sym.gofmt())
-		g.Printf("*slice = append(*slice, ")
-		if !esym.isBasic() {
-			g.Printf("*(*%[1]s)(unsafe.Pointer(v))", esym.gofmt())
-		} else {
-			if esym.isNamed() {
-				g.Printf("%[1]s(v)", esym.gofmt())
-			} else {
-				g.Printf("v")
-			}
-		}
-		g.Printf(")\n")
+		g.Printf("in := new(seq.Buffer)\n")
+		g.Printf("in.WriteByteArray(p)\n")
+		g.Printf("w.py.call(%d, in, func(out *seq.Buffer) {\n", code)
+		g.Indent()
+		g.Printf("if !out.ReadBool() {\n")
+		g.Printf("\terr = out.ReadError()\n")
+		g.Printf("\treturn\n")
+		g.Printf("}\n")
+		g.Printf("n = int(out.ReadInt64())\n")
+		g.Outdent()
+		g.Printf("})\n")
+		g.Printf("if n < len(p) && err == nil {\n")
+		g.Printf("\terr = io.ErrShortWrite\n")
+		g.Printf("}\n")
+		g.Printf("return n, err\n")
 		g.Outdent()
 		g.Printf("}\n\n")
+
+		g.Printf("// cgo_func_%[1]s_ makes the python file object fn into a %[2]s\n", file.ID(), sym.gofmt())
+		g.Printf("func cgo_func_%[1]s_(fn uint64) %[2]s {\n", file.ID(), sym.gofmt())
+		g.Printf("\treturn &cgopy_writer{newPyFunc(fn)}\n")
+		g.Printf("}\n\n")
 	}
 
-	g.genTypeTPCall(sym)
+	g.genFunc(file)
+}
 
-	g.genTypeMethods(sym)
+// genTypeErrorsAs generates the go side of errors_as for the values of the
+// type typ.
+func (g *goGen) genTypeErrorsAs(typ Type) {
+	as := typ.funcs.as
+	target := g.pkg.syms.symtype(as.Return()).gofmt()
+	g.Printf("// cgo_func_%[1]s_ returns the first error of the chain of err which is a %[2]s\n", as.ID(), target)
+	g.Printf("func cgo_func_%[1]s_(err error) (%[2]s, bool) {\n", as.ID(), target)
+	g.Indent()
+	g.Printf("var target %s\n", target)
+	g.Printf("ok := errors.As(err, &target)\n")
+	g.Printf("return target, ok\n")
+	g.Outdent()
+	g.Printf("}\n\n")
 
+	g.genFunc(as)
 }
 
-func (g *goGen) genTypeTPCall(sym *symbol) {
-	if !sym.isSignature() {
+// genTypeSeq generates the go side of the sequence protocol of arrays and
+// slices. Struct, array and slice items are returned by pointer, aliasing
+// the storage of the array or slice.
+func (g *goGen) genTypeSeq(typ Type) {
+	sym := typ.sym
+	f := typ.funcs
+	item := g.pkg.syms.symtype(f.item.Return())
+	elem := g.pkg.syms.symtype(f.setitem.Signature().Params()[1].GoType())
+
+	g.Printf("// cgo_func_%[1]s_ returns the length of a %[2]s\n", f.len.ID(), sym.gofmt())
+	g.Printf("func cgo_func_%[1]s_(o *%[2]s) int {\n", f.len.ID(), sym.gofmt())
+	g.Printf("\treturn len(*o)\n")
+	g.Printf("}\n\n")
+	g.genMethod(typ, f.len)
+
+	g.Printf("// cgo_func_%[1]s_ returns the i-th item of a %[2]s\n", f.item.ID(), sym.gofmt())
+	g.Printf("func cgo_func_%[1]s_(o *%[2]s, i int) %[3]s {\n", f.item.ID(), sym.gofmt(), item.gofmt())
+	if _, ok := f.item.Return().(*types.Pointer); ok {
+		g.Printf("\treturn &(*o)[i]\n")
+	} else {
+		g.Printf("\treturn (*o)[i]\n")
+	}
+	g.Printf("}\n\n")
+	g.genMethod(typ, f.item)
+
+	if typ.itemLock() != "" {
+		// items holding a lock are read-only, as they cannot be copied.
 		return
 	}
 
-	sig := sym.GoType().Underlying().(*types.Signature)
-	if sig.Recv() != nil {
-		// don't generate tp_call for methods.
+	g.Printf("// cgo_func_%[1]s_ sets the i-th item of a %[2]s\n", f.setitem.ID(), sym.gofmt())
+	g.Printf("func cgo_func_%[1]s_(o *%[2]s, i int, v %[3]s) {\n", f.setitem.ID(), sym.gofmt(), elem.gofmt())
+	g.Printf("\t(*o)[i] = v\n")
+	g.Printf("}\n\n")
+	g.genMethod(typ, f.setitem)
+
+	if !sym.isSlice() {
 		return
 	}
+	g.Printf("// cgo_func_%[1]s_ appends an item to a %[2]s\n", f.append.ID(), sym.gofmt())
+	g.Printf("func cgo_func_%[1]s_(o *%[2]s, v %[3]s) {\n", f.append.ID(), sym.gofmt(), elem.gofmt())
+	g.Printf("\t*o = append(*o, v)\n")
+	g.Printf("}\n\n")
+	g.genMethod(typ, f.append)
+}
 
-	// support for __call__
-	g.Printf("//export cgo_func_%[1]s_call\n", sym.id)
-	g.Printf("func cgo_func_%[1]s_call(self %[2]s", sym.id, sym.cgotypename())
-	params := sig.Params()
-	res := sig.Results()
-	if params != nil && params.Len() > 0 {
-		for i := 0; i < params.Len(); i++ {
-			arg := params.At(i)
-			sarg := g.pkg.syms.symtype(arg.Type())
-			if sarg == nil {
-				panic(fmt.Errorf(
-					"gopy: could not find symtype for [%T]",
-					arg.Type(),
-				))
-			}
-			g.Printf(", arg%03d %s", i, sarg.cgotypename())
-		}
+// genTypeBuffer generates the go side of the buffer protocol of byte arrays
+// and slices. Their storage is pinned by a new handle while python accesses
+// it, and its address is sent as an integer.
+func (g *goGen) genTypeBuffer(typ Type) {
+	sym := typ.sym
+	desc, id := typ.bufferFunc()
+	g.Printf("// cgo_func_%[1]s pins the storage of a %[2]s and returns its address\n", id, sym.gofmt())
+	g.Printf("// and length.\n")
+	g.Printf("func cgo_func_%[1]s(out, in *seq.Buffer) {\n", id)
+	g.Indent()
+	g.genRead("o", "in", sym.GoType())
+	g.Printf("b := (*o)[:]\n")
+	g.Printf("out.WriteGoRef(&b)\n")
+	g.Printf("var p uintptr\n")
+	g.Printf("if len(b) > 0 {\n")
+	g.Printf("\tp = uintptr(unsafe.Pointer(&b[0]))\n")
+	g.Printf("}\n")
+	g.Printf("out.WriteInt64(int64(p))\n")
+	g.Printf("out.WriteInt64(int64(len(b)))\n")
+	g.Outdent()
+	g.Printf("}\n\n")
+
+	g.regs = append(g.regs, goReg{
+		Descriptor: desc,
+		ID:         uhash(id),
+		Func:       id,
+	})
+}
+
+// genTypeIter generates the go side of the iteration over iter.Seq and
+// iter.Seq2 values. Python pulls their values one at a time, through a
+// handle to the next and stop funcs of iter.Pull or iter.Pull2: the pull is
+// stopped when python drops its iterator.
+func (g *goGen) genTypeIter(typ Type) {
+	sym := typ.sym
+	vals := iterSeqTypes(sym.GoType())
+	pull := "iter.Pull"
+	if len(vals) == 2 {
+		pull = "iter.Pull2"
 	}
-	g.Printf(")")
-	if res != nil && res.Len() > 0 {
-		g.Printf(" (")
-		for i := 0; i < res.Len(); i++ {
-			ret := res.At(i)
-			sret := g.pkg.syms.symtype(ret.Type())
-			if sret == nil {
-				panic(fmt.Errorf(
-					"gopy: could not find symbol for [%T]",
-					ret.Type(),
-				))
-			}
-			comma := ", "
-			if i == 0 {
-				comma = ""
-			}
-			g.Printf("%s%s", comma, sret.cgotypename())
-		}
-		g.Printf(")")
+	names := []string{"v"}
+	if len(vals) == 2 {
+		names = []string{"k", "v"}
+	}
+	elems := make([]string, len(vals))
+	for i, v := range vals {
+		elems[i] = g.pkg.syms.symtype(v).gofmt()
 	}
-	g.Printf(" {\n")
+	_, id := typ.iterFunc("pull")
+
+	g.Printf("// cgopy_pull_%[1]s pulls the values of a %[2]s. Its mutex\n", id, sym.gofmt())
+	g.Printf("// serializes the python threads sharing an iterator.\n")
+	g.Printf("type cgopy_pull_%s struct {\n", id)
 	g.Indent()
-	if res != nil && res.Len() > 0 {
-		for i := 0; i < res.Len(); i++ {
-			if i > 0 {
-				g.Printf(", ")
-			}
-			g.Printf("res%03d", i)
+	g.Printf("mu   sync.Mutex\n")
+	g.Printf("next func() (%s, bool)\n", strings.Join(elems, ", "))
+	g.Printf("stop func()\n")
+	g.Outdent()
+	g.Printf("}\n\n")
+
+	for _, op := range []string{"pull", "next", "stop"} {
+		desc, fid := typ.iterFunc(op)
+		switch op {
+		case "pull":
+			g.Printf("// cgo_func_%[1]s starts pulling the values of a %[2]s\n", fid, sym.gofmt())
+		case "next":
+			g.Printf("// cgo_func_%[1]s pulls the next value of a %[2]s, if any\n", fid, sym.gofmt())
+		case "stop":
+			g.Printf("// cgo_func_%[1]s stops pulling the values of a %[2]s\n", fid, sym.gofmt())
 		}
-		g.Printf(" := ")
-	}
-	g.Printf("(*(*%[1]s)(unsafe.Pointer(self)))(", sym.gofmt())
-	if params != nil && params.Len() > 0 {
-		for i := 0; i < params.Len(); i++ {
-			comma := ", "
-			if i == 0 {
-				comma = ""
-			}
-			arg := params.At(i)
-			sarg := g.pkg.syms.symtype(arg.Type())
-			if sarg.isBasic() {
-				g.Printf("%sarg%03d", comma, i)
-			} else {
-				g.Printf(
-					"%s*(*%s)(unsafe.Pointer(arg%03d))",
-					comma,
-					sarg.gofmt(),
-					i,
-				)
+		g.Printf("func cgo_func_%[1]s(out, in *seq.Buffer) {\n", fid)
+		g.Indent()
+		switch op {
+		case "pull":
+			g.genRead("o", "in", sym.GoType())
+			g.Printf("next, stop := %s(o)\n", pull)
+			g.Printf("out.WriteGoRef(&cgopy_pull_%[1]s{next: next, stop: stop})\n", id)
+		case "next":
+			g.Printf("p := in.ReadRef().Get().(*cgopy_pull_%s)\n", id)
+			g.Printf("p.mu.Lock()\n")
+			g.Printf("%s, ok := p.next()\n", strings.Join(names, ", "))
+			g.Printf("p.mu.Unlock()\n")
+			g.Printf("out.WriteBool(ok)\n")
+			g.Printf("if ok {\n")
+			g.Indent()
+			for i, v := range vals {
+				g.genWrite(names[i], "out", v)
 			}
+			g.Outdent()
+			g.Printf("}\n")
+		case "stop":
+			g.Printf("p := in.ReadRef().Get().(*cgopy_pull_%s)\n", id)
+			g.Printf("p.mu.Lock()\n")
+			g.Printf("p.stop()\n")
+			g.Printf("p.mu.Unlock()\n")
 		}
+		g.Outdent()
+		g.Printf("}\n\n")
+
+		g.regs = append(g.regs, goReg{
+			Descriptor: desc,
+			ID:         uhash(fid),
+			Func:       fid,
+		})
 	}
-	g.Printf(")\n")
-	if res != nil && res.Len() > 0 {
-		for i := 0; i < res.Len(); i++ {
-			ret := res.At(i)
-			sret := g.pkg.syms.symtype(ret.Type())
-			if !needWrapType(sret.GoType()) {
-				continue
-			}
-			g.Printf("cgopy_incref(unsafe.Pointer(&arg%03d))", i)
-		}
+}
 
-		g.Printf("return ")
-		for i := 0; i < res.Len(); i++ {
-			if i > 0 {
-				g.Printf(", ")
-			}
-			ret := res.At(i)
-			sret := g.pkg.syms.symtype(ret.Type())
-			if needWrapType(ret.Type()) {
-				g.Printf("%s(unsafe.Pointer(&", sret.cgotypename())
-			}
-			g.Printf("res%03d", i)
-			if needWrapType(ret.Type()) {
-				g.Printf("))")
-			}
+// genTypeMap generates the go side of the mapping protocol of maps. Items
+// are returned by value: map elements are not addressable.
+func (g *goGen) genTypeMap(typ Type) {
+	sym := typ.sym
+	f := typ.funcs
+	m := sym.GoType().Underlying().(*types.Map)
+	key := g.pkg.syms.symtype(m.Key()).gofmt()
+	elem := g.pkg.syms.symtype(m.Elem()).gofmt()
+
+	g.Printf("// cgo_func_%[1]s_ returns the length of a %[2]s\n", f.len.ID(), sym.gofmt())
+	g.Printf("func cgo_func_%[1]s_(o %[2]s) int {\n", f.len.ID(), sym.gofmt())
+	g.Printf("\treturn len(o)\n")
+	g.Printf("}\n\n")
+	g.genMethod(typ, f.len)
+
+	g.Printf("// cgo_func_%[1]s_ returns the item of a %[2]s at key k, if any\n", f.item.ID(), sym.gofmt())
+	g.Printf("func cgo_func_%[1]s_(o %[2]s, k %[3]s) (%[4]s, bool) {\n", f.item.ID(), sym.gofmt(), key, elem)
+	g.Printf("\tv, ok := o[k]\n")
+	g.Printf("\treturn v, ok\n")
+	g.Printf("}\n\n")
+	g.genMethod(typ, f.item)
+
+	g.Printf("// cgo_func_%[1]s_ sets the item of a %[2]s at key k\n", f.setitem.ID(), sym.gofmt())
+	g.Printf("func cgo_func_%[1]s_(o %[2]s, k %[3]s, v %[4]s) {\n", f.setitem.ID(), sym.gofmt(), key, elem)
+	g.Printf("\to[k] = v\n")
+	g.Printf("}\n\n")
+	g.genMethod(typ, f.setitem)
+
+	g.Printf("// cgo_func_%[1]s_ deletes the item of a %[2]s at key k, if any\n", f.delitem.ID(), sym.gofmt())
+	g.Printf("func cgo_func_%[1]s_(o %[2]s, k %[3]s) bool {\n", f.delitem.ID(), sym.gofmt(), key)
+	g.Printf("\t_, ok := o[k]\n")
+	g.Printf("\tdelete(o, k)\n")
+	g.Printf("\treturn ok\n")
+	g.Printf("}\n\n")
+	g.genMethod(typ, f.delitem)
+
+	// python iterates over the keys in a stable order: ordered keys are
+	// sorted by value, booleans false first, structs by their string form.
+	less := "keys[i] < keys[j]"
+	switch u := m.Key().Underlying().(type) {
+	case *types.Basic:
+		if u.Info()&types.IsBoolean != 0 {
+			less = "!keys[i] && keys[j]"
 		}
-		g.Printf("\n")
+	case *types.Struct:
+		less = "fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])"
 	}
+	g.Printf("// cgo_func_%[1]s_ returns the sorted keys of a %[2]s\n", f.keys.ID(), sym.gofmt())
+	g.Printf("func cgo_func_%[1]s_(o %[2]s) []%[3]s {\n", f.keys.ID(), sym.gofmt(), key)
+	g.Indent()
+	g.Printf("keys := make([]%s, 0, len(o))\n", key)
+	g.Printf("for k := range o {\n")
+	g.Printf("\tkeys = append(keys, k)\n")
+	g.Printf("}\n")
+	g.Printf("sort.Slice(keys, func(i, j int) bool { return %s })\n", less)
+	g.Printf("return keys\n")
+	g.Outdent()
+	g.Printf("}\n\n")
+
+	g.Printf("\n// cgo_func_%[1]s wraps the keys of %[2]s\n", f.keys.ID(), sym.gofmt())
+	g.Printf("func cgo_func_%[1]s(out, in *seq.Buffer) {\n", f.keys.ID())
+	g.Indent()
+	g.genRead("o", "in", sym.GoType())
+	g.Printf("keys := cgo_func_%[1]s_(o)\n", f.keys.ID())
+	g.Printf("out.WriteInt(len(keys))\n")
+	// struct keys are written by reference: each one needs its own
+	// variable.
+	g.Printf("for i := range keys {\n")
+	g.Indent()
+	g.genWrite("keys[i]", "out", m.Key())
+	g.Outdent()
+	g.Printf("}\n")
 	g.Outdent()
 	g.Printf("}\n\n")
 
+	g.regs = append(g.regs, goReg{
+		Descriptor: f.keys.Descriptor(),
+		ID:         uhash(f.keys.ID()),
+		Func:       f.keys.ID(),
+	})
 }
 
-func (g *goGen) genTypeMethods(sym *symbol) {
-	if !sym.isNamed() {
-		return
-	}
+// genTypeChan generates the go side of the methods generated for chan
+// types. Sending on or closing a closed channel is reported as an error,
+// not as a panic.
+func (g *goGen) genTypeChan(typ Type) {
+	sym := typ.sym
+	ch := sym.GoType().Underlying().(*types.Chan)
+	elem := g.pkg.syms.symtype(ch.Elem()).gofmt()
 
-	typ := sym.GoType().(*types.Named)
-	for imeth := 0; imeth < typ.NumMethods(); imeth++ {
-		m := typ.Method(imeth)
-		if !m.Exported() {
+	for _, m := range typ.meths {
+		if m.typ != nil {
+			// declared in go.
 			continue
 		}
-
-		mname := types.ObjectString(m, nil)
-		msym := g.pkg.syms.sym(mname)
-		if msym == nil {
-			panic(fmt.Errorf(
-				"gopy: could not find symbol for [%[1]T] (%#[1]v) (%[2]s)",
-				m.Type(),
-				m.Name()+" || "+m.FullName(),
-			))
-		}
-		g.Printf("//export cgo_func_%[1]s\n", msym.id)
-		g.Printf("func cgo_func_%[1]s(self %[2]s",
-			msym.id,
-			sym.cgoname,
-		)
-		sig := m.Type().(*types.Signature)
-		params := sig.Params()
-		if params != nil {
-			for i := 0; i < params.Len(); i++ {
-				arg := params.At(i)
-				sarg := g.pkg.syms.symtype(arg.Type())
-				if sarg == nil {
-					panic(fmt.Errorf(
-						"gopy: could not find symbol for [%T]",
-						arg.Type(),
-					))
-				}
-				g.Printf(", arg%03d %s", i, sarg.cgotypename())
-			}
-		}
-		g.Printf(") ")
-		res := sig.Results()
-		if res != nil {
-			g.Printf("(")
-			for i := 0; i < res.Len(); i++ {
-				if i > 0 {
-					g.Printf(", ")
-				}
-				ret := res.At(i)
-				sret := g.pkg.syms.symtype(ret.Type())
-				if sret == nil {
-					panic(fmt.Errorf(
-						"gopy: could not find symbol for [%T]",
-						ret.Type(),
-					))
-				}
-				g.Printf("%s", sret.cgotypename())
-			}
-			g.Printf(")")
+		g.Printf("// cgo_func_%[1]s_ wraps %[2]s.%[3]s\n", m.ID(), sym.gofmt(), m.GoName())
+		switch m.GoName() {
+		case "Send":
+			g.Printf("func cgo_func_%[1]s_(o %[2]s, v %[3]s) (err error) {\n", m.ID(), sym.gofmt(), elem)
+			g.Indent()
+			g.genChanRecover()
+			g.Printf("o <- v\n")
+			g.Printf("return nil\n")
+		case "Signal":
+			g.Printf("func cgo_func_%[1]s_(o %[2]s) (err error) {\n", m.ID(), sym.gofmt())
+			g.Indent()
+			g.genChanRecover()
+			g.Printf("o <- %s{}\n", elem)
+			g.Printf("return nil\n")
+		case "Recv":
+			g.Printf("func cgo_func_%[1]s_(o %[2]s, timeout float64) (%[3]s, error) {\n", m.ID(), sym.gofmt(), elem)
+			g.Indent()
+			g.Printf("var v %s\n", elem)
+			g.Printf("ok := false\n")
+			g.genChanRecv("v, ok = <-o", "return v, seq.ErrTimeout")
+			g.Printf("if !ok {\n")
+			g.Printf("\treturn v, fmt.Errorf(\"receive from closed channel\")\n")
+			g.Printf("}\n")
+			g.Printf("return v, nil\n")
+		case "Wait":
+			// a closed channel is always signaled.
+			g.Printf("func cgo_func_%[1]s_(o %[2]s, timeout float64) bool {\n", m.ID(), sym.gofmt())
+			g.Indent()
+			g.genChanRecv("<-o", "return false")
+			g.Printf("return true\n")
+		case "Close":
+			g.Printf("func cgo_func_%[1]s_(o %[2]s) (err error) {\n", m.ID(), sym.gofmt())
+			g.Indent()
+			g.genChanRecover()
+			g.Printf("close(o)\n")
+			g.Printf("return nil\n")
+		case "Len":
+			g.Printf("func cgo_func_%[1]s_(o %[2]s) int {\n", m.ID(), sym.gofmt())
+			g.Indent()
+			g.Printf("return len(o)\n")
+		case "Cap":
+			g.Printf("func cgo_func_%[1]s_(o %[2]s) int {\n", m.ID(), sym.gofmt())
+			g.Indent()
+			g.Printf("return cap(o)\n")
+		default:
+			panic(fmt.Errorf("gopy: unhandled chan method %s", m.GoName()))
 		}
-		g.Printf(" {\n")
-		g.Indent()
+		g.Outdent()
+		g.Printf("}\n\n")
+	}
+}
 
-		if res != nil {
-			for i := 0; i < res.Len(); i++ {
-				if i > 0 {
-					g.Printf(", ")
-				}
-				g.Printf("res%03d", i)
-			}
-			if res.Len() > 0 {
-				g.Printf(" := ")
-			}
-		}
-		if sym.isBasic() {
-			g.Printf("(*%s)(unsafe.Pointer(&self)).%s(",
-				sym.gofmt(),
-				msym.goname,
-			)
-		} else {
-			g.Printf("(*%s)(unsafe.Pointer(self)).%s(",
-				sym.gofmt(),
-				msym.goname,
-			)
-		}
+// genChanRecv runs recv, receiving from the channel o, waiting at most
+// timeout seconds if timeout is not negative, a zero timeout polling the
+// channel. The expired statement returns once the timeout expires.
+func (g *goGen) genChanRecv(recv, expired string) {
+	g.Printf("switch {\n")
+	g.Printf("case timeout < 0:\n")
+	g.Printf("\t%s\n", recv)
+	g.Printf("case timeout == 0:\n")
+	g.Indent()
+	g.Printf("select {\n")
+	g.Printf("case %s:\n", recv)
+	g.Printf("default:\n")
+	g.Printf("\t%s\n", expired)
+	g.Printf("}\n")
+	g.Outdent()
+	g.Printf("default:\n")
+	g.Indent()
+	g.Printf("timer := time.NewTimer(time.Duration(timeout * float64(time.Second)))\n")
+	g.Printf("defer timer.Stop()\n")
+	g.Printf("select {\n")
+	g.Printf("case %s:\n", recv)
+	g.Printf("case <-timer.C:\n")
+	g.Printf("\t%s\n", expired)
+	g.Printf("}\n")
+	g.Outdent()
+	g.Printf("}\n")
+}
 
-		if params != nil {
-			for i := 0; i < params.Len(); i++ {
-				if i > 0 {
-					g.Printf(", ")
-				}
-				sarg := g.pkg.syms.symtype(params.At(i).Type())
-				if needWrapType(sarg.GoType()) {
-					g.Printf("*(*%s)(unsafe.Pointer(arg%03d))",
-						sarg.gofmt(),
-						i,
-					)
-				} else {
-					g.Printf("arg%03d", i)
-				}
-			}
-		}
-		g.Printf(")\n")
+// genChanRecover turns the panic of a send on, or a close of, a closed
+// channel into the error result err.
+func (g *goGen) genChanRecover() {
+	g.Printf("defer func() {\n")
+	g.Indent()
+	g.Printf("if e := recover(); e != nil {\n")
+	g.Printf("\terr = fmt.Errorf(\"%%v\", e)\n")
+	g.Printf("}\n")
+	g.Outdent()
+	g.Printf("}()\n")
+}
 
-		if res == nil || res.Len() <= 0 {
-			g.Outdent()
-			g.Printf("}\n\n")
+// genTypeContext generates the go side of the methods generated for
+// context.Context. The contexts derived from python hold their cancel
+// func.
+func (g *goGen) genTypeContext(typ Type) {
+	g.Printf("// cgopy_context is a context derived from python, which python can cancel.\n")
+	g.Printf("type cgopy_context struct {\n")
+	g.Printf("\tcontext.Context\n")
+	g.Printf("\tcancel context.CancelFunc\n")
+	g.Printf("}\n\n")
+
+	for _, m := range typ.meths {
+		if m.typ != nil {
+			// declared in go.
 			continue
 		}
-
-		g.Printf("return ")
-		for i := 0; i < res.Len(); i++ {
-			if i > 0 {
-				g.Printf(", ")
-			}
-			sret := g.pkg.syms.symtype(res.At(i).Type())
-			if needWrapType(sret.GoType()) {
-				g.Printf(
-					"%s(unsafe.Pointer(&",
-					sret.cgoname,
-				)
-			}
-			g.Printf("res%03d", i)
-			if needWrapType(sret.GoType()) {
-				g.Printf("))")
-			}
+		g.Printf("// cgo_func_%[1]s_ wraps context.Context.%[2]s\n", m.ID(), m.GoName())
+		switch m.GoName() {
+		case "WithCancel":
+			g.Printf("func cgo_func_%[1]s_(o context.Context) context.Context {\n", m.ID())
+			g.Indent()
+			g.Printf("ctx, cancel := context.WithCancel(o)\n")
+			g.Printf("return &cgopy_context{ctx, cancel}\n")
+		case "WithTimeout":
+			g.Printf("func cgo_func_%[1]s_(o context.Context, seconds float64) context.Context {\n", m.ID())
+			g.Indent()
+			g.Printf("ctx, cancel := context.WithTimeout(o, time.Duration(seconds*float64(time.Second)))\n")
+			g.Printf("return &cgopy_context{ctx, cancel}\n")
+		case "Cancel":
+			g.Printf("func cgo_func_%[1]s_(o context.Context) error {\n", m.ID())
+			g.Indent()
+			g.Printf("ctx, ok := o.(*cgopy_context)\n")
+			g.Printf("if !ok {\n")
+			g.Printf("\treturn fmt.Errorf(\"context can not be canceled from python\")\n")
+			g.Printf("}\n")
+			g.Printf("ctx.cancel()\n")
+			g.Printf("return nil\n")
+		case "Deadline":
+			g.Printf("func cgo_func_%[1]s_(o context.Context) interface{} {\n", m.ID())
+			g.Indent()
+			g.Printf("deadline, ok := o.Deadline()\n")
+			g.Printf("if !ok {\n")
+			g.Printf("\treturn nil\n")
+			g.Printf("}\n")
+			g.Printf("return float64(deadline.UnixNano()) / 1e9\n")
+		default:
+			panic(fmt.Errorf("gopy: unhandled context method %s", m.GoName()))
 		}
-		g.Printf("\n")
+		g.Outdent()
+		g.Printf("}\n\n")
+	}
+}
 
+// genTypeBytesBuffer generates the go side of the methods generated for
+// bytes.Buffer.
+func (g *goGen) genTypeBytesBuffer(typ Type) {
+	for _, m := range typ.meths {
+		if m.typ != nil {
+			// declared in go.
+			continue
+		}
+		g.Printf("// cgo_func_%[1]s_ wraps bytes.Buffer.%[2]s\n", m.ID(), m.GoName())
+		switch m.GoName() {
+		case "write":
+			g.Printf("func cgo_func_%[1]s_(o *bytes.Buffer, b []byte) int {\n", m.ID())
+			g.Indent()
+			g.Printf("n, _ := o.Write(b)\n")
+			g.Printf("return n\n")
+		case "read":
+			g.Printf("func cgo_func_%[1]s_(o *bytes.Buffer, n int) []byte {\n", m.ID())
+			g.Indent()
+			g.Printf("if n < 0 {\n")
+			g.Printf("\tn = o.Len()\n")
+			g.Printf("}\n")
+			g.Printf("return append([]byte(nil), o.Next(n)...)\n")
+		case "string":
+			g.Printf("func cgo_func_%[1]s_(o *bytes.Buffer) string {\n", m.ID())
+			g.Indent()
+			g.Printf("return o.String()\n")
+		default:
+			panic(fmt.Errorf("gopy: unhandled bytes.Buffer method %s", m.GoName()))
+		}
 		g.Outdent()
 		g.Printf("}\n\n")
 	}
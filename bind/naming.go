@@ -0,0 +1,151 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bind
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Naming describes how the names of go funcs, methods and fields are
+// exposed to python.
+// The names of the generated C and Go entities are not affected.
+type Naming int
+
+const (
+	NamingGo    Naming = iota // go names are exposed as-is
+	NamingSnake               // CamelCase go names are exposed as snake_case
+)
+
+// ParseNaming returns the Naming convention named s.
+func ParseNaming(s string) (Naming, error) {
+	switch s {
+	case "", "go":
+		return NamingGo, nil
+	case "snake":
+		return NamingSnake, nil
+	}
+	return NamingGo, fmt.Errorf("bind: unknown naming convention %q", s)
+}
+
+// pyname returns the python name of the go entity named n.
+// Names clashing with a python keyword or builtin get a trailing
+// underscore: type -> type_.
+func (n Naming) pyname(name string) string {
+	return pyEscape(n.convert(name))
+}
+
+// convert returns name, converted by the naming convention n.
+func (n Naming) convert(name string) string {
+	switch n {
+	case NamingSnake:
+		return snakeCase(name)
+	}
+	return name
+}
+
+// pydoc returns the docstring doc of the go entity named name, noting the
+// name it was renamed from when its python name clashes with a python
+// keyword or builtin.
+func (n Naming) pydoc(name, doc string) string {
+	orig := n.convert(name)
+	if !isPyReserved(orig) {
+		return doc
+	}
+	note := fmt.Sprintf("(%s is a python %s: exposed as %s.)", orig, pyReservedKind(orig), orig+"_")
+	if doc == "" {
+		return note
+	}
+	return strings.TrimRight(doc, "\n") + "\n\n" + note
+}
+
+// pyKeywords are the reserved words of python 2 and 3.
+var pyKeywords = map[string]bool{
+	"False": true, "None": true, "True": true,
+	"and": true, "as": true, "assert": true, "async": true, "await": true,
+	"break": true, "class": true, "continue": true, "def": true, "del": true,
+	"elif": true, "else": true, "except": true, "exec": true, "finally": true,
+	"for": true, "from": true, "global": true, "if": true, "import": true,
+	"in": true, "is": true, "lambda": true, "nonlocal": true, "not": true,
+	"or": true, "pass": true, "print": true, "raise": true, "return": true,
+	"try": true, "while": true, "with": true, "yield": true,
+}
+
+// pyBuiltins are the builtin functions and types of python 2 and 3,
+// exceptions aside.
+var pyBuiltins = map[string]bool{
+	"abs": true, "all": true, "any": true, "apply": true, "ascii": true,
+	"basestring": true, "bin": true, "bool": true, "buffer": true,
+	"bytearray": true, "bytes": true, "callable": true, "chr": true,
+	"classmethod": true, "cmp": true, "coerce": true, "compile": true,
+	"complex": true, "delattr": true, "dict": true, "dir": true,
+	"divmod": true, "enumerate": true, "eval": true, "execfile": true,
+	"file": true, "filter": true, "float": true, "format": true,
+	"frozenset": true, "getattr": true, "globals": true, "hasattr": true,
+	"hash": true, "help": true, "hex": true, "id": true, "input": true,
+	"int": true, "intern": true, "isinstance": true, "issubclass": true,
+	"iter": true, "len": true, "list": true, "locals": true, "long": true,
+	"map": true, "max": true, "memoryview": true, "min": true, "next": true,
+	"object": true, "oct": true, "open": true, "ord": true, "pow": true,
+	"property": true, "range": true, "raw_input": true, "reduce": true,
+	"reload": true, "repr": true, "reversed": true, "round": true,
+	"set": true, "setattr": true, "slice": true, "sorted": true,
+	"staticmethod": true, "str": true, "sum": true, "super": true,
+	"tuple": true, "type": true, "unichr": true, "unicode": true,
+	"vars": true, "xrange": true, "zip": true,
+}
+
+// isPyReserved returns whether name is a python keyword or builtin.
+func isPyReserved(name string) bool {
+	return pyKeywords[name] || pyBuiltins[name]
+}
+
+// pyReservedKind describes the python keyword or builtin name.
+func pyReservedKind(name string) string {
+	if pyKeywords[name] {
+		return "keyword"
+	}
+	return "builtin"
+}
+
+// pyEscape appends an underscore to name if it is a python keyword or
+// builtin.
+func pyEscape(name string) string {
+	if isPyReserved(name) {
+		return name + "_"
+	}
+	return name
+}
+
+// pyArgName returns the python keyword of the go parameter named name:
+// keywords get a trailing underscore, to be usable as keyword arguments,
+// but builtins are left as-is, as they are not shadowed by arguments.
+func pyArgName(name string) string {
+	if pyKeywords[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// snakeCase converts a CamelCase identifier into snake_case.
+// Runs of upper-case letters are treated as a single word, so that
+// acronyms are kept together: HTTPServer -> http_server.
+func snakeCase(s string) string {
+	rs := []rune(s)
+	out := make([]rune, 0, len(rs)+4)
+	for i, r := range rs {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := rs[i-1]
+			next := i+1 < len(rs) && unicode.IsLower(rs[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				(unicode.IsUpper(prev) && next) {
+				out = append(out, '_')
+			}
+		}
+		out = append(out, unicode.ToLower(r))
+	}
+	return string(out)
+}
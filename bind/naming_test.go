@@ -0,0 +1,92 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bind
+
+import (
+	"testing"
+)
+
+func TestSnakeCase(t *testing.T) {
+	for _, table := range []struct {
+		name string
+		want string
+	}{
+		{"Add", "add"},
+		{"add", "add"},
+		{"NewPerson", "new_person"},
+		{"HTTPServer", "http_server"},
+		{"GetURL", "get_url"},
+		{"ID", "id"},
+		{"UserID2", "user_id2"},
+		{"Int64Value", "int64_value"},
+		{"GetC1", "get_c1"},
+		{"already_snake", "already_snake"},
+	} {
+		got := snakeCase(table.name)
+		if got != table.want {
+			t.Errorf("snakeCase(%q): got=%q want=%q\n", table.name, got, table.want)
+		}
+	}
+}
+
+func TestPyName(t *testing.T) {
+	for _, table := range []struct {
+		naming Naming
+		name   string
+		want   string
+	}{
+		{NamingGo, "Type", "Type"},
+		{NamingGo, "None", "None_"},
+		{NamingSnake, "Type", "type_"},
+		{NamingSnake, "Class", "class_"},
+		{NamingSnake, "ID", "id_"},
+		{NamingSnake, "ConnID", "conn_id"},
+		{NamingSnake, "Async", "async_"},
+	} {
+		got := table.naming.pyname(table.name)
+		if got != table.want {
+			t.Errorf("pyname(%q): got=%q want=%q\n", table.name, got, table.want)
+		}
+	}
+}
+
+func TestPyDoc(t *testing.T) {
+	for _, table := range []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"Add", "adds.", "adds."},
+		{"Type", "", "(type is a python builtin: exposed as type_.)"},
+		{"Def", "defines.\n", "defines.\n\n(def is a python keyword: exposed as def_.)"},
+	} {
+		got := NamingSnake.pydoc(table.name, table.doc)
+		if got != table.want {
+			t.Errorf("pydoc(%q): got=%q want=%q\n", table.name, got, table.want)
+		}
+	}
+}
+
+func TestParseNaming(t *testing.T) {
+	for _, table := range []struct {
+		name string
+		want Naming
+		err  bool
+	}{
+		{"", NamingGo, false},
+		{"go", NamingGo, false},
+		{"snake", NamingSnake, false},
+		{"camel", NamingGo, true},
+	} {
+		got, err := ParseNaming(table.name)
+		if (err != nil) != table.err {
+			t.Errorf("ParseNaming(%q): unexpected error value: %v\n", table.name, err)
+			continue
+		}
+		if got != table.want {
+			t.Errorf("ParseNaming(%q): got=%v want=%v\n", table.name, got, table.want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bind
+
+import (
+	"fmt"
+)
+
+// NetTypes describes how net.IP and *url.URL values are exchanged with
+// python.
+type NetTypes int
+
+const (
+	NetTypesText   NetTypes = iota // values are exchanged as their text, nil as None
+	NetTypesOpaque                 // values are wrapped as the other types of external packages
+)
+
+// ParseNetTypes returns the NetTypes convention named s.
+func ParseNetTypes(s string) (NetTypes, error) {
+	switch s {
+	case "", "text":
+		return NetTypesText, nil
+	case "opaque":
+		return NetTypesOpaque, nil
+	}
+	return NetTypesText, fmt.Errorf("bind: unknown net types convention %q", s)
+}
@@ -6,40 +6,79 @@ package bind
 
 import (
 	"fmt"
+	"go/ast"
 	"go/doc"
+	"go/importer"
+	"go/token"
 	"go/types"
 	"reflect"
+	"sort"
 	"strings"
 )
 
 // Package ties types.Package and ast.Package together.
 // Package also collects informations about structs and funcs.
 type Package struct {
-	pkg *types.Package
-	n   int // number of entities to wrap
-	sz  types.Sizes
-	doc *doc.Package
+	pkg  *types.Package
+	n    int // number of entities to wrap
+	sz   types.Sizes
+	doc  *doc.Package
+	fset *token.FileSet
+
+	diags   ErrorList                // entities which could not be bound
+	notes   []*Note                  // entities bound in a restricted way
+	wrapped map[*types.TypeName]bool // named types with a python type
+
+	syms    *symtab
+	objs    map[string]Object
+	consts  []Const
+	vars    []Var
+	types   []Type
+	funcs   []Func
+	aliases []typeAlias  // exported aliases of wrapped types
+	convs   []*converter // types annotated with //gopy:convert
+
+	overloads []overload      // funcs grouped by //gopy:overload
+	errorsIs  Func            // errors.Is, called by the errors_is func of the module
+	shadowed  map[string]bool // GetX and SetX funcs of the consts and vars clashing with module objects
+
+	buffer     *types.TypeName // bytes.Buffer, exposed by every module
+	usesBuffer bool            // true if the exported API of the package refers to bytes.Buffer
+}
+
+// overload is a group of funcs annotated with //gopy:overload name, called
+// from python through a func of that name which dispatches on the number
+// and the types of its arguments.
+type overload struct {
+	name  string
+	funcs []Func // module funcs and ctors, in the order of their declaration
+}
 
-	syms   *symtab
-	objs   map[string]Object
-	consts []Const
-	vars   []Var
-	types  []Type
-	funcs  []Func
+// typeAlias is an exported type alias, exposed to python as another name
+// of the python type of its target.
+type typeAlias struct {
+	obj *types.TypeName
+	typ Type // wrapped target
 }
 
 // NewPackage creates a new Package, tying types.Package and ast.Package together.
-func NewPackage(pkg *types.Package, doc *doc.Package) (*Package, error) {
+// fset resolves the positions of the objects of pkg. The packages pkg imports
+// are renamed by renameImports.
+// nets tells how the net.IP and *url.URL values are exchanged with python.
+func NewPackage(fset *token.FileSet, pkg *types.Package, doc *doc.Package, nets NetTypes) (*Package, error) {
 	universe.pkg = pkg // FIXME(sbinet)
+	renameImports(pkg)
 	sz := int64(reflect.TypeOf(int(0)).Size())
 	p := &Package{
 		pkg:  pkg,
 		n:    0,
 		sz:   &types.StdSizes{sz, sz},
 		doc:  doc,
+		fset: fset,
 		syms: newSymtab(pkg, nil),
 		objs: map[string]Object{},
 	}
+	p.syms.nets = nets
 	err := p.process()
 	if err != nil {
 		return nil, err
@@ -47,6 +86,57 @@ func NewPackage(pkg *types.Package, doc *doc.Package) (*Package, error) {
 	return p, err
 }
 
+// keptNames are the import paths of the packages the generated go code
+// refers to by their name, such as the ones imported by its preamble.
+var keptNames = map[string]bool{
+	"bytes":                              true,
+	"context":                            true,
+	"errors":                             true,
+	"fmt":                                true,
+	"github.com/go-python/gopy/bind/seq": true,
+	"math/big":                           true,
+	"net":                                true,
+	"net/url":                            true,
+	"os":                                 true,
+	"reflect":                            true,
+	"sort":                               true,
+	"sync":                               true,
+	"time":                               true,
+	"unsafe":                             true,
+}
+
+// renameImports renames the packages imported by pkg, directly or not,
+// which share their name with another one, such as html/template and
+// text/template, after their import path, e.g. html_template: the generated
+// go code imports and refers to them by name. pkg keeps its name, as do the
+// packages of keptNames.
+func renameImports(pkg *types.Package) {
+	byName := make(map[string][]*types.Package)
+	seen := map[*types.Package]bool{pkg: true}
+	var walk func(p *types.Package)
+	walk = func(p *types.Package) {
+		for _, imp := range p.Imports() {
+			if seen[imp] {
+				continue
+			}
+			seen[imp] = true
+			byName[imp.Name()] = append(byName[imp.Name()], imp)
+			walk(imp)
+		}
+	}
+	walk(pkg)
+	for name, pkgs := range byName {
+		if len(pkgs) < 2 && name != pkg.Name() {
+			continue
+		}
+		for _, imp := range pkgs {
+			if !keptNames[imp.Path()] {
+				imp.SetName(pathID(imp.Path()))
+			}
+		}
+	}
+}
+
 // Name returns the package name.
 func (p *Package) Name() string {
 	return p.pkg.Name()
@@ -57,6 +147,62 @@ func (p *Package) ImportPath() string {
 	return p.doc.ImportPath
 }
 
+// Diagnostics returns the list of the exported entities which could not be
+// bound, as *Diagnostic values.
+func (p *Package) Diagnostics() ErrorList {
+	return p.diags
+}
+
+// skip records that the entity obj of the given kind, named name, is not
+// bound because of err.
+func (p *Package) skip(kind string, obj types.Object, name string, err error) {
+	var pos token.Position
+	if p.fset != nil && obj.Pos().IsValid() {
+		pos = p.fset.Position(obj.Pos())
+	}
+	p.diags = append(p.diags, &Diagnostic{
+		Pos:  pos,
+		Kind: kind,
+		Name: name,
+		Err:  err,
+	})
+}
+
+// checkSelectors reports the methods promoted through the embedded fields
+// of the struct type t which are ambiguous, and not bound, or shadowed by a
+// field or method at a shallower depth.
+func (p *Package) checkSelectors(t Type) {
+	qname := p.Name() + "." + t.obj.Name()
+	for _, c := range selectorConflicts(t.GoType()) {
+		name := qname + "." + c.name
+		if c.ambiguous {
+			err := fmt.Errorf("ambiguous selector: promoted from %s", strings.Join(c.paths, " and "))
+			p.skip("method", t.obj, name, err)
+			continue
+		}
+		p.note(t.obj, name, "shadows the promoted "+strings.Join(c.paths, ", "))
+	}
+}
+
+// Notes returns the exported entities which are bound in a restricted way.
+func (p *Package) Notes() []*Note {
+	return p.notes
+}
+
+// note records that the entity obj, named name, is bound as described by
+// msg.
+func (p *Package) note(obj types.Object, name, msg string) {
+	var pos token.Position
+	if p.fset != nil && obj.Pos().IsValid() {
+		pos = p.fset.Position(obj.Pos())
+	}
+	p.notes = append(p.notes, &Note{
+		Pos:  pos,
+		Name: name,
+		Msg:  msg,
+	})
+}
+
 // getDoc returns the doc string associated with types.Object
 // parent is the name of the containing scope ("" for global scope)
 func (p *Package) getDoc(parent string, o types.Object) string {
@@ -72,6 +218,22 @@ func (p *Package) getDoc(parent string, o types.Object) string {
 		}
 
 	case *types.Var:
+		if parent != "" {
+			// the fields of a struct type are documented as go doc
+			// renders them, their doc comment or line comment following
+			// their declaration.
+			doc := fmt.Sprintf("%s %s", n, typeString(o.Type()))
+			if field := p.getField(parent, n); field != nil {
+				text := field.Doc.Text()
+				if text == "" {
+					text = field.Comment.Text()
+				}
+				if text != "" {
+					doc = fmt.Sprintf("%s\n\n%s", doc, text)
+				}
+			}
+			return doc
+		}
 		for _, v := range p.doc.Vars {
 			for _, vn := range v.Names {
 				if n == vn {
@@ -81,6 +243,7 @@ func (p *Package) getDoc(parent string, o types.Object) string {
 		}
 
 	case *types.Func:
+		parent := recvName(parent, o)
 		doc := func() string {
 			if o.Parent() == nil || (o.Parent() != nil && parent != "") {
 				for _, typ := range p.doc.Types {
@@ -107,36 +270,20 @@ func (p *Package) getDoc(parent string, o types.Object) string {
 						return f.Doc
 					}
 				}
+				// go/doc associates the funcs returning a type with
+				// that type.
+				for _, typ := range p.doc.Types {
+					for _, f := range typ.Funcs {
+						if n == f.Name {
+							return f.Doc
+						}
+					}
+				}
 			}
 			return ""
 		}()
 
-		sig := o.Type().(*types.Signature)
-
-		parseFn := func(tup *types.Tuple) []string {
-			params := []string{}
-			if tup == nil {
-				return params
-			}
-			for i := 0; i < tup.Len(); i++ {
-				paramVar := tup.At(i)
-				paramType := p.syms.symtype(paramVar.Type()).pysig
-				if paramVar.Name() != "" {
-					paramType = fmt.Sprintf("%s %s", paramType, paramVar.Name())
-				}
-				params = append(params, paramType)
-			}
-			return params
-		}
-
-		params := parseFn(sig.Params())
-		results := parseFn(sig.Results())
-
-		paramString := strings.Join(params, ", ")
-		resultString := strings.Join(results, ", ")
-
-		//FIXME(sbinet): add receiver for methods?
-		docSig := fmt.Sprintf("%s(%s) %s", o.Name(), paramString, resultString)
+		docSig := funcSignature(o.(*types.Func))
 
 		if doc != "" {
 			doc = fmt.Sprintf("%s\n\n%s", docSig, doc)
@@ -151,15 +298,281 @@ func (p *Package) getDoc(parent string, o types.Object) string {
 				return t.Doc
 			}
 		}
+		switch typ := o.Type().(type) {
+		case *types.Struct:
+			return fmt.Sprintf("%s wraps the anonymous %s.", n, typeString(typ))
+		case *types.Signature:
+			return fmt.Sprintf("%s wraps the unnamed %s.", n, typeString(typ))
+		case *types.Named:
+			if isInstance(typ) {
+				return fmt.Sprintf("%s wraps %s.", n, typeString(typ))
+			}
+		}
 
-	default:
-		// TODO(sbinet)
-		panic(fmt.Errorf("not yet supported: %v (%T)", o, o))
 	}
 
+	// other objects are not bound, and have no doc.
 	return ""
 }
 
+// getFuncDecl returns the declaration of the func or method o, or nil if
+// it is not declared in the sources of the package.
+// parent is the name of the receiver type ("" for funcs)
+func (p *Package) getFuncDecl(parent string, o types.Object) *ast.FuncDecl {
+	fct, ok := o.(*types.Func)
+	if !ok {
+		// e.g. the call of a func type.
+		return nil
+	}
+	n := fct.Name()
+	if sig := fct.Type().(*types.Signature); sig.Recv() != nil {
+		parent = recvName(parent, o)
+		for _, typ := range p.doc.Types {
+			if typ.Name != parent {
+				continue
+			}
+			for _, m := range typ.Methods {
+				if m.Name == n {
+					return m.Decl
+				}
+			}
+		}
+		return nil
+	}
+
+	// go/doc associates the funcs returning a type with that type.
+	for _, f := range p.doc.Funcs {
+		if f.Name == n {
+			return f.Decl
+		}
+	}
+	for _, typ := range p.doc.Types {
+		for _, f := range typ.Funcs {
+			if f.Name == n {
+				return f.Decl
+			}
+		}
+	}
+	return nil
+}
+
+// getTypeDoc returns the doc comment of the type declaration named n,
+// directives included.
+func (p *Package) getTypeDoc(n string) *ast.CommentGroup {
+	for _, typ := range p.doc.Types {
+		if typ.Name != n || typ.Decl == nil {
+			continue
+		}
+		for _, spec := range typ.Decl.Specs {
+			spec, ok := spec.(*ast.TypeSpec)
+			if !ok || spec.Name.Name != n {
+				continue
+			}
+			if spec.Doc != nil {
+				return spec.Doc
+			}
+		}
+		return typ.Decl.Doc
+	}
+	return nil
+}
+
+// getFieldDoc returns the doc comment of the field f of the struct type
+// named n, if any.
+func (p *Package) getFieldDoc(n, f string) *ast.CommentGroup {
+	if field := p.getField(n, f); field != nil {
+		return field.Doc
+	}
+	return nil
+}
+
+// getField returns the declaration of the field f of the struct type named
+// n, or nil if it is not declared in the sources of the package.
+// Embedded fields are named after their type.
+func (p *Package) getField(n, f string) *ast.Field {
+	for _, typ := range p.doc.Types {
+		if typ.Name != n || typ.Decl == nil {
+			continue
+		}
+		for _, spec := range typ.Decl.Specs {
+			spec, ok := spec.(*ast.TypeSpec)
+			if !ok || spec.Name.Name != n {
+				continue
+			}
+			st, ok := spec.Type.(*ast.StructType)
+			if !ok {
+				return nil
+			}
+			for _, field := range st.Fields.List {
+				if len(field.Names) == 0 && embeddedName(field.Type) == f {
+					return field
+				}
+				for _, name := range field.Names {
+					if name.Name == f {
+						return field
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// lookupInterface returns the interface type named name: an interface of
+// the package, a predeclared one such as error, or one qualified by the name
+// or the import path of a package imported by the package, such as
+// io.Reader or encoding/json.Marshaler.
+func (p *Package) lookupInterface(name string) (types.Type, error) {
+	var obj types.Object
+	if i := strings.LastIndex(name, "."); i < 0 {
+		obj = p.pkg.Scope().Lookup(name)
+		if obj == nil {
+			obj = types.Universe.Lookup(name)
+		}
+	} else if pkg := p.lookupImport(name[:i]); pkg != nil {
+		obj = pkg.Scope().Lookup(name[i+1:])
+	} else {
+		return nil, fmt.Errorf("package %s not imported by %s", name[:i], p.ImportPath())
+	}
+	tn, ok := obj.(*types.TypeName)
+	if !ok || !types.IsInterface(tn.Type()) {
+		return nil, fmt.Errorf("%s is not an interface", name)
+	}
+	return tn.Type(), nil
+}
+
+// lookupImport returns the package named, or with the import path, qual,
+// among the packages imported by the package, directly or not.
+// The export data the package was loaded from only records the imports its
+// exported API refers to: the other imports of its files are imported
+// afresh.
+func (p *Package) lookupImport(qual string) *types.Package {
+	seen := make(map[*types.Package]bool)
+	pkgs := p.pkg.Imports()
+	for len(pkgs) > 0 {
+		pkg := pkgs[0]
+		pkgs = pkgs[1:]
+		if seen[pkg] {
+			continue
+		}
+		seen[pkg] = true
+		if pkg.Path() == qual || pkg.Name() == qual {
+			return pkg
+		}
+		pkgs = append(pkgs, pkg.Imports()...)
+	}
+	imp := importer.ForCompiler(p.fset, "gc", nil)
+	for _, path := range p.doc.Imports {
+		pkg, err := imp.Import(path)
+		if err != nil {
+			continue
+		}
+		if pkg.Path() == qual || pkg.Name() == qual {
+			return pkg
+		}
+	}
+	return nil
+}
+
+// checkImplements returns an error if the type obj does not implement the
+// interfaces listed by the //gopy:implements directives of its doc comment.
+// Values are held by pointer: the methods of *T count, for a type T.
+func (p *Package) checkImplements(obj *types.TypeName) error {
+	for _, name := range directiveArgs(p.getTypeDoc(obj.Name()), "gopy:implements") {
+		iface, err := p.lookupInterface(name)
+		if err != nil {
+			return fmt.Errorf("bind: %s: //gopy:implements %s: %v", obj.Name(), name, err)
+		}
+		typ := obj.Type()
+		if !types.IsInterface(typ) {
+			typ = types.NewPointer(typ)
+		}
+		if m, wrong := types.MissingMethod(typ, iface.Underlying().(*types.Interface), true); m != nil {
+			what := "missing method"
+			if wrong {
+				what = "wrong type for method"
+			}
+			return fmt.Errorf("bind: %s does not implement %s: %s %s", obj.Name(), name, what, m.Name())
+		}
+	}
+	return nil
+}
+
+// exportedMethods returns the unexported methods of the type obj listed by
+// the //gopy:export directives of its doc comment, bound as its exported
+// methods are. They must be declared on obj itself, which must not be an
+// interface: the go side calls them through a //go:linkname.
+func (p *Package) exportedMethods(obj *types.TypeName) (map[string]bool, error) {
+	names := directiveArgs(p.getTypeDoc(obj.Name()), "gopy:export")
+	if len(names) == 0 {
+		return nil, nil
+	}
+	if types.IsInterface(obj.Type()) {
+		return nil, fmt.Errorf("bind: %s: //gopy:export: the methods of interfaces can not be exported", obj.Name())
+	}
+	meths := make(map[string]bool, len(names))
+	for _, name := range names {
+		m, index, _ := types.LookupFieldOrMethod(types.NewPointer(obj.Type()), true, p.pkg, name)
+		fct, ok := m.(*types.Func)
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("bind: %s: //gopy:export %s: no such method", obj.Name(), name)
+		case fct.Exported():
+			return nil, fmt.Errorf("bind: %s: //gopy:export %s: method is already exported", obj.Name(), name)
+		case len(index) > 1:
+			return nil, fmt.Errorf("bind: %s: //gopy:export %s: method is promoted from an embedded field", obj.Name(), name)
+		}
+		meths[name] = true
+	}
+	return meths, nil
+}
+
+// linkname returns the linker symbol of the method fct, as named by a
+// //go:linkname directive: path.T.name or path.(*T).name.
+func linkname(fct *types.Func) string {
+	recv := fct.Type().(*types.Signature).Recv().Type()
+	ptr := false
+	if p, ok := recv.(*types.Pointer); ok {
+		recv, ptr = p.Elem(), true
+	}
+	obj := types.Unalias(recv).(*types.Named).Obj()
+	name := obj.Name()
+	if ptr {
+		name = "(*" + name + ")"
+	}
+	return obj.Pkg().Path() + "." + name + "." + fct.Name()
+}
+
+// isConverted returns whether obj is a type annotated with //gopy:convert,
+// or one of the funcs of its converter.
+func (p *Package) isConverted(obj types.Object) bool {
+	for _, conv := range p.convs {
+		if obj == conv.obj || conv.isHook(obj) {
+			return true
+		}
+	}
+	return false
+}
+
+// recvName returns the name of the type declaring the method o, as found
+// in the sources of the package: the methods of an instantiated generic
+// type are declared by the generic type.
+// parent is the name of the type wrapping the method.
+func recvName(parent string, o types.Object) string {
+	sig, ok := o.Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return parent
+	}
+	typ := sig.Recv().Type()
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem()
+	}
+	if isInstance(typ) {
+		return typ.(*types.Named).Obj().Name()
+	}
+	return parent
+}
+
 // process collects informations about a go package.
 func (p *Package) process() error {
 	var err error
@@ -167,23 +580,71 @@ func (p *Package) process() error {
 	funcs := make(map[string]Func)
 	typs := make(map[string]Type)
 
+	// the types annotated with //gopy:convert are exchanged with python
+	// through their converters, whose funcs are not exposed.
+	p.convs, err = p.converters()
+	if err != nil {
+		return err
+	}
+	for _, conv := range p.convs {
+		p.syms.convs[conv.obj] = conv
+	}
+
+	// anonymous structs and instantiated generic types are added first,
+	// as they have no symbol of their own to be found when processing the
+	// signatures using them.
+	aliases := p.aliasTypes()
+
 	scope := p.pkg.Scope()
+	var objs []types.Object
 	for _, name := range scope.Names() {
 		obj := scope.Lookup(name)
-		if !obj.Exported() {
+		if !obj.Exported() || p.isConverted(obj) {
+			continue
+		}
+		if tn, ok := obj.(*types.TypeName); ok && isGeneric(tn.Type()) {
+			// generic types are only wrapped through their
+			// instantiations.
 			continue
 		}
+		if err := checkObject(obj); err != nil {
+			p.skip(objectKind(obj), obj, p.Name()+"."+name, err)
+			continue
+		}
+		if err := p.checkConvertedObject(obj); err != nil {
+			p.skip(objectKind(obj), obj, p.Name()+"."+name, err)
+			continue
+		}
+		if tn, ok := obj.(*types.TypeName); ok && tn.IsAlias() {
+			if _, ok := types.Unalias(tn.Type()).(*types.Named); !ok {
+				// only named types have a python type to alias.
+				err := fmt.Errorf("alias of unnamed type %s", typeString(tn.Type()))
+				p.skip("type", obj, p.Name()+"."+name, err)
+				continue
+			}
+		}
 
-		p.n++
 		p.syms.addSymbol(obj)
+		if err := p.syms.checkBoundObject(obj); err != nil {
+			p.skip(objectKind(obj), obj, p.Name()+"."+name, err)
+			continue
+		}
+		objs = append(objs, obj)
+		p.n++
 	}
 
-	for _, name := range scope.Names() {
-		obj := scope.Lookup(name)
-		if !obj.Exported() {
-			continue
+	// the interfaces types are documented to implement are checked
+	// first, so bindings are not generated for a broken contract.
+	for _, obj := range objs {
+		if tn, ok := obj.(*types.TypeName); ok && !tn.IsAlias() {
+			if err := p.checkImplements(tn); err != nil {
+				return err
+			}
 		}
+	}
 
+	for _, obj := range objs {
+		name := obj.Name()
 		switch obj := obj.(type) {
 		case *types.Const:
 			p.addConst(obj)
@@ -198,23 +659,170 @@ func (p *Package) process() error {
 			}
 
 		case *types.TypeName:
+			if obj.IsAlias() {
+				// aliases name the python type of their target,
+				// once all the types are known.
+				continue
+			}
 			typs[name], err = newType(p, obj)
 			if err != nil {
 				return err
 			}
+			if st, ok := obj.Type().Underlying().(*types.Struct); ok {
+				for i := 0; i < st.NumFields(); i++ {
+					f := st.Field(i)
+					if !f.Exported() {
+						continue
+					}
+					err := checkType(f.Type())
+					if err == nil {
+						err = checkLock(f.Type())
+					}
+					if err == nil {
+						err = p.syms.checkConverted(f.Type())
+					}
+					if err == nil {
+						err = p.syms.checkBound(f.Type())
+					}
+					if err != nil {
+						p.skip("field", f, p.Name()+"."+name+"."+f.Name(), err)
+					}
+				}
+			}
 
 		default:
-			//TODO(sbinet)
-			panic(fmt.Errorf("not yet supported: %v (%T)", obj, obj))
+			p.skip(objectKind(obj), obj, p.Name()+"."+name, fmt.Errorf("unsupported object %v", obj))
+		}
+
+	}
+
+	for _, obj := range aliases {
+		typs[obj.Name()], err = newType(p, obj)
+		if err != nil {
+			return err
+		}
+	}
+
+	// expose the builtin error interface as a wrapped type, so error
+	// values can be held, passed around and compared from python.
+	errobj := types.Universe.Lookup("error").(*types.TypeName)
+	p.syms.syms[errobj.Name()] = p.syms.sym(errobj.Name())
+	typs[errobj.Name()], err = newType(p, errobj)
+	if err != nil {
+		return err
+	}
+
+	// wrap the types from other packages used in the signatures of
+	// exported funcs and methods, so values of these types can be
+	// held, passed around and have their methods called from python.
+	// The ones of internal packages are opaque handles.
+	for _, obj := range p.externalTypes() {
+		tname := pathID(obj.Pkg().Path()) + "_" + obj.Name()
+		typs[tname], err = newType(p, obj)
+		if err != nil {
+			return err
+		}
+		p.checkExternalFields(obj)
+	}
+
+	wrapped := make(map[*types.TypeName]bool, len(typs))
+	for _, t := range typs {
+		wrapped[t.obj] = true
+	}
+	p.wrapped = wrapped
+
+	// values of converted types are exchanged with python too, as values
+	// of their basic types.
+	exchanged := make(map[*types.TypeName]bool, len(wrapped)+len(p.convs))
+	for obj := range wrapped {
+		exchanged[obj] = true
+	}
+	for _, conv := range p.convs {
+		exchanged[conv.obj] = true
+	}
+	p.walkTypes(func(typ types.Type) {
+		if p.syms.netType(typ) == "" {
+			return
 		}
+		if ptr, ok := typ.(*types.Pointer); ok {
+			typ = ptr.Elem()
+		}
+		exchanged[typ.(*types.Named).Obj()] = true
+	})
 
+	for _, obj := range objs {
+		tn, ok := obj.(*types.TypeName)
+		if !ok || !tn.IsAlias() {
+			continue
+		}
+		target := types.Unalias(tn.Type())
+		found := false
+		for _, t := range typs {
+			if types.Identical(t.GoType(), target) {
+				p.aliases = append(p.aliases, typeAlias{obj: tn, typ: t})
+				found = true
+				break
+			}
+		}
+		if !found {
+			err := fmt.Errorf("alias of unwrapped type %s", typeString(target))
+			p.skip("type", obj, p.Name()+"."+tn.Name(), err)
+		}
 	}
 
 	// remove ctors from funcs.
 	// add methods.
-	for tname, t := range typs {
-		for name, fct := range funcs {
-			if fct.Return() == nil {
+	// types and funcs are processed in a deterministic order, so the
+	// generated bindings are reproducible.
+	tnames := make([]string, 0, len(typs))
+	for tname := range typs {
+		tnames = append(tnames, tname)
+	}
+	sort.Strings(tnames)
+	fnames := make([]string, 0, len(funcs))
+	for name := range funcs {
+		fnames = append(fnames, name)
+	}
+	sort.Strings(fnames)
+
+	// funcs annotated with //gopy:static T are static methods of T, in
+	// python, rather than module funcs or ctors.
+	for _, name := range fnames {
+		decl := p.getFuncDecl("", scope.Lookup(name))
+		tname, ok := directiveArg(decl, "gopy:static")
+		if !ok {
+			continue
+		}
+		t, ok := typs[tname]
+		if !ok || t.isExternal() {
+			return fmt.Errorf("bind: %s: //gopy:static names unknown type %q", name, tname)
+		}
+		t.statics = append(t.statics, funcs[name])
+		typs[tname] = t
+		delete(funcs, name)
+	}
+
+	// funcs annotated with //gopy:not-ctor stay module funcs, even when
+	// they return one of the types.
+	notCtors := make(map[string]bool)
+	for _, name := range fnames {
+		if hasDirective(p.getFuncDecl("", scope.Lookup(name)), "gopy:not-ctor") {
+			notCtors[name] = true
+		}
+	}
+
+	for _, tname := range tnames {
+		t := typs[tname]
+		for _, name := range fnames {
+			fct, ok := funcs[name]
+			if !ok || notCtors[name] {
+				// already a ctor or a static method of another type, or
+				// kept at module level.
+				continue
+			}
+			// funcs returning types named by gopy, or a comma-ok,
+			// are not ctors.
+			if fct.Return() == nil || fct.ok || t.isAliased() {
 				continue
 			}
 			if fct.Return() == t.GoType() {
@@ -226,30 +834,262 @@ func (p *Package) process() error {
 			}
 		}
 
-		ptyp := types.NewPointer(t.GoType())
-		p.syms.addType(nil, ptyp)
-		mset := types.NewMethodSet(ptyp)
+		var exported map[string]bool
+		if !t.isExternal() {
+			var err error
+			exported, err = p.exportedMethods(t.obj)
+			if err != nil {
+				return err
+			}
+		}
+
+		var mset *types.MethodSet
+		if types.IsInterface(t.GoType()) {
+			mset = types.NewMethodSet(t.GoType())
+		} else {
+			ptyp := types.NewPointer(t.GoType())
+			p.syms.addType(nil, ptyp)
+			mset = types.NewMethodSet(ptyp)
+		}
 		for i := 0; i < mset.Len(); i++ {
 			meth := mset.At(i)
-			if !meth.Obj().Exported() {
+			unexported := !meth.Obj().Exported()
+			if unexported && !exported[meth.Obj().Name()] {
+				continue
+			}
+			err := checkSig(meth.Type().(*types.Signature))
+			if err == nil {
+				err = p.syms.checkConverted(meth.Type())
+			}
+			if err != nil {
+				if !t.isExternal() {
+					p.skip("method", meth.Obj(), p.Name()+"."+t.obj.Name()+"."+meth.Obj().Name(), err)
+				}
+				continue
+			}
+			if t.isExternal() && !isWrappableSig(meth.Type().(*types.Signature), exchanged) {
+				// the methods of external types are not part of the
+				// API of the package: they are left out silently.
+				continue
+			}
+			if t.isExternal() || unexported {
+				// unnamed types of external signatures, such as the
+				// interface{} of an any parameter, have no symbol yet,
+				// nor those of unexported methods.
+				sig := meth.Type().(*types.Signature)
+				p.syms.processTuple(sig.Params())
+				p.syms.processTuple(sig.Results())
+			}
+			if err := p.syms.checkBoundSig(meth.Type().(*types.Signature)); err != nil {
+				if !t.isExternal() {
+					p.skip("method", meth.Obj(), p.Name()+"."+t.obj.Name()+"."+meth.Obj().Name(), err)
+				}
 				continue
 			}
 			m, err := newFuncFrom(p, tname, meth.Obj(), meth.Type().(*types.Signature))
 			if err != nil {
 				return err
 			}
+			if unexported {
+				m.linkname = linkname(meth.Obj().(*types.Func))
+			}
+			if types.IsInterface(t.GoType()) {
+				m.nilRecv = true
+			} else {
+				m.embed = embeddedIface(meth)
+			}
 			t.meths = append(t.meths, m)
 			if isStringer(meth.Obj()) {
 				t.prots |= ProtoStringer
 			}
+			if isStringMethod(meth.Obj(), "Error") {
+				t.prots |= ProtoError
+			}
+			if isStringMethod(meth.Obj(), "GoString") {
+				t.prots |= ProtoGoStringer
+			}
+			if isContainer(meth.Obj()) {
+				t.prots |= ProtoContainer
+			}
+		}
+		if !t.isExternal() && !t.isAliased() {
+			p.checkSelectors(t)
+		}
+
+		// values of named basic types know the name of the const
+		// holding their value, if any.
+		if consts := p.typeConsts(t.GoType()); len(consts) > 0 {
+			recv := newVar(p, t.GoType(), "recv", t.obj.Name(), t.sym.doc)
+			styp := universe.sym("string")
+			t.consts = consts
+			t.funcs.name = Func{
+				pkg: p,
+				sig: newSignature(
+					p, recv, nil,
+					[]*Var{newVar(p, styp.GoType(), "ret", "string", "")},
+				),
+				typ:  nil,
+				name: "name",
+				desc: p.ImportPath() + "." + t.obj.Name() + ".name",
+				id:   t.sym.id + "_name",
+				doc:  "",
+				ret:  styp.GoType(),
+				err:  false,
+			}
+		}
+
+		// values of func types are exposed as python callables when
+		// their parameters and results can be exchanged with python.
+		// otherwise, they are opaque handles which can only be passed
+		// back to go.
+		if sig, ok := t.GoType().Underlying().(*types.Signature); ok && isWrappableSig(sig, exchanged) && !hasOpaqueType(sig) {
+			call, err := newFuncFrom(p, tname, t.obj, sig)
+			if err != nil {
+				return err
+			}
+			for i, arg := range call.sig.args {
+				// params of func types are often unnamed.
+				arg.name = fmt.Sprintf("arg%03d", i)
+			}
+			call.typ = nil
+			call.name = "call"
+			call.desc = p.ImportPath() + "." + t.obj.Name() + ".call"
+			call.id = t.sym.id + "_call"
+			t.funcs.call = call
+
+			// python callables are made into values of the func type,
+			// calling them back, when their results can be returned to
+			// go: all of them, or the message of the exception raised.
+			if !call.ok {
+				t.funcs.wrap = Func{
+					pkg: p,
+					sig: newSignature(
+						p, nil,
+						[]*Var{newVar(p, types.Typ[types.Uint64], "fn", "fn", "")},
+						[]*Var{newVar(p, t.GoType(), "ret", t.obj.Name(), "")},
+					),
+					typ:  nil,
+					name: "wrap",
+					desc: p.ImportPath() + "." + t.obj.Name() + ".wrap",
+					id:   t.sym.id + "_wrap",
+					doc:  "",
+					ret:  t.GoType(),
+					err:  false,
+				}
+			}
+		}
+
+		// the getters of the fields annotated with //gopy:ref return
+		// references aliasing the struct.
+		if t.Struct() != nil && !t.isExternal() {
+			if err := p.refFields(t); err != nil {
+				return err
+			}
+		}
+
+		// arrays and slices are indexed from python through the sequence
+		// protocol, maps through the mapping protocol.
+		if t.sym.isArray() || t.sym.isSlice() {
+			p.seqFuncs(tname, &t)
+		}
+		if t.sym.isMap() {
+			p.mapFuncs(tname, &t)
+		}
+
+		// values of chan types are exposed with methods sending to and
+		// receiving from the channel.
+		if ch, ok := t.GoType().Underlying().(*types.Chan); ok {
+			t.meths = append(t.meths, p.chanMethods(tname, t, ch)...)
+		}
+
+		// contexts can be derived and canceled from python.
+		if t.isContext() {
+			t.meths = append(t.meths, p.contextMethods(tname, t)...)
+		}
+
+		// buffers are read and written as python files are, and sized
+		// by their unread bytes.
+		if t.isBytesBuffer() {
+			t.meths = append(t.meths, p.bufferMethods(tname, t)...)
+			t.prots |= ProtoSized
+		}
+
+		// python file objects are made into io.Reader and io.Writer
+		// values, calling back their read or write method.
+		if streamMethod(t.GoType()) != "" && !t.isOpaque() {
+			t.funcs.file = Func{
+				pkg: p,
+				sig: newSignature(
+					p, nil,
+					[]*Var{newVar(p, types.Typ[types.Uint64], "fn", "fn", "")},
+					[]*Var{newVar(p, t.GoType(), "ret", t.obj.Name(), "")},
+				),
+				typ:  nil,
+				name: "file",
+				desc: p.ImportPath() + "." + t.obj.Name() + ".file",
+				id:   t.sym.id + "_file",
+				doc:  "",
+				ret:  t.GoType(),
+				err:  false,
+			}
+		}
+
+		// the errors of a chain which are values of the type are found
+		// from python with errors_as.
+		if target := errorsAsTarget(t.GoType()); target != nil && !t.isOpaque() {
+			t.funcs.as = Func{
+				pkg: p,
+				sig: newSignature(
+					p, nil,
+					[]*Var{newVar(p, errobj.Type(), "err", "err", "")},
+					[]*Var{
+						newVar(p, target, "ret", t.obj.Name(), ""),
+						newVar(p, types.Typ[types.Bool], "ok", "ok", ""),
+					},
+				),
+				typ:    nil,
+				name:   "as",
+				desc:   p.ImportPath() + "." + t.obj.Name() + ".as",
+				id:     t.sym.id + "_as",
+				doc:    "",
+				ret:    target,
+				ok:     true,
+				okNone: true,
+			}
 		}
 		p.addType(t)
 	}
 
-	for _, fct := range funcs {
-		p.addFunc(fct)
+	for _, name := range fnames {
+		if fct, ok := funcs[name]; ok {
+			p.addFunc(fct)
+		}
+	}
+
+	p.errorsIs = Func{
+		pkg: p,
+		sig: newSignature(
+			p, nil,
+			[]*Var{
+				newVar(p, errobj.Type(), "err", "err", ""),
+				newVar(p, errobj.Type(), "target", "target", ""),
+			},
+			[]*Var{newVar(p, types.Typ[types.Bool], "ret", "bool", "")},
+		),
+		typ:  nil,
+		name: "errors_is",
+		desc: p.ImportPath() + ".errors_is",
+		id:   p.Name() + "_errors_is",
+		doc:  "",
+		ret:  types.Typ[types.Bool],
+		err:  false,
 	}
 
+	if err := p.addOverloads(fnames); err != nil {
+		return err
+	}
+	p.checkAccessors()
+
 	// attach docstrings to methods
 	for _, n := range p.syms.names() {
 		sym := p.syms.syms[n]
@@ -260,23 +1100,357 @@ func (p *Package) process() error {
 		case *types.Named:
 			for i := 0; i < typ.NumMethods(); i++ {
 				m := typ.Method(i)
-				if !m.Exported() {
+				if !m.Exported() || checkSig(m.Type().(*types.Signature)) != nil {
 					continue
 				}
 				doc := p.getDoc(sym.goname, m)
 				mname := types.ObjectString(m, nil)
 				msym := p.syms.sym(mname)
 				if msym == nil {
-					panic(fmt.Errorf(
-						"gopy: could not retrieve symbol for %q",
-						m.FullName(),
-					))
+					// the method is not bound: it was reported, if its
+					// type is not external, when the type was processed.
+					continue
 				}
 				msym.doc = doc
 			}
 		}
 	}
-	return err
+	return err
+}
+
+// walkTypes calls visit with the types of the exported vars and struct
+// fields of p, and with the parameter and result types of its exported
+// funcs and methods. The fields of anonymous structs, the parameters and
+// results of funcs are visited too, as well as the type arguments, fields
+// and methods of instantiated generic types.
+func (p *Package) walkTypes(visit func(typ types.Type)) {
+	seen := make(map[string]bool) // type strings of the instantiations
+	var walk func(typ types.Type)
+	walkTuple := func(tuple *types.Tuple) {
+		for i := 0; i < tuple.Len(); i++ {
+			walk(tuple.At(i).Type())
+		}
+	}
+	walkMembers := func(typ types.Type) {
+		switch u := typ.Underlying().(type) {
+		case *types.Struct:
+			for i := 0; i < u.NumFields(); i++ {
+				if f := u.Field(i); p.syms.isWrappedField(f) {
+					walk(f.Type())
+				}
+			}
+		case *types.Signature:
+			walkTuple(u.Params())
+			walkTuple(u.Results())
+		case *types.Array:
+			walk(u.Elem())
+		case *types.Slice:
+			walk(u.Elem())
+		case *types.Map:
+			walk(u.Key())
+			walk(u.Elem())
+		case *types.Chan:
+			walk(u.Elem())
+		}
+		var exported map[string]bool
+		if named, ok := typ.(*types.Named); ok && named.Obj().Pkg() == p.pkg {
+			// invalid //gopy:export directives are reported once the
+			// methods are bound.
+			exported, _ = p.exportedMethods(named.Obj())
+		}
+		mset := types.NewMethodSet(types.NewPointer(typ))
+		for i := 0; i < mset.Len(); i++ {
+			meth := mset.At(i).Obj()
+			if !meth.Exported() && !exported[meth.Name()] {
+				continue
+			}
+			sig := meth.Type().(*types.Signature)
+			if checkSig(sig) != nil {
+				continue
+			}
+			walkTuple(sig.Params())
+			walkTuple(sig.Results())
+		}
+	}
+	// the byte slices of the methods of types from other packages are
+	// wrapped too, as buffers.
+	walkBuffers := func(named *types.Named) {
+		key := types.TypeString(named, nil)
+		if obj := named.Obj(); obj.Pkg() == nil || obj.Pkg() == p.pkg || seen[key] {
+			return
+		}
+		seen[key] = true
+		mset := types.NewMethodSet(types.NewPointer(named))
+		if types.IsInterface(named) {
+			mset = types.NewMethodSet(named)
+		}
+		for i := 0; i < mset.Len(); i++ {
+			meth := mset.At(i).Obj()
+			sig := meth.Type().(*types.Signature)
+			if !meth.Exported() || checkSig(sig) != nil {
+				continue
+			}
+			for _, tuple := range []*types.Tuple{sig.Params(), sig.Results()} {
+				for j := 0; j < tuple.Len(); j++ {
+					if typ, ok := types.Unalias(tuple.At(j).Type()).(*types.Slice); ok && isByteSeqType(typ) {
+						visit(typ)
+					}
+				}
+			}
+		}
+	}
+	walk = func(typ types.Type) {
+		typ = types.Unalias(typ)
+		visit(typ)
+		switch typ := typ.(type) {
+		case *types.Struct:
+			for i := 0; i < typ.NumFields(); i++ {
+				walk(typ.Field(i).Type())
+			}
+		case *types.Signature:
+			walkTuple(typ.Params())
+			walkTuple(typ.Results())
+		case *types.Pointer:
+			named, ok := typ.Elem().(*types.Named)
+			switch {
+			case isInstance(typ.Elem()):
+				walk(typ.Elem())
+			case ok && !isFileType(typ) && !isBigType(typ) && p.syms.netType(typ) == "":
+				walkBuffers(named)
+			}
+		case *types.Array:
+			walk(typ.Elem())
+		case *types.Slice:
+			walk(typ.Elem())
+		case *types.Map:
+			walk(typ.Key())
+			walk(typ.Elem())
+		case *types.Named:
+			if p.syms.netType(typ) != "" {
+				return
+			}
+			if !isInstance(typ) {
+				walkBuffers(typ)
+				return
+			}
+			key := types.TypeString(typ, nil)
+			if seen[key] {
+				return
+			}
+			seen[key] = true
+			args := typ.TypeArgs()
+			for i := 0; i < args.Len(); i++ {
+				walk(args.At(i))
+			}
+			walkMembers(typ)
+		}
+	}
+
+	scope := p.pkg.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if !obj.Exported() || checkObject(obj) != nil {
+			continue
+		}
+		switch obj := obj.(type) {
+		case *types.Var:
+			walk(obj.Type())
+		case *types.Func:
+			sig := obj.Type().(*types.Signature)
+			walkTuple(sig.Params())
+			walkTuple(sig.Results())
+		case *types.TypeName:
+			if obj.IsAlias() {
+				// the target of an alias may be declared in
+				// another package.
+				walk(obj.Type())
+			}
+			walkMembers(obj.Type())
+		}
+	}
+	if buf := p.bytesBuffer(); buf != nil {
+		p.usesBuffer = seen[types.TypeString(buf.Type(), nil)]
+		walk(types.NewPointer(buf.Type()))
+	}
+}
+
+// externalTypes returns the exported named types declared in other
+// packages and used in the signatures of the exported funcs and methods
+// of p, or as the types of its exported vars and struct fields.
+func (p *Package) externalTypes() []*types.TypeName {
+	var objs []*types.TypeName
+	seen := make(map[*types.TypeName]bool)
+	p.walkTypes(func(typ types.Type) {
+		if isFileType(typ) || isBigType(typ) || isDurationType(typ) || p.syms.netType(typ) != "" {
+			// files are exchanged as file descriptors, math/big
+			// numbers as their decimal text, durations as seconds,
+			// and IPs and URLs as their text.
+			return
+		}
+		if ptr, ok := typ.(*types.Pointer); ok {
+			typ = ptr.Elem()
+		}
+		named, ok := typ.(*types.Named)
+		if !ok || isInstance(named) {
+			return
+		}
+		obj := named.Obj()
+		if obj.Pkg() == nil || obj.Pkg() == p.pkg || !obj.Exported() || seen[obj] {
+			return
+		}
+		seen[obj] = true
+		objs = append(objs, obj)
+	})
+
+	bound := objs[:0]
+	for _, obj := range objs {
+		p.syms.addType(obj, obj.Type())
+		if p.syms.symtype(obj.Type()) == nil {
+			// the entities using it are skipped.
+			continue
+		}
+		bound = append(bound, obj)
+	}
+	return bound
+}
+
+// checkExternalFields reports the exported fields of the struct type obj,
+// declared in another package, which can not be exchanged with python, as
+// the ones of the structs of p are.
+func (p *Package) checkExternalFields(obj *types.TypeName) {
+	st, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok || isOpaqueType(obj.Type()) {
+		return
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		if !f.Exported() {
+			continue
+		}
+		err := checkType(f.Type())
+		if err == nil {
+			err = checkLock(f.Type())
+		}
+		if err == nil {
+			err = p.syms.checkConverted(f.Type())
+		}
+		if err == nil {
+			err = p.syms.checkBound(f.Type())
+		}
+		if err != nil {
+			p.skip("field", f, obj.Pkg().Name()+"."+obj.Name()+"."+f.Name(), err)
+		}
+	}
+}
+
+// aliasTypes returns the type names generated for the anonymous structs,
+// the unnamed arrays and slices and the instantiated generic types used by
+// the exported entities of p, and adds them to the symbols table.
+// Identical types share a type name: struct{Size int64; Name string} is
+// named StructSizeName, after its fields, func(int) error is named
+// FuncIntRetError, after its parameters and results, [3]float64 is named
+// Array3Float64, after its length and element, []int is named SliceInt,
+// after its element, and List[int] is named ListInt, after its type
+// arguments.
+func (p *Package) aliasTypes() []*types.TypeName {
+	var objs []*types.TypeName
+	seen := make(map[string]bool)  // type strings of the aliased types
+	names := make(map[string]bool) // generated names
+	scope := p.pkg.Scope()
+	p.walkTypes(func(typ types.Type) {
+		if !isAliased(typ) {
+			return
+		}
+		key := p.syms.typename(typ, nil)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+
+		name := aliasName(typ)
+		if scope.Lookup(name) != nil || names[name] {
+			name = fmt.Sprintf("%s_%x", name, uhash(key))
+		}
+		names[name] = true
+
+		// the type name denotes the aliased type itself.
+		objs = append(objs, types.NewTypeName(token.NoPos, p.pkg, name, unalias(typ)))
+	})
+
+	for _, obj := range objs {
+		p.syms.aliases[p.syms.typename(obj.Type(), nil)] = obj
+	}
+	for _, obj := range objs {
+		p.syms.addType(obj, obj.Type())
+	}
+	return objs
+}
+
+// aliasName returns the name generated for the type typ, when used as an
+// aliased type or as a type argument.
+func aliasName(typ types.Type) string {
+	switch typ := types.Unalias(typ).(type) {
+	case *types.Basic:
+		return title(typ.Name())
+	case *types.Named:
+		name := title(typ.Obj().Name())
+		args := typ.TypeArgs()
+		for i := 0; i < args.Len(); i++ {
+			name += aliasName(args.At(i))
+		}
+		return name
+	case *types.Pointer:
+		return "Ptr" + aliasName(typ.Elem())
+	case *types.Struct:
+		name := "Struct"
+		for i := 0; i < typ.NumFields(); i++ {
+			name += typ.Field(i).Name()
+		}
+		return name
+	case *types.Signature:
+		name := "Func"
+		for i := 0; i < typ.Params().Len(); i++ {
+			name += aliasName(typ.Params().At(i).Type())
+		}
+		if typ.Results().Len() > 0 {
+			name += "Ret"
+		}
+		for i := 0; i < typ.Results().Len(); i++ {
+			name += aliasName(typ.Results().At(i).Type())
+		}
+		return name
+	case *types.Array:
+		return fmt.Sprintf("Array%d%s", typ.Len(), aliasName(typ.Elem()))
+	case *types.Slice:
+		return "Slice" + aliasName(typ.Elem())
+	case *types.Map:
+		if isDictType(typ) {
+			return "Dict"
+		}
+		return "Map" + aliasName(typ.Key()) + aliasName(typ.Elem())
+	case *types.Interface:
+		return "Any"
+	}
+	return ""
+}
+
+// title returns s with its first letter in upper case.
+func title(s string) string {
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// typeConsts returns the wrapped consts of type typ, in declaration order.
+func (p *Package) typeConsts(typ types.Type) []Const {
+	var consts []Const
+	for _, c := range p.consts {
+		if types.Identical(c.GoType(), typ) {
+			consts = append(consts, c)
+		}
+	}
+	sort.Slice(consts, func(i, j int) bool {
+		return consts[i].obj.Pos() < consts[j].obj.Pos()
+	})
+	return consts
 }
 
 func (p *Package) addConst(obj *types.Const) {
@@ -297,6 +1471,135 @@ func (p *Package) addFunc(f Func) {
 	p.objs[f.GoName()] = f
 }
 
+// addOverloads groups the funcs among fnames annotated with
+// //gopy:overload name, module funcs or ctors, by name.
+// A func of the group may be named name, and is then only called through
+// the dispatch. Other objects of the package can not.
+func (p *Package) addOverloads(fnames []string) error {
+	scope := p.pkg.Scope()
+	funcs := make(map[string]Func)
+	for _, f := range p.funcs {
+		funcs[f.GoName()] = f
+	}
+	for _, t := range p.types {
+		for _, f := range t.ctors {
+			funcs[f.GoName()] = f
+		}
+	}
+
+	groups := make(map[string]int) // index of the overloads, by name
+	for _, fname := range fnames {
+		name, ok := directiveArg(p.getFuncDecl("", scope.Lookup(fname)), "gopy:overload")
+		if !ok {
+			continue
+		}
+		f, ok := funcs[fname]
+		switch {
+		case !ok:
+			return fmt.Errorf("bind: %s: //gopy:overload %s: static methods can not be overloaded", fname, name)
+		case !token.IsIdentifier(name):
+			return fmt.Errorf("bind: %s: //gopy:overload %s: invalid name", fname, name)
+		}
+		i, ok := groups[name]
+		if !ok {
+			i = len(p.overloads)
+			groups[name] = i
+			p.overloads = append(p.overloads, overload{name: name})
+		}
+		p.overloads[i].funcs = append(p.overloads[i].funcs, f)
+	}
+
+	for i := range p.overloads {
+		o := &p.overloads[i]
+		// the first matching func is called: the funcs are tried in the
+		// order of their declaration.
+		sort.SliceStable(o.funcs, func(i, j int) bool {
+			return scope.Lookup(o.funcs[i].GoName()).Pos() < scope.Lookup(o.funcs[j].GoName()).Pos()
+		})
+		if obj := scope.Lookup(o.name); obj != nil && !p.isOverloaded(o.name) {
+			return fmt.Errorf("bind: //gopy:overload %[1]s: %[1]s is a %[2]s of the package, not an overloaded func", o.name, objectKind(obj))
+		}
+	}
+	return nil
+}
+
+// isOverloaded returns whether the func named name is overloaded under
+// its own name, and only called from python through the dispatch.
+func (p *Package) isOverloaded(name string) bool {
+	for _, o := range p.overloads {
+		if o.name != name {
+			continue
+		}
+		for _, f := range o.funcs {
+			if f.GoName() == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkAccessors leaves out the GetX and SetX funcs of the consts and vars
+// of the package whose names clash with the ones of its funcs, ctors,
+// overloads or types, under either naming: the funcs of the package win,
+// and the consts and vars stay reachable as module attributes.
+func (p *Package) checkAccessors() {
+	names := make(map[string]string) // python names of the module objects, by snake_case name
+	add := func(name string) {
+		names[snakeCase(name)] = name
+	}
+	for _, f := range p.funcs {
+		if !p.isOverloaded(f.GoName()) {
+			add(f.GoName())
+		}
+	}
+	for _, o := range p.overloads {
+		add(o.name)
+	}
+	for _, t := range p.types {
+		for _, f := range t.ctors {
+			if !p.isOverloaded(f.GoName()) {
+				add(f.GoName())
+			}
+		}
+		if !t.isExternal() && t.obj.Parent() == p.pkg.Scope() {
+			// types keep their go name: they only clash with the
+			// accessors of the same name.
+			names[t.obj.Name()] = t.obj.Name()
+		}
+	}
+	for _, a := range p.aliases {
+		names[a.obj.Name()] = a.obj.Name()
+	}
+
+	p.shadowed = make(map[string]bool)
+	check := func(name string, accessors ...string) {
+		var clashes []string
+		for _, acc := range accessors {
+			obj, ok := names[acc]
+			if !ok {
+				obj, ok = names[snakeCase(acc)]
+			}
+			if !ok {
+				continue
+			}
+			p.shadowed[acc] = true
+			clashes = append(clashes, fmt.Sprintf("%s clashes with %s.%s", acc, p.Name(), obj))
+		}
+		if len(clashes) == 0 {
+			return
+		}
+		msg := "module attribute only (" + strings.Join(clashes, ", ") + ")"
+		p.note(p.pkg.Scope().Lookup(name), p.Name()+"."+name, msg)
+	}
+	for _, c := range p.consts {
+		check(c.GoName(), "Get"+c.GoName())
+	}
+	for _, v := range p.vars {
+		check(v.Name(), "Get"+v.Name(), "Set"+v.Name())
+	}
+}
+
 // Lookup returns the bind.Object corresponding to a types.Object
 func (p *Package) Lookup(o types.Object) (Object, bool) {
 	obj, ok := p.objs[o.Name()]
@@ -308,6 +1611,10 @@ type Protocol int
 
 const (
 	ProtoStringer Protocol = 1 << iota
+	ProtoContainer
+	ProtoError      // Error() string, used by __str__ without a String method
+	ProtoGoStringer // GoString() string, used by __repr__
+	ProtoSized      // Len() int, used by __len__, for bytes.Buffer
 )
 
 // Type collects informations about a go type (struct, named-type, ...)
@@ -316,24 +1623,44 @@ type Type struct {
 	sym *symbol
 	obj *types.TypeName
 
-	id    string
-	doc   string
-	ctors []Func
-	meths []Func
-	funcs struct {
+	id      string
+	doc     string
+	ctors   []Func
+	meths   []Func
+	statics []Func // package funcs annotated with //gopy:static
+	funcs   struct {
 		new  Func
 		del  Func
 		init Func
 		str  Func
+		repr Func // only used for types with a GoString method
+		fmt  Func // formats values with a go fmt verb, for __format__
+		call Func // only set for callable func types
+		wrap Func // only set for func types made from python callables
+		file Func // only set for io.Reader and io.Writer, made from python files
+		as   Func // only set for the types errors.As finds in error chains, for errors_as
+		eq   Func // only set for structs annotated with //gopy:deepeq
+		name Func // only set for types with consts
+
+		// only set for arrays, slices and maps, append only for
+		// slices, delitem and keys only for maps.
+		len     Func
+		item    Func
+		setitem Func
+		append  Func
+		delitem Func
+		keys    Func
 	}
 
-	prots Protocol
+	prots  Protocol
+	consts []Const  // consts of the type, in declaration order
+	bases  []string // python base classes, from //gopy:base directives
 }
 
 func newType(p *Package, obj *types.TypeName) (Type, error) {
 	sym := p.syms.symtype(obj.Type())
 	if sym == nil {
-		panic(fmt.Errorf("no such object [%s] in symbols table", obj.Id()))
+		return Type{}, fmt.Errorf("gopy: no such object [%s] in symbols table", obj.Id())
 	}
 	sym.doc = p.getDoc("", obj)
 	typ := Type{
@@ -345,18 +1672,29 @@ func newType(p *Package, obj *types.TypeName) (Type, error) {
 	desc := p.ImportPath() + "." + obj.Name()
 	recv := newVar(p, obj.Type(), "recv", obj.Name(), sym.doc)
 
+	// values holding a lock, or a C value, are allocated, and never copied,
+	// by the go side: new values are returned by pointer.
+	ret := obj.Type()
+	if path := noCopyPath(ret); path != "" {
+		ret = types.NewPointer(ret)
+		p.syms.addType(nil, ret)
+		if obj.Pkg() == p.pkg && !obj.IsAlias() {
+			p.note(obj, p.Name()+"."+obj.Name(), "holds a "+path+": wrapped by pointer only, with no value copies")
+		}
+	}
+
 	typ.funcs.new = Func{
 		pkg: p,
 		sig: newSignature(
 			p, nil, nil,
-			[]*Var{newVar(p, obj.Type(), "ret", obj.Name(), sym.doc)},
+			[]*Var{newVar(p, ret, "ret", obj.Name(), sym.doc)},
 		),
 		typ:  nil,
 		name: obj.Name(),
 		desc: desc + ".new",
 		id:   sym.id + "_new",
 		doc:  sym.doc,
-		ret:  obj.Type(),
+		ret:  ret,
 		err:  false,
 	}
 
@@ -376,13 +1714,623 @@ func newType(p *Package, obj *types.TypeName) (Type, error) {
 		err:  false,
 	}
 
+	typ.funcs.repr = Func{
+		pkg: p,
+		sig: newSignature(
+			p, recv, nil,
+			[]*Var{newVar(p, styp.GoType(), "ret", "string", "")},
+		),
+		typ:  nil,
+		name: obj.Name(),
+		desc: desc + ".repr",
+		id:   sym.id + "_repr",
+		doc:  "",
+		ret:  styp.GoType(),
+		err:  false,
+	}
+
+	etyp := universe.sym("error")
+	typ.funcs.fmt = Func{
+		pkg: p,
+		sig: newSignature(
+			p, recv,
+			[]*Var{newVar(p, styp.GoType(), "spec", "spec", "")},
+			[]*Var{
+				newVar(p, styp.GoType(), "ret", "string", ""),
+				newVar(p, etyp.GoType(), "err", "error", ""),
+			},
+		),
+		typ:  nil,
+		name: "__format__",
+		desc: desc + ".format",
+		id:   sym.id + "_format",
+		doc:  formatDoc,
+		ret:  styp.GoType(),
+		err:  true,
+	}
+
+	// values of structs annotated with //gopy:deepeq are compared field by
+	// field, with reflect.DeepEqual, against values held by pointer.
+	if obj.Pkg() == p.pkg && hasDocDirective(p.getTypeDoc(obj.Name()), "gopy:deepeq") {
+		if !sym.isStruct() {
+			return typ, fmt.Errorf("bind: %s: //gopy:deepeq: only structs are compared field by field", obj.Name())
+		}
+		ptr := types.NewPointer(obj.Type())
+		p.syms.addType(nil, ptr)
+		typ.funcs.eq = Func{
+			pkg: p,
+			sig: newSignature(
+				p, recv,
+				[]*Var{newVar(p, ptr, "other", "other", "")},
+				[]*Var{newVar(p, types.Typ[types.Bool], "ret", "bool", "")},
+			),
+			typ:  nil,
+			name: "eq",
+			desc: desc + ".eq",
+			id:   sym.id + "_eq",
+			doc:  "",
+			ret:  types.Typ[types.Bool],
+			err:  false,
+		}
+	}
+
+	if obj.Pkg() == p.pkg {
+		for _, name := range directiveArgs(p.getTypeDoc(obj.Name()), "gopy:base") {
+			if !isPyClassPath(name) {
+				return typ, fmt.Errorf("bind: %s: //gopy:base %s: invalid name, expected module.Class", obj.Name(), name)
+			}
+			typ.bases = append(typ.bases, name)
+		}
+	}
+
 	return typ, nil
 }
 
+// formatDoc documents the __format__ method of the wrapped types.
+const formatDoc = `__format__(spec) -> str
+
+Formats the value with the go fmt verb spec, e.g. "v", "+v" or "08.3f".
+An empty spec formats the value with %v.`
+
+// selectTypes returns the chan types whose values can be passed to the
+// select function of the module.
+func (p *Package) selectTypes() []Type {
+	var typs []Type
+	for _, t := range p.types {
+		if t.sym.isChan() && isSelectable(t.GoType()) {
+			typs = append(typs, t)
+		}
+	}
+	return typs
+}
+
+// chanMethods returns the methods generated for the chan type t: Send and
+// Close for channels one can send to, Recv for channels one can receive
+// from, and Len and Cap.
+// Channels of empty structs carry signals, not values: Signal and Wait
+// stand for Send and Recv, Wait returning whether a signal was received,
+// or the channel closed, as python's threading.Event.wait.
+// Send, Signal, Recv and Wait release the GIL while they block. Recv and
+// Wait take an optional timeout, in seconds.
+// Methods declared on t take precedence over the generated ones.
+func (p *Package) chanMethods(tname string, t Type, ch *types.Chan) []Func {
+	declared := make(map[string]bool)
+	for _, m := range t.meths {
+		declared[m.GoName()] = true
+	}
+
+	recv := newVar(p, t.GoType(), "recv", t.obj.Name(), t.sym.doc)
+	elem := newVar(p, ch.Elem(), "v", "v", "")
+	errv := newVar(p, universe.sym("error").GoType(), "err", "err", "")
+	intv := newVar(p, universe.sym("int").GoType(), "ret", "int", "")
+	secs := newVar(p, universe.sym("float64").GoType(), "timeout", "timeout", "")
+	okv := newVar(p, universe.sym("bool").GoType(), "ret", "bool", "")
+
+	var meths []Func
+	add := func(name, doc string, params, results []*Var, ret types.Type, err bool) {
+		if declared[name] {
+			return
+		}
+		meths = append(meths, Func{
+			pkg:  p,
+			sig:  newSignature(p, recv, params, results),
+			typ:  nil,
+			name: name,
+			desc: p.ImportPath() + "." + tname + "." + name,
+			id:   t.sym.id + "_" + name,
+			doc:  doc,
+			ret:  ret,
+			err:  err,
+
+			blocking: name == "Send" || name == "Recv" || name == "Signal" || name == "Wait",
+			timeout:  name == "Recv" || name == "Wait",
+		})
+	}
+
+	signal := isSignalChan(ch)
+	switch {
+	case signal && ch.Dir() != types.RecvOnly:
+		add("Signal", "Signal sends a signal on the channel, raising an exception if it is closed.",
+			nil, []*Var{errv}, nil, true,
+		)
+	case ch.Dir() != types.RecvOnly:
+		add("Send", "Send sends v on the channel, raising an exception if it is closed.",
+			[]*Var{elem}, []*Var{errv}, nil, true,
+		)
+	}
+	switch {
+	case signal && ch.Dir() != types.SendOnly:
+		add("Wait", "Wait waits for a signal on the channel, returning True once one is received or the channel is closed, or False once the timeout, in seconds, expires. A zero timeout polls the channel; None waits forever.",
+			[]*Var{secs}, []*Var{okv}, okv.GoType(), false,
+		)
+	case ch.Dir() != types.SendOnly:
+		add("Recv", "Recv receives a value from the channel, raising an exception once it is closed and drained, or a TimeoutError once the timeout, in seconds, expires. A zero timeout polls the channel; None waits forever.",
+			[]*Var{secs}, []*Var{elem, errv}, ch.Elem(), true,
+		)
+	}
+	if ch.Dir() != types.RecvOnly {
+		add("Close", "Close closes the channel, raising an exception if it is already closed.",
+			nil, []*Var{errv}, nil, true,
+		)
+	}
+	add("Len", "Len returns the number of values buffered in the channel.",
+		nil, []*Var{intv}, intv.GoType(), false,
+	)
+	add("Cap", "Cap returns the capacity of the channel buffer.",
+		nil, []*Var{intv}, intv.GoType(), false,
+	)
+	return meths
+}
+
+// contextMethods returns the methods generated for context.Context:
+// WithCancel and WithTimeout derive a context which python can cancel with
+// Cancel, and Deadline returns the deadline of the context, in seconds
+// since the epoch, or None.
+func (p *Package) contextMethods(tname string, t Type) []Func {
+	recv := newVar(p, t.GoType(), "recv", t.obj.Name(), t.sym.doc)
+	ctxv := newVar(p, t.GoType(), "ret", t.obj.Name(), t.sym.doc)
+	secs := newVar(p, universe.sym("float64").GoType(), "seconds", "seconds", "")
+	errv := newVar(p, universe.sym("error").GoType(), "err", "err", "")
+	anyt := types.NewInterfaceType(nil, nil)
+	if p.syms.symtype(anyt) == nil {
+		p.syms.addType(nil, anyt)
+	}
+	anyv := newVar(p, anyt, "ret", "interface{}", "")
+
+	fct := func(name, doc string, params, results []*Var, ret types.Type, err bool) Func {
+		return Func{
+			pkg:  p,
+			sig:  newSignature(p, recv, params, results),
+			typ:  nil,
+			name: name,
+			desc: p.ImportPath() + "." + tname + "." + name,
+			id:   t.sym.id + "_" + name,
+			doc:  doc,
+			ret:  ret,
+			err:  err,
+		}
+	}
+	return []Func{
+		fct("WithCancel", "WithCancel returns a child of the context, canceled by its Cancel method.",
+			nil, []*Var{ctxv}, ctxv.GoType(), false,
+		),
+		fct("WithTimeout", "WithTimeout returns a child of the context, canceled after the given number of seconds or by its Cancel method.",
+			[]*Var{secs}, []*Var{ctxv}, ctxv.GoType(), false,
+		),
+		fct("Cancel", "Cancel cancels a context returned by WithCancel or WithTimeout, raising an exception for other contexts.",
+			nil, []*Var{errv}, nil, true,
+		),
+		fct("Deadline", "Deadline returns the time, in seconds since the epoch, when the context will be canceled, or None.",
+			nil, []*Var{anyv}, anyv.GoType(), false,
+		),
+	}
+}
+
+// bufferMethods returns the methods generated for bytes.Buffer, exchanging
+// python bytes as file objects do: write writes bytes, returning their
+// number, read reads at most n bytes, all of the unread ones for a negative
+// n, and string returns the unread bytes as a string.
+func (p *Package) bufferMethods(tname string, t Type) []Func {
+	recv := newVar(p, t.GoType(), "recv", t.obj.Name(), t.sym.doc)
+	byteSlice := types.NewSlice(types.Universe.Lookup("byte").Type())
+	bytev := newVar(p, byteSlice, "b", "b", "")
+	bytesv := newVar(p, byteSlice, "ret", "[]byte", "")
+	strv := newVar(p, universe.sym("string").GoType(), "ret", "string", "")
+	intv := newVar(p, universe.sym("int").GoType(), "ret", "int", "")
+	nv := newVar(p, universe.sym("int").GoType(), "n", "n", "")
+
+	fct := func(name, doc string, params, results []*Var, ret types.Type) Func {
+		return Func{
+			pkg:   p,
+			sig:   newSignature(p, recv, params, results),
+			typ:   nil,
+			name:  name,
+			desc:  p.ImportPath() + "." + tname + "." + name,
+			id:    t.sym.id + "_" + name,
+			doc:   doc,
+			ret:   ret,
+			bytes: name == "read",
+		}
+	}
+	return []Func{
+		fct("write", "write writes the bytes b to the buffer, returning their number.",
+			[]*Var{bytev}, []*Var{intv}, intv.GoType(),
+		),
+		fct("read", "read reads and returns at most n bytes from the buffer, all of the unread ones when n is negative.",
+			[]*Var{nv}, []*Var{bytesv}, bytesv.GoType(),
+		),
+		fct("string", "string returns the unread part of the buffer as a string.",
+			nil, []*Var{strv}, strv.GoType(),
+		),
+	}
+}
+
+// bytesBuffer returns the bytes.Buffer type name, imported afresh when the
+// package does not import bytes: modules expose it even when the package
+// does not use it, so python can create buffers. It returns nil if bytes
+// can not be imported.
+func (p *Package) bytesBuffer() *types.TypeName {
+	if p.buffer != nil || p.pkg.Path() == "bytes" {
+		return p.buffer
+	}
+	pkg := p.lookupImport("bytes")
+	if pkg == nil || pkg.Path() != "bytes" {
+		var err error
+		pkg, err = importer.ForCompiler(p.fset, "gc", nil).Import("bytes")
+		if err != nil {
+			return nil
+		}
+	}
+	p.buffer, _ = pkg.Scope().Lookup("Buffer").(*types.TypeName)
+	return p.buffer
+}
+
+// seqFuncs sets the funcs of the sequence protocol of the array or slice
+// type t: its length, the read and write accesses to its items and, for
+// slices, appending an item.
+// Struct, array and slice items are read by pointer, aliasing the storage
+// of t, so that p[1].X = 3 or m[1][2] = 5 modify t itself.
+func (p *Package) seqFuncs(tname string, t *Type) {
+	var elem types.Type
+	switch u := t.GoType().Underlying().(type) {
+	case *types.Array:
+		elem = u.Elem()
+	case *types.Slice:
+		elem = u.Elem()
+	}
+	item := elem
+	switch elem.Underlying().(type) {
+	case *types.Struct, *types.Array, *types.Slice:
+		if p.syms.conv(elem) != nil || p.syms.netType(elem) != "" {
+			// converted items, and IPs, are copied into python values.
+			break
+		}
+		item = types.NewPointer(elem)
+		if p.syms.symtype(item) == nil {
+			p.syms.addType(nil, item)
+		}
+	}
+
+	recv := newVar(p, t.GoType(), "recv", t.obj.Name(), t.sym.doc)
+	intt := universe.sym("int").GoType()
+	idx := newVar(p, intt, "i", "i", "")
+	val := newVar(p, elem, "v", "v", "")
+	lenv := newVar(p, intt, "ret", "int", "")
+	itemv := newVar(p, item, "ret", "ret", "")
+
+	fct := func(name string, params, results []*Var, ret types.Type) Func {
+		return Func{
+			pkg:  p,
+			sig:  newSignature(p, recv, params, results),
+			typ:  nil,
+			name: name,
+			desc: p.ImportPath() + "." + tname + "." + name,
+			id:   t.sym.id + "_sq_" + name,
+			doc:  "",
+			ret:  ret,
+			err:  false,
+		}
+	}
+
+	t.funcs.len = fct("len", nil, []*Var{lenv}, intt)
+	t.funcs.item = fct("item", []*Var{idx}, []*Var{itemv}, item)
+	t.funcs.setitem = fct("setitem", []*Var{idx, val}, nil, nil)
+	if t.sym.isSlice() {
+		t.funcs.append = fct("append", []*Var{val}, nil, nil)
+	}
+}
+
+// refFields checks the fields of the struct type t annotated with a
+// //gopy:ref directive, which must be structs, arrays or slices, and adds
+// the pointer types their getters return.
+func (p *Package) refFields(t Type) error {
+	typ := t.Struct()
+	for i := 0; i < typ.NumFields(); i++ {
+		f := typ.Field(i)
+		if !t.isExposedField(f) {
+			continue
+		}
+		if hasDocDirective(p.getFieldDoc(t.obj.Name(), f.Name()), "gopy:ref") && !isRefType(p, f.Type()) {
+			return fmt.Errorf(
+				"bind: %s.%s: //gopy:ref: %s fields are read as a copy",
+				t.obj.Name(), f.Name(), typeString(f.Type()),
+			)
+		}
+		if !t.isRefField(f) {
+			continue
+		}
+		ptr := types.NewPointer(f.Type())
+		if p.syms.symtype(ptr) == nil {
+			p.syms.addType(nil, ptr)
+		}
+	}
+	return nil
+}
+
+// isRefType returns whether fields of type typ may be read by reference:
+// structs, arrays and slices which are not converted, nor IPs.
+func isRefType(p *Package, typ types.Type) bool {
+	switch typ.Underlying().(type) {
+	case *types.Struct, *types.Array, *types.Slice:
+		return p.syms.conv(typ) == nil && p.syms.netType(typ) == ""
+	}
+	return false
+}
+
+// mapFuncs sets the funcs of the mapping protocol of the map type t.
+// Items are looked up in the comma-ok style, so that missing keys raise a
+// KeyError: they are deleted likewise.
+func (p *Package) mapFuncs(tname string, t *Type) {
+	m := t.GoType().Underlying().(*types.Map)
+
+	recv := newVar(p, t.GoType(), "recv", t.obj.Name(), t.sym.doc)
+	intt := universe.sym("int").GoType()
+	boolt := universe.sym("bool").GoType()
+	key := newVar(p, m.Key(), "k", "k", "")
+	val := newVar(p, m.Elem(), "v", "v", "")
+	lenv := newVar(p, intt, "ret", "int", "")
+	itemv := newVar(p, m.Elem(), "ret", "ret", "")
+	okv := newVar(p, boolt, "ok", "bool", "")
+
+	fct := func(name string, params, results []*Var, ret types.Type) Func {
+		return Func{
+			pkg:  p,
+			sig:  newSignature(p, recv, params, results),
+			typ:  nil,
+			name: name,
+			desc: p.ImportPath() + "." + tname + "." + name,
+			id:   t.sym.id + "_mp_" + name,
+			doc:  "",
+			ret:  ret,
+			err:  false,
+		}
+	}
+
+	t.funcs.len = fct("len", nil, []*Var{lenv}, intt)
+	t.funcs.item = fct("item", []*Var{key}, []*Var{itemv, okv}, m.Elem())
+	t.funcs.item.ok = true
+	t.funcs.setitem = fct("setitem", []*Var{key, val}, nil, nil)
+	t.funcs.delitem = fct("delitem", []*Var{key}, []*Var{okv}, boolt)
+
+	// the keys are sent sorted, after their number: python iterates over
+	// them, looking the items up one by one.
+	t.funcs.keys = fct("keys", nil, nil, nil)
+}
+
 func (t Type) Package() *Package {
 	return t.pkg
 }
 
+// isAnonymous returns whether the type wraps an anonymous struct, under a
+// generated name.
+func (t Type) isAnonymous() bool {
+	_, ok := t.obj.Type().(*types.Struct)
+	return ok
+}
+
+// isHeldByPointer returns whether the values of the type are held by
+// pointer on the go side: structs, arrays and slices.
+func (t Type) isHeldByPointer() bool {
+	switch t.GoType().Underlying().(type) {
+	case *types.Struct, *types.Array, *types.Slice:
+		return true
+	}
+	return false
+}
+
+// hasLock returns whether the values of the type hold a lock, such as a
+// sync.Mutex, or a C value: they are only handled by pointer, never copied.
+func (t Type) hasLock() bool {
+	return noCopyPath(t.GoType()) != ""
+}
+
+// itemLock returns the lock, or the C value, held by the items of the array
+// or slice type, such as sync.Mutex, or "" if they hold none: they cannot be
+// assigned.
+func (t Type) itemLock() string {
+	switch u := t.GoType().Underlying().(type) {
+	case *types.Array:
+		return noCopyPath(u.Elem())
+	case *types.Slice:
+		return noCopyPath(u.Elem())
+	}
+	return ""
+}
+
+// hasState returns whether values of the type can be rebuilt from python
+// by the _new_<Type> factory of the module: structs from a dict of their
+// field values, named basic types from their value, arrays and slices from
+// a sequence of their items and maps from a mapping.
+func (t Type) hasState() bool {
+	if !t.sym.isType() || t.isExternal() {
+		return false
+	}
+	switch t.GoType().Underlying().(type) {
+	case *types.Struct:
+		return !t.isAnonymous()
+	case *types.Basic:
+		return t.sym.isNamed()
+	case *types.Array, *types.Map:
+		return true
+	case *types.Slice:
+		return t.itemLock() == ""
+	}
+	return false
+}
+
+// bufferFunc returns the descriptor and the id of the func giving access to
+// the storage of byte arrays and slices, for the buffer protocol.
+func (t Type) bufferFunc() (desc, id string) {
+	return t.pkg.ImportPath() + "." + t.sym.goname + ".buffer", t.sym.id + "_buffer"
+}
+
+// isIterator returns whether the type wraps an iter.Seq or an iter.Seq2,
+// whose values python iterates over.
+func (t Type) isIterator() bool {
+	return iterSeqTypes(t.GoType()) != nil
+}
+
+// iterFunc returns the descriptor and the id of the func pulling the values
+// of iter.Seq and iter.Seq2 types: op is one of pull, next and stop.
+func (t Type) iterFunc(op string) (desc, id string) {
+	return t.pkg.ImportPath() + "." + t.sym.goname + "." + op, t.sym.id + "_" + op
+}
+
+// isAliased returns whether the type wraps an anonymous struct, an unnamed
+// func, array or slice or an instantiated generic type, under a generated
+// name.
+func (t Type) isAliased() bool {
+	return isAliased(t.obj.Type())
+}
+
+// isExternal returns whether the type is declared in another package
+// than the one being wrapped.
+func (t Type) isExternal() bool {
+	pkg := t.obj.Pkg()
+	return pkg != nil && pkg != t.pkg.pkg
+}
+
+// isOpaque returns whether the type is declared in an internal package:
+// its values are opaque handles, with no fields, whose methods are called
+// through reflection.
+func (t Type) isOpaque() bool {
+	return t.isExternal() && isOpaqueType(t.obj.Type())
+}
+
+// isRefField returns whether the getter of the struct field f of t returns
+// a reference aliasing the field, instead of a copy of its value: fields of
+// struct, array and slice types annotated with //gopy:ref, or all of them
+// when the struct type is.
+func (t Type) isRefField(f *types.Var) bool {
+	if t.isExternal() || !isRefType(t.pkg, f.Type()) {
+		return false
+	}
+	name := t.obj.Name()
+	return hasDocDirective(t.pkg.getTypeDoc(name), "gopy:ref") ||
+		hasDocDirective(t.pkg.getFieldDoc(name, f.Name()), "gopy:ref")
+}
+
+// fieldType returns the type returned by the getter of the struct field f
+// of t: a pointer to the field for references, the type of f otherwise.
+func (t Type) fieldType(f *types.Var) types.Type {
+	if t.isRefField(f) {
+		return types.NewPointer(f.Type())
+	}
+	return f.Type()
+}
+
+// isExposedField returns whether the struct field f of t is exposed to python.
+// Like their methods, the fields of types from other packages are only
+// exposed when their type is wrapped too.
+func (t Type) isExposedField(f *types.Var) bool {
+	if !t.pkg.syms.isWrappedField(f) || t.isOpaque() {
+		return false
+	}
+	return !t.isExternal() || isWrappable(f.Type(), t.pkg.wrapped)
+}
+
+// isContext returns whether the type wraps context.Context.
+func (t Type) isContext() bool {
+	return isContextType(t.obj.Type())
+}
+
+// isBytesBuffer returns whether the type wraps bytes.Buffer.
+func (t Type) isBytesBuffer() bool {
+	obj := t.obj
+	return obj.Pkg() != nil && obj.Pkg().Path() == "bytes" && obj.Name() == "Buffer"
+}
+
+// isSingleton returns whether the values of the type, an empty struct such
+// as struct{}, are all the same python object: being all equal, they share
+// one wrapper, created once. Types with python base classes are left out,
+// their instances having a dict of their own.
+func (t Type) isSingleton() bool {
+	st, ok := t.GoType().Underlying().(*types.Struct)
+	return ok && st.NumFields() == 0 && t.sym.isNamed() && !t.isExternal() && len(t.bases) == 0
+}
+
+// isStrEnum returns whether the type is a string type with consts, exposed
+// like an enum: its consts are members of the python type, and the python
+// values created from a string must hold the value of one of them.
+func (t Type) isStrEnum() bool {
+	b, ok := t.GoType().Underlying().(*types.Basic)
+	return ok && b.Info()&types.IsString != 0 && len(t.consts) > 0
+}
+
+// isDeepEq returns whether values of the type are compared field by field.
+func (t Type) isDeepEq() bool {
+	return t.funcs.eq.sig != nil
+}
+
+// isCallable returns whether values of the type can be called from python.
+func (t Type) isCallable() bool {
+	return t.funcs.call.sig != nil
+}
+
+// isWrappingCallables returns whether python callables can be made into
+// values of the type.
+func (t Type) isWrappingCallables() bool {
+	return t.funcs.wrap.sig != nil
+}
+
+// isWrappingFiles returns whether python file objects can be made into
+// values of the type, an io.Reader or an io.Writer.
+func (t Type) isWrappingFiles() bool {
+	return t.funcs.file.sig != nil
+}
+
+// callsBack returns whether go may call back into python: python callables
+// or file objects can be made into go values, which go may store, and call
+// later from any goroutine.
+func (p *Package) callsBack() bool {
+	for _, t := range p.types {
+		if t.isWrappingCallables() || t.isWrappingFiles() {
+			return true
+		}
+	}
+	return false
+}
+
+// isErrorsAsTarget returns whether errors_as finds the values of the type
+// in error chains.
+func (t Type) isErrorsAsTarget() bool {
+	return t.funcs.as.sig != nil
+}
+
+// callableType returns the func type python callables are made into, to
+// be passed as values of typ, if any.
+func (p *Package) callableType(typ types.Type) (Type, bool) {
+	if _, ok := typ.Underlying().(*types.Signature); !ok {
+		return Type{}, false
+	}
+	for _, t := range p.types {
+		if t.isWrappingCallables() && types.Identical(t.GoType(), typ) {
+			return t, true
+		}
+	}
+	return Type{}, false
+}
+
 func (t Type) ID() string {
 	return t.sym.id
 }
@@ -412,6 +2360,8 @@ type Signature struct {
 	ret  []*Var
 	args []*Var
 	recv *Var
+
+	variadic bool // true if the last parameter is a ...T
 }
 
 func newSignatureFrom(pkg *Package, sig *types.Signature) *Signature {
@@ -422,8 +2372,10 @@ func newSignatureFrom(pkg *Package, sig *types.Signature) *Signature {
 
 	return &Signature{
 		ret:  newVarsFrom(pkg, sig.Results()),
-		args: newVarsFrom(pkg, sig.Params()),
+		args: newParamsFrom(pkg, sig.Params()),
 		recv: recv,
+
+		variadic: sig.Variadic(),
 	}
 }
 
@@ -447,6 +2399,12 @@ func (sig *Signature) Recv() *Var {
 	return sig.recv
 }
 
+// Variadic reports whether the last parameter is a ...T parameter.
+// The type of that parameter is then []T.
+func (sig *Signature) Variadic() bool {
+	return sig.variadic
+}
+
 // Func collects informations about a go func/method.
 type Func struct {
 	pkg  *Package
@@ -459,43 +2417,104 @@ type Func struct {
 	doc  string
 	ret  types.Type // return type, if any
 	err  bool       // true if original go func has comma-error
+	ok   bool       // true if original go func has comma-ok
 	ctor bool       // true if this is a newXXX function
+
+	blocking bool // true if the GIL is released around the go call
+	async    bool // true if an _async variant, returning a future, is generated with -async
+	list     bool // true if the returned slice is copied into a python list
+	bytes    bool // true if the returned byte slice is copied into python bytes
+	buffer   bool // true if the returned byte array or slice is viewed through a python memoryview
+	okNone   bool // true if a false comma-ok returns None, instead of a (value, ok) tuple
+	warn     bool // true if a non-nil error is issued as a python warning, the results being returned
+	field    bool // true if this is the getter or setter of a struct field, held by reference
+	ref      bool // true if this is the getter of a struct field returning a reference aliasing it
+	tuple    bool // true if the results, but a trailing error, are returned as a python tuple
+	out      bool // true if the returned struct may be copied into the wrapper passed as the out argument
+	timeout  bool // true if the last parameter is an optional timeout, in seconds, None waiting forever
+
+	linkname string // linker symbol of an unexported method listed by //gopy:export, called through a //go:linkname
+	embed    string // selector of the embedded interface field the method is promoted through, checked for nil before the call
+	nilRecv  bool   // true if the receiver is an interface, checked for nil before the call
+}
+
+// hasOpaque returns whether a parameter or a result of f is of an opaque
+// type: the go call is made through reflection.
+func (f Func) hasOpaque() bool {
+	sig, ok := f.typ.(*types.Signature)
+	return ok && hasOpaqueType(sig)
 }
 
 func newFuncFrom(p *Package, parent string, obj types.Object, sig *types.Signature) (Func, error) {
 	haserr := false
+	hasok := false
 	res := sig.Results()
 	var ret types.Type
 
-	switch res.Len() {
-	case 2:
-		if !isErrorType(res.At(1).Type()) {
+	tuple := isTuple(res)
+
+	switch n := res.Len(); {
+	case tuple:
+		// ret is left nil: the results are read one by one, and a
+		// func returning a tuple is never a constructor.
+		haserr = isErrorType(res.At(n - 1).Type())
+
+	case n == 2:
+		switch {
+		case isErrorType(res.At(1).Type()):
+			haserr = true
+		case isOkType(res.At(1).Type()):
+			hasok = true
+		default:
 			return Func{}, fmt.Errorf(
-				"bind: second result value must be of type error: %s",
+				"bind: second result value must be of type error or bool: %s",
 				obj,
 			)
 		}
-		haserr = true
-		ret = res.At(0).Type()
+		ret = unalias(res.At(0).Type())
 
-	case 1:
+	case n == 1:
 		if isErrorType(res.At(0).Type()) {
 			haserr = true
 			ret = nil
 		} else {
-			ret = res.At(0).Type()
+			ret = unalias(res.At(0).Type())
 		}
-	case 0:
+	case n == 0:
 		ret = nil
 	default:
 		return Func{}, fmt.Errorf("bind: too many results to return: %v", obj)
 	}
 
-	desc := obj.Pkg().Path() + "." + obj.Name()
-	id := obj.Pkg().Name() + "_" + obj.Name()
+	decl := p.getFuncDecl(parent, obj)
+	list := false
+	buffer := false
+	if ret != nil {
+		_, list = ret.Underlying().(*types.Slice)
+		list = list && hasDirective(decl, "gopy:list")
+		buffer = !list && isByteSeqType(ret) && hasDirective(decl, "gopy:buffer")
+	}
+
+	out := hasDirective(decl, "gopy:out")
+	if out {
+		if err := checkOut(p, obj, sig, ret, hasok || tuple); err != nil {
+			return Func{}, err
+		}
+	}
+
+	// funcs taking a context may block until it is canceled, which
+	// python can only do with the GIL released.
+	// async funcs run on their own thread, next to the caller's.
+	// funcs taking callbacks may call them from other goroutines, which
+	// take the GIL, and from them call back into go.
+	blocking := hasDirective(decl, "gopy:blocking") || hasDirective(decl, "gopy:async") ||
+		hasContextParam(sig) || hasCallbackParam(sig)
+
+	desc := p.ImportPath() + "." + obj.Name()
+	id := p.Name() + "_" + obj.Name()
 	if parent != "" {
-		id = obj.Pkg().Name() + "_" + parent + "_" + obj.Name()
-		desc = obj.Pkg().Path() + "." + parent + "." + obj.Name()
+		id = p.Name() + "_" + parent + "_" + obj.Name()
+		desc = p.ImportPath() + "." + parent + "." + obj.Name()
 	}
 
 	return Func{
@@ -508,9 +2527,47 @@ func newFuncFrom(p *Package, parent string, obj types.Object, sig *types.Signatu
 		doc:  p.getDoc(parent, obj),
 		ret:  ret,
 		err:  haserr,
+		ok:   hasok,
+
+		blocking: blocking,
+		async:    hasDirective(decl, "gopy:async"),
+		list:     list,
+		buffer:   buffer,
+		okNone:   hasok && hasDirective(decl, "gopy:ok"),
+		warn:     haserr && hasDirective(decl, "gopy:warn-on-error"),
+		tuple:    tuple,
+		out:      out,
 	}, nil
 }
 
+// checkOut returns an error if the func obj, annotated with //gopy:out,
+// does not return a wrapped struct by value, and a trailing error at most,
+// or does not take all its arguments by keyword too, as the out argument.
+func checkOut(p *Package, obj types.Object, sig *types.Signature, ret types.Type, multi bool) error {
+	errorf := func(format string, args ...interface{}) error {
+		return fmt.Errorf("bind: %s: //gopy:out: "+format, append([]interface{}{obj.Name()}, args...)...)
+	}
+	if multi || ret == nil || p.syms.symtype(ret) == nil || p.syms.conv(ret) != nil {
+		return errorf("must return a wrapped struct by value")
+	}
+	if _, ok := ret.Underlying().(*types.Struct); !ok {
+		return errorf("must return a wrapped struct by value")
+	}
+	if sig.Variadic() {
+		return errorf("variadic funcs take no keyword arguments")
+	}
+	params := sig.Params()
+	for i := 0; i < params.Len(); i++ {
+		switch name := params.At(i).Name(); {
+		case name == "" || name == "_":
+			return errorf("blank parameters are positional only")
+		case pyArgName(name) == "out":
+			return errorf("parameter %s is named as the out argument", name)
+		}
+	}
+	return nil
+}
+
 func (f Func) Package() *Package {
 	return f.pkg
 }
@@ -543,6 +2600,13 @@ func (f Func) Return() types.Type {
 	return f.ret
 }
 
+// strList returns whether the value of type typ, a parameter or result of
+// f, is exchanged with python as a list of str. Struct fields keep their
+// wrapper, which refers to the field.
+func (f Func) strList(typ types.Type) bool {
+	return isStringSlice(typ) && !f.field
+}
+
 type Const struct {
 	pkg *Package
 	sym *symbol
@@ -0,0 +1,299 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bind
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExportedMethods(t *testing.T) {
+	const src = `package p
+
+// B is embedded.
+type B struct{}
+
+func (B) base() {}
+
+// T is a struct.
+//
+//gopy:export %s
+type T struct{ B }
+
+func (t *T) Get() int  { return 0 }
+func (t *T) get() int  { return 0 }
+func (t T) peek() int  { return 0 }
+func (t *T) hidden()   {}
+
+// I is an interface.
+//
+//gopy:export %s
+type I interface{ m() }
+`
+	for _, tc := range []struct {
+		t, i  string
+		meths []string
+		err   string
+	}{
+		{t: "get peek", i: "", meths: []string{"Get", "get", "peek"}},
+		{t: "get", i: "", meths: []string{"Get", "get"}},
+		{t: "Get", i: "", err: "bind: T: //gopy:export Get: method is already exported"},
+		{t: "put", i: "", err: "bind: T: //gopy:export put: no such method"},
+		{t: "base", i: "", err: "bind: T: //gopy:export base: method is promoted from an embedded field"},
+		{t: "get", i: "m", err: "bind: I: //gopy:export: the methods of interfaces can not be exported"},
+	} {
+		p, err := newTestPackage(t, fmt.Sprintf(src, tc.t, tc.i))
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%q: unexpected error: %v", tc.t, err)
+			continue
+		case tc.err != "":
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("%q: got error %v, want %q", tc.t, err, tc.err)
+			}
+			continue
+		}
+		var got []string
+		for _, typ := range p.types {
+			if typ.obj.Name() != "T" {
+				continue
+			}
+			for _, m := range typ.meths {
+				got = append(got, m.GoName())
+				if want := m.GoName() != "Get"; (m.linkname != "") != want {
+					t.Errorf("%q: %s: linkname %q", tc.t, m.GoName(), m.linkname)
+				}
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.meths) {
+			t.Errorf("%q: got methods %v, want %v", tc.t, got, tc.meths)
+		}
+	}
+}
+
+func TestOutFuncs(t *testing.T) {
+	const src = `package p
+
+// V is a struct.
+type V struct{ X int }
+
+//gopy:out
+func %s
+`
+	for _, tc := range []struct {
+		fct string
+		err string
+	}{
+		{fct: "F(a V) V { return a }"},
+		{fct: "F(a V) (V, error) { return a, nil }"},
+		{fct: "F(a V) *V { return &a }", err: "bind: F: //gopy:out: must return a wrapped struct by value"},
+		{fct: "F(a V) (V, bool) { return a, true }", err: "bind: F: //gopy:out: must return a wrapped struct by value"},
+		{fct: "F(a V) int { return 0 }", err: "bind: F: //gopy:out: must return a wrapped struct by value"},
+		{fct: "F(a ...V) V { return V{} }", err: "bind: F: //gopy:out: variadic funcs take no keyword arguments"},
+		{fct: "F(_ V) V { return V{} }", err: "bind: F: //gopy:out: blank parameters are positional only"},
+		{fct: "F(out V) V { return out }", err: "bind: F: //gopy:out: parameter out is named as the out argument"},
+	} {
+		p, err := newTestPackage(t, fmt.Sprintf(src, tc.fct))
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%q: unexpected error: %v", tc.fct, err)
+		case tc.err != "" && (err == nil || err.Error() != tc.err):
+			t.Errorf("%q: got error %v, want %q", tc.fct, err, tc.err)
+		case tc.err == "":
+			f := testType(t, p, "V").ctors[0]
+			if !f.out || !hasKwargs(f) {
+				t.Errorf("%q: out=%v, kwargs=%v", tc.fct, f.out, hasKwargs(f))
+			}
+		}
+	}
+}
+
+func TestNotCtor(t *testing.T) {
+	p, err := newTestPackage(t, `package p
+
+// V is a struct.
+type V struct{ X int }
+
+// New returns a new V.
+func New() V { return V{} }
+
+// Unit returns the unit V.
+//
+//gopy:not-ctor
+func Unit() V { return V{X: 1} }
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ctors, funcs []string
+	for _, f := range testType(t, p, "V").ctors {
+		ctors = append(ctors, f.GoName())
+	}
+	for _, f := range p.funcs {
+		funcs = append(funcs, f.GoName())
+	}
+	if !reflect.DeepEqual(ctors, []string{"New"}) || !reflect.DeepEqual(funcs, []string{"Unit"}) {
+		t.Errorf("got ctors=%v funcs=%v, want ctors=[New] funcs=[Unit]", ctors, funcs)
+	}
+}
+
+// testType returns the type of p named name.
+func testType(t *testing.T, p *Package, name string) Type {
+	t.Helper()
+	for _, typ := range p.types {
+		if typ.obj.Name() == name && typ.obj.Pkg() == p.pkg {
+			return typ
+		}
+	}
+	t.Fatalf("no type %s", name)
+	return Type{}
+}
+
+func TestBytesBuffer(t *testing.T) {
+	p, err := newTestPackage(t, `package p
+
+// F does nothing.
+func F() {}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, typ := range p.types {
+		if !typ.isBytesBuffer() {
+			continue
+		}
+		if typ.prots&ProtoSized == 0 {
+			t.Errorf("bytes.Buffer is not sized")
+		}
+		for _, m := range typ.meths {
+			if m.typ == nil {
+				got = append(got, m.GoName())
+			}
+		}
+	}
+	if want := []string{"write", "read", "string"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got generated bytes.Buffer methods %v, want %v", got, want)
+	}
+}
+
+func TestExternalTypes(t *testing.T) {
+	p, err := newTestPackage(t, `package p
+
+import (
+	htmltemplate "html/template"
+	"net/http"
+	"text/template"
+)
+
+// S holds types of other packages.
+type S struct {
+	C *http.Client
+	H http.Header
+}
+
+// Set sets the header of s.
+func (s *S) Set(h http.Header) { s.H = h }
+
+// Client returns a new client.
+func Client() *http.Client { return new(http.Client) }
+
+// Header returns a new header.
+func Header() http.Header { return make(http.Header) }
+
+// Text returns a text template.
+func Text() *template.Template { return template.New("text") }
+
+// HTML returns an html template.
+func HTML() *htmltemplate.Template { return htmltemplate.New("html") }
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, err := range p.Diagnostics() {
+		d := err.(*Diagnostic)
+		if !d.Pos.IsValid() {
+			t.Errorf("%s: no position", d.Name)
+		}
+		if strings.HasPrefix(d.Name, "p.") {
+			got = append(got, d.Name+": "+d.Err.Error())
+		}
+	}
+	want := []string{
+		"p.Header: result #0: unsupported type []string",
+		"p.S.H: unsupported type []string",
+		"p.S.Set: parameter h: unsupported type []string",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diagnostics:\ngot= %q\nwant=%q", got, want)
+	}
+	var funcs []string
+	for _, f := range p.funcs {
+		funcs = append(funcs, f.GoName())
+	}
+	if want := []string{"Client", "HTML", "Text"}; !reflect.DeepEqual(funcs, want) {
+		t.Errorf("got funcs=%v, want %v", funcs, want)
+	}
+	text := p.syms.symtype(p.funcs[2].Signature().Results()[0].GoType())
+	html := p.syms.symtype(p.funcs[1].Signature().Results()[0].GoType())
+	if text.id == html.id {
+		t.Errorf("text and html templates share the id %q", text.id)
+	}
+}
+
+func TestAccessorClashes(t *testing.T) {
+	p, err := newTestPackage(t, `package p
+
+// Level is set through SetLevel.
+var Level int
+
+// SetLevel sets Level, clamped.
+func SetLevel(l int) int { Level = l; return l }
+
+// URL is read through GetUrl under snake naming.
+var URL string
+
+// GetUrl returns URL.
+func GetUrl() string { return URL }
+
+// Max is a const.
+const Max = 3
+
+// GetMax is a type.
+type GetMax struct{}
+
+// Name has its accessors.
+var Name string
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, n := range p.Notes() {
+		if !n.Pos.IsValid() {
+			t.Errorf("%s: no position", n.Name)
+		}
+		got = append(got, n.Name+": "+n.Msg)
+	}
+	want := []string{
+		"p.Max: module attribute only (GetMax clashes with p.GetMax)",
+		"p.Level: module attribute only (SetLevel clashes with p.SetLevel)",
+		"p.URL: module attribute only (GetURL clashes with p.GetUrl)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("notes:\ngot= %q\nwant=%q", got, want)
+	}
+	for acc, want := range map[string]bool{
+		"GetMax": true, "GetLevel": false, "SetLevel": true,
+		"GetURL": true, "SetURL": false, "GetName": false, "SetName": false,
+	} {
+		if got := p.shadowed[acc]; got != want {
+			t.Errorf("shadowed[%s]=%v, want %v", acc, got, want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bind
+
+import (
+	"go/token"
+	"go/types"
+)
+
+// Report lists the exported entities of the package, in the order of their
+// names, each type followed by its methods and fields, and reports whether
+// they are bound.
+// The reasons of the skipped entities are the errors of their diagnostics.
+func (p *Package) Report() []Symbol {
+	diags := make(map[string]*Diagnostic)
+	for _, err := range p.diags {
+		if d, ok := err.(*Diagnostic); ok {
+			diags[d.Name] = d
+		}
+	}
+	notes := make(map[string]string)
+	for _, n := range p.notes {
+		notes[n.Name] = n.Msg
+	}
+
+	// how the funcs and types of the package are bound.
+	bound := make(map[string]string)
+	for _, f := range p.funcs {
+		bound[f.GoName()] = ""
+	}
+	for _, c := range p.consts {
+		bound[c.GoName()] = ""
+	}
+	for _, v := range p.vars {
+		bound[v.Name()] = ""
+	}
+	for _, a := range p.aliases {
+		bound[a.obj.Name()] = "alias of " + a.typ.sym.gofmt()
+	}
+	typs := make(map[string]Type)
+	for _, t := range p.types {
+		if t.isExternal() || t.obj.Pkg() != p.pkg || t.obj.Parent() != p.pkg.Scope() {
+			continue
+		}
+		typs[t.obj.Name()] = t
+		bound[t.obj.Name()] = ""
+		for _, f := range t.ctors {
+			bound[f.GoName()] = "constructor of " + t.obj.Name()
+		}
+		for _, f := range t.statics {
+			bound[f.GoName()] = "static method of " + t.obj.Name()
+		}
+	}
+
+	var syms []Symbol
+	add := func(kind string, obj types.Object, name string, ok bool, reason string) {
+		var pos token.Position
+		if p.fset != nil && obj.Pos().IsValid() {
+			pos = p.fset.Position(obj.Pos())
+		}
+		switch {
+		case ok && reason == "":
+			reason = notes[name]
+		case !ok && diags[name] != nil:
+			reason = diags[name].Err.Error()
+		case !ok && reason == "":
+			reason = "not bound"
+		}
+		syms = append(syms, Symbol{
+			Pos:    pos,
+			Kind:   kind,
+			Name:   name,
+			Bound:  ok,
+			Reason: reason,
+		})
+	}
+
+	scope := p.pkg.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if !obj.Exported() {
+			continue
+		}
+		qname := p.Name() + "." + name
+		reason, ok := bound[name]
+		tn, isType := obj.(*types.TypeName)
+		if isType && isGeneric(tn.Type()) {
+			add("type", obj, qname, false, "generic type, only bound through its instantiations")
+			continue
+		}
+		add(objectKind(obj), obj, qname, ok, reason)
+
+		t, ok := typs[name]
+		if !ok {
+			continue
+		}
+		p.reportMembers(t, add)
+	}
+	return syms
+}
+
+// reportMembers adds the exported methods and fields of the wrapped type t
+// to a report.
+func (p *Package) reportMembers(t Type, add func(kind string, obj types.Object, name string, ok bool, reason string)) {
+	qname := p.Name() + "." + t.obj.Name()
+	meths := make(map[string]bool)
+	for _, m := range t.meths {
+		meths[m.GoName()] = true
+	}
+	var mset *types.MethodSet
+	if types.IsInterface(t.GoType()) {
+		mset = types.NewMethodSet(t.GoType())
+	} else {
+		mset = types.NewMethodSet(types.NewPointer(t.GoType()))
+	}
+	for i := 0; i < mset.Len(); i++ {
+		obj := mset.At(i).Obj()
+		if !obj.Exported() && !meths[obj.Name()] {
+			// unexported methods are only reported once bound, when
+			// listed by a //gopy:export directive.
+			continue
+		}
+		add("method", obj, qname+"."+obj.Name(), meths[obj.Name()], "")
+	}
+	for _, c := range selectorConflicts(t.GoType()) {
+		if c.ambiguous {
+			// ambiguous selectors are not in the method set.
+			add("method", t.obj, qname+"."+c.name, false, "")
+		}
+	}
+
+	st, ok := t.GoType().Underlying().(*types.Struct)
+	if !ok {
+		return
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		if !f.Exported() {
+			continue
+		}
+		add("field", f, qname+"."+f.Name(), t.isExposedField(f), "")
+	}
+}
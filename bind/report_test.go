@@ -0,0 +1,93 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bind
+
+import (
+	"go/ast"
+	"go/doc"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"reflect"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	const src = `package p
+
+import "sync"
+
+// T is a struct.
+type T struct {
+	N  int
+	F  chan<- int
+	mu sync.Mutex
+}
+
+// NewT returns a T.
+func NewT() T { return T{} }
+
+func (t *T) Get() int       { return t.N }
+func (t *T) Ch() chan<- int { return nil }
+
+// G is generic.
+type G[E any] struct{ E E }
+
+const C = 1
+
+var V = []func(){}
+
+func F(c chan<- int) {}
+
+func unexported() {}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dpkg, err := doc.NewFromFiles(fset, []*ast.File{f}, "p", doc.PreserveAST)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewPackage(fset, pkg, dpkg, NetTypesText)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type entry struct {
+		kind, name string
+		bound      bool
+		reason     string
+	}
+	var got []entry
+	for _, sym := range p.Report() {
+		if !sym.Pos.IsValid() || sym.Pos.Filename != "p.go" {
+			t.Errorf("%s: invalid position %v", sym.Name, sym.Pos)
+		}
+		got = append(got, entry{sym.Kind, sym.Name, sym.Bound, sym.Reason})
+	}
+	want := []entry{
+		{"constant", "p.C", true, ""},
+		{"function", "p.F", false, "parameter c: unsupported type chan<- int"},
+		{"type", "p.G", false, "generic type, only bound through its instantiations"},
+		{"function", "p.NewT", true, "constructor of T"},
+		{"type", "p.T", true, "holds a sync.Mutex: wrapped by pointer only, with no value copies"},
+		{"method", "p.T.Ch", false, "result #0: unsupported type chan<- int"},
+		{"method", "p.T.Get", true, ""},
+		{"field", "p.T.N", true, ""},
+		{"field", "p.T.F", false, "unsupported type chan<- int"},
+		{"variable", "p.V", false, "unsupported type func()"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("invalid report:\ngot:  %v\nwant: %v", got, want)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// WriteBigInt writes the decimal text of x, or an empty text for a nil x.
+func (b *Buffer) WriteBigInt(x *big.Int) {
+	if x == nil {
+		b.WriteByteArray(nil)
+		return
+	}
+	b.WriteByteArray([]byte(x.String()))
+}
+
+// ReadBigInt reads the decimal text of an integer written by the other
+// side, and returns it, or nil for an empty text.
+func (b *Buffer) ReadBigInt() *big.Int {
+	s := string(b.ReadByteArray())
+	if s == "" {
+		return nil
+	}
+	x, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic(fmt.Sprintf("seq: invalid integer %q", s))
+	}
+	return x
+}
+
+// WriteBigFloat writes whether x is exactly a float64, followed by the
+// shortest decimal text of x at its precision, or an empty text for a nil x.
+func (b *Buffer) WriteBigFloat(x *big.Float) {
+	if x == nil {
+		b.WriteBool(false)
+		b.WriteByteArray(nil)
+		return
+	}
+	_, acc := x.Float64()
+	b.WriteBool(acc == big.Exact)
+	b.WriteByteArray([]byte(x.Text('g', -1)))
+}
+
+// ReadBigFloat reads the precision and the decimal text of a float written
+// by the other side, and returns it, or nil for an empty text.
+// A zero precision is large enough to hold all the digits of the text.
+func (b *Buffer) ReadBigFloat() *big.Float {
+	prec := uint(b.ReadInt64())
+	s := string(b.ReadByteArray())
+	if s == "" {
+		return nil
+	}
+	// python decimals spell infinities out.
+	s = strings.Replace(s, "Infinity", "Inf", 1)
+	if prec == 0 {
+		// a decimal digit takes less than 4 bits.
+		prec = 4 * uint(len(s))
+		if prec < 64 {
+			prec = 64
+		}
+	}
+	x, _, err := big.ParseFloat(s, 10, prec, big.ToNearestEven)
+	if err != nil {
+		panic(fmt.Sprintf("seq: invalid float %q: %v", s, err))
+	}
+	return x
+}
@@ -0,0 +1,79 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestBigInt(t *testing.T) {
+	huge, _ := new(big.Int).SetString("-"+strings.Repeat("1234567890", 20), 10)
+	for _, x := range []*big.Int{nil, big.NewInt(0), big.NewInt(42), huge} {
+		buf := new(Buffer)
+		buf.WriteBigInt(x)
+		buf.Offset = 0
+		got := buf.ReadBigInt()
+		switch {
+		case x == nil:
+			if got != nil {
+				t.Errorf("ReadBigInt()=%v, want nil", got)
+			}
+		case got == nil || got.Cmp(x) != 0:
+			t.Errorf("ReadBigInt()=%v, want %v", got, x)
+		}
+	}
+}
+
+func TestBigFloat(t *testing.T) {
+	for _, test := range []struct {
+		x     *big.Float
+		exact bool
+		text  string
+	}{
+		{nil, false, ""},
+		{big.NewFloat(1.5), true, "1.5"},
+		{new(big.Float).SetPrec(200).Quo(big.NewFloat(1), big.NewFloat(3)), false,
+			"0.3333333333333333333333333333333333333333333333333333333333334"},
+	} {
+		buf := new(Buffer)
+		buf.WriteBigFloat(test.x)
+		buf.Offset = 0
+		if exact := buf.ReadBool(); exact != test.exact {
+			t.Errorf("WriteBigFloat(%v) wrote exact=%v, want %v", test.x, exact, test.exact)
+		}
+		if text := string(buf.ReadByteArray()); text != test.text {
+			t.Errorf("WriteBigFloat(%v) wrote %q, want %q", test.x, text, test.text)
+		}
+	}
+
+	for _, test := range []struct {
+		prec int64
+		text string
+		want string
+	}{
+		{0, "", ""},
+		{53, "0.1", "0.1"},
+		{0, "3.14159265358979323846264338327950288", "3.14159265358979323846264338327950288"},
+		{0, "-Infinity", "-Inf"},
+		{53, "inf", "+Inf"},
+	} {
+		buf := new(Buffer)
+		buf.WriteInt64(test.prec)
+		buf.WriteByteArray([]byte(test.text))
+		buf.Offset = 0
+		got := buf.ReadBigFloat()
+		if got == nil {
+			if test.want != "" {
+				t.Errorf("ReadBigFloat(%q)=nil, want %s", test.text, test.want)
+			}
+			continue
+		}
+		if text := got.Text('g', -1); text != test.want {
+			t.Errorf("ReadBigFloat(%q)=%s, want %s", test.text, text, test.want)
+		}
+	}
+}
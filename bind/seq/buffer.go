@@ -7,6 +7,7 @@ package seq
 import (
 	"bytes"
 	"fmt"
+	"reflect"
 	"runtime"
 	"unsafe"
 )
@@ -325,24 +326,36 @@ func (b *Buffer) WriteString(v string) {
 	EncString(b, v)
 }
 
+// WriteGoRef pins obj and writes its reference number.
+// nil pointers, nil interfaces and pointers to nil funcs are written as the
+// reference number 0.
 func (b *Buffer) WriteGoRef(obj interface{}) {
-	refs.Lock()
-	num := refs.refs[obj]
-	if num != 0 {
-		s := refs.objs[num]
-		refs.objs[num] = countedObj{s.obj, s.cnt + 1}
-	} else {
-		num = refs.next
-		refs.next--
-		if refs.next > 0 {
-			panic("refs.next underflow")
-		}
-		refs.refs[obj] = num
-		refs.objs[num] = countedObj{obj, 1}
+	if isNil(obj) {
+		b.WriteInt32(0)
+		return
 	}
+	refs.Lock()
+	num := refs.table.pin(obj)
 	refs.Unlock()
 
-	b.WriteInt32(int32(num))
+	b.WriteInt32(num)
+}
+
+// isNil returns whether obj is nil, as a nil interface value, or holds a
+// nil pointer, or a pointer to a nil func: funcs are held by pointer.
+func isNil(obj interface{}) bool {
+	if obj == nil {
+		return true
+	}
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr {
+		return false
+	}
+	if v.IsNil() {
+		return true
+	}
+	v = v.Elem()
+	return v.Kind() == reflect.Func && v.IsNil()
 }
 
 /*  TODO: Will we need it?
@@ -0,0 +1,41 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq
+
+import (
+	"fmt"
+	"os"
+)
+
+// WriteFile writes the descriptor of a duplicate of f, or -1 for a nil f.
+// The reader owns the duplicate, and must close it: f itself is left open.
+func (b *Buffer) WriteFile(f *os.File) {
+	if f == nil {
+		b.WriteInt64(-1)
+		return
+	}
+	fd, err := dup(int(f.Fd()))
+	if err != nil {
+		panic(fmt.Sprintf("seq: could not duplicate %s: %v", f.Name(), err))
+	}
+	b.WriteInt64(int64(fd))
+}
+
+// ReadFile reads a file descriptor written by the other side, and returns
+// a file for a duplicate of it, or nil for a negative descriptor.
+// The returned file owns the duplicate: closing it, explicitly or when it
+// is garbage collected, leaves the descriptor written by the other side
+// open.
+func (b *Buffer) ReadFile() *os.File {
+	fd := b.ReadInt64()
+	if fd < 0 {
+		return nil
+	}
+	dfd, err := dup(int(fd))
+	if err != nil {
+		panic(fmt.Sprintf("seq: could not duplicate file descriptor %d: %v", fd, err))
+	}
+	return os.NewFile(uintptr(dfd), fmt.Sprintf("fd%d", fd))
+}
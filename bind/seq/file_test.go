@@ -0,0 +1,75 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !windows
+// +build !windows
+
+package seq
+
+import (
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestFile(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	buf := new(Buffer)
+	buf.WriteFile(w)
+	buf.WriteFile(nil)
+	buf.WriteInt64(int64(w.Fd()))
+	buf.WriteInt64(-1)
+	buf.Offset = 0
+
+	// the written descriptor is a duplicate, owned by the reader.
+	fd := int(buf.ReadInt64())
+	if fd < 0 || fd == int(w.Fd()) {
+		t.Fatalf("WriteFile(w) wrote %d, want a duplicate of %d", fd, w.Fd())
+	}
+	if _, err := syscall.Write(fd, []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	syscall.Close(fd)
+	if got := buf.ReadInt64(); got != -1 {
+		t.Fatalf("WriteFile(nil) wrote %d, want -1", got)
+	}
+
+	// the read file is a duplicate, owned by the go side.
+	f := buf.ReadFile()
+	if f == nil {
+		t.Fatalf("ReadFile()=nil, want a file")
+	}
+	if f.Fd() == w.Fd() {
+		t.Fatalf("ReadFile() shares the descriptor %d of the written file", f.Fd())
+	}
+	if _, err := f.WriteString(" world"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.ReadFile(); got != nil {
+		t.Fatalf("ReadFile()=%v, want nil", got)
+	}
+
+	// the written file is still open.
+	if _, err := w.WriteString("!"); err != nil {
+		t.Fatalf("write after closing the read file: %v", err)
+	}
+	w.Close()
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "hello world!"; got != want {
+		t.Fatalf("read %q, want %q", got, want)
+	}
+}
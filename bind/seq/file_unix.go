@@ -0,0 +1,14 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !windows
+// +build !windows
+
+package seq
+
+import "syscall"
+
+func dup(fd int) (int, error) {
+	return syscall.Dup(fd)
+}
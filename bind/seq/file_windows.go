@@ -0,0 +1,13 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq
+
+import "errors"
+
+// dup fails on windows: python exchanges C runtime descriptors, not the
+// handles os.NewFile expects.
+func dup(fd int) (int, error) {
+	return -1, errors.New("*os.File values are not supported on windows")
+}
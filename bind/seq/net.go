@@ -0,0 +1,61 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+)
+
+// WriteIP writes whether ip is not nil, followed by its text.
+func (b *Buffer) WriteIP(ip net.IP) {
+	b.WriteBool(ip != nil)
+	if ip == nil {
+		b.WriteByteArray(nil)
+		return
+	}
+	b.WriteByteArray([]byte(ip.String()))
+}
+
+// ReadIP reads an IP written by the other side, and returns it, or nil.
+// The other side checked its text with net.ParseIP.
+func (b *Buffer) ReadIP() net.IP {
+	ok := b.ReadBool()
+	s := string(b.ReadByteArray())
+	if !ok {
+		return nil
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		panic(fmt.Sprintf("seq: invalid IP address %q", s))
+	}
+	return ip
+}
+
+// WriteURL writes whether u is not nil, followed by its text.
+func (b *Buffer) WriteURL(u *url.URL) {
+	b.WriteBool(u != nil)
+	if u == nil {
+		b.WriteByteArray(nil)
+		return
+	}
+	b.WriteByteArray([]byte(u.String()))
+}
+
+// ReadURL reads a URL written by the other side, and returns it, or nil.
+// The other side checked its text with url.Parse.
+func (b *Buffer) ReadURL() *url.URL {
+	ok := b.ReadBool()
+	s := string(b.ReadByteArray())
+	if !ok {
+		return nil
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		panic(fmt.Sprintf("seq: %v", err))
+	}
+	return u
+}
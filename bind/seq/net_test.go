@@ -0,0 +1,49 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq
+
+import (
+	"net"
+	"net/url"
+	"testing"
+)
+
+func TestIP(t *testing.T) {
+	for _, ip := range []net.IP{nil, net.ParseIP("10.0.0.1"), net.ParseIP("2001:db8::1")} {
+		buf := new(Buffer)
+		buf.WriteIP(ip)
+		buf.Offset = 0
+		got := buf.ReadIP()
+		switch {
+		case ip == nil:
+			if got != nil {
+				t.Errorf("ReadIP()=%v, want nil", got)
+			}
+		case !got.Equal(ip):
+			t.Errorf("ReadIP()=%v, want %v", got, ip)
+		}
+	}
+}
+
+func TestURL(t *testing.T) {
+	u, err := url.Parse("https://example.com/a%20b?q=1&r=x+y#top")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range []*url.URL{nil, {}, u} {
+		buf := new(Buffer)
+		buf.WriteURL(u)
+		buf.Offset = 0
+		got := buf.ReadURL()
+		switch {
+		case u == nil:
+			if got != nil {
+				t.Errorf("ReadURL()=%v, want nil", got)
+			}
+		case got == nil || got.String() != u.String():
+			t.Errorf("ReadURL()=%v, want %v", got, u)
+		}
+	}
+}
@@ -19,52 +19,143 @@ type countedObj struct {
 	cnt int32
 }
 
+// firstRef is the reference number of the first Go object pinned. Go
+// objects get negative reference numbers. Arbitrary starting point.
+const firstRef = -24
+
+// refTable pins the Go objects passed to another language under their
+// reference numbers, counting the references to each of them. An object
+// pinned several times keeps its reference number: python compares the
+// wrappers of Go objects by reference number.
+type refTable interface {
+	// pin increments the count of obj, pinning it if needed, and returns
+	// its reference number.
+	pin(obj interface{}) int32
+	// get returns the object of reference number num, if pinned.
+	get(num int32) (interface{}, bool)
+	// release decrements the count of the object of reference number
+	// num, unpinning it at zero. It reports whether num was pinned.
+	release(num int32) bool
+	// len returns the number of pinned objects.
+	len() int
+}
+
 // refs stores Go objects that have been passed to another language.
+// refs is shared by all the interpreters loading the bindings in a process:
+// reference numbers are process-wide and every access goes through the mutex.
+// The table is a slabTable when built with the gopy_slab build tag, and a
+// mapTable otherwise.
 var refs struct {
 	sync.Mutex
-	next int32 // next reference number to use for Go object, always negative
-	refs map[interface{}]int32
-	objs map[int32]countedObj
+	table refTable
 }
 
 func init() {
 	refs.Lock()
-	refs.next = -24 // Go objects get negative reference numbers. Arbitrary starting point.
-	refs.refs = make(map[interface{}]int32)
-	refs.objs = make(map[int32]countedObj)
+	if slabRefs {
+		refs.table = newSlabTable()
+	} else {
+		refs.table = newMapTable()
+	}
 	refs.Unlock()
 }
 
+// mapTable is a refTable made of two maps: from objects to reference
+// numbers, and from reference numbers to counted objects. Reference numbers
+// are never reused.
+type mapTable struct {
+	next int32 // next reference number to use for Go object, always negative
+	refs map[interface{}]int32
+	objs map[int32]countedObj
+}
+
+func newMapTable() *mapTable {
+	return &mapTable{
+		next: firstRef,
+		refs: make(map[interface{}]int32),
+		objs: make(map[int32]countedObj),
+	}
+}
+
+func (t *mapTable) pin(obj interface{}) int32 {
+	num := t.refs[obj]
+	if num != 0 {
+		s := t.objs[num]
+		t.objs[num] = countedObj{s.obj, s.cnt + 1}
+		return num
+	}
+	num = t.next
+	t.next--
+	if t.next > 0 {
+		panic("refs.next underflow")
+	}
+	t.refs[obj] = num
+	t.objs[num] = countedObj{obj, 1}
+	return num
+}
+
+func (t *mapTable) get(num int32) (interface{}, bool) {
+	o, ok := t.objs[num]
+	return o.obj, ok
+}
+
+func (t *mapTable) release(num int32) bool {
+	o, ok := t.objs[num]
+	if !ok {
+		return false
+	}
+	if o.cnt <= 1 {
+		delete(t.objs, num)
+		delete(t.refs, o.obj)
+	} else {
+		t.objs[num] = countedObj{o.obj, o.cnt - 1}
+	}
+	return true
+}
+
+func (t *mapTable) len() int {
+	return len(t.objs)
+}
+
 // A Ref represents a Java or Go object passed across the language
 // boundary.
+// The reference number 0 stands for a nil pointer.
 type Ref struct {
 	Num int32
 }
 
-// Get returns the underlying object.
+// Get returns the underlying object, nil for the reference number 0.
 func (r *Ref) Get() interface{} {
+	if r.Num == 0 {
+		return nil
+	}
 	refs.Lock()
-	o, ok := refs.objs[r.Num]
+	o, ok := refs.table.get(r.Num)
 	refs.Unlock()
 	if !ok {
 		panic(fmt.Sprintf("unknown ref %d", r.Num))
 	}
-	return o.obj
+	return o
+}
+
+// NumRefs returns the number of Go objects currently passed to another
+// language, e.g. to check that they are released.
+func NumRefs() int {
+	refs.Lock()
+	defer refs.Unlock()
+	return refs.table.len()
 }
 
 // Delete decrements the reference count and removes the pinned object
 // from the object map when the reference count becomes zero.
+// Deleting the reference number 0, a nil pointer, does nothing.
 func Delete(num int32) {
+	if num == 0 {
+		return
+	}
 	refs.Lock()
 	defer refs.Unlock()
-	o, ok := refs.objs[num]
-	if !ok {
+	if !refs.table.release(num) {
 		panic(fmt.Sprintf("seq.Delete unknown refnum: %d", num))
 	}
-	if o.cnt <= 1 {
-		delete(refs.objs, num)
-		delete(refs.refs, o.obj)
-	} else {
-		refs.objs[num] = countedObj{o.obj, o.cnt - 1}
-	}
 }
@@ -0,0 +1,102 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq
+
+import (
+	"fmt"
+	"testing"
+)
+
+var refTables = []struct {
+	name string
+	new  func() refTable
+}{
+	{"maps", func() refTable { return newMapTable() }},
+	{"slab", func() refTable { return newSlabTable() }},
+}
+
+func TestRefTable(t *testing.T) {
+	for _, tt := range refTables {
+		t.Run(tt.name, func(t *testing.T) {
+			tbl := tt.new()
+			a, b := new(int), new(int)
+
+			na := tbl.pin(a)
+			nb := tbl.pin(b)
+			if na >= 0 || nb >= 0 || na == nb {
+				t.Fatalf("pin: got %d and %d, want distinct negative numbers", na, nb)
+			}
+			if got := tbl.pin(a); got != na {
+				t.Errorf("pin(a) again: got %d, want %d", got, na)
+			}
+			if got, want := tbl.len(), 2; got != want {
+				t.Errorf("len()=%d, want %d", got, want)
+			}
+
+			// a is pinned twice: it is unpinned by the second release.
+			for i := 0; i < 2; i++ {
+				if o, ok := tbl.get(na); !ok || o != a {
+					t.Fatalf("get(%d)=%v, %v after %d release(s), want a", na, o, ok, i)
+				}
+				if !tbl.release(na) {
+					t.Fatalf("release(%d) #%d: unknown", na, i)
+				}
+			}
+			if _, ok := tbl.get(na); ok {
+				t.Errorf("get(%d): a still pinned", na)
+			}
+			if tbl.release(na) {
+				t.Errorf("release(%d): a still pinned", na)
+			}
+			if got, want := tbl.len(), 1; got != want {
+				t.Errorf("len()=%d, want %d", got, want)
+			}
+
+			for _, num := range []int32{0, 1, firstRef + 1, nb - 100} {
+				if _, ok := tbl.get(num); ok {
+					t.Errorf("get(%d): unknown number pinned", num)
+				}
+			}
+
+			c := new(int)
+			nc := tbl.pin(c)
+			if nc == nb {
+				t.Errorf("pin(c): got the number %d of b", nc)
+			}
+			if o, ok := tbl.get(nb); !ok || o != b {
+				t.Errorf("get(%d)=%v, %v, want b", nb, o, ok)
+			}
+		})
+	}
+}
+
+// BenchmarkRefTable pins, looks up and releases short-lived objects, while
+// others stay pinned, as python wrappers of go values created in a loop.
+func BenchmarkRefTable(b *testing.B) {
+	for _, tt := range refTables {
+		for _, live := range []int{100, 100000} {
+			b.Run(fmt.Sprintf("%s/live=%d", tt.name, live), func(b *testing.B) {
+				tbl := tt.new()
+				for i := 0; i < live; i++ {
+					tbl.pin(new(int))
+				}
+				objs := make([]*int, 64)
+				for i := range objs {
+					objs[i] = new(int)
+				}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					obj := objs[i%len(objs)]
+					num := tbl.pin(obj)
+					if o, _ := tbl.get(num); o != obj {
+						b.Fatalf("get(%d): got %v, want %v", num, o, obj)
+					}
+					tbl.release(num)
+				}
+			})
+		}
+	}
+}
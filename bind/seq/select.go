@@ -0,0 +1,57 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ErrTimeout is returned by the receives whose timeout expired.
+var ErrTimeout = errors.New("timeout expired")
+
+// Select waits for a value from one of chans, which hold channels or
+// pointers to channels, as a select statement with a receive case for each
+// channel.
+// It returns the index of the chosen channel, the received value and
+// whether it was sent, false meaning the channel is closed.
+// A non-negative timeout, in seconds, bounds the wait: Select returns -1,
+// nil and false when it expires. A zero timeout polls the channels.
+func Select(chans []interface{}, timeout float64) (int, interface{}, bool) {
+	cases := make([]reflect.SelectCase, len(chans), len(chans)+1)
+	for i, ch := range chans {
+		v := reflect.ValueOf(ch)
+		if v.Kind() == reflect.Ptr {
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Chan {
+			panic(fmt.Sprintf("seq: select on non-channel %T", ch))
+		}
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: v}
+	}
+
+	switch {
+	case timeout == 0:
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+	case timeout > 0:
+		timer := time.NewTimer(time.Duration(timeout * float64(time.Second)))
+		defer timer.Stop()
+		cases = append(cases, reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(timer.C),
+		})
+	}
+
+	i, v, ok := reflect.Select(cases)
+	if i == len(chans) {
+		return -1, nil, false
+	}
+	if !ok {
+		return i, nil, false
+	}
+	return i, v.Interface(), true
+}
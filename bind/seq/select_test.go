@@ -0,0 +1,43 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSelect(t *testing.T) {
+	ints := make(chan int, 1)
+	strs := make(chan string, 1)
+	chans := []interface{}{ints, &strs}
+
+	strs <- "hello"
+	if i, v, ok := Select(chans, -1); i != 1 || v != "hello" || !ok {
+		t.Fatalf("Select()=(%d, %v, %v), want (1, hello, true)", i, v, ok)
+	}
+
+	if i, v, ok := Select(chans, 0); i != -1 || v != nil || ok {
+		t.Fatalf("Select(poll)=(%d, %v, %v), want (-1, <nil>, false)", i, v, ok)
+	}
+
+	start := time.Now()
+	if i, v, ok := Select(chans, 0.01); i != -1 || v != nil || ok {
+		t.Fatalf("Select(timeout)=(%d, %v, %v), want (-1, <nil>, false)", i, v, ok)
+	}
+	if d := time.Since(start); d < 10*time.Millisecond {
+		t.Fatalf("Select(timeout) returned after %v, want at least 10ms", d)
+	}
+
+	go func() { ints <- 42 }()
+	if i, v, ok := Select(chans, 1); i != 0 || v != 42 || !ok {
+		t.Fatalf("Select()=(%d, %v, %v), want (0, 42, true)", i, v, ok)
+	}
+
+	close(ints)
+	if i, v, ok := Select(chans, -1); i != 0 || v != nil || ok {
+		t.Fatalf("Select(closed)=(%d, %v, %v), want (0, <nil>, false)", i, v, ok)
+	}
+}
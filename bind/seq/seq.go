@@ -32,6 +32,8 @@ type Func func(out, in *Buffer)
 
 // Registry holds functions callable from gobind generated bindings.
 // Functions are keyed by descriptor and function code.
+// Registry is only modified during package initialization and is read-only
+// afterwards, so it can be used concurrently.
 var Registry = make(map[string]map[int]Func)
 
 // Register registers a function in the Registry.
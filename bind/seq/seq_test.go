@@ -34,3 +34,61 @@ func TestBuffer(t *testing.T) {
 		t.Errorf("buf.ReadFloat32()=%f, want %f", got, want)
 	}
 }
+
+func TestNumRefs(t *testing.T) {
+	n := NumRefs()
+	buf := new(Buffer)
+	buf.WriteGoRef(new(int))
+	if got, want := NumRefs(), n+1; got != want {
+		t.Errorf("NumRefs()=%d, want %d", got, want)
+	}
+
+	buf.Offset = 0
+	Delete(buf.ReadInt32())
+	if got, want := NumRefs(), n; got != want {
+		t.Errorf("NumRefs()=%d after Delete, want %d", got, want)
+	}
+}
+
+func TestNilRef(t *testing.T) {
+	buf := new(Buffer)
+	buf.WriteInt32(0)
+	buf.Offset = 0
+
+	if got := buf.ReadRef().Get(); got != nil {
+		t.Errorf("buf.ReadRef().Get()=%v, want nil", got)
+	}
+
+	n := NumRefs()
+	Delete(0)
+	if got := NumRefs(); got != n {
+		t.Errorf("NumRefs()=%d after Delete(0), want %d", got, n)
+	}
+}
+
+func TestWriteNilGoRef(t *testing.T) {
+	type node struct{ next *node }
+
+	n := NumRefs()
+	buf := new(Buffer)
+	var err error
+	var fct, add func(int) int
+	add = func(i int) int { return i + 1 }
+	buf.WriteGoRef((*node)(nil))
+	buf.WriteGoRef(&node{})
+	buf.WriteGoRef(err)
+	buf.WriteGoRef(&fct)
+	buf.WriteGoRef(&add)
+	buf.Offset = 0
+
+	for i, want := range []bool{true, false, true, true, false} {
+		ref := buf.ReadRef()
+		if got := ref.Num == 0; got != want {
+			t.Errorf("ref #%d: nil=%v, want %v", i, got, want)
+		}
+		Delete(ref.Num)
+	}
+	if got := NumRefs(); got != n {
+		t.Errorf("NumRefs()=%d, want %d", got, n)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq
+
+import "math"
+
+// slabTable is a refTable holding the counted objects in a slab, indexed by
+// reference number: the slots of the unpinned objects are reused, through a
+// free list, instead of growing a map. A single map, from objects to
+// reference numbers, finds the objects already pinned.
+type slabTable struct {
+	slots []countedObj // the free slots have a zero count
+	free  []int32      // indices of the free slots
+	nums  map[interface{}]int32
+	n     int // number of pinned objects
+}
+
+func newSlabTable() *slabTable {
+	return &slabTable{nums: make(map[interface{}]int32)}
+}
+
+func (t *slabTable) pin(obj interface{}) int32 {
+	if num, ok := t.nums[obj]; ok {
+		t.slots[firstRef-num].cnt++
+		return num
+	}
+	var i int32
+	if n := len(t.free); n > 0 {
+		i = t.free[n-1]
+		t.free = t.free[:n-1]
+	} else {
+		if len(t.slots) > math.MaxInt32+firstRef {
+			panic("refs: too many pinned objects")
+		}
+		i = int32(len(t.slots))
+		t.slots = append(t.slots, countedObj{})
+	}
+	num := firstRef - i
+	t.slots[i] = countedObj{obj, 1}
+	t.nums[obj] = num
+	t.n++
+	return num
+}
+
+// slot returns the slot of the object of reference number num, nil if it
+// is not pinned.
+func (t *slabTable) slot(num int32) *countedObj {
+	i := int64(firstRef) - int64(num)
+	if i < 0 || i >= int64(len(t.slots)) || t.slots[i].cnt == 0 {
+		return nil
+	}
+	return &t.slots[i]
+}
+
+func (t *slabTable) get(num int32) (interface{}, bool) {
+	s := t.slot(num)
+	if s == nil {
+		return nil, false
+	}
+	return s.obj, true
+}
+
+func (t *slabTable) release(num int32) bool {
+	s := t.slot(num)
+	if s == nil {
+		return false
+	}
+	if s.cnt--; s.cnt > 0 {
+		return true
+	}
+	delete(t.nums, s.obj)
+	// the slot no longer keeps the object alive.
+	*s = countedObj{}
+	t.free = append(t.free, firstRef-num)
+	t.n--
+	return true
+}
+
+func (t *slabTable) len() int {
+	return t.n
+}
@@ -0,0 +1,12 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !gopy_slab
+// +build !gopy_slab
+
+package seq
+
+// slabRefs tells whether the Go objects passed to another language are
+// pinned in a slabTable, rather than a mapTable.
+const slabRefs = false
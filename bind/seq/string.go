@@ -121,3 +121,24 @@ func (b *Buffer) ReadUTF8() string {
 	b.Offset = offset + size
 	return string(b.Data[offset : offset+size])
 }
+
+// WriteStrings writes the number of strings of v, followed by the strings.
+func (b *Buffer) WriteStrings(v []string) {
+	b.WriteInt64(int64(len(v)))
+	for _, s := range v {
+		b.WriteString(s)
+	}
+}
+
+// ReadStrings reads strings written by WriteStrings, into a new slice.
+func (b *Buffer) ReadStrings() []string {
+	n := int(b.ReadInt64())
+	if n < 0 {
+		panic(fmt.Sprintf("strings size negative: %d", n))
+	}
+	v := make([]string, n)
+	for i := range v {
+		v[i] = b.ReadString()
+	}
+	return v
+}
@@ -49,3 +49,30 @@ func TestSequential(t *testing.T) {
 		}
 	}
 }
+
+func TestStrings(t *testing.T) {
+	// strings are encoded as byte arrays, as by the cpython backend.
+	enc, dec := EncString, DecString
+	defer func() { EncString, DecString = enc, dec }()
+	EncString = func(out *Buffer, v string) { out.WriteByteArray([]byte(v)) }
+	DecString = func(in *Buffer) string { return string(in.ReadByteArray()) }
+
+	for _, test := range [][]string{nil, {""}, strData} {
+		buf := new(Buffer)
+		buf.WriteStrings(test)
+		buf.WriteInt32(42)
+		buf.Offset = 0
+		got := buf.ReadStrings()
+		if len(got) != len(test) {
+			t.Fatalf("got %q, want %q", got, test)
+		}
+		for i := range test {
+			if got[i] != test[i] {
+				t.Errorf("%d: got %q, want %q", i, got[i], test[i])
+			}
+		}
+		if v := buf.ReadInt32(); v != 42 {
+			t.Errorf("read %d after the strings, want 42", v)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// Kinds of the dynamically typed values exchanged with WriteValue and
+// ReadValue. Each value is encoded as its kind followed by its payload.
+const (
+	ValueNil    int8 = iota // no payload
+	ValueBool               // bool
+	ValueInt                // int64
+	ValueFloat              // float64
+	ValueString             // byte array
+	ValueList               // int64 length, then each item
+	ValueDict               // int64 length, then each key (byte array) and item
+	ValueRef                // go type name (byte array) and ref number
+	ValueError              // error message (byte array), for unsupported values
+	ValueUint               // uint64, for unsigned integers above math.MaxInt64
+)
+
+// WriteValue writes v, converting it by dynamic type: booleans, integers,
+// floats, strings, slices and arrays, and maps with string keys, holding
+// values of these types.
+// Structs and pointers to structs are written as refs, tagged with the name
+// of their type. Struct values are copied, so that all refs hold a pointer.
+// Values of other types, such as complex numbers, channels, funcs and maps
+// with non-string keys, are written as a ValueError, which python raises as a
+// TypeError, and WriteValue returns the error of the first of them.
+func (b *Buffer) WriteValue(v interface{}) error {
+	if v == nil {
+		b.WriteInt8(ValueNil)
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Struct:
+		ptr := reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		b.writeValueRef(ptr)
+		return nil
+	case reflect.Ptr:
+		if !rv.IsNil() && rv.Elem().Kind() == reflect.Struct {
+			b.writeValueRef(rv)
+			return nil
+		}
+	}
+	switch rv.Kind() {
+	case reflect.Bool:
+		b.WriteInt8(ValueBool)
+		b.WriteBool(rv.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b.WriteInt8(ValueInt)
+		b.WriteInt64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u := rv.Uint(); u > math.MaxInt64 {
+			b.WriteInt8(ValueUint)
+			b.WriteUint64(u)
+			break
+		}
+		b.WriteInt8(ValueInt)
+		b.WriteInt64(int64(rv.Uint()))
+	case reflect.Float32, reflect.Float64:
+		b.WriteInt8(ValueFloat)
+		b.WriteFloat64(rv.Float())
+	case reflect.String:
+		b.WriteInt8(ValueString)
+		b.WriteByteArray([]byte(rv.String()))
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			b.WriteInt8(ValueNil)
+			return nil
+		}
+		b.WriteInt8(ValueList)
+		b.WriteInt64(int64(rv.Len()))
+		// all the items are written, so that the buffer stays well
+		// formed past an unsupported one.
+		var err error
+		for i := 0; i < rv.Len(); i++ {
+			if e := b.WriteValue(rv.Index(i).Interface()); err == nil {
+				err = e
+			}
+		}
+		return err
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return b.writeValueError(fmt.Errorf("seq: unsupported map key type %v", rv.Type().Key()))
+		}
+		if rv.IsNil() {
+			b.WriteInt8(ValueNil)
+			return nil
+		}
+		b.WriteInt8(ValueDict)
+		b.WriteInt64(int64(rv.Len()))
+		var err error
+		for _, k := range rv.MapKeys() {
+			b.WriteByteArray([]byte(k.String()))
+			if e := b.WriteValue(rv.MapIndex(k).Interface()); err == nil {
+				err = e
+			}
+		}
+		return err
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			b.WriteInt8(ValueNil)
+			return nil
+		}
+		return b.WriteValue(rv.Elem().Interface())
+	default:
+		return b.writeValueError(fmt.Errorf("seq: unsupported value type %T", v))
+	}
+	return nil
+}
+
+// writeValueError writes err in place of an unsupported value, and returns
+// it.
+func (b *Buffer) writeValueError(err error) error {
+	b.WriteInt8(ValueError)
+	b.WriteByteArray([]byte(err.Error()))
+	return err
+}
+
+func (b *Buffer) writeValueRef(ptr reflect.Value) {
+	b.WriteInt8(ValueRef)
+	b.WriteByteArray([]byte(ptr.Type().Elem().String()))
+	b.WriteGoRef(ptr.Interface())
+}
+
+// ReadValue reads a value written by WriteValue.
+// Integers are read as int, or uint64 above math.MaxInt64, lists as
+// []interface{} and dicts as map[string]interface{}. Refs are read as the go
+// value they refer to, and unsupported values as an error.
+func (b *Buffer) ReadValue() interface{} {
+	switch kind := b.ReadInt8(); kind {
+	case ValueNil:
+		return nil
+	case ValueBool:
+		return b.ReadBool()
+	case ValueInt:
+		return int(b.ReadInt64())
+	case ValueUint:
+		return b.ReadUint64()
+	case ValueFloat:
+		return b.ReadFloat64()
+	case ValueString:
+		return string(b.ReadByteArray())
+	case ValueList:
+		n := b.ReadInt64()
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = b.ReadValue()
+		}
+		return list
+	case ValueDict:
+		n := b.ReadInt64()
+		dict := make(map[string]interface{}, n)
+		for i := int64(0); i < n; i++ {
+			k := string(b.ReadByteArray())
+			dict[k] = b.ReadValue()
+		}
+		return dict
+	case ValueRef:
+		b.ReadByteArray() // type name, only needed by the other side.
+		return b.ReadRef().Get()
+	case ValueError:
+		return errors.New(string(b.ReadByteArray()))
+	default:
+		panic(fmt.Sprintf("seq: invalid value kind %d", kind))
+	}
+}
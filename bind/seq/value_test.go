@@ -0,0 +1,134 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package seq
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+type point struct {
+	X, Y int
+}
+
+func TestValue(t *testing.T) {
+	pt := &point{1, 2}
+	for _, test := range []struct {
+		v    interface{}
+		want interface{}
+	}{
+		{nil, nil},
+		{true, true},
+		{42, 42},
+		{int8(-3), -3},
+		{uint16(7), 7},
+		{uint64(math.MaxInt64), math.MaxInt64},
+		{uint64(1) << 63, uint64(1) << 63},
+		{uint(math.MaxUint64), uint64(math.MaxUint64)},
+		{float32(1.5), 1.5},
+		{2.25, 2.25},
+		{"", ""},
+		{"hello", "hello"},
+		{[]int(nil), nil},
+		{[]string{"a", "b"}, []interface{}{"a", "b"}},
+		{[2]float64{1, 2}, []interface{}{1.0, 2.0}},
+		{
+			map[string]interface{}{
+				"name":  "gopy",
+				"n":     1.0,
+				"ok":    false,
+				"none":  nil,
+				"items": []interface{}{1.0, "x", map[string]interface{}{"k": true}},
+			},
+			map[string]interface{}{
+				"name":  "gopy",
+				"n":     1.0,
+				"ok":    false,
+				"none":  nil,
+				"items": []interface{}{1.0, "x", map[string]interface{}{"k": true}},
+			},
+		},
+		{map[string]int{"one": 1}, map[string]interface{}{"one": 1}},
+		{point{3, 4}, &point{3, 4}},
+		{pt, pt},
+		{[]interface{}{pt, nil}, []interface{}{pt, nil}},
+	} {
+		buf := new(Buffer)
+		buf.WriteValue(test.v)
+		buf.WriteInt32(1 << 13) // sentinel
+		buf.Offset = 0
+
+		got := buf.ReadValue()
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ReadValue(WriteValue(%#v))=%#v, want %#v", test.v, got, test.want)
+		}
+		if p, ok := test.v.(*point); ok && got != p {
+			t.Errorf("ReadValue(WriteValue(%p))=%p, want the same pointer", p, got)
+		}
+		if got, want := buf.ReadInt32(), int32(1<<13); got != want {
+			t.Errorf("%#v: sentinel=%d, want %d", test.v, got, want)
+		}
+	}
+}
+
+func TestValueError(t *testing.T) {
+	for _, test := range []struct {
+		v    interface{}
+		err  string
+		want interface{}
+	}{
+		{
+			v:    complex(1, 2),
+			err:  "seq: unsupported value type complex128",
+			want: errors.New("seq: unsupported value type complex128"),
+		},
+		{
+			v:    map[int]string{1: "one"},
+			err:  "seq: unsupported map key type int",
+			want: errors.New("seq: unsupported map key type int"),
+		},
+		{
+			v:    make(chan int),
+			err:  "seq: unsupported value type chan int",
+			want: errors.New("seq: unsupported value type chan int"),
+		},
+		{
+			v:    func() {},
+			err:  "seq: unsupported value type func()",
+			want: errors.New("seq: unsupported value type func()"),
+		},
+		{
+			v:   []interface{}{1, complex64(1), "x", func() {}},
+			err: "seq: unsupported value type complex64",
+			want: []interface{}{
+				1, errors.New("seq: unsupported value type complex64"),
+				"x", errors.New("seq: unsupported value type func()"),
+			},
+		},
+		{
+			v:    map[string]interface{}{"c": complex(0, 1)},
+			err:  "seq: unsupported value type complex128",
+			want: map[string]interface{}{"c": errors.New("seq: unsupported value type complex128")},
+		},
+	} {
+		buf := new(Buffer)
+		err := buf.WriteValue(test.v)
+		buf.WriteInt32(1 << 13) // sentinel
+		buf.Offset = 0
+
+		if err == nil || err.Error() != test.err {
+			t.Errorf("WriteValue(%#v): got error %v, want %q", test.v, err, test.err)
+		}
+		got := buf.ReadValue()
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ReadValue(WriteValue(%#v))=%#v, want %#v", test.v, got, test.want)
+		}
+		if got, want := buf.ReadInt32(), int32(1<<13); got != want {
+			t.Errorf("%#v: sentinel=%d, want %d", test.v, got, want)
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"unicode"
 )
 
 var (
@@ -27,6 +28,17 @@ func uhash(s string) uint32 {
 	return h.Sum32()
 }
 
+// pathID returns the import path path made into an identifier, e.g.
+// text_template for text/template.
+func pathID(path string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x80 && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return '_'
+	}, path)
+}
+
 // symkind describes the kinds of symbol
 type symkind int
 
@@ -45,6 +57,7 @@ const (
 	skSlice
 	skStruct
 	skString
+	skChan
 )
 
 var (
@@ -63,6 +76,7 @@ var (
 		"slice":     skSlice,
 		"struct":    skStruct,
 		"string":    skString,
+		"chan":      skChan,
 	}
 )
 
@@ -142,6 +156,14 @@ func (s symbol) isStruct() bool {
 	return (s.kind & skStruct) != 0
 }
 
+func (s symbol) isChan() bool {
+	return (s.kind & skChan) != 0
+}
+
+func (s symbol) isPointer() bool {
+	return (s.kind & skPointer) != 0
+}
+
 func (s symbol) hasConverter() bool {
 	return s.pyfmt == "O&" && (s.c2py != "" || s.py2c != "")
 }
@@ -186,7 +208,7 @@ func (s symbol) cgotypename() string {
 func (s symbol) gofmt() string {
 	return types.TypeString(
 		s.GoType(),
-		func(*types.Package) string { return s.pkgname() },
+		func(pkg *types.Package) string { return pkg.Name() },
 	)
 }
 
@@ -222,6 +244,20 @@ type symtab struct {
 	pkg    *types.Package
 	syms   map[string]*symbol
 	parent *symtab
+
+	// type names generated for anonymous structs and instantiated
+	// generic types, by type string.
+	aliases map[string]*types.TypeName
+
+	// converters of the types annotated with //gopy:convert.
+	convs map[*types.TypeName]*converter
+
+	// how net.IP and *url.URL values are exchanged with python.
+	nets NetTypes
+
+	// reasons why types, by type string, have no symbol, such as the
+	// unnamed types only used by the types of other packages.
+	unbound map[string]error
 }
 
 func newSymtab(pkg *types.Package, parent *symtab) *symtab {
@@ -229,13 +265,68 @@ func newSymtab(pkg *types.Package, parent *symtab) *symtab {
 		parent = universe
 	}
 	s := &symtab{
-		pkg:    pkg,
-		syms:   make(map[string]*symbol),
-		parent: parent,
+		pkg:     pkg,
+		syms:    make(map[string]*symbol),
+		parent:  parent,
+		aliases: make(map[string]*types.TypeName),
+		convs:   make(map[*types.TypeName]*converter),
+		unbound: make(map[string]error),
 	}
 	return s
 }
 
+// unbind records that the type named fn has no symbol, because of err: the
+// entities using it are not wrapped.
+func (sym *symtab) unbind(fn string, err error) {
+	sym.unbound[fn] = err
+}
+
+// typeErr returns the reason why typ has no symbol.
+func (sym *symtab) typeErr(typ types.Type) error {
+	if err := sym.unbound[sym.typename(typ, nil)]; err != nil {
+		return err
+	}
+	return fmt.Errorf("unsupported type %s", typeString(typ))
+}
+
+// checkBound returns an error if typ has no symbol.
+func (sym *symtab) checkBound(typ types.Type) error {
+	if sym.symtype(typ) == nil {
+		return sym.typeErr(typ)
+	}
+	return nil
+}
+
+// checkBoundSig returns an error if a parameter or a result of sig has
+// no symbol.
+func (sym *symtab) checkBoundSig(sig *types.Signature) error {
+	params := sig.Params()
+	for i := 0; i < params.Len(); i++ {
+		if err := sym.checkBound(params.At(i).Type()); err != nil {
+			return fmt.Errorf("parameter %s: %v", varName(params.At(i), i), err)
+		}
+	}
+	res := sig.Results()
+	for i := 0; i < res.Len(); i++ {
+		if err := sym.checkBound(res.At(i).Type()); err != nil {
+			return fmt.Errorf("result %s: %v", varName(res.At(i), i), err)
+		}
+	}
+	return nil
+}
+
+// checkBoundObject returns an error if the type of the package-level
+// object obj, or of a parameter or a result of a func, has no symbol.
+func (sym *symtab) checkBoundObject(obj types.Object) error {
+	switch obj := obj.(type) {
+	case *types.Func:
+		return sym.checkBoundSig(obj.Type().(*types.Signature))
+	case *types.Var, *types.TypeName:
+		return sym.checkBound(obj.Type())
+	}
+	return nil
+}
+
 func (sym *symtab) names() []string {
 	names := make([]string, 0, len(sym.syms))
 	for n := range sym.syms {
@@ -274,6 +365,7 @@ func (sym *symtab) typeof(n string) *symbol {
 }
 
 func (sym *symtab) typename(t types.Type, pkg *types.Package) string {
+	t = unalias(t)
 	if pkg == nil {
 		return types.TypeString(t, nil)
 	}
@@ -355,15 +447,42 @@ func (sym *symtab) processTuple(tuple *types.Tuple) {
 }
 
 func (sym *symtab) addType(obj types.Object, t types.Type) {
+	t = unalias(t)
 	fn := sym.typename(t, nil)
 	n := sym.typename(t, sym.pkg)
+	id := n
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != nil {
+		// named types are identified by their declaration, which may
+		// live in another package than the object using them.
+		obj = named.Obj()
+		id = obj.Name()
+	}
+	if isAliased(t) {
+		// anonymous structs, unnamed arrays and slices and instantiated
+		// generic types are named after the type name generated for them.
+		alias := sym.aliases[fn]
+		if alias == nil {
+			// only used by the types of other packages, whose fields
+			// and methods using it are not wrapped.
+			sym.unbind(fn, fmt.Errorf("unsupported type %s: only used by other packages", typeString(t)))
+			return
+		}
+		obj = alias
+		n = obj.Name()
+		id = n
+	}
 	var pkg *types.Package
 	if obj != nil {
 		pkg = obj.Pkg()
 	}
-	id := n
-	if pkg != nil {
-		id = pkg.Name() + "_" + n
+	switch {
+	case pkg == nil:
+	case pkg == sym.pkg:
+		id = pkg.Name() + "_" + id
+	default:
+		// types of other packages are identified by import path, as
+		// text/template.Template and html/template.Template.
+		id = pathID(pkg.Path()) + "_" + id
 	}
 	kind := skType
 	switch typ := t.(type) {
@@ -384,6 +503,18 @@ func (sym *symtab) addType(obj types.Object, t types.Type) {
 		sym.addSignatureType(pkg, obj, t, kind, id, n)
 
 	case *types.Named:
+		if conv := sym.convs[typ.Obj()]; conv != nil {
+			sym.addConvType(pkg, obj, t, kind, id, n, conv)
+			break
+		}
+		if isDurationType(typ) {
+			sym.addDurationType(pkg, obj, t, kind, id, n)
+			break
+		}
+		if sym.netType(typ) != "" {
+			sym.addNetType(pkg, obj, t, kind|skBasic, id, n)
+			break
+		}
 		kind |= skNamed
 		switch typ := typ.Underlying().(type) {
 		case *types.Struct:
@@ -417,6 +548,12 @@ func (sym *symtab) addType(obj types.Object, t types.Type) {
 		case *types.Signature:
 			sym.addSignatureType(pkg, obj, t, kind, id, n)
 
+		case *types.Chan:
+			sym.addChanType(pkg, obj, t, kind, id, n)
+
+		case *types.Map:
+			sym.addMapType(pkg, obj, t, kind, id, n)
+
 		case *types.Pointer:
 			sym.addPointerType(pkg, obj, t, kind, id, n)
 
@@ -430,7 +567,7 @@ func (sym *symtab) addType(obj types.Object, t types.Type) {
 		// add methods
 		for i := 0; i < typ.NumMethods(); i++ {
 			m := typ.Method(i)
-			if !m.Exported() {
+			if !m.Exported() || checkSig(m.Type().(*types.Signature)) != nil {
 				continue
 			}
 			if true {
@@ -441,11 +578,36 @@ func (sym *symtab) addType(obj types.Object, t types.Type) {
 		}
 
 	case *types.Pointer:
+		if isFileType(t) {
+			sym.addFileType(pkg, obj, t, kind, id, n)
+			break
+		}
+		if isBigType(t) {
+			sym.addBigType(pkg, obj, t, kind, id, n)
+			break
+		}
+		if sym.netType(t) != "" {
+			sym.addNetType(pkg, obj, t, kind|skPointer, id, n)
+			break
+		}
 		sym.addPointerType(pkg, obj, t, kind, id, n)
 
+	case *types.Struct:
+		sym.addStructType(pkg, obj, t, kind, id, n)
+
 	case *types.Map:
+		if isDictType(t) {
+			sym.addDictType(pkg, obj, t, kind, id, n)
+			break
+		}
 		sym.addMapType(pkg, obj, t, kind, id, n)
 
+	case *types.Interface:
+		if !typ.Empty() {
+			panic(fmt.Errorf("unhandled unnamed interface type [%s]", t))
+		}
+		sym.addAnyType(pkg, obj, t, kind, id, n)
+
 	default:
 		panic(fmt.Errorf("unhandled obj [%T]\ntype [%#v]", obj, t))
 	}
@@ -459,17 +621,17 @@ func (sym *symtab) addArrayType(pkg *types.Package, obj types.Object, t types.Ty
 	elt := sym.sym(enam)
 	if elt == nil || elt.goname == "" {
 		eltname := sym.typename(typ.Elem(), pkg)
-		eobj := sym.pkg.Scope().Lookup(eltname)
-		if eobj == nil {
-			panic(fmt.Errorf("could not look-up %q!\n", enam))
+		if eobj := sym.pkg.Scope().Lookup(eltname); eobj != nil {
+			sym.addSymbol(eobj)
+		} else {
+			// unnamed elements, such as the interface{} of a
+			// ...interface{} parameter, are not in scope.
+			sym.addType(nil, typ.Elem())
 		}
-		sym.addSymbol(eobj)
 		elt = sym.sym(enam)
 		if elt == nil {
-			panic(fmt.Errorf(
-				"gopy: could not retrieve array-elt symbol for %q",
-				enam,
-			))
+			sym.unbind(fn, fmt.Errorf("element: %v", sym.typeErr(typ.Elem())))
+			return
 		}
 	}
 	id = hash(id)
@@ -495,21 +657,34 @@ func (sym *symtab) addMapType(pkg *types.Package, obj types.Object, t types.Type
 	fn := sym.typename(t, nil)
 	typ := t.Underlying().(*types.Map)
 	kind |= skMap
+	knam := sym.typename(typ.Key(), nil)
+	if key := sym.sym(knam); key == nil || key.goname == "" {
+		keyname := sym.typename(typ.Key(), pkg)
+		if kobj := sym.pkg.Scope().Lookup(keyname); kobj != nil {
+			sym.addSymbol(kobj)
+		} else {
+			sym.addType(nil, typ.Key())
+		}
+		if sym.sym(knam) == nil {
+			sym.unbind(fn, fmt.Errorf("key: %v", sym.typeErr(typ.Key())))
+			return
+		}
+	}
 	enam := sym.typename(typ.Elem(), nil)
 	elt := sym.sym(enam)
 	if elt == nil || elt.goname == "" {
 		eltname := sym.typename(typ.Elem(), pkg)
-		eobj := sym.pkg.Scope().Lookup(eltname)
-		if eobj == nil {
-			panic(fmt.Errorf("could not look-up %q!\n", enam))
+		if eobj := sym.pkg.Scope().Lookup(eltname); eobj != nil {
+			sym.addSymbol(eobj)
+		} else {
+			// unnamed elements, such as the interface{} of a
+			// ...interface{} parameter, are not in scope.
+			sym.addType(nil, typ.Elem())
 		}
-		sym.addSymbol(eobj)
 		elt = sym.sym(enam)
 		if elt == nil {
-			panic(fmt.Errorf(
-				"gopy: could not retrieve map-elt symbol for %q",
-				enam,
-			))
+			sym.unbind(fn, fmt.Errorf("element: %v", sym.typeErr(typ.Elem())))
+			return
 		}
 	}
 	id = hash(id)
@@ -531,6 +706,203 @@ func (sym *symtab) addMapType(pkg *types.Package, obj types.Object, t types.Type
 	}
 }
 
+// addDictType adds a map[string]interface{}, converted to and from a python
+// dict by value.
+func (sym *symtab) addDictType(pkg *types.Package, obj types.Object, t types.Type, kind symkind, id, n string) {
+	fn := sym.typename(t, nil)
+	kind |= skMap
+	sym.syms[fn] = &symbol{
+		gopkg:   pkg,
+		goobj:   obj,
+		gotyp:   t,
+		kind:    kind,
+		id:      "dict",
+		goname:  n,
+		cgoname: "PyObject*",
+		cpyname: "PyObject",
+		pyfmt:   "O&",
+		pybuf:   "P",
+		pysig:   "dict",
+		c2py:    "cgopy_cnv_c2py_dict",
+		py2c:    "cgopy_cnv_py2c_dict",
+		pychk:   "PyDict_Check(%s)",
+	}
+}
+
+// addAnyType adds an interface{}, converted to and from python objects by
+// dynamic type.
+func (sym *symtab) addAnyType(pkg *types.Package, obj types.Object, t types.Type, kind symkind, id, n string) {
+	fn := sym.typename(t, nil)
+	kind |= skInterface
+	sym.syms[fn] = &symbol{
+		gopkg:   pkg,
+		goobj:   obj,
+		gotyp:   t,
+		kind:    kind,
+		id:      "any",
+		goname:  n,
+		cgoname: "PyObject*",
+		cpyname: "PyObject",
+		pyfmt:   "O&",
+		pybuf:   "P",
+		pysig:   "object",
+		c2py:    "cgopy_cnv_c2py_any",
+		py2c:    "cgopy_cnv_py2c_any",
+		pychk:   "cgopy_seq_check_value(%s)",
+	}
+}
+
+// addFileType adds an *os.File, exchanged with python as a file descriptor.
+func (sym *symtab) addFileType(pkg *types.Package, obj types.Object, t types.Type, kind symkind, id, n string) {
+	fn := sym.typename(t, nil)
+	kind |= skPointer
+	sym.syms[fn] = &symbol{
+		gopkg:   pkg,
+		goobj:   obj,
+		gotyp:   t,
+		kind:    kind,
+		id:      "file",
+		goname:  n,
+		cgoname: "int64_t",
+		cpyname: "int64_t",
+		pyfmt:   "O&",
+		pybuf:   "q",
+		pysig:   "file",
+		c2py:    "cgopy_cnv_c2py_file",
+		py2c:    "cgopy_cnv_py2c_file",
+		pychk:   "cgopy_check_file(%s)",
+	}
+}
+
+// addBigType adds a *big.Int or a *big.Float, converted to and from python
+// ints and floats through their decimal text.
+func (sym *symtab) addBigType(pkg *types.Package, obj types.Object, t types.Type, kind symkind, id, n string) {
+	fn := sym.typename(t, nil)
+	kind |= skPointer
+	id = "big" + strings.ToLower(bigType(t))
+	pysig := "int"
+	if id == "bigfloat" {
+		pysig = "float"
+	}
+	sym.syms[fn] = &symbol{
+		gopkg:   pkg,
+		goobj:   obj,
+		gotyp:   t,
+		kind:    kind,
+		id:      id,
+		goname:  n,
+		cgoname: "PyObject*",
+		cpyname: "PyObject",
+		pyfmt:   "O&",
+		pybuf:   "P",
+		pysig:   pysig,
+		c2py:    "cgopy_cnv_c2py_big",
+		py2c:    "cgopy_cnv_py2c_" + id,
+		pychk:   "cgopy_check_" + id + "(%s)",
+	}
+}
+
+// addNetType adds a net.IP or a *url.URL, exchanged with python as its
+// text, None standing for nil. The text received from python is checked by
+// the go side, with net.ParseIP or url.Parse.
+func (sym *symtab) addNetType(pkg *types.Package, obj types.Object, t types.Type, kind symkind, id, n string) {
+	fn := sym.typename(t, nil)
+	id = "net" + strings.ToLower(netType(t))
+	sym.syms[fn] = &symbol{
+		gopkg:   pkg,
+		goobj:   obj,
+		gotyp:   t,
+		kind:    kind,
+		id:      id,
+		goname:  n,
+		cgoname: "PyObject*",
+		cpyname: "PyObject",
+		pyfmt:   "O&",
+		pybuf:   "P",
+		pysig:   "str",
+		c2py:    "cgopy_cnv_c2py_net",
+		py2c:    "cgopy_cnv_py2c_" + id,
+		pychk:   "cgopy_check_net(%s)",
+	}
+}
+
+// netType returns the name, IP or URL, of the type typ if its values are
+// exchanged with python as their text, or "".
+func (sym *symtab) netType(typ types.Type) string {
+	if sym.nets == NetTypesOpaque {
+		return ""
+	}
+	return netType(unalias(typ))
+}
+
+// netTypes returns the names of the net types exchanged as their text by
+// the package, sorted.
+func (sym *symtab) netTypes() []string {
+	var names []string
+	for _, s := range sym.syms {
+		if kind := netType(s.gotyp); kind != "" && s.id == "net"+strings.ToLower(kind) {
+			names = append(names, kind)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// addDurationType adds a time.Duration, exchanged with python as its
+// nanoseconds, converted to and from seconds.
+func (sym *symtab) addDurationType(pkg *types.Package, obj types.Object, t types.Type, kind symkind, id, n string) {
+	fn := sym.typename(t, nil)
+	sym.syms[fn] = &symbol{
+		gopkg:   pkg,
+		goobj:   obj,
+		gotyp:   t,
+		kind:    kind | skBasic,
+		id:      "duration",
+		goname:  n,
+		cgoname: "int64_t",
+		cpyname: "int64_t",
+		pyfmt:   "O&",
+		pybuf:   "q",
+		pysig:   "float",
+		c2py:    "cgopy_cnv_c2py_duration",
+		py2c:    "cgopy_cnv_py2c_duration",
+		pychk:   "cgopy_check_duration(%s)",
+	}
+}
+
+// addConvType adds a type converted by conv, exchanged with python as a
+// value of its basic type.
+func (sym *symtab) addConvType(pkg *types.Package, obj types.Object, t types.Type, kind symkind, id, n string, conv *converter) {
+	fn := sym.typename(t, nil)
+	bsym := sym.symtype(conv.base)
+	sym.syms[fn] = &symbol{
+		gopkg:   pkg,
+		goobj:   obj,
+		gotyp:   t,
+		kind:    kind | skBasic,
+		id:      id,
+		goname:  n,
+		cgoname: bsym.cgoname,
+		cpyname: bsym.cpyname,
+		pyfmt:   "O&",
+		pybuf:   bsym.pybuf,
+		pysig:   bsym.pysig,
+		c2py:    bsym.c2py,
+		py2c:    "cgopy_cnv_py2c_" + id,
+		pychk:   bsym.pychk,
+	}
+}
+
+// conv returns the converter of the type typ, or nil if its values are not
+// converted.
+func (sym *symtab) conv(typ types.Type) *converter {
+	named, ok := unalias(typ).(*types.Named)
+	if !ok {
+		return nil
+	}
+	return sym.convs[named.Obj()]
+}
+
 func (sym *symtab) addSliceType(pkg *types.Package, obj types.Object, t types.Type, kind symkind, id, n string) {
 	fn := sym.typename(t, nil)
 	typ := t.Underlying().(*types.Slice)
@@ -539,17 +911,17 @@ func (sym *symtab) addSliceType(pkg *types.Package, obj types.Object, t types.Ty
 	elt := sym.sym(enam)
 	if elt == nil || elt.goname == "" {
 		eltname := sym.typename(typ.Elem(), pkg)
-		eobj := sym.pkg.Scope().Lookup(eltname)
-		if eobj == nil {
-			panic(fmt.Errorf("could not look-up %q!\n", enam))
+		if eobj := sym.pkg.Scope().Lookup(eltname); eobj != nil {
+			sym.addSymbol(eobj)
+		} else {
+			// unnamed elements, such as the interface{} of a
+			// ...interface{} parameter, are not in scope.
+			sym.addType(nil, typ.Elem())
 		}
-		sym.addSymbol(eobj)
 		elt = sym.sym(enam)
 		if elt == nil {
-			panic(fmt.Errorf(
-				"gopy: could not retrieve slice-elt symbol for %q",
-				enam,
-			))
+			sym.unbind(fn, fmt.Errorf("element: %v", sym.typeErr(typ.Elem())))
+			return
 		}
 	}
 	id = hash(id)
@@ -575,9 +947,27 @@ func (sym *symtab) addStructType(pkg *types.Package, obj types.Object, t types.T
 	fn := sym.typename(t, nil)
 	typ := t.Underlying().(*types.Struct)
 	kind |= skStruct
+	// the symbol is registered before its fields are processed, so
+	// recursive types (through a pointer field) refer to it.
+	ssym := &symbol{
+		gopkg:   pkg,
+		goobj:   obj,
+		gotyp:   t,
+		kind:    kind,
+		id:      id,
+		goname:  n,
+		cgoname: "int32_t",
+		cpyname: "cpy_type_" + id,
+		pyfmt:   "O&",
+		pysig:   "object",
+		c2py:    "cgopy_cnv_c2py_" + id,
+		py2c:    "cgopy_cnv_py2c_" + id,
+		pychk:   fmt.Sprintf("cpy_func_%[1]s_check(%%s)", id),
+	}
+	sym.syms[fn] = ssym
 	pybuf := make([]string, 0, typ.NumFields())
 	for i := 0; i < typ.NumFields(); i++ {
-		if isPrivate(typ.Field(i).Name()) {
+		if !sym.isWrappedField(typ.Field(i)) {
 			continue
 		}
 		ftyp := typ.Field(i).Type()
@@ -586,14 +976,22 @@ func (sym *symtab) addStructType(pkg *types.Package, obj types.Object, t types.T
 			sym.addType(typ.Field(i), ftyp)
 			fsym = sym.symtype(ftyp)
 			if fsym == nil {
-				panic(fmt.Errorf(
-					"gopy: could not add type [%s]",
-					ftyp.String(),
-				))
+				// the field is not exposed, as the struct is.
+				continue
 			}
 		}
 		pybuf = append(pybuf, fsym.pybuf)
 	}
+	ssym.pybuf = strings.Join(pybuf, "")
+}
+
+func (sym *symtab) addSignatureType(pkg *types.Package, obj types.Object, t types.Type, kind symkind, id, n string) {
+	fn := sym.typename(t, nil)
+	//typ := t.(*types.Signature)
+	kind |= skSignature
+	if (kind&skNamed) == 0 && !isAliased(t) {
+		id = hash(id)
+	}
 	sym.syms[fn] = &symbol{
 		gopkg:   pkg,
 		goobj:   obj,
@@ -601,23 +999,36 @@ func (sym *symtab) addStructType(pkg *types.Package, obj types.Object, t types.T
 		kind:    kind,
 		id:      id,
 		goname:  n,
-		cgoname: "int32_t", // FIXME(sbinet) define a proper C-type for refs?
+		cgoname: "int32_t", // handle to a func value
 		cpyname: "cpy_type_" + id,
 		pyfmt:   "O&",
-		pybuf:   strings.Join(pybuf, ""),
-		pysig:   "object",
+		pybuf:   "P",
+		pysig:   "callable",
 		c2py:    "cgopy_cnv_c2py_" + id,
 		py2c:    "cgopy_cnv_py2c_" + id,
 		pychk:   fmt.Sprintf("cpy_func_%[1]s_check(%%s)", id),
 	}
 }
 
-func (sym *symtab) addSignatureType(pkg *types.Package, obj types.Object, t types.Type, kind symkind, id, n string) {
+// addChanType adds a named chan type, held by pointer.
+func (sym *symtab) addChanType(pkg *types.Package, obj types.Object, t types.Type, kind symkind, id, n string) {
 	fn := sym.typename(t, nil)
-	//typ := t.(*types.Signature)
-	kind |= skSignature
-	if (kind & skNamed) == 0 {
-		id = hash(id)
+	typ := t.Underlying().(*types.Chan)
+	kind |= skChan
+	enam := sym.typename(typ.Elem(), nil)
+	elt := sym.sym(enam)
+	if elt == nil || elt.goname == "" {
+		eltname := sym.typename(typ.Elem(), pkg)
+		if eobj := sym.pkg.Scope().Lookup(eltname); eobj != nil {
+			sym.addSymbol(eobj)
+		} else {
+			sym.addType(nil, typ.Elem())
+		}
+		elt = sym.sym(enam)
+		if elt == nil {
+			sym.unbind(fn, fmt.Errorf("element: %v", sym.typeErr(typ.Elem())))
+			return
+		}
 	}
 	sym.syms[fn] = &symbol{
 		gopkg:   pkg,
@@ -626,11 +1037,11 @@ func (sym *symtab) addSignatureType(pkg *types.Package, obj types.Object, t type
 		kind:    kind,
 		id:      id,
 		goname:  n,
-		cgoname: "cgo_type_" + id,
+		cgoname: "int32_t", // handle to a chan value
 		cpyname: "cpy_type_" + id,
 		pyfmt:   "O&",
 		pybuf:   "P",
-		pysig:   "callable",
+		pysig:   "object",
 		c2py:    "cgopy_cnv_c2py_" + id,
 		py2c:    "cgopy_cnv_py2c_" + id,
 		pychk:   fmt.Sprintf("cpy_func_%[1]s_check(%%s)", id),
@@ -664,10 +1075,8 @@ func (sym *symtab) addPointerType(pkg *types.Package, obj types.Object, t types.
 		sym.addType(obj, etyp)
 		esym = sym.symtype(etyp)
 		if esym == nil {
-			panic(fmt.Errorf(
-				"gopy: could not retrieve symbol for %q",
-				sym.typename(etyp, nil),
-			))
+			sym.unbind(fn, sym.typeErr(etyp))
+			return
 		}
 	}
 
@@ -675,6 +1084,8 @@ func (sym *symtab) addPointerType(pkg *types.Package, obj types.Object, t types.
 	if true {
 		elm := *esym
 		elm.kind |= skPointer
+		elm.goobj = nil
+		elm.gotyp = t
 		sym.syms[fn] = &elm
 	} else {
 		id = hash(id)
@@ -699,10 +1110,10 @@ func (sym *symtab) addPointerType(pkg *types.Package, obj types.Object, t types.
 
 func (sym *symtab) addInterfaceType(pkg *types.Package, obj types.Object, t types.Type, kind symkind, id, n string) {
 	fn := sym.typename(t, nil)
-	typ := t.Underlying().(*types.Interface)
+	//typ := t.Underlying().(*types.Interface)
 	kind |= skInterface
 	// special handling of 'error'
-	if isErrorType(typ) {
+	if isErrorType(t) {
 		return
 	}
 
@@ -713,7 +1124,7 @@ func (sym *symtab) addInterfaceType(pkg *types.Package, obj types.Object, t type
 		kind:    kind,
 		id:      id,
 		goname:  n,
-		cgoname: "cgo_type_" + id,
+		cgoname: "int32_t",
 		cpyname: "cpy_type_" + id,
 		pyfmt:   "O&",
 		pybuf:   "P",
@@ -839,7 +1250,7 @@ func init() {
 			goname:  "int64",
 			cpyname: "int64_t",
 			cgoname: "GoInt64",
-			pyfmt:   "k",
+			pyfmt:   "l",
 			pybuf:   "q",
 			pysig:   "long",
 			c2py:    "cgopy_cnv_c2py_int64",
@@ -1027,15 +1438,17 @@ func init() {
 			gopkg:   look("error").Pkg(),
 			goobj:   look("error"),
 			gotyp:   look("error").Type(),
-			kind:    skType | skInterface,
+			kind:    skType | skNamed | skInterface,
+			id:      "error",
 			goname:  "error",
-			cgoname: "GoInterface",
-			cpyname: "GoInterface",
+			cgoname: "int32_t",
+			cpyname: "cpy_type_error",
 			pyfmt:   "O&",
-			pybuf:   "PP",
-			pysig:   "object",
+			pybuf:   "P",
+			pysig:   "error",
 			c2py:    "cgopy_cnv_c2py_error",
 			py2c:    "cgopy_cnv_py2c_error",
+			pychk:   "cpy_func_error_check(%s)",
 		},
 	}
 
@@ -1048,7 +1461,7 @@ func init() {
 			goname:  "int",
 			cpyname: "int64_t",
 			cgoname: "GoInt",
-			pyfmt:   "k",
+			pyfmt:   "l",
 			pybuf:   "q",
 			pysig:   "int",
 			c2py:    "cgopy_cnv_c2py_int",
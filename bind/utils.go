@@ -8,21 +8,1196 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
 	"go/types"
 	"io"
 	"os/exec"
 	"regexp"
 	"sort"
+	"strings"
 )
 
+// isErrorType returns whether typ is the error interface, or an alias of
+// it. Named types implementing error, such as a MyError struct, are values
+// of their own: they are never taken for the comma-error result.
 func isErrorType(typ types.Type) bool {
-	return typ == types.Universe.Lookup("error").Type()
+	return types.Unalias(typ) == types.Universe.Lookup("error").Type()
+}
+
+// isOkType returns whether typ is the bool of comma-ok results.
+func isOkType(typ types.Type) bool {
+	return types.Unalias(typ) == types.Universe.Lookup("bool").Type()
+}
+
+// isInstance returns whether typ is an instantiation of a generic type,
+// such as List[int].
+func isInstance(typ types.Type) bool {
+	named, ok := typ.(*types.Named)
+	return ok && named.TypeArgs().Len() > 0
+}
+
+// isGeneric returns whether typ is a generic type, with type parameters
+// but no type arguments.
+func isGeneric(typ types.Type) bool {
+	named, ok := typ.(*types.Named)
+	return ok && named.TypeParams().Len() > 0 && named.TypeArgs().Len() == 0
+}
+
+// isAliased returns whether typ is wrapped under a generated type name:
+// anonymous structs, unnamed funcs, unnamed arrays (the rows of
+// multi-dimensional arrays), unnamed slices and instantiated generic types
+// have no name of their own which could be used in python.
+// The slices of the other elements, such as the []interface{} of a
+// ...interface{} parameter, are only exchanged item by item.
+// Unnamed maps are aliased too, but for the map[string]interface{}
+// exchanged as a dict.
+func isAliased(typ types.Type) bool {
+	switch typ := unalias(typ).(type) {
+	case *types.Struct, *types.Signature, *types.Array:
+		return true
+	case *types.Slice:
+		return checkElemSeen(typ.Elem(), make(map[types.Type]bool)) == nil
+	case *types.Map:
+		return !isDictType(typ) && checkMapSeen(typ, make(map[types.Type]bool)) == nil
+	}
+	return isInstance(typ)
+}
+
+// isWrappableSig returns whether all the parameters and results of sig
+// can be exchanged with python.
+// wrapped holds the named types for which a python type is generated.
+func isWrappableSig(sig *types.Signature, wrapped map[*types.TypeName]bool) bool {
+	if sig.Variadic() {
+		return false
+	}
+	res := sig.Results()
+	switch res.Len() {
+	case 0, 1:
+	case 2:
+		if isErrorType(res.At(0).Type()) || !isErrorType(res.At(1).Type()) && !isOkType(res.At(1).Type()) {
+			return false
+		}
+	default:
+		return false
+	}
+	for _, tuple := range []*types.Tuple{sig.Params(), res} {
+		for i := 0; i < tuple.Len(); i++ {
+			if !isWrappable(tuple.At(i).Type(), wrapped) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isWrappable returns whether values of type typ can be exchanged with python.
+func isWrappable(typ types.Type, wrapped map[*types.TypeName]bool) bool {
+	switch typ := unalias(typ).(type) {
+	case *types.Basic:
+		if typ.Name() == "rune" {
+			// runes have no C type nor converter.
+			return false
+		}
+		switch typ.Kind() {
+		case types.Bool, types.Int, types.Int8, types.Int16, types.Int32, types.Int64,
+			types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64,
+			types.Float32, types.Float64, types.String:
+			return true
+		}
+	case *types.Named:
+		// instantiated generic types are always wrapped, under a
+		// generated type name.
+		return wrapped[typ.Obj()] || isInstance(typ) || isDurationType(typ)
+	case *types.Signature:
+		// unnamed funcs are wrapped under a generated type name.
+		return isWrappableSig(typ, wrapped)
+	case *types.Slice:
+		// byte slices are wrapped under a generated type name, with the
+		// buffer protocol.
+		return isByteSeqType(typ)
+	case *types.Map:
+		return isDictType(typ)
+	case *types.Interface:
+		return typ.Empty()
+	case *types.Pointer:
+		if isFileType(typ) || isBigType(typ) {
+			return true
+		}
+		named, ok := typ.Elem().(*types.Named)
+		if !ok {
+			return false
+		}
+		_, ok = named.Underlying().(*types.Struct)
+		return ok && (wrapped[named.Obj()] || isInstance(named))
+	}
+	return false
+}
+
+// checkType returns an error if values of type typ can not be exchanged
+// with python.
+// Named structs, interfaces and funcs are always wrapped: their fields and
+// methods are checked one by one.
+func checkType(typ types.Type) error {
+	return checkTypeSeen(typ, make(map[types.Type]bool))
+}
+
+func checkTypeSeen(typ types.Type, seen map[types.Type]bool) error {
+	switch typ := unalias(typ).(type) {
+	case *types.Basic:
+		if typ.Name() == "rune" {
+			// runes have no C type nor converter.
+			break
+		}
+		switch typ.Kind() {
+		case types.Bool, types.Int, types.Int8, types.Int16, types.Int32, types.Int64,
+			types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64,
+			types.Float32, types.Float64, types.String:
+			return nil
+		}
+	case *types.Named:
+		if isInternal(typ.Obj().Pkg()) {
+			return errInternal(typ)
+		}
+		if seen[typ] {
+			// elements of named arrays and slices form a chain: a
+			// type seen twice is an element of itself.
+			return fmt.Errorf("recursive type %s", typeString(typ))
+		}
+		seen[typ] = true
+		if args := typ.TypeArgs(); args.Len() > 0 {
+			// instantiations are wrapped into a generated named type,
+			// which is named after their type arguments.
+			for i := 0; i < args.Len(); i++ {
+				if err := checkType(args.At(i)); err != nil {
+					return fmt.Errorf("type argument %s: %v", typeString(args.At(i)), err)
+				}
+			}
+		} else if isGeneric(typ) {
+			return fmt.Errorf("unsupported generic type %s", typeString(typ))
+		}
+		switch u := typ.Underlying().(type) {
+		case *types.Struct, *types.Interface, *types.Signature:
+			return nil
+		case *types.Basic:
+			if checkTypeSeen(u, seen) == nil {
+				return nil
+			}
+		case *types.Array:
+			return checkElemSeen(u.Elem(), seen)
+		case *types.Slice:
+			return checkElemSeen(u.Elem(), seen)
+		case *types.Map:
+			return checkMapSeen(u, seen)
+		case *types.Chan:
+			// elements are exchanged through the generated Send and
+			// Recv methods, as parameters and results.
+			return checkTypeSeen(u.Elem(), seen)
+		}
+	case *types.Slice:
+		// unnamed slices are wrapped into a generated named type, as
+		// named ones.
+		return checkElemSeen(typ.Elem(), seen)
+	case *types.Pointer:
+		if named, ok := typ.Elem().(*types.Named); ok {
+			if isInternal(named.Obj().Pkg()) {
+				return errInternal(named)
+			}
+			if _, ok := named.Underlying().(*types.Struct); ok {
+				return nil
+			}
+		}
+	case *types.Map:
+		if isDictType(typ) {
+			return nil
+		}
+		// other maps are wrapped into a generated named type, as named
+		// ones.
+		return checkMapSeen(typ, seen)
+	case *types.Interface:
+		if typ.Empty() {
+			return nil
+		}
+	case *types.Struct:
+		// anonymous structs are wrapped into a generated named type, the
+		// struct type literal being spelled out in the generated go code.
+		for i := 0; i < typ.NumFields(); i++ {
+			f := typ.Field(i)
+			if !f.Exported() {
+				return fmt.Errorf("anonymous struct with unexported field %s", f.Name())
+			}
+			if typ.Tag(i) != "" {
+				return fmt.Errorf("anonymous struct with tagged field %s", f.Name())
+			}
+			if err := checkType(f.Type()); err != nil {
+				return fmt.Errorf("field %s: %v", f.Name(), err)
+			}
+		}
+		return nil
+	case *types.Signature:
+		// unnamed funcs are wrapped into a generated named type too,
+		// holding the func value.
+		if err := checkSig(typ); err != nil {
+			return fmt.Errorf("%s: %v", typeString(typ), err)
+		}
+		if hasOpaqueType(typ) {
+			// the generated type spells its parameters and results out.
+			return errInternal(typ)
+		}
+		if isTuple(typ.Results()) {
+			// func values are called with a single result, or a
+			// comma-error or comma-ok.
+			return fmt.Errorf("%s: tuple results of func values are not supported", typeString(typ))
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported type %s", typeString(typ))
+}
+
+// hasOpaqueType returns whether a parameter or a result of sig is of an
+// opaque type, held as an interface{} value.
+func hasOpaqueType(sig *types.Signature) bool {
+	for _, tuple := range []*types.Tuple{sig.Params(), sig.Results()} {
+		for i := 0; i < tuple.Len(); i++ {
+			if isOpaqueType(tuple.At(i).Type()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// errInternal returns the error of typ, using the types of internal
+// packages elsewhere than in the signatures of funcs and methods.
+func errInternal(typ types.Type) error {
+	return fmt.Errorf("unsupported type %s: the types of internal packages are only passed to and returned by funcs and methods", typeString(typ))
+}
+
+// checkElemSeen checks the element type of an array or slice, which
+// must be a basic or a named type, or an unnamed array of those.
+func checkElemSeen(elem types.Type, seen map[types.Type]bool) error {
+	switch elem := unalias(elem).(type) {
+	case *types.Basic, *types.Named:
+		return checkTypeSeen(elem, seen)
+	case *types.Array:
+		return checkElemSeen(elem.Elem(), seen)
+	}
+	return fmt.Errorf("unsupported type %s", typeString(elem))
+}
+
+// checkMapSeen checks the key and element types of a map. Keys must be
+// basic types, named or not, or comparable named structs, to be converted
+// from the python keys: the elements are checked as the ones of arrays and
+// slices.
+func checkMapSeen(typ *types.Map, seen map[types.Type]bool) error {
+	switch key := unalias(typ.Key()).(type) {
+	case *types.Basic:
+		if err := checkType(key); err != nil {
+			return err
+		}
+	case *types.Named:
+		switch key.Underlying().(type) {
+		case *types.Basic, *types.Struct:
+			// keys are not elements: the elements may be of the
+			// type of the keys.
+			if err := checkType(key); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported map key type %s", typeString(key))
+		}
+	default:
+		return fmt.Errorf("unsupported map key type %s", typeString(key))
+	}
+	return checkElemSeen(typ.Elem(), seen)
+}
+
+// checkSig returns an error if funcs or methods with signature sig can not
+// be called from python.
+func checkSig(sig *types.Signature) error {
+	if sig.TypeParams().Len() > 0 {
+		return fmt.Errorf("unsupported generic function")
+	}
+	res := sig.Results()
+	for i := 0; i < res.Len()-1; i++ {
+		if isErrorType(res.At(i).Type()) {
+			return fmt.Errorf("result %s: only the last result may be an error", varName(res.At(i), i))
+		}
+	}
+
+	params := sig.Params()
+	for i := 0; i < params.Len(); i++ {
+		typ := params.At(i).Type()
+		if sig.Variadic() && i == params.Len()-1 {
+			typ = typ.(*types.Slice).Elem()
+		} else if isOpaqueType(typ) {
+			// held as an opaque handle.
+			continue
+		}
+		if err := checkType(typ); err != nil {
+			return fmt.Errorf("parameter %s: %v", varName(params.At(i), i), err)
+		}
+		if err := checkLock(typ); err != nil {
+			// the go call would be made with a copy of the value.
+			return fmt.Errorf("parameter %s: %v", varName(params.At(i), i), err)
+		}
+	}
+	for i := 0; i < res.Len(); i++ {
+		if isOpaqueType(res.At(i).Type()) {
+			continue
+		}
+		if err := checkType(res.At(i).Type()); err != nil {
+			return fmt.Errorf("result %s: %v", varName(res.At(i), i), err)
+		}
+	}
+	return nil
+}
+
+// checkObject returns an error if the package-level object obj can not be
+// bound.
+func checkObject(obj types.Object) error {
+	switch obj := obj.(type) {
+	case *types.Const:
+		typ := types.Default(obj.Type())
+		if err := checkType(typ); err != nil {
+			return err
+		}
+		if obj.Val().Kind() == constant.Int {
+			if _, exact := constant.Int64Val(obj.Val()); !exact {
+				return fmt.Errorf("constant %s overflows %s", obj.Val(), typeString(typ))
+			}
+		}
+		return nil
+	case *types.Var:
+		if err := checkType(obj.Type()); err != nil {
+			return err
+		}
+		return checkLock(obj.Type())
+	case *types.Func:
+		return checkSig(obj.Type().(*types.Signature))
+	case *types.TypeName:
+		return checkType(obj.Type())
+	}
+	return fmt.Errorf("unsupported object %v", obj)
+}
+
+// objectKind returns the kind of the package-level object obj, for
+// diagnostics.
+func objectKind(obj types.Object) string {
+	switch obj.(type) {
+	case *types.Const:
+		return "constant"
+	case *types.Var:
+		return "variable"
+	case *types.Func:
+		return "function"
+	case *types.TypeName:
+		return "type"
+	}
+	return "object"
+}
+
+// isWrappedField returns whether the struct field f is exposed to python.
+func isWrappedField(f *types.Var) bool {
+	return f.Exported() && checkType(f.Type()) == nil && checkLock(f.Type()) == nil
+}
+
+// lockPath returns the name of the lock held by the values of type typ,
+// such as sync.Mutex, or "" if they hold none. As for go vet, locks are the
+// structs of the sync and sync/atomic packages, and the types whose pointer,
+// but not value, has Lock and Unlock methods.
+func lockPath(typ types.Type) string {
+	typ = unalias(typ)
+	if arr, ok := typ.Underlying().(*types.Array); ok {
+		return lockPath(arr.Elem())
+	}
+	st, ok := typ.Underlying().(*types.Struct)
+	if !ok {
+		return ""
+	}
+	named, _ := typ.(*types.Named)
+	if named != nil {
+		if pkg := named.Obj().Pkg(); pkg != nil && (pkg.Path() == "sync" || pkg.Path() == "sync/atomic") {
+			return typeString(named)
+		}
+	}
+	// the lock held by a field is reported, rather than the struct
+	// embedding it.
+	for i := 0; i < st.NumFields(); i++ {
+		if path := lockPath(st.Field(i).Type()); path != "" {
+			return path
+		}
+	}
+	if named != nil && hasMethods(types.NewPointer(named), "Lock", "Unlock") && !hasMethods(named, "Lock", "Unlock") {
+		return typeString(named)
+	}
+	return ""
+}
+
+// cgoPath returns the name of the C value held by the values of type typ,
+// such as C.struct_point, or "" if they hold none. As for locks, such values
+// are not copied: C code may keep pointers to them, or free the C storage
+// they point to. C values are C structs, unions and arrays, and pointers to
+// C types, which cgo declares as the _Ctype_ types of the package using it.
+func cgoPath(typ types.Type) string {
+	if ptr, ok := unalias(typ).(*types.Pointer); ok {
+		if name := cgoName(ptr.Elem()); name != "" {
+			return "*" + name
+		}
+		return ""
+	}
+	switch u := unalias(typ).Underlying().(type) {
+	case *types.Array:
+		if name := cgoName(typ); name != "" {
+			return name
+		}
+		return cgoPath(u.Elem())
+	case *types.Struct:
+		if name := cgoName(typ); name != "" {
+			return name
+		}
+		for i := 0; i < u.NumFields(); i++ {
+			if path := cgoPath(u.Field(i).Type()); path != "" {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// cgoName returns the C name of the type declared by cgo for typ, such as
+// C.int for _Ctype_int, or "" if typ is not a C type.
+func cgoName(typ types.Type) string {
+	var obj *types.TypeName
+	switch typ := typ.(type) {
+	case *types.Alias:
+		obj = typ.Obj()
+	case *types.Named:
+		obj = typ.Obj()
+	default:
+		return ""
+	}
+	if !strings.HasPrefix(obj.Name(), "_Ctype_") {
+		return ""
+	}
+	return "C." + strings.TrimPrefix(obj.Name(), "_Ctype_")
+}
+
+// noCopyPath returns the lock, or the C value, held by the values of type
+// typ, or "" if they hold none: they are only handled by pointer.
+func noCopyPath(typ types.Type) string {
+	if path := lockPath(typ); path != "" {
+		return path
+	}
+	return cgoPath(typ)
+}
+
+// hasMethods returns whether the method set of typ holds all the methods
+// named names.
+func hasMethods(typ types.Type, names ...string) bool {
+	mset := types.NewMethodSet(typ)
+	for _, name := range names {
+		if mset.Lookup(nil, name) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// embeddedIface returns the selector of the embedded interface field, e.g.
+// Inner.Reader, through which the method selected by sel is promoted to
+// the struct type of its receiver, or "" if it is not promoted through an
+// interface.
+func embeddedIface(sel *types.Selection) string {
+	index := sel.Index()
+	t := sel.Recv()
+	var path []string
+	for _, i := range index[:len(index)-1] {
+		if ptr, ok := t.Underlying().(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		st, ok := t.Underlying().(*types.Struct)
+		if !ok {
+			return ""
+		}
+		f := st.Field(i)
+		path = append(path, f.Name())
+		t = f.Type()
+		if types.IsInterface(t) {
+			return strings.Join(path, ".")
+		}
+	}
+	return ""
+}
+
+// selectorConflict is an exported method promoted through the embedded
+// fields of a struct, which is either ambiguous, or shadowed by a field or
+// method of the same name at a shallower depth.
+type selectorConflict struct {
+	name      string
+	ambiguous bool     // true if several fields or methods are at the shallowest depth
+	paths     []string // selectors of the ambiguous or shadowed entities, e.g. A.B.Close
+}
+
+// selectorConflicts returns the conflicts of the exported methods promoted
+// through the embedded fields of the struct type typ, in the order of the
+// fields. Following the selector rules of go, the field or method at the
+// shallowest depth wins, and several at that depth make the selector
+// ambiguous: the method is then not in the method set of typ.
+func selectorConflicts(typ types.Type) []selectorConflict {
+	if _, ok := typ.Underlying().(*types.Struct); !ok {
+		return nil
+	}
+	type entry struct {
+		depth int
+		path  string
+		meth  bool
+	}
+	type embedded struct {
+		typ  types.Type
+		path string
+	}
+	var names []string
+	entries := make(map[string][]entry)
+	add := func(name string, e entry) {
+		if _, ok := entries[name]; !ok {
+			names = append(names, name)
+		}
+		entries[name] = append(entries[name], e)
+	}
+	join := func(path, name string) string {
+		if path == "" {
+			return name
+		}
+		return path + "." + name
+	}
+
+	seen := make(map[*types.Named]int) // depth of the named types
+	cur := []embedded{{typ: typ}}
+	for depth := 0; len(cur) > 0; depth++ {
+		var next []embedded
+		for _, e := range cur {
+			t := e.typ
+			if ptr, ok := t.(*types.Pointer); ok {
+				t = ptr.Elem()
+			}
+			if named, ok := t.(*types.Named); ok {
+				for i := 0; i < named.NumMethods(); i++ {
+					m := named.Method(i)
+					add(m.Name(), entry{depth, join(e.path, m.Name()), true})
+				}
+				if d, ok := seen[named]; ok && d < depth {
+					// embedded at a shallower depth, whose fields and
+					// methods win: the embedded fields are not walked
+					// again, which would never end for recursive types.
+					continue
+				}
+				seen[named] = depth
+			}
+			switch u := t.Underlying().(type) {
+			case *types.Interface:
+				// the methods of interfaces are those of their
+				// underlying type.
+				for i := 0; i < u.NumMethods(); i++ {
+					m := u.Method(i)
+					add(m.Name(), entry{depth, join(e.path, m.Name()), true})
+				}
+			case *types.Struct:
+				for i := 0; i < u.NumFields(); i++ {
+					f := u.Field(i)
+					add(f.Name(), entry{depth, join(e.path, f.Name()), false})
+					if f.Embedded() {
+						next = append(next, embedded{f.Type(), join(e.path, f.Name())})
+					}
+				}
+			}
+		}
+		cur = next
+	}
+
+	var conflicts []selectorConflict
+	for _, name := range names {
+		if !token.IsExported(name) {
+			continue
+		}
+		es := entries[name]
+		var first, deeper []string
+		meth := false
+		for _, e := range es {
+			meth = meth || e.meth
+			switch {
+			case e.depth == es[0].depth:
+				first = append(first, e.path)
+			case e.meth:
+				deeper = append(deeper, e.path)
+			}
+		}
+		switch {
+		case !meth:
+			continue
+		case len(first) > 1:
+			conflicts = append(conflicts, selectorConflict{name: name, ambiguous: true, paths: first})
+		case len(deeper) > 0:
+			conflicts = append(conflicts, selectorConflict{name: name, paths: deeper})
+		}
+	}
+	return conflicts
+}
+
+// checkLock returns an error if the values of type typ hold a lock, or a C
+// value, which must not be copied.
+func checkLock(typ types.Type) error {
+	kind, path := "lock", lockPath(typ)
+	if path == "" {
+		kind, path = "C", cgoPath(typ)
+	}
+	switch path {
+	case "":
+		return nil
+	case typeString(typ):
+		return fmt.Errorf("copies %s value: %s", kind, path)
+	default:
+		return fmt.Errorf("copies %s value: %s contains %s", kind, typeString(typ), path)
+	}
+}
+
+// varName returns the name of the i-th parameter or result v, for diagnostics.
+func varName(v *types.Var, i int) string {
+	if v.Name() == "" {
+		return fmt.Sprintf("#%d", i)
+	}
+	return v.Name()
+}
+
+// funcSignature returns the signature of the func or method f, as
+// rendered by go doc: with the receiver of methods, all the results and
+// package-qualified type names.
+//
+//	func (p *hi.Person) Work(in int) (int, error)
+func funcSignature(f *types.Func) string {
+	qf := func(pkg *types.Package) string { return pkg.Name() }
+	sig := f.Type().(*types.Signature)
+
+	var buf bytes.Buffer
+	buf.WriteString("func ")
+	if recv := sig.Recv(); recv != nil {
+		buf.WriteString("(")
+		if recv.Name() != "" {
+			buf.WriteString(recv.Name() + " ")
+		}
+		buf.WriteString(types.TypeString(recv.Type(), qf) + ") ")
+	}
+	buf.WriteString(f.Name())
+	types.WriteSignature(&buf, sig, qf)
+	return buf.String()
+}
+
+// unalias returns typ with the type aliases it refers to, at any depth,
+// replaced by their target. typ is returned as is when it holds no alias.
+func unalias(typ types.Type) types.Type {
+	switch t := types.Unalias(typ).(type) {
+	case *types.Pointer:
+		if elem := unalias(t.Elem()); elem != t.Elem() {
+			return types.NewPointer(elem)
+		}
+		return t
+	case *types.Slice:
+		if elem := unalias(t.Elem()); elem != t.Elem() {
+			return types.NewSlice(elem)
+		}
+		return t
+	case *types.Array:
+		if elem := unalias(t.Elem()); elem != t.Elem() {
+			return types.NewArray(elem, t.Len())
+		}
+		return t
+	case *types.Map:
+		key, elem := unalias(t.Key()), unalias(t.Elem())
+		if key != t.Key() || elem != t.Elem() {
+			return types.NewMap(key, elem)
+		}
+		return t
+	case *types.Chan:
+		if elem := unalias(t.Elem()); elem != t.Elem() {
+			return types.NewChan(t.Dir(), elem)
+		}
+		return t
+	case *types.Signature:
+		params, pok := unaliasTuple(t.Params())
+		results, rok := unaliasTuple(t.Results())
+		if pok && rok {
+			return t
+		}
+		return types.NewSignatureType(t.Recv(), nil, nil, params, results, t.Variadic())
+	case *types.Struct:
+		fields := make([]*types.Var, t.NumFields())
+		tags := make([]string, t.NumFields())
+		same := true
+		for i := range fields {
+			f := t.Field(i)
+			fields[i], tags[i] = f, t.Tag(i)
+			if ft := unalias(f.Type()); ft != f.Type() {
+				fields[i] = types.NewField(f.Pos(), f.Pkg(), f.Name(), ft, f.Embedded())
+				same = false
+			}
+		}
+		if same {
+			return t
+		}
+		return types.NewStruct(fields, tags)
+	default:
+		return t
+	}
+}
+
+// unaliasTuple returns tup with its types unaliased, and whether it is
+// unchanged.
+func unaliasTuple(tup *types.Tuple) (*types.Tuple, bool) {
+	if tup == nil {
+		return nil, true
+	}
+	vars := make([]*types.Var, tup.Len())
+	same := true
+	for i := range vars {
+		v := tup.At(i)
+		vars[i] = v
+		if vt := unalias(v.Type()); vt != v.Type() {
+			vars[i] = types.NewParam(v.Pos(), v.Pkg(), v.Name(), vt)
+			same = false
+		}
+	}
+	if same {
+		return tup, true
+	}
+	return types.NewTuple(vars...), false
+}
+
+// typeString returns the name of typ, qualified by package names.
+func typeString(typ types.Type) string {
+	return types.TypeString(typ, func(pkg *types.Package) string {
+		return pkg.Name()
+	})
+}
+
+// embeddedName returns the name of the field embedding the type expr: the
+// name of the type, without its package or type arguments.
+func embeddedName(expr ast.Expr) string {
+	switch x := expr.(type) {
+	case *ast.Ident:
+		return x.Name
+	case *ast.StarExpr:
+		return embeddedName(x.X)
+	case *ast.SelectorExpr:
+		return x.Sel.Name
+	case *ast.IndexExpr:
+		return embeddedName(x.X)
+	case *ast.IndexListExpr:
+		return embeddedName(x.X)
+	}
+	return ""
+}
+
+// hasDirective returns whether the doc comment of decl holds the
+// //name directive, e.g. //gopy:blocking.
+func hasDirective(decl *ast.FuncDecl, name string) bool {
+	return decl != nil && hasDocDirective(decl.Doc, name)
+}
+
+// hasDocDirective returns whether the doc comment doc holds the //name
+// directive.
+func hasDocDirective(doc *ast.CommentGroup, name string) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == "//"+name {
+			return true
+		}
+	}
+	return false
+}
+
+// directiveArg returns the argument of the //name directive held by the
+// doc comment of decl, e.g. T for //gopy:static T, and whether there is one.
+func directiveArg(decl *ast.FuncDecl, name string) (string, bool) {
+	if decl == nil || decl.Doc == nil {
+		return "", false
+	}
+	for _, c := range decl.Doc.List {
+		text := strings.TrimSpace(c.Text)
+		if strings.HasPrefix(text, "//"+name+" ") {
+			return strings.TrimSpace(text[len("//"+name):]), true
+		}
+	}
+	return "", false
+}
+
+// directiveArgs returns the arguments of all the //name directives of the
+// doc comment doc, e.g. io.Reader and fmt.Stringer for
+//
+//	//gopy:implements io.Reader
+//	//gopy:implements fmt.Stringer
+func directiveArgs(doc *ast.CommentGroup, name string) []string {
+	if doc == nil {
+		return nil
+	}
+	var args []string
+	for _, c := range doc.List {
+		text := strings.TrimSpace(c.Text)
+		if strings.HasPrefix(text, "//"+name+" ") {
+			args = append(args, strings.Fields(text[len("//"+name):])...)
+		}
+	}
+	return args
+}
+
+// errorsAsTarget returns the type of the target of errors.As finding the
+// values of type typ in an error chain: typ itself for interfaces and types
+// implementing error, *typ for types whose pointers do, or nil.
+func errorsAsTarget(typ types.Type) types.Type {
+	errtyp := types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+	switch {
+	case types.IsInterface(typ), types.Implements(typ, errtyp):
+		return typ
+	case types.Implements(types.NewPointer(typ), errtyp):
+		return types.NewPointer(typ)
+	}
+	return nil
+}
+
+// isStringSlice returns whether typ is a []string, exchanged with python
+// as a list of str by the parameters and results of functions.
+func isStringSlice(typ types.Type) bool {
+	s, ok := unalias(typ).(*types.Slice)
+	if !ok {
+		return false
+	}
+	elem, ok := unalias(s.Elem()).(*types.Basic)
+	return ok && elem.Kind() == types.String
+}
+
+// isDictType returns whether typ is a map[string]interface{}, exchanged
+// with python as a dict.
+func isDictType(typ types.Type) bool {
+	m, ok := typ.(*types.Map)
+	if !ok {
+		return false
+	}
+	key, ok := m.Key().(*types.Basic)
+	if !ok || key.Kind() != types.String {
+		return false
+	}
+	elem, ok := m.Elem().(*types.Interface)
+	return ok && elem.Empty()
+}
+
+// isFileType returns whether typ is an *os.File, exchanged with python as
+// a file descriptor.
+func isFileType(typ types.Type) bool {
+	ptr, ok := typ.(*types.Pointer)
+	if !ok {
+		return false
+	}
+	named, ok := ptr.Elem().(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "os" && obj.Name() == "File"
+}
+
+// isInternal returns whether pkg is an internal package, which the
+// generated package can not import.
+func isInternal(pkg *types.Package) bool {
+	if pkg == nil {
+		return false
+	}
+	for _, elem := range strings.Split(pkg.Path(), "/") {
+		if elem == "internal" {
+			return true
+		}
+	}
+	return false
+}
+
+// isOpaqueType returns whether typ is a struct or an interface type of an
+// internal package, or a pointer to such a struct: its values are held by
+// the generated package as interface{} values, their methods being called
+// through reflection.
+func isOpaqueType(typ types.Type) bool {
+	typ = unalias(typ)
+	if ptr, ok := typ.(*types.Pointer); ok {
+		named, ok := unalias(ptr.Elem()).(*types.Named)
+		if !ok {
+			return false
+		}
+		_, ok = named.Underlying().(*types.Struct)
+		return ok && isInternal(named.Obj().Pkg())
+	}
+	named, ok := typ.(*types.Named)
+	if !ok || !isInternal(named.Obj().Pkg()) {
+		return false
+	}
+	switch named.Underlying().(type) {
+	case *types.Struct, *types.Interface:
+		return true
+	}
+	return false
+}
+
+// bigType returns the name, Int or Float, of the math/big type typ points
+// to, or "" if typ is not a *big.Int or a *big.Float.
+// Those are exchanged with python as the decimal text of their values.
+func bigType(typ types.Type) string {
+	ptr, ok := typ.(*types.Pointer)
+	if !ok {
+		return ""
+	}
+	named, ok := ptr.Elem().(*types.Named)
+	if !ok {
+		return ""
+	}
+	obj := named.Obj()
+	if obj.Pkg() == nil || obj.Pkg().Path() != "math/big" {
+		return ""
+	}
+	switch obj.Name() {
+	case "Int", "Float":
+		return obj.Name()
+	}
+	return ""
+}
+
+// isBigType returns whether typ is a *big.Int or a *big.Float.
+func isBigType(typ types.Type) bool {
+	return bigType(typ) != ""
+}
+
+// netType returns the name, IP or URL, of the type typ if it is a net.IP or
+// a *url.URL, or "" otherwise.
+// Those are exchanged with python as their text, unless bound with
+// -nettypes=opaque.
+func netType(typ types.Type) string {
+	if ptr, ok := typ.(*types.Pointer); ok {
+		named, ok := ptr.Elem().(*types.Named)
+		if !ok {
+			return ""
+		}
+		obj := named.Obj()
+		if obj.Pkg() != nil && obj.Pkg().Path() == "net/url" && obj.Name() == "URL" {
+			return "URL"
+		}
+		return ""
+	}
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return ""
+	}
+	obj := named.Obj()
+	if obj.Pkg() != nil && obj.Pkg().Path() == "net" && obj.Name() == "IP" {
+		return "IP"
+	}
+	return ""
+}
+
+// netGoType returns the go type of the net type named kind, IP or URL.
+func netGoType(kind string) string {
+	if kind == "URL" {
+		return "*url.URL"
+	}
+	return "net.IP"
+}
+
+// isDurationType returns whether typ is a time.Duration, exchanged with
+// python as float seconds.
+func isDurationType(typ types.Type) bool {
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Duration"
+}
+
+// isContextType returns whether typ is a context.Context.
+func isContextType(typ types.Type) bool {
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "context" && obj.Name() == "Context"
+}
+
+// streamMethod returns the method of the python file objects made into
+// values of typ: "read" for an io.Reader, "write" for an io.Writer, or ""
+// for other types.
+func streamMethod(typ types.Type) string {
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return ""
+	}
+	obj := named.Obj()
+	if obj.Pkg() == nil || obj.Pkg().Path() != "io" {
+		return ""
+	}
+	switch obj.Name() {
+	case "Reader":
+		return "read"
+	case "Writer":
+		return "write"
+	}
+	return ""
+}
+
+// hasContextParam returns whether sig takes a context.Context.
+func hasContextParam(sig *types.Signature) bool {
+	params := sig.Params()
+	for i := 0; i < params.Len(); i++ {
+		if isContextType(params.At(i).Type()) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasCallbackParam returns whether sig takes a func or an io.Reader or
+// io.Writer, which may be a python callable or file object called back
+// from go, from any goroutine.
+func hasCallbackParam(sig *types.Signature) bool {
+	params := sig.Params()
+	for i := 0; i < params.Len(); i++ {
+		typ := params.At(i).Type()
+		if _, ok := typ.Underlying().(*types.Signature); ok || streamMethod(typ) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// isSelectable returns whether values of the chan type typ can be received
+// by the select function of the module, which sends the received elements
+// to python as dynamically typed values.
+func isSelectable(typ types.Type) bool {
+	ch, ok := typ.Underlying().(*types.Chan)
+	if !ok || ch.Dir() == types.SendOnly {
+		return false
+	}
+	switch elem := ch.Elem().(type) {
+	case *types.Basic:
+		return elem.Info()&(types.IsBoolean|types.IsInteger|types.IsFloat|types.IsString) != 0
+	case *types.Named:
+		switch elem.Underlying().(type) {
+		case *types.Basic:
+			return isSelectable(types.NewChan(types.RecvOnly, elem.Underlying()))
+		case *types.Struct:
+			return true
+		}
+	case *types.Pointer:
+		_, ok := elem.Elem().Underlying().(*types.Struct)
+		return ok && !isFileType(elem) && !isBigType(elem) && netType(elem) == ""
+	case *types.Map:
+		return isDictType(elem)
+	case *types.Interface:
+		return elem.Empty()
+	}
+	return false
+}
+
+// isSignalChan returns whether ch is a channel of empty structs, such as
+// chan struct{}, whose values carry no data: they are exposed to python as
+// signals, sent by Signal and received by Wait.
+func isSignalChan(ch *types.Chan) bool {
+	st, ok := ch.Elem().Underlying().(*types.Struct)
+	return ok && st.NumFields() == 0
+}
+
+// isStringType returns whether typ is a named type with a string
+// underlying type.
+func isStringType(typ types.Type) bool {
+	if _, ok := typ.(*types.Named); !ok {
+		return false
+	}
+	basic, ok := typ.Underlying().(*types.Basic)
+	return ok && basic.Kind() == types.String
+}
+
+// isPyClassPath returns whether name is the dotted path of a python class,
+// module.Class, whose module may be a package: pkg.module.Class.
+func isPyClassPath(name string) bool {
+	parts := strings.Split(name, ".")
+	if len(parts) < 2 {
+		return false
+	}
+	for _, part := range parts {
+		if !token.IsIdentifier(part) {
+			return false
+		}
+	}
+	return true
+}
+
+// iterSeqTypes returns the types of the values yielded by typ, when it is
+// an instance of iter.Seq or iter.Seq2, and nil otherwise.
+func iterSeqTypes(typ types.Type) []types.Type {
+	named, ok := unalias(typ).(*types.Named)
+	if !ok || !isInstance(named) {
+		return nil
+	}
+	obj := named.Obj()
+	if obj.Pkg() == nil || obj.Pkg().Path() != "iter" {
+		return nil
+	}
+	switch obj.Name() {
+	case "Seq", "Seq2":
+	default:
+		return nil
+	}
+	args := named.TypeArgs()
+	vals := make([]types.Type, args.Len())
+	for i := range vals {
+		vals[i] = args.At(i)
+	}
+	return vals
+}
+
+// isByteSeqType returns whether typ is an array or a slice of bytes, named
+// or not, whose storage python can access through the buffer protocol.
+func isByteSeqType(typ types.Type) bool {
+	var elem types.Type
+	switch u := typ.Underlying().(type) {
+	case *types.Array:
+		elem = u.Elem()
+	case *types.Slice:
+		elem = u.Elem()
+	default:
+		return false
+	}
+	basic, ok := elem.Underlying().(*types.Basic)
+	return ok && basic.Kind() == types.Uint8
+}
+
+// isIntegerType returns whether typ is a named type with an integer
+// underlying type.
+func isIntegerType(typ types.Type) bool {
+	if _, ok := typ.(*types.Named); !ok {
+		return false
+	}
+	basic, ok := typ.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsInteger != 0
 }
 
 func isStringer(obj types.Object) bool {
+	return isStringMethod(obj, "String")
+}
+
+// isStringMethod returns whether obj is a method name() string, such as
+// the String method of a fmt.Stringer or the Error method of an error.
+func isStringMethod(obj types.Object, name string) bool {
 	switch obj := obj.(type) {
 	case *types.Func:
-		if obj.Name() != "String" {
+		if obj.Name() != name {
 			return false
 		}
 		sig, ok := obj.Type().(*types.Signature)
@@ -51,6 +1226,33 @@ func isStringer(obj types.Object) bool {
 	return false
 }
 
+// isTuple returns whether the results res of a func are returned to python
+// as a tuple: there are several of them, a trailing error aside, and they
+// are not a comma-ok.
+func isTuple(res *types.Tuple) bool {
+	n := res.Len()
+	if n > 0 && isErrorType(res.At(n-1).Type()) {
+		n--
+	}
+	if res.Len() == 2 && isOkType(res.At(1).Type()) {
+		return false
+	}
+	return n > 1
+}
+
+// isContainer returns whether obj is a Contains(x T) bool method.
+func isContainer(obj types.Object) bool {
+	fct, ok := obj.(*types.Func)
+	if !ok || fct.Name() != "Contains" {
+		return false
+	}
+	sig := fct.Type().(*types.Signature)
+	if sig.Recv() == nil || sig.Params().Len() != 1 || sig.Results().Len() != 1 {
+		return false
+	}
+	return sig.Results().At(0).Type() == types.Typ[types.Bool]
+}
+
 func hasError(sig *types.Signature) bool {
 	res := sig.Results()
 	if res == nil || res.Len() <= 0 {
@@ -0,0 +1,947 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bind
+
+import (
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+)
+
+const checkSrc = `package p
+
+import (
+	"math/big"
+	"os"
+	"sync"
+)
+
+type S struct{ A int }
+type Rec []Rec
+type P *S
+type G[T any] struct{ V T }
+type Ch chan *S
+type RecvCh <-chan S
+type SendCh chan<- int
+type BadCh chan map[*S]int
+type RecCh chan RecCh
+type FileCh chan *os.File
+type BigCh chan *big.Int
+type Done chan struct{}
+type Unit struct{}
+type Units <-chan Unit
+type Mat [3][3]float64
+type Grid [][2]S
+type BadMat [2][]int
+type A = S
+type L struct{ mu sync.Mutex }
+type LL [2]L
+type _Ctype_char int8
+type _Ctype_pt struct{ x, y int32 }
+type CP struct{ c _Ctype_pt }
+type CS struct{ s *_Ctype_char }
+type CN struct{ n _Ctype_char }
+
+const C1 = 42
+const C2 = 1 << 70
+
+func F1(a int, s *S) (S, error) { return S{}, nil }
+func F2(x rune)                 {}
+func F3() (int, int)            { return 0, 0 }
+func F4() (int, int, error)     { return 0, 0, nil }
+func F5(args ...interface{})    {}
+func F6(c chan int)             {}
+func F7() map[string]int        { return nil }
+func F8(r Rec)                  {}
+func F9(p P)                    {}
+func F10[T any](v T)            {}
+func F11(int, []int)            {}
+func F12() struct{ A, B int }   { return struct{ A, B int }{} }
+func F13() struct{ a int }      { return struct{ a int }{} }
+func F14(struct{ A int "t" })   {}
+func F15(g G[int]) *G[S]        { return nil }
+func F16(g G[chan int])         {}
+func F17() func(int) error      { return nil }
+func F18() func() chan int      { return nil }
+func F19(m [3]int)              {}
+func F20(s []*S) []string       { return nil }
+func F21() (func(int) int, error) { return nil, nil }
+func F22(a *A, s []A) map[string]A { return nil }
+func F23(k string) (*S, bool)       { return nil, false }
+func F24() (error, bool)             { return nil, false }
+func F25(l L) L                      { return L{} }
+func F26(l *L, ls []L) *L            { return l }
+func F27(ls ...L)                    {}
+func F28(m sync.Mutex)               {}
+func F29(a LL)                       {}
+func F30(m map[S]int) map[int]string { return nil }
+func F31(m map[float64][]int)        {}
+func F32() map[interface{}]int       { return nil }
+func F33(x *big.Int) *big.Float      { return nil }
+func F34() (*S, int, error)           { return nil, 0, nil }
+func F35() func() (int, int)          { return nil }
+func F36(p CP, ps []CP) *CP           { return nil }
+func F37(s CS)                        {}
+func F38(p *CP, n CN)                 {}
+
+var V1 L
+var V2 *L
+
+func (s *S) Rename(name string, tags ...string) (*S, error) { return s, nil }
+`
+
+func TestCheckObject(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", checkSrc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range []struct {
+		name string
+		want string
+	}{
+		{"S", ""},
+		{"Rec", "recursive type p.Rec"},
+		{"P", "unsupported type p.P"},
+		{"G", "unsupported generic type p.G[T any]"},
+		{"Ch", ""},
+		{"RecvCh", ""},
+		{"SendCh", ""},
+		{"BadCh", "unsupported map key type *p.S"},
+		{"RecCh", "recursive type p.RecCh"},
+		{"Mat", ""},
+		{"Grid", ""},
+		{"BadMat", "unsupported type []int"},
+		{"C1", ""},
+		{"C2", "constant 1180591620717411303424 overflows int"},
+		{"F1", ""},
+		{"F2", "parameter x: unsupported type rune"},
+		{"F3", ""},
+		{"F4", ""},
+		{"F5", ""},
+		{"F6", "parameter c: unsupported type chan int"},
+		{"F7", ""},
+		{"F8", "parameter r: recursive type p.Rec"},
+		{"F9", "parameter p: unsupported type p.P"},
+		{"F10", "unsupported generic function"},
+		{"F11", ""},
+		{"F12", ""},
+		{"F13", "result #0: anonymous struct with unexported field a"},
+		{"F14", "parameter #0: anonymous struct with tagged field A"},
+		{"F15", ""},
+		{"F16", "parameter g: type argument chan int: unsupported type chan int"},
+		{"F17", ""},
+		{"F18", "result #0: func() chan int: result #0: unsupported type chan int"},
+		{"F19", "parameter m: unsupported type [3]int"},
+		{"F20", "parameter s: unsupported type *p.S"},
+		{"F21", ""},
+		{"A", ""},
+		{"F22", ""},
+		{"F23", ""},
+		{"F24", "result #0: only the last result may be an error"},
+		{"L", ""},
+		{"LL", ""},
+		{"F25", "parameter l: copies lock value: p.L contains sync.Mutex"},
+		{"F26", ""},
+		{"F27", "parameter ls: copies lock value: p.L contains sync.Mutex"},
+		{"F28", "parameter m: copies lock value: sync.Mutex"},
+		{"F29", "parameter a: copies lock value: p.LL contains sync.Mutex"},
+		{"F30", ""},
+		{"F31", "parameter m: unsupported type []int"},
+		{"F32", "result #0: unsupported map key type interface{}"},
+		{"F33", ""},
+		{"F34", ""},
+		{"F35", "result #0: func() (int, int): tuple results of func values are not supported"},
+		{"F36", "parameter p: copies C value: p.CP contains C.pt"},
+		{"F37", "parameter s: copies C value: p.CS contains *C.char"},
+		{"F38", ""},
+		{"V1", "copies lock value: p.L contains sync.Mutex"},
+		{"V2", ""},
+	} {
+		obj := pkg.Scope().Lookup(table.name)
+		got := ""
+		if err := checkObject(obj); err != nil {
+			got = err.Error()
+		}
+		if got != table.want {
+			t.Errorf("checkObject(%s): got=%q want=%q\n", table.name, got, table.want)
+		}
+	}
+}
+
+func TestOpaqueTypes(t *testing.T) {
+	const src = `package p
+
+import "internal/poll"
+
+type S struct{ FD *poll.FD }
+type Fds []poll.FD
+
+func F1() *poll.FD                { return nil }
+func F2(fd *poll.FD) poll.FD       { return *fd }
+func F3(fds []poll.FD)             {}
+func F4(fds ...*poll.FD)           {}
+func F5() func(*poll.FD)           { return nil }
+func F6(m map[string]poll.FD)      {}
+func F7(d poll.DeadlineExceededError) {}
+
+var V *poll.FD
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const only = ": the types of internal packages are only passed to and returned by funcs and methods"
+	for _, table := range []struct {
+		name string
+		want string
+	}{
+		{"Fds", "unsupported type poll.FD" + only},
+		{"F1", ""},
+		{"F2", ""},
+		{"F3", "parameter fds: unsupported type poll.FD" + only},
+		{"F4", "parameter fds: unsupported type poll.FD" + only},
+		{"F5", "result #0: unsupported type func(*poll.FD)" + only},
+		{"F6", "parameter m: unsupported type poll.FD" + only},
+		{"F7", ""},
+		{"V", "unsupported type poll.FD" + only},
+	} {
+		obj := pkg.Scope().Lookup(table.name)
+		got := ""
+		if err := checkObject(obj); err != nil {
+			got = err.Error()
+		}
+		if got != table.want {
+			t.Errorf("checkObject(%s): got=%q want=%q\n", table.name, got, table.want)
+		}
+	}
+
+	st := pkg.Scope().Lookup("S").Type().Underlying().(*types.Struct)
+	if err := checkType(st.Field(0).Type()); err == nil {
+		t.Errorf("checkType(%s): want an error", st.Field(0).Type())
+	}
+}
+
+func TestIsSelectable(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", checkSrc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range []struct {
+		name string
+		want bool
+	}{
+		{"S", false},
+		{"Ch", true},
+		{"RecvCh", true},
+		{"SendCh", false},
+		{"RecCh", false},
+		{"FileCh", false},
+		{"BigCh", false},
+	} {
+		typ := pkg.Scope().Lookup(table.name).Type()
+		if got := isSelectable(typ); got != table.want {
+			t.Errorf("isSelectable(%s): got=%v want=%v\n", table.name, got, table.want)
+		}
+	}
+}
+
+func TestIsSignalChan(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", checkSrc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range []struct {
+		name string
+		want bool
+	}{
+		{"Ch", false},
+		{"RecvCh", false},
+		{"SendCh", false},
+		{"Done", true},
+		{"Units", true},
+	} {
+		ch := pkg.Scope().Lookup(table.name).Type().Underlying().(*types.Chan)
+		if got := isSignalChan(ch); got != table.want {
+			t.Errorf("isSignalChan(%s): got=%v want=%v\n", table.name, got, table.want)
+		}
+	}
+}
+
+func TestFuncSignature(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", checkSrc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := pkg.Scope().Lookup("S").Type().(*types.Named)
+	for _, table := range []struct {
+		fct  *types.Func
+		want string
+	}{
+		{pkg.Scope().Lookup("F1").(*types.Func), "func F1(a int, s *p.S) (p.S, error)"},
+		{pkg.Scope().Lookup("F5").(*types.Func), "func F5(args ...interface{})"},
+		{pkg.Scope().Lookup("F11").(*types.Func), "func F11(int, []int)"},
+		{pkg.Scope().Lookup("F17").(*types.Func), "func F17() func(int) error"},
+		{s.Method(0), "func (s *p.S) Rename(name string, tags ...string) (*p.S, error)"},
+	} {
+		if got := funcSignature(table.fct); got != table.want {
+			t.Errorf("funcSignature(%s): got=%q want=%q\n", table.fct.Name(), got, table.want)
+		}
+	}
+}
+
+func TestUnalias(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", checkSrc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range []struct {
+		name string
+		want string
+	}{
+		{"A", "p.S"},
+		{"F22", "func(a *p.S, s []p.S) map[string]p.S"},
+		{"F1", "func(a int, s *p.S) (p.S, error)"},
+	} {
+		typ := pkg.Scope().Lookup(table.name).Type()
+		if got := typeString(unalias(typ)); got != table.want {
+			t.Errorf("unalias(%s): got=%q want=%q\n", table.name, got, table.want)
+		}
+	}
+
+	// types without aliases are returned as is.
+	f1 := pkg.Scope().Lookup("F1").Type()
+	if unalias(f1) != f1 {
+		t.Errorf("unalias(F1): got a new type\n")
+	}
+}
+
+func TestDirectiveArg(t *testing.T) {
+	const src = `package p
+
+// A parses a.
+//
+//gopy:static T
+func A() {}
+
+//gopy:blocking
+func B() {}
+
+//gopy:static
+func C() {}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, table := range []struct {
+		arg string
+		ok  bool
+	}{
+		{"T", true},
+		{"", false},
+		{"", false},
+	} {
+		decl := f.Decls[i].(*ast.FuncDecl)
+		arg, ok := directiveArg(decl, "gopy:static")
+		if arg != table.arg || ok != table.ok {
+			t.Errorf("directiveArg(%s): got=(%q, %v) want=(%q, %v)\n",
+				decl.Name.Name, arg, ok, table.arg, table.ok)
+		}
+	}
+}
+
+func TestCheckImplements(t *testing.T) {
+	const src = `package p
+
+import (
+	"fmt"
+	"io"
+)
+
+// R reads nothing.
+//
+//gopy:implements io.Reader fmt.Stringer
+type R struct{}
+
+func (*R) Read(p []byte) (int, error) { return 0, io.EOF }
+func (R) String() string              { return "R" }
+
+var _ fmt.Stringer = R{}
+`
+	for _, tc := range []struct {
+		decl string
+		err  string
+	}{
+		{decl: "", err: ""},
+		{
+			decl: "//gopy:implements error\ntype W struct{}",
+			err:  "bind: W does not implement error: missing method Error",
+		},
+		{
+			decl: "//gopy:implements io.Writer\ntype W struct{}\nfunc (W) Write(p []byte) error { return nil }",
+			err:  "bind: W does not implement io.Writer: wrong type for method Write",
+		},
+		{
+			decl: "//gopy:implements bufio.Reader\ntype W struct{}",
+			err:  "bind: W: //gopy:implements bufio.Reader: package bufio not imported by p",
+		},
+		{
+			decl: "//gopy:implements R\ntype W struct{}",
+			err:  "bind: W: //gopy:implements R: R is not an interface",
+		},
+	} {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "p.go", src+tc.decl, parser.ParseComments)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conf := types.Config{Importer: importer.Default()}
+		pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dpkg, err := doc.NewFromFiles(fset, []*ast.File{f}, "p", doc.PreserveAST)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = NewPackage(fset, pkg, dpkg, NetTypesText)
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%q: unexpected error: %v", tc.decl, err)
+		case tc.err != "" && (err == nil || err.Error() != tc.err):
+			t.Errorf("%q: got error %v, want %q", tc.decl, err, tc.err)
+		}
+	}
+}
+
+func TestRefFields(t *testing.T) {
+	const src = `package p
+
+type P struct{ X, Y int }
+
+type S struct {
+	A P
+
+	//gopy:ref
+	B P
+
+	%s
+}
+
+//gopy:ref
+type R struct {
+	A P
+	N int
+}
+`
+	for _, tc := range []struct {
+		field string
+		err   string
+	}{
+		{field: "", err: ""},
+		{field: "//gopy:ref\nN int", err: "bind: S.N: //gopy:ref: int fields are read as a copy"},
+		{field: "//gopy:ref\nQ *P", err: "bind: S.Q: //gopy:ref: *p.P fields are read as a copy"},
+	} {
+		p, err := newTestPackage(t, fmt.Sprintf(src, tc.field))
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%q: unexpected error: %v", tc.field, err)
+			continue
+		case tc.err != "" && (err == nil || err.Error() != tc.err):
+			t.Errorf("%q: got error %v, want %q", tc.field, err, tc.err)
+			continue
+		case tc.err != "":
+			continue
+		}
+
+		var got []string
+		for _, typ := range p.types {
+			st := typ.Struct()
+			if st == nil || typ.isExternal() {
+				continue
+			}
+			for i := 0; i < st.NumFields(); i++ {
+				f := st.Field(i)
+				got = append(got, typ.obj.Name()+"."+f.Name()+": "+typeString(typ.fieldType(f)))
+			}
+		}
+		want := []string{
+			"P.X: int", "P.Y: int",
+			"R.A: *p.P", "R.N: int",
+			"S.A: p.P", "S.B: *p.P",
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("field types:\ngot= %q\nwant=%q", got, want)
+		}
+	}
+}
+
+func TestOverloads(t *testing.T) {
+	const src = `package p
+
+type T struct{}
+
+//gopy:overload New
+func NewT(n int) T { return T{} }
+
+//gopy:overload New
+func ParseT(s string) T { return T{} }
+
+//gopy:overload F
+func F(n int) {}
+
+//gopy:overload F
+func G(s string) {}
+
+%s
+`
+	for _, tc := range []struct {
+		decl string
+		err  string
+	}{
+		{decl: "", err: ""},
+		{decl: "//gopy:static T\n//gopy:overload F\nfunc H() {}", err: "bind: H: //gopy:overload F: static methods can not be overloaded"},
+		{decl: "//gopy:overload 1F\nfunc H() {}", err: "bind: H: //gopy:overload 1F: invalid name"},
+		{decl: "const New = 1", err: "bind: //gopy:overload New: New is a constant of the package, not an overloaded func"},
+	} {
+		p, err := newTestPackage(t, fmt.Sprintf(src, tc.decl))
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%q: unexpected error: %v", tc.decl, err)
+			continue
+		case tc.err != "" && (err == nil || err.Error() != tc.err):
+			t.Errorf("%q: got error %v, want %q", tc.decl, err, tc.err)
+			continue
+		case tc.err != "":
+			continue
+		}
+
+		var got []string
+		for _, o := range p.overloads {
+			for _, f := range o.funcs {
+				got = append(got, o.name+": "+f.GoName())
+			}
+		}
+		want := []string{"F: F", "F: G", "New: NewT", "New: ParseT"}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("overloads:\ngot= %q\nwant=%q", got, want)
+		}
+		if !p.isOverloaded("F") || p.isOverloaded("G") || p.isOverloaded("New") {
+			t.Errorf("isOverloaded: got F=%v G=%v New=%v, want true false false",
+				p.isOverloaded("F"), p.isOverloaded("G"), p.isOverloaded("New"))
+		}
+	}
+}
+
+func TestIterSeqTypes(t *testing.T) {
+	const src = `package p
+
+import "iter"
+
+type Seq[V any] func(yield func(V) bool)
+
+var (
+	S1 iter.Seq[int]
+	S2 iter.Seq2[string, bool]
+	S3 Seq[int]
+	S4 func(yield func(int) bool)
+)
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range []struct {
+		name string
+		want string
+	}{
+		{"S1", "[int]"},
+		{"S2", "[string bool]"},
+		{"S3", "[]"},
+		{"S4", "[]"},
+	} {
+		typ := pkg.Scope().Lookup(table.name).Type()
+		if got := fmt.Sprint(iterSeqTypes(typ)); got != table.want {
+			t.Errorf("iterSeqTypes(%s): got=%q want=%q\n", table.name, got, table.want)
+		}
+	}
+}
+
+func TestBases(t *testing.T) {
+	const src = `package p
+
+// T is a sequence.
+//
+//gopy:base %s
+type T []int
+`
+	for _, tc := range []struct {
+		base string
+		want []string
+		err  string
+	}{
+		{base: "collections.Sequence", want: []string{"collections.Sequence"}},
+		{base: "a.b.Mixin collections.Sized", want: []string{"a.b.Mixin", "collections.Sized"}},
+		{base: "Sequence", err: "bind: T: //gopy:base Sequence: invalid name, expected module.Class"},
+		{base: "collections.1Sequence", err: "bind: T: //gopy:base collections.1Sequence: invalid name, expected module.Class"},
+	} {
+		p, err := newTestPackage(t, fmt.Sprintf(src, tc.base))
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%q: unexpected error: %v", tc.base, err)
+			continue
+		case tc.err != "" && (err == nil || err.Error() != tc.err):
+			t.Errorf("%q: got error %v, want %q", tc.base, err, tc.err)
+			continue
+		case tc.err != "":
+			continue
+		}
+		var got []string
+		for _, typ := range p.types {
+			if typ.obj.Name() == "T" {
+				got = typ.bases
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%q: got bases %q, want %q", tc.base, got, tc.want)
+		}
+	}
+}
+
+func TestDeepEq(t *testing.T) {
+	const src = `package p
+
+// T is compared field by field.
+//
+//gopy:deepeq
+%s
+`
+	for _, tc := range []struct {
+		decl string
+		err  string
+	}{
+		{decl: "type T struct{ S []int }"},
+		{decl: "type T []int", err: "bind: T: //gopy:deepeq: only structs are compared field by field"},
+	} {
+		p, err := newTestPackage(t, fmt.Sprintf(src, tc.decl))
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%q: unexpected error: %v", tc.decl, err)
+			continue
+		case tc.err != "" && (err == nil || err.Error() != tc.err):
+			t.Errorf("%q: got error %v, want %q", tc.decl, err, tc.err)
+			continue
+		case tc.err != "":
+			continue
+		}
+		for _, typ := range p.types {
+			if typ.obj.Name() == "T" && !typ.isDeepEq() {
+				t.Errorf("%q: T is not compared field by field", tc.decl)
+			}
+		}
+	}
+}
+
+func TestErrorResults(t *testing.T) {
+	const src = `package p
+
+// MyError is an error value, returned like any other value.
+type MyError struct{ Msg string }
+
+func (e MyError) Error() string { return e.Msg }
+
+// E is an alias of error.
+type E = error
+
+func F() (MyError, error) { return MyError{}, nil }
+func G() (int, MyError)   { return 0, MyError{} }
+func H() (int, E)         { return 0, nil }
+func K() E                { return nil }
+func L() MyError          { return MyError{} }
+func M() (int, int, E)    { return 0, 0, nil }
+`
+	p, err := newTestPackage(t, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	funcs := make(map[string]Func)
+	for _, f := range p.funcs {
+		funcs[f.GoName()] = f
+	}
+	for _, typ := range p.types {
+		for _, f := range typ.ctors {
+			funcs[f.GoName()] = f
+		}
+	}
+
+	for _, tc := range []struct {
+		name  string
+		err   bool
+		tuple bool
+		ret   string
+	}{
+		{name: "F", err: true, ret: "p.MyError"},
+		{name: "G", tuple: true},
+		{name: "H", err: true, ret: "int"},
+		{name: "K", err: true},
+		{name: "L", ret: "p.MyError"},
+		{name: "M", err: true, tuple: true},
+	} {
+		f, ok := funcs[tc.name]
+		if !ok {
+			t.Errorf("%s: not wrapped", tc.name)
+			continue
+		}
+		ret := ""
+		if f.ret != nil {
+			ret = f.ret.String()
+		}
+		if f.err != tc.err || f.tuple != tc.tuple || ret != tc.ret {
+			t.Errorf("%s: got err=%v tuple=%v ret=%q, want err=%v tuple=%v ret=%q",
+				tc.name, f.err, f.tuple, ret, tc.err, tc.tuple, tc.ret)
+		}
+	}
+}
+
+func TestCallbackParams(t *testing.T) {
+	const src = `package p
+
+import "io"
+
+type Op func(a, b int) int
+
+func Spawn(op Op, a, b int) int   { return op(a, b) }
+func Each(f func(int), n int)     {}
+func Copy(r io.Reader) (int, error) { return 0, nil }
+func Add(a, b int) int            { return a + b }
+`
+	p, err := newTestPackage(t, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	funcs := make(map[string]Func)
+	for _, f := range p.funcs {
+		funcs[f.GoName()] = f
+	}
+
+	for _, tc := range []struct {
+		name     string
+		blocking bool
+	}{
+		{name: "Spawn", blocking: true},
+		{name: "Each", blocking: true},
+		{name: "Copy", blocking: true},
+		{name: "Add", blocking: false},
+	} {
+		f, ok := funcs[tc.name]
+		if !ok {
+			t.Errorf("%s: not wrapped", tc.name)
+			continue
+		}
+		if f.blocking != tc.blocking {
+			t.Errorf("%s: got blocking=%v, want %v", tc.name, f.blocking, tc.blocking)
+		}
+	}
+}
+
+func TestSelectorConflicts(t *testing.T) {
+	const src = `package p
+
+type A struct{}
+
+func (A) Close() {}
+func (A) Read()  {}
+
+type B struct{}
+
+func (*B) Close() {}
+
+type Closer interface{ Close() }
+
+// AB: Close is ambiguous.
+type AB struct {
+	A
+	*B
+}
+
+// C: Close shadows the ambiguous ones.
+type C struct{ AB }
+
+func (C) Close() {}
+
+// D: Close of B shadows the one of AB.B.
+type D struct {
+	B
+	AB
+}
+
+// E: the field Read shadows the method of A.
+type E struct {
+	A
+	Read int
+}
+
+// F: Close of the interface is ambiguous with the one of A.
+type F struct {
+	Closer
+	A
+}
+
+// Node is recursive.
+type Node struct {
+	*Node
+	A
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range []struct {
+		name string
+		want string
+	}{
+		{"A", ""},
+		{"AB", "Close: ambiguous A.Close, B.Close"},
+		{"C", "Close: shadows AB.A.Close, AB.B.Close"},
+		{"D", "Close: shadows AB.A.Close, AB.B.Close"},
+		{"E", "Read: shadows A.Read"},
+		{"F", "Close: ambiguous Closer.Close, A.Close"},
+		{"Node", ""},
+	} {
+		var got []string
+		for _, c := range selectorConflicts(pkg.Scope().Lookup(table.name).Type()) {
+			verb := "shadows"
+			if c.ambiguous {
+				verb = "ambiguous"
+			}
+			got = append(got, c.name+": "+verb+" "+strings.Join(c.paths, ", "))
+		}
+		if got := strings.Join(got, "; "); got != table.want {
+			t.Errorf("selectorConflicts(%s): got=%q want=%q\n", table.name, got, table.want)
+		}
+	}
+}
+
+func TestEmbeddedIface(t *testing.T) {
+	const src = `package p
+
+type Namer interface{ Name() string }
+
+type A struct{}
+
+func (A) Name() string { return "" }
+
+type S struct{ Namer }
+
+type P struct{ *S }
+
+type T struct {
+	Inner struct{ Namer }
+}
+
+type U struct{ A }
+
+type V struct{ Namer }
+
+func (V) Name() string { return "" }
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("p", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range []struct {
+		name string
+		want string
+	}{
+		{"S", "Namer"},
+		{"P", "S.Namer"},
+		{"T", ""},
+		{"U", ""},
+		{"V", ""},
+	} {
+		typ := types.NewPointer(pkg.Scope().Lookup(table.name).Type())
+		sel := types.NewMethodSet(typ).Lookup(nil, "Name")
+		got := ""
+		if sel != nil {
+			got = embeddedIface(sel)
+		}
+		if got != table.want {
+			t.Errorf("embeddedIface(%s.Name): got=%q want=%q\n", table.name, got, table.want)
+		}
+	}
+}
@@ -15,6 +15,8 @@ type Var struct {
 	id   string
 	doc  string
 	name string
+
+	blank bool // true for a blank or unnamed parameter, given a placeholder name
 }
 
 func (v *Var) Name() string {
@@ -43,6 +45,30 @@ func newVarsFrom(p *Package, tuple *types.Tuple) []*Var {
 	return vars
 }
 
+// newParamsFrom returns the parameters of tuple. Blank and unnamed ones are
+// given placeholder names, arg0, arg1..., so that the generated code can
+// refer to them.
+func newParamsFrom(p *Package, tuple *types.Tuple) []*Var {
+	vars := newVarsFrom(p, tuple)
+	taken := make(map[string]bool)
+	for _, v := range vars {
+		taken[v.name] = true
+	}
+	for i, v := range vars {
+		if v.name != "" && v.name != "_" {
+			continue
+		}
+		name := fmt.Sprintf("arg%d", i)
+		for taken[name] {
+			name += "_"
+		}
+		taken[name] = true
+		v.name = name
+		v.blank = true
+	}
+	return vars
+}
+
 func newVarFrom(p *Package, v *types.Var) *Var {
 	return newVar(p, v.Type(), v.Name(), v.Name(), p.getDoc("", v))
 }
@@ -57,7 +83,7 @@ func (v *Var) Doc() string       { return v.doc }
 func (v *Var) GoName() string    { return v.name }
 
 func (v *Var) GoType() types.Type {
-	return v.sym.goobj.Type()
+	return v.sym.GoType()
 }
 
 func (v *Var) CType() string {
@@ -108,7 +134,16 @@ func (v *Var) getArgParse() (string, []string) {
 	return v.sym.pyfmt, addrs
 }
 
-func (v *Var) getArgBuildValue() (string, []string) {
+// getArgBuildValue returns the Py_BuildValue format and arguments of v,
+// for the python version lang, 0 for both.
+// The K format of 64-bit unsigned ints always builds a long in python 2,
+// whose repr has an L suffix, even for small values: they are built by the
+// converter of their basic type instead, which builds an int when they fit.
+func (v *Var) getArgBuildValue(lang int) (string, []string) {
+	if v.sym.pyfmt == "K" && lang != 3 {
+		bsym := universe.symtype(v.GoType().Underlying())
+		return "O&", []string{bsym.c2py, "&c_" + v.Name()}
+	}
 	args := make([]string, 0, 1)
 	cnv := v.sym.hasConverter()
 	if cnv {
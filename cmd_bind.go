@@ -6,12 +6,14 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strings"
 
+	"github.com/go-python/gopy/bind"
 	"github.com/gonuts/commander"
 	"github.com/gonuts/flag"
 )
@@ -27,12 +29,29 @@ bind generates and compiles (C)Python language bindings for a Go package.
 ex:
  $ gopy bind [options] <go-package-name>
  $ gopy bind github.com/go-python/gopy/_examples/hi
+ $ gopy bind -package=myproject.gobindings github.com/go-python/gopy/_examples/hi
+ $ gopy bind -async github.com/go-python/gopy/_examples/hi
+ $ gopy bind -ldflags="-undefined dynamic_lookup" github.com/go-python/gopy/_examples/hi
+ $ gopy bind -tags=netgo,osusergo github.com/go-python/gopy/_examples/hi
+ $ gopy bind -work github.com/go-python/gopy/_examples/hi
 `,
 		Flag: *flag.NewFlagSet("gopy-bind", flag.ExitOnError),
 	}
 
 	cmd.Flag.String("lang", defaultPyVersion, "python version to use for bindings (python2|py2|python3|py3)")
 	cmd.Flag.String("output", "", "output directory for bindings")
+	cmd.Flag.String("naming", "go", "naming convention for python funcs, methods and fields (go|snake)")
+	cmd.Flag.Bool("async", false, "generate an _async variant, returning a future, of the funcs and methods annotated with //gopy:async")
+	cmd.Flag.String("durations", "seconds", "how time.Duration values are returned to python (seconds|timedelta)")
+	cmd.Flag.String("nettypes", "text", "how net.IP and *url.URL values are exchanged with python (text|opaque)")
+	cmd.Flag.Bool("py23", false, "generate C sources compiling against both the python-2 and the python-3 headers")
+	cmd.Flag.String("package", "", "python package holding the bindings, created under the output directory (e.g. myproject.gobindings)")
+	cmd.Flag.String("cflags", "", "extra flags for the C compiler, added to $CGO_CFLAGS")
+	cmd.Flag.String("ldflags", "", "extra flags for the linker, added to $CGO_LDFLAGS")
+	cmd.Flag.String("tags", "", "comma-separated build tags, selecting the files of the package to bind")
+	cmd.Flag.String("goos", "", "target operating system of the bindings, instead of the host's")
+	cmd.Flag.String("goarch", "", "target architecture of the bindings, instead of the host's")
+	cmd.Flag.Bool("work", false, "print the paths of the temporary work directory and of the sources generated into it, and keep it when exiting")
 	return cmd
 }
 
@@ -48,6 +67,42 @@ func gopyRunCmdBind(cmdr *commander.Command, args []string) error {
 
 	odir := cmdr.Flag.Lookup("output").Value.Get().(string)
 	lang := cmdr.Flag.Lookup("lang").Value.Get().(string)
+	pypkg := cmdr.Flag.Lookup("package").Value.Get().(string)
+	if pypkg != "" {
+		err = checkPyPackage(pypkg)
+		if err != nil {
+			return fmt.Errorf("gopy-bind: %v", err)
+		}
+	}
+
+	naming, err := bind.ParseNaming(cmdr.Flag.Lookup("naming").Value.Get().(string))
+	if err != nil {
+		return fmt.Errorf("gopy-bind: %v", err)
+	}
+	async := cmdr.Flag.Lookup("async").Value.Get().(bool)
+	py23 := cmdr.Flag.Lookup("py23").Value.Get().(bool)
+	durations, err := bind.ParseDurations(cmdr.Flag.Lookup("durations").Value.Get().(string))
+	if err != nil {
+		return fmt.Errorf("gopy-bind: %v", err)
+	}
+	nets, err := bind.ParseNetTypes(cmdr.Flag.Lookup("nettypes").Value.Get().(string))
+	if err != nil {
+		return fmt.Errorf("gopy-bind: %v", err)
+	}
+
+	keep := cmdr.Flag.Lookup("work").Value.Get().(bool)
+
+	cflags := cmdr.Flag.Lookup("cflags").Value.Get().(string)
+	ldflags := cmdr.Flag.Lookup("ldflags").Value.Get().(string)
+	cfg := newLoadConfig(
+		cmdr.Flag.Lookup("tags").Value.Get().(string),
+		cmdr.Flag.Lookup("goos").Value.Get().(string),
+		cmdr.Flag.Lookup("goarch").Value.Get().(string),
+	)
+	// packages using cgo are installed and loaded with the flags they are
+	// built with into the binder, so that their C code compiles alike.
+	cfg.cflags, cfg.ldflags = cflags, ldflags
+	cfg.nets = nets
 
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -70,7 +125,7 @@ func gopyRunCmdBind(cmdr *commander.Command, args []string) error {
 	}
 
 	path := args[0]
-	pkg, err := newPackage(path)
+	pkg, err := newPackage(path, cfg)
 	if err != nil {
 		return fmt.Errorf(
 			"gopy-bind: go/build.Import failed with path=%q: %v\n",
@@ -79,12 +134,11 @@ func gopyRunCmdBind(cmdr *commander.Command, args []string) error {
 		)
 	}
 
+	logDiagnostics(pkg)
+
 	// go-get it to tickle the GOPATH cache (and make sure it compiles
 	// correctly)
-	cmd := exec.Command(
-		"go", "get", "-buildmode=c-shared",
-		pkg.ImportPath(),
-	)
+	cmd := cfg.goCmd("get", "-buildmode=c-shared", pkg.ImportPath())
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -97,23 +151,33 @@ func gopyRunCmdBind(cmdr *commander.Command, args []string) error {
 	if err != nil {
 		return fmt.Errorf("gopy-bind: could not create temp-workdir (%v)", err)
 	}
-	log.Printf("work: %s\n", work)
+	if keep {
+		log.Printf("work: %s\n", work)
+	} else {
+		defer os.RemoveAll(work)
+	}
 
 	err = os.MkdirAll(work, 0644)
 	if err != nil {
 		return fmt.Errorf("gopy-bind: could not create workdir (%v)", err)
 	}
-	//defer os.RemoveAll(work)
 
-	err = genPkg(work, pkg, lang)
+	info := buildInfo()
+	out := newGenOutput(work, false)
+	err = genPkg(out, pkg, lang, py23, naming, async, durations, info)
 	if err != nil {
 		return err
 	}
 
-	err = genPkg(work, pkg, "go")
+	err = genPkg(out, pkg, "go", py23, naming, async, durations, info)
 	if err != nil {
 		return err
 	}
+	if keep {
+		for _, fname := range out.files {
+			log.Printf("work: %s\n", fname)
+		}
+	}
 
 	wbind, err := ioutil.TempDir("", "gopy-")
 	if err != nil {
@@ -126,9 +190,15 @@ func gopyRunCmdBind(cmdr *commander.Command, args []string) error {
 	}
 	defer os.RemoveAll(wbind)
 
-	cmd = exec.Command(
-		"go", "build", "-buildmode=c-shared",
-		"-o", filepath.Join(wbind, pkg.Name())+".so",
+	// python looks for extension modules with the suffix of the platform.
+	ext := ".so"
+	if cfg.context().GOOS == "windows" {
+		ext = ".pyd"
+	}
+
+	cmd = cfg.goCmd(
+		"build", "-buildmode=c-shared",
+		"-o", filepath.Join(wbind, pkg.Name())+ext,
 		".",
 	)
 	cmd.Dir = work
@@ -137,21 +207,80 @@ func gopyRunCmdBind(cmdr *commander.Command, args []string) error {
 	cmd.Stderr = os.Stderr
 	err = cmd.Run()
 	if err != nil {
+		if !keep {
+			return fmt.Errorf("gopy-bind: could not build the bindings (%v): rerun with -work to keep the generated sources", err)
+		}
 		return err
 	}
 
-	cmd = exec.Command(
-		"/bin/cp",
-		filepath.Join(wbind, pkg.Name())+".so",
-		filepath.Join(odir, pkg.Name())+".so",
+	if pypkg != "" {
+		odir, err = genPyPackage(odir, pypkg, pkg.Name())
+		if err != nil {
+			return fmt.Errorf("gopy-bind: %v", err)
+		}
+	}
+
+	err = copyFile(
+		filepath.Join(odir, pkg.Name())+ext,
+		filepath.Join(wbind, pkg.Name())+ext,
 	)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	err = cmd.Run()
 	if err != nil {
-		return err
+		return fmt.Errorf("gopy-bind: %v", err)
 	}
 
 	return err
 }
+
+// copyFile copies the file src to dst.
+func copyFile(dst, src string) error {
+	r, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	w, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	_, err = io.Copy(w, r)
+	if err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// cgoEnv returns env, with cflags and ldflags added to the flags cgo passes
+// to the C compiler and to the linker.
+func cgoEnv(env []string, cflags, ldflags string) []string {
+	env = append([]string(nil), env...)
+	for _, v := range []struct {
+		key   string
+		flags string
+	}{
+		{"CGO_CFLAGS", cflags},
+		{"CGO_LDFLAGS", ldflags},
+	} {
+		if v.flags == "" {
+			continue
+		}
+		// cgo defaults to -g -O2 when the variable is not set.
+		old := "-g -O2"
+		i := len(env)
+		for j, kv := range env {
+			if strings.HasPrefix(kv, v.key+"=") {
+				i = j
+				old = strings.TrimPrefix(kv, v.key+"=")
+			}
+		}
+		kv := v.key + "=" + strings.TrimSpace(old+" "+v.flags)
+		if i == len(env) {
+			env = append(env, kv)
+		} else {
+			env[i] = kv
+		}
+	}
+	return env
+}
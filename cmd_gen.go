@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/go-python/gopy/bind"
 	"github.com/gonuts/commander"
 	"github.com/gonuts/flag"
 )
@@ -25,12 +26,23 @@ gen generates (C)Python language bindings for a Go package.
 ex:
  $ gopy gen [options] <go-package-name>
  $ gopy gen github.com/go-python/gopy/_examples/hi
+ $ gopy gen -check -output=bindings github.com/go-python/gopy/_examples/hi
+ $ gopy gen -goos=windows -goarch=amd64 github.com/go-python/gopy/_examples/hi
 `,
 		Flag: *flag.NewFlagSet("gopy-gen", flag.ExitOnError),
 	}
 
 	cmd.Flag.String("lang", defaultPyVersion, "target language for bindings")
 	cmd.Flag.String("output", "", "output directory for bindings")
+	cmd.Flag.String("naming", "go", "naming convention for python funcs, methods and fields (go|snake)")
+	cmd.Flag.Bool("async", false, "generate an _async variant, returning a future, of the funcs and methods annotated with //gopy:async")
+	cmd.Flag.String("durations", "seconds", "how time.Duration values are returned to python (seconds|timedelta)")
+	cmd.Flag.String("nettypes", "text", "how net.IP and *url.URL values are exchanged with python (text|opaque)")
+	cmd.Flag.Bool("py23", false, "generate C sources compiling against both the python-2 and the python-3 headers")
+	cmd.Flag.Bool("check", false, "check that the bindings in the output directory are up to date, without writing them")
+	cmd.Flag.String("tags", "", "comma-separated build tags, selecting the files of the package to bind")
+	cmd.Flag.String("goos", "", "target operating system of the bindings, instead of the host's")
+	cmd.Flag.String("goarch", "", "target architecture of the bindings, instead of the host's")
 	return cmd
 }
 
@@ -46,6 +58,28 @@ func gopyRunCmdGen(cmdr *commander.Command, args []string) error {
 
 	odir := cmdr.Flag.Lookup("output").Value.Get().(string)
 	lang := cmdr.Flag.Lookup("lang").Value.Get().(string)
+	check := cmdr.Flag.Lookup("check").Value.Get().(bool)
+	async := cmdr.Flag.Lookup("async").Value.Get().(bool)
+	py23 := cmdr.Flag.Lookup("py23").Value.Get().(bool)
+	cfg := newLoadConfig(
+		cmdr.Flag.Lookup("tags").Value.Get().(string),
+		cmdr.Flag.Lookup("goos").Value.Get().(string),
+		cmdr.Flag.Lookup("goarch").Value.Get().(string),
+	)
+
+	naming, err := bind.ParseNaming(cmdr.Flag.Lookup("naming").Value.Get().(string))
+	if err != nil {
+		return fmt.Errorf("gopy-gen: %v", err)
+	}
+	durations, err := bind.ParseDurations(cmdr.Flag.Lookup("durations").Value.Get().(string))
+	if err != nil {
+		return fmt.Errorf("gopy-gen: %v", err)
+	}
+	nets, err := bind.ParseNetTypes(cmdr.Flag.Lookup("nettypes").Value.Get().(string))
+	if err != nil {
+		return fmt.Errorf("gopy-gen: %v", err)
+	}
+	cfg.nets = nets
 
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -54,7 +88,7 @@ func gopyRunCmdGen(cmdr *commander.Command, args []string) error {
 
 	if odir == "" {
 		odir = cwd
-	} else {
+	} else if !check {
 		err = os.MkdirAll(odir, 0755)
 		if err != nil {
 			return fmt.Errorf(
@@ -72,7 +106,7 @@ func gopyRunCmdGen(cmdr *commander.Command, args []string) error {
 	}
 
 	path := args[0]
-	pkg, err := newPackage(path)
+	pkg, err := newPackage(path, cfg)
 	if err != nil {
 		return fmt.Errorf(
 			"gopy-gen: go/build.Import failed with path=%q: %v\n",
@@ -81,10 +115,20 @@ func gopyRunCmdGen(cmdr *commander.Command, args []string) error {
 		)
 	}
 
-	err = genPkg(odir, pkg, lang)
+	logDiagnostics(pkg)
+
+	out := newGenOutput(odir, check)
+	err = genPkg(out, pkg, lang, py23, naming, async, durations, buildInfo("check"))
 	if err != nil {
 		return err
 	}
 
+	if len(out.stale) > 0 {
+		for _, fname := range out.stale {
+			log.Printf("gopy-gen: %s is out of date\n", fname)
+		}
+		return fmt.Errorf("gopy-gen: %d generated files out of date", len(out.stale))
+	}
+
 	return err
 }
@@ -0,0 +1,133 @@
+// Copyright 2015 The go-python Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/go-python/gopy/bind"
+	"github.com/gonuts/commander"
+	"github.com/gonuts/flag"
+)
+
+func gopyMakeCmdInspect() *commander.Command {
+	cmd := &commander.Command{
+		Run:       gopyRunCmdInspect,
+		UsageLine: "inspect <go-package-name>",
+		Short:     "list the symbols of a Go package bound and skipped by gopy",
+		Long: `
+inspect lists the exported symbols of a Go package, and reports whether gopy
+binds them, or why it skips them, without generating the bindings.
+
+ex:
+ $ gopy inspect [options] <go-package-name>
+ $ gopy inspect github.com/go-python/gopy/_examples/hi
+ $ gopy inspect -json github.com/go-python/gopy/_examples/hi
+`,
+		Flag: *flag.NewFlagSet("gopy-inspect", flag.ExitOnError),
+	}
+
+	cmd.Flag.Bool("json", false, "print the report as a JSON array")
+	cmd.Flag.String("tags", "", "comma-separated build tags, selecting the files of the package to bind")
+	cmd.Flag.String("goos", "", "target operating system of the bindings, instead of the host's")
+	cmd.Flag.String("goarch", "", "target architecture of the bindings, instead of the host's")
+	return cmd
+}
+
+func gopyRunCmdInspect(cmdr *commander.Command, args []string) error {
+	if len(args) != 1 {
+		log.Printf("expect a fully qualified go package name as argument\n")
+		return fmt.Errorf(
+			"gopy-inspect: expect a fully qualified go package name as argument",
+		)
+	}
+
+	asJSON := cmdr.Flag.Lookup("json").Value.Get().(bool)
+	cfg := newLoadConfig(
+		cmdr.Flag.Lookup("tags").Value.Get().(string),
+		cmdr.Flag.Lookup("goos").Value.Get().(string),
+		cmdr.Flag.Lookup("goarch").Value.Get().(string),
+	)
+
+	path := args[0]
+	pkg, err := newPackage(path, cfg)
+	if err != nil {
+		return fmt.Errorf(
+			"gopy-inspect: go/build.Import failed with path=%q: %v\n",
+			path,
+			err,
+		)
+	}
+
+	if asJSON {
+		return writeReportJSON(os.Stdout, pkg.Report())
+	}
+	return writeReport(os.Stdout, pkg.Report())
+}
+
+// reportEntry is the JSON form of a bind.Symbol.
+type reportEntry struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	Status string `json:"status"` // "bound" or "skipped"
+	Reason string `json:"reason,omitempty"`
+	Pos    string `json:"pos,omitempty"`
+}
+
+// status returns the status of sym in a report: "bound" or "skipped".
+func status(sym bind.Symbol) string {
+	if sym.Bound {
+		return "bound"
+	}
+	return "skipped"
+}
+
+// writeReportJSON writes syms to w as a JSON array.
+func writeReportJSON(w io.Writer, syms []bind.Symbol) error {
+	entries := make([]reportEntry, 0, len(syms))
+	for _, sym := range syms {
+		e := reportEntry{
+			Name:   sym.Name,
+			Kind:   sym.Kind,
+			Status: status(sym),
+			Reason: sym.Reason,
+		}
+		if sym.Pos.IsValid() {
+			e.Pos = sym.Pos.String()
+		}
+		entries = append(entries, e)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+// writeReport writes syms to w as a table, one symbol per line, followed by
+// the number of bound and skipped symbols.
+func writeReport(w io.Writer, syms []bind.Symbol) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 1, ' ', 0)
+	nbound := 0
+	for _, sym := range syms {
+		if sym.Bound {
+			nbound++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s", status(sym), sym.Kind, sym.Name)
+		if sym.Reason != "" {
+			fmt.Fprintf(tw, "\t(%s)", sym.Reason)
+		}
+		fmt.Fprintf(tw, "\n")
+	}
+	err := tw.Flush()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%d bound, %d skipped\n", nbound, len(syms)-nbound)
+	return err
+}
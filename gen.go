@@ -5,6 +5,7 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"go/ast"
 	"go/build"
@@ -20,6 +21,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/go-python/gopy/bind"
 )
@@ -28,9 +34,71 @@ var (
 	fset = token.NewFileSet()
 )
 
-func genPkg(odir string, p *bind.Package, lang string) error {
+// genOutput writes the files generated for a package into a directory.
+// Files already holding the generated content are left untouched.
+type genOutput struct {
+	dir   string
+	check bool     // only compare the generated files with those of dir
+	stale []string // files of dir which differ from the generated ones
+	files []string // files of dir holding the generated content
+}
+
+func newGenOutput(dir string, check bool) *genOutput {
+	return &genOutput{dir: dir, check: check}
+}
+
+// writeFile writes data into the file name of the output directory, unless
+// that file already holds data.
+// In check mode, files which would change are only recorded as stale.
+func (out *genOutput) writeFile(name string, data []byte) error {
+	fname := filepath.Join(out.dir, name)
+	old, err := ioutil.ReadFile(fname)
+	switch {
+	case err == nil && bytes.Equal(old, data):
+		out.files = append(out.files, fname)
+		return nil
+	case err != nil && !os.IsNotExist(err):
+		return err
+	}
+	if out.check {
+		out.stale = append(out.stale, fname)
+		return nil
+	}
+	err = ioutil.WriteFile(fname, data, 0644)
+	if err == nil {
+		out.files = append(out.files, fname)
+	}
+	return err
+}
+
+// buildInfo describes the running gopy command, for the bindings it
+// generates.
+// The flags listed in skip, which do not change the generated files, are
+// left out of the command line.
+func buildInfo(skip ...string) bind.BuildInfo {
+	version := "devel"
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		version = info.Main.Version
+	}
+
+	args := []string{filepath.Base(os.Args[0])}
+loop:
+	for _, arg := range os.Args[1:] {
+		for _, name := range skip {
+			if arg == "-"+name || strings.HasPrefix(arg, "-"+name+"=") {
+				continue loop
+			}
+		}
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\$`") {
+			arg = strconv.Quote(arg)
+		}
+		args = append(args, arg)
+	}
+	return bind.BuildInfo{Version: version, Cmd: strings.Join(args, " ")}
+}
+
+func genPkg(out *genOutput, p *bind.Package, lang string, py23 bool, naming bind.Naming, async bool, durations bind.Durations, info bind.BuildInfo) error {
 	var err error
-	var o *os.File
 
 	switch lang {
 	case "python", "py":
@@ -48,6 +116,18 @@ func genPkg(odir string, p *bind.Package, lang string) error {
 		pyvers = 2
 	case "python3", "py3":
 		pyvers = 3
+	case "go":
+		if py23 {
+			// the sources compile against both versions: the go side
+			// links against the python found in $PATH.
+			vers, err := getPythonVersion()
+			if err != nil {
+				return err
+			}
+			if vers == "py3" {
+				pyvers = 3
+			}
+		}
 	}
 
 	if err != nil {
@@ -55,127 +135,109 @@ func genPkg(odir string, p *bind.Package, lang string) error {
 	}
 
 	switch lang {
-	case "python2", "py2":
-		o, err = os.Create(filepath.Join(odir, p.Name()+".c"))
+	case "python2", "py2", "python3", "py3":
+		cpyvers := pyvers
+		switch {
+		case py23:
+			cpyvers = 0
+		case pyvers == 3:
+			return fmt.Errorf("gopy: python-3 support not yet implemented (use -py23)")
+		}
+		buf := new(bytes.Buffer)
+		err = bind.GenCPython(buf, fset, p, cpyvers, naming, async, durations, info)
 		if err != nil {
 			return err
 		}
-		defer o.Close()
-		err = bind.GenCPython(o, fset, p, 2)
+		err = out.writeFile(p.Name()+".c", buf.Bytes())
 		if err != nil {
 			return err
 		}
 
-	case "python3", "py3":
-		return fmt.Errorf("gopy: python-3 support not yet implemented")
-
 	case "go":
-		o, err = os.Create(filepath.Join(odir, p.Name()+".go"))
+		cwd, err := os.Getwd()
 		if err != nil {
 			return err
 		}
-		defer o.Close()
 
-		cwd, err := os.Getwd()
+		bindpkg, err := build.Import("github.com/go-python/gopy/bind", cwd, 0)
 		if err != nil {
 			return err
 		}
 
-		bindpkg, err := build.Import("github.com/go-python/gopy/bind", cwd, 0)
+		var tmpdir string
+		tmpdir, err = ioutil.TempDir("", "gopy-go-cgo-")
 		if err != nil {
 			return err
 		}
+		defer os.RemoveAll(tmpdir)
 
 		for _, fname := range []string{
 			"cgopy_seq_cpy.h",
 			"cgopy_seq_cpy.c",
 			"cgopy_seq_cpy.go",
 		} {
-			ftmpl, err := os.Open(filepath.Join(bindpkg.Dir, "_cpy", fname))
+			tmpl, err := ioutil.ReadFile(filepath.Join(bindpkg.Dir, "_cpy", fname))
 			if err != nil {
 				return err
 			}
-			defer ftmpl.Close()
-
-			fout, err := os.Create(filepath.Join(odir, fname))
+			err = out.writeFile(fname, tmpl)
 			if err != nil {
 				return err
 			}
-			defer fout.Close()
-
-			_, err = io.Copy(fout, ftmpl)
-			if err != nil {
-				return err
-			}
-			err = fout.Close() // explicit to catch filesystem errors
+			// the export headers are generated from the sources in tmpdir.
+			err = ioutil.WriteFile(filepath.Join(tmpdir, fname), tmpl, 0644)
 			if err != nil {
 				return err
 			}
 		}
 
-		err = bind.GenGo(o, fset, p, pyvers)
+		buf := new(bytes.Buffer)
+		err = bind.GenGo(buf, fset, p, pyvers)
 		if err != nil {
 			return err
 		}
-
-		var tmpdir string
-		tmpdir, err = ioutil.TempDir("", "gopy-go-cgo-")
+		err = out.writeFile(p.Name()+".go", buf.Bytes())
 		if err != nil {
 			return err
 		}
-		defer os.RemoveAll(tmpdir)
-
-		hdr := filepath.Join(odir, p.Name()+".h")
-		cmd := exec.Command(
-			"go", "tool", "cgo",
-			"-exportheader", hdr,
-			o.Name(),
-		)
-		cmd.Dir = tmpdir
-		cmd.Stdin = os.Stdin
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		err = cmd.Run()
+		err = ioutil.WriteFile(filepath.Join(tmpdir, p.Name()+".go"), buf.Bytes(), 0644)
 		if err != nil {
 			return err
 		}
 
-		seqhdr := filepath.Join(odir, "_cgopy_seq_export.h")
-		cmd = exec.Command(
-			"go", "tool", "cgo",
-			"-exportheader", seqhdr,
-			filepath.Join(odir, "cgopy_seq_cpy.go"),
-		)
-		cmd.Dir = tmpdir
-		cmd.Stdin = os.Stdin
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		err = cmd.Run()
-		if err != nil {
-			return err
-		}
-
-	default:
-		return fmt.Errorf("unknown target language: %q\n", lang)
-	}
+		for _, hdr := range []struct {
+			name string // name of the export header
+			src  string // go file exporting the funcs
+		}{
+			{p.Name() + ".h", p.Name() + ".go"},
+			{"_cgopy_seq_export.h", "cgopy_seq_cpy.go"},
+		} {
+			cmd := exec.Command(
+				"go", "tool", "cgo",
+				"-exportheader", hdr.name,
+				hdr.src,
+			)
+			cmd.Dir = tmpdir
+			cmd.Stdin = os.Stdin
+			cmd.Stdout = os.Stdout
+			cmd.Stderr = os.Stderr
+			err = cmd.Run()
+			if err != nil {
+				return err
+			}
 
-	if err != nil {
-		if list, _ := err.(bind.ErrorList); len(list) > 0 {
-			for _, err := range list {
-				log.Printf("%v\n", err)
+			data, err := ioutil.ReadFile(filepath.Join(tmpdir, hdr.name))
+			if err != nil {
+				return err
+			}
+			err = out.writeFile(hdr.name, data)
+			if err != nil {
+				return err
 			}
-		} else {
-			log.Printf("%v\n", err)
 		}
-	}
 
-	if err != nil {
-		return err
-	}
-
-	err = o.Close()
-	if err != nil {
-		return err
+	default:
+		return fmt.Errorf("unknown target language: %q\n", lang)
 	}
 
 	return err
@@ -207,13 +269,107 @@ func parseFiles(dir string, fnames []string) ([]*ast.File, error) {
 	return files, err
 }
 
-func newPackage(path string) (*bind.Package, error) {
+// loadConfig selects the build tags and the target platform for which a
+// package is loaded and built. The zero value targets the host platform,
+// without extra build tags.
+type loadConfig struct {
+	tags   []string // extra build tags
+	goos   string   // target operating system, if not the host's
+	goarch string   // target architecture, if not the host's
+
+	// extra flags for the C compiler and the linker, passed to cgo for
+	// the bound package, when it uses cgo, as for the generated binder.
+	cflags  string
+	ldflags string
+
+	// how the net.IP and *url.URL values of the bound package are
+	// exchanged with python.
+	nets bind.NetTypes
+}
+
+// newLoadConfig returns the load config for the comma- or space-separated
+// build tags and the target goos and goarch.
+func newLoadConfig(tags, goos, goarch string) loadConfig {
+	return loadConfig{
+		tags: strings.FieldsFunc(tags, func(r rune) bool {
+			return r == ',' || r == ' '
+		}),
+		goos:   goos,
+		goarch: goarch,
+	}
+}
+
+// isHost returns whether cfg loads packages as the go tool does by default.
+func (cfg loadConfig) isHost() bool {
+	return len(cfg.tags) == 0 && cfg.goos == "" && cfg.goarch == ""
+}
+
+// context returns the build context selecting the files of packages.
+func (cfg loadConfig) context() *build.Context {
+	ctx := build.Default
+	if cfg.goos != "" {
+		ctx.GOOS = cfg.goos
+	}
+	if cfg.goarch != "" {
+		ctx.GOARCH = cfg.goarch
+	}
+	ctx.BuildTags = append(ctx.BuildTags[:len(ctx.BuildTags):len(ctx.BuildTags)], cfg.tags...)
+	return &ctx
+}
+
+// goFlags returns the flags passing the build tags to the go tool.
+func (cfg loadConfig) goFlags() []string {
+	if len(cfg.tags) == 0 {
+		return nil
+	}
+	return []string{"-tags=" + strings.Join(cfg.tags, ",")}
+}
+
+// env returns env, with the target platform of the go tool set and the
+// extra cgo flags added.
+func (cfg loadConfig) env(env []string) []string {
+	env = cgoEnv(env, cfg.cflags, cfg.ldflags)
+	if cfg.goos != "" {
+		env = append(env, "GOOS="+cfg.goos)
+	}
+	if cfg.goarch != "" {
+		env = append(env, "GOARCH="+cfg.goarch)
+	}
+	return env
+}
+
+// goCmd returns the go tool command running args, with the build tags
+// and the target platform of cfg.
+func (cfg loadConfig) goCmd(cmd string, args ...string) *exec.Cmd {
+	args = append(append([]string{cmd}, cfg.goFlags()...), args...)
+	c := exec.Command("go", args...)
+	c.Env = cfg.env(os.Environ())
+	return c
+}
+
+// lookup opens the export data of the package path, as compiled by the go
+// tool with the build tags and for the target platform of cfg.
+func (cfg loadConfig) lookup(path string) (io.ReadCloser, error) {
+	cmd := cfg.goCmd("list", "-export", "-f", "{{.Export}}", path)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("gopy: could not find export data of %q: %v", path, err)
+	}
+	fname := strings.TrimSpace(string(out))
+	if fname == "" {
+		return nil, fmt.Errorf("gopy: no export data for %q", path)
+	}
+	return os.Open(fname)
+}
+
+func newPackage(path string, cfg loadConfig) (*bind.Package, error) {
 	cwd, err := os.Getwd()
 	if err != nil {
 		return nil, err
 	}
 
-	cmd := exec.Command("go", "install", path)
+	cmd := cfg.goCmd("install", path)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -228,7 +384,7 @@ func newPackage(path string) (*bind.Package, error) {
 		return nil, err
 	}
 
-	bpkg, err := build.Import(path, cwd, 0)
+	bpkg, err := cfg.context().Import(path, cwd, 0)
 	if err != nil {
 		log.Printf("error resolving import path [%s]: %v\n",
 			path,
@@ -237,7 +393,16 @@ func newPackage(path string) (*bind.Package, error) {
 		return nil, err
 	}
 
-	pkg, err := importer.Default().Import(bpkg.ImportPath)
+	// the export data of other platforms or build tags is not where the
+	// default importer looks for it.
+	var lookup importer.Lookup
+	if !cfg.isHost() {
+		lookup = cfg.lookup
+	}
+
+	// import through fset, so diagnostics can report the positions of
+	// the entities which could not be bound.
+	pkg, err := importer.ForCompiler(fset, "gc", lookup).Import(bpkg.ImportPath)
 	if err != nil {
 		log.Printf("error importing package [%v]: %v\n",
 			bpkg.ImportPath,
@@ -246,7 +411,7 @@ func newPackage(path string) (*bind.Package, error) {
 		return nil, err
 	}
 
-	p, err := newPackageFrom(bpkg, pkg)
+	p, err := newPackageFrom(bpkg, pkg, cfg.nets)
 	if err != nil {
 		log.Printf("%v\n", err)
 		return nil, err
@@ -255,10 +420,55 @@ func newPackage(path string) (*bind.Package, error) {
 	return p, err
 }
 
-func newPackageFrom(bpkg *build.Package, p *types.Package) (*bind.Package, error) {
+// logDiagnostics logs the entities of p bound in a restricted way, and the
+// ones which could not be bound, followed by a summary such as "skipped 3
+// functions, 1 field (unsupported types or signatures)".
+func logDiagnostics(p *bind.Package) {
+	for _, note := range p.Notes() {
+		log.Printf("%v\n", note)
+	}
+
+	diags := p.Diagnostics()
+	if len(diags) == 0 {
+		return
+	}
+
+	var kinds []string
+	count := make(map[string]int)
+	for _, err := range diags {
+		log.Printf("%v\n", err)
+		if d, ok := err.(*bind.Diagnostic); ok {
+			if count[d.Kind] == 0 {
+				kinds = append(kinds, d.Kind)
+			}
+			count[d.Kind]++
+		}
+	}
+
+	summary := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		n := count[kind]
+		if n > 1 {
+			kind += "s"
+		}
+		summary = append(summary, fmt.Sprintf("%d %s", n, kind))
+	}
+	log.Printf("gopy: skipped %s (unsupported types or signatures)\n", strings.Join(summary, ", "))
+}
+
+func newPackageFrom(bpkg *build.Package, p *types.Package, nets bind.NetTypes) (*bind.Package, error) {
 
 	var pkgast *ast.Package
-	pkgs, err := parser.ParseDir(fset, bpkg.Dir, nil, parser.ParseComments)
+	// only the files selected by the build tags and the target platform
+	// hold the docs and directives of the package.
+	files := make(map[string]bool)
+	for _, fnames := range [][]string{bpkg.GoFiles, bpkg.CgoFiles} {
+		for _, fname := range fnames {
+			files[fname] = true
+		}
+	}
+	filter := func(fi os.FileInfo) bool { return files[fi.Name()] }
+	pkgs, err := parser.ParseDir(fset, bpkg.Dir, filter, parser.ParseComments)
 	if err != nil {
 		return nil, err
 	}
@@ -267,7 +477,113 @@ func newPackageFrom(bpkg *build.Package, p *types.Package) (*bind.Package, error
 		return nil, fmt.Errorf("gopy: could not find AST for package %q", p.Name())
 	}
 
-	pkgdoc := doc.New(pkgast, bpkg.ImportPath, 0)
+	// keep the doc comments in the AST, for the //gopy: directives.
+	pkgdoc := doc.New(pkgast, bpkg.ImportPath, doc.PreserveAST)
+
+	return bind.NewPackage(fset, p, pkgdoc, nets)
+}
+
+// pyInitHeader starts the __init__.py files generated by genPyPackage.
+const pyInitHeader = "# File is generated by gopy bind. Do not edit."
+
+var pyIdent = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// genPyPackage creates the directories of the dotted python package pypkg
+// (e.g. "myproject.gobindings") under odir, and returns the directory of its
+// innermost package, where the extension module modname is to be written.
+// Missing __init__.py files of the enclosing packages are created empty.
+// The __init__.py of the innermost package imports the extension modules
+// bound into it, relatively, so the directory can be moved into a larger
+// project as a subpackage.
+func genPyPackage(odir, pypkg, modname string) (string, error) {
+	err := checkPyPackage(pypkg)
+	if err != nil {
+		return "", err
+	}
+	names := strings.Split(pypkg, ".")
+
+	dir := odir
+	for _, name := range names[:len(names)-1] {
+		dir = filepath.Join(dir, name)
+		err := os.MkdirAll(dir, 0755)
+		if err != nil {
+			return "", err
+		}
+		fname := filepath.Join(dir, "__init__.py")
+		f, err := os.OpenFile(fname, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		err = f.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	dir = filepath.Join(dir, names[len(names)-1])
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
+	}
+
+	// keep the modules bound by previous runs.
+	fname := filepath.Join(dir, "__init__.py")
+	mods := []string{modname}
+	buf, err := ioutil.ReadFile(fname)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return "", err
+	case !bytes.HasPrefix(buf, []byte(pyInitHeader)):
+		log.Printf("gopy: %s not generated by gopy, left untouched\n", fname)
+		return dir, nil
+	default:
+		for _, line := range strings.Split(string(buf), "\n") {
+			if !strings.HasPrefix(line, "from . import ") {
+				continue
+			}
+			mod := strings.TrimPrefix(line, "from . import ")
+			if mod != modname {
+				mods = append(mods, mod)
+			}
+		}
+	}
+	sort.Strings(mods)
+
+	init := new(bytes.Buffer)
+	fmt.Fprintf(init, "%s\n\n", pyInitHeader)
+	fmt.Fprintf(init, "# package %s holds go bindings.\n", pypkg)
+	fmt.Fprintf(init, "from __future__ import absolute_import\n\n")
+	for _, mod := range mods {
+		fmt.Fprintf(init, "from . import %s\n", mod)
+	}
+	fmt.Fprintf(init, "\n__all__ = [%s]\n", pyStrings(mods))
 
-	return bind.NewPackage(p, pkgdoc)
+	err = ioutil.WriteFile(fname, init.Bytes(), 0644)
+	if err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// checkPyPackage returns an error if pypkg is not a dotted python package name.
+func checkPyPackage(pypkg string) error {
+	for _, name := range strings.Split(pypkg, ".") {
+		if !pyIdent.MatchString(name) {
+			return fmt.Errorf("gopy: invalid python package name %q", pypkg)
+		}
+	}
+	return nil
+}
+
+// pyStrings formats strs as the items of a python list literal.
+func pyStrings(strs []string) string {
+	quoted := make([]string, len(strs))
+	for i, str := range strs {
+		quoted[i] = fmt.Sprintf("%q", str)
+	}
+	return strings.Join(quoted, ", ")
 }
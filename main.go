@@ -26,6 +26,7 @@ func init() {
 		Subcommands: []*commander.Command{
 			gopyMakeCmdGen(),
 			gopyMakeCmdBind(),
+			gopyMakeCmdInspect(),
 		},
 		Flag: *flag.NewFlagSet("gopy", flag.ExitOnError),
 	}
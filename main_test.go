@@ -6,16 +6,20 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
 type pkg struct {
 	path string
+	args []string // extra arguments to gopy-bind
+	py3  bool     // bind with -lang=py3 -py23, and run test.py with python-3
 	want []byte
 }
 
@@ -30,7 +34,16 @@ func testPkg(t *testing.T, table pkg) {
 	}
 	defer os.RemoveAll(workdir)
 
-	cmd := exec.Command("gopy", "bind", "-output="+workdir, "./"+table.path)
+	python := "python2"
+	args := append([]string{"bind", "-output=" + workdir}, table.args...)
+	cmd := exec.Command("gopy", append(args, "./"+table.path)...)
+	if table.py3 {
+		python = python3(t, workdir)
+		args = append(args, "-lang=py3", "-py23")
+		cmd = exec.Command("gopy", append(args, "./"+table.path)...)
+		// the go side links against the python found in $PATH.
+		cmd.Env = append(os.Environ(), "PATH="+filepath.Dir(python)+string(os.PathListSeparator)+os.Getenv("PATH"))
+	}
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -39,10 +52,12 @@ func testPkg(t *testing.T, table pkg) {
 		t.Fatalf("[%s]: error running gopy-bind: %v\n", table.path, err)
 	}
 
-	cmd = exec.Command(
-		"/bin/cp", "./"+table.path+"/test.py",
-		filepath.Join(workdir, "test.py"),
-	)
+	// test.py may import the other python files of the package.
+	scripts, err := filepath.Glob("./" + table.path + "/*.py")
+	if err != nil {
+		t.Fatalf("[%s]: error listing python files: %v\n", table.path, err)
+	}
+	cmd = exec.Command("/bin/cp", append(scripts, workdir)...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
@@ -52,7 +67,7 @@ func testPkg(t *testing.T, table pkg) {
 	}
 
 	buf := new(bytes.Buffer)
-	cmd = exec.Command("python2", "./test.py")
+	cmd = exec.Command(python, "./test.py")
 	cmd.Dir = workdir
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = buf
@@ -121,21 +136,21 @@ package hi exposes a few Go functions to be wrapped and used from Python.
 --- hi.SetAnon(hi.NewPerson('you', 24))...
 --- hi.GetAnon(): hi.Person{Name="you", Age=24}
 --- doc(hi.Hi)...
-Hi() 
+func Hi()
 
 Hi prints hi from Go
 
 --- hi.Hi()...
 hi from go
 --- doc(hi.Hello)...
-Hello(str s) 
+func Hello(s string)
 
 Hello prints a greeting from Go
 
 --- hi.Hello('you')...
 hello you from go
 --- doc(hi.Add)...
-Add(int i, int j) int
+func Add(i int, j int) int
 
 Add returns the sum of its arguments.
 
@@ -152,7 +167,7 @@ Person is a simple struct
 --- p.Name: 
 --- p.Age: 0
 --- doc(hi.Greet):
-Greet() str
+func (p *hi.Person) Greet() string
 
 Greet sends greetings
 
@@ -232,7 +247,6 @@ mem(slice): 2
 }
 
 func TestBindFuncs(t *testing.T) {
-	t.Skip("bind/seq") // FIXME(sbinet)
 	t.Parallel()
 	testPkg(t, pkg{
 		path: "_examples/funcs",
@@ -250,6 +264,26 @@ s2 = funcs.S2()...
 s2.F1 = funcs.GetF1()...
 calling F1
 s2.F1() = None
+s1.F1 = python func...
+calling python func
+s1.F1() = None
+s2.F1 = python lambda...
+calling python lambda
+s2.F1() = None
+funcs.Op(python lambda)...
+op(6, 7) = 42
+p = funcs.Plugin(Name='add', Op=python lambda, Check=python func)...
+p.Run(1, 2) = 3
+caught: funcs: plugin "add": 30 is too big
+p.Op = 42...
+caught: invalid type for 'Op' attribute
+funcs.Spawn(funcs.Op(python lambda), 6, 7)...
+funcs.Spawn(...) = 42
+funcs.Spawn(funcs.Op(nested), 5, 3) = 20
+outer.Run(6, 7) = 43
+p.Go(4, 5) = 9
+funcs.Fire(4, 5) = 29
+threads: [-10, 0, 10, 20]
 `),
 	})
 }
@@ -264,6 +298,11 @@ pkg.Func()...
 fct = pkg.Func...
 fct()...
 pkg.Add(1,2)= 3
+buf.write(b'gopy') = 4, len(buf) = 4
+pkg.__gopy_package__ = github.com/go-python/gopy/_examples/simple
+pkg.__gopy_cmd__ = gopy bind ... ./_examples/simple
+pkg.__gopy_version__ is set: True
+pkg.__gopy_build_time__ is a date: True
 `),
 	})
 }
@@ -296,13 +335,12 @@ s.Value = 3
 }
 
 func TestBindNamed(t *testing.T) {
-	t.Skip("bind/seq") // FIXME(sbinet)
 	t.Parallel()
 	testPkg(t, pkg{
 		path: "_examples/named",
 		want: []byte(`doc(named): 'package named tests various aspects of named types.\n'
 doc(named.Float): ''
-doc(named.Float.Value): 'Value() float\n\nValue returns a float32 value\n'
+doc(named.Float.Value): 'func (f named.Float) Value() float32\n\nValue returns a float32 value\n'
 v = named.Float()
 v = 0
 v.Value() = 0.0
@@ -340,10 +378,10 @@ x = named.XXXX(42.0)
 x = 42
 x.Value() = 42.0
 s = named.Str()
-s = ""
+s = 
 s.Value() = ''
 s = named.Str('string')
-s = "string"
+s = string
 s.Value() = 'string'
 arr = named.Array()
 arr = named.Array{0, 0}
@@ -384,6 +422,15 @@ caught error: S2.__init__ takes at most 1 argument(s)
 s2 = structs.S2{Public:42, private:0}
 s2.Public = 42
 caught error: 'structs.S2' object has no attribute 'private'
+handles after creating 10 values: +10
+handles after releasing them: +0
+a == b: True, a != b: False
+a == c: False, a != c: True
+a == a: True
+after c.Points[1] = 2, a == c: True
+a == 'a': False, a == None: False
+caught: unhashable type: 'structs.Path'
+structs.S2(1) == structs.S2(1): False
 `),
 	})
 }
@@ -401,6 +448,24 @@ c6 = 42
 c7 = 666.666
 k1 = 1
 k2 = 2
+['a', 'b', 'c'][consts.GetKind1()] = b
+'abc'[:consts.Kind(2)] = ab
+range(consts.Kind(3)) = [0, 1, 2]
+int(consts.Kind(-4)) = -4
+consts.C1 = c1
+consts.C2 = 42
+consts.C3 = 666.666
+consts.C6 = 42
+consts.Kind1 = 1
+type(consts.Kind1) = Kind
+consts.Kind2 = 2
+consts.Kind1.name = Kind1
+consts.Kind(1).name = Kind1
+consts.Kind(3).name = None
+consts.Red = red
+consts.Red.name = Red
+consts.Rouge.name = Red
+consts.Color('green').name = Green
 `),
 	})
 }
@@ -427,12 +492,627 @@ v6 = 24
 v7 = -666.666
 k1 = 11
 k2 = 22
+vars.Debug = False
+vars.Debug = True
+vars.Debug = True
+vars.GetDebug() = True
+vars.V1 = -v1 again-
+vars.Kind1 = 11
+vars.Settings.Name = default
+vars.Settings = s
+vars.Settings.Name = custom
+vars.V2 = 'x'
+caught: an integer is required
+del vars.Debug
+caught: cannot delete 'Debug' attribute
+vars.Other = 1
+vars.Other = 1
+vars.SetLevel(5) = 3
+vars.Level = 3
+vars.GetLevel() = 3
+`),
+	})
+}
+
+func TestBindErrs(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/errs",
+		want: []byte(`doc(errs): 'package errs tests the wrapping of error values.\n'
+err = not found
+err.Error() = 'not found'
+errs.Find('gopy')...
+errs.Find('foo')...
+caught: not found
+r.Err = not found
+r.Err == err: True
+r.Err = None
+r.Err == err: False
+r.Err is None: True
+errs.errors_is(None, err): False
+caught: nil error
+r.Err = errs: stat /foo: not found
+r.Err == err: False
+errs.errors_is(r.Err, err): True
+errs.errors_is(r.Err, errs.Lookup('bar').Err): True
+errs.errors_is(errs.Stat('/gopy').Err, err): False
+errs.errors_as(r.Err, errs.PathError): PathError stat /foo
+errs.errors_as(r.Err, errs.PathError).Err == err: True
+errs.errors_as(err, errs.PathError): None
+caught: errors_as() argument 2 must be a go interface or error type, not int
+errs.Parse('123') = 3
+errs.Parse('1a2') = 2
+errs.Result().Check() = None
+warning: UserWarning: skipped non-digits in 1a2
+warning: UserWarning: empty name
+caught: skipped non-digits in x
+errs.Open('/gopy') = /gopy
+caught: open /tmp: not found
+errs.Blame('/tmp') = PathError, p.Path = /tmp
+caught: no path to blame
+`),
+	})
+}
+
+func TestBindNaming(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/naming",
+		args: []string{"-naming=snake"},
+		want: []byte(`naming.http_get_url('example.com') = 'http://example.com'
+naming.get_max_conns() = 8
+s = naming.Server(http_addr='localhost', max_conns=2)
+s.http_addr = 'localhost'
+s.max_conns = 2
+s.conn_id(3) = 'localhost#3'
+s.type_() = 'server'
+'type' in dir(s): False
+s.type_.__doc__ = 'func (s *naming.Server) Type() string\n\nType returns the type of the server, renamed type_ in python.\n\n(type is a python builtin: exposed as type_.)'
+n = naming.Node(class_='leaf', id_=1)
+n.class_ = 'leaf'
+n.id_ = 1
+naming.import_() = 'nothing'
+naming.import_(from_=s) = 'localhost'
+naming.get_base_url() = 'http://localhost/'
+naming.base_url = 'http://localhost'
+`),
+	})
+}
+
+func TestBindExtTypes(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/exttypes",
+		want: []byte(`buf = hello
+buf.Len() = 5
+buf.WriteString(' world') = 6
+buf.String() = 'hello world'
+buf.ReadString(32) = 'hello '
+buf.String() = 'world'
+buf.Len() = 0
+ReadAll(r) = 'some data'
+ReadAll(r) = ''
+exttypes.Buffer() = ''
+buf.Write(b'hello') = 5
+buf.Write(bytearray(b' world')) = 6
+buf.String() = 'hello world', buf.Len() = 11
+buf.Next(3) = 'hel'
+buf.Read(p) = 4, p = 'lo w'
+buf.Bytes() = 'orld'
+buf.write(b'hello world') = 11
+len(buf) = 11
+buf.read(5) = 'hello'
+buf.string() = ' world', len(buf) = 6
+buf.read(-1) = ' world', len(buf) = 0
+r.Read(p) = 4, p = 'more'
+ReadAll(r) = ' data'
+c.Timeout = 1.5
+c has CheckRedirect: False
+t.Name() = 'text', h.Name() = 'html'
+same type: False
+t.Lookup('text').Name() = 'text'
+`),
+	})
+}
+
+func TestBindStrs(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/strs",
+		want: []byte(`s = strs.Str('string')
+s = string
+len(s) = 6
+s[0] = 's'
+s[-1] = 'g'
+s[1:4] = 'tri'
+str(s) = 'string'
+s.Upper() = 'STRING'
+s = strs.Hello('world')
+s = hello world
+len(s) = 11
+s = strs.Str('h\xc3\xa9llo')
+len(s) = 5
+s[1] = '\xc3\xa9'
+s[1:] = '\xc3\xa9llo'
+caught: string index out of range
+`),
+	})
+}
+
+func TestBindOptions(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/options",
+		want: []byte(`s = options.New()
+s.Name = 'default', s.Size = 1
+s = options.New([options.WithName('srv'), options.WithSize(42)])
+s.Name = 'srv', s.Size = 42
+s = options.New(options.WithSize(3), options.WithName('gopy'))
+s.Name = 'gopy', s.Size = 3
+g = s.Greeter()
+g('world') = 'hello world from gopy'
+s = options.New([42])
+caught: invalid type (got=int, expected a options.Option)
+`),
+	})
+}
+
+func TestBindSets(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/sets",
+		want: []byte(`s = sets.NewSet('a', 'b')
+s.Contains('a') = True
+'a' in s = True
+'z' in s = False
+'z' not in s = True
+s.Add('z')
+'z' in s = True
+1 in s
+caught: expected string or Unicode object, int found
+r = sets.Range()
+5 in r = True
+42 in r = False
+`),
+	})
+}
+
+func TestBindMaps(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/maps",
+		want: []byte(`m = maps.MapsFunc2()
+len(m) = 2
+m[1] = hello
+m[2] = world
+1 in m = True, 3 in m = False
+m[3] = 'again'
+maps.Describe(m) = 1:hello 2:world 3:again
+del m[1]
+maps.Describe(m) = 2:world 3:again
+m[1]
+caught: KeyError 1
+del m[1]
+caught: KeyError 1
+m['x']
+caught: TypeError: an integer is required
+m[4] = 4
+caught: TypeError: expected string or Unicode object, int found
+m.keys() = [2, 3]
+m.values() = ['world', 'again']
+m.items() = [(2, 'world'), (3, 'again')]
+for k in m: k = 2, m[k] = world
+for k in m: k = 3, m[k] = again
+for k, v in m.items(): k = 2, v = world
+for k, v in m.items(): k = 3, v = again
+dict(m) = [(2, 'world'), (3, 'again')]
+s = maps.Scores()
+s[Key(a, 1)].Score = 0.5
+s[Key(c, 3)] = Value(Score=2.0)
+len(s) = 3
+maps.Sum(s) = 4.0
+Key(a, 2) in s = False
+s.items(): a/1 = 0.5
+s.items(): b/2 = 1.5
+s.items(): c/3 = 2.0
+s['a']
+caught: TypeError: invalid type (got=str, expected a maps.Key)
+f = maps.Flags({'x': True, 'y': False, 'z': True})
+f.Enabled() = x,z
+f.Enabled() = x,y,z
+g = maps.Flags(f)
+g.keys() = ['x', 'y', 'z']
+maps.Flags([1])
+caught: Flags.__init__ takes a mapping as argument
+maps.Config({'width': 80, 'height': 24}) = height=24 width=80
+maps.Describe({1: 'a', 2: 'b'}) = 1:a 2:b
+maps.Config({'width': 80, 'height': 'tall'})
+caught: invalid item 'height': an integer is required
+maps.Config({1: 80})
+caught: invalid item 1: expected string or Unicode object, int found
+`),
+	})
+}
+
+func TestBindDicts(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/dicts",
+		want: []byte(`cfg = dicts.Config()
+type(cfg) = dict
+cfg['db'] = {'host': 'localhost'}
+cfg['debug'] = True
+cfg['name'] = 'gopy'
+cfg['none'] = None
+cfg['port'] = 8080
+cfg['ratio'] = 0.5
+cfg['tags'] = ['a', 'b']
+dicts.Describe(cfg)
+db=map[host:localhost] (map[string]interface {}), debug=true (bool), name=gopy (string), none=<nil> (<nil>), port=8080 (int), ratio=0.5 (float64), tags=[a b] ([]interface {})
+dicts.Describe({'x': 1, 'y': [1.5, u'\xe9'], 'z': {'ok': False}})
+x=1 (int), y=[1.5 é] ([]interface {}), z=map[ok:false] (map[string]interface {})
+dicts.Describe({1: 'one'})
+caught: invalid dict key type (got=int, expected a str)
+dicts.Describe({'f': object()})
+caught: invalid value type (got=object)
+dicts.Describe([1])
+caught: invalid type (got=list, expected a dict)
+`),
+	})
+}
+
+func TestBindAnys(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/anys",
+		want: []byte(`anys.Describe(None) = <nil> (<nil>)
+anys.Describe(True) = true (bool)
+anys.Describe(42) = 42 (int)
+anys.Describe(1099511627776) = 1099511627776 (int)
+anys.Describe(1.5) = 1.5 (float64)
+anys.Describe('str') = str (string)
+anys.Describe(u'\xe9') = é (string)
+anys.Describe([1, 'a']) = [1 a] ([]interface {})
+anys.Describe((2.5,)) = [2.5] ([]interface {})
+anys.Describe({'k': [None]}) = map[k:[<nil>]] (map[string]interface {})
+anys.Identity(None) = None
+anys.Identity(False) = False
+anys.Identity(42) = 42
+anys.Identity(1.5) = 1.5
+anys.Identity('str') = 'str'
+anys.Identity([1, [2]]) = [1, [2]]
+anys.Identity({'k': {'v': 1}}) = {'k': {'v': 1}}
+p = anys.Origin()
+type(p) = Point
+anys.Describe(p) = &{0 0} (*anys.Point)
+q = anys.Identity(p)
+type(q) = Point
+anys.Same(p, q) = True
+anys.Same(p, anys.Origin()) = False
+anys.Move(p, 1, 2)
+p.X, p.Y = 1, 2
+q.X, q.Y = 1, 2
+r.X, r.Y = 1, 2
+l = anys.Identity([p, {'p': p}])
+anys.Same(l[0], p) = True
+anys.Same(l[1]['p'], p) = True
+anys.Describe(object())
+caught: invalid value type (got=object)
+anys.Describe(2**70)
+caught: long too big to convert
+anys.Unsupported('complex')
+caught: seq: unsupported value type complex128
+anys.Unsupported('map')
+caught: seq: unsupported map key type int
+anys.Unsupported('chan')
+caught: seq: unsupported value type chan int
+anys.Unsupported('func')
+caught: seq: unsupported value type func()
+anys.Unsupported('list')
+caught: seq: unsupported value type complex128
+anys.Uints() = [255, 9223372036854775807, 9223372036854775808L, 18446744073709551615L]
+`),
+	})
+}
+
+func TestBindBlocking(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/blocking",
+		want: []byte(`doc(blocking.Sleep): 'func Sleep(ms int)\n\nSleep sleeps for ms milliseconds.\n'
+e.Wait(10000) = True
+e.Wait(10) = False
+`),
+	})
+}
+
+func TestBindSubpkg(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/subpkg",
+		args: []string{"-package=myproject.gobindings"},
+		want: []byte(`subpkg.__name__ = myproject.gobindings.subpkg
+myproject.gobindings.__all__ = ['subpkg']
+myproject.gobindings.subpkg is subpkg = True
+subpkg.Hello('gopy') = hello gopy
+`),
+	})
+}
+
+func TestBindAnonstructs(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/anonstructs",
+		want: []byte(`type(st) = StructSizeName
+doc(st) = 'StructSizeName wraps the anonymous struct{Size int64; Name string}.'
+st.Size, st.Name = 42, file.txt
+type(lst) is type(st) = True
+anonstructs.Describe(lst) = link
+caught: AttributeError
+type(seg) = StructFromToMeta
+seg.From.X, seg.To.Y = 1, 4
+seg.Meta.Label = seg
+hasattr(anonstructs, 'Opaque') = False
+`),
+	})
+}
+
+func TestBindGenerics(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/generics",
+		want: []byte(`type(ints) = ListInt
+doc(ints) = 'ListInt wraps generics.List[int].'
+ints.Len() = 4
+ints.At(3) = 4
+ints = List[1 2 3 4]
+generics.Sum(ints) = 10
+type(strs) = ListString
+strs.At(0) = hello
+type(p) = PairString
+p.First, p.Second = b, a
+type(pairs) = ListPairInt
+pairs.At(1).Second = 4
+generics.Sum(l) = 7
+hasattr(generics, 'Unused') = False
+`),
+	})
+}
+
+func TestBindClosures(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/closures",
+		want: []byte(`type(c) = FuncRetInt
+doc(c) = 'FuncRetInt wraps the unnamed func() int.'
+c() = 1
+c() = 2
+c() = 3
+c2() = 1
+c() = 4
+c() = 5
+closures.Apply(c, 10) = 16
+type(deposit) = FuncIntRetError
+a.Balance = 42
+deposit(8) = None
+caught: invalid amount -1
+type(scale) = FuncIntRetInt
+scale(14) = 42
+caught: invalid factor 0
+closures.Named('double')(21) = 42
+closures.Named('') = None
+closures.Lookup('double')(21) = 42
+closures.Lookup('') = None
+`),
+	})
+}
+
+func TestBindFiles(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/files",
+		want: []byte(`r, w = os.pipe()
+files.WriteString(w, 'from go\n')
+files.Close(w)
+os.write(w, 'still open\n')
+os.read(r, 64) = 'from go\nstill open\n'
+f = open(name, 'w')
+files.WriteString(f, 'from go\n')
+open(name).read() = 'from python\nfrom go\nfrom python again\n'
+fd = files.Open(name)
+type(fd) = int
+f.read() = 'from python\nfrom go\nfrom python again\n'
+files.ReadAll(open(name)) = 'from python\nfrom go\nfrom python again\n'
+files.Open(missing)
+caught: RuntimeError
+files.IsNil(None) = True
+files.Null() = None
+fd != 1: True
+files.WriteString('x', 'y')
+caught: argument must be an int, or have a fileno() method
+files.WriteString(bad, 'y')
+caught: Bad file descriptor
+l = files.Logger()
+l.Log('hello')
+type(l.Out) = int
+l.Out = None
+os.read(r, 64) = '> hello\n'
+`),
+	})
+}
+
+func TestBindBigs(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/bigs",
+		want: []byte(`len(str(n)) = 200
+bigs.Mul(n, 1) == n: True
+bigs.Neg(n) == -n: True
+bigs.Mul(n, n) == n*n: True
+bigs.Mul(6, 7) = 42
+bigs.Neg(None) = None
+bigs.Neg() = None
+bigs.Factorial(30) = 265252859812191058636308480000000
+bigs.Half(3.0) = 1.5
+bigs.Half(1e300) = 5e+299
+int(bigs.Half(n)) == n//2: True
+bigs.Half(decimal.Decimal('0.1')) = Decimal('0.05')
+bigs.Prec(0.1) = 53
+bigs.Third(53) = 0.3333333333333333
+bigs.Third(100) = Decimal('0.3333333333333333333333333333335')
+bigs.Half(float('inf')) = inf
+bigs.Mul(1.5, 2)
+caught: invalid type (got=float, expected an int)
+bigs.Half(float('nan'))
+caught: invalid value nan (expected a number)
+a = bigs.Account(Owner='bob')
+a.Balance = None
+a.Deposit(n)
+a.Deposit(1)
+a.Balance == n+1: True
+a.Balance = 5
+a.Balance = 5
+`),
+	})
+}
+
+func TestBindStringers(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/stringers",
+		want: []byte(`str(p) = (1, 2)
+repr(p) = stringers.Point{X:1, Y:2}
+str(f) = open failed with code 2
+repr(f) is default: True
+str(s) = status 3
+str(c) = error code 4
+repr(c) = stringers.Code(4)
+repr([c]) = [stringers.Code(4)]
+str(pl) = stringers.Plain{Name:"plain"}
+repr(pl) is default: True
+`),
+	})
+}
+
+func TestBindSubclass(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/subclass",
+		want: []byte(`isinstance(s, subclass.Person) = True
+s.Introduce() = hello, I am alice, from gopher school
+s.Age = 21
+subclass.Describe(s) = alice (21)
+a.Greet() = hello, I am bob
+t.Fahrenheit() = 212.0
+t.Boiling() = True
+subclass.Person.zero() =  (0)
+repr(z.Name) = ''
+type(Student.zero()) = Student
+hasattr(Student.zero(), 'school') = False
+subclass.NewPerson('bob') = bob
+hasattr(subclass.Celsius, 'zero') = False
+doc(subclass.Person.Greet) = 'func (p *subclass.Person) Greet() string\n\nGreet returns a greeting from p.\n'
+handles after creating 5 subclass instances: +5
+handles after releasing them: +0
+`),
+	})
+}
+
+func TestBindChans(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/chans",
+		want: []byte(`events = [('w', 0), ('w', 1), ('w', 2)]
+err = receive from closed channel
+len = 2, cap = 3
+sum = 3
+send: send on closed channel
+close: close of closed channel
+unbuffered cap = 0
+recv = 42
+results have Send: False
+select = (1, 7, True)
+select(poll) = (-1, None, False)
+select(timeout) = (-1, None, False)
+select(later) = (1, 'LATE', True)
+select(event) = (1, 's', 0, True)
+select(closed) = (0, None, False)
+TypeError: select: invalid channel (got=int)
+ValueError: select: no channels and no timeout
+ValueError: select: negative timeout
+recv(0): TimeoutError: timeout expired
+recv(0.01): TimeoutError: timeout expired
+recv(poll) = 5
+recv(None) = 6
+ValueError: Recv: negative timeout
+recv(closed): receive from closed channel
+done has Send: False, Recv: False
+wait(poll) = False
+wait(0.01) = False
+len = 1
+wait(signaled) = True
+wait(closed) = True
+signal: send on closed channel
+ValueError: Wait: negative timeout
+relayed = [True, True, True]
+pong closed = True
+`),
+	})
+}
+
+func TestBindCtxs(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/ctxs",
+		want: []byte(`bg.Deadline() = None
+bg.Err() = None
+bg.Cancel(): context can not be canceled from python
+ctxs.Sleep(bg, 1) = None
+ctxs.Wait(ctx): context canceled
+ctx.Err(): context canceled
+ctx canceled twice
+deadline in range: True
+ctxs.Sleep(ctx, 5000): context deadline exceeded
+ctxs.Name(child) = gopher
+ctxs.Wait(child): context canceled
+`),
+	})
+}
+
+func TestBindMutual(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/mutual",
+		want: []byte(`t.Root.Name = root
+t.Root.Parent().Name = root
+n.Parent().Name = root
+t.Walk(n).Name = root
+`),
+	})
+}
+
+func TestBindArrays(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/arrays",
+		want: []byte(`m = arrays.Matrix{[3]float64{1, 0, 0}, [3]float64{0, 1, 0}, [3]float64{0, 0, 1}}
+len(m) = 3, len(m[1]) = 3
+type(m[1]) = Array3Float64
+m[1][2] = 5.0
+m = arrays.Matrix{[3]float64{1, 0, 0}, [3]float64{0, 1, 5}, [3]float64{0, 0, 1}}
+m.Trace() = 5.0
+m[0][1] = 1.0, m[1][1] = 7.0
+caught: array index out of range
+t.Centroid() = arrays.Point{X:1, Y:2}
+[p.X for p in t] = [0.0, 3.0, 0.0]
+t[0] = arrays.Point{X:3, Y:3}
+c.Sum() = 6
+c = arrays.Cube{[2][2]int{[2]int{0, 0}, [2]int{2, 0}}, [2][2]int{[2]int{0, 4}, [2]int{0, 0}}}
 `),
 	})
 }
 
 func TestBindSeqs(t *testing.T) {
-	t.Skip("bind/seq") // FIXME(sbinet)
 	t.Parallel()
 	testPkg(t, pkg{
 		path: "_examples/seqs",
@@ -453,28 +1133,1366 @@ s += [1,2]
 s = seqs.Slice{1, 2}
 s += [10,20]
 s = seqs.Slice{1, 2, 10, 20}
+seqs.Sum([1,2,3]) = 6
+seqs.Sum((1,2,3,4)) = 10
+seqs.Sum([]) = 0
+r = seqs.Range(4)
+r = []int{0, 1, 2, 3}
+seqs.Sum(r) = 6
+seqs.Fib(10) = [0, 1, 1, 2, 3, 5, 8, 13, 21, 34]
+seqs.Fib(0) = []
+h = s.Head(2)
+h[0] = 100
+s = seqs.Slice{100, 2, 10, 20}
+v = s.Values()
+v = [100.0, 2.0, 10.0, 20.0] (list)
+v[0] = -1
+s = seqs.Slice{100, 2, 10, 20}
+h[0] = 1
+s.Dot([1,1,1,1]) = 33.0
+s.Dot(s) = 505.0
+caught: TypeError
+caught: TypeError
+people = seqs.People('alice', 'bob')
+type(people) = SlicePerson, type(people[0]) = Person
+  alice is 20
+  bob is 21
+people[1].Age = 30
+people[1].Age = 30
+[p.Name for p in people] = ['alice', 'bob']
+alice = people[0]; del people
+alice.Name = alice, alice.Age = 20
+handles released: True
+b = seqs.NewBag(3, 4)
+items[0] = 10; pair[1] = 20
+b.Sum() = 35
+del b
+handles released: True
+pair[0] = 5
+items = [10, 4], pair = [5, 20]
 `),
 	})
 }
 
-func TestBindInterfaces(t *testing.T) {
-	t.Skip("not ready")
+func TestBindOptArgs(t *testing.T) {
 	t.Parallel()
 	testPkg(t, pkg{
-		path: "_examples/iface",
-		want: []byte(`
+		path: "_examples/optargs",
+		want: []byte(`F() = defaults
+F(None) = defaults
+F(o) = verbose=true level=2
+F(opts=None) = defaults
+F(opts=o) = verbose=true level=2
+Greet('bob') = hello bob (defaults)
+Greet('bob', o) = hello bob (verbose=true level=2)
+Greet(name='bob', opts=o) = hello bob (verbose=true level=2)
+s.Start() = srv started (defaults)
+s.Start(None) = srv started (defaults)
+s.Start(opts=o) = srv started (verbose=true level=2)
+caught: TypeError
+caught: TypeError
 `),
 	})
 }
 
-func TestBindCgoPackage(t *testing.T) {
-	t.Skip("bind/seq") // FIXME(sbinet)
+func TestBindBlanks(t *testing.T) {
 	t.Parallel()
 	testPkg(t, pkg{
-		path: "_examples/cgo",
-		want: []byte(`cgo.doc: 'Package cgo tests bindings of CGo-based packages.\n'
-cgo.Hi()= 'hi from go\n'
-cgo.Hello(you)= 'hello you from go\n'
+		path: "_examples/blanks",
+		want: []byte(`blanks.Second(1, 2) = 2
+blanks.Ignore('a', 'b') = True
+blanks.Join('a', 1) = 'unnamed'
+c = blanks.Counter()
+c.Add('why', 2) = 2
+c.Add('why', 3, c) = 5
+c.Add('why', n=4)
+caught: Add() takes no keyword arguments
+blanks.Apply(blanks.Pred(lambda n, s: len(s) == 3*n)) = True
+`),
+	})
+}
+
+func TestBindTuples(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/tuples",
+		want: []byte(`p, i = tuples.Find('bob')
+p.Name = 'bob', i = 1
+tuples.Find('carol') = (None, -1)
+p, name = tuples.Get(0)
+p.Age = 42, name = 'alice'
+caught: tuples: index out of range
+tuples.Split('carol:7') = ('carol', 7)
+tuples.Names() = (['alice', 'bob'], 2)
+q, adult, n = tuples.Person(Name='dan', Age=10).Older(8)
+q.Age = 18, adult = True, n = 8
+`),
+	})
+}
+
+func TestBindOutParams(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/outparams",
+		want: []byte(`Add(a, b) = (4.0, 6.0)
+Add(a, b, out=out) = (4.0, 6.0), is out: True
+Add(a, b, out=None) = (4.0, 6.0), is out: False
+a.Scale(10, out=out) = (10.0, 20.0), is out: True
+Normalize(b, out=out) = (0.6, 0.8), is out: True
+Normalize(zero): zero vector, out = (0.6, 0.8)
+TypeError: out: expected outparams.Vec (got=int)
+Sub takes no out argument
+go values without out: 100
+go values with out: 0, wrappers: 1
+`),
+	})
+}
+
+func TestBindConverts(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/converts",
+		want: []byte(`converts.Mix('#ff0000', '#0000ff') = '#7f007f'
+converts.Darker('#804020') = '#402010'
+caught: converts: black has no darker color
+caught: converts: invalid color "red"
+caught: TypeError
+converts.Warm(300.0) = True
+converts.Warm(280.0) = False
+caught: converts: negative temperature -1
+converts.Freezing = 273.15
+converts.Boiling = 373.15
+s.Fill = '#00ff00'
+s.Describe() = 'square filled with #123456'
+caught: converts: invalid color "blue"
+s.Fill = '#123456'
+hasattr(converts, 'Color') = False
+hasattr(converts, 'ColorToPy') = False
+list(converts.Palette()) = ['#ff0000', '#0000ff']
+p[1] = '#00ffff'
+caught: converts: invalid color "cyan"
+m['red'] = '#ff0000'
+m['blue'] = '#0000ff'
+converts.Paint(converts.Painter(lambda c: c[:3] + '0000')) = '#ff0000'
+`),
+	})
+}
+
+func TestBindFactories(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/factories",
+		want: []byte(`factories._new_Point({'X': 1, 'Y': 2}) = Point{X: 1, Y: 2}
+factories._new_Level(3) = 3
+factories._new_Triple([1, 2, 3]) = [1, 2, 3]
+len(factories._new_Path([p, p])) = 2
+factories._new_Ages({'alice': 42})['alice'] = 42
+caught: _new_Point takes a dict of field values
+factories._new_Point
+_new_Point(state) -> Point
+
+Rebuilds a Point from its state, a dict of its field values.
+pickle.loads(pickle.dumps(factories.Point(X=4, Y=5))) = Point{X: 4, Y: 5}
+`),
+	})
+}
+
+func TestBindBuffers(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/buffers",
+		want: []byte(`type(img.Pixels()) = memoryview, len = 6
+img.Sum() = 11
+img.Pixels().tolist() = [5, 0, 0, 1, 2, 3]
+type(img.Row(1)) = SliceByte
+memoryview(img.Row(1)).tobytes() = '\x01\x02\x03'
+str(buffer(img.Row(1))) = '\x01\x02\x03'
+img.Sum() = 17
+pinned while viewed: True
+released with the view: True
+bytearray(d) = bytearray(b'gopy')
+`),
+	})
+}
+
+func TestBindStreams(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/streams",
+		want: []byte(`streams.Copy(dst, src) = 18000
+dst.getvalue() == src.getvalue(): True
+streams.ReadAll(io.BytesIO(b'abc')) = 'abc'
+streams.ReadAll(io.BytesIO()) = ''
+streams.Upper(out, io.BytesIO(b'shout')): 'SHOUT'
+streams.Copy(out, streams.NewReader('from go')): 'from go'
+type(r) = Reader
+streams.ReadAll(r) = 'wrapped'
+lines.chunks = ['one\n', 'two', '\n']
+streams.ReadAll(Failing())
+caught: disk on fire
+streams.WriteString(Failing(), 'x')
+caught: disk full
+streams.ReadAll(Greedy())
+caught: read(512) returned 513 bytes
+streams.ReadAll(io.StringIO(u'text'))
+caught: 'unicode' does not have the buffer interface
+streams.Copy(streams.Broken(), io.BytesIO(b'x'))
+caught: broken writer
+streams.ReadAll('not a file')
+caught: invalid type (got=str, expected a io.Reader)
+Reader(1)
+caught: io.Reader.__init__ takes a file object with a read method
+`),
+	})
+}
+
+func TestBindFields(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/fields",
+		want: []byte(`s.Sum() = 10
+a.X = 10: s.A.X = 1, a.X = 10
+s.A.Y = 20: s.A.Y = 2
+b.X = 30: s.B.X = 30
+s.B.Y = 40: s.B.Y = 40
+s.Sum() = 73
+steps[0] = 5: s.Steps[0] = 0
+marks[0] = 6: s.Marks[0] = 6
+s.B = Point(7, 8): b.X = 7, b.Y = 8
+b.X = 9
+sh.Center.X = 1, sh.Corner[1].Y = 2
+Segment.A.__doc__ = 'A fields.Point\n\nA is read as a copy.\n'
+Segment.B.__doc__ = 'B fields.Point\n\nB is read by reference.\n'
+Segment.Name.__doc__ = 'Name string'
+Shape.Size.__doc__ = 'Size int\n\nSize is the side of the shape.\n'
+Label.Point.__doc__ = 'Point fields.Point\n\nPoint is the position of the text.\n'
+`),
+	})
+}
+
+func TestBindExports(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/exports",
+		want: []byte(`exports.__all__ = ['Box', 'Crate', 'Default', 'Max', 'NewBox', 'Size', 'Small', 'Total']
+star import: ['Box', 'Crate', 'Default', 'Max', 'NewBox', 'Size', 'Small', 'Total']
+SliceInt in dir(exports): True
+Default: 1
+`),
+	})
+}
+
+func TestBindVersioned(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/versioned/v2",
+		want: []byte(`versioned.__name__ = versioned
+versioned.Version = 2
+p.Sum() = 3
+type(p) = Point
+`),
+	})
+}
+
+func TestBindInts(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/ints",
+		want: []byte(`ints.Int64() = 5
+ints.Uint64() = 5
+ints.Uint() = 5
+ints.MaxUint64() == 2**64-1: True
+ints.Pair() = (1, 2)
+c.Signed, c.Unsigned = 7, 8
+ints.Totals()['a'] = 1
+ints.ID(41).Next() = 42
+ints.Uint64() == 5: True
+`),
+	})
+}
+
+func TestBindUnits(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/units",
+		want: []byte(`units.Signal() is units.Signal(): True
+units.Signal() == units.Signal(): True
+units.Unit() is units.Signal(): True
+hash(units.Unit()) == hash(units.Signal()): True
+handles made by 10 Signals: 0
+sorted(m) = ['a', 'b']
+m['a'] is m['b'] is a: True
+units.Seen()[units.Unit()] = True
+units.Take(units.Unit()) = True
+`),
+	})
+}
+
+func TestBindUnexported(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/unexported",
+		want: []byte(`peek = 3
+add = 6
+add: negative increment -1
+reset: peek = 0
+hidden: False
+double = 6
+`),
+	})
+}
+
+func TestBindSideEffects(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/sideeffects",
+		want: []byte(`sideeffects.init()... [CALLED] handlers=lower,trim,upper
+doc(pkg):
+'Package sideeffects registers handlers in its init funcs, and exports\nnothing: it is wrapped and imported for its side-effects only.\n'
+pkg.__gopy_package__ = github.com/go-python/gopy/_examples/sideeffects
+hasattr(pkg, '__all__') = False
+pkg._gopy_handle_count() = 0
+sideeffects is pkg: True
+`),
+	})
+}
+
+func TestBindOverloads(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/overloads",
+		want: []byte(`pkg.New(1, 2) = (1.0, 2.0)
+pkg.New('3,4') = (3.0, 4.0)
+pkg.New('3'): caught: overloads: invalid point "3"
+pkg.Area(2, 3) = 6.0
+pkg.Area(1) = 3.14159
+pkg.CircleArea(1) = 3.14159
+pkg.Describe(True) = bool true
+pkg.Describe(42) = int 42
+pkg.Describe('hi') = string "hi"
+pkg.Describe(u'hi') = string "hi"
+pkg.Describe(p) = point {3 4}
+pkg.Describe(None) = no point
+pkg.Describe([1, 2]) = ints [1 2]
+pkg.Sum() = 0
+pkg.Sum(1, 2, 3) = 6
+pkg.Sum(1, 2.5) = 3.5
+pkg.Describe(1.5,): caught: Describe(float) matches none of:
+	func DescribeBool(b bool) string
+	func DescribeInt(n int) string
+	func DescribeString(s string) string
+	func DescribePoint(p *overloads.Point) string
+	func DescribeInts(xs []int) string
+pkg.Describe(1, 2, 3): caught: Describe(int, int, int) matches none of:
+	func DescribeBool(b bool) string
+	func DescribeInt(n int) string
+	func DescribeString(s string) string
+	func DescribePoint(p *overloads.Point) string
+	func DescribeInts(xs []int) string
+'Describe' in pkg.__all__: True
+'DescribeInt' in pkg.__all__: True
+pkg.Area.__doc__:
+Area(*args)
+
+Calls the first of the following funcs its arguments match:
+
+func Area(w float64, h float64) float64
+func CircleArea(r float64) float64
+`),
+	})
+}
+
+func TestBindIters(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/iters",
+		want: []byte(`for i in iters.Count(3):
+  0
+  1
+  2
+list(seq) = [0, 1, 2, 3]
+list(seq) = [0, 1, 2, 3]
+sum(iters.Count(5)) = 10
+list(iters.Count(0)) = []
+for w, n in iters.Pairs(['go', 'python']):
+  go 2
+  python 6
+next(it) = g
+next(it) = o
+iters.Stopped() = 0
+iters.Stopped() = 1
+iters.Stopped() = 2
+list(iters.Letters('ok')) = ['o', 'k']
+iters.Stopped() = 2
+next(it) = 0
+caught: StopIteration
+`),
+	})
+}
+
+func TestBindBases(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/bases",
+		want: []byte(`bases.Ints.__bases__ = (<class '_abcoll.Sequence'>,)
+s = bases.Ints{0, 1, 4, 9}
+isinstance(s, collections.Sequence) = True
+s.index(9) = 3
+s.count(4) = 1
+4 in s = True, 5 in s = False
+list(reversed(s)) = [9, 4, 1, 0]
+isinstance(p, mixins.Describer) = True
+p.describe() = Point at (1, 2)
+p.label = origin
+r() is p: True
+r() = None
+p.describe() = Point3 at (1, 2), p.Z = 3
+isinstance(p, mixins.Describer) = True
+handles after releasing points: +0
+`),
+	})
+}
+
+func TestBindDurations(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/durations",
+		want: []byte(`durations.Timeout() = 1.5
+durations.Double(1.25) = 2.5
+durations.Double(2) = 4.0
+durations.Double(-0.5) = -1.0
+durations.Double(timedelta(minutes=1, microseconds=3)) = 120.000006
+durations.Parse('1h30m') = 5400.0
+durations.Parse('1ns') = 1e-09
+durations.String(4.35) = '4.35s'
+durations.String(1e-9) = '1ns'
+durations.String(timedelta(days=-1)) = '-24h0m0s'
+caught: time: invalid duration "soon"
+caught TypeError: invalid type (got=str, expected a float or a datetime.timedelta)
+caught OverflowError: duration out of range
+caught OverflowError: duration out of range
+caught ValueError: invalid value nan (expected a duration)
+caught OverflowError: duration out of range
+b.Base = 0.1
+b.Max = 1.0
+b.Delay(3) = 0.8
+b.Steps(5) = [0.1, 0.2, 0.4, 0.8, 1.0]
+b.Delay(10) = 2.0
+caught: invalid type for 'Max' attribute
 `),
 	})
 }
+
+func TestBindDurationsTimedelta(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/durations",
+		args: []string{"-durations=timedelta"},
+		want: []byte(`durations.Timeout() = datetime.timedelta(0, 1, 500000)
+durations.Double(1.25) = datetime.timedelta(0, 2, 500000)
+durations.Double(2) = datetime.timedelta(0, 4)
+durations.Double(-0.5) = datetime.timedelta(-1, 86399)
+durations.Double(timedelta(minutes=1, microseconds=3)) = datetime.timedelta(0, 120, 6)
+durations.Parse('1h30m') = datetime.timedelta(0, 5400)
+durations.Parse('1ns') = datetime.timedelta(0)
+durations.String(4.35) = '4.35s'
+durations.String(1e-9) = '1ns'
+durations.String(timedelta(days=-1)) = '-24h0m0s'
+caught: time: invalid duration "soon"
+caught TypeError: invalid type (got=str, expected a float or a datetime.timedelta)
+caught OverflowError: duration out of range
+caught OverflowError: duration out of range
+caught ValueError: invalid value nan (expected a duration)
+caught OverflowError: duration out of range
+b.Base = datetime.timedelta(0, 0, 100000)
+b.Max = datetime.timedelta(0, 1)
+b.Delay(3) = datetime.timedelta(0, 0, 800000)
+b.Steps(5) = [datetime.timedelta(0, 0, 100000), datetime.timedelta(0, 0, 200000), datetime.timedelta(0, 0, 400000), datetime.timedelta(0, 0, 800000), datetime.timedelta(0, 1)]
+b.Delay(10) = datetime.timedelta(0, 2)
+caught: invalid type for 'Max' attribute
+`),
+	})
+}
+
+func TestBindInternals(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/internals",
+		want: []byte(`type(s) = Store
+s.Get('a') = ('1', True)
+s.Get('z') = ('', False)
+internals.Len(s) = 2
+internals.Len(None) = -1
+after merge: internals.Len(s) = 3
+caught RuntimeError: store: merge of a nil store
+snap = snapshot of 3 item(s)
+snap.Len() = 3
+c.Count() = 3
+cache.Store().Get('c') = ('3', True)
+str(s) = store.Store{Name:"main", items:map[string]string{"a":"1", "b":"2", "c":"3"}}
+format(snap, 'T') = *store.Snapshot
+hasattr(s, 'Name') = False
+caught TypeError: invalid type (got=int, expected a store.Store)
+handles released: True
+caught TypeError: store.Store values are only made by go: the type is declared in an internal package
+`),
+	})
+}
+
+func TestBindNets(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/nets",
+		want: []byte(`nets.Loopback() = '::1'
+nets.Same('2001:db8::1') = '2001:db8::1'
+nets.Same('2001:0db8:0000::0001') = '2001:db8::1'
+nets.Is4('192.168.0.1') = True
+nets.Same(None) = None
+caught ValueError: invalid IP address "10.0.0"
+caught TypeError: invalid type (got=int, expected a str)
+nets.Endpoint() = 'https://example.com/api?q=go+python&page=2'
+nets.Echo(u) == u: True
+nets.Query(u, 'q') = 'go python'
+nets.Query('http://h/p?a=1&b=x%20y', 'b') = 'x y'
+caught ValueError: parse "http://h/%zz": invalid URL escape "%zz"
+s.Addr = '10.0.0.1'
+s.Base = None
+s.Base, s.Addr = 'http://localhost:8080/', 'fe80::1'
+list(s.Peers) = ['::1']
+`),
+	})
+}
+
+func TestBindNetsOpaque(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/nets",
+		args: []string{"-nettypes=opaque"},
+		want: []byte(`type(nets.Loopback()) = IP
+nets.Is4(ip) = False
+type(nets.Endpoint()) = URL
+type(s.Addr) = IP
+`),
+	})
+}
+
+func TestBindEmbeds(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/embeds",
+		want: []byte(`rw.Read() = 'line of in'
+rw.Write('x') = 'wrote "x" to out'
+hasattr(rw, 'Close') = False
+f.Read() = 'line of f.txt'
+f.Close() = 'closed file f.txt'
+t.Read() = 'line of b'
+t.Write('x') = 'wrote "x" to a'
+t.Close() = 'closed a'
+l.Name() = 'log'
+tag.Name() = 'tag'
+embeds.Logger().Name(): nil embedded Namer
+embeds.Find('n').Name() = 'n'
+embeds.Find('') = None
+embeds.Logger().Namer = None
+embeds.Namer().Name(): nil Namer
+s.Read(bytearray(2)) = 2
+s.Len() = 1
+str(embeds.NewLabel('w')) = 'writer to w'
+str(embeds.Label()) = 'embeds.Label{Stringer:fmt.Stringer(nil)}'
+`),
+	})
+}
+
+func TestBindStatics(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/statics",
+		want: []byte(`statics.Point.Parse('1,2') = statics.Point{X:1, Y:2}
+statics.Point.Origin() = statics.Point{X:0, Y:0}
+p.Origin() = statics.Point{X:0, Y:0}
+statics.Dist(p, o) = 3
+hasattr(statics, 'Parse') = False
+hasattr(statics, 'Origin') = False
+statics.Unit() = statics.Point{X:1, Y:1}
+doc(statics.Unit) = 'func Unit() statics.Point\n\nUnit returns the point at 1,1. It is a func of the module, not a\nconstructor of Point.\n'
+hasattr(statics.Point, 'zero') = False
+doc(statics.Point.Parse) = 'func Parse(s string) (*statics.Point, error)\n\nParse parses a point written as "x,y".\n'
+caught: invalid point "nope"
+`),
+	})
+}
+
+func TestBindAliases(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/aliases",
+		want: []byte(`aliases.Pt is aliases.Point: True
+type(p): Point
+aliases.Norm1(p): 7
+aliases.Norm1(aliases.Point(X=1, Y=2)): 3
+q: 6 8
+isinstance(q, aliases.Pt): True
+points: [(0, 0), (1, 1), (2, 2)]
+isinstance(b, aliases.Buffer): True
+b.Len(): 5
+`),
+	})
+}
+
+func TestBindCommaOk(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/commaok",
+		want: []byte(`commaok.Lookup('red') = ('#ff0000', True)
+commaok.Lookup('blue') = ('', False)
+commaok.Find('green') = '#00ff00'
+commaok.Find('blue') = None
+commaok.FindPoint('unit') = (1, 1)
+commaok.FindPoint('nowhere') = None
+t.Get('a') = (2, True)
+t.Get('b') = (0, False)
+t.Count('a') = 2
+t.Count('b') = None
+`),
+	})
+}
+
+func TestBindFormats(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/formats",
+		want: []byte(`format(p) = {1 2}
+format(p, '+v') = {X:1 Y:2}
+format(p, '#v') = formats.Point{X:1, Y:2}
+'{:v}'.format(p) = {1 2}
+format(t, '6.2f') = [ 21.50]
+format(t, 'e') = 2.150000e+01
+format(l, 'v') = info
+format(l, 'd') = 1
+format(l, '03d') = 001
+format(p, 's'): ValueError: invalid format spec "s" for formats.Point
+format(p, '5'): ValueError: invalid format spec "5"
+format(p, 'vv'): ValueError: invalid format spec "vv"
+format(p, '%v'): ValueError: invalid format spec "%v"
+doc(p.__format__) = '__format__(spec) -> str\n\nFormats the value with the go fmt verb spec, e.g. "v", "+v" or "08.3f".\nAn empty spec formats the value with %v.'
+`),
+	})
+}
+
+func TestBindLinkedList(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/linkedlist",
+		want: []byte(`values(head) = [3, 2, 1]
+linkedlist.Len(head) = 3
+head.Sum() = 6
+linkedlist.Len(None) = 0
+values(reversed) = [1, 2, 3]
+values(n1) = [10, 20]
+n2.Next is None: True
+files = ['etc/hosts', 'etc/passwd']
+`),
+	})
+}
+
+func TestBindImplements(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/implements",
+		want: []byte(`u: Upper(5 bytes left)
+implements.ReadAll(u): HELLO
+u: Upper(0 bytes left)
+`),
+	})
+}
+
+func TestBindStrLists(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/strlists",
+		want: []byte(`strlists.Split('a,b,c', ','): ['a', 'b', 'c'] list str
+strlists.Split('', ','): ['']
+strlists.Join(['x', 'y'], '-'): x-y
+strlists.Join(('x', u'y'), '+'): x+y
+strlists.Join([], '-'): ''
+strlists.Upper(['go', 'py']): ['GO', 'PY']
+strlists.Fields(' a  b '): ['a', 'b']
+caught: invalid item 1 (got=int, expected a str)
+caught: invalid type (got=str, expected a list or tuple of str)
+caught: strlists: no fields
+d.Sorted(): ['blue', 'green', 'red']
+len(d.Tags): 3
+strlists.Join(d.Tags, ','): red,blue,green
+`),
+	})
+}
+
+func TestBindLocks(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/locks",
+		want: []byte(`c.Inc(): 2
+str(c): True
+format(c, 'T'): locks.Counter
+r.Name: reg
+r.Load('a'): 1
+r.Load('c'): ''
+r.Lookups(): 2
+r.Stores(): 2
+hasattr(r, 'Hits'): False
+hasattr(r, 'Count'): False
+locks.Total(cs): 3
+caught: items of SliceCounter hold a sync.Mutex: they cannot be assigned
+caught: SliceCounter.__init__ takes no argument: its items hold a sync.Mutex
+hasattr(locks, 'Value'): False
+hasattr(locks, 'GetDefault'): False
+`),
+	})
+}
+
+func TestBindFutures(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/futures",
+		args: []string{"-async"},
+		want: []byte(`futures.Sleep(1): 1
+hasattr(futures, 'Add_async'): False
+results: [200, 200, 200, 200, 200]
+concurrent: True
+done: True
+c.Fetch_async('k'): svc:k
+hasattr(c, 'Close_async'): False
+caught: fetch: empty key
+callback: ['svc:cb']
+doc: Sleep_async runs Sleep on a thread, returning a future of its result.
+`),
+	})
+}
+
+func TestBindFuturesPy3(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/futures",
+		args: []string{"-async"},
+		py3:  true,
+		want: []byte(`futures.Sleep(1): 1
+hasattr(futures, 'Add_async'): False
+results: [200, 200, 200, 200, 200]
+concurrent: True
+done: True
+c.Fetch_async('k'): svc:k
+hasattr(c, 'Close_async'): False
+caught: fetch: empty key
+callback: ['svc:cb']
+doc: Sleep_async runs Sleep on a thread, returning a future of its result.
+asyncio future: True
+await Sleep_async(300): 300
+loop kept running: True
+gather: ['svc:a', 'svc:b']
+caught: fetch: empty key
+`),
+	})
+}
+
+func TestBindEnums(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/enums",
+		want: []byte(`enums.Version: v1
+Status.StatusActive.name: StatusActive
+Status.StatusActive.value: active
+enums.StatusClosed is Status.StatusClosed: True
+Status.__members__: ['StatusActive', 'StatusClosed', 'StatusDefault', 'StatusSuspended']
+aliases: True
+Status('suspended').name: StatusSuspended
+Status('suspended') == StatusSuspended: True
+Status('suspended') != StatusClosed: True
+dict key: x
+caught: 'deleted' is not a valid Status
+Parse('Deleted'): deleted None
+Parse('Active') == StatusActive: True
+IsOpen(Status('active')): True
+a.Status.name: StatusClosed
+`),
+	})
+}
+
+func TestBindEnumsPy3(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/enums",
+		py3:  true,
+		want: []byte(`enums.Version: v1
+Status.StatusActive.name: StatusActive
+Status.StatusActive.value: active
+enums.StatusClosed is Status.StatusClosed: True
+Status.__members__: ['StatusActive', 'StatusClosed', 'StatusDefault', 'StatusSuspended']
+aliases: True
+Status('suspended').name: StatusSuspended
+Status('suspended') == StatusSuspended: True
+Status('suspended') != StatusClosed: True
+dict key: x
+caught: 'deleted' is not a valid Status
+Parse('Deleted'): deleted None
+Parse('Active') == StatusActive: True
+IsOpen(Status('active')): True
+a.Status.name: StatusClosed
+issubclass(Status, enum.Enum): True
+isinstance(StatusActive, enum.Enum): True
+isinstance(Status('closed'), enum.Enum): True
+list(Status): ['StatusActive', 'StatusSuspended', 'StatusClosed']
+Parse('Active') is StatusActive: True
+isinstance(a.Status, enum.Enum): True
+isinstance(Parse('Deleted'), enum.Enum): False
+`),
+	})
+}
+
+func TestBindSubinterpsPy3(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/subinterps",
+		py3:  true,
+		want: []byte(`reimported: True
+same types: True
+same enum: True
+subinterps.Counter = 1
+subinterps.Incr() = 2
+first.Counter = 2
+sub-interpreter: Counter = 2, Incr() = 3
+run_in_subinterp(code) = 0
+subinterps.Counter = 3
+`),
+	})
+
+	// the module declares a multi-phase init, refusing isolated interpreters.
+	const path = "_examples/subinterps"
+	workdir, err := ioutil.TempDir("", "gopy-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workdir)
+
+	cmd := exec.Command("gopy", "gen", "-lang=py3", "-py23", "-output="+workdir, "./"+path)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("[%s]: error running gopy-gen -lang=py3 -py23: %v\n%s\n", path, err, out)
+	}
+	src, err := ioutil.ReadFile(filepath.Join(workdir, "subinterps.c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"PyModuleDef_Init(&cpy_subinterps_module)",
+		"{Py_mod_exec, (void*)cpy_subinterps_module_exec}",
+		"{Py_mod_multiple_interpreters, Py_MOD_MULTIPLE_INTERPRETERS_NOT_SUPPORTED}",
+	} {
+		if !bytes.Contains(src, []byte(want)) {
+			t.Errorf("[%s]: missing %q\n", path, want)
+		}
+	}
+}
+
+func TestBindVariadics(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/variadics",
+		want: []byte(`Max(1, 5, 3): 5
+Max(*[4, 2]): 4
+Max([7, 8]): 8
+Max((-1, -2)): -1
+Max(-3, -4): -3
+Max(): 0
+Count(): 0
+Count(*range(10)): 10
+Mean(1, 2.5): 1.75
+Mean(): 0.0
+Any(False, True): True
+Any(): False
+Join('-', 'a', 'b'): a-b
+Join('-'): ''
+Bytes(1, 2, 255): 258
+a.Add(1, 2): 3
+a.Add(): 3
+a.Add(*[3, 4]): 10
+caught: an integer is required
+caught: an integer is required
+caught: integer argument expected, got float
+l.Addf('no args'): 1
+l.Addf('%s=%d %v', 'x', 1, True): 2
+l.Addf('%v %v', 1.5, None): 3
+l.Lines: ['no args', 'x=1 true', '1.5 <nil>']
+Sprintf('%d-%d', 1, 2): 1-2
+l.Points(): 0 points, sum 0
+l.Points(Point(X=1, Y=2), Point(X=3)): 2 points, sum 6
+Sprintf('%v', [1, 2]): [1 2]
+Sprintf('%v %v', (1,), 'a'): [1] a
+`),
+	})
+}
+
+func TestBindInterfaces(t *testing.T) {
+	t.Skip("not ready")
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/iface",
+		want: []byte(`
+`),
+	})
+}
+
+func TestBindCgoPackage(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/cgo",
+		want: []byte(`cgo.doc: 'Package cgo tests bindings of CGo-based packages.\n'
+cgo.Hi()= 'hi from go\n'
+cgo.Hello(you)= 'hello you from go\n'
+cgo.ScaledHypot(3, 4)= 10.0
+p.Hypot()= 5.0
+p.Scale(2); p.Hypot()= 10.0
+cgo.Point().Hypot()= 0.0
+hasattr(cgo, 'Norm')= False
+`),
+	})
+}
+
+func TestInspect(t *testing.T) {
+	t.Parallel()
+	const path = "_examples/locks"
+
+	cmd := exec.Command("gopy", "inspect", "./"+path)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("[%s]: error running gopy-inspect: %v\n", path, err)
+	}
+	want := `bound   type     locks.Counter (holds a sync.Mutex: wrapped by pointer only, with no value copies)
+bound   method   locks.Counter.Inc
+bound   method   locks.Counter.Value
+bound   function locks.Counters
+skipped variable locks.Default (copies lock value: locks.Registry contains sync.RWMutex)
+bound   function locks.NewRegistry
+bound   type     locks.Registry (holds a sync.RWMutex: wrapped by pointer only, with no value copies)
+bound   method   locks.Registry.Load
+bound   method   locks.Registry.Lock
+bound   method   locks.Registry.Lookups
+bound   method   locks.Registry.RLock
+bound   method   locks.Registry.RLocker
+bound   method   locks.Registry.RUnlock
+bound   method   locks.Registry.Store
+bound   method   locks.Registry.Stores
+bound   method   locks.Registry.TryLock
+bound   method   locks.Registry.TryRLock
+bound   method   locks.Registry.Unlock
+skipped field    locks.Registry.RWMutex (copies lock value: sync.RWMutex)
+bound   field    locks.Registry.Name
+skipped field    locks.Registry.Hits  (copies lock value: locks.Counter contains sync.Mutex)
+skipped field    locks.Registry.Count (copies lock value: atomic.Int64)
+bound   function locks.Total
+skipped function locks.Value (parameter c: copies lock value: locks.Counter contains sync.Mutex)
+19 bound, 5 skipped
+`
+	if string(out) != want {
+		t.Fatalf("[%s]: invalid report:\ngot:\n%s\nwant:\n%s\n", path, out, want)
+	}
+
+	cmd = exec.Command("gopy", "inspect", "-json", "./"+path)
+	cmd.Stderr = os.Stderr
+	out, err = cmd.Output()
+	if err != nil {
+		t.Fatalf("[%s]: error running gopy-inspect -json: %v\n", path, err)
+	}
+	var syms []struct {
+		Name   string `json:"name"`
+		Kind   string `json:"kind"`
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+		Pos    string `json:"pos"`
+	}
+	err = json.Unmarshal(out, &syms)
+	if err != nil {
+		t.Fatalf("[%s]: invalid JSON report: %v\n%s\n", path, err, out)
+	}
+	if len(syms) != 24 {
+		t.Fatalf("[%s]: got %d symbols, want 24\n", path, len(syms))
+	}
+	sym := syms[len(syms)-1]
+	if sym.Name != "locks.Value" || sym.Kind != "function" || sym.Status != "skipped" ||
+		sym.Reason != "parameter c: copies lock value: locks.Counter contains sync.Mutex" ||
+		!strings.Contains(sym.Pos, "locks.go:") {
+		t.Fatalf("[%s]: invalid JSON entry: %+v\n", path, sym)
+	}
+}
+
+func TestGenCheck(t *testing.T) {
+	t.Parallel()
+	const path = "_examples/simple"
+
+	workdir, err := ioutil.TempDir("", "gopy-")
+	if err != nil {
+		t.Fatalf("[%s]: could not create workdir: %v\n", path, err)
+	}
+	defer os.RemoveAll(workdir)
+
+	gen := func(args ...string) error {
+		args = append([]string{"gen", "-output=" + workdir}, args...)
+		cmd := exec.Command("gopy", append(args, "./"+path)...)
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+
+	for _, lang := range []string{"go", "py2"} {
+		err = gen("-lang=" + lang)
+		if err != nil {
+			t.Fatalf("[%s]: error running gopy-gen -lang=%s: %v\n", path, lang, err)
+		}
+		err = gen("-check", "-lang="+lang)
+		if err != nil {
+			t.Fatalf("[%s]: bindings for -lang=%s out of date after gopy-gen: %v\n", path, lang, err)
+		}
+	}
+
+	fname := filepath.Join(workdir, "simple.c")
+	stale := []byte("/* stale */\n")
+	err = ioutil.WriteFile(fname, stale, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = gen("-check", "-lang=py2")
+	if err == nil {
+		t.Fatalf("[%s]: expected stale bindings to be reported\n", path)
+	}
+	got, err := ioutil.ReadFile(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, stale) {
+		t.Fatalf("[%s]: gopy-gen -check overwrote %s\n", path, fname)
+	}
+}
+
+func TestGenReproducible(t *testing.T) {
+	t.Parallel()
+
+	// bindings generated twice from the same package are byte-identical.
+	for _, path := range []string{
+		"_examples/structs",
+		"_examples/maps",
+		"_examples/enums",
+		"_examples/overloads",
+		"_examples/errs",
+	} {
+		workdir, err := ioutil.TempDir("", "gopy-")
+		if err != nil {
+			t.Fatalf("[%s]: could not create workdir: %v\n", path, err)
+		}
+		defer os.RemoveAll(workdir)
+
+		// the command line, recorded by the bindings, is the same.
+		var outs [2]map[string][]byte
+		for i := range outs {
+			for _, lang := range []string{"go", "py2"} {
+				cmd := exec.Command("gopy", "gen", "-lang="+lang, "-output="+workdir, "./"+path)
+				out, err := cmd.CombinedOutput()
+				if err != nil {
+					t.Fatalf("[%s]: error running gopy-gen -lang=%s: %v\n%s\n", path, lang, err, out)
+				}
+			}
+
+			files, err := ioutil.ReadDir(workdir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			outs[i] = make(map[string][]byte, len(files))
+			for _, fi := range files {
+				if fi.IsDir() {
+					continue
+				}
+				src, err := ioutil.ReadFile(filepath.Join(workdir, fi.Name()))
+				if err != nil {
+					t.Fatal(err)
+				}
+				outs[i][fi.Name()] = src
+			}
+		}
+
+		if len(outs[0]) != len(outs[1]) {
+			t.Fatalf("[%s]: generated %d files, then %d\n", path, len(outs[0]), len(outs[1]))
+		}
+		for name, src := range outs[0] {
+			if !bytes.Equal(src, outs[1][name]) {
+				t.Errorf("[%s]: %s differs between two generations\n", path, name)
+			}
+		}
+	}
+}
+
+// python3 returns the path to a python executable, linking to python-3,
+// in a directory of its own under workdir. It skips the test if python-3 is
+// not available.
+func python3(t *testing.T, workdir string) string {
+	t.Helper()
+	if _, err := exec.Command("pkg-config", "--exists", "python3").CombinedOutput(); err != nil {
+		t.Skip("python-3 pkg-config file not available")
+	}
+	exe, err := exec.Command("python3", "-c", "import sys; print(sys.executable)").Output()
+	if err != nil {
+		t.Skip("python3 not available")
+	}
+	dir := filepath.Join(workdir, "python3")
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	python := filepath.Join(dir, "python")
+	err = os.Symlink(strings.TrimSpace(string(exe)), python)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return python
+}
+
+func TestBindPy23(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/strs",
+		args: []string{"-py23"},
+		want: []byte(`s = strs.Str('string')
+s = string
+len(s) = 6
+s[0] = 's'
+s[-1] = 'g'
+s[1:4] = 'tri'
+str(s) = 'string'
+s.Upper() = 'STRING'
+s = strs.Hello('world')
+s = hello world
+len(s) = 11
+s = strs.Str('h\xc3\xa9llo')
+len(s) = 5
+s[1] = '\xc3\xa9'
+s[1:] = '\xc3\xa9llo'
+caught: string index out of range
+`),
+	})
+}
+
+func TestGenPy23(t *testing.T) {
+	t.Parallel()
+
+	cc, err := exec.LookPath("gcc")
+	if err != nil {
+		t.Skip("gcc not available")
+	}
+
+	// the sources compile against the headers of each python found.
+	var configs []string
+	for _, cfg := range []string{"python2-config", "python3-config"} {
+		if _, err := exec.LookPath(cfg); err == nil {
+			configs = append(configs, cfg)
+		}
+	}
+	if len(configs) == 0 {
+		t.Skip("no python-config available")
+	}
+
+	for _, path := range []string{
+		"_examples/hi",
+		"_examples/named",
+		"_examples/maps",
+		"_examples/enums",
+		"_examples/iface",
+		"_examples/buffers",
+		"_examples/vars",
+		"_examples/subinterps",
+	} {
+		workdir, err := ioutil.TempDir("", "gopy-")
+		if err != nil {
+			t.Fatalf("[%s]: could not create workdir: %v\n", path, err)
+		}
+		defer os.RemoveAll(workdir)
+
+		for _, lang := range []string{"go", "py3"} {
+			cmd := exec.Command("gopy", "gen", "-lang="+lang, "-py23", "-output="+workdir, "./"+path)
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("[%s]: error running gopy-gen -lang=%s -py23: %v\n%s\n", path, lang, err, out)
+			}
+		}
+
+		name := filepath.Base(path)
+		for _, cfg := range configs {
+			includes, err := exec.Command(cfg, "--includes").Output()
+			if err != nil {
+				t.Fatalf("[%s]: error running %s: %v\n", path, cfg, err)
+			}
+			args := []string{"-fsyntax-only", "-Werror=implicit-function-declaration", "-I" + workdir}
+			args = append(args, strings.Fields(string(includes))...)
+			cmd := exec.Command(cc, append(args, name+".c")...)
+			cmd.Dir = workdir
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Errorf("[%s]: sources do not compile against the %s headers: %v\n%s\n", path, cfg, err, out)
+			}
+		}
+	}
+}
+
+func TestBindBuildTags(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/buildtags",
+		args: []string{"-tags=gopy_extra"},
+		want: []byte(`buildtags.Common() = common
+buildtags.Extra() = extra
+hasattr(buildtags, 'Plain') = False
+hasattr(buildtags, 'Windows') = False
+`),
+	})
+
+	// the files of the target platform are bound.
+	const path = "_examples/buildtags"
+	workdir, err := ioutil.TempDir("", "gopy-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workdir)
+
+	cmd := exec.Command("gopy", "gen", "-lang=py2", "-goos=windows", "-output="+workdir, "./"+path)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("[%s]: error running gopy-gen -goos=windows: %v\n%s\n", path, err, out)
+	}
+	src, err := ioutil.ReadFile(filepath.Join(workdir, "buildtags.c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Common", "Plain", "Windows"} {
+		if !bytes.Contains(src, []byte(`"`+name+`"`)) {
+			t.Errorf("[%s]: %s not bound for windows\n", path, name)
+		}
+	}
+	if bytes.Contains(src, []byte(`"Extra"`)) {
+		t.Errorf("[%s]: Extra bound without the gopy_extra build tag\n", path)
+	}
+}
+
+func TestBindCgoFlags(t *testing.T) {
+	t.Parallel()
+	testPkg(t, pkg{
+		path: "_examples/simple",
+		args: []string{"-cflags=-DGOPY_TEST=1", "-ldflags=-lm"},
+		want: []byte(`doc(pkg):
+'simple is a simple package.\n'
+pkg.Func()...
+fct = pkg.Func...
+fct()...
+pkg.Add(1,2)= 3
+buf.write(b'gopy') = 4, len(buf) = 4
+pkg.__gopy_package__ = github.com/go-python/gopy/_examples/simple
+pkg.__gopy_cmd__ = gopy bind ... ./_examples/simple
+pkg.__gopy_version__ is set: True
+pkg.__gopy_build_time__ is a date: True
+`),
+	})
+
+	// the flags reach the linker.
+	workdir, err := ioutil.TempDir("", "gopy-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workdir)
+
+	cmd := exec.Command("gopy", "bind", "-output="+workdir, "-ldflags=-lgopy-no-such-lib", "./_examples/simple")
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("expected gopy-bind to fail to link with a missing library:\n%s\n", out)
+	}
+	if !bytes.Contains(out, []byte("gopy-no-such-lib")) {
+		t.Fatalf("expected the missing library to be reported:\n%s\n", out)
+	}
+}
+
+func TestBindWork(t *testing.T) {
+	t.Parallel()
+	workdir, err := ioutil.TempDir("", "gopy-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(workdir)
+
+	cmd := exec.Command("gopy", "bind", "-work", "-output="+workdir, "./_examples/hi")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("error running gopy-bind -work: %v\n%s\n", err, out)
+	}
+
+	// the work directory is reported first, then the files generated into it.
+	var paths []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if i := strings.Index(line, "work: "); i >= 0 {
+			paths = append(paths, line[i+len("work: "):])
+		}
+	}
+	if len(paths) == 0 {
+		t.Fatalf("expected the work directory to be reported:\n%s\n", out)
+	}
+	work := paths[0]
+	defer os.RemoveAll(work)
+
+	for _, name := range []string{"hi.c", "hi.go", "cgopy_seq_cpy.h"} {
+		fname := filepath.Join(work, name)
+		found := false
+		for _, path := range paths[1:] {
+			found = found || path == fname
+		}
+		if !found {
+			t.Errorf("%s not reported:\n%s\n", fname, out)
+		}
+		if _, err := os.Stat(fname); err != nil {
+			t.Errorf("%s not kept: %v\n", fname, err)
+		}
+	}
+}
+
+func TestCgoEnv(t *testing.T) {
+	for _, test := range []struct {
+		env     []string
+		cflags  string
+		ldflags string
+		want    []string
+	}{
+		{
+			env:  []string{"HOME=/home/gopy"},
+			want: []string{"HOME=/home/gopy"},
+		},
+		{
+			env:     []string{"HOME=/home/gopy"},
+			cflags:  "-I/opt/include",
+			ldflags: "-undefined dynamic_lookup",
+			want: []string{
+				"HOME=/home/gopy",
+				"CGO_CFLAGS=-g -O2 -I/opt/include",
+				"CGO_LDFLAGS=-g -O2 -undefined dynamic_lookup",
+			},
+		},
+		{
+			env:     []string{"CGO_CFLAGS=-O3", "CGO_LDFLAGS=", "HOME=/home/gopy"},
+			cflags:  "-I/opt/include",
+			ldflags: "-L/opt/lib",
+			want:    []string{"CGO_CFLAGS=-O3 -I/opt/include", "CGO_LDFLAGS=-L/opt/lib", "HOME=/home/gopy"},
+		},
+	} {
+		env := append([]string(nil), test.env...)
+		got := cgoEnv(env, test.cflags, test.ldflags)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("cgoEnv(%q, %q, %q)=%q, want %q", test.env, test.cflags, test.ldflags, got, test.want)
+		}
+		if !reflect.DeepEqual(env, test.env) {
+			t.Errorf("cgoEnv(%q, %q, %q) modified its input: %q", test.env, test.cflags, test.ldflags, env)
+		}
+	}
+}
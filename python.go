@@ -20,7 +20,8 @@ func getPythonVersion() (string, error) {
 		)
 	}
 
-	out, err := exec.Command(py, "--version").Output()
+	// python-2 prints its version on stderr.
+	out, err := exec.Command(py, "--version").CombinedOutput()
 	if err != nil {
 		return "", fmt.Errorf(
 			"gopy: error retrieving python version (err: %v)",